@@ -0,0 +1,310 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/awslabs/ferret-scan/v2/internal/scanresult"
+)
+
+// Exit codes of `ferret-scan diff`. They follow the scan's own convention —
+// 1 means "there is something to look at", 2 means the tool could not do its
+// job — so a CI step gating on a scan can gate on a diff unchanged.
+const (
+	diffExitClean   = 0
+	diffExitChanged = 1
+	diffExitError   = 2
+)
+
+// Values of --fail-on.
+const (
+	failOnNew  = "new"
+	failOnAny  = "any"
+	failOnNone = "none"
+)
+
+const diffUsage = `Usage: ferret-scan diff [options] <base-result> <head-result>
+
+Compares two saved scan results (JSON or SARIF, in any combination) and
+reports findings that are new, fixed, unchanged, or whose confidence band
+moved. Findings are matched by suppression identity, so a finding is
+unchanged exactly when a suppression rule for it would still apply.
+
+Options:
+`
+
+// diffReport is the JSON shape of a comparison. The lists come straight from
+// scanresult.Comparison, filtered by --confidence.
+type diffReport struct {
+	Base        string                  `json:"base"`
+	Head        string                  `json:"head"`
+	Summary     diffSummary             `json:"summary"`
+	New         []scanresult.Finding    `json:"new"`
+	Fixed       []scanresult.Finding    `json:"fixed"`
+	BandChanged []scanresult.BandChange `json:"band_changed"`
+	Unchanged   []scanresult.Finding    `json:"unchanged"`
+	Warnings    []string                `json:"warnings,omitempty"`
+}
+
+type diffSummary struct {
+	New         int `json:"new"`
+	Fixed       int `json:"fixed"`
+	BandChanged int `json:"band_changed"`
+	BandRaised  int `json:"band_raised"`
+	Unchanged   int `json:"unchanged"`
+}
+
+// runDiff implements `ferret-scan diff`.
+func runDiff(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	format := fs.String("format", "text", "Output format: text, json, markdown")
+	failOn := fs.String("fail-on", failOnNew, "Exit 1 when the diff has: new (new findings or a raised confidence band), any (any difference), none (never)")
+	confidence := fs.String("confidence", "", "Confidence levels to compare: high, medium, low, or combinations like 'high,medium' (default: all)")
+	fs.Usage = func() {
+		fmt.Fprint(stderr, diffUsage)
+		fs.PrintDefaults()
+	}
+
+	// Accept flags before, between or after the two paths: the flag package
+	// stops at the first positional argument, so parse in rounds.
+	var paths []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return diffExitClean
+			}
+			return diffExitError
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		paths = append(paths, args[0])
+		args = args[1:]
+	}
+
+	if len(paths) != 2 {
+		fmt.Fprintf(stderr, "ferret-scan diff: expected a base and a head result, got %d path(s)\n\n", len(paths))
+		fs.Usage()
+		return diffExitError
+	}
+	switch *format {
+	case "text", "json", "markdown":
+	default:
+		fmt.Fprintf(stderr, "ferret-scan diff: unsupported format %q (use text, json or markdown)\n", *format)
+		return diffExitError
+	}
+	switch *failOn {
+	case failOnNew, failOnAny, failOnNone:
+	default:
+		fmt.Fprintf(stderr, "ferret-scan diff: unsupported --fail-on %q (use new, any or none)\n", *failOn)
+		return diffExitError
+	}
+
+	base, err := scanresult.Load(paths[0])
+	if err != nil {
+		fmt.Fprintf(stderr, "ferret-scan diff: %v\n", err)
+		return diffExitError
+	}
+	head, err := scanresult.Load(paths[1])
+	if err != nil {
+		fmt.Fprintf(stderr, "ferret-scan diff: %v\n", err)
+		return diffExitError
+	}
+
+	report := newDiffReport(paths[0], paths[1], base, head, parseConfidenceLevels(*confidence))
+	for _, w := range report.Warnings {
+		fmt.Fprintf(stderr, "ferret-scan diff: warning: %s\n", w)
+	}
+
+	switch *format {
+	case "json":
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			fmt.Fprintf(stderr, "ferret-scan diff: %v\n", err)
+			return diffExitError
+		}
+	case "markdown":
+		writeDiffMarkdown(stdout, report)
+	default:
+		writeDiffText(stdout, report)
+	}
+
+	return diffExitCode(report.Summary, *failOn)
+}
+
+// newDiffReport compares two results and keeps the entries in the requested
+// confidence bands. A band change is kept when either side is in a requested
+// band, so `--confidence high` still reports a finding that dropped out of HIGH.
+func newDiffReport(basePath, headPath string, base, head *scanresult.Result, levels map[string]bool) *diffReport {
+	c := scanresult.Compare(base, head)
+	in := func(level string) bool { return levels[strings.ToLower(level)] }
+	keep := func(findings []scanresult.Finding) []scanresult.Finding {
+		out := []scanresult.Finding{}
+		for _, f := range findings {
+			if in(f.ConfidenceLevel) {
+				out = append(out, f)
+			}
+		}
+		return out
+	}
+
+	r := &diffReport{
+		Base:        basePath,
+		Head:        headPath,
+		New:         keep(c.New),
+		Fixed:       keep(c.Fixed),
+		Unchanged:   keep(c.Unchanged),
+		BandChanged: []scanresult.BandChange{},
+	}
+	for _, b := range c.BandChanged {
+		if in(b.ConfidenceLevel) || in(b.FromLevel) {
+			r.BandChanged = append(r.BandChanged, b)
+			if b.Raised() {
+				r.Summary.BandRaised++
+			}
+		}
+	}
+	r.Summary.New = len(r.New)
+	r.Summary.Fixed = len(r.Fixed)
+	r.Summary.BandChanged = len(r.BandChanged)
+	r.Summary.Unchanged = len(r.Unchanged)
+
+	// Say what the comparison could not see. Each of these makes a count wrong
+	// in a specific direction, and a silent diff would present it as fact.
+	// A truncated base inflates "new"; a truncated head inflates "fixed".
+	if base.Truncated {
+		r.Warnings = append(r.Warnings, "base result was truncated by --limit, so some findings reported as new may have existed before (re-scan with --limit 0)")
+	}
+	if head.Truncated {
+		r.Warnings = append(r.Warnings, "head result was truncated by --limit, so some findings reported as fixed may still be present (re-scan with --limit 0)")
+	}
+	for _, side := range []struct {
+		name   string
+		result *scanresult.Result
+	}{{"base", base}, {"head", head}} {
+		if side.result.Unidentified > 0 {
+			r.Warnings = append(r.Warnings, fmt.Sprintf(
+				"%d %s finding(s) carry no suppression identity and were left out (a result written by an older version without --show-match)",
+				side.result.Unidentified, side.name))
+		}
+	}
+	return r
+}
+
+// diffExitCode applies --fail-on. A raised band counts as new under "new":
+// a LOW finding that became HIGH needs the same review as a fresh HIGH one.
+func diffExitCode(s diffSummary, failOn string) int {
+	switch failOn {
+	case failOnNone:
+		return diffExitClean
+	case failOnAny:
+		if s.New+s.Fixed+s.BandChanged > 0 {
+			return diffExitChanged
+		}
+	default:
+		if s.New+s.BandRaised > 0 {
+			return diffExitChanged
+		}
+	}
+	return diffExitClean
+}
+
+func findingLocation(f scanresult.Finding) string {
+	if f.LineNumber > 0 {
+		return fmt.Sprintf("%s:%d", f.Filename, f.LineNumber)
+	}
+	return f.Filename
+}
+
+func writeDiffText(w io.Writer, r *diffReport) {
+	fmt.Fprintf(w, "Comparing %s -> %s\n", r.Base, r.Head)
+	fmt.Fprintf(w, "  New: %d   Fixed: %d   Band moved: %d   Unchanged: %d\n",
+		r.Summary.New, r.Summary.Fixed, r.Summary.BandChanged, r.Summary.Unchanged)
+
+	section := func(title string, findings []scanresult.Finding) {
+		if len(findings) == 0 {
+			return
+		}
+		fmt.Fprintf(w, "\n%s (%d)\n", title, len(findings))
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, f := range findings {
+			fmt.Fprintf(tw, "  %s\t%.1f\t%s\t%s\n", f.ConfidenceLevel, f.Confidence, f.Type, findingLocation(f))
+		}
+		tw.Flush()
+	}
+	section("NEW", r.New)
+	section("FIXED", r.Fixed)
+
+	if len(r.BandChanged) > 0 {
+		fmt.Fprintf(w, "\nCONFIDENCE BAND MOVED (%d)\n", len(r.BandChanged))
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, b := range r.BandChanged {
+			fmt.Fprintf(tw, "  %s -> %s\t%.1f -> %.1f\t%s\t%s\n", b.FromLevel, b.ConfidenceLevel, b.FromConfidence, b.Confidence, b.Type, findingLocation(b.Finding))
+		}
+		tw.Flush()
+	}
+}
+
+// markdownCell escapes the characters that would break a table cell or start
+// markup. File names come from the scanned tree and may contain anything.
+func markdownCell(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "|", `\|`, "`", "\\`", "*", `\*`, "_", `\_`, "<", "&lt;", ">", "&gt;",
+	"[", `\[`, "]", `\]`, "\r", " ", "\n", " ",
+)
+
+// writeDiffMarkdown renders the comparison for a pull request comment or a CI
+// job summary.
+func writeDiffMarkdown(w io.Writer, r *diffReport) {
+	fmt.Fprintln(w, "## ferret-scan diff")
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Comparing %s → %s\n\n", markdownCell(r.Base), markdownCell(r.Head))
+	fmt.Fprintln(w, "| Change | Findings |")
+	fmt.Fprintln(w, "|---|---:|")
+	fmt.Fprintf(w, "| New | %d |\n", r.Summary.New)
+	fmt.Fprintf(w, "| Fixed | %d |\n", r.Summary.Fixed)
+	fmt.Fprintf(w, "| Confidence band moved | %d |\n", r.Summary.BandChanged)
+	fmt.Fprintf(w, "| Unchanged | %d |\n", r.Summary.Unchanged)
+
+	for _, warning := range r.Warnings {
+		fmt.Fprintf(w, "\n> **Warning:** %s\n", markdownCell(warning))
+	}
+
+	section := func(title string, findings []scanresult.Finding) {
+		if len(findings) == 0 {
+			return
+		}
+		fmt.Fprintf(w, "\n### %s\n\n", title)
+		fmt.Fprintln(w, "| Confidence | Type | Location |")
+		fmt.Fprintln(w, "|---|---|---|")
+		for _, f := range findings {
+			fmt.Fprintf(w, "| %s (%.1f) | %s | %s |\n", f.ConfidenceLevel, f.Confidence, markdownCell(f.Type), markdownCell(findingLocation(f)))
+		}
+	}
+	section("New findings", r.New)
+	section("Fixed findings", r.Fixed)
+
+	if len(r.BandChanged) > 0 {
+		fmt.Fprintln(w, "\n### Confidence band moved")
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "| From | To | Type | Location |")
+		fmt.Fprintln(w, "|---|---|---|---|")
+		for _, b := range r.BandChanged {
+			fmt.Fprintf(w, "| %s (%.1f) | %s (%.1f) | %s | %s |\n", b.FromLevel, b.FromConfidence, b.ConfidenceLevel, b.Confidence, markdownCell(b.Type), markdownCell(findingLocation(b.Finding)))
+		}
+	}
+}
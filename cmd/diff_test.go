@@ -0,0 +1,160 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/awslabs/ferret-scan/v2/internal/detector"
+	"github.com/awslabs/ferret-scan/v2/internal/formatters"
+	jsonfmt "github.com/awslabs/ferret-scan/v2/internal/formatters/json"
+	"github.com/awslabs/ferret-scan/v2/internal/formatters/sarif"
+)
+
+// writeResult renders matches through a real formatter, the way a scheduled
+// scan would have saved them.
+func writeResult(t *testing.T, f formatters.Formatter, name string, matches []detector.Match, limit int) string {
+	t.Helper()
+	out, err := f.Format(matches, nil, formatters.FormatterOptions{
+		ConfidenceLevel: map[string]bool{"high": true, "medium": true, "low": true},
+		Limit:           limit,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(out), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func diffFixture() (base, head []detector.Match) {
+	ssn := detector.Match{Text: "123-45-6789", Type: "SSN", Validator: "SSN", Confidence: 95, Filename: "people.txt", LineNumber: 3}
+	email := detector.Match{Text: "a@example.com", Type: "EMAIL", Validator: "EMAIL", Confidence: 40, Filename: "contacts.md", LineNumber: 7}
+	card := detector.Match{Text: "4111111111111111", Type: "VISA", Validator: "CREDIT_CARD", Confidence: 70, Filename: "pay|ments.csv", LineNumber: 2}
+
+	raised := email
+	raised.Confidence = 92
+	return []detector.Match{ssn, email}, []detector.Match{ssn, raised, card}
+}
+
+func runDiffArgs(args ...string) (code int, stdout, stderr string) {
+	var out, errOut bytes.Buffer
+	code = runDiff(args, &out, &errOut)
+	return code, out.String(), errOut.String()
+}
+
+// TestDiff_JSONAcrossFormats compares a JSON base with a SARIF head: identities
+// are the same in both, so the pairing must not depend on the format.
+func TestDiff_JSONAcrossFormats(t *testing.T) {
+	baseMatches, headMatches := diffFixture()
+	base := writeResult(t, jsonfmt.NewFormatter(), "base.json", baseMatches, 0)
+	head := writeResult(t, sarif.NewFormatter(), "head.sarif", headMatches, 0)
+
+	code, out, stderr := runDiffArgs(base, head, "--format", "json")
+	if code != diffExitChanged {
+		t.Errorf("exit = %d, want %d for a new finding; stderr: %s", code, diffExitChanged, stderr)
+	}
+	var report diffReport
+	if err := json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out)
+	}
+	if report.Summary != (diffSummary{New: 1, BandChanged: 1, BandRaised: 1, Unchanged: 1}) {
+		t.Errorf("summary = %+v", report.Summary)
+	}
+	if len(report.New) != 1 || report.New[0].Type != "VISA" {
+		t.Errorf("new = %+v", report.New)
+	}
+	if b := report.BandChanged; len(b) != 1 || b[0].FromLevel != "LOW" || b[0].ConfidenceLevel != "HIGH" {
+		t.Errorf("band_changed = %+v", b)
+	}
+	if strings.Contains(out, "123-45-6789") || strings.Contains(out, "4111111111111111") {
+		t.Error("diff output contains a matched value")
+	}
+}
+
+func TestDiff_ExitCodes(t *testing.T) {
+	baseMatches, headMatches := diffFixture()
+	base := writeResult(t, jsonfmt.NewFormatter(), "base.json", baseMatches, 0)
+	head := writeResult(t, jsonfmt.NewFormatter(), "head.json", headMatches, 0)
+
+	for _, tc := range []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"identical", []string{base, base}, diffExitClean},
+		{"fixed only passes the default gate", []string{head, base}, diffExitClean},
+		{"fixed fails --fail-on any", []string{"--fail-on", "any", head, base}, diffExitChanged},
+		{"none never fails", []string{head, base, "--fail-on=none"}, diffExitClean},
+		{"a move into HIGH fails --confidence high", []string{"--confidence", "high", base, head}, diffExitChanged},
+		{"a move out of LOW fails --confidence low", []string{"--confidence", "low", base, head}, diffExitChanged},
+		{"a fixed MEDIUM finding fails --fail-on any", []string{"--fail-on", "any", "--confidence", "medium", head, base}, diffExitChanged},
+		{"missing file", []string{base, filepath.Join(t.TempDir(), "nope.json")}, diffExitError},
+		{"one path", []string{base}, diffExitError},
+		{"bad format", []string{"--format", "xml", base, head}, diffExitError},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if code, _, stderr := runDiffArgs(tc.args...); code != tc.want {
+				t.Errorf("exit = %d, want %d; stderr: %s", code, tc.want, stderr)
+			}
+		})
+	}
+
+	// --confidence medium: the new VISA (MEDIUM) is selected; the LOW->HIGH move is not.
+	code, out, _ := runDiffArgs("--confidence", "medium", "--format", "json", base, head)
+	var report diffReport
+	if err := json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatal(err)
+	}
+	if code != diffExitChanged || report.Summary.New != 1 || report.Summary.BandChanged != 0 {
+		t.Errorf("--confidence medium: exit %d, summary %+v", code, report.Summary)
+	}
+}
+
+func TestDiff_TextAndMarkdown(t *testing.T) {
+	baseMatches, headMatches := diffFixture()
+	base := writeResult(t, jsonfmt.NewFormatter(), "base.json", baseMatches, 0)
+	head := writeResult(t, jsonfmt.NewFormatter(), "head.json", headMatches, 0)
+
+	_, text, _ := runDiffArgs(base, head)
+	for _, want := range []string{"New: 1", "NEW (1)", "pay|ments.csv:2", "CONFIDENCE BAND MOVED (1)", "LOW -> HIGH"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text output missing %q:\n%s", want, text)
+		}
+	}
+
+	_, md, _ := runDiffArgs("--format", "markdown", base, head)
+	for _, want := range []string{"## ferret-scan diff", "| New | 1 |", "### New findings", `pay\|ments.csv:2`, "### Confidence band moved"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown output missing %q:\n%s", want, md)
+		}
+	}
+}
+
+// TestDiff_WarnsOnTruncatedResult: a --limit result is missing findings, and
+// the diff must say so rather than report them as fixed.
+func TestDiff_WarnsOnTruncatedResult(t *testing.T) {
+	baseMatches, headMatches := diffFixture()
+	base := writeResult(t, jsonfmt.NewFormatter(), "base.json", baseMatches, 0)
+	head := writeResult(t, jsonfmt.NewFormatter(), "head.json", headMatches, 1)
+
+	_, out, stderr := runDiffArgs("--format", "json", base, head)
+	if !strings.Contains(stderr, "head result was truncated") {
+		t.Errorf("stderr = %q, want a truncation warning", stderr)
+	}
+	var report diffReport
+	if err := json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Warnings) != 1 {
+		t.Errorf("warnings = %v", report.Warnings)
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/awslabs/ferret-scan/v2/internal/edm"
+)
+
+// Exit codes of `ferret-scan edm`: 2, as for diff, means the tool could not do
+// its job.
+const (
+	edmExitOK    = 0
+	edmExitError = 2
+)
+
+const edmUsage = `Usage: ferret-scan edm build [options] <table.csv> --out <index.edm>
+
+Builds an exact data match index from a CSV table of known sensitive records
+whose first row names its columns. The index holds salted hashes of the
+normalised values, by column and row, and never a value. Scan with
+--edm <index.edm> to report the table's values wherever they appear.
+
+Anyone holding the index can recover short or guessable values (an SSN, a
+name) by hashing candidates under the salt it carries. The index is written
+readable by its owner only; protect it as you would the table.
+
+Options:
+`
+
+// runEDM implements `ferret-scan edm`. build is its only action; the argument
+// is there so the index can grow others (inspect, say) without a new command.
+func runEDM(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("edm build", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	out := fs.String("out", "", "Path to write the index to (required)")
+	fs.Usage = func() {
+		fmt.Fprint(stderr, edmUsage)
+		fs.PrintDefaults()
+	}
+
+	// Accept flags before, between or after the action and the table, as diff
+	// does.
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return edmExitOK
+			}
+			return edmExitError
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+
+	if len(positional) == 0 || positional[0] != "build" {
+		fmt.Fprint(stderr, "ferret-scan edm: expected the action build\n\n")
+		fs.Usage()
+		return edmExitError
+	}
+	if len(positional) != 2 {
+		fmt.Fprintf(stderr, "ferret-scan edm build: expected one table, got %d path(s)\n\n", len(positional)-1)
+		fs.Usage()
+		return edmExitError
+	}
+	if *out == "" {
+		fmt.Fprint(stderr, "ferret-scan edm build: --out is required\n\n")
+		fs.Usage()
+		return edmExitError
+	}
+	table := positional[1]
+	if sameFile(table, *out) {
+		fmt.Fprintf(stderr, "ferret-scan edm build: --out %s is the table itself\n", *out)
+		return edmExitError
+	}
+
+	f, err := os.Open(table) // #nosec G304 -- path named by the user on the command line
+	if err != nil {
+		fmt.Fprintf(stderr, "ferret-scan edm build: %v\n", err)
+		return edmExitError
+	}
+	ix, stats, err := edm.Build(f)
+	_ = f.Close()
+	if err != nil {
+		fmt.Fprintf(stderr, "ferret-scan edm build: %s: %v\n", table, err)
+		return edmExitError
+	}
+	if err := ix.WriteFile(*out); err != nil {
+		fmt.Fprintf(stderr, "ferret-scan edm build: %v\n", err)
+		return edmExitError
+	}
+
+	fmt.Fprintf(stdout, "Indexed %d value(s) from %d record(s) in %d column(s) into %s\n",
+		stats.Values, stats.Records, stats.Columns, *out)
+	if stats.Short+stats.Long+stats.Common > 0 {
+		fmt.Fprintf(stdout, "Not indexed: %d cell(s) under 4 characters, %d over %d words, %d shared by too many records to identify one\n",
+			stats.Short, stats.Long, edm.MaxValueWords, stats.Common)
+	}
+	return edmExitOK
+}
+
+// sameFile reports whether a and b name one existing file, so that a
+// transposed command line cannot overwrite the table with its own index.
+func sameFile(a, b string) bool {
+	ai, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	bi, err := os.Stat(b)
+	if err != nil {
+		return filepath.Clean(a) == filepath.Clean(b)
+	}
+	return os.SameFile(ai, bi)
+}
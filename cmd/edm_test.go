@@ -0,0 +1,74 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/awslabs/ferret-scan/v2/internal/edm"
+)
+
+func runEDMArgs(args ...string) (code int, stdout, stderr string) {
+	var out, errOut bytes.Buffer
+	code = runEDM(args, &out, &errOut)
+	return code, out.String(), errOut.String()
+}
+
+func TestEDMBuild(t *testing.T) {
+	dir := t.TempDir()
+	table := filepath.Join(dir, "customers.csv")
+	if err := os.WriteFile(table, []byte("name,ssn,state\nJane Doe,123-45-6789,TX\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "customers.edm")
+
+	// --out after the table, as in the documented command line.
+	code, stdout, stderr := runEDMArgs("build", table, "--out", out)
+	if code != edmExitOK {
+		t.Fatalf("exit %d, stderr %q", code, stderr)
+	}
+	if !strings.Contains(stdout, "Indexed 2 value(s) from 1 record(s) in 3 column(s)") ||
+		!strings.Contains(stdout, "1 cell(s) under 4 characters") {
+		t.Errorf("stdout = %q", stdout)
+	}
+	ix, err := edm.Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hits := ix.Search("SSN 123456789"); len(hits) != 1 {
+		t.Errorf("the built index finds %d hit(s) for the SSN, want 1", len(hits))
+	}
+	if runtime.GOOS != "windows" {
+		if fi, err := os.Stat(out); err != nil || fi.Mode().Perm() != 0o600 {
+			t.Errorf("index mode = %v, %v; want 0600", fi.Mode().Perm(), err)
+		}
+	}
+}
+
+func TestEDMBuildErrors(t *testing.T) {
+	dir := t.TempDir()
+	table := filepath.Join(dir, "customers.csv")
+	if err := os.WriteFile(table, []byte("name\nJane Doe\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for name, args := range map[string][]string{
+		"no action":      {table, "--out", filepath.Join(dir, "a.edm")},
+		"no --out":       {"build", table},
+		"two tables":     {"build", table, table, "--out", filepath.Join(dir, "a.edm")},
+		"missing table":  {"build", filepath.Join(dir, "none.csv"), "--out", filepath.Join(dir, "a.edm")},
+		"out is a table": {"build", table, "--out", table},
+	} {
+		if code, _, _ := runEDMArgs(args...); code != edmExitError {
+			t.Errorf("%s: exit %d, want %d", name, code, edmExitError)
+		}
+	}
+	if data, _ := os.ReadFile(table); string(data) != "name\nJane Doe\n" {
+		t.Errorf("the table was overwritten: %q", data)
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/awslabs/ferret-scan/v2/internal/parallel"
+)
+
+// A document that stays locked fails in the router with an error wrapping
+// encryption.ErrEncrypted. It must be listed under its own cause, with the
+// scheme as the detail: filed under "coverage cut short" it read as a timeout,
+// and the operator's remedy — a password — was nowhere in the report.
+func TestLockedDocumentsAreReportedAsEncrypted(t *testing.T) {
+	failed := []parallel.FileDiagnostic{
+		{
+			FilePath: "/data/q3.xlsx",
+			Reason:   "encrypted: ECMA-376 agile encryption; none of the 2 supplied password(s) opened it",
+		},
+		{
+			FilePath: "/data/old.doc",
+			Reason:   "encrypted: Word 97-2003 document encryption is detected but not decrypted; save the document without a password to scan it",
+		},
+		{
+			// A PDF library's own wording is a parse failure, not this cause.
+			FilePath: "/data/broken.pdf",
+			Reason:   "all preprocessors failed for file: /data/broken.pdf: encrypted PDF: invalid password",
+		},
+	}
+
+	got := collectUnscanned(nil, nil, failed, nil, nil)
+	causes := map[string]unscannedEntry{}
+	for _, e := range got {
+		causes[e.Path] = e
+	}
+
+	for _, path := range []string{"/data/q3.xlsx", "/data/old.doc"} {
+		e := causes[path]
+		if e.Cause != causeEncrypted {
+			t.Errorf("%s: cause = %q, want %q", path, e.Cause, causeEncrypted)
+		}
+		if strings.HasPrefix(e.Detail, "encrypted:") {
+			t.Errorf("%s: detail %q repeats the cause", path, e.Detail)
+		}
+	}
+	if !strings.Contains(causes["/data/q3.xlsx"].Detail, "none of the 2 supplied password(s)") {
+		t.Errorf("detail = %q, want what was tried", causes["/data/q3.xlsx"].Detail)
+	}
+	if c := causes["/data/broken.pdf"].Cause; c == causeEncrypted {
+		t.Errorf("a library parse error was filed as %q", c)
+	}
+}
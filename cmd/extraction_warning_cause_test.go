@@ -49,6 +49,16 @@ func TestEveryExtractionWarningProducerLandsInTheRightBucket(t *testing.T) {
 			reason:   "no text extracted from .pdf: the file parsed but held no document text, so page content was NOT scanned",
 			want:     causeNoText,
 		},
+		{
+			producer: "PDF pages unreadable (text_preprocessor.pdfPageCoverageWarning)",
+			reason:   "Text Extractor: 2 of 120 pages in .pdf could not be read and were NOT scanned",
+			want:     causeCutShort,
+		},
+		{
+			producer: "PDF pages past the text cap (text_preprocessor.pdfPageCoverageWarning)",
+			reason:   "Text Extractor: pages 801-900 of 900 in .pdf were NOT scanned: extracted text reached the 200MB per-document limit",
+			want:     causeCutShort,
+		},
 		{
 			producer: "router-prefixed no-text warning",
 			reason:   "Text Extractor: no text extracted from .docx: no document body part was found in the archive, so document content was NOT scanned",
@@ -69,6 +79,21 @@ func TestEveryExtractionWarningProducerLandsInTheRightBucket(t *testing.T) {
 			reason:   `office_metadata: embedded item "attachment.docx" was not examined: embedded container nesting limit reached`,
 			want:     causeCutShort,
 		},
+		{
+			producer: "embedded part of a decrypted document declined (meta-extract-officelib)",
+			reason:   `office_metadata: embedded part "notes.txt" was not examined: the document is encrypted, and extracting the part would write its decrypted content to disk`,
+			want:     causeCutShort,
+		},
+		{
+			producer: "attachment of a decrypted PDF declined (text-extract-pdftextlib)",
+			reason:   `pdf_structure: embedded file "data.csv" was not examined: the document is encrypted, and extracting the file would write its decrypted content to disk`,
+			want:     causeCutShort,
+		},
+		{
+			producer: "encrypted attachment inside a container (base_metadata_preprocessor)",
+			reason:   `office_metadata: embedded item "locked.pdf" was not examined: encrypted: PDF standard security handler; no password was supplied for it (see --password-file)`,
+			want:     causeCutShort,
+		},
 		{
 			producer: "WAV chunk walk stopped early (meta-extract-audiolib)",
 			reason:   "audio_metadata: audio metadata may be incomplete: the WAV chunk layout could not be walked to the end",
@@ -118,6 +143,28 @@ func TestEveryExtractionWarningProducerLandsInTheRightBucket(t *testing.T) {
 			reason:   "video_metadata: video metadata may be incomplete: no moov box was found in the file",
 			want:     causeCutShort,
 		},
+		// A Parquet or Avro file read in part: rows left to a sample or the text cap, or one
+		// column of one row group in a compression the reader does not support.
+		{
+			producer: "columnar rows left unread by --sample-rows (columnarCoverageWarning)",
+			reason:   "columnar: rows 1001-5000000 of 5000000 were NOT scanned: only the first 1000 were sampled (--sample-rows)",
+			want:     causeCutShort,
+		},
+		{
+			producer: "columnar rows past the text cap (columnarCoverageWarning)",
+			reason:   "columnar: rows after 812345 were NOT scanned: extracted text reached the 200MB per-file limit",
+			want:     causeCutShort,
+		},
+		{
+			producer: "Parquet column in an unsupported codec (text-extract-columnarlib)",
+			reason:   "columnar: column email, rows 1-250000: ZSTD compression is not supported, so those values were NOT scanned",
+			want:     causeCutShort,
+		},
+		{
+			producer: "Avro block unreadable (text-extract-columnarlib)",
+			reason:   "columnar: rows after 4096: sync marker mismatch, so the rest of the file was NOT scanned",
+			want:     causeCutShort,
+		},
 	}
 
 	for _, c := range cases {
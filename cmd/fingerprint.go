@@ -0,0 +1,161 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/awslabs/ferret-scan/v2/internal/fingerprint"
+	"github.com/awslabs/ferret-scan/v2/internal/router"
+)
+
+// Exit codes of `ferret-scan fingerprint`: 2, as for diff and edm, means the
+// tool could not do its job.
+const (
+	fingerprintExitOK    = 0
+	fingerprintExitError = 2
+)
+
+const fingerprintUsage = `Usage: ferret-scan fingerprint register [options] <file>... --store <store.fp>
+
+Registers documents as confidential: extracts their text with the same
+preprocessors a scan uses, and adds winnowed hashes of its six-word runs to
+the store, creating it if it does not exist. A document registered again
+under the same path replaces its earlier fingerprints. Scan with
+--fingerprints <store.fp> to report content copied from the registered
+documents, in any format, as DOCUMENT_FINGERPRINT.
+
+The store holds no text, only hashes and the registered paths. Every file must
+be read before the store is written; if one cannot be, nothing is.
+
+Options:
+`
+
+// runFingerprint implements `ferret-scan fingerprint`. register is its only
+// action; the argument is there so the store can grow others (list, remove)
+// without a new command.
+func runFingerprint(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("fingerprint register", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	storePath := fs.String("store", "", "Path of the store to add to or create (required)")
+	fs.Usage = func() {
+		fmt.Fprint(stderr, fingerprintUsage)
+		fs.PrintDefaults()
+	}
+
+	// Accept flags before, between or after the action and the files, as diff
+	// does.
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return fingerprintExitOK
+			}
+			return fingerprintExitError
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+
+	if len(positional) == 0 || positional[0] != "register" {
+		fmt.Fprint(stderr, "ferret-scan fingerprint: expected the action register\n\n")
+		fs.Usage()
+		return fingerprintExitError
+	}
+	files := positional[1:]
+	if len(files) == 0 {
+		fmt.Fprint(stderr, "ferret-scan fingerprint register: expected one or more files\n\n")
+		fs.Usage()
+		return fingerprintExitError
+	}
+	if *storePath == "" {
+		fmt.Fprint(stderr, "ferret-scan fingerprint register: --store is required\n\n")
+		fs.Usage()
+		return fingerprintExitError
+	}
+	for _, f := range files {
+		if sameFile(f, *storePath) {
+			fmt.Fprintf(stderr, "ferret-scan fingerprint register: --store %s is one of the documents\n", *storePath)
+			return fingerprintExitError
+		}
+	}
+
+	// An existing file at --store is added to, and must be a store: anything
+	// else is refused rather than overwritten.
+	store := fingerprint.NewStore()
+	if data, err := os.ReadFile(*storePath); err == nil { // #nosec G304 -- path named by the user on the command line
+		if store, err = fingerprint.Read(data); err != nil {
+			fmt.Fprintf(stderr, "ferret-scan fingerprint register: %s: %v\n", *storePath, err)
+			return fingerprintExitError
+		}
+	} else if !os.IsNotExist(err) {
+		fmt.Fprintf(stderr, "ferret-scan fingerprint register: %v\n", err)
+		return fingerprintExitError
+	}
+
+	fr := router.NewFileRouter(false)
+	router.RegisterDefaultPreprocessors(fr)
+	fr.InitializePreprocessors(router.CreateRouterConfig(false))
+
+	type registered struct {
+		name  string
+		count int
+	}
+	var done []registered
+	for _, f := range files {
+		text, err := extractText(fr, f)
+		if err == nil {
+			name := filepath.Clean(f)
+			var n int
+			if n, err = store.Add(name, text); err == nil {
+				done = append(done, registered{name, n})
+				continue
+			}
+		}
+		fmt.Fprintf(stderr, "ferret-scan fingerprint register: %s: %v\n", f, err)
+		return fingerprintExitError
+	}
+	if err := store.WriteFile(*storePath); err != nil {
+		fmt.Fprintf(stderr, "ferret-scan fingerprint register: %v\n", err)
+		return fingerprintExitError
+	}
+
+	for _, d := range done {
+		fmt.Fprintf(stdout, "Registered %s: %d fingerprint(s)\n", d.name, d.count)
+	}
+	fmt.Fprintf(stdout, "%s holds %d document(s)\n", *storePath, len(store.Documents()))
+	return fingerprintExitOK
+}
+
+// extractText returns the text a scan of path would validate: the document
+// text and metadata its preprocessors extract.
+func extractText(fr *router.FileRouter, path string) (string, error) {
+	if ok, reason := fr.CanProcessFile(path, true); !ok {
+		return "", errors.New(reason)
+	}
+	pc, err := fr.CreateProcessingContext(path, false)
+	if err != nil {
+		return "", err
+	}
+	content, err := fr.ProcessFileWithContext(path, pc)
+	if err != nil {
+		return "", err
+	}
+	if !content.Success {
+		if content.Error != nil {
+			return "", content.Error
+		}
+		return "", errors.New("no text could be extracted")
+	}
+	return content.Text, nil
+}
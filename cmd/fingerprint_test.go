@@ -0,0 +1,146 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/awslabs/ferret-scan/v2/internal/fingerprint"
+)
+
+func runFingerprintArgs(args ...string) (code int, stdout, stderr string) {
+	var out, errOut bytes.Buffer
+	code = runFingerprint(args, &out, &errOut)
+	return code, out.String(), errOut.String()
+}
+
+// confidentialPlan is prose no run of six words repeats in.
+const confidentialPlan = "The board approved the northern expansion on the condition that " +
+	"supplier margins recover before the third quarter. Legal will review the " +
+	"revised licensing terms with our two largest distributors while finance " +
+	"models a price increase of four percent across wholesale channels. If the " +
+	"merger talks with Halvard resume, the retail rollout moves to spring and " +
+	"the regional offices in Tromso and Bergen close by the end of next year. " +
+	"Nobody outside the steering group is to be told the target valuation."
+
+// writeDocx writes a minimal .docx whose body is one paragraph of text.
+func writeDocx(t *testing.T, path, text string) {
+	t.Helper()
+	const contentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>` +
+		`</Types>`
+	const rels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>` +
+		`</Relationships>`
+	body := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>` +
+		`<w:p><w:r><w:t>` + text + `</w:t></w:r></w:p></w:body></w:document>`
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, p := range []struct{ name, content string }{
+		{"[Content_Types].xml", contentTypes},
+		{"_rels/.rels", rels},
+		{"word/document.xml", body},
+	} {
+		w, err := zw.Create(p.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(p.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// A document registered as a Word file is found in plain text: register reads
+// it through the preprocessors a scan uses.
+func TestFingerprintRegister(t *testing.T) {
+	dir := t.TempDir()
+	plan := filepath.Join(dir, "plan.docx")
+	writeDocx(t, plan, confidentialPlan)
+	notes := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(notes, []byte(strings.Repeat("weekly status meeting notes for the platform team ", 3)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	store := filepath.Join(dir, "docs.fp")
+
+	code, stdout, stderr := runFingerprintArgs("register", plan, notes, "--store", store)
+	if code != fingerprintExitOK {
+		t.Fatalf("exit %d, stderr %q", code, stderr)
+	}
+	if !strings.Contains(stdout, "Registered "+plan+": ") || !strings.Contains(stdout, "holds 2 document(s)") {
+		t.Errorf("stdout = %q", stdout)
+	}
+
+	s, err := fingerprint.Open(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pasted := "fwd:\n" + strings.ToLower(strings.ReplaceAll(confidentialPlan, ". ", ".\n\n"))
+	got := s.Compare(pasted, 0.3)
+	if len(got) != 1 || got[0].Document != plan || got[0].Similarity < 0.9 {
+		t.Errorf("Compare of a pasted copy = %+v, want %s at over 90%%", got, plan)
+	}
+
+	// Registering again adds to the store.
+	memo := filepath.Join(dir, "memo.txt")
+	if err := os.WriteFile(memo, []byte(strings.ToUpper(confidentialPlan)+" Appendix follows."), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if code, stdout, stderr := runFingerprintArgs("register", "--store", store, memo); code != fingerprintExitOK || !strings.Contains(stdout, "holds 3 document(s)") {
+		t.Errorf("second register: exit %d, stdout %q, stderr %q", code, stdout, stderr)
+	}
+}
+
+func TestFingerprintRegisterErrors(t *testing.T) {
+	dir := t.TempDir()
+	plan := filepath.Join(dir, "plan.txt")
+	if err := os.WriteFile(plan, []byte(confidentialPlan), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	short := filepath.Join(dir, "short.txt")
+	if err := os.WriteFile(short, []byte("too short"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	notAStore := filepath.Join(dir, "customers.csv")
+	if err := os.WriteFile(notAStore, []byte("name\nJane Doe\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	store := filepath.Join(dir, "docs.fp")
+	for name, args := range map[string][]string{
+		"no action":       {plan, "--store", store},
+		"no files":        {"register", "--store", store},
+		"no --store":      {"register", plan},
+		"missing file":    {"register", plan, filepath.Join(dir, "none.txt"), "--store", store},
+		"too short":       {"register", plan, short, "--store", store},
+		"store is a file": {"register", plan, "--store", plan},
+		"not a store":     {"register", plan, "--store", notAStore},
+	} {
+		if code, _, _ := runFingerprintArgs(args...); code != fingerprintExitError {
+			t.Errorf("%s: exit %d, want %d", name, code, fingerprintExitError)
+		}
+	}
+	if _, err := os.Stat(store); !os.IsNotExist(err) {
+		t.Errorf("a failed register wrote the store: %v", err)
+	}
+	if data, _ := os.ReadFile(notAStore); string(data) != "name\nJane Doe\n" {
+		t.Errorf("a file that is not a store was overwritten: %q", data)
+	}
+}
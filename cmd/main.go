@@ -18,6 +18,9 @@ import (
 
 	"github.com/awslabs/ferret-scan/v2/internal/config"
 	"github.com/awslabs/ferret-scan/v2/internal/core"
+	"github.com/awslabs/ferret-scan/v2/internal/edm"
+	"github.com/awslabs/ferret-scan/v2/internal/encryption"
+	"github.com/awslabs/ferret-scan/v2/internal/fingerprint"
 	"github.com/awslabs/ferret-scan/v2/internal/gitignore"
 	"github.com/awslabs/ferret-scan/v2/internal/precommit"
 	"github.com/awslabs/ferret-scan/v2/internal/version"
@@ -33,7 +36,9 @@ import (
 	"github.com/awslabs/ferret-scan/v2/internal/formatters"
 	_ "github.com/awslabs/ferret-scan/v2/internal/formatters/csv"
 	_ "github.com/awslabs/ferret-scan/v2/internal/formatters/gitlab-sast"
+	_ "github.com/awslabs/ferret-scan/v2/internal/formatters/html"
 	_ "github.com/awslabs/ferret-scan/v2/internal/formatters/json"
+	_ "github.com/awslabs/ferret-scan/v2/internal/formatters/jsonl"
 	_ "github.com/awslabs/ferret-scan/v2/internal/formatters/junit"
 	_ "github.com/awslabs/ferret-scan/v2/internal/formatters/sarif"
 	_ "github.com/awslabs/ferret-scan/v2/internal/formatters/text"
@@ -88,6 +93,13 @@ func tooLargeReason() string {
 	return fmt.Sprintf("file too large (max size: %dMB)", maxScanSize/(1024*1024))
 }
 
+// withinScanSize reports whether discovery admits a file of size bytes: one no
+// larger than maxScanSize, or one the router reads within its own bound at any
+// size (router.SizeExempt).
+func withinScanSize(path string, size int64, enablePreprocessors bool) bool {
+	return size <= maxScanSize || router.SizeExempt(path, enablePreprocessors)
+}
+
 // resolveIncompleteExitCode applies the --fail-on-incomplete policy on top of a
 // base exit code: when enabled and coverage was incomplete, an otherwise-clean
 // result (base 0) escalates to exitCodeIncompleteCoverage, but a non-zero base
@@ -1022,12 +1034,18 @@ func parseExcludePatterns(excludeStr string) []string {
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		if run, ok := subcommands[os.Args[1]]; ok {
+			os.Exit(run(os.Args[2:], os.Stdout, os.Stderr))
+		}
+	}
+
 	// Parse command line flags
 	inputFile := flag.String("file", "", "Path to the input file, directory, or glob pattern (e.g., *.pdf)")
 	configFile := flag.String("config", "", "Path to configuration file (YAML)")
 	profileName := flag.String("profile", "", "Profile name to use from config file")
 	listProfiles := flag.Bool("list-profiles", false, "List available profiles in config file")
-	outputFormat := flag.String("format", "", "Output format: text, json, csv, yaml, junit, gitlab-sast, sarif (default: text)")
+	outputFormat := flag.String("format", "", "Output format: text, json, csv, yaml, junit, gitlab-sast, sarif, html, jsonl (default: text)")
 	confidenceLevels := flag.String("confidence", "", "Confidence levels to display: high, medium, low, or combinations like 'high,medium'")
 	checksToRun := flag.String("checks", "", "Specific checks to run: "+strings.Join(core.CheckNames(), ", ")+", all (default: all)")
 	verbose := flag.Bool("verbose", false, "Display detailed information for each finding")
@@ -1055,6 +1073,9 @@ func main() {
 	redactionOutputDir := flag.String("redaction-output-dir", "./redacted", "Directory where redacted files will be stored")
 	redactionStrategy := flag.String("redaction-strategy", "format_preserving", "Default redaction strategy: simple, format_preserving, or synthetic")
 	redactionAuditLog := flag.String("redaction-audit-log", "", "Path to save redaction audit log file (JSON format for compliance)")
+	clearNotebookOutputs := flag.Bool("clear-notebook-outputs", false, "With --enable-redaction, also empty the outputs of every Jupyter notebook cell holding a HIGH confidence finding")
+	gpsPrecisionFlag := flag.String("gps-precision", "", "With --enable-redaction, keep reported GPS positions in image and video metadata coarsened to N decimal places (1-5) or a distance (e.g. 1km, 100m) instead of removing them")
+	sanitizeMetadata := flag.Bool("sanitize-metadata", false, "Write a copy of each file to --redaction-output-dir with its personal metadata removed, whatever a scan would report: authors, company, template, custom properties, revision IDs and printer names of Office documents, and EXIF, XMP, GPS and tags of images, audio and video. Reports the fields removed per file; nothing is scanned")
 
 	// Exclusion flag
 	excludePatterns := flag.String("exclude", "", "Comma-separated list of patterns to exclude from scanning (e.g., '.git,*.log,temp/')")
@@ -1066,6 +1087,10 @@ func main() {
 	// IP sub-type control flag
 	disableIPTypes := flag.String("disable-ip-types", "", "Comma-separated list of IP sub-types to disable: copyright,patent,trademark,trade_secret,internal_url")
 	validatorBudget := flag.String("validator-budget", "", "Per-validator time budget as NAME=DURATION pairs. DURATION takes any Go duration unit — ms, s, m, h (e.g. 'SSN=500ms,IP_ADDRESS=2m'). Use 'all=<dur>' for every validator; specific names override it. A validator exceeding its budget is stopped and the scan is marked incomplete. Default: no budget.")
+	passwordFile := flag.String("password-file", "", "Path to a YAML file of per-glob passwords for encrypted PDF and Office documents (see docs/configuration.md). Documents are decrypted in memory only. An encrypted document no password opens is reported as not examined (encrypted). Password-protected Word, Excel and PowerPoint 97-2003 files (.doc, .xls, .ppt) are not decrypted: they are skipped and reported as not examined (encrypted) whatever password is given.")
+	edmIndexPath := flag.String("edm", "", "Path to an exact data match index built with 'ferret-scan edm build'. Reports the indexed table's values (check EDM_MATCH, HIGH confidence), including a name or other field found with the last 4 digits of the same record's SSN or account number. Default: none.")
+	fingerprintStorePath := flag.String("fingerprints", "", "Path to a store of confidential documents registered with 'ferret-scan fingerprint register'. Reports content copied from them, in any format, with the source document and similarity (check DOCUMENT_FINGERPRINT). Default: none.")
+	sampleRows := flag.Int64("sample-rows", 0, "Read only the first N rows of each Parquet or Avro file, for a fast classification of files too large to read through. The rows left unread are reported as incomplete coverage. Default: 0 (every row).")
 	maxLiveBytes := flag.String("max-live-bytes", "", "Cap total extracted content held in memory across concurrently scanned files, e.g. '256MB' or '1GB' (units: B, KB, MB, GB; bare number = bytes). Bounds peak memory on constrained hosts (e.g. Lambda) so many large files cannot multiply memory. Default: no cap (bounded only by the 100MB per-file limit × worker count).")
 
 	// Web server flags
@@ -1100,6 +1125,40 @@ func main() {
 		os.Exit(1)
 	}
 
+	if *sampleRows < 0 {
+		fmt.Fprintf(os.Stderr, "Error: --sample-rows must be zero or more, got %d\n", *sampleRows)
+		os.Exit(1)
+	}
+
+	// Load --password-file up front as well, so a typo in it fails before any
+	// file is scanned rather than leaving every encrypted document reported as
+	// locked. A nil *Passwords supplies no passwords.
+	var passwords *encryption.Passwords
+	if *passwordFile != "" {
+		var pwErr error
+		passwords, pwErr = encryption.LoadPasswordFile(*passwordFile)
+		if pwErr != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", pwErr)
+			os.Exit(1)
+		}
+	}
+
+	// Open --edm and --fingerprints up front for the same reason: a missing or
+	// damaged index or store would otherwise fail its check on every file, after
+	// the walk.
+	if *edmIndexPath != "" {
+		if _, err := edm.Open(*edmIndexPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --edm %s: %v\n", *edmIndexPath, err)
+			os.Exit(1)
+		}
+	}
+	if *fingerprintStorePath != "" {
+		if _, err := fingerprint.Open(*fingerprintStorePath); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --fingerprints %s: %v\n", *fingerprintStorePath, err)
+			os.Exit(1)
+		}
+	}
+
 	// Extract all flag values once for performance and consistency
 	flags := extractAllFlags(flagPointers{
 		// Boolean flags
@@ -1139,6 +1198,12 @@ func main() {
 		disableIPTypes:     disableIPTypes,
 	})
 
+	// --sanitize-metadata writes files; it has no web or stdin form.
+	if *sanitizeMetadata && (flags.webMode || *stdinMode || flags.inputFile == "-") {
+		fmt.Fprintf(os.Stderr, "Error: --sanitize-metadata cannot be used with --web or --stdin\n")
+		os.Exit(1)
+	}
+
 	// Handle web mode early - validate flags and start web server if requested
 	if flags.webMode {
 		if err := handleWebMode(flags.webPort, flags.webBind, flag.Args(), flags.inputFile, flags.configFile, flags.suppressionFile, flags.excludePatterns); err != nil {
@@ -1165,6 +1230,8 @@ func main() {
 			explain:          *explainFindings,
 			validatorBudgets: validatorBudgets,
 			limit:            *limitFlag,
+			edmIndex:         *edmIndexPath,
+			fingerprintStore: *fingerprintStorePath,
 		})
 		os.Exit(exitCode)
 	}
@@ -1292,6 +1359,37 @@ func main() {
 		os.Setenv("FERRET_DEBUG", "1")
 	}
 
+	// Clearing outputs is a redaction setting; alone it would do nothing.
+	if *clearNotebookOutputs && !finalConfig.enableRedaction {
+		fmt.Fprintf(os.Stderr, "Error: --clear-notebook-outputs requires --enable-redaction\n")
+		os.Exit(1)
+	}
+	// Parsed up front so a bad value stops the run before any file is scanned.
+	gpsPrecision, gpsErr := redactors.ParseGPSPrecision(*gpsPrecisionFlag)
+	if gpsErr != nil {
+		fmt.Fprintf(os.Stderr, "Error: --gps-precision: %v\n", gpsErr)
+		os.Exit(1)
+	}
+	if gpsPrecision.Enabled() && !finalConfig.enableRedaction {
+		fmt.Fprintf(os.Stderr, "Error: --gps-precision requires --enable-redaction\n")
+		os.Exit(1)
+	}
+
+	// --sanitize-metadata is a mode of its own: it writes a sanitized copy of
+	// every file and scans none, so scan and redaction flags have no meaning.
+	if *sanitizeMetadata {
+		for _, name := range []string{"enable-redaction", "preprocess-only", "p", "output"} {
+			if isFlagSet(name) {
+				fmt.Fprintf(os.Stderr, "Error: --sanitize-metadata cannot be used with --%s\n", name)
+				os.Exit(1)
+			}
+		}
+		if isFlagSet("format") && finalConfig.format != "text" && finalConfig.format != "json" {
+			fmt.Fprintf(os.Stderr, "Error: --sanitize-metadata supports --format text or json\n")
+			os.Exit(1)
+		}
+	}
+
 	// Validate flag combinations
 	if finalConfig.preprocessOnly {
 		// Check for incompatible flags with preprocess-only mode
@@ -1420,6 +1518,17 @@ func main() {
 		}
 	}
 
+	// --edm and --fingerprints name their file in the config, and naming the
+	// file asks for its check.
+	if *edmIndexPath != "" {
+		cfg, _ = core.WithEDMIndex(cfg, nil, *edmIndexPath)
+		enabledChecks["EDM_MATCH"] = true
+	}
+	if *fingerprintStorePath != "" {
+		cfg, _ = core.WithFingerprintStore(cfg, nil, *fingerprintStorePath)
+		enabledChecks["DOCUMENT_FINGERPRINT"] = true
+	}
+
 	standardValidators := core.BuildValidatorSet(enabledChecks, cfg, activeProfile)
 
 	// Set up dual path validation integration
@@ -1695,14 +1804,20 @@ func main() {
 
 	// Initialize file router with observability
 	fileRouter := router.NewFileRouter(finalConfig.debug)
+	fileRouter.SetPasswords(passwords)
+	if mainDebugObs != nil && passwords.Len() > 0 {
+		mainDebugObs.LogDetail("main", fmt.Sprintf("Loaded %d password rule(s) for encrypted documents", passwords.Len()))
+	}
 
 	if mainDebugObs != nil {
 		mainDebugObs.LogDetail("main", "Registering default preprocessors...")
 	}
 	router.RegisterDefaultPreprocessors(fileRouter)
 
-	// Router configuration: pass the redaction setting to preprocessors.
+	// Router configuration: pass the redaction setting and the row sample to
+	// preprocessors.
 	routerConfig := router.CreateRouterConfig(finalConfig.enableRedaction)
+	routerConfig["sample_rows"] = *sampleRows
 
 	if mainDebugObs != nil {
 		mainDebugObs.LogDetail("main", "Initializing preprocessors...")
@@ -1736,7 +1851,8 @@ func main() {
 		strategy := redactors.ParseRedactionStrategy(finalConfig.redactionStrategy)
 		var mgrErr error
 		redactionManager, _, mgrErr = core.NewDefaultRedactionManager(
-			finalConfig.redactionOutputDir, strategy, redactionObserver)
+			finalConfig.redactionOutputDir, strategy, redactionObserver,
+			core.RedactionOptions{ClearNotebookOutputs: *clearNotebookOutputs, GPSPrecision: gpsPrecision})
 		if mgrErr != nil {
 			fmt.Fprintf(os.Stderr, "Error creating redaction manager: %v\n", mgrErr)
 			os.Exit(1)
@@ -1804,6 +1920,19 @@ func main() {
 		Limit:           *limitFlag,
 	}
 
+	// A streaming format (jsonl) bound for stdout writes each file's findings as
+	// the worker pool produces them, so a consumer such as jq can act on them
+	// while the scan is still running. The stream is opened here, fed from the
+	// pool's per-file hook, and closed where the other formats call Format, once
+	// the stats and the not-examined list exist. An output file and pre-commit
+	// mode take the ordinary Format path.
+	var findingStream formatters.FindingStream
+	if sf, ok := formatter.(formatters.StreamingFormatter); ok && *outputFile == "" && precommitConfig == nil {
+		streamOptions := formatterOptions
+		streamOptions.StreamWriter = os.Stdout
+		findingStream = sf.NewStream(streamOptions)
+	}
+
 	// Process all files using parallel processing
 	var allMatches []detector.Match
 	processedFiles := 0
@@ -1930,6 +2059,16 @@ func main() {
 		skippedFiles++
 	}
 
+	// Handle sanitize mode - every file, so one that cannot be read or
+	// sanitized is reported rather than skipped.
+	if *sanitizeMetadata {
+		format := "text"
+		if finalConfig.format == "json" {
+			format = "json"
+		}
+		os.Exit(runSanitizeMetadata(filesToProcess, finalConfig.redactionOutputDir, format, os.Stdout, os.Stderr))
+	}
+
 	// Handle preprocess-only mode - exit early after preprocessing
 	if finalConfig.preprocessOnly {
 		err := processPreprocessOnly(supportedFiles, fileRouter, finalConfig)
@@ -1960,6 +2099,17 @@ func main() {
 			ValidatorBudgets:   validatorBudgets,
 			MaxLiveBytes:       maxLiveBytesVal,
 		}
+		if findingStream != nil {
+			var explainer explain.Explainer
+			if *explainFindings {
+				explainer = explain.NewSignalSynthesizer()
+			}
+			jobConfig.OnFileMatches = func(_ string, matches []detector.Match) {
+				// A write error is sticky in the stream and reported by Close
+				// below, so the scan is not aborted halfway through a file.
+				_ = streamFileMatches(findingStream, matches, suppressionManager, explainer, finalConfig.showSuppressed, time.Now())
+			}
+		}
 
 		// Show initial progress
 		if !finalConfig.debug {
@@ -2355,7 +2505,11 @@ func main() {
 
 	// Format and display results
 	var result string
-	if finalConfig.showSuppressed {
+	if findingStream != nil {
+		// The findings went out as the pool produced them; Close writes the
+		// not-examined records and the closing summary.
+		err = findingStream.Close(formatterOptions)
+	} else if finalConfig.showSuppressed {
 		result, err = formatter.Format(unsuppressedMatches, suppressedMatches, formatterOptions)
 	} else {
 		result, err = formatter.Format(unsuppressedMatches, nil, formatterOptions)
@@ -2613,7 +2767,7 @@ func getFilesToProcess(inputPath string, recursive bool, excludePatterns []strin
 			return nil, fmt.Errorf("path does not exist or is not accessible: %w", err)
 		}
 		if info.Mode().IsRegular() {
-			if info.Size() <= maxScanSize {
+			if withinScanSize(inputPath, info.Size(), enablePreprocessors) {
 				// Check if file is excluded
 				if isExcluded(inputPath, excludePatterns) {
 					result.SkippedFiles = append(result.SkippedFiles, SkippedFile{
@@ -2754,7 +2908,7 @@ func getFilesToProcess(inputPath string, recursive bool, excludePatterns []strin
 					continue // Skip gitignored files
 				}
 
-				if info.Size() <= maxScanSize {
+				if withinScanSize(cleanMatch, info.Size(), enablePreprocessors) {
 					filesToProcess = append(filesToProcess, cleanMatch)
 				} else if router.CanProcessType(cleanMatch, enablePreprocessors) {
 					// A processable type refused for size is a coverage LOSS, so it has
@@ -2805,7 +2959,7 @@ func getFilesToProcess(inputPath string, recursive bool, excludePatterns []strin
 	if fileInfo.Mode().IsRegular() {
 		// A file refused for size — see the identical decision on the glob path above
 		// and router.CanProcessType.
-		if fileInfo.Size() > maxScanSize {
+		if !withinScanSize(inputPath, fileInfo.Size(), enablePreprocessors) {
 			reason := tooLargeReason()
 			if router.CanProcessType(inputPath, enablePreprocessors) {
 				result.UnexaminedFiles = append(result.UnexaminedFiles, SkippedFile{
@@ -2953,7 +3107,7 @@ func getFilesToProcess(inputPath string, recursive bool, excludePatterns []strin
 			// Only add regular files
 			if info.Mode().IsRegular() {
 				// Check file size
-				if info.Size() <= maxScanSize {
+				if withinScanSize(cleanWalkPath, info.Size(), enablePreprocessors) {
 					filesToProcess = append(filesToProcess, cleanWalkPath)
 				} else if router.CanProcessType(cleanWalkPath, enablePreprocessors) {
 					// Processable type refused for size: a coverage loss, so it must reach
@@ -2990,7 +3144,7 @@ func getFilesToProcess(inputPath string, recursive bool, excludePatterns []strin
 				// Collected rather than decided here: whether to follow depends on
 				// whether the target is also reachable as a real file in this same
 				// walk, which is not known until the walk ends.
-				d, resolved, reason := classifySymlink(cleanWalkPath, cleanPath, maxScanSize)
+				d, resolved, reason := classifySymlink(cleanWalkPath, cleanPath, maxScanSize, enablePreprocessors)
 				symlinkCands = append(symlinkCands, symlinkCandidate{
 					linkPath: cleanWalkPath,
 					resolved: resolved,
@@ -3239,6 +3393,16 @@ func validateWebModeFlags() error {
 		troubleshooting = append(troubleshooting, "Web mode does not support redaction features")
 	}
 
+	if isFlagSet("clear-notebook-outputs") {
+		incompatibleFlags = append(incompatibleFlags, "--clear-notebook-outputs")
+		troubleshooting = append(troubleshooting, "Web mode does not support redaction features")
+	}
+
+	if isFlagSet("gps-precision") {
+		incompatibleFlags = append(incompatibleFlags, "--gps-precision")
+		troubleshooting = append(troubleshooting, "Web mode does not support redaction features")
+	}
+
 	// Check for CLI help/info flags
 	if isFlagSet("help") {
 		incompatibleFlags = append(incompatibleFlags, "--help")
@@ -3284,6 +3448,21 @@ func validateWebModeFlags() error {
 		troubleshooting = append(troubleshooting, "Web mode does not apply the CLI live-bytes memory cap")
 	}
 
+	if isFlagSet("edm") {
+		incompatibleFlags = append(incompatibleFlags, "--edm")
+		troubleshooting = append(troubleshooting, "Web mode reads an exact data match index from validators.edm.index in the config file")
+	}
+
+	if isFlagSet("fingerprints") {
+		incompatibleFlags = append(incompatibleFlags, "--fingerprints")
+		troubleshooting = append(troubleshooting, "Web mode reads a document fingerprint store from validators.fingerprint.store in the config file")
+	}
+
+	if isFlagSet("sample-rows") {
+		incompatibleFlags = append(incompatibleFlags, "--sample-rows")
+		troubleshooting = append(troubleshooting, "Web mode reads every row of Parquet and Avro files")
+	}
+
 	// If any incompatible flags were found, return an error
 	if len(incompatibleFlags) > 0 {
 		errorMsg := fmt.Sprintf("--web flag cannot be used with the following flags: %s\n\n", strings.Join(incompatibleFlags, ", "))
@@ -276,6 +276,7 @@ func TestEveryCauseMapsToItsOwnFormatterCause(t *testing.T) {
 		{causeCutShort, formatters.NotExaminedCutShort},
 		{causeNotFollowed, formatters.NotExaminedNotFollowed},
 		{causeTooLarge, formatters.NotExaminedTooLarge},
+		{causeEncrypted, formatters.NotExaminedEncrypted},
 	}
 
 	seen := make(map[formatters.NotExaminedCause]unscannedCause, len(cases))
@@ -313,7 +314,7 @@ func TestSymlinkDisclosureCarriesItsCause(t *testing.T) {
 		t.Skipf("cannot create symlinks here: %v", err)
 	}
 
-	d, resolved, reason := classifySymlink(link, root, 100*1024*1024)
+	d, resolved, reason := classifySymlink(link, root, 100*1024*1024, true)
 	if d != symlinkDisclose {
 		t.Fatalf("fixture: disposition = %v, want symlinkDisclose", d)
 	}
@@ -346,7 +347,7 @@ func TestSymlinkDisclosureCarriesItsCause(t *testing.T) {
 func TestBreakdownAccountsForEveryCause(t *testing.T) {
 	allCauses := []unscannedCause{
 		causeUnreadable, causeUnparseable, causeNoText, causeCutShort,
-		causeNotFollowed, causeTooLarge,
+		causeNotFollowed, causeTooLarge, causeEncrypted,
 	}
 
 	// More than inlineDetailLimit entries, so the collapsed tally path is taken.
@@ -527,3 +528,43 @@ func TestDiscoveryLimitIsTheRouterLimit(t *testing.T) {
 			"filed as an unsupported type", maxScanSize, router.MaxFileSize)
 	}
 }
+
+// A Parquet or Avro file is read a page or block at a time within a text limit of its
+// own, so discovery admits it at any size, by every route, and the rows past the limit
+// are disclosed by the extractor as not scanned. Refused here, a data-lake export over
+// 100MB was never opened at all. Without preprocessors nothing reads it, and the size
+// refusal stands.
+func TestOversizeColumnarFileIsAdmitted(t *testing.T) {
+	dir := t.TempDir()
+	big := filepath.Join(dir, "events.avro")
+	f, err := os.Create(big) // #nosec G304 -- test temp dir
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString("Obj\x01"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Truncate(maxScanSize + 1); err != nil {
+		t.Fatal(err)
+	}
+	_ = f.Close()
+
+	for _, input := range []string{big, dir, filepath.Join(dir, "*.avro")} {
+		res, err := getFilesToProcess(input, true, nil, nil, true)
+		if err != nil {
+			t.Fatalf("%s: %v", input, err)
+		}
+		if len(res.FilesToProcess) != 1 || len(res.UnexaminedFiles) != 0 {
+			t.Errorf("%s: FilesToProcess = %v, UnexaminedFiles = %v; want the file queued",
+				input, res.FilesToProcess, pathsOf(res.UnexaminedFiles))
+		}
+	}
+
+	res, err := getFilesToProcess(big, false, nil, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.FilesToProcess) != 0 {
+		t.Errorf("queued without preprocessors: %v", res.FilesToProcess)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/awslabs/ferret-scan/v2/internal/core"
+)
+
+// Exit codes of --sanitize-metadata: 2, as for diff, edm and fingerprint,
+// means the tool could not do its job for at least one file.
+const (
+	sanitizeExitOK    = 0
+	sanitizeExitError = 2
+)
+
+// sanitizeReport is one file's line of the --sanitize-metadata report. It
+// names the fields removed, never their values.
+type sanitizeReport struct {
+	File          string              `json:"file"`
+	SanitizedFile string              `json:"sanitized_file,omitempty"`
+	Removed       []core.RemovedField `json:"removed,omitempty"`
+	Skipped       string              `json:"skipped,omitempty"`
+	Error         string              `json:"error,omitempty"`
+}
+
+// runSanitizeMetadata implements --sanitize-metadata: it writes a copy of
+// every file under outputDir with its personal metadata removed and reports
+// the fields removed from each, as text or, for format "json", as a JSON
+// array. A file of a format no redactor can sanitize is skipped; one that
+// could not be sanitized fails the run, and leaves no copy.
+func runSanitizeMetadata(files []string, outputDir, format string, stdout, stderr io.Writer) int {
+	reports := make([]sanitizeReport, 0, len(files))
+	sanitized, skipped, failed := 0, 0, 0
+	for _, f := range files {
+		r := sanitizeReport{File: f}
+		result, err := core.SanitizeFile(core.SanitizeConfig{FilePath: f, OutputDir: outputDir, LogWriter: io.Discard})
+		switch {
+		case errors.Is(err, core.ErrSanitizeNotSupported):
+			r.Skipped = err.Error()
+			skipped++
+		case err != nil:
+			r.Error = err.Error()
+			failed++
+		default:
+			r.SanitizedFile = result.SanitizedFilePath
+			r.Removed = result.Removed
+			sanitized++
+		}
+		reports = append(reports, r)
+	}
+
+	if format == "json" {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(reports); err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return sanitizeExitError
+		}
+	} else {
+		for _, r := range reports {
+			switch {
+			case r.Skipped != "":
+				fmt.Fprintf(stdout, "Skipped %s: %s\n", r.File, r.Skipped)
+			case r.Error != "":
+				fmt.Fprintf(stderr, "Error: %s\n", r.Error)
+			default:
+				fmt.Fprintf(stdout, "Sanitized %s -> %s: removed %d field(s)\n", r.File, r.SanitizedFile, len(r.Removed))
+				for _, f := range r.Removed {
+					if f.Part != "" {
+						fmt.Fprintf(stdout, "  %s (%s)\n", f.Field, f.Part)
+					} else {
+						fmt.Fprintf(stdout, "  %s\n", f.Field)
+					}
+				}
+			}
+		}
+		fmt.Fprintf(stdout, "%d file(s) sanitized, %d skipped, %d failed\n", sanitized, skipped, failed)
+	}
+	if failed > 0 {
+		return sanitizeExitError
+	}
+	return sanitizeExitOK
+}
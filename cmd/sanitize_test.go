@@ -0,0 +1,68 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSanitizeMetadataReport(t *testing.T) {
+	dir := t.TempDir()
+	plan := filepath.Join(dir, "plan.docx")
+	writeDocx(t, plan, "Quarterly plan")
+	notes := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(notes, []byte("Author: Jane Quincy\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "out")
+
+	var stdout, stderr bytes.Buffer
+	if code := runSanitizeMetadata([]string{plan, notes}, out, "text", &stdout, &stderr); code != sanitizeExitOK {
+		t.Fatalf("exit %d, stderr %q", code, stderr.String())
+	}
+	for _, want := range []string{"Sanitized " + plan + " -> ", "Skipped " + notes + ": metadata sanitization is not supported for .txt files", "1 file(s) sanitized, 1 skipped, 0 failed"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("stdout = %q, want %q", stdout.String(), want)
+		}
+	}
+
+	stdout.Reset()
+	if code := runSanitizeMetadata([]string{plan}, out, "json", &stdout, &stderr); code != sanitizeExitOK {
+		t.Fatalf("json: exit %d, stderr %q", code, stderr.String())
+	}
+	var reports []sanitizeReport
+	if err := json.Unmarshal(stdout.Bytes(), &reports); err != nil {
+		t.Fatalf("json report %q: %v", stdout.String(), err)
+	}
+	if len(reports) != 1 || reports[0].File != plan || !strings.HasPrefix(reports[0].SanitizedFile, out) {
+		t.Errorf("reports = %+v", reports)
+	}
+}
+
+// A file of a supported format that cannot be sanitized fails the run and
+// leaves no copy.
+func TestSanitizeMetadataFailure(t *testing.T) {
+	dir := t.TempDir()
+	broken := filepath.Join(dir, "broken.docx")
+	// A zip signature and nothing after it: an Office document cut short.
+	if err := os.WriteFile(broken, []byte("PK\x03\x04\x14\x00\x00\x00\x08\x00"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "out")
+	var stdout, stderr bytes.Buffer
+	if code := runSanitizeMetadata([]string{broken}, out, "text", &stdout, &stderr); code != sanitizeExitError {
+		t.Errorf("exit %d, want %d", code, sanitizeExitError)
+	}
+	if !strings.Contains(stderr.String(), "broken.docx") || !strings.Contains(stdout.String(), "0 file(s) sanitized, 0 skipped, 1 failed") {
+		t.Errorf("stdout %q, stderr %q", stdout.String(), stderr.String())
+	}
+	if entries, _ := os.ReadDir(out); len(entries) != 0 {
+		t.Errorf("a failed file left output: %v", entries)
+	}
+}
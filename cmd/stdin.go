@@ -43,6 +43,12 @@ type stdinScanInputs struct {
 	validatorBudgets map[string]execguard.ValidatorBudget
 	// limit is the --limit value (max findings to display). 0 = unlimited.
 	limit int
+	// edmIndex is the --edm index path, or "" when unset. main() has already
+	// checked that it opens.
+	edmIndex string
+	// fingerprintStore is the --fingerprints store path, or "" when unset,
+	// checked by main() in the same way.
+	fingerprintStore string
 }
 
 // runStdinScan is the entry point for stdin scanning. It mirrors the
@@ -181,6 +187,13 @@ func runStdinScan(in stdinScanInputs) int {
 		return 1
 	}
 
+	if in.edmIndex != "" {
+		cfg, checks = core.WithEDMIndex(cfg, checks, in.edmIndex)
+	}
+	if in.fingerprintStore != "" {
+		cfg, checks = core.WithFingerprintStore(cfg, checks, in.fingerprintStore)
+	}
+
 	scanCfg := core.ContentScanConfig{
 		VirtualPath:        in.stdinName,
 		Checks:             checks,
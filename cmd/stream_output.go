@@ -0,0 +1,51 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"time"
+
+	"github.com/awslabs/ferret-scan/v2/internal/detector"
+	"github.com/awslabs/ferret-scan/v2/internal/explain"
+	"github.com/awslabs/ferret-scan/v2/internal/formatters"
+	"github.com/awslabs/ferret-scan/v2/internal/suppressions"
+)
+
+// streamFileMatches hands one file's findings to a streaming formatter.
+//
+// It applies the same explanation pass and suppression split the buffered path
+// applies to the whole result set after the scan, and must stay in step with
+// it: the summary record is built from that later split, so a finding streamed
+// as active but counted there as suppressed would make the stream contradict
+// its own last line. Both consult the same SuppressionManager, whose lookup has
+// no side effects, so running it twice gives the same answer.
+//
+// Suppressed findings are only written with --show-suppressed, as in every
+// other format.
+func streamFileMatches(stream formatters.FindingStream, matches []detector.Match, sm *suppressions.SuppressionManager, explainer explain.Explainer, showSuppressed bool, now time.Time) error {
+	explain.Annotate(matches, explainer)
+
+	for _, match := range matches {
+		suppressed, rule := sm.IsSuppressed(match)
+		if !suppressed {
+			if err := stream.WriteMatch(match); err != nil {
+				return err
+			}
+			continue
+		}
+		if !showSuppressed {
+			continue
+		}
+		if err := stream.WriteSuppressed(detector.SuppressedMatch{
+			Match:        match,
+			SuppressedBy: rule.ID,
+			RuleReason:   rule.Reason,
+			ExpiresAt:    rule.ExpiresAt,
+			Expired:      rule.ExpiresAt != nil && now.After(*rule.ExpiresAt),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/awslabs/ferret-scan/v2/internal/detector"
+	"github.com/awslabs/ferret-scan/v2/internal/formatters"
+	"github.com/awslabs/ferret-scan/v2/internal/suppressions"
+)
+
+type recordingStream struct {
+	active     []detector.Match
+	suppressed []detector.SuppressedMatch
+}
+
+func (r *recordingStream) WriteMatch(m detector.Match) error {
+	r.active = append(r.active, m)
+	return nil
+}
+
+func (r *recordingStream) WriteSuppressed(s detector.SuppressedMatch) error {
+	r.suppressed = append(r.suppressed, s)
+	return nil
+}
+
+func (r *recordingStream) Close(formatters.FormatterOptions) error { return nil }
+
+// TestStreamFileMatches_AppliesSuppressions pins the streamed split to the one
+// the summary is built from: a suppressed finding must never be streamed as
+// active, and is written as suppressed only with --show-suppressed.
+func TestStreamFileMatches_AppliesSuppressions(t *testing.T) {
+	kept := detector.Match{Text: "a@example.com", Type: "EMAIL", Confidence: 70, Filename: "a.txt", LineNumber: 1}
+	silenced := detector.Match{Text: "b@example.com", Type: "EMAIL", Confidence: 70, Filename: "a.txt", LineNumber: 2}
+
+	sm := suppressions.NewSuppressionManager(filepath.Join(t.TempDir(), "sup.yaml"))
+	if err := sm.AddSuppression(silenced, "fixture", "test", nil); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+
+	for _, show := range []bool{false, true} {
+		rec := &recordingStream{}
+		if err := streamFileMatches(rec, []detector.Match{kept, silenced}, sm, nil, show, now); err != nil {
+			t.Fatal(err)
+		}
+		if len(rec.active) != 1 || rec.active[0].LineNumber != 1 {
+			t.Errorf("show=%v: active = %+v, want only line 1", show, rec.active)
+		}
+		wantSuppressed := 0
+		if show {
+			wantSuppressed = 1
+		}
+		if len(rec.suppressed) != wantSuppressed {
+			t.Errorf("show=%v: %d suppressed records, want %d", show, len(rec.suppressed), wantSuppressed)
+		}
+		if show && (rec.suppressed[0].RuleReason != "fixture" || rec.suppressed[0].Expired) {
+			t.Errorf("suppressed record = %+v", rec.suppressed[0])
+		}
+	}
+}
@@ -0,0 +1,20 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import "io"
+
+// subcommand runs one `ferret-scan <name>` command with the arguments after its
+// name and returns the process exit code.
+type subcommand func(args []string, stdout, stderr io.Writer) int
+
+// subcommands are dispatched on the first argument, before any scan flag is
+// defined, so each one owns its flag set and `ferret-scan diff -h` describes
+// diff rather than the scan. A file that happens to share a subcommand's name
+// is still scannable as ./name or with --file.
+var subcommands = map[string]subcommand{
+	"diff":        runDiff,
+	"edm":         runEDM,
+	"fingerprint": runFingerprint,
+}
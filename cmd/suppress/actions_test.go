@@ -0,0 +1,195 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/awslabs/ferret-scan/v2/internal/suppressions"
+)
+
+func writeFixture(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+	return path
+}
+
+// TestMergeJSONReportsConflicts pins the JSON contract of the merge action: a
+// conflicting decision is listed with both sides under snake_case keys, and the
+// report reaches the caller so main can exit with exitConflicts.
+func TestMergeJSONReportsConflicts(t *testing.T) {
+	base := writeFixture(t, "base.yaml", suppressionFixture)
+	other := writeFixture(t, "other.yaml", `version: "1.0"
+rules:
+  - id: SUP-00000001
+    hash: abc123def456
+    reason: real customer data
+    enabled: false
+    created_at: 2026-02-01T00:00:00Z
+  - id: SUP-00000007
+    hash: feedface
+    reason: new fixture
+    enabled: true
+    created_at: 2026-02-01T00:00:00Z
+`)
+
+	manager := suppressions.NewSuppressionManager(base)
+	var report *suppressions.MergeReport
+	out := captureStdout(t, func() {
+		var err error
+		report, err = mergeSuppressions(manager, other, true)
+		if err != nil {
+			t.Errorf("mergeSuppressions: %v", err)
+		}
+	})
+	if report == nil || len(report.Conflicts) != 1 {
+		t.Fatalf("report = %+v, want one conflict", report)
+	}
+
+	var got struct {
+		Added     []string `json:"added"`
+		Conflicts []struct {
+			Existing struct {
+				ID      string `json:"id"`
+				Enabled bool   `json:"enabled"`
+			} `json:"existing"`
+			Incoming struct {
+				Reason string `json:"reason"`
+			} `json:"incoming"`
+		} `json:"conflicts"`
+	}
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("merge output is not JSON: %v\n%s", err, out)
+	}
+	if len(got.Added) != 1 || got.Added[0] != "SUP-00000007" {
+		t.Errorf("added = %v", got.Added)
+	}
+	if len(got.Conflicts) != 1 || got.Conflicts[0].Existing.ID != "SUP-00000001" ||
+		!got.Conflicts[0].Existing.Enabled || got.Conflicts[0].Incoming.Reason != "real customer data" {
+		t.Errorf("conflicts = %+v", got.Conflicts)
+	}
+}
+
+func TestMergeRejectsUnreadableFile(t *testing.T) {
+	manager := suppressions.NewSuppressionManager(writeFixture(t, "base.yaml", suppressionFixture))
+	if _, err := mergeSuppressions(manager, filepath.Join(t.TempDir(), "missing.yaml"), false); err == nil {
+		t.Fatal("merge of a missing file reported success")
+	}
+}
+
+func TestImportFromJSONResult(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sup.yaml")
+	input := writeFixture(t, "result.json", `{"results": [
+		{"text": "[HIDDEN]", "type": "SSN", "filename": "a.txt", "line_number": 3, "confidence": 95,
+		 "suppression_hash": "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"},
+		{"text": "[HIDDEN]", "type": "EMAIL", "filename": "b.txt", "line_number": 4, "confidence": 60}
+	]}`)
+
+	manager := suppressions.NewSuppressionManager(path)
+	out := captureStdout(t, func() {
+		if err := importSuppressions(manager, input, "", "ci", false, true); err != nil {
+			t.Errorf("importSuppressions: %v", err)
+		}
+	})
+
+	var report importReport
+	if err := json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("import output is not JSON: %v\n%s", err, out)
+	}
+	if len(report.Added) != 1 || report.Unidentified != 1 || report.Format != "json" {
+		t.Errorf("report = %+v, want one rule added and one unidentified finding", report)
+	}
+	rules := suppressions.NewSuppressionManager(path).ListSuppressions()
+	if len(rules) != 1 || rules[0].Enabled || rules[0].CreatedBy != "ci" || rules[0].Reason != "Imported from scan result" {
+		t.Errorf("rules = %+v", rules)
+	}
+}
+
+func TestStaleAndExpiringJSON(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	path := writeFixture(t, "sup.yaml", `version: "1.0"
+rules:
+  - id: SUP-00000001
+    hash: aaaa
+    reason: old
+    enabled: true
+    created_at: 2025-01-01T00:00:00Z
+    last_seen_at: 2026-01-01T00:00:00Z
+  - id: SUP-00000002
+    hash: bbbb
+    reason: lapsing
+    enabled: true
+    created_at: 2026-05-20T00:00:00Z
+    expires_at: 2026-06-05T00:00:00Z
+`)
+	manager := suppressions.NewSuppressionManager(path)
+
+	out := captureStdout(t, func() {
+		if err := staleSuppressions(manager, 90, now, true); err != nil {
+			t.Errorf("staleSuppressions: %v", err)
+		}
+	})
+	var stale []struct {
+		ID            string `json:"id"`
+		DaysSinceSeen int    `json:"days_since_seen"`
+	}
+	if err := json.Unmarshal([]byte(out), &stale); err != nil {
+		t.Fatalf("stale output is not JSON: %v\n%s", err, out)
+	}
+	if len(stale) != 1 || stale[0].ID != "SUP-00000001" || stale[0].DaysSinceSeen != 151 {
+		t.Errorf("stale = %+v", stale)
+	}
+
+	out = captureStdout(t, func() {
+		if err := expiringSuppressions(manager, 14, now, true); err != nil {
+			t.Errorf("expiringSuppressions: %v", err)
+		}
+	})
+	var expiring []struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal([]byte(out), &expiring); err != nil {
+		t.Fatalf("expiring output is not JSON: %v\n%s", err, out)
+	}
+	if len(expiring) != 1 || expiring[0].ID != "SUP-00000002" {
+		t.Errorf("expiring = %+v", expiring)
+	}
+
+	// An empty listing is still an array, so a CI job can test its length.
+	out = captureStdout(t, func() {
+		if err := expiringSuppressions(manager, 1, now, true); err != nil {
+			t.Errorf("expiringSuppressions: %v", err)
+		}
+	})
+	if strings.TrimSpace(out) != "[]" {
+		t.Errorf("empty expiring listing = %q, want []", out)
+	}
+}
+
+func TestReviewAction(t *testing.T) {
+	path := writeFixture(t, "sup.yaml", suppressionFixture)
+	manager := suppressions.NewSuppressionManager(path)
+	now := time.Date(2026, 6, 1, 9, 30, 0, 0, time.UTC)
+
+	out := captureStdout(t, func() {
+		if err := reviewSuppression(manager, "SUP-00000001", "sam", now, false); err != nil {
+			t.Errorf("reviewSuppression: %v", err)
+		}
+	})
+	if !strings.Contains(out, "SUP-00000001") {
+		t.Errorf("output = %q", out)
+	}
+	rule := suppressions.NewSuppressionManager(path).ListSuppressions()[0]
+	if rule.ReviewedBy != "sam" || rule.ReviewedAt == nil || !rule.ReviewedAt.Equal(now) {
+		t.Errorf("review not recorded: %+v", rule)
+	}
+}
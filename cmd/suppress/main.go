@@ -4,39 +4,68 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
 	"sort"
+	"time"
 
+	"github.com/awslabs/ferret-scan/v2/internal/scanresult"
 	"github.com/awslabs/ferret-scan/v2/internal/suppressions"
 )
 
+// Exit codes. exitConflicts lets a CI job tell "merged, but a human has to
+// resolve conflicting decisions" apart from a failed run.
+const (
+	exitError     = 1
+	exitConflicts = 2
+)
+
+const validActions = "list, remove, cleanup, enable, merge, import, stale, review, expiring"
+
 func main() {
 	var (
 		suppressionFile = flag.String("suppression-file", "", "Path to suppression configuration file (default: .ferret-scan-suppressions.yaml)")
-		action          = flag.String("action", "", "Action to perform: list, remove, cleanup, enable")
-		id              = flag.String("id", "", "Suppression rule ID (for remove action)")
+		action          = flag.String("action", "", "Action to perform: "+validActions)
+		id              = flag.String("id", "", "Suppression rule ID (for remove and review actions)")
 		hash            = flag.String("hash", "", "Finding hash (for enable action)")
-		reason          = flag.String("reason", "", "Reason for suppression (for enable action)")
+		reason          = flag.String("reason", "", "Reason for suppression (for enable and import actions)")
+		format          = flag.String("format", "text", "Output format: text or json")
+		mergeFile       = flag.String("merge-file", "", "Suppression file to merge into --suppression-file (for merge action)")
+		input           = flag.String("input", "", "JSON or SARIF scan result to import rules from (for import action)")
+		enabled         = flag.Bool("enabled", false, "Create imported rules enabled (for import action; default disabled, like --generate-suppressions)")
+		createdBy       = flag.String("created-by", "", "Value recorded as created_by on imported rules (for import action)")
+		days            = flag.Int("days", 90, "Report rules not seen for at least this many days (for stale action)")
+		reviewer        = flag.String("reviewer", "", "Name recorded as reviewed_by (for review action)")
+		within          = flag.Int("within", 14, "Report rules expiring within this many days (for expiring action)")
 	)
 	flag.Parse()
 
 	if *action == "" {
 		fmt.Println("Error: --action is required")
-		fmt.Println("Usage: ferret-suppress --action <list|remove|cleanup|enable> [options]")
-		os.Exit(1)
+		fmt.Println("Usage: ferret-suppress --action <list|remove|cleanup|enable|merge|import|stale|review|expiring> [options]")
+		os.Exit(exitError)
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Printf("Error: Unknown format '%s' (want text or json)\n", *format)
+		os.Exit(exitError)
 	}
+	asJSON := *format == "json"
 
 	manager := suppressions.NewSuppressionManager(*suppressionFile)
 
 	switch *action {
 	case "list":
+		if asJSON {
+			exitOnError(printJSON(ruleViews(manager.ListSuppressions())))
+			return
+		}
 		listSuppressions(manager)
 	case "remove":
 		if *id == "" {
 			fmt.Println("Error: --id is required for remove action")
-			os.Exit(1)
+			os.Exit(exitError)
 		}
 		removeSuppression(manager, *id)
 	case "cleanup":
@@ -44,16 +73,67 @@ func main() {
 	case "enable":
 		if *hash == "" {
 			fmt.Println("Error: --hash is required for enable action")
-			os.Exit(1)
+			os.Exit(exitError)
 		}
 		enableSuppression(manager, *hash, *reason)
+	case "merge":
+		if *mergeFile == "" {
+			fmt.Println("Error: --merge-file is required for merge action")
+			os.Exit(exitError)
+		}
+		report, err := mergeSuppressions(manager, *mergeFile, asJSON)
+		exitOnError(err)
+		if len(report.Conflicts) > 0 {
+			os.Exit(exitConflicts)
+		}
+	case "import":
+		if *input == "" {
+			fmt.Println("Error: --input is required for import action")
+			os.Exit(exitError)
+		}
+		exitOnError(importSuppressions(manager, *input, *reason, *createdBy, *enabled, asJSON))
+	case "stale":
+		if *days < 0 {
+			fmt.Println("Error: --days must not be negative")
+			os.Exit(exitError)
+		}
+		exitOnError(staleSuppressions(manager, *days, time.Now(), asJSON))
+	case "review":
+		if *id == "" || *reviewer == "" {
+			fmt.Println("Error: --id and --reviewer are required for review action")
+			os.Exit(exitError)
+		}
+		exitOnError(reviewSuppression(manager, *id, *reviewer, time.Now(), asJSON))
+	case "expiring":
+		if *within < 0 {
+			fmt.Println("Error: --within must not be negative")
+			os.Exit(exitError)
+		}
+		exitOnError(expiringSuppressions(manager, *within, time.Now(), asJSON))
 	default:
 		fmt.Printf("Error: Unknown action '%s'\n", *action)
-		fmt.Println("Valid actions: list, remove, cleanup, enable")
-		os.Exit(1)
+		fmt.Println("Valid actions: " + validActions)
+		os.Exit(exitError)
+	}
+}
+
+// exitOnError reports err in the same shape as the original actions and exits.
+func exitOnError(err error) {
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(exitError)
 	}
 }
 
+// printJSON writes v to stdout as indented JSON. The JSON shapes below are the
+// CI contract of each action, so they use fixed, snake_case keys and empty
+// arrays rather than null.
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
 func listSuppressions(manager *suppressions.SuppressionManager) {
 	rules := manager.ListSuppressions()
 	if len(rules) == 0 {
@@ -123,3 +203,245 @@ func shortHash(hash string) string {
 	}
 	return hash[:8]
 }
+
+// ruleView is the JSON shape of a rule, keyed like the YAML file. The rule type
+// itself has no JSON tags because the web UI consumes its default encoding.
+type ruleView struct {
+	ID         string                     `json:"id"`
+	Kind       string                     `json:"kind,omitempty"`
+	Hash       string                     `json:"hash,omitempty"`
+	Match      *suppressions.PatternMatch `json:"match,omitempty"`
+	Reason     string                     `json:"reason"`
+	Enabled    bool                       `json:"enabled"`
+	CreatedBy  string                     `json:"created_by,omitempty"`
+	CreatedAt  time.Time                  `json:"created_at"`
+	LastSeenAt *time.Time                 `json:"last_seen_at,omitempty"`
+	ExpiresAt  *time.Time                 `json:"expires_at,omitempty"`
+	ReviewedBy string                     `json:"reviewed_by,omitempty"`
+	ReviewedAt *time.Time                 `json:"reviewed_at,omitempty"`
+	Metadata   map[string]string          `json:"metadata,omitempty"`
+}
+
+func newRuleView(r suppressions.SuppressionRule) ruleView {
+	return ruleView{
+		ID: r.ID, Kind: r.Kind, Hash: r.Hash, Match: r.Match,
+		Reason: r.Reason, Enabled: r.Enabled,
+		CreatedBy: r.CreatedBy, CreatedAt: r.CreatedAt,
+		LastSeenAt: r.LastSeenAt, ExpiresAt: r.ExpiresAt,
+		ReviewedBy: r.ReviewedBy, ReviewedAt: r.ReviewedAt,
+		Metadata: r.Metadata,
+	}
+}
+
+func ruleViews(rules []suppressions.SuppressionRule) []ruleView {
+	out := make([]ruleView, 0, len(rules))
+	for _, r := range rules {
+		out = append(out, newRuleView(r))
+	}
+	return out
+}
+
+// mergeView is the JSON shape of the merge action.
+type mergeView struct {
+	Added      []string          `json:"added"`
+	Duplicates []string          `json:"duplicates"`
+	Renumbered map[string]string `json:"renumbered"`
+	Conflicts  []conflictView    `json:"conflicts"`
+}
+
+type conflictView struct {
+	Existing ruleView `json:"existing"`
+	Incoming ruleView `json:"incoming"`
+}
+
+func newMergeView(report *suppressions.MergeReport) mergeView {
+	view := mergeView{
+		Added:      report.Added,
+		Duplicates: report.Duplicates,
+		Renumbered: report.Renumbered,
+		Conflicts:  make([]conflictView, 0, len(report.Conflicts)),
+	}
+	if view.Renumbered == nil {
+		view.Renumbered = map[string]string{}
+	}
+	for _, c := range report.Conflicts {
+		view.Conflicts = append(view.Conflicts, conflictView{
+			Existing: newRuleView(c.Existing),
+			Incoming: newRuleView(c.Incoming),
+		})
+	}
+	return view
+}
+
+// mergeSuppressions merges the rules of path into the manager's file. The
+// caller decides the exit code from the report's conflicts.
+func mergeSuppressions(manager *suppressions.SuppressionManager, path string, asJSON bool) (*suppressions.MergeReport, error) {
+	other, err := suppressions.LoadConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+	report, err := manager.Merge(other)
+	if err != nil {
+		return nil, fmt.Errorf("merge failed: %w", err)
+	}
+	if asJSON {
+		return report, printJSON(newMergeView(report))
+	}
+
+	fmt.Printf("Merged %s: %d added, %d duplicate, %d conflicting\n",
+		path, len(report.Added), len(report.Duplicates), len(report.Conflicts))
+	renamed := make([]string, 0, len(report.Renumbered))
+	for from := range report.Renumbered {
+		renamed = append(renamed, from)
+	}
+	sort.Strings(renamed)
+	for _, from := range renamed {
+		fmt.Printf("Renumbered %s -> %s (ID already in use)\n", from, report.Renumbered[from])
+	}
+	for _, c := range report.Conflicts {
+		fmt.Printf("Conflict: incoming %s differs from existing %s; kept existing\n", c.IncomingID, c.ExistingID)
+		fmt.Printf("  existing: enabled=%t expires=%s reason=%q\n", c.Existing.Enabled, formatExpiry(c.Existing.ExpiresAt), c.Existing.Reason)
+		fmt.Printf("  incoming: enabled=%t expires=%s reason=%q\n", c.Incoming.Enabled, formatExpiry(c.Incoming.ExpiresAt), c.Incoming.Reason)
+	}
+	return report, nil
+}
+
+// importReport is the JSON shape of the import action.
+type importReport struct {
+	Input        string   `json:"input"`
+	Format       string   `json:"format"`
+	Added        []string `json:"added"`
+	Existing     int      `json:"existing"`
+	Suppressed   int      `json:"suppressed"`
+	Unidentified int      `json:"unidentified"`
+}
+
+// importSuppressions adds a rule for every active finding in a saved scan
+// result. Findings the scan had already suppressed are not re-imported.
+func importSuppressions(manager *suppressions.SuppressionManager, input, reason, createdBy string, enabled, asJSON bool) error {
+	result, err := scanresult.Load(input)
+	if err != nil {
+		return err
+	}
+	if reason == "" {
+		reason = "Imported from scan result"
+	}
+
+	findings := make([]suppressions.ImportedFinding, 0, len(result.Findings))
+	for _, f := range result.Findings {
+		findings = append(findings, suppressions.ImportedFinding{
+			Hash:       f.Hash,
+			Type:       f.Type,
+			Filename:   f.Filename,
+			LineNumber: f.LineNumber,
+			Confidence: f.Confidence,
+		})
+	}
+	added, existing, err := manager.ImportFindings(findings, reason, createdBy, enabled)
+	if err != nil {
+		return fmt.Errorf("import failed: %w", err)
+	}
+
+	report := importReport{
+		Input:        input,
+		Format:       result.Format,
+		Added:        added,
+		Existing:     existing,
+		Suppressed:   result.Suppressed,
+		Unidentified: result.Unidentified,
+	}
+	if asJSON {
+		return printJSON(report)
+	}
+	state := "disabled"
+	if enabled {
+		state = "enabled"
+	}
+	fmt.Printf("Imported %d %s rules from %s (%s); %d findings already had a rule\n",
+		len(added), state, input, result.Format, existing)
+	if result.Unidentified > 0 {
+		fmt.Printf("Skipped %d findings with no suppression identity (result written without suppression_hash; re-scan, or use --show-match --verbose)\n", result.Unidentified)
+	}
+	return nil
+}
+
+// ruleAge is one rule in the stale report with its age in whole days.
+type ruleAge struct {
+	ruleView
+	DaysSinceSeen int `json:"days_since_seen"`
+}
+
+// staleSuppressions lists rules not seen for at least days days, the deletion
+// candidates for a shared suppression file. It only reports; removal stays a
+// deliberate `--action remove`.
+func staleSuppressions(manager *suppressions.SuppressionManager, days int, now time.Time, asJSON bool) error {
+	rules := manager.StaleRules(time.Duration(days)*24*time.Hour, now)
+	out := make([]ruleAge, 0, len(rules))
+	for _, rule := range rules {
+		seen := rule.CreatedAt
+		if rule.LastSeenAt != nil {
+			seen = *rule.LastSeenAt
+		}
+		out = append(out, ruleAge{ruleView: newRuleView(rule), DaysSinceSeen: int(now.Sub(seen).Hours() / 24)})
+	}
+	if asJSON {
+		return printJSON(out)
+	}
+
+	if len(out) == 0 {
+		fmt.Printf("No suppression rules unseen for %d days or more.\n", days)
+		return nil
+	}
+	fmt.Printf("Found %d suppression rules unseen for %d days or more:\n\n", len(out), days)
+	for _, r := range out {
+		last := "never"
+		if r.LastSeenAt != nil {
+			last = r.LastSeenAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("%s  last seen %s (%d days)  %s\n", r.ID, last, r.DaysSinceSeen, r.Reason)
+	}
+	return nil
+}
+
+// reviewSuppression stamps reviewed_by/reviewed_at on one rule.
+func reviewSuppression(manager *suppressions.SuppressionManager, id, reviewer string, now time.Time, asJSON bool) error {
+	if err := manager.ReviewSuppression(id, reviewer, now); err != nil {
+		return fmt.Errorf("reviewing suppression: %w", err)
+	}
+	if asJSON {
+		return printJSON(map[string]string{
+			"id":          id,
+			"reviewed_by": reviewer,
+			"reviewed_at": now.UTC().Format(time.RFC3339),
+		})
+	}
+	fmt.Printf("Marked suppression rule %s as reviewed by %s\n", id, reviewer)
+	return nil
+}
+
+// expiringSuppressions lists rules that lapse within the next days days, so
+// owners can renew or drop them before findings resurface in CI.
+func expiringSuppressions(manager *suppressions.SuppressionManager, days int, now time.Time, asJSON bool) error {
+	rules := manager.ExpiringWithin(time.Duration(days)*24*time.Hour, now)
+	if asJSON {
+		return printJSON(ruleViews(rules))
+	}
+
+	if len(rules) == 0 {
+		fmt.Printf("No suppression rules expire within %d days.\n", days)
+		return nil
+	}
+	fmt.Printf("Found %d suppression rules expiring within %d days:\n\n", len(rules), days)
+	for _, rule := range rules {
+		fmt.Printf("%s  expires %s  %s\n", rule.ID, rule.ExpiresAt.Format("2006-01-02 15:04:05"), rule.Reason)
+	}
+	return nil
+}
+
+// formatExpiry renders an optional expiry for text output.
+func formatExpiry(t *time.Time) string {
+	if t == nil {
+		return "never"
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
@@ -8,6 +8,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/awslabs/ferret-scan/v2/internal/router"
 )
 
 // Symlink handling for directory walks.
@@ -63,12 +65,13 @@ const (
 // scanRoot is the directory the user asked to scan; it bounds what "inside the tree"
 // means. sizeLimit mirrors the walk's own cap and is applied to the TARGET's size,
 // because Lstat reports the length of the link text rather than of the file it names —
-// so a link to a 200MB file looked like a 30-byte entry.
+// so a link to a 200MB file looked like a 30-byte entry. A target the walk would admit
+// at any size (router.SizeExempt) is exempt here too.
 //
 // resolved is the absolute target path, returned so the caller can deduplicate against
 // files it has already queued. reason is human-facing and payload-free: it names the
 // condition, never file contents.
-func classifySymlink(linkPath, scanRoot string, sizeLimit int64) (d symlinkDisposition, resolved, reason string) {
+func classifySymlink(linkPath, scanRoot string, sizeLimit int64, enablePreprocessors bool) (d symlinkDisposition, resolved, reason string) {
 	// EvalSymlinks resolves the whole chain and returns an error for a dangling link or
 	// a loop (ELOOP), which is why loop protection needs no separate counter here.
 	target, err := filepath.EvalSymlinks(linkPath)
@@ -97,7 +100,7 @@ func classifySymlink(linkPath, scanRoot string, sizeLimit int64) (d symlinkDispo
 		// these for the same reason.
 		return symlinkDisclose, absTarget, "symlink target is not a regular file"
 	}
-	if sizeLimit > 0 && info.Size() > sizeLimit {
+	if sizeLimit > 0 && info.Size() > sizeLimit && !router.SizeExempt(absTarget, enablePreprocessors) {
 		return symlinkDisclose, absTarget,
 			fmt.Sprintf("symlink target is too large (%d bytes, limit %d)", info.Size(), sizeLimit)
 	}
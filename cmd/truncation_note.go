@@ -16,9 +16,11 @@ import (
 //
 // Measured on a 3-finding scan at --limit 1: text prints "... and 2 more
 // findings", json/yaml carry `truncated: true` with the real total, junit uses
-// <system-out>, sarif uses run properties, gitlab-sast uses the scan block. Only
-// CSV has nowhere to put it — a comment line is not CSV syntax and an extra data
-// row inflates the count consumers read.
+// <system-out>, sarif uses run properties, gitlab-sast uses the scan block, html
+// states "the first N of M" in its summary, and jsonl's closing summary record
+// carries `truncated` with the real total. Only CSV has nowhere to put it — a
+// comment line is not CSV syntax and an extra data row inflates the count
+// consumers read.
 var formatsDisclosingTruncationInBand = map[string]bool{
 	"text":        true,
 	"json":        true,
@@ -26,6 +28,8 @@ var formatsDisclosingTruncationInBand = map[string]bool{
 	"junit":       true,
 	"sarif":       true,
 	"gitlab-sast": true,
+	"html":        true,
+	"jsonl":       true,
 }
 
 // truncationNote returns the out-of-band message announcing that --limit dropped
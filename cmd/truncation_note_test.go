@@ -135,7 +135,7 @@ func TestTruncationNoteOnlyForFormatsThatCannotSayItThemselves(t *testing.T) {
 	matches := noteMatches(36, 40)
 	opts := noteOptions("high", "medium", "low")
 
-	for _, format := range []string{"text", "json", "yaml", "junit", "sarif", "gitlab-sast"} {
+	for _, format := range []string{"text", "json", "yaml", "junit", "sarif", "gitlab-sast", "html", "jsonl"} {
 		if got := truncationNote(matches, opts, 3, format); got != "" {
 			t.Errorf("%s already discloses truncation in the report itself, so the "+
 				"out-of-band note is a duplicate line immediately after it; got %q",
@@ -12,6 +12,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/awslabs/ferret-scan/v2/internal/encryption"
 	"github.com/awslabs/ferret-scan/v2/internal/formatters"
 	"github.com/awslabs/ferret-scan/v2/internal/parallel"
 )
@@ -72,6 +73,14 @@ const (
 	// An UNPROCESSABLE type refused for size gets no entry here at all; it is a
 	// genuine skip, because no finding was ever possible from it.
 	causeTooLarge
+	// causeEncrypted is a password-protected document that stayed locked: no
+	// password from --password-file opened it, or its scheme (legacy Office
+	// 97-2003 encryption) is detected but not decrypted.
+	//
+	// Its own cause because the remedy is neither chmod nor a repaired file: it is
+	// a password, and the operator can only supply one for a document they know
+	// was skipped for that reason. Under "cannot parse" it read as corruption.
+	causeEncrypted
 )
 
 func (c unscannedCause) String() string {
@@ -91,6 +100,8 @@ func (c unscannedCause) String() string {
 		return "symlink not followed"
 	case causeTooLarge:
 		return "file too large to scan"
+	case causeEncrypted:
+		return "encrypted"
 	default:
 		return "unknown"
 	}
@@ -139,6 +150,8 @@ func toFormatterNotExamined(entries []unscannedEntry) []formatters.NotExaminedFi
 			cause = formatters.NotExaminedNotFollowed
 		case causeTooLarge:
 			cause = formatters.NotExaminedTooLarge
+		case causeEncrypted:
+			cause = formatters.NotExaminedEncrypted
 		default:
 			cause = formatters.NotExaminedUnreadable
 		}
@@ -288,6 +301,12 @@ func classifyExtractionWarning(reason string) unscannedCause {
 func classifyReason(reason string) unscannedCause {
 	l := strings.ToLower(reason)
 	switch {
+	// Matched on the sentinel's own text, which the router's error for a locked
+	// document always leads with, rather than on the word "encrypted" anywhere:
+	// a PDF library's "encrypted PDF: invalid password" is a parse failure from a
+	// different layer.
+	case strings.Contains(reason, encryption.ErrEncrypted.Error()+": "):
+		return causeEncrypted
 	case strings.Contains(l, "permission denied"),
 		strings.Contains(l, "unreadable"),
 		strings.Contains(l, "no such file"):
@@ -353,6 +372,11 @@ func collectUnscanned(
 	for _, fd := range failed {
 		cause := classifyReason(fd.Reason)
 		detail := humanizeReason(fd.FilePath, fd.Reason)
+		if cause == causeEncrypted {
+			// The cause line already says "encrypted"; the detail says which scheme
+			// and what was tried.
+			detail = strings.TrimPrefix(detail, encryption.ErrEncrypted.Error()+": ")
+		}
 		if cause == causeUnparseable && detail == "" {
 			// The internal reason ("all preprocessors failed for file: <path>") carries
 			// no information once the path is stripped, so look at the bytes.
@@ -35,6 +35,8 @@ var validFormats = map[string]bool{
 	"junit":       true,
 	"gitlab-sast": true,
 	"sarif":       true,
+	"html":        true,
+	"jsonl":       true,
 }
 
 // validRedactionStrategies mirrors redactors.ParseRedactionStrategy.
@@ -64,8 +66,11 @@ var validCheckNames = map[string]bool{
 	"CLOUD_RESOURCES":       true,
 	"CREDIT_CARD":           true,
 	"DATE_OF_BIRTH":         true,
+	"DOCUMENT_FINGERPRINT":  true,
 	"DRIVERS_LICENSE":       true,
+	"EDM_MATCH":             true,
 	"EMAIL":                 true,
+	"FILE_TYPE_MISMATCH":    true,
 	"INTELLECTUAL_PROPERTY": true,
 	"IP_ADDRESS":            true,
 	"MEDICAL_ID":            true,
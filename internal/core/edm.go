@@ -0,0 +1,16 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"github.com/awslabs/ferret-scan/v2/internal/config"
+	"github.com/awslabs/ferret-scan/v2/internal/validators/edmmatch"
+)
+
+// WithEDMIndex returns the configuration and checks of a scan that also looks
+// for the values of the EDM index at path: cfg naming the index as
+// validators.edm.index, and checks with EDM_MATCH added. cfg is not modified.
+func WithEDMIndex(cfg *config.Config, checks []string, path string) (*config.Config, []string) {
+	return withValidatorSetting(cfg, checks, edmmatch.ConfigSection, edmmatch.IndexKey, path, edmmatch.CheckType)
+}
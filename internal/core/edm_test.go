@@ -0,0 +1,92 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/awslabs/ferret-scan/v2/internal/config"
+	"github.com/awslabs/ferret-scan/v2/internal/edm"
+)
+
+func TestWithEDMIndexLeavesTheSharedConfigAlone(t *testing.T) {
+	shared := &config.Config{Validators: map[string]map[string]interface{}{
+		"edm":          {"index": "a.edm"},
+		"social_media": {"platform_patterns": "x"},
+	}}
+	cfg, checks := WithEDMIndex(shared, []string{"SSN"}, "b.edm")
+	if cfg.Validators["edm"]["index"] != "b.edm" || cfg.Validators["social_media"]["platform_patterns"] != "x" {
+		t.Errorf("validators = %v", cfg.Validators)
+	}
+	if shared.Validators["edm"]["index"] != "a.edm" {
+		t.Errorf("the shared config was changed: %v", shared.Validators)
+	}
+	if !reflect.DeepEqual(checks, []string{"SSN", "EDM_MATCH"}) {
+		t.Errorf("checks = %v, want EDM_MATCH added", checks)
+	}
+	if _, checks := WithEDMIndex(nil, []string{"all"}, "b.edm"); !reflect.DeepEqual(checks, []string{"all"}) {
+		t.Errorf("checks = %v, want all left alone", checks)
+	}
+}
+
+// An exact match stays HIGH in content the document analysis takes for test
+// data, which costs every other finding 30 points.
+func TestEDMMatchKeepsItsBandInTestData(t *testing.T) {
+	ix, _, err := edm.Build(strings.NewReader("name,ssn\nJane Doe,123-45-6789\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "customers.edm")
+	if err := ix.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+	// Five of the eight test-data phrases the document analysis counts, and no
+	// commas: a table earns a boost that would hide the penalty.
+	content := "test data for a test case with sample data and dummy data and placeholder values\n" +
+		"Jane Doe 123-45-6789\n"
+	cfg, checks := WithEDMIndex(nil, []string{"SSN"}, path)
+	result, err := ScanContent(content, ContentScanConfig{Checks: checks, Config: cfg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var edmMatches int
+	for _, m := range result.Matches {
+		if m.Type != "EDM_MATCH" {
+			continue
+		}
+		edmMatches++
+		if m.Confidence < 90 {
+			t.Errorf("%q reported at %v, want HIGH", m.Text, m.Confidence)
+		}
+	}
+	if edmMatches == 0 {
+		t.Fatalf("no EDM_MATCH in %+v", result.Matches)
+	}
+}
+
+// A profile that configures other validators keeps the index the global
+// configuration named: BuildValidatorSet configures every validator again with
+// the profile's sections alone.
+func TestProfileWithoutAnEDMSectionKeepsTheIndex(t *testing.T) {
+	ix, _, err := edm.Build(strings.NewReader("ssn\n123-45-6789\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "customers.edm")
+	if err := ix.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+	cfg, _ := WithEDMIndex(nil, nil, path)
+	profile := &config.Profile{Validators: map[string]map[string]interface{}{
+		"social_media": {"platform_patterns": map[string]interface{}{}},
+	}}
+	set := BuildValidatorSet(map[string]bool{"EDM_MATCH": true}, cfg, profile)
+	matches, err := set["EDM_MATCH"].ValidateContent("SSN 123-45-6789", "a.txt")
+	if err != nil || len(matches) != 1 {
+		t.Errorf("ValidateContent = %v, %v; want the SSN", matches, err)
+	}
+}
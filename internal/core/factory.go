@@ -13,7 +13,9 @@ import (
 	"github.com/awslabs/ferret-scan/v2/internal/validators/cloudresources"
 	"github.com/awslabs/ferret-scan/v2/internal/validators/creditcard"
 	"github.com/awslabs/ferret-scan/v2/internal/validators/dob"
+	"github.com/awslabs/ferret-scan/v2/internal/validators/docfingerprint"
 	"github.com/awslabs/ferret-scan/v2/internal/validators/driverslicense"
+	"github.com/awslabs/ferret-scan/v2/internal/validators/edmmatch"
 	"github.com/awslabs/ferret-scan/v2/internal/validators/email"
 	"github.com/awslabs/ferret-scan/v2/internal/validators/intellectualproperty"
 	"github.com/awslabs/ferret-scan/v2/internal/validators/ipaddress"
@@ -26,6 +28,7 @@ import (
 	"github.com/awslabs/ferret-scan/v2/internal/validators/secrets"
 	"github.com/awslabs/ferret-scan/v2/internal/validators/socialmedia"
 	"github.com/awslabs/ferret-scan/v2/internal/validators/ssn"
+	"github.com/awslabs/ferret-scan/v2/internal/validators/typemismatch"
 	"github.com/awslabs/ferret-scan/v2/internal/validators/vin"
 )
 
@@ -39,8 +42,11 @@ var validatorConstructors = map[string]func() detector.Validator{
 	"CLOUD_RESOURCES":       func() detector.Validator { return cloudresources.NewValidator() },
 	"CREDIT_CARD":           func() detector.Validator { return creditcard.NewValidator() },
 	"DATE_OF_BIRTH":         func() detector.Validator { return dob.NewValidator() },
+	"DOCUMENT_FINGERPRINT":  func() detector.Validator { return docfingerprint.NewValidator() },
 	"DRIVERS_LICENSE":       func() detector.Validator { return driverslicense.NewValidator() },
+	"EDM_MATCH":             func() detector.Validator { return edmmatch.NewValidator() },
 	"EMAIL":                 func() detector.Validator { return email.NewValidator() },
+	"FILE_TYPE_MISMATCH":    func() detector.Validator { return typemismatch.NewValidator() },
 	"PHONE":                 func() detector.Validator { return phone.NewValidator() },
 	"IP_ADDRESS":            func() detector.Validator { return ipaddress.NewValidator() },
 	"MEDICAL_ID":            func() detector.Validator { return medicalid.NewValidator() },
@@ -101,14 +107,34 @@ func BuildValidatorSet(enabledChecks map[string]bool, cfg *config.Config, profil
 	//
 	// Each Configure returns early when its own section is absent, so passing a
 	// profile-only config overrides just the sections the profile actually sets
-	// and leaves the global settings for the others in place.
+	// and leaves the global settings for the others in place. EDM_MATCH is the
+	// exception: a config without an index clears it, so the global index is
+	// passed in for a profile that names none.
 	if profile != nil && profile.Validators != nil {
-		configureConfigurableValidators(result, &config.Config{Validators: profile.Validators})
+		configureConfigurableValidators(result, &config.Config{Validators: withGlobalEDMSection(profile.Validators, cfg)})
 	}
 
 	return result
 }
 
+// withGlobalEDMSection returns the profile's validator sections, with the
+// global edm section added when the profile has none of its own.
+func withGlobalEDMSection(sections map[string]map[string]interface{}, cfg *config.Config) map[string]map[string]interface{} {
+	if _, own := sections[edmmatch.ConfigSection]; own || cfg == nil {
+		return sections
+	}
+	global, ok := cfg.Validators[edmmatch.ConfigSection]
+	if !ok {
+		return sections
+	}
+	merged := make(map[string]map[string]interface{}, len(sections)+1)
+	for name, section := range sections {
+		merged[name] = section
+	}
+	merged[edmmatch.ConfigSection] = global
+	return merged
+}
+
 // configureConfigurableValidators hands cfg to every validator that reads the
 // `validators:` config block. Keeping this in one place is what stops the
 // global and profile passes from drifting apart again.
@@ -122,4 +148,10 @@ func configureConfigurableValidators(result map[string]detector.Validator, cfg *
 	if v, ok := result["SOCIAL_MEDIA"].(*socialmedia.Validator); ok {
 		v.Configure(cfg)
 	}
+	if v, ok := result["EDM_MATCH"].(*edmmatch.Validator); ok {
+		v.Configure(cfg)
+	}
+	if v, ok := result["DOCUMENT_FINGERPRINT"].(*docfingerprint.Validator); ok {
+		v.Configure(cfg)
+	}
 }
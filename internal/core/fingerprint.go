@@ -0,0 +1,17 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"github.com/awslabs/ferret-scan/v2/internal/config"
+	"github.com/awslabs/ferret-scan/v2/internal/validators/docfingerprint"
+)
+
+// WithFingerprintStore returns the configuration and checks of a scan that
+// also looks for copies of the documents registered in the fingerprint store at
+// path: cfg naming the store as validators.fingerprint.store, and checks with
+// DOCUMENT_FINGERPRINT added. cfg is not modified.
+func WithFingerprintStore(cfg *config.Config, checks []string, path string) (*config.Config, []string) {
+	return withValidatorSetting(cfg, checks, docfingerprint.ConfigSection, docfingerprint.StoreKey, path, docfingerprint.CheckType)
+}
@@ -0,0 +1,50 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/awslabs/ferret-scan/v2/internal/fingerprint"
+)
+
+// A confidential document that reads like test data — a QA plan full of "test
+// case" and "sample data" — is still reported at the band its similarity earns.
+func TestDocumentFingerprintKeepsItsBandInTestData(t *testing.T) {
+	plan := "this test data plan covers the test case for every sample data set and the " +
+		"dummy data we load before release and the placeholder accounts finance keeps " +
+		"for the northern region migration scheduled after the merger closes in march"
+	s := fingerprint.NewStore()
+	if _, err := s.Add("qa-plan.docx", plan); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "docs.fp")
+	if err := s.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, checks := WithFingerprintStore(nil, []string{"SSN"}, path)
+	if !reflect.DeepEqual(checks, []string{"SSN", "DOCUMENT_FINGERPRINT"}) {
+		t.Errorf("checks = %v, want DOCUMENT_FINGERPRINT added", checks)
+	}
+	result, err := ScanContent(plan+"\n", ContentScanConfig{Checks: checks, Config: cfg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found int
+	for _, m := range result.Matches {
+		if m.Type != "DOCUMENT_FINGERPRINT" {
+			continue
+		}
+		found++
+		if m.Confidence != 100 {
+			t.Errorf("a whole copy reported at %v, want 100", m.Confidence)
+		}
+	}
+	if found == 0 {
+		t.Fatalf("no DOCUMENT_FINGERPRINT in %+v", result.Matches)
+	}
+}
@@ -16,11 +16,15 @@ import (
 	"github.com/awslabs/ferret-scan/v2/internal/parallel"
 	"github.com/awslabs/ferret-scan/v2/internal/redactors"
 	"github.com/awslabs/ferret-scan/v2/internal/redactors/audio"
+	"github.com/awslabs/ferret-scan/v2/internal/redactors/heif"
 	"github.com/awslabs/ferret-scan/v2/internal/redactors/image"
 	"github.com/awslabs/ferret-scan/v2/internal/redactors/legacyole"
+	"github.com/awslabs/ferret-scan/v2/internal/redactors/markup"
+	"github.com/awslabs/ferret-scan/v2/internal/redactors/notebook"
 	"github.com/awslabs/ferret-scan/v2/internal/redactors/office"
 	"github.com/awslabs/ferret-scan/v2/internal/redactors/pdf"
 	"github.com/awslabs/ferret-scan/v2/internal/redactors/plaintext"
+	"github.com/awslabs/ferret-scan/v2/internal/redactors/sqlite"
 	"github.com/awslabs/ferret-scan/v2/internal/redactors/video"
 	"github.com/awslabs/ferret-scan/v2/internal/router"
 	"github.com/awslabs/ferret-scan/v2/internal/validators"
@@ -42,6 +46,26 @@ type RedactConfig struct {
 	// LogWriter receives payload-free progress output; defaults to io.Discard
 	// here so in-process callers never leak to stderr.
 	LogWriter io.Writer
+	// ClearNotebookOutputs empties the outputs of every Jupyter notebook cell
+	// holding a HIGH confidence finding, besides redacting the finding.
+	ClearNotebookOutputs bool
+	// GPSPrecision keeps a reported GPS position in image and video metadata,
+	// coarsened to this many decimal places, instead of removing it. Zero
+	// removes it.
+	GPSPrecision redactors.GPSPrecision
+}
+
+// RedactionOptions are the redactor settings beyond the strategy.
+type RedactionOptions struct {
+	// ClearNotebookOutputs empties the outputs of every Jupyter notebook cell
+	// holding a HIGH confidence finding (--clear-notebook-outputs).
+	ClearNotebookOutputs bool
+	// GPSPrecision coarsens reported GPS positions in image and video metadata
+	// instead of removing them (--gps-precision). Zero removes them.
+	GPSPrecision redactors.GPSPrecision
+	// SanitizeMetadata makes the Office redactor remove a package's personal
+	// metadata whether or not it was reported (--sanitize-metadata).
+	SanitizeMetadata bool
 }
 
 // RedactResult reports the outcome of a redaction.
@@ -92,7 +116,8 @@ func RedactFile(cfg RedactConfig) (*RedactResult, error) {
 	// is the SAME shared factory the CLI uses (NewDefaultRedactionManager), so
 	// manager config and the set of registered redactors live in one place.
 	strategy := redactors.ParseRedactionStrategy(cfg.Strategy)
-	redactionManager, outputManager, err := NewDefaultRedactionManager(cfg.OutputDir, strategy, observer)
+	redactionManager, outputManager, err := NewDefaultRedactionManager(cfg.OutputDir, strategy, observer,
+		RedactionOptions{ClearNotebookOutputs: cfg.ClearNotebookOutputs, GPSPrecision: cfg.GPSPrecision})
 	if err != nil {
 		return nil, err
 	}
@@ -135,8 +160,16 @@ func RedactFile(cfg RedactConfig) (*RedactResult, error) {
 	// match to redact. For a clean file (no findings), copy the original to the
 	// output path so callers always have a file to hand off — a clean document
 	// is safe to share as-is.
+	// Findings about the whole file (detector.FileLevelKey) have no value to
+	// remove, so a file with only those is clean for this purpose.
+	redactable := 0
+	for _, m := range matches {
+		if !m.IsFileLevel() {
+			redactable++
+		}
+	}
 	if _, statErr := os.Stat(redactedPath); statErr != nil {
-		if len(matches) == 0 {
+		if redactable == 0 {
 			if copyErr := copyFile(cfg.FilePath, redactedPath); copyErr != nil {
 				return nil, fmt.Errorf("failed to copy clean file to output: %w", copyErr)
 			}
@@ -147,7 +180,7 @@ func RedactFile(cfg RedactConfig) (*RedactResult, error) {
 
 	return &RedactResult{
 		RedactedFilePath: redactedPath,
-		RedactionCount:   len(matches),
+		RedactionCount:   redactable,
 		Strategy:         strategy.String(),
 		Matches:          matches,
 	}, nil
@@ -187,7 +220,7 @@ func copyFile(src, dst string) error {
 // It returns the manager and its output manager (callers need the latter to
 // compute mirrored output paths).
 func NewDefaultRedactionManager(outputDir string, strategy redactors.RedactionStrategy,
-	observer observability.Observer) (*redactors.RedactionManager, *redactors.OutputStructureManager, error) {
+	observer observability.Observer, opts RedactionOptions) (*redactors.RedactionManager, *redactors.OutputStructureManager, error) {
 
 	outputManager, err := redactors.NewOutputStructureManager(outputDir, observer)
 	if err != nil {
@@ -207,15 +240,28 @@ func NewDefaultRedactionManager(outputDir string, strategy redactors.RedactionSt
 		})
 
 	officeRedactor := office.NewOfficeRedactor(outputManager, observer)
+	officeRedactor.SetSanitizeMetadata(opts.SanitizeMetadata)
+	notebookRedactor := notebook.NewNotebookRedactor(outputManager, observer)
+	notebookRedactor.SetClearOutputs(opts.ClearNotebookOutputs)
+	imageRedactor := image.NewImageMetadataRedactor(outputManager, observer)
+	imageRedactor.SetGPSPrecision(opts.GPSPrecision)
+	heifRedactor := heif.NewHEIFRedactor(outputManager, observer)
+	heifRedactor.SetGPSPrecision(opts.GPSPrecision)
+	videoRedactor := video.NewVideoRedactor(outputManager, observer)
+	videoRedactor.SetGPSPrecision(opts.GPSPrecision)
 
 	for _, r := range []redactors.Redactor{
 		plaintext.NewPlainTextRedactor(outputManager, observer),
+		markup.NewMarkupRedactor(outputManager, observer),
+		notebookRedactor,
 		pdf.NewPDFRedactor(outputManager, observer),
 		officeRedactor,
 		legacyole.NewLegacyOLERedactor(outputManager, observer),
-		image.NewImageMetadataRedactor(outputManager, observer),
+		sqlite.NewSQLiteRedactor(outputManager, observer),
+		imageRedactor,
+		heifRedactor,
 		audio.NewAudioRedactor(outputManager, observer),
-		video.NewVideoRedactor(outputManager, observer),
+		videoRedactor,
 	} {
 		if err := manager.RegisterRedactor(r); err != nil {
 			return nil, nil, fmt.Errorf("failed to register redactor %s: %w", r.GetName(), err)
@@ -0,0 +1,312 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/awslabs/ferret-scan/v2/internal/detector"
+	"github.com/awslabs/ferret-scan/v2/internal/observability"
+	"github.com/awslabs/ferret-scan/v2/internal/preprocessors"
+	"github.com/awslabs/ferret-scan/v2/internal/redactors"
+	"github.com/awslabs/ferret-scan/v2/internal/router"
+)
+
+// ErrSanitizeNotSupported is returned, wrapped, by SanitizeFile for a format
+// whose redactor cannot remove metadata.
+var ErrSanitizeNotSupported = errors.New("metadata sanitization is not supported")
+
+// SanitizeConfig configures a single-file metadata sanitization.
+type SanitizeConfig struct {
+	// FilePath is the file to sanitize.
+	FilePath string
+	// OutputDir is the base directory the sanitized copy is written under,
+	// mirroring the input's path as redaction does.
+	OutputDir string
+	// LogWriter receives payload-free progress output; defaults to stderr.
+	LogWriter io.Writer
+}
+
+// RemovedField is one metadata field a sanitization removed.
+type RemovedField struct {
+	// Source is the extractor that read the field ("office_metadata",
+	// "image_metadata", ...), or "office_package" for what the Office
+	// package holds outside its properties.
+	Source string `json:"source"`
+	// Field is the field's name as the metadata extractor reports it:
+	// "Author", "Custom_ClientName", "GPS_Coordinates", or "RevisionIDs" and
+	// "PrinterName" for an Office package.
+	Field string `json:"field"`
+	// Part is the package part an Office package field was removed from.
+	Part string `json:"part,omitempty"`
+}
+
+// SanitizeResult reports the outcome of a sanitization.
+type SanitizeResult struct {
+	// SanitizedFilePath is the path of the written copy.
+	SanitizedFilePath string
+	// Removed lists the fields whose values are gone from the copy, in the
+	// order the extractors report them. Never the values.
+	Removed []RemovedField
+}
+
+// sanitizers are the redactors that can remove a file's metadata, by name.
+// The Office redactor removes the package's personal properties itself (see
+// office.SetSanitizeMetadata) and the image redactor strips all metadata on
+// every run, so neither is given values; the others overwrite values in place
+// and are given one match per personal field.
+var sanitizers = map[string]bool{
+	"office_redactor":         false,
+	"image_metadata_redactor": false,
+	"legacy_ole_redactor":     true,
+	"heif_metadata_redactor":  true,
+	"audio_metadata_redactor": true,
+	"video_metadata_redactor": true,
+}
+
+// personalFieldNames are the parts of a metadata field name that mark it as
+// personal, matched case-insensitively: who made, edited or owns the file,
+// where it was made, and on what device. A field is personal when its name
+// contains one of them; technical fields (dimensions, codecs, dates, counts)
+// are not.
+var personalFieldNames = []string{
+	"author", "creator", "lastmodifiedby", "company", "manager", "template",
+	"hyperlinkbase", "custom_", "owner", "artist", "performer", "composer",
+	"conductor", "publisher", "copyright", "rights", "studio", "engineer",
+	"director", "producer", "byline", "credit", "captionwriter", "contact",
+	"gps", "coordinates", "location", "city", "country", "sublocation",
+	"cameramake", "cameramodel", "lensmodel", "serial", "software",
+	"hostcomputer", "encodedby", "xmp", "iptc",
+}
+
+// personalExactNames are personal field names too short to match as parts:
+// EXIF's camera make and model.
+var personalExactNames = map[string]bool{"make": true, "model": true}
+
+// fileSystemFields describe the file rather than anything in it, so they are
+// neither removable nor removed.
+var fileSystemFields = map[string]bool{"FileSize": true, "FileModTime": true, "MimeType": true}
+
+// minOverwriteLength is the shortest value the legacy OLE redactor is asked to
+// overwrite. It overwrites a value wherever it occurs in the file, so a shorter
+// one would hit unrelated text; the field is left and the file refused.
+const minOverwriteLength = 4
+
+// metadataField is one "Name: value" line of a metadata section.
+type metadataField struct {
+	source, name, value string
+}
+
+func (f metadataField) personal() bool {
+	name := strings.ToLower(f.name)
+	if personalExactNames[name] {
+		return true
+	}
+	if strings.Contains(name, "makernote") {
+		return false
+	}
+	for _, p := range personalFieldNames {
+		if strings.Contains(name, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// SanitizeFile writes a copy of the file at cfg.FilePath with its personal
+// metadata removed, whatever a scan would report: authors and editors,
+// company, template, custom properties, revision IDs and printer names of
+// Office documents, and EXIF, XMP and GPS of images and media. It uses the
+// redactor of the file's format, as RedactFile does.
+//
+// The copy is read back with the metadata extractors a scan uses. A personal
+// field still holding its value fails the sanitization, and no copy is left.
+// Formats without a metadata-capable redactor (PDF, text, archives) are an
+// error.
+func SanitizeFile(cfg SanitizeConfig) (*SanitizeResult, error) {
+	logWriter := resolveLogWriter(cfg.LogWriter)
+	observer := observability.NewStandardObserver(observability.ObservabilityMetrics, logWriter)
+
+	manager, outputManager, err := NewDefaultRedactionManager(cfg.OutputDir, redactors.RedactionSimple, observer,
+		RedactionOptions{SanitizeMetadata: true})
+	if err != nil {
+		return nil, err
+	}
+	redactor, err := manager.GetRedactorForFile(cfg.FilePath)
+	if err != nil {
+		return nil, fmt.Errorf("%w for %s files", ErrSanitizeNotSupported, extensionOf(cfg.FilePath))
+	}
+	byValue, ok := sanitizers[redactor.GetName()]
+	if !ok {
+		return nil, fmt.Errorf("%w for %s files", ErrSanitizeNotSupported, extensionOf(cfg.FilePath))
+	}
+
+	fileRouter := router.NewFileRouter(false)
+	router.RegisterDefaultPreprocessors(fileRouter)
+	fileRouter.InitializePreprocessors(router.CreateRouterConfig(false))
+
+	before, err := readMetadataFields(fileRouter, cfg.FilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the metadata of %s: %w", cfg.FilePath, err)
+	}
+
+	outputPath, err := outputManager.CreateMirroredPath(cfg.FilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute output path: %w", err)
+	}
+
+	var matches []detector.Match
+	if byValue {
+		matches = personalMatches(before, redactor.GetName() == "legacy_ole_redactor")
+	}
+	var structural []RemovedField
+	if byValue && len(matches) == 0 {
+		// Nothing personal to overwrite; these redactors refuse a file they find
+		// no metadata region in, so pass the file through as RedactFile does a
+		// clean one.
+		if err := copyFile(cfg.FilePath, outputPath); err != nil {
+			return nil, fmt.Errorf("failed to copy %s to output: %w", cfg.FilePath, err)
+		}
+	} else {
+		result, err := redactor.RedactDocument(cfg.FilePath, outputPath, matches, redactors.RedactionSimple)
+		if err == nil && (result == nil || !result.Success) {
+			err = errors.New("the redactor reported failure")
+			if result != nil && result.Error != nil {
+				err = result.Error
+			}
+		}
+		if err != nil {
+			_ = os.Remove(outputPath)
+			return nil, fmt.Errorf("could not sanitize %s: %w", cfg.FilePath, err)
+		}
+		for _, m := range result.RedactionMap {
+			field, _ := m.Metadata["metadata_field"].(string)
+			part, _ := m.Metadata["office_file"].(string)
+			if field != "" {
+				structural = append(structural, RemovedField{Source: "office_package", Field: field, Part: part})
+			}
+		}
+	}
+
+	// Verify by extracting the copy the way the original was read.
+	after, err := readMetadataFields(fileRouter, outputPath)
+	if err != nil {
+		_ = os.Remove(outputPath)
+		return nil, fmt.Errorf("could not read back the sanitized copy of %s: %w", cfg.FilePath, err)
+	}
+	remaining := map[metadataField]bool{}
+	for _, f := range after {
+		remaining[metadataField{name: f.name, value: f.value}] = true
+	}
+	var removed []RemovedField
+	var survived []string
+	for _, f := range before {
+		if fileSystemFields[f.name] {
+			continue
+		}
+		if !remaining[metadataField{name: f.name, value: f.value}] {
+			removed = append(removed, RemovedField{Source: f.source, Field: f.name})
+		} else if f.personal() {
+			survived = append(survived, f.name)
+		}
+	}
+	if len(survived) > 0 {
+		// Names, never the values: this message reaches stderr and every
+		// output format.
+		_ = os.Remove(outputPath)
+		return nil, fmt.Errorf("refusing to write %s: %d personal metadata field(s) still present after sanitizing: %s",
+			filepath.Base(outputPath), len(survived), strings.Join(uniqueSorted(survived), ", "))
+	}
+
+	return &SanitizeResult{
+		SanitizedFilePath: outputPath,
+		Removed:           append(removed, structural...),
+	}, nil
+}
+
+// readMetadataFields extracts path and returns the fields of its own metadata
+// sections. Sections of files embedded in it are left out: each is its own
+// file, sanitized or not by its own run.
+func readMetadataFields(fr *router.FileRouter, path string) ([]metadataField, error) {
+	if ok, reason := fr.CanProcessFile(path, true); !ok {
+		return nil, errors.New(reason)
+	}
+	pc, err := fr.CreateProcessingContext(path, false)
+	if err != nil {
+		return nil, err
+	}
+	content, err := fr.ProcessFileWithContext(path, pc)
+	if err != nil {
+		return nil, err
+	}
+	var fields []metadataField
+	for _, s := range content.Sections {
+		if s.Kind != preprocessors.SectionKindMetadata || strings.Contains(s.SourceFile, " -> ") {
+			continue
+		}
+		for _, line := range strings.Split(s.Text, "\n") {
+			name, value, ok := strings.Cut(line, ":")
+			name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+			if !ok || name == "" || value == "" || strings.ContainsAny(name, " \t") {
+				continue
+			}
+			fields = append(fields, metadataField{source: s.Type, name: name, value: value})
+		}
+	}
+	return fields, nil
+}
+
+// personalMatches returns one match per distinct value of a personal field,
+// for a redactor that overwrites values. A GPS field is typed GPS, which the
+// HEIF and video redactors clear structurally even when the extractor's
+// rendering of the position is not in the file's bytes.
+func personalMatches(fields []metadataField, skipShort bool) []detector.Match {
+	seen := map[string]bool{}
+	var matches []detector.Match
+	for _, f := range fields {
+		if !f.personal() || seen[f.value] || (skipShort && len(f.value) < minOverwriteLength) {
+			continue
+		}
+		seen[f.value] = true
+		matchType := "METADATA"
+		if name := strings.ToLower(f.name); strings.Contains(name, "gps") || strings.Contains(name, "coordinates") {
+			matchType = "GPS"
+		}
+		matches = append(matches, detector.Match{
+			Text:       f.value,
+			Type:       matchType,
+			Confidence: 100,
+			Validator:  "metadata",
+			Context:    detector.ContextInfo{FullLine: f.name + ": " + f.value},
+			Metadata:   map[string]any{"source": f.source, "field": f.name},
+		})
+	}
+	return matches
+}
+
+func extensionOf(path string) string {
+	if ext := strings.ToLower(filepath.Ext(path)); ext != "" {
+		return ext
+	}
+	return "extensionless"
+}
+
+func uniqueSorted(names []string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, n := range names {
+		if !seen[n] {
+			seen[n] = true
+			out = append(out, n)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
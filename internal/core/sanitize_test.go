@@ -0,0 +1,171 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// removedNames returns the fields of a result, as "Field" or "Field@part".
+func removedNames(r *SanitizeResult) map[string]bool {
+	names := map[string]bool{}
+	for _, f := range r.Removed {
+		if f.Part != "" {
+			names[f.Field+"@"+f.Part] = true
+		} else {
+			names[f.Field] = true
+		}
+	}
+	return names
+}
+
+// A Word document loses its personal properties, custom properties and
+// revision IDs, keeps its body even where the body names the author, and the
+// report names what went, read back from the copy.
+func TestSanitizeFileOffice(t *testing.T) {
+	dir := t.TempDir()
+	const author = "Jane Quincy"
+	in := writeDocxBody(t, dir, "plan.docx", "Prepared by "+author+" for the board.", map[string][]byte{
+		"docProps/core.xml": []byte(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties"` +
+			` xmlns:dc="http://purl.org/dc/elements/1.1/">` +
+			`<dc:title>Quarterly plan</dc:title><dc:creator>` + author + `</dc:creator><cp:lastModifiedBy>Omar Lind</cp:lastModifiedBy>` +
+			`</cp:coreProperties>`),
+		"docProps/app.xml": []byte(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<Properties xmlns="http://schemas.openxmlformats.org/officeDocument/2006/extended-properties">` +
+			`<Template>\\corp-fs01\templates\plan.dotx</Template><Company>Project Nightjar</Company>` +
+			`</Properties>`),
+		"docProps/custom.xml": []byte(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<Properties xmlns="http://schemas.openxmlformats.org/officeDocument/2006/custom-properties"` +
+			` xmlns:vt="http://schemas.openxmlformats.org/officeDocument/2006/docPropsVTypes">` +
+			`<property fmtid="{D5CDD505-2E9C-101B-9397-08002B2CF9AE}" pid="2" name="ClientName"><vt:lpwstr>Acme Holdings</vt:lpwstr></property>` +
+			`</Properties>`),
+		"word/settings.xml": []byte(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<w:settings xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">` +
+			`<w:rsids><w:rsidRoot w:val="00A1B2C3"/></w:rsids></w:settings>`),
+	})
+
+	out := filepath.Join(dir, "out")
+	result, err := SanitizeFile(SanitizeConfig{FilePath: in, OutputDir: out, LogWriter: io.Discard})
+	if err != nil {
+		t.Fatalf("SanitizeFile: %v", err)
+	}
+	if !strings.HasPrefix(result.SanitizedFilePath, out) {
+		t.Errorf("SanitizedFilePath = %s, want it under %s", result.SanitizedFilePath, out)
+	}
+	names := removedNames(result)
+	for _, want := range []string{"Author", "LastModifiedBy", "Company", "Template", "Custom_ClientName", "RevisionIDs@word/settings.xml"} {
+		if !names[want] {
+			t.Errorf("Removed = %+v, want %s", result.Removed, want)
+		}
+	}
+	if names["Title"] {
+		t.Errorf("Removed = %+v, the title is not personal and must be kept", result.Removed)
+	}
+	for _, gone := range []string{"Omar Lind", "corp-fs01", "Project Nightjar", "Acme Holdings", "00A1B2C3"} {
+		if hits := cleartextHits(t, result.SanitizedFilePath, gone); len(hits) > 0 {
+			t.Errorf("%q is still in %v", gone, hits)
+		}
+	}
+	body := string(readEntry(t, result.SanitizedFilePath, "word/document.xml"))
+	if !strings.Contains(body, "Prepared by "+author+" for the board.") {
+		t.Errorf("the body changed: %s", body)
+	}
+}
+
+// An image loses all of its metadata; the report names the fields.
+func TestSanitizeFileImage(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "photo.jpg")
+	if err := os.WriteFile(in, buildJPEGWithEXIF(t, "Site visit, Jane Quincy"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	result, err := SanitizeFile(SanitizeConfig{FilePath: in, OutputDir: filepath.Join(dir, "out"), LogWriter: io.Discard})
+	if err != nil {
+		t.Fatalf("SanitizeFile: %v", err)
+	}
+	if !removedNames(result)["ImageDescription"] {
+		t.Errorf("Removed = %+v, want ImageDescription", result.Removed)
+	}
+	data, err := os.ReadFile(result.SanitizedFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("Jane Quincy")) {
+		t.Error("the sanitized image still holds the description")
+	}
+}
+
+// An MP3 loses its personal ID3 frames and keeps the rest: the audio redactor
+// overwrites the values it is given, in place.
+func TestSanitizeFileAudio(t *testing.T) {
+	dir := t.TempDir()
+	var frames bytes.Buffer
+	for _, f := range []struct{ id, value string }{{"TIT2", "Morning call"}, {"TPE1", "Jane Quincy"}} {
+		payload := append([]byte{0x00}, f.value...)
+		frames.WriteString(f.id)
+		frames.Write(binary.BigEndian.AppendUint32(nil, uint32(len(payload))))
+		frames.Write([]byte{0x00, 0x00})
+		frames.Write(payload)
+	}
+	n := frames.Len()
+	tag := []byte{'I', 'D', '3', 0x03, 0x00, 0x00, byte(n >> 21 & 0x7F), byte(n >> 14 & 0x7F), byte(n >> 7 & 0x7F), byte(n & 0x7F)}
+	tag = append(append(tag, frames.Bytes()...), 0xFF, 0xFB, 0x90, 0x00)
+	in := filepath.Join(dir, "call.mp3")
+	if err := os.WriteFile(in, tag, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := SanitizeFile(SanitizeConfig{FilePath: in, OutputDir: filepath.Join(dir, "out"), LogWriter: io.Discard})
+	if err != nil {
+		t.Fatalf("SanitizeFile: %v", err)
+	}
+	if names := removedNames(result); !names["Artist"] || names["Title"] {
+		t.Errorf("Removed = %+v, want Artist and not Title", result.Removed)
+	}
+	data, err := os.ReadFile(result.SanitizedFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("Jane Quincy")) || !bytes.Contains(data, []byte("Morning call")) || len(data) != len(tag) {
+		t.Errorf("sanitized mp3 = %q", data)
+	}
+}
+
+// A format no redactor can remove metadata from is refused, and nothing is
+// written.
+func TestSanitizeFileUnsupported(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(in, []byte("Author: Jane Quincy\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "out")
+	if _, err := SanitizeFile(SanitizeConfig{FilePath: in, OutputDir: out, LogWriter: io.Discard}); err == nil ||
+		!strings.Contains(err.Error(), "not supported for .txt files") {
+		t.Errorf("err = %v, want .txt unsupported", err)
+	}
+	if files := allFiles(out); len(files) != 0 {
+		t.Errorf("wrote %v", files)
+	}
+}
+
+func TestPersonalFields(t *testing.T) {
+	for name, want := range map[string]bool{
+		"Author": true, "LastModifiedBy": true, "Custom_ClientName": true, "Artist": true,
+		"GPS_Coordinates": true, "CameraMake": true, "Make": true, "Model": true, "Software": true, "Location": true,
+		"Title": false, "PageCount": false, "Codec": false, "Duration": false, "MakerNote": false, "ColorModel": false,
+		"CreationDate": false, "Width": false,
+	} {
+		if got := (metadataField{name: name}).personal(); got != want {
+			t.Errorf("personal(%s) = %v, want %v", name, got, want)
+		}
+	}
+}
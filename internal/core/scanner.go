@@ -14,6 +14,7 @@ import (
 
 	"github.com/awslabs/ferret-scan/v2/internal/config"
 	"github.com/awslabs/ferret-scan/v2/internal/detector"
+	"github.com/awslabs/ferret-scan/v2/internal/encryption"
 	"github.com/awslabs/ferret-scan/v2/internal/execguard"
 	"github.com/awslabs/ferret-scan/v2/internal/explain"
 	"github.com/awslabs/ferret-scan/v2/internal/observability"
@@ -44,6 +45,15 @@ type ScanConfig struct {
 	// is forwarded verbatim to the worker pool's JobConfig; the mirror of the
 	// CLI's --max-live-bytes flag.
 	MaxLiveBytes int64
+	// Passwords supplies candidate passwords for encrypted PDF and Office
+	// documents; the mirror of the CLI's --password-file. Nil tries only the
+	// empty PDF user password, and a document that stays locked is reported in
+	// Incomplete rather than scanned as ciphertext.
+	Passwords *encryption.Passwords
+	// SampleRows, when positive, reads only the first that many rows of each
+	// Parquet or Avro file; the mirror of the CLI's --sample-rows. The rows left
+	// unread are reported in Incomplete.
+	SampleRows int64
 	// Explain, when true, attaches an advisory explanation (plain-language
 	// rationale, verdict gloss, drafted suppression reason) to each surfaced
 	// match via internal/explain. Off by default (opt-in). It never mutates
@@ -137,8 +147,11 @@ func ScanFile(scanConfig ScanConfig) (*ScanResult, error) {
 
 	// Initialize file router
 	fileRouter := router.NewFileRouter(scanConfig.Debug)
+	fileRouter.SetPasswords(scanConfig.Passwords)
 	router.RegisterDefaultPreprocessors(fileRouter)
-	fileRouter.InitializePreprocessors(router.CreateRouterConfig(scanConfig.EnableRedaction))
+	routerConfig := router.CreateRouterConfig(scanConfig.EnableRedaction)
+	routerConfig["sample_rows"] = scanConfig.SampleRows
+	fileRouter.InitializePreprocessors(routerConfig)
 	detectorFacade.SetFileRouter(fileRouter)
 
 	// Validate the target file is processable
@@ -329,6 +342,10 @@ func ScanContent(content string, cfg ContentScanConfig) (*ScanResult, error) {
 	// for the metadata extractors to read), so omit the metadata validator.
 	// Callers that need it can scan a file instead.
 	delete(standardValidators, "METADATA")
+	// FILE_TYPE_MISMATCH compares a file's bytes with its name, and VirtualPath
+	// names no file: a file of that name in the working directory is not the
+	// content being scanned.
+	delete(standardValidators, "FILE_TYPE_MISMATCH")
 
 	// Set up the detection facade so contextual analysis behaves identically to
 	// ScanFile. No FileRouter is configured; for plaintext
@@ -0,0 +1,43 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import "github.com/awslabs/ferret-scan/v2/internal/config"
+
+// withValidatorSetting returns the configuration and checks of a scan that
+// also runs check with validators.<section>.<key> set to value: a command-line
+// flag naming the file a config-driven check reads (--edm, --fingerprints).
+// Naming the file is asking for the check, so it is added to an explicit list
+// that left it out.
+//
+// cfg is not modified: the copy shares everything but its validators map and
+// the one section, so a config shared with other scans keeps its own setting.
+func withValidatorSetting(cfg *config.Config, checks []string, section, key string, value interface{}, check string) (*config.Config, []string) {
+	c := &config.Config{}
+	if cfg != nil {
+		*c = *cfg
+	}
+	c.Validators = make(map[string]map[string]interface{}, len(c.Validators)+1)
+	if cfg != nil {
+		for name, s := range cfg.Validators {
+			c.Validators[name] = s
+		}
+	}
+	s := make(map[string]interface{}, len(c.Validators[section])+1)
+	for k, v := range c.Validators[section] {
+		s[k] = v
+	}
+	s[key] = value
+	c.Validators[section] = s
+
+	if len(checks) == 0 || (len(checks) == 1 && checks[0] == "all") {
+		return c, checks
+	}
+	for _, name := range checks {
+		if name == check {
+			return c, checks
+		}
+	}
+	return c, append(append([]string(nil), checks...), check)
+}
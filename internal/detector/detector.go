@@ -100,6 +100,21 @@ type Match struct {
 	StartColumn int `json:"start_column,omitempty"`
 	EndColumn   int `json:"end_column,omitempty"`
 
+	// Page is the 1-based page of a paged document (a PDF) the match was
+	// extracted from; zero when the source has no pages or the line lies outside
+	// the paged text. LineNumber stays a line of the whole extraction, because
+	// the suppression identity is built from it and a page-relative line would
+	// collide across pages. Set by the validator bridge from the page boundaries
+	// the preprocessor declared.
+	Page int `json:"page,omitempty"`
+
+	// Cell names the spreadsheet cell the match was extracted from, as a formula
+	// would refer to it ("Sheet1!C42", "'Payroll Q3'!C42"); empty for anything
+	// but a spreadsheet. Like Page it sits beside LineNumber rather than
+	// replacing it, because the suppression identity is built from the line. Set
+	// after StartColumn, from the cell positions the preprocessor declared.
+	Cell string `json:"cell,omitempty"`
+
 	// New field for context information
 	Context ContextInfo
 }
@@ -110,6 +125,18 @@ func (m Match) IsVirtual() bool {
 	return m.SourceKind == SourceKindVirtual
 }
 
+// FileLevelKey is the Match.Metadata key, set to true, that marks a finding about
+// the file as a whole rather than a value in it: its Text names what was found
+// (a FILE_TYPE_MISMATCH names the format) and is nowhere in the content, so
+// there is nothing to redact.
+const FileLevelKey = "file_level"
+
+// IsFileLevel reports whether the match is about the whole file (FileLevelKey).
+func (m Match) IsFileLevel() bool {
+	fileLevel, _ := m.Metadata[FileLevelKey].(bool)
+	return fileLevel
+}
+
 // SuppressedMatch represents a finding that was suppressed by a rule
 type SuppressedMatch struct {
 	Match        Match      `json:"finding"`
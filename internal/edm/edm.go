@@ -0,0 +1,492 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package edm builds and searches an exact data match index: salted hashes of
+// the values in a table of known sensitive records, kept by column and row, so
+// a scan can tell a real customer's SSN from every other number shaped like
+// one.
+//
+// The index never holds a value. Each value is split into words, normalised
+// (see key), HMAC-SHA256'd under a random per-index salt, and kept as the first
+// 8 bytes of the digest with the row and column it came from. The column names
+// are kept in the clear, since a finding names the column it matched.
+//
+// What the salt does and does not buy: it stops a table of hashes precomputed
+// for one index from working on another, and stops two indexes from being
+// joined on their hashes. It does NOT stop someone holding the index from
+// hashing every nine-digit number under the salt the index carries and
+// recovering the SSNs in it, which takes minutes. An index must be protected
+// like the column of hashes it is, and is written readable by its owner only.
+package edm
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// MaxValueWords bounds the words of an indexed value. A scan joins up to
+	// this many adjacent words of a line into each candidate it looks up, so
+	// the bound sets the cost of a scan as well as the longest value found. A
+	// longer value, a free-text note, is not indexed.
+	MaxValueWords = 6
+
+	// minValueLen is the shortest normalised value indexed. A shorter one ("NY",
+	// "M", "42") would match everywhere and identify no one.
+	minValueLen = 4
+
+	// last4MinDigits is the length from which an all-digit value (an SSN, an
+	// account or card number) is also indexed by its last four digits. Those
+	// only ever count toward a record match: four digits alone identify no
+	// one.
+	last4MinDigits = 8
+
+	// A value held by more than commonFloor records and more than 1 in
+	// commonDivisor of them ("Texas", "Smith") is not indexed: a value a
+	// hundred customers share identifies none of them, and would turn every
+	// nearby last-4 into a record match.
+	commonFloor   = 10
+	commonDivisor = 100
+
+	saltLen = 32
+)
+
+// magic begins every index file.
+var magic = []byte("ferret-edm 1\n")
+
+// ErrNotIndex is returned for a file that is not an index, or is damaged.
+var ErrNotIndex = errors.New("not a ferret-scan EDM index")
+
+const (
+	// flagStandalone marks a value distinctive enough to be reported on its own.
+	flagStandalone uint8 = 1 << iota
+	// flagLast4 marks the last four digits of a longer all-digit value.
+	flagLast4
+)
+
+// entry is one indexed value of one record.
+type entry struct {
+	hash   uint64
+	record uint32
+	column uint16
+	flags  uint8
+}
+
+// entrySize is the encoded size of an entry.
+const entrySize = 8 + 4 + 2 + 1
+
+// Index is a loaded exact data match index. It is safe for concurrent use.
+type Index struct {
+	salt     []byte
+	columns  []string
+	records  int
+	maxWords int
+	// entries is sorted by hash, then record, then column.
+	entries []entry
+
+	hashers sync.Pool
+}
+
+// Columns returns the column names of the indexed table, in table order.
+func (ix *Index) Columns() []string { return append([]string(nil), ix.columns...) }
+
+// Records returns the number of rows the index was built from.
+func (ix *Index) Records() int { return ix.records }
+
+// BuildStats reports what Build indexed and what it left out.
+type BuildStats struct {
+	Records int
+	Columns int
+	// Values counts the indexed values, their last-4 forms not included.
+	Values int
+	// Short, Long and Common count the cells left out: shorter than four
+	// characters, longer than MaxValueWords words, or shared by too many
+	// records to identify any of them.
+	Short  int
+	Long   int
+	Common int
+}
+
+// Build reads a CSV table whose first row names its columns and returns its
+// index. Empty cells are skipped; a row may be shorter or longer than the
+// header, and cells past the header are ignored.
+func Build(r io.Reader) (*Index, BuildStats, error) {
+	var stats BuildStats
+	cr := csv.NewReader(bufio.NewReader(r))
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, stats, errors.New("the table is empty")
+	}
+	if err != nil {
+		return nil, stats, fmt.Errorf("reading the header row: %w", err)
+	}
+	if len(header) > math.MaxUint16 {
+		return nil, stats, fmt.Errorf("the table has %d columns; at most %d are supported", len(header), math.MaxUint16)
+	}
+	columns := make([]string, len(header))
+	for i, h := range header {
+		if i == 0 {
+			h = strings.TrimPrefix(h, "\ufeff")
+		}
+		if columns[i] = strings.TrimSpace(h); columns[i] == "" {
+			columns[i] = fmt.Sprintf("column %d", i+1)
+		}
+	}
+
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, stats, err
+	}
+	ix := &Index{salt: salt, columns: columns, maxWords: 1}
+
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, stats, fmt.Errorf("reading row %d: %w", ix.records+2, err)
+		}
+		if ix.records == math.MaxUint32 {
+			return nil, stats, fmt.Errorf("the table has more than %d rows", uint32(math.MaxUint32))
+		}
+		record := uint32(ix.records)
+		ix.records++
+		for col, cell := range row {
+			if col >= len(columns) {
+				break
+			}
+			ws := words(cell)
+			if len(ws) == 0 {
+				continue
+			}
+			if len(ws) > MaxValueWords {
+				stats.Long++
+				continue
+			}
+			k, digits := key(ws)
+			if len(k) < minValueLen {
+				stats.Short++
+				continue
+			}
+			var flags uint8
+			if distinctive(k, len(ws), digits) {
+				flags |= flagStandalone
+			}
+			ix.entries = append(ix.entries, entry{hash: ix.hash(k), record: record, column: uint16(col), flags: flags})
+			stats.Values++
+			if len(ws) > ix.maxWords {
+				ix.maxWords = len(ws)
+			}
+			if digits && len(k) >= last4MinDigits {
+				ix.entries = append(ix.entries, entry{hash: ix.hash(k[len(k)-4:]), record: record, column: uint16(col), flags: flagLast4})
+			}
+		}
+	}
+	if ix.records == 0 {
+		return nil, stats, errors.New("the table has a header row but no records")
+	}
+
+	ix.sortEntries()
+	stats.Common = ix.dropCommon()
+	stats.Values -= stats.Common
+	stats.Records = ix.records
+	stats.Columns = len(columns)
+	return ix, stats, nil
+}
+
+// distinctive reports whether a value identifies a record on its own: an
+// all-digit value of six or more digits, an identifier with four or more
+// digits in it, or three or more words (a full name with a middle name, an
+// email address, a street address). A first or last name alone is not
+// distinctive, and is only reported next to another field of its record.
+func distinctive(k string, nwords int, digits bool) bool {
+	if digits {
+		return len(k) >= 6
+	}
+	nd := 0
+	for i := 0; i < len(k); i++ {
+		if k[i] >= '0' && k[i] <= '9' {
+			nd++
+		}
+	}
+	return (nd >= 4 && len(k) >= 6) || (nwords >= 3 && len(k) >= 8)
+}
+
+func (ix *Index) sortEntries() {
+	sort.Slice(ix.entries, func(i, j int) bool {
+		a, b := ix.entries[i], ix.entries[j]
+		if a.hash != b.hash {
+			return a.hash < b.hash
+		}
+		if a.record != b.record {
+			return a.record < b.record
+		}
+		if a.column != b.column {
+			return a.column < b.column
+		}
+		return a.flags < b.flags
+	})
+}
+
+// dropCommon removes the values held by too many records, and returns how many
+// entries it removed. Last-4 forms are exempt: ten thousand possible values
+// over a million records share every one of them, and a last-4 is never
+// reported alone anyway.
+func (ix *Index) dropCommon() int {
+	limit := ix.records / commonDivisor
+	if limit < commonFloor {
+		limit = commonFloor
+	}
+	removed := 0
+	out := ix.entries[:0]
+	for lo := 0; lo < len(ix.entries); {
+		hi := lo
+		holders, last := 0, int64(-1)
+		for hi < len(ix.entries) && ix.entries[hi].hash == ix.entries[lo].hash {
+			if e := ix.entries[hi]; e.flags&flagLast4 == 0 && int64(e.record) != last {
+				holders++
+				last = int64(e.record)
+			}
+			hi++
+		}
+		for _, e := range ix.entries[lo:hi] {
+			if holders > limit && e.flags&flagLast4 == 0 {
+				removed++
+				continue
+			}
+			out = append(out, e)
+		}
+		lo = hi
+	}
+	ix.entries = out
+	return removed
+}
+
+// hash returns the index's hash of a normalised value.
+func (ix *Index) hash(k string) uint64 {
+	h, _ := ix.hashers.Get().(hash.Hash)
+	if h == nil {
+		h = hmac.New(sha256.New, ix.salt)
+	}
+	h.Reset()
+	h.Write([]byte(k))
+	var sum [sha256.Size]byte
+	v := binary.BigEndian.Uint64(h.Sum(sum[:0]))
+	ix.hashers.Put(h)
+	return v
+}
+
+// lookup returns the range of entries holding hash v.
+func (ix *Index) lookup(v uint64) (int, int) {
+	lo := sort.Search(len(ix.entries), func(i int) bool { return ix.entries[i].hash >= v })
+	hi := lo
+	for hi < len(ix.entries) && ix.entries[hi].hash == v {
+		hi++
+	}
+	return lo, hi
+}
+
+// WriteTo writes the index in its file format.
+func (ix *Index) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	cw := &countingWriter{w: bw}
+	cw.Write(magic)
+	cw.Write(ix.salt)
+	var buf [entrySize]byte
+	binary.LittleEndian.PutUint16(buf[:2], uint16(len(ix.columns)))
+	cw.Write(buf[:2])
+	for _, c := range ix.columns {
+		if len(c) > math.MaxUint16 {
+			c = c[:math.MaxUint16]
+		}
+		binary.LittleEndian.PutUint16(buf[:2], uint16(len(c)))
+		cw.Write(buf[:2])
+		cw.Write([]byte(c))
+	}
+	binary.LittleEndian.PutUint32(buf[:4], uint32(ix.records))
+	cw.Write(buf[:4])
+	cw.Write([]byte{uint8(ix.maxWords)})
+	binary.LittleEndian.PutUint64(buf[:8], uint64(len(ix.entries)))
+	cw.Write(buf[:8])
+	for _, e := range ix.entries {
+		binary.LittleEndian.PutUint64(buf[0:8], e.hash)
+		binary.LittleEndian.PutUint32(buf[8:12], e.record)
+		binary.LittleEndian.PutUint16(buf[12:14], e.column)
+		buf[14] = e.flags
+		cw.Write(buf[:])
+	}
+	if cw.err != nil {
+		return cw.n, cw.err
+	}
+	return cw.n, bw.Flush()
+}
+
+// WriteFile writes the index to path, readable by its owner only, replacing
+// any file there only once the new one is complete.
+func (ix *Index) WriteFile(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := ix.WriteTo(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
+
+// Read parses an index. Every count in the file is checked against the bytes
+// that follow it before anything is allocated, and the entries must be in
+// order, so a damaged or hand-made file is refused rather than searched wrong.
+func Read(data []byte) (*Index, error) {
+	if !bytes.HasPrefix(data, magic) {
+		return nil, ErrNotIndex
+	}
+	p := data[len(magic):]
+	take := func(n int) []byte {
+		if n > len(p) {
+			return nil
+		}
+		b := p[:n]
+		p = p[n:]
+		return b
+	}
+	ix := &Index{}
+	if ix.salt = take(saltLen); ix.salt == nil {
+		return nil, ErrNotIndex
+	}
+	ix.salt = append([]byte(nil), ix.salt...)
+	b := take(2)
+	if b == nil {
+		return nil, ErrNotIndex
+	}
+	ncols := int(binary.LittleEndian.Uint16(b))
+	for i := 0; i < ncols; i++ {
+		b = take(2)
+		if b == nil {
+			return nil, ErrNotIndex
+		}
+		name := take(int(binary.LittleEndian.Uint16(b)))
+		if name == nil {
+			return nil, ErrNotIndex
+		}
+		ix.columns = append(ix.columns, string(name))
+	}
+	if b = take(4); b == nil {
+		return nil, ErrNotIndex
+	}
+	ix.records = int(binary.LittleEndian.Uint32(b))
+	if b = take(1); b == nil || b[0] == 0 || b[0] > MaxValueWords {
+		return nil, ErrNotIndex
+	}
+	ix.maxWords = int(b[0])
+	if b = take(8); b == nil {
+		return nil, ErrNotIndex
+	}
+	n := binary.LittleEndian.Uint64(b)
+	if n != uint64(len(p))/entrySize || uint64(len(p))%entrySize != 0 {
+		return nil, ErrNotIndex
+	}
+	ix.entries = make([]entry, n)
+	for i := range ix.entries {
+		e := entry{
+			hash:   binary.LittleEndian.Uint64(p[0:8]),
+			record: binary.LittleEndian.Uint32(p[8:12]),
+			column: binary.LittleEndian.Uint16(p[12:14]),
+			flags:  p[14],
+		}
+		p = p[entrySize:]
+		if int(e.column) >= ncols || int(e.record) >= ix.records || (i > 0 && e.hash < ix.entries[i-1].hash) {
+			return nil, ErrNotIndex
+		}
+		ix.entries[i] = e
+	}
+	return ix, nil
+}
+
+// opened caches the indexes Open has loaded, by path.
+var (
+	openMu sync.Mutex
+	opened = map[string]openedIndex{}
+)
+
+type openedIndex struct {
+	size    int64
+	modTime time.Time
+	ix      *Index
+}
+
+// Open loads the index at path. An index is loaded once per process for as
+// long as the file is unchanged: the validator set is built per scan, the web
+// server builds one per request, and an index of a large table runs to
+// hundreds of megabytes.
+func Open(path string) (*Index, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := os.Stat(abs)
+	if err != nil {
+		return nil, fmt.Errorf("EDM index: %w", err)
+	}
+	openMu.Lock()
+	defer openMu.Unlock()
+	if o, ok := opened[abs]; ok && o.size == fi.Size() && o.modTime.Equal(fi.ModTime()) {
+		return o.ix, nil
+	}
+	data, err := os.ReadFile(abs) // #nosec G304 -- the index path is the user's own argument
+	if err != nil {
+		return nil, fmt.Errorf("EDM index: %w", err)
+	}
+	ix, err := Read(data)
+	if err != nil {
+		return nil, fmt.Errorf("EDM index %s: %w", path, err)
+	}
+	opened[abs] = openedIndex{size: fi.Size(), modTime: fi.ModTime(), ix: ix}
+	return ix, nil
+}
@@ -0,0 +1,146 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package edm
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const customers = `name,ssn,email,state
+Jane Doe,123-45-6789,jane.doe@example.com,TX
+John Roe,987-65-4320,jroe@example.org,TX
+`
+
+func buildIndex(t *testing.T, table string) *Index {
+	t.Helper()
+	ix, _, err := Build(strings.NewReader(table))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every test searches an index that went through the file format.
+	var buf bytes.Buffer
+	if _, err := ix.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	ix, err = Read(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ix
+}
+
+func TestIndexHoldsNoPlaintext(t *testing.T) {
+	ix, stats, err := Build(strings.NewReader(customers))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := ix.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []string{"Jane", "jane", "Doe", "doe", "123456789", "6789", "example", "jroe"} {
+		if bytes.Contains(buf.Bytes(), []byte(v)) {
+			t.Errorf("the index file holds %q", v)
+		}
+	}
+	if stats.Records != 2 || stats.Columns != 4 || stats.Values != 6 || stats.Short != 2 {
+		t.Errorf("stats = %+v, want 2 records, 4 columns, 6 values, 2 short (the states)", stats)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	ix := buildIndex(t, customers)
+	type want struct {
+		text   string
+		record bool
+		fields []string
+		row    int
+	}
+	cases := []struct {
+		name string
+		line string
+		want []want
+	}{
+		{"an SSN alone, written differently", "ticket 4411: customer SSN 123 45 6789 verified",
+			[]want{{"123 45 6789", false, nil, 0}}},
+		{"an email address alone", "Contact: JANE.DOE@EXAMPLE.COM",
+			[]want{{"JANE.DOE@EXAMPLE.COM", false, nil, 0}}},
+		{"a valid SSN of no customer", "customer SSN 219-09-9999", nil},
+		{"a name alone", "Jane Doe called about her order", nil},
+		{"a last-4 alone", "card ending 6789", nil},
+		{"a name and the same record's last-4", "Jane Doe, SSN ending 6789",
+			[]want{{"Jane Doe", true, []string{"name", "ssn (last 4)"}, 1}, {"6789", true, []string{"name", "ssn (last 4)"}, 1}}},
+		{"a name and another record's last-4", "Jane Doe, SSN ending 4320", nil},
+		{"two last-4s of one record", "6789 6789", nil},
+		{"the name inside a full row", "John Roe,987-65-4320,jroe@example.org,TX",
+			[]want{
+				{"John Roe", true, []string{"email", "name", "ssn"}, 2},
+				{"987-65-4320", true, []string{"email", "name", "ssn"}, 2},
+				{"jroe@example.org", true, []string{"email", "name", "ssn"}, 2},
+			}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []want
+			for _, h := range ix.Search(tc.line) {
+				got = append(got, want{tc.line[h.Start:h.End], h.Record, h.Fields, h.Row})
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Search(%q) =\n  %+v\nwant\n  %+v", tc.line, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestHitNamesItsColumns(t *testing.T) {
+	ix := buildIndex(t, customers)
+	hits := ix.Search("SSN: 123456789")
+	if len(hits) != 1 || !reflect.DeepEqual(hits[0].Columns, []string{"ssn"}) {
+		t.Fatalf("hits = %+v, want the ssn column", hits)
+	}
+}
+
+func TestCommonValuesAreNotIndexed(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("name,city\n")
+	for i := 0; i < 20; i++ {
+		fmt.Fprintf(&b, "Person%02d Smith,Springfield\n", i)
+	}
+	ix, stats, err := Build(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Common != 20 {
+		t.Errorf("stats.Common = %d, want the 20 Springfield cells", stats.Common)
+	}
+	if hits := ix.Search("Person03 Smith lives in Springfield"); len(hits) != 1 || hits[0].Record {
+		t.Errorf("hits = %+v, want the distinctive name alone and no record match on the city", hits)
+	}
+}
+
+func TestReadRefusesADamagedIndex(t *testing.T) {
+	ix, _, err := Build(strings.NewReader(customers))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := ix.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	for name, bad := range map[string][]byte{
+		"truncated":    data[:len(data)-3],
+		"not an index": []byte("name,ssn\nJane,123\n"),
+		"empty":        nil,
+	} {
+		if _, err := Read(bad); !errors.Is(err, ErrNotIndex) {
+			t.Errorf("%s: Read = %v, want ErrNotIndex", name, err)
+		}
+	}
+}
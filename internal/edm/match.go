@@ -0,0 +1,307 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package edm
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// maxGap is the most separator bytes between two words joined into one
+// candidate: enough for "123 - 45 - 6789" or "Doe, Jane", not for two values
+// in the cells of a padded table.
+const maxGap = 3
+
+// word is one run of letters or of digits in a line.
+type word struct {
+	start, end int
+	// text is the run case-folded and NFKC-normalised, so full-width digits
+	// and ligatures compare equal to their plain forms.
+	text   string
+	digits bool
+}
+
+// words splits s into runs of letters and runs of digits. A letter run and a
+// digit run are separate words even when they touch, so "SSN123456789" holds
+// the word "123456789" and a customer ID "AB1234" is the two words "ab" and
+// "1234" in the index and in the text alike.
+func words(s string) []word {
+	var out []word
+	start, class := -1, 0
+	flush := func(end int) {
+		if start < 0 {
+			return
+		}
+		raw := s[start:end]
+		text := raw
+		ascii := true
+		for i := 0; i < len(raw); i++ {
+			if raw[i] >= utf8.RuneSelf {
+				ascii = false
+				break
+			}
+		}
+		if !ascii {
+			text = norm.NFKC.String(text)
+		}
+		text = strings.ToLower(text)
+		digits := class == 2
+		for i := 0; digits && i < len(text); i++ {
+			digits = text[i] >= '0' && text[i] <= '9'
+		}
+		out = append(out, word{start: start, end: end, text: text, digits: digits})
+		start = -1
+	}
+	for i, r := range s {
+		c := 0
+		switch {
+		case unicode.IsDigit(r):
+			c = 2
+		case unicode.IsLetter(r), unicode.IsMark(r) && class == 1:
+			c = 1
+		}
+		if c != class || c == 0 {
+			flush(i)
+		}
+		if c != 0 && start < 0 {
+			start = i
+		}
+		class = c
+	}
+	flush(len(s))
+	return out
+}
+
+// key is the normalised form of a value: its digits run together when every
+// word is digits, so "123-45-6789" and "123 45 6789" are one value, and
+// otherwise its words joined by single spaces, so "Doe,  Jane" and "doe jane"
+// are.
+func key(ws []word) (string, bool) {
+	digits := true
+	for _, w := range ws {
+		if !w.digits {
+			digits = false
+			break
+		}
+	}
+	var b strings.Builder
+	for i, w := range ws {
+		if i > 0 && !digits {
+			b.WriteByte(' ')
+		}
+		b.WriteString(w.text)
+	}
+	return b.String(), digits
+}
+
+// Hit is one value of the index found in a line.
+type Hit struct {
+	// Start and End are the byte offsets of the value in the line.
+	Start, End int
+	// Columns names the columns the value was found in, in table order. A
+	// last-4 is named "ssn (last 4)".
+	Columns []string
+	// Record is set when the value is one of two or more fields of one
+	// record found on the line. Fields then names every one of them, and Row
+	// is the record's 1-based data row in the indexed table.
+	Record bool
+	Fields []string
+	Row    int
+}
+
+// candidate is a run of words whose key the index holds.
+type candidate struct {
+	start, end int
+	lo, hi     int
+	// hit is set once the candidate is reported.
+	hit *Hit
+}
+
+// Search returns the values of the index found in line, in line order.
+//
+// A value is reported alone when it identifies a record on its own (see
+// distinctive). Any other value, a first name or the last four digits of an
+// SSN, is reported only when another field of the same record is on the same
+// line; a record match needs at least one field that is not a last-4. A line
+// is the record window because a row of a CSV export, a spreadsheet and most
+// logs is one line.
+//
+// A value found inside a longer one that is also reported ("Doe" within "Jane
+// Doe") is not reported again.
+func (ix *Index) Search(line string) []Hit {
+	if len(ix.entries) == 0 {
+		return nil
+	}
+	ws := words(line)
+	var cands []candidate
+	for i := range ws {
+		for n := 1; n <= ix.maxWords && i+n <= len(ws); n++ {
+			if n > 1 && ws[i+n-1].start-ws[i+n-2].end > maxGap {
+				break
+			}
+			k, _ := key(ws[i : i+n])
+			if len(k) < minValueLen {
+				continue
+			}
+			if lo, hi := ix.lookup(ix.hash(k)); lo < hi {
+				cands = append(cands, candidate{start: ws[i].start, end: ws[i+n-1].end, lo: lo, hi: hi})
+			}
+		}
+	}
+	if len(cands) == 0 {
+		return nil
+	}
+
+	// Record matches first: they name the row, which a lone value does not.
+	byRecord := make(map[uint32][]field)
+	var recordOrder []uint32
+	for ci, c := range cands {
+		for _, e := range ix.entries[c.lo:c.hi] {
+			if _, seen := byRecord[e.record]; !seen {
+				recordOrder = append(recordOrder, e.record)
+			}
+			byRecord[e.record] = append(byRecord[e.record], field{ci, e.column, e.flags&flagLast4 != 0})
+		}
+	}
+	sort.Slice(recordOrder, func(i, j int) bool { return recordOrder[i] < recordOrder[j] })
+	for _, r := range recordOrder {
+		fs := byRecord[r]
+		if !recordMatch(fs, cands) {
+			continue
+		}
+		// A last-4 of a column found in full ("4320" within "987-65-4320")
+		// is not a field of its own.
+		full := make(map[uint16]bool)
+		for _, f := range fs {
+			if !f.last4 {
+				full[f.column] = true
+			}
+		}
+		var names []string
+		seen := make(map[string]bool)
+		for _, f := range fs {
+			if f.last4 && full[f.column] {
+				continue
+			}
+			if name := ix.columnName(f.column, f.last4); !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, f := range fs {
+			c := &cands[f.cand]
+			if c.hit == nil {
+				c.hit = &Hit{Record: true, Fields: names, Row: int(r) + 1}
+			}
+		}
+	}
+
+	// Then the values distinctive enough to report alone.
+	for ci := range cands {
+		c := &cands[ci]
+		if c.hit != nil {
+			continue
+		}
+		for _, e := range ix.entries[c.lo:c.hi] {
+			if e.flags&flagStandalone != 0 {
+				c.hit = &Hit{}
+				break
+			}
+		}
+	}
+
+	// Sorted by start, longest first, a reported candidate lies inside a
+	// longer one exactly when an earlier one reaches as far.
+	var reported []candidate
+	for _, c := range cands {
+		if c.hit != nil {
+			reported = append(reported, c)
+		}
+	}
+	sort.Slice(reported, func(i, j int) bool {
+		if reported[i].start != reported[j].start {
+			return reported[i].start < reported[j].start
+		}
+		return reported[i].end > reported[j].end
+	})
+	var hits []Hit
+	reach := -1
+	for _, c := range reported {
+		if c.end <= reach {
+			continue
+		}
+		reach = c.end
+		h := *c.hit
+		h.Start, h.End = c.start, c.end
+		h.Columns = ix.candidateColumns(c, h.Row)
+		hits = append(hits, h)
+	}
+	return hits
+}
+
+// field is one indexed value of a record, found at a candidate.
+type field struct {
+	cand   int
+	column uint16
+	last4  bool
+}
+
+// recordMatch reports whether the fields of one record found on a line make a
+// record match: two fields, in different columns, at spans that do not
+// overlap, not both last-4s.
+func recordMatch(fs []field, cands []candidate) bool {
+	for i, a := range fs {
+		for _, b := range fs[i+1:] {
+			if a.column == b.column || (a.last4 && b.last4) {
+				continue
+			}
+			ca, cb := cands[a.cand], cands[b.cand]
+			if ca.start < cb.end && cb.start < ca.end {
+				continue
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// candidateColumns names the columns a candidate's value belongs to: in the
+// record of a record match (row > 0), or, for a value reported alone, wherever
+// it is distinctive.
+func (ix *Index) candidateColumns(c candidate, row int) []string {
+	cols := make(map[uint16]bool)
+	last4 := make(map[uint16]bool)
+	for _, e := range ix.entries[c.lo:c.hi] {
+		switch {
+		case row > 0 && int(e.record) != row-1:
+		case e.flags&flagLast4 != 0:
+			if row > 0 {
+				last4[e.column] = true
+			}
+		case row > 0 || e.flags&flagStandalone != 0:
+			cols[e.column] = true
+		}
+	}
+	var out []string
+	for col := range ix.columns {
+		if cols[uint16(col)] {
+			out = append(out, ix.columns[col])
+		} else if last4[uint16(col)] {
+			out = append(out, ix.columnName(uint16(col), true))
+		}
+	}
+	return out
+}
+
+func (ix *Index) columnName(col uint16, last4 bool) string {
+	if last4 {
+		return ix.columns[col] + " (last 4)"
+	}
+	return ix.columns[col]
+}
@@ -91,7 +91,8 @@ var ErrBudgetExhausted = errors.New("embedded container decompression budget exh
 // order cannot reach output.
 var kindByExt = map[string]string{
 	// Images. Scanned for metadata (EXIF/XMP/IPTC), which is where an author
-	// name, GPS fix or free-text description leaks from.
+	// name, GPS fix or free-text description leaks from, and for the payloads
+	// of the barcodes in their pixels.
 	".jpg": "image", ".jpeg": "image", ".png": "image",
 	".tiff": "image", ".tif": "image", ".gif": "image",
 	".bmp": "image", ".webp": "image",
@@ -233,6 +234,11 @@ var opaqueExts = map[string]bool{
 // scan inflates. The one exception is a format whose text a preprocessor decompresses
 // but the scan cannot — PDF — which is why PDF is listed explicitly above. A format
 // nobody can extract from produces no findings, so there is nothing reported to leak.
+//
+// An image is inspectable for its metadata but not for a barcode, whose payload is
+// in the pixels. That is not handled here, by making every image opaque, which would
+// dispatch every picture in a document with any finding at all: the Office redactor
+// decodes the symbols of an image part when a finding was reported against one.
 func ResidueInspectable(name string) bool {
 	return !opaqueExts[strings.ToLower(filepath.Ext(name))]
 }
@@ -0,0 +1,169 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package encryption opens password-protected documents in memory.
+//
+// Encrypted documents are the ones most likely to hold what this tool looks for —
+// a payroll workbook is password-protected precisely because of what is in it —
+// and they were the ones it read least. An encrypted PDF got the page count and
+// little else, an encrypted .docx (an OLE container holding an EncryptedPackage
+// stream, not a zip) failed every extractor and was reported as "cannot parse",
+// and a password-protected .doc had its ciphertext scavenged for printable runs
+// and reported as scanned.
+//
+// Three things are handled:
+//
+//   - PDF standard security handlers, every revision (RC4 40/128-bit, AES-128,
+//     AES-256), decrypted by pdfcpu into a plaintext PDF held in memory.
+//   - ECMA-376 agile and standard encryption of OOXML, decrypted here into the
+//     zip the EncryptedPackage stream wraps.
+//   - Legacy .doc/.xls/.ppt encryption, which is DETECTED and reported but not
+//     decrypted. Those formats encrypt stream by stream inside the compound file,
+//     so the plaintext is not a single buffer an extractor can be pointed at.
+//
+// # Plaintext stays in memory
+//
+// Decrypted bytes are never written to disk: not to a temp file, not to the
+// redaction output directory. Every extractor this package serves already opens
+// its input from a path, so the router holds the plaintext here for the duration
+// of one file (Hold) and the extractors ask for it by the same path (Plaintext)
+// before falling back to the file. That is the same shape as the router's nesting
+// depth, and for the same reason: a preprocessor instance is shared across
+// workers and Process takes only a path.
+//
+// The container-walking extractors consult Plaintext for a second reason: an
+// embedded part of a decrypted document would otherwise be written to a temp file
+// to be routed, which is plaintext on disk. They decline those parts and say so.
+package encryption
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ErrEncrypted is the cause of every failure to open an encrypted document:
+// no supplied password opened it, or its encryption is one this package
+// detects but cannot decrypt. The router returns it wrapped, so the report can
+// name "encrypted" as the reason a file was not examined rather than calling a
+// well-formed document unparseable.
+var ErrEncrypted = errors.New("encrypted")
+
+// MaxDecryptSize bounds the encrypted file this package will read into memory,
+// and so the plaintext it holds. It matches the router's per-file ceiling: a
+// larger file is refused for size by the preprocessors before any of this would
+// matter.
+const MaxDecryptSize = int64(100 * 1024 * 1024)
+
+// oleSignature opens every OLE compound file: legacy Office documents and the
+// wrapper ECMA-376 puts around an encrypted OOXML package.
+var oleSignature = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+// Unlock opens filePath if it is an encrypted document, trying each password in
+// order.
+//
+// It returns (nil, nil) for a file that is not encrypted or is not a format this
+// package knows, which is every file but a handful; the caller then reads it as
+// before. It returns the plaintext when a password opened the document, and an
+// error wrapping ErrEncrypted when none did. Any other error is the file's own:
+// the extractors will meet it too, and report it in their own words.
+func Unlock(filePath string, passwords []string) ([]byte, error) {
+	f, err := os.Open(filePath) // #nosec G304 -- path already vetted by the router
+	if err != nil {
+		return nil, nil
+	}
+	defer f.Close()
+
+	var head [8]byte
+	n, _ := io.ReadFull(f, head[:])
+	switch {
+	case bytes.HasPrefix(head[:n], []byte("%PDF")):
+		return unlockPDF(f, passwords)
+	case bytes.Equal(head[:n], oleSignature):
+		return unlockOLE(f, passwords)
+	default:
+		return nil, nil
+	}
+}
+
+// readAllBounded reads a whole file, refusing one past MaxDecryptSize.
+func readAllBounded(f *os.File) ([]byte, bool) {
+	fi, err := f.Stat()
+	if err != nil || fi.Size() > MaxDecryptSize {
+		return nil, false
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, false
+	}
+	data, err := io.ReadAll(io.LimitReader(f, MaxDecryptSize))
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// encryptedError builds the error for a document that stayed locked. detail is
+// payload-free: it names the scheme and what was tried, never a password.
+func encryptedError(detail string, passwords []string) error {
+	if len(passwords) == 0 {
+		return fmt.Errorf("%w: %s; no password was supplied for it (see --password-file)", ErrEncrypted, detail)
+	}
+	return fmt.Errorf("%w: %s; none of the %d supplied password(s) opened it", ErrEncrypted, detail, len(passwords))
+}
+
+// held is the plaintext of the documents currently being processed, keyed by
+// cleaned path. An entry is counted because the same path can be in flight
+// twice: a file named twice on the command line, or two scans in one process.
+var (
+	heldMu sync.Mutex
+	held   = map[string]*heldPlaintext{}
+)
+
+type heldPlaintext struct {
+	data []byte
+	refs int
+}
+
+// Hold makes plaintext available to Plaintext(filePath) until the returned
+// release function is called. The router calls release when the file is done,
+// so the map holds only the files in flight rather than every document a
+// directory scan decrypted.
+func Hold(filePath string, plaintext []byte) (release func()) {
+	key := filepath.Clean(filePath)
+	heldMu.Lock()
+	defer heldMu.Unlock()
+	if h, ok := held[key]; ok {
+		h.refs++
+	} else {
+		held[key] = &heldPlaintext{data: plaintext, refs: 1}
+	}
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			heldMu.Lock()
+			defer heldMu.Unlock()
+			if h, ok := held[key]; ok {
+				if h.refs--; h.refs == 0 {
+					delete(held, key)
+				}
+			}
+		})
+	}
+}
+
+// Plaintext returns the decrypted bytes held for filePath. An extractor that
+// gets ok == true reads these instead of the file, and must not write them, or
+// anything derived from them, to disk.
+func Plaintext(filePath string) (data []byte, ok bool) {
+	heldMu.Lock()
+	defer heldMu.Unlock()
+	h, ok := held[filepath.Clean(filePath)]
+	if !ok {
+		return nil, false
+	}
+	return h.data, true
+}
@@ -0,0 +1,111 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package encryption
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/awslabs/ferret-scan/v2/internal/olefixture"
+)
+
+func writeOLE(t *testing.T, name string, streams []olefixture.Stream) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, olefixture.MustBuild(streams), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// fib returns the start of a WordDocument stream: a FibBase with wIdent and
+// the flag word set.
+func fib(flags uint16) []byte {
+	b := make([]byte, 64)
+	binary.LittleEndian.PutUint16(b[0:2], 0xA5EC)
+	binary.LittleEndian.PutUint16(b[10:12], flags)
+	return b
+}
+
+// biff returns workbook globals made of the given record types, each with a
+// small body, ending in EOF.
+func biff(types ...uint16) []byte {
+	var b []byte
+	for _, typ := range append(types, 0x000A) {
+		b = binary.LittleEndian.AppendUint16(b, typ)
+		b = binary.LittleEndian.AppendUint16(b, 4)
+		b = append(b, 0, 0, 0, 0)
+	}
+	return b
+}
+
+func TestUnlock_LegacyEncryptionIsReportedNotScavenged(t *testing.T) {
+	for _, c := range []struct {
+		name    string
+		streams []olefixture.Stream
+		want    string
+	}{
+		{"locked.doc", []olefixture.Stream{{Name: olefixture.StreamWordDocument, Data: fib(0x0100)}}, "Word 97-2003"},
+		{"locked.xls", []olefixture.Stream{{Name: olefixture.StreamWorkbook, Data: biff(0x0809, 0x002F)}}, "Excel 97-2003"},
+		{"locked.ppt", []olefixture.Stream{
+			{Name: olefixture.StreamPowerPoint, Data: make([]byte, 64)},
+			{Name: "EncryptedSummary", Data: make([]byte, 64)},
+		}, "PowerPoint 97-2003"},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			// A password is supplied and still the error must not blame it.
+			_, err := Unlock(writeOLE(t, c.name, c.streams), []string{"pw"})
+			if !errors.Is(err, ErrEncrypted) {
+				t.Fatalf("err = %v, want ErrEncrypted", err)
+			}
+			if !strings.Contains(err.Error(), c.want) || !strings.Contains(err.Error(), "not decrypted") {
+				t.Errorf("err = %q, want it to name %s as detected but not decrypted", err, c.want)
+			}
+		})
+	}
+}
+
+func TestUnlock_UnencryptedLegacyDocumentsAreLeftAlone(t *testing.T) {
+	for name, streams := range map[string][]olefixture.Stream{
+		"plain.doc": {{Name: olefixture.StreamWordDocument, Data: fib(0x0200)}},
+		"plain.xls": {{Name: olefixture.StreamWorkbook, Data: biff(0x0809, 0x0042)}},
+	} {
+		got, err := Unlock(writeOLE(t, name, streams), nil)
+		if got != nil || err != nil {
+			t.Errorf("%s: Unlock = (%d bytes, %v), want (nil, nil)", name, len(got), err)
+		}
+	}
+}
+
+func TestHoldAndPlaintext(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "doc.docx")
+
+	if _, ok := Plaintext(path); ok {
+		t.Fatal("Plaintext reported a document that was never held")
+	}
+
+	release := Hold(path, []byte("decrypted"))
+	// Paths are cleaned, so an extractor handed a non-canonical spelling of the
+	// same path still finds it.
+	if got, ok := Plaintext(filepath.Dir(path) + "/./doc.docx"); !ok || string(got) != "decrypted" {
+		t.Fatalf("Plaintext = (%q, %v), want the held bytes", got, ok)
+	}
+
+	// A nested hold (the same document reached twice in one scan) keeps it
+	// held until both are released; a repeated release is harmless.
+	releaseAgain := Hold(path, []byte("decrypted"))
+	release()
+	release()
+	if _, ok := Plaintext(path); !ok {
+		t.Fatal("an outstanding hold was dropped by another hold's release")
+	}
+	releaseAgain()
+	if _, ok := Plaintext(path); ok {
+		t.Fatal("plaintext outlived every hold")
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package encryption
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/richardlehane/mscfb"
+)
+
+// maxEncryptionInfoBytes bounds the EncryptionInfo stream. A real one is a few
+// hundred bytes of XML or a fixed binary header; nothing legitimate approaches
+// this.
+const maxEncryptionInfoBytes = 1 << 20
+
+// unlockOLE handles the two kinds of encrypted document that arrive as an OLE
+// compound file.
+//
+// An encrypted .docx/.xlsx/.pptx is not a zip at all: ECMA-376 wraps the
+// encrypted package in a compound file with an EncryptionInfo stream (how it was
+// encrypted) and an EncryptedPackage stream (the zip, encrypted). Those are
+// decrypted. A legacy .doc/.xls/.ppt carries its encryption inside the format's
+// own streams; that is detected, so the file is reported as encrypted instead of
+// its ciphertext being scavenged for printable runs and called scanned.
+func unlockOLE(f *os.File, passwords []string) (plaintext []byte, err error) {
+	fi, err := f.Stat()
+	if err != nil || fi.Size() > MaxDecryptSize {
+		return nil, nil
+	}
+	// mscfb follows sector chains the file declares; a hostile chain is a parser
+	// problem for the extractors to report, not a reason to lose the worker here.
+	defer func() {
+		if r := recover(); r != nil {
+			plaintext, err = nil, nil
+		}
+	}()
+
+	doc, err := mscfb.New(f)
+	if err != nil {
+		return nil, nil
+	}
+
+	var info, pkg []byte
+	var legacy string
+	for entry, nerr := doc.Next(); nerr == nil; entry, nerr = doc.Next() {
+		if len(entry.Path) != 0 {
+			continue // only root-level streams matter here
+		}
+		switch entry.Name {
+		case "EncryptionInfo":
+			info, err = io.ReadAll(io.LimitReader(entry, maxEncryptionInfoBytes))
+			if err != nil {
+				return nil, nil
+			}
+		case "EncryptedPackage":
+			pkg, err = io.ReadAll(io.LimitReader(entry, MaxDecryptSize))
+			if err != nil {
+				return nil, nil
+			}
+		case "WordDocument":
+			if wordEncrypted(entry) {
+				legacy = "Word 97-2003 document encryption"
+			}
+		case "Workbook", "Book":
+			if workbookEncrypted(entry) {
+				legacy = "Excel 97-2003 workbook encryption"
+			}
+		case "EncryptedSummary":
+			// Present only in an encrypted PowerPoint 97-2003 file, which moves its
+			// property streams in here.
+			legacy = "PowerPoint 97-2003 presentation encryption"
+		}
+	}
+
+	switch {
+	case info != nil && pkg != nil:
+		return decryptOOXML(info, pkg, passwords)
+	case legacy != "":
+		// Reported whether or not a password was supplied: there is no password
+		// that would let this package open it, and saying "none of the passwords
+		// worked" would send the operator to check a password that may be right.
+		return nil, fmt.Errorf("%w: %s is detected but not decrypted; "+
+			"save the document without a password to scan it", ErrEncrypted, legacy)
+	default:
+		return nil, nil
+	}
+}
+
+// wordEncrypted reads fEncrypted from the FIB at the start of the WordDocument
+// stream ([MS-DOC] 2.5.2, FibBase). The flag word sits at offset 10 and bit 8 is
+// fEncrypted; fObfuscated (bit 15) is the XOR scheme, which also sets it.
+func wordEncrypted(r io.Reader) bool {
+	var fib [12]byte
+	if _, err := io.ReadFull(r, fib[:]); err != nil {
+		return false
+	}
+	if binary.LittleEndian.Uint16(fib[0:2]) != 0xA5EC { // wIdent
+		return false
+	}
+	return binary.LittleEndian.Uint16(fib[10:12])&0x0100 != 0
+}
+
+// workbookEncrypted looks for a FilePass record in the workbook globals
+// ([MS-XLS] 2.4.117). It follows the BOF record within the first few records,
+// so the walk stops well before the sheet data.
+func workbookEncrypted(r io.Reader) bool {
+	const (
+		recordFilePass = 0x002F
+		recordEOF      = 0x000A
+		maxRecords     = 64
+	)
+	var hdr [4]byte
+	for i := 0; i < maxRecords; i++ {
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			return false
+		}
+		typ := binary.LittleEndian.Uint16(hdr[0:2])
+		size := int64(binary.LittleEndian.Uint16(hdr[2:4]))
+		switch typ {
+		case recordFilePass:
+			return true
+		case recordEOF:
+			return false
+		}
+		if _, err := io.CopyN(io.Discard, r, size); err != nil {
+			return false
+		}
+	}
+	return false
+}
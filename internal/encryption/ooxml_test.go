@@ -0,0 +1,245 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package encryption
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha1"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/awslabs/ferret-scan/v2/internal/olefixture"
+)
+
+// The encryptors below are written from [MS-OFFCRYPTO] independently of the
+// decryptors under test, so a round trip checks the two readings of the
+// specification against each other rather than one function against itself.
+// Office's own output cannot be committed (it would be an opaque binary) and no
+// encryptor ships in the module's dependencies.
+
+// docxBytes builds a minimal .docx holding body.
+func docxBytes(t *testing.T, body string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("word/document.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?><w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body><w:p><w:r><w:t>%s</w:t></w:r></w:p></w:body></w:document>`, body)
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func padBlock(b []byte) []byte {
+	if r := len(b) % aes.BlockSize; r != 0 {
+		b = append(b, make([]byte, aes.BlockSize-r)...)
+	}
+	return b
+}
+
+func cbcEncrypt(t *testing.T, key, iv, data []byte) []byte {
+	t.Helper()
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = padBlock(append([]byte{}, data...))
+	out := make([]byte, len(data))
+	cipher.NewCBCEncrypter(block, iv[:aes.BlockSize]).CryptBlocks(out, data)
+	return out
+}
+
+// encryptAgile produces the EncryptionInfo and EncryptedPackage streams Office
+// 2010+ writes: AES-256, SHA-512, 100,000 rounds.
+func encryptAgile(t *testing.T, plain []byte, password string) (info, pkg []byte) {
+	t.Helper()
+	keySalt := bytes.Repeat([]byte{0x11}, 16)
+	dataSalt := bytes.Repeat([]byte{0x22}, 16)
+	secret := bytes.Repeat([]byte{0x33}, 32)
+	verifier := bytes.Repeat([]byte{0x44}, 16)
+	const spins = 100000
+
+	h := sha512.New()
+	h.Write(keySalt)
+	h.Write(utf16LE(password))
+	hn := h.Sum(nil)
+	for i := 0; i < spins; i++ {
+		h.Reset()
+		binary.Write(h, binary.LittleEndian, uint32(i))
+		h.Write(hn)
+		hn = h.Sum(nil)
+	}
+	key := func(block []byte) []byte {
+		s := sha512.Sum512(append(append([]byte{}, hn...), block...))
+		return s[:32]
+	}
+	verifierHash := sha512.Sum512(verifier)
+
+	b64 := base64.StdEncoding.EncodeToString
+	descriptor := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<encryption xmlns="http://schemas.microsoft.com/office/2006/encryption" xmlns:p="http://schemas.microsoft.com/office/2006/keyEncryptor/password">
+<keyData saltSize="16" blockSize="16" keyBits="256" hashSize="64" cipherAlgorithm="AES" cipherChaining="ChainingModeCBC" hashAlgorithm="SHA512" saltValue="%s"/>
+<keyEncryptors><keyEncryptor uri="http://schemas.microsoft.com/office/2006/keyEncryptor/password">
+<p:encryptedKey spinCount="%d" saltSize="16" blockSize="16" keyBits="256" hashSize="64" cipherAlgorithm="AES" cipherChaining="ChainingModeCBC" hashAlgorithm="SHA512" saltValue="%s" encryptedVerifierHashInput="%s" encryptedVerifierHashValue="%s" encryptedKeyValue="%s"/>
+</keyEncryptor></keyEncryptors></encryption>`,
+		b64(dataSalt), spins, b64(keySalt),
+		b64(cbcEncrypt(t, key(blockKeyVerifierInput), keySalt, verifier)),
+		b64(cbcEncrypt(t, key(blockKeyVerifierValue), keySalt, verifierHash[:])),
+		b64(cbcEncrypt(t, key(blockKeyEncryptedKey), keySalt, secret)))
+
+	info = append([]byte{4, 0, 4, 0, 0x40, 0, 0, 0}, descriptor...)
+
+	pkg = binary.LittleEndian.AppendUint64(nil, uint64(len(plain)))
+	for i := 0; i*4096 < len(plain); i++ {
+		seg := plain[i*4096 : min((i+1)*4096, len(plain))]
+		iv := sha512.Sum512(binary.LittleEndian.AppendUint32(append([]byte{}, dataSalt...), uint32(i)))
+		pkg = append(pkg, cbcEncrypt(t, secret, iv[:16], seg)...)
+	}
+	return info, pkg
+}
+
+// encryptStandard produces the streams Office 2007 writes: AES-128 under the
+// SHA-1 CryptDeriveKey derivation.
+func encryptStandard(t *testing.T, plain []byte, password string) (info, pkg []byte) {
+	t.Helper()
+	salt := bytes.Repeat([]byte{0x55}, 16)
+	verifier := bytes.Repeat([]byte{0x66}, 16)
+
+	hn := sha1.Sum(append(append([]byte{}, salt...), utf16LE(password)...))
+	for i := 0; i < 50000; i++ {
+		hn = sha1.Sum(append(binary.LittleEndian.AppendUint32(nil, uint32(i)), hn[:]...))
+	}
+	final := sha1.Sum(append(hn[:], 0, 0, 0, 0))
+	x := func(pad byte) []byte {
+		buf := bytes.Repeat([]byte{pad}, 64)
+		for i := range final {
+			buf[i] ^= final[i]
+		}
+		s := sha1.Sum(buf)
+		return s[:]
+	}
+	key := append(x(0x36), x(0x5c)...)[:16]
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecb := func(data []byte) []byte {
+		data = padBlock(append([]byte{}, data...))
+		out := make([]byte, len(data))
+		for i := 0; i < len(data); i += 16 {
+			block.Encrypt(out[i:i+16], data[i:i+16])
+		}
+		return out
+	}
+	verifierHash := sha1.Sum(verifier)
+
+	csp := utf16LE("Microsoft Enhanced RSA and AES Cryptographic Provider\x00")
+	header := binary.LittleEndian.AppendUint32(nil, 0x24) // flags: fCryptoAPI|fAES
+	header = binary.LittleEndian.AppendUint32(header, 0)  // sizeExtra
+	header = binary.LittleEndian.AppendUint32(header, algAES128)
+	header = binary.LittleEndian.AppendUint32(header, algSHA1)
+	header = binary.LittleEndian.AppendUint32(header, 128)
+	header = binary.LittleEndian.AppendUint32(header, 0x18) // PROV_RSA_AES
+	header = binary.LittleEndian.AppendUint32(header, 0)
+	header = binary.LittleEndian.AppendUint32(header, 0)
+	header = append(header, csp...)
+
+	info = []byte{3, 0, 2, 0}
+	info = binary.LittleEndian.AppendUint32(info, 0x24)
+	info = binary.LittleEndian.AppendUint32(info, uint32(len(header)))
+	info = append(info, header...)
+	info = binary.LittleEndian.AppendUint32(info, 16)
+	info = append(info, salt...)
+	info = append(info, ecb(verifier)...)
+	info = binary.LittleEndian.AppendUint32(info, sha1.Size)
+	info = append(info, ecb(verifierHash[:])...)
+
+	pkg = binary.LittleEndian.AppendUint64(nil, uint64(len(plain)))
+	pkg = append(pkg, ecb(plain)...)
+	return info, pkg
+}
+
+// writeEncryptedOffice wraps the two streams in a compound file, the way Office
+// saves an encrypted .docx.
+func writeEncryptedOffice(t *testing.T, name string, info, pkg []byte) string {
+	t.Helper()
+	data, err := olefixture.Build([]olefixture.Stream{
+		{Name: "EncryptionInfo", Data: info},
+		{Name: "EncryptedPackage", Data: pkg},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestUnlockOOXML(t *testing.T) {
+	// More than one 4096-byte segment, so the per-segment IV is exercised.
+	plain := docxBytes(t, "SSN 536-22-1874 "+strings.Repeat("filler text ", 600))
+
+	for _, c := range []struct {
+		name    string
+		encrypt func(*testing.T, []byte, string) ([]byte, []byte)
+	}{
+		{"agile", encryptAgile},
+		{"standard", encryptStandard},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			info, pkg := c.encrypt(t, plain, "s3cret")
+			path := writeEncryptedOffice(t, "locked.docx", info, pkg)
+
+			got, err := Unlock(path, []string{"wrong", "s3cret"})
+			if err != nil {
+				t.Fatalf("Unlock with the right password among the candidates: %v", err)
+			}
+			if !bytes.Equal(got, plain) {
+				t.Fatalf("decrypted %d bytes, want the %d-byte package back", len(got), len(plain))
+			}
+			if _, err := zip.NewReader(bytes.NewReader(got), int64(len(got))); err != nil {
+				t.Errorf("plaintext is not a zip: %v", err)
+			}
+
+			_, err = Unlock(path, []string{"wrong"})
+			if !errors.Is(err, ErrEncrypted) {
+				t.Fatalf("wrong password: err = %v, want ErrEncrypted", err)
+			}
+			if strings.Contains(err.Error(), "wrong") {
+				t.Errorf("error %q repeats a password", err)
+			}
+
+			if _, err := Unlock(path, nil); !errors.Is(err, ErrEncrypted) || !strings.Contains(err.Error(), "--password-file") {
+				t.Errorf("no password: err = %v, want ErrEncrypted naming --password-file", err)
+			}
+		})
+	}
+}
+
+// A declared round count is attacker-controlled CPU time per password tried.
+func TestAgileSpinCountIsBounded(t *testing.T) {
+	info, pkg := encryptAgile(t, docxBytes(t, "x"), "pw")
+	info = bytes.Replace(info, []byte(`spinCount="100000"`), []byte(`spinCount="2000000000"`), 1)
+	path := writeEncryptedOffice(t, "greedy.docx", info, pkg)
+
+	_, err := Unlock(path, []string{"pw"})
+	if !errors.Is(err, ErrEncrypted) || !strings.Contains(err.Error(), "spin count") {
+		t.Errorf("err = %v, want the spin count refused", err)
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package encryption
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/awslabs/ferret-scan/v2/internal/pathglob"
+)
+
+// Rule supplies one password for the documents whose path matches a glob.
+type Rule struct {
+	// Path is a glob in the suppression-rule dialect (see package pathglob):
+	// "finance/**/*.xlsx", "*.pdf", or "**" for every file.
+	Path     string `yaml:"path"`
+	Password string `yaml:"password"`
+}
+
+// passwordFile is the on-disk shape of --password-file.
+type passwordFile struct {
+	Passwords []Rule `yaml:"passwords"`
+}
+
+// Passwords selects the candidate passwords for a file. The zero value and a
+// nil *Passwords both supply none.
+type Passwords struct {
+	rules []compiledRule
+}
+
+type compiledRule struct {
+	glob     pathglob.Glob
+	password string
+}
+
+// NewPasswords compiles rules. A malformed glob or an empty password is an
+// error rather than a rule that silently never applies: the operator supplied
+// the password because a document needs it, and a rule that cannot match leaves
+// that document reported as encrypted with nothing to say why.
+func NewPasswords(rules []Rule) (*Passwords, error) {
+	p := &Passwords{}
+	for i, r := range rules {
+		if r.Path == "" {
+			return nil, fmt.Errorf("password rule %d has no path", i+1)
+		}
+		if r.Password == "" {
+			return nil, fmt.Errorf("password rule %d (%s) has an empty password", i+1, r.Path)
+		}
+		g, err := pathglob.Compile(r.Path)
+		if err != nil {
+			return nil, fmt.Errorf("password rule %d: %w", i+1, err)
+		}
+		p.rules = append(p.rules, compiledRule{glob: g, password: r.Password})
+	}
+	return p, nil
+}
+
+// LoadPasswordFile reads a YAML password file:
+//
+//	passwords:
+//	  - path: "finance/**/*.xlsx"
+//	    password: "quarter-close"
+//	  - path: "**"
+//	    password: "company-default"
+//
+// Errors name the file and the rule, never a password.
+func LoadPasswordFile(path string) (*Passwords, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- operator-supplied flag value
+	if err != nil {
+		return nil, fmt.Errorf("cannot read password file: %w", err)
+	}
+	var pf passwordFile
+	if err := yaml.Unmarshal(data, &pf); err != nil {
+		// The YAML error can quote the offending line, and the offending line may be
+		// a password. Report where, not what.
+		return nil, fmt.Errorf("password file %s is not valid YAML", path)
+	}
+	p, err := NewPasswords(pf.Passwords)
+	if err != nil {
+		return nil, fmt.Errorf("password file %s: %w", path, err)
+	}
+	return p, nil
+}
+
+// For returns the passwords whose glob matches filePath, in file order and
+// without repeats. Several rules may match one file — a directory password and a
+// catch-all — and each is tried, so the order only decides which is tried first.
+func (p *Passwords) For(filePath string) []string {
+	if p == nil {
+		return nil
+	}
+	var out []string
+	seen := map[string]bool{}
+	for _, r := range p.rules {
+		if r.glob.Match(filePath) && !seen[r.password] {
+			seen[r.password] = true
+			out = append(out, r.password)
+		}
+	}
+	return out
+}
+
+// Len reports how many rules were loaded, for a payload-free log line.
+func (p *Passwords) Len() int {
+	if p == nil {
+		return 0
+	}
+	return len(p.rules)
+}
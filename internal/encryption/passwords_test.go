@@ -0,0 +1,85 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package encryption
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writePasswordFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "passwords.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPasswordsFor(t *testing.T) {
+	p, err := LoadPasswordFile(writePasswordFile(t, `
+passwords:
+  - path: "finance/**/*.xlsx"
+    password: "quarter-close"
+  - path: "*.pdf"
+    password: "pdf-pw"
+  - path: "**"
+    password: "company-default"
+  - path: "**"
+    password: "quarter-close"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Len() != 4 {
+		t.Errorf("Len = %d, want 4", p.Len())
+	}
+	for path, want := range map[string][]string{
+		"/data/finance/2025/q3.xlsx": {"quarter-close", "company-default"},
+		"/data/report.pdf":           {"pdf-pw", "company-default", "quarter-close"},
+		"notes.docx":                 {"company-default", "quarter-close"},
+	} {
+		if got := p.For(path); !reflect.DeepEqual(got, want) {
+			t.Errorf("For(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestNilPasswordsSupplyNone(t *testing.T) {
+	var p *Passwords
+	if got := p.For("a.pdf"); got != nil {
+		t.Errorf("For = %q, want nil", got)
+	}
+	if p.Len() != 0 {
+		t.Errorf("Len = %d, want 0", p.Len())
+	}
+}
+
+func TestLoadPasswordFileRejectsUnusableRules(t *testing.T) {
+	for name, content := range map[string]string{
+		"no path":        "passwords:\n  - password: \"x\"\n",
+		"empty password": "passwords:\n  - path: \"*.pdf\"\n",
+		"bad glob":       "passwords:\n  - path: \"[\"\n    password: \"x\"\n",
+	} {
+		if _, err := LoadPasswordFile(writePasswordFile(t, content)); err == nil {
+			t.Errorf("%s: want an error", name)
+		}
+	}
+}
+
+// The YAML parser quotes the line it failed on, and that line may hold a
+// password. The error names the file and nothing from inside it.
+func TestLoadPasswordFileErrorNeverQuotesContent(t *testing.T) {
+	path := writePasswordFile(t, "passwords:\n  - path: \"*.pdf\"\n    password: [hunter2\n")
+	_, err := LoadPasswordFile(path)
+	if err == nil {
+		t.Fatal("want an error for malformed YAML")
+	}
+	if strings.Contains(err.Error(), "hunter2") {
+		t.Errorf("err = %q quotes the password", err)
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package encryption
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/ledongthuc/pdf"
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+// unlockPDF decrypts a PDF protected by the standard security handler.
+//
+// The text extractor's library (ledongthuc/pdf) opens RC4 and AES-128 documents
+// whose user password is empty and nothing else: no AES-256, no user password.
+// pdfcpu implements every revision of the handler, so it decrypts here and the
+// extractors read its output, a plain PDF, exactly as they read an unencrypted
+// one.
+//
+// A document the text library can open without a password is decrypted too.
+// It is readable, but its Info dictionary and form values are still ciphertext
+// to the metadata and structure extractors, which parse the raw bytes.
+//
+// The empty password is always tried first. It is the user password of every
+// document protected only against editing or printing, which is most encrypted
+// PDFs in circulation, and it needs no entry in the password file.
+func unlockPDF(f *os.File, passwords []string) ([]byte, error) {
+	fi, err := f.Stat()
+	if err != nil || fi.Size() > MaxDecryptSize {
+		return nil, nil
+	}
+
+	if plainPDF(f, fi.Size()) {
+		return nil, nil
+	}
+
+	data, ok := readAllBounded(f)
+	if !ok {
+		return nil, nil
+	}
+
+	candidates := append([]string{""}, passwords...)
+	for _, pw := range candidates {
+		plaintext, err := decryptPDF(data, pw)
+		if err == nil {
+			return plaintext, nil
+		}
+		if !errors.Is(err, pdfcpu.ErrWrongPassword) {
+			// Not a password problem: pdfcpu could not read the document, or found it
+			// was not encrypted after all (the text library fails on plenty of
+			// unencrypted PDFs). Leave it to the extractors, which read it as before.
+			return nil, nil
+		}
+	}
+	return nil, encryptedError("PDF standard security handler", passwords)
+}
+
+// plainPDF is the cheap check that runs for every PDF: it parses only the
+// cross-reference table and trailer. Nearly every PDF is unencrypted, and for
+// those the document is never read into memory here. A trailer the library
+// cannot parse is not known to be plain, so it goes on to pdfcpu.
+func plainPDF(f *os.File, size int64) (plain bool) {
+	defer func() {
+		if recover() != nil {
+			plain = false
+		}
+	}()
+	r, err := pdf.NewReader(f, size)
+	return err == nil && r.Trailer().Key("Encrypt").IsNull()
+}
+
+// decryptPDF runs one attempt. The password is offered as both user and owner
+// password, since an operator who knows a document's owner password can open it
+// with that alone.
+func decryptPDF(data []byte, password string) (plaintext []byte, err error) {
+	// pdfcpu reports most failures as errors, but it is a large parser fed
+	// untrusted bytes, and one panic would take the worker with it.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("pdf decryption panicked: %v", r)
+		}
+	}()
+	conf := model.NewDefaultConfiguration()
+	conf.UserPW = password
+	conf.OwnerPW = password
+	conf.Cmd = model.DECRYPT
+	// Read and written without api.Decrypt's validation pass. That pass holds the
+	// document to the specification (a form field without /DA fails it), which is
+	// right for an editor and wrong here: the extractors read such documents
+	// without complaint, and a decryption that refuses one leaves it unscanned.
+	ctx, err := api.ReadContext(bytes.NewReader(data), conf)
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	if err := api.WriteContext(ctx, &out); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
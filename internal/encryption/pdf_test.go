@@ -0,0 +1,158 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package encryption
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ledongthuc/pdf"
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+// plainPDFBytes builds a one-page PDF showing text, with a correct xref and
+// startxref so both PDF libraries parse it rather than repairing it.
+func plainPDFBytes(text string) []byte {
+	content := "BT /F1 12 Tf 72 700 Td (" + text + ") Tj ET\n"
+	objs := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(content), content),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	}
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objs))
+	for i, o := range objs {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objs)+1, xref)
+	return b.Bytes()
+}
+
+// encryptedPDF writes plainPDFBytes(text) encrypted by pdfcpu under the given
+// passwords and returns its path.
+func encryptedPDF(t *testing.T, text, userPW, ownerPW string, aes bool, keyLength int) string {
+	t.Helper()
+	conf := model.NewDefaultConfiguration()
+	conf.UserPW = userPW
+	conf.OwnerPW = ownerPW
+	conf.EncryptUsingAES = aes
+	conf.EncryptKeyLength = keyLength
+	var out bytes.Buffer
+	if err := api.Encrypt(bytes.NewReader(plainPDFBytes(text)), &out, conf); err != nil {
+		t.Fatalf("encrypting fixture: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "locked.pdf")
+	if err := os.WriteFile(path, out.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// pdfText reads the plaintext back with the text extractor's library, which is
+// what matters: the extractors must be able to use what Unlock returns.
+func pdfText(t *testing.T, data []byte) string {
+	t.Helper()
+	r, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("plaintext does not open as a PDF: %v", err)
+	}
+	rd, err := r.GetPlainText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if _, err := b.ReadFrom(rd); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+func TestUnlockPDF_UserPassword(t *testing.T) {
+	path := encryptedPDF(t, "SSN 536-22-1874", "open-me", "owner-pw", true, 256)
+
+	got, err := Unlock(path, []string{"nope", "open-me"})
+	if err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+	if text := pdfText(t, got); !strings.Contains(text, "536-22-1874") {
+		t.Errorf("decrypted text = %q, want the SSN", text)
+	}
+}
+
+func TestUnlockPDF_OwnerPasswordAlsoOpens(t *testing.T) {
+	path := encryptedPDF(t, "owner opened", "user-pw", "owner-pw", true, 128)
+
+	got, err := Unlock(path, []string{"owner-pw"})
+	if err != nil {
+		t.Fatalf("Unlock with the owner password: %v", err)
+	}
+	if text := pdfText(t, got); !strings.Contains(text, "owner opened") {
+		t.Errorf("decrypted text = %q", text)
+	}
+}
+
+// A document locked only against editing opens with the empty user password,
+// so it is decrypted with no password file at all.
+func TestUnlockPDF_EmptyUserPasswordNeedsNoPasswordFile(t *testing.T) {
+	path := encryptedPDF(t, "print protected", "", "owner-pw", false, 128)
+
+	got, err := Unlock(path, nil)
+	if err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+	if got == nil {
+		t.Fatal("Unlock returned no plaintext for an encrypted PDF")
+	}
+	if text := pdfText(t, got); !strings.Contains(text, "print protected") {
+		t.Errorf("decrypted text = %q", text)
+	}
+}
+
+func TestUnlockPDF_WrongPasswordIsEncrypted(t *testing.T) {
+	path := encryptedPDF(t, "secret", "right", "owner", true, 256)
+
+	_, err := Unlock(path, []string{"wrong-one", "wrong-two"})
+	if !errors.Is(err, ErrEncrypted) {
+		t.Fatalf("err = %v, want ErrEncrypted", err)
+	}
+	if !strings.Contains(err.Error(), "2 supplied password") {
+		t.Errorf("err = %q, want the number of passwords tried", err)
+	}
+	if strings.Contains(err.Error(), "wrong-one") {
+		t.Errorf("err = %q repeats a password", err)
+	}
+}
+
+func TestUnlock_PlainFilesAreLeftAlone(t *testing.T) {
+	dir := t.TempDir()
+	for name, data := range map[string][]byte{
+		"plain.pdf": plainPDFBytes("nothing to see"),
+		"notes.txt": []byte("just text"),
+		"empty.bin": nil,
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		got, err := Unlock(path, []string{"pw"})
+		if got != nil || err != nil {
+			t.Errorf("%s: Unlock = (%d bytes, %v), want (nil, nil)", name, len(got), err)
+		}
+	}
+}
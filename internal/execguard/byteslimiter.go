@@ -5,6 +5,7 @@ package execguard
 
 import (
 	"context"
+	"path/filepath"
 	"sync"
 )
 
@@ -105,3 +106,79 @@ func (bl *BytesLimiter) ReleaseBytes(n int64) {
 	bl.cond.Broadcast()
 	bl.mu.Unlock()
 }
+
+// TryAcquireBytes reserves n bytes if they are free now and reports whether it
+// did; it never waits. A true result MUST be paired with ReleaseBytes(n). Unlike
+// AcquireBytes it does not admit an item larger than the whole budget: a caller
+// that cannot wait decides for itself what to do instead. Always true when the
+// gate is disabled or n <= 0.
+func (bl *BytesLimiter) TryAcquireBytes(n int64) bool {
+	if bl == nil || bl.budget <= 0 || n <= 0 {
+		return true
+	}
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+	if bl.available >= n {
+		bl.available -= n
+		return true
+	}
+	return false
+}
+
+// Files in flight under a shared live-bytes budget, by path.
+//
+// The budget is the run's, built by the worker pool, and the extractors that
+// hold content of their own while they work (the PDF page decoder) have to
+// charge it too, or N workers hold N times their private bound outside it.
+// Extractors receive only a path, so the router holds the run's limiter against
+// the path for as long as it processes the file, the way encryption.Hold makes
+// decrypted bytes available; an extractor that finds none uses a bound of its
+// own.
+var (
+	liveBytesMu sync.Mutex
+	liveBytes   = map[string]*heldLiveBytes{}
+)
+
+type heldLiveBytes struct {
+	limiter *BytesLimiter
+	refs    int
+}
+
+// HoldLiveBytes makes bl what LiveBytesFor(path) returns until the returned
+// release function is called. A nil bl holds nothing.
+func HoldLiveBytes(path string, bl *BytesLimiter) (release func()) {
+	if bl == nil {
+		return func() {}
+	}
+	key := filepath.Clean(path)
+	liveBytesMu.Lock()
+	defer liveBytesMu.Unlock()
+	if h, ok := liveBytes[key]; ok {
+		h.refs++
+	} else {
+		liveBytes[key] = &heldLiveBytes{limiter: bl, refs: 1}
+	}
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			liveBytesMu.Lock()
+			defer liveBytesMu.Unlock()
+			if h, ok := liveBytes[key]; ok {
+				if h.refs--; h.refs == 0 {
+					delete(liveBytes, key)
+				}
+			}
+		})
+	}
+}
+
+// LiveBytesFor returns the shared live-bytes limiter held for path, or nil when
+// the file is not being processed under one.
+func LiveBytesFor(path string) *BytesLimiter {
+	liveBytesMu.Lock()
+	defer liveBytesMu.Unlock()
+	if h, ok := liveBytes[filepath.Clean(path)]; ok {
+		return h.limiter
+	}
+	return nil
+}
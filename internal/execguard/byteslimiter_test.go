@@ -157,3 +157,45 @@ func TestBytesLimiter_ConcurrentNeverExceedsBudget(t *testing.T) {
 		t.Fatalf("concurrently-held bytes %d exceeded budget %d", maxSeen, budget)
 	}
 }
+
+func TestBytesLimiter_TryAcquireNeverWaits(t *testing.T) {
+	bl := NewBytesLimiter(100)
+	if !bl.TryAcquireBytes(60) {
+		t.Fatal("60 of 100 free bytes: want reserved")
+	}
+	if bl.TryAcquireBytes(50) {
+		t.Error("50 with 40 free: want refused, not waited for")
+	}
+	if bl.TryAcquireBytes(200) {
+		t.Error("an item larger than the budget is not admitted by TryAcquireBytes")
+	}
+	bl.ReleaseBytes(60)
+	if !bl.TryAcquireBytes(100) {
+		t.Error("after release the whole budget must be free")
+	}
+	if !NewBytesLimiter(0).TryAcquireBytes(1 << 40) {
+		t.Error("a disabled gate must always admit")
+	}
+}
+
+func TestHoldLiveBytes(t *testing.T) {
+	bl := NewBytesLimiter(10)
+	release := HoldLiveBytes("dir/../doc.pdf", bl)
+	again := HoldLiveBytes("doc.pdf", bl)
+	if LiveBytesFor("doc.pdf") != bl {
+		t.Fatal("a held limiter must be found by the cleaned path")
+	}
+	release()
+	release()
+	if LiveBytesFor("doc.pdf") != bl {
+		t.Error("the second holder's hold was dropped by the first release")
+	}
+	again()
+	if LiveBytesFor("doc.pdf") != nil {
+		t.Error("released by every holder: want nothing held")
+	}
+	HoldLiveBytes("other.pdf", nil)()
+	if LiveBytesFor("other.pdf") != nil {
+		t.Error("a nil limiter holds nothing")
+	}
+}
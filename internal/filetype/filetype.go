@@ -0,0 +1,557 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package filetype identifies the format of a file from its content.
+//
+// The preprocessors and redactors choose how to read a file by its extension,
+// so a file whose name does not say what it is was read as something else, or
+// not at all: a .docx renamed .dat, a PDF saved as .txt. Detect reads the
+// file's signature instead, for every format the preprocessors read. The router
+// reads such a file as the format it is, and a name that contradicts the
+// content is reported (FILE_TYPE_MISMATCH): renaming a file is the cheapest way
+// past a filter that trusts names.
+package filetype
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	textextractcolumnarlib "github.com/awslabs/ferret-scan/v2/internal/preprocessors/text-extractors/text-extract-columnarlib"
+	textextractsqlitelib "github.com/awslabs/ferret-scan/v2/internal/preprocessors/text-extractors/text-extract-sqlitelib"
+	"github.com/richardlehane/mscfb"
+)
+
+// Format is a file format Detect can identify.
+type Format struct {
+	// Name describes the format in a report: "Word document".
+	Name string
+	// Extensions are the extensions, lower case with the dot, a file of the
+	// format is saved under. The first is the one the preprocessors read the
+	// format by.
+	Extensions []string
+}
+
+// Ext returns the extension the preprocessors read the format by.
+func (f *Format) Ext() string {
+	return f.Extensions[0]
+}
+
+// Claims reports whether ext, in any case, is an extension the format is saved
+// under.
+func (f *Format) Claims(ext string) bool {
+	ext = strings.ToLower(ext)
+	for _, e := range f.Extensions {
+		if e == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// Contradicts reports whether the name of the file at path says it is
+// something other than f: it has an extension, and not one f is saved under. A
+// name without an extension claims nothing.
+func (f *Format) Contradicts(path string) bool {
+	ext := filepath.Ext(path)
+	return ext != "" && !f.Claims(ext)
+}
+
+// The formats Detect identifies. A format saved under several extensions
+// claims them all, so a .jpeg, a .docm or a .m4b is not a mismatch; a .zip is
+// claimed by the Office and OpenDocument formats, which are zip archives and
+// are often named as one.
+var (
+	PDF = &Format{Name: "PDF document", Extensions: []string{".pdf", ".ai"}}
+
+	Word       = &Format{Name: "Word document", Extensions: []string{".docx", ".docm", ".dotx", ".dotm", ".zip"}}
+	Excel      = &Format{Name: "Excel workbook", Extensions: []string{".xlsx", ".xlsm", ".xltx", ".xltm", ".xlam", ".zip"}}
+	PowerPoint = &Format{Name: "PowerPoint presentation", Extensions: []string{".pptx", ".pptm", ".potx", ".potm", ".ppsx", ".ppsm", ".ppam", ".zip"}}
+
+	OpenDocumentText         = &Format{Name: "OpenDocument text", Extensions: []string{".odt", ".ott", ".zip"}}
+	OpenDocumentSpreadsheet  = &Format{Name: "OpenDocument spreadsheet", Extensions: []string{".ods", ".ots", ".zip"}}
+	OpenDocumentPresentation = &Format{Name: "OpenDocument presentation", Extensions: []string{".odp", ".otp", ".zip"}}
+
+	LegacyWord       = &Format{Name: "Word 97-2003 document", Extensions: []string{".doc", ".dot"}}
+	LegacyExcel      = &Format{Name: "Excel 97-2003 workbook", Extensions: []string{".xls", ".xlt", ".xla"}}
+	LegacyPowerPoint = &Format{Name: "PowerPoint 97-2003 presentation", Extensions: []string{".ppt", ".pps", ".pot"}}
+	OutlookMessage   = &Format{Name: "Outlook message", Extensions: []string{".msg", ".oft"}}
+	RTF              = &Format{Name: "RTF document", Extensions: []string{".rtf", ".doc"}}
+
+	JPEG = &Format{Name: "JPEG image", Extensions: []string{".jpg", ".jpeg", ".jpe", ".jfif"}}
+	PNG  = &Format{Name: "PNG image", Extensions: []string{".png", ".apng"}}
+	GIF  = &Format{Name: "GIF image", Extensions: []string{".gif"}}
+	WebP = &Format{Name: "WebP image", Extensions: []string{".webp"}}
+	TIFF = &Format{Name: "TIFF image", Extensions: []string{".tif", ".tiff", ".dng", ".cr2", ".nef", ".nrw", ".arw", ".srf", ".sr2", ".pef", ".3fr", ".erf", ".mos", ".iiq", ".rwl"}}
+	BMP  = &Format{Name: "BMP image", Extensions: []string{".bmp", ".dib"}}
+	HEIF = &Format{Name: "HEIF image", Extensions: []string{".heic", ".heif", ".hif"}}
+	AVIF = &Format{Name: "AVIF image", Extensions: []string{".avif", ".heif"}}
+
+	MP3  = &Format{Name: "MPEG audio", Extensions: []string{".mp3", ".mp2", ".mpga"}}
+	WAV  = &Format{Name: "WAV audio", Extensions: []string{".wav", ".wave"}}
+	FLAC = &Format{Name: "FLAC audio", Extensions: []string{".flac"}}
+	Ogg  = &Format{Name: "Ogg media", Extensions: []string{".ogg", ".oga", ".opus", ".ogv", ".ogx", ".spx"}}
+	AIFF = &Format{Name: "AIFF audio", Extensions: []string{".aiff", ".aif", ".aifc"}}
+	M4A  = &Format{Name: "MPEG-4 audio", Extensions: []string{".m4a", ".m4b", ".m4p", ".m4r", ".mp4"}}
+
+	MP4       = &Format{Name: "MPEG-4 video", Extensions: []string{".mp4", ".m4v", ".m4a", ".m4b", ".m4p", ".m4r", ".mov", ".3gp", ".3g2", ".f4v"}}
+	QuickTime = &Format{Name: "QuickTime movie", Extensions: []string{".mov", ".qt", ".mp4", ".m4v"}}
+	WebM      = &Format{Name: "WebM video", Extensions: []string{".webm", ".mkv"}}
+	Matroska  = &Format{Name: "Matroska video", Extensions: []string{".mkv", ".mka", ".mks", ".mk3d", ".webm"}}
+	AVI       = &Format{Name: "AVI video", Extensions: []string{".avi"}}
+
+	SQLite  = &Format{Name: "SQLite database", Extensions: []string{".sqlite", ".sqlite3", ".db", ".db3", ".s3db", ".sl3", ".sqlitedb", ".gpkg", ".mbtiles"}}
+	Parquet = &Format{Name: "Parquet file", Extensions: []string{".parquet", ".parq", ".pqt"}}
+	Avro    = &Format{Name: "Avro file", Extensions: []string{".avro"}}
+)
+
+// Formats lists every format Detect identifies.
+var Formats = []*Format{
+	PDF, Word, Excel, PowerPoint, OpenDocumentText, OpenDocumentSpreadsheet, OpenDocumentPresentation,
+	LegacyWord, LegacyExcel, LegacyPowerPoint, OutlookMessage, RTF,
+	JPEG, PNG, GIF, WebP, TIFF, BMP, HEIF, AVIF,
+	MP3, WAV, FLAC, Ogg, AIFF, M4A, MP4, QuickTime, WebM, Matroska, AVI,
+	SQLite, Parquet, Avro,
+}
+
+// headSize is how much of a file the signatures are read from. Every signature
+// sits in the first few dozen bytes; the rest is room for the second frame an
+// MPEG audio stream without an ID3 tag must show to be told from noise.
+const headSize = 4096
+
+// maxMarkerBytes bounds what is read of a zip entry that names a format: the
+// mimetype entry of an OpenDocument file.
+const maxMarkerBytes = 128
+
+// Detect identifies the format of the file at path from its content. It
+// returns nil for a file in none of the formats above, which includes every
+// text file, and an error only when the file cannot be read.
+func Detect(path string) (*Format, error) {
+	f, err := os.Open(filepath.Clean(path)) // #nosec G304 -- path vetted by the caller
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return DetectReader(f, info.Size())
+}
+
+// DetectReader is Detect for content of the given size read through r.
+func DetectReader(r io.ReaderAt, size int64) (*Format, error) {
+	head := make([]byte, min(size, headSize))
+	if _, err := r.ReadAt(head, 0); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	switch {
+	case bytes.HasPrefix(head, []byte("%PDF-")):
+		return PDF, nil
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")):
+		return detectZip(r, size), nil
+	case bytes.HasPrefix(head, []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}):
+		return detectOLE(r, size), nil
+	case bytes.HasPrefix(head, []byte{0xFF, 0xD8, 0xFF}):
+		return JPEG, nil
+	case bytes.HasPrefix(head, []byte("\x89PNG\r\n\x1a\n")):
+		return PNG, nil
+	case bytes.HasPrefix(head, []byte("GIF87a")), bytes.HasPrefix(head, []byte("GIF89a")):
+		return GIF, nil
+	case len(head) >= 12 && string(head[:4]) == "RIFF":
+		return riffFormats[string(head[8:12])], nil
+	case len(head) >= 12 && string(head[:4]) == "FORM" && (string(head[8:12]) == "AIFF" || string(head[8:12]) == "AIFC"):
+		return AIFF, nil
+	case isTIFF(head):
+		return TIFF, nil
+	case isBMP(head):
+		return BMP, nil
+	case len(head) >= 8 && string(head[4:8]) == "ftyp":
+		return detectFtyp(head), nil
+	case isQuickTimeAtom(head, size):
+		return QuickTime, nil
+	case bytes.HasPrefix(head, []byte{0x1A, 0x45, 0xDF, 0xA3}):
+		return detectEBML(head), nil
+	case bytes.HasPrefix(head, []byte("fLaC")):
+		return FLAC, nil
+	case bytes.HasPrefix(head, []byte("OggS")):
+		return Ogg, nil
+	case bytes.HasPrefix(head, []byte("ID3")):
+		return detectID3(r, head, size), nil
+	case textextractsqlitelib.IsSQLite(head):
+		return SQLite, nil
+	case textextractcolumnarlib.IsParquet(head):
+		return Parquet, nil
+	case textextractcolumnarlib.IsAvro(head):
+		return Avro, nil
+	case bytes.HasPrefix(head, []byte(`{\rtf`)):
+		return RTF, nil
+	case isMPEGAudio(head):
+		return MP3, nil
+	}
+	return nil, nil
+}
+
+// riffFormats are the RIFF forms Detect identifies, by form type.
+var riffFormats = map[string]*Format{
+	"WEBP": WebP,
+	"WAVE": WAV,
+	"AVI ": AVI,
+}
+
+// ooxmlMainParts are the main parts of the Office packages the preprocessors
+// read. A package is identified by its main part, not by its folders: an .xlsb
+// workbook has an xl/ folder too, but its main part is xl/workbook.bin, which
+// no preprocessor reads and which its name does not misstate.
+var ooxmlMainParts = map[string]*Format{
+	"word/document.xml":    Word,
+	"xl/workbook.xml":      Excel,
+	"ppt/presentation.xml": PowerPoint,
+}
+
+// detectZip tells an Office or OpenDocument package from other zip archives by
+// the entries it holds. A plain zip, and an Office package of any other kind,
+// is not identified.
+func detectZip(r io.ReaderAt, size int64) *Format {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil
+	}
+	var contentTypes bool
+	var main *Format
+	for _, f := range zr.File {
+		switch {
+		case f.Name == "mimetype":
+			return openDocumentFormat(f)
+		case f.Name == "[Content_Types].xml":
+			contentTypes = true
+		case ooxmlMainParts[f.Name] != nil && main == nil:
+			main = ooxmlMainParts[f.Name]
+		}
+	}
+	if !contentTypes {
+		return nil
+	}
+	return main
+}
+
+// openDocumentFormat reads the mimetype entry an OpenDocument package begins
+// with.
+func openDocumentFormat(f *zip.File) *Format {
+	rc, err := f.Open()
+	if err != nil {
+		return nil
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, maxMarkerBytes))
+	if err != nil {
+		return nil
+	}
+	mimeType := strings.TrimSpace(string(data))
+	for prefix, format := range map[string]*Format{
+		"application/vnd.oasis.opendocument.text":         OpenDocumentText,
+		"application/vnd.oasis.opendocument.spreadsheet":  OpenDocumentSpreadsheet,
+		"application/vnd.oasis.opendocument.presentation": OpenDocumentPresentation,
+	} {
+		// The template types extend these: ...text-template.
+		if mimeType == prefix || mimeType == prefix+"-template" {
+			return format
+		}
+	}
+	return nil
+}
+
+// detectOLE tells the compound files the preprocessors read apart by their
+// root streams. An encrypted OOXML package is a compound file too, and is not
+// identified: whether it is a Word, Excel or PowerPoint file is inside the
+// encryption.
+func detectOLE(r io.ReaderAt, size int64) (format *Format) {
+	// mscfb follows the sector chains the file declares; a hostile chain is the
+	// extractors' to report, not a reason to lose the worker here.
+	defer func() {
+		if recover() != nil {
+			format = nil
+		}
+	}()
+	doc, err := mscfb.New(io.NewSectionReader(r, 0, size))
+	if err != nil {
+		return nil
+	}
+	for entry, nerr := doc.Next(); nerr == nil; entry, nerr = doc.Next() {
+		if len(entry.Path) != 0 {
+			continue
+		}
+		switch {
+		case entry.Name == "WordDocument":
+			return LegacyWord
+		case entry.Name == "Workbook", entry.Name == "Book":
+			return LegacyExcel
+		case entry.Name == "PowerPoint Document":
+			return LegacyPowerPoint
+		case strings.HasPrefix(entry.Name, "__substg1.0_"), entry.Name == "__properties_version1.0":
+			return OutlookMessage
+		}
+	}
+	return nil
+}
+
+func isTIFF(head []byte) bool {
+	for _, sig := range []string{"II*\x00", "MM\x00*", "II+\x00", "MM\x00+"} {
+		if bytes.HasPrefix(head, []byte(sig)) {
+			return true
+		}
+	}
+	return false
+}
+
+// isBMP checks the size of the DIB header after the file header as well as the
+// "BM" the file header begins with, which on its own opens plenty of text.
+func isBMP(head []byte) bool {
+	if len(head) < 18 || string(head[:2]) != "BM" {
+		return false
+	}
+	switch binary.LittleEndian.Uint32(head[14:18]) {
+	case 12, 40, 52, 56, 64, 108, 124:
+		return true
+	}
+	return false
+}
+
+// heifBrands are the ISO-BMFF brands of a HEIF image or image sequence; the
+// AVIF brands are told apart from them.
+var heifBrands = map[string]bool{
+	"mif1": true, "mif2": true, "msf1": true, "heic": true, "heix": true, "heim": true,
+	"heis": true, "hevc": true, "hevx": true, "hevm": true, "hevs": true,
+}
+
+// mp4BrandPrefixes begin the major brands of MPEG-4 and 3GPP video. A brand
+// not among them, such as Canon's "crx " raw images, is not identified.
+var mp4BrandPrefixes = []string{"iso", "mp4", "mp7", "avc1", "dash", "M4V", "3gp", "3g2", "f4v", "mmp4", "MSNV", "XAVC", "NDAS"}
+
+// detectFtyp reads the ftyp box an ISO base media file begins with: its major
+// brand, then its compatible brands.
+func detectFtyp(head []byte) *Format {
+	boxSize := int(binary.BigEndian.Uint32(head[:4]))
+	if boxSize < 16 || boxSize > len(head) {
+		return nil
+	}
+	major := string(head[8:12])
+	brands := []string{major}
+	for i := 16; i+4 <= boxSize; i += 4 {
+		brands = append(brands, string(head[i:i+4]))
+	}
+	var heif bool
+	for _, b := range brands {
+		if b == "avif" || b == "avis" {
+			return AVIF
+		}
+		heif = heif || heifBrands[b]
+	}
+	switch {
+	case heif:
+		return HEIF
+	case major == "M4A " || major == "M4B " || major == "M4P ":
+		return M4A
+	case major == "qt  ":
+		return QuickTime
+	}
+	for _, p := range mp4BrandPrefixes {
+		if strings.HasPrefix(major, p) {
+			return MP4
+		}
+	}
+	return nil
+}
+
+// isQuickTimeAtom recognises a QuickTime movie from before ftyp boxes, which
+// begins directly with one of its top-level atoms.
+func isQuickTimeAtom(head []byte, size int64) bool {
+	if len(head) < 8 {
+		return false
+	}
+	switch string(head[4:8]) {
+	case "moov", "mdat", "wide", "pnot":
+	default:
+		return false
+	}
+	atomSize := int64(binary.BigEndian.Uint32(head[:4]))
+	return atomSize == 1 || (atomSize >= 8 && atomSize <= size)
+}
+
+// detectEBML tells WebM from other Matroska files by the DocType element of
+// the EBML header, which defaults to "matroska".
+func detectEBML(head []byte) *Format {
+	window := head[:min(len(head), 64)]
+	i := bytes.Index(window, []byte{0x42, 0x82})
+	if i < 0 || i+3 > len(window) {
+		return Matroska
+	}
+	// The element size is an EBML variable-length integer: its width is one
+	// more than the leading zero bits of its first byte.
+	first := window[i+2]
+	width := 1
+	for width <= 8 && first&(0x80>>(width-1)) == 0 {
+		width++
+	}
+	if width > 8 || i+2+width > len(window) {
+		return Matroska
+	}
+	n := int(first & (0xFF >> width))
+	for _, b := range window[i+3 : i+2+width] {
+		n = n<<8 | int(b)
+	}
+	start := i + 2 + width
+	if start+n > len(window) {
+		return Matroska
+	}
+	if string(bytes.TrimRight(window[start:start+n], "\x00")) == "webm" {
+		return WebM
+	}
+	return Matroska
+}
+
+// detectID3 looks past an ID3v2 tag at what follows it. The tag is not the
+// format: a FLAC stream can carry one, and so can an AAC stream, which is not
+// identified.
+func detectID3(r io.ReaderAt, head []byte, size int64) *Format {
+	if len(head) < 10 {
+		return nil
+	}
+	// The tag size is synchsafe: 7 bits in each of four bytes.
+	tagSize := int64(head[6]&0x7F)<<21 | int64(head[7]&0x7F)<<14 | int64(head[8]&0x7F)<<7 | int64(head[9]&0x7F)
+	offset := 10 + tagSize
+	if head[5]&0x10 != 0 {
+		offset += 10 // footer
+	}
+	var next [4]byte
+	if offset+int64(len(next)) > size {
+		return nil
+	}
+	if _, err := r.ReadAt(next[:], offset); err != nil {
+		return nil
+	}
+	switch {
+	case string(next[:]) == "fLaC":
+		return FLAC
+	case frameLength(next[:]) > 0:
+		return MP3
+	}
+	return nil
+}
+
+// isMPEGAudio recognises an MPEG audio stream with no ID3 tag. Its frame sync
+// is only 11 bits, which binary data holds by chance, so a second frame header
+// must follow where the first frame ends.
+func isMPEGAudio(head []byte) bool {
+	n := frameLength(head)
+	return n > 0 && n+4 <= len(head) && frameLength(head[n:]) > 0
+}
+
+// Bitrates in kbit/s by bitrate index, and sample rates in Hz by sample-rate
+// index, of MPEG-1, MPEG-2 and MPEG-2.5 audio.
+var (
+	bitratesV1L1 = [15]int{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448}
+	bitratesV1L2 = [15]int{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384}
+	bitratesV1L3 = [15]int{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320}
+	bitratesV2L1 = [15]int{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256}
+	bitratesV2L2 = [15]int{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160}
+
+	sampleRatesV1  = [3]int{44100, 48000, 32000}
+	sampleRatesV2  = [3]int{22050, 24000, 16000}
+	sampleRatesV25 = [3]int{11025, 12000, 8000}
+)
+
+// frameLength returns the length in bytes of the MPEG audio frame whose header
+// begins b, or 0 when b does not begin a valid header. Free-format frames have
+// no length in their header and are not recognised; layer 0 is the ADTS header
+// of AAC, not MPEG audio.
+func frameLength(b []byte) int {
+	if len(b) < 4 || b[0] != 0xFF || b[1]&0xE0 != 0xE0 {
+		return 0
+	}
+	version := (b[1] >> 3) & 0x03 // 0: MPEG-2.5, 1: reserved, 2: MPEG-2, 3: MPEG-1
+	layer := (b[1] >> 1) & 0x03   // 0: reserved, 1: III, 2: II, 3: I
+	bitrateIndex := int(b[2] >> 4)
+	rateIndex := int(b[2]>>2) & 0x03
+	padding := int(b[2]>>1) & 0x01
+	if version == 1 || layer == 0 || bitrateIndex == 0 || bitrateIndex == 15 || rateIndex == 3 {
+		return 0
+	}
+
+	var bitrates *[15]int
+	var sampleRate int
+	switch version {
+	case 3:
+		sampleRate = sampleRatesV1[rateIndex]
+		bitrates = map[byte]*[15]int{3: &bitratesV1L1, 2: &bitratesV1L2, 1: &bitratesV1L3}[layer]
+	default:
+		if version == 2 {
+			sampleRate = sampleRatesV2[rateIndex]
+		} else {
+			sampleRate = sampleRatesV25[rateIndex]
+		}
+		bitrates = &bitratesV2L2
+		if layer == 3 {
+			bitrates = &bitratesV2L1
+		}
+	}
+	bitrate := bitrates[bitrateIndex] * 1000
+
+	switch {
+	case layer == 3:
+		return (12*bitrate/sampleRate + padding) * 4
+	case layer == 1 && version != 3:
+		// Layer III of MPEG-2 and 2.5 has half as many samples per frame.
+		return 72*bitrate/sampleRate + padding
+	default:
+		return 144*bitrate/sampleRate + padding
+	}
+}
+
+// Alias makes a path that reads as the file at path but is named with ext: a
+// symlink in a directory of its own, or a copy where symlinks are not
+// available. The preprocessors and redactors choose how to read a file by its
+// extension, so a file whose name contradicts its content is handed to them
+// through an alias named for its format. release removes the alias.
+func Alias(path, ext string) (alias string, release func(), err error) {
+	dir, err := os.MkdirTemp("", "ferret-content-*")
+	if err != nil {
+		return "", nil, err
+	}
+	alias = filepath.Join(dir, filepath.Base(path)+ext)
+	if err := linkOrCopy(path, alias); err != nil {
+		_ = os.RemoveAll(dir)
+		return "", nil, err
+	}
+	return alias, func() { _ = os.RemoveAll(dir) }, nil
+}
+
+// linkOrCopy makes target read as source.
+func linkOrCopy(source, target string) error {
+	abs, err := filepath.Abs(source)
+	if err != nil {
+		return err
+	}
+	if os.Symlink(abs, target) == nil {
+		return nil
+	}
+	in, err := os.Open(filepath.Clean(source)) // #nosec G304 -- path vetted by the caller
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600) // #nosec G304 -- inside our own MkdirTemp
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
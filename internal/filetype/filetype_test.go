@@ -0,0 +1,194 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package filetype
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/awslabs/ferret-scan/v2/internal/olefixture"
+)
+
+func zipOf(t *testing.T, names ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name == "mimetype" {
+			_, err = w.Write([]byte("application/vnd.oasis.opendocument.spreadsheet"))
+		} else {
+			_, err = w.Write([]byte("<x/>"))
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// ftyp returns an ftyp box with the given major and compatible brands.
+func ftyp(major string, compatible ...string) []byte {
+	box := binary.BigEndian.AppendUint32(nil, uint32(16+4*len(compatible)))
+	box = append(box, "ftyp"+major+"\x00\x00\x00\x00"...)
+	for _, c := range compatible {
+		box = append(box, c...)
+	}
+	return append(box, make([]byte, 32)...)
+}
+
+// mpegFrames returns n MPEG-1 Layer III frames at 128 kbit/s and 44.1 kHz:
+// 417 bytes each.
+func mpegFrames(n int) []byte {
+	var out []byte
+	for i := 0; i < n; i++ {
+		frame := make([]byte, 417)
+		copy(frame, []byte{0xFF, 0xFB, 0x90, 0x00})
+		out = append(out, frame...)
+	}
+	return out
+}
+
+func TestDetect(t *testing.T) {
+	bmp := append([]byte("BM"), make([]byte, 12)...)
+	bmp = append(binary.LittleEndian.AppendUint32(bmp, 40), make([]byte, 40)...)
+	// A four-byte ID3v2.3 tag; full slice so each append below copies it.
+	id3 := []byte("ID3\x03\x00\x00\x00\x00\x00\x04abcd")
+	id3 = id3[:len(id3):len(id3)]
+
+	cases := []struct {
+		name string
+		data []byte
+		want *Format
+	}{
+		{"pdf", []byte("%PDF-1.7\n1 0 obj\n"), PDF},
+		{"docx", zipOf(t, "[Content_Types].xml", "_rels/.rels", "word/document.xml"), Word},
+		{"xlsx", zipOf(t, "[Content_Types].xml", "xl/workbook.xml"), Excel},
+		{"pptx", zipOf(t, "[Content_Types].xml", "ppt/presentation.xml"), PowerPoint},
+		{"ods", zipOf(t, "mimetype", "content.xml"), OpenDocumentSpreadsheet},
+		{"plain zip", zipOf(t, "notes.txt"), nil},
+		{"xlsb", zipOf(t, "[Content_Types].xml", "_rels/.rels", "xl/workbook.bin", "xl/worksheets/sheet1.bin", "xl/styles.bin"), nil},
+		{"sldx", zipOf(t, "[Content_Types].xml", "_rels/.rels", "ppt/slides/slide1.xml"), nil},
+		{"word part without content types", zipOf(t, "word/document.xml"), nil},
+		{"doc", olefixture.MustBuild([]olefixture.Stream{{Name: "WordDocument", Data: make([]byte, 64)}}), LegacyWord},
+		{"xls", olefixture.MustBuild([]olefixture.Stream{{Name: "Workbook", Data: make([]byte, 64)}}), LegacyExcel},
+		{"ppt", olefixture.MustBuild([]olefixture.Stream{{Name: "PowerPoint Document", Data: make([]byte, 64)}}), LegacyPowerPoint},
+		{"msg", olefixture.MustBuild([]olefixture.Stream{{Name: "__substg1.0_0037001F", Data: []byte("S\x00")}}), OutlookMessage},
+		{"encrypted ooxml", olefixture.MustBuild([]olefixture.Stream{{Name: "EncryptionInfo", Data: make([]byte, 64)}, {Name: "EncryptedPackage", Data: make([]byte, 64)}}), nil},
+		{"rtf", []byte(`{\rtf1\ansi Hello}`), RTF},
+		{"jpeg", []byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x10, 'J', 'F', 'I', 'F'}, JPEG},
+		{"png", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), PNG},
+		{"gif", []byte("GIF89a\x01\x00\x01\x00"), GIF},
+		{"webp", []byte("RIFF\x00\x00\x00\x00WEBPVP8 "), WebP},
+		{"wav", []byte("RIFF\x00\x00\x00\x00WAVEfmt "), WAV},
+		{"avi", []byte("RIFF\x00\x00\x00\x00AVI LIST"), AVI},
+		{"other riff", []byte("RIFF\x00\x00\x00\x00RMIDdata"), nil},
+		{"tiff", []byte("II*\x00\x08\x00\x00\x00"), TIFF},
+		{"bmp", bmp, BMP},
+		{"text starting BM", []byte("BM is a fine abbreviation for a bowel movement."), nil},
+		{"heic", ftyp("heic", "mif1", "heic"), HEIF},
+		{"avif", ftyp("avif", "mif1", "avif"), AVIF},
+		{"avif by compatible brand", ftyp("mif1", "avif"), AVIF},
+		{"m4a", ftyp("M4A ", "isom"), M4A},
+		{"mov", ftyp("qt  ", "qt  "), QuickTime},
+		{"mp4", ftyp("isom", "iso2", "mp41"), MP4},
+		{"canon raw", ftyp("crx ", "isom"), nil},
+		{"old quicktime", append([]byte{0, 0, 0, 8}, "wide\x00\x00\x00\x10mdat"...), QuickTime},
+		{"webm", []byte{0x1A, 0x45, 0xDF, 0xA3, 0x9F, 0x42, 0x86, 0x81, 0x01, 0x42, 0x82, 0x84, 'w', 'e', 'b', 'm'}, WebM},
+		{"mkv", []byte{0x1A, 0x45, 0xDF, 0xA3, 0x9F, 0x42, 0x82, 0x88, 'm', 'a', 't', 'r', 'o', 's', 'k', 'a'}, Matroska},
+		{"flac", []byte("fLaC\x00\x00\x00\x22"), FLAC},
+		{"ogg", []byte("OggS\x00\x02"), Ogg},
+		{"aiff", []byte("FORM\x00\x00\x00\x00AIFFCOMM"), AIFF},
+		{"mp3 with id3", append(id3, mpegFrames(1)...), MP3},
+		{"flac with id3", append(id3, "fLaC"...), FLAC},
+		{"aac with id3", append(id3, 0xFF, 0xF1, 0x50, 0x80), nil},
+		{"mp3 without id3", mpegFrames(3), MP3},
+		{"one frame sync", append([]byte{0xFF, 0xFB, 0x90, 0x00}, make([]byte, 600)...), nil},
+		{"sqlite", append([]byte("SQLite format 3\x00"), make([]byte, 84)...), SQLite},
+		{"parquet", []byte("PAR1\x15\x04"), Parquet},
+		{"avro", []byte("Obj\x01\x04\x14avro"), Avro},
+		{"text", []byte("name,ssn\nJane,123-45-6789\n"), nil},
+		{"empty", nil, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := DetectReader(bytes.NewReader(tc.data), int64(len(tc.data)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("DetectReader = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDetectReadsAFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.txt")
+	if err := os.WriteFile(path, []byte("%PDF-1.4\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Detect(path)
+	if err != nil || got != PDF {
+		t.Fatalf("Detect = %v, %v; want PDF", got, err)
+	}
+	if !got.Contradicts(path) {
+		t.Error("a PDF named .txt must contradict its name")
+	}
+	if _, err := Detect(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("Detect of a missing file: want an error")
+	}
+}
+
+// A binary workbook holds an xl/ folder like any Excel package, and is no
+// Excel workbook the preprocessors read; its name is not a mismatch.
+func TestBinaryWorkbookIsNotAMismatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "budget.xlsb")
+	data := zipOf(t, "[Content_Types].xml", "_rels/.rels", "docProps/app.xml",
+		"xl/workbook.bin", "xl/_rels/workbook.bin.rels", "xl/worksheets/sheet1.bin", "xl/sharedStrings.bin", "xl/styles.bin")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Detect(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != nil && got.Contradicts(path) {
+		t.Errorf("Detect = %s, which contradicts .xlsb", got.Name)
+	}
+}
+
+func TestContradicts(t *testing.T) {
+	cases := []struct {
+		format *Format
+		path   string
+		want   bool
+	}{
+		{Word, "plan.docx", false},
+		{Word, "PLAN.DOCM", false},
+		{Word, "plan.zip", false},
+		{Word, "plan.dat", true},
+		{Word, "plan", false},
+		{JPEG, "photo.jpeg", false},
+		{JPEG, "photo.png", true},
+		{RTF, "letter.doc", false},
+		{LegacyWord, "letter.rtf", true},
+		{PDF, "archive.tar.gz", true},
+	}
+	for _, tc := range cases {
+		if got := tc.format.Contradicts(tc.path); got != tc.want {
+			t.Errorf("%s.Contradicts(%q) = %v, want %v", tc.format.Name, tc.path, got, tc.want)
+		}
+	}
+}
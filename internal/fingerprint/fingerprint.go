@@ -0,0 +1,440 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package fingerprint keeps a store of winnowed shingle hashes of registered
+// documents, and measures how much of a scanned text was copied from each of
+// them.
+//
+// A document's text is split into words (see words), every run of ShingleWords
+// consecutive words is hashed, and winnowing keeps a fraction of the hashes
+// chosen so that any run of Window+ShingleWords-1 words shared with another
+// text yields a shared fingerprint. A copy is found through reflowing,
+// reformatting, a change of case or punctuation and a change of file format,
+// and an excerpt is found inside a longer text.
+//
+// The store holds no text: only 64-bit hashes of word runs and the names the
+// documents were registered under. The hashes are not salted, since nothing is
+// gained by it: a run of six words cannot be guessed, and anyone holding a
+// candidate document can tell whether it was registered whatever the store
+// holds. The names are kept in the clear, since a finding names its source.
+package fingerprint
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// ShingleWords is the number of words hashed into each shingle. Fewer
+	// make stock phrases ("in accordance with the terms of") match between
+	// unrelated documents; more let a small edit break more shingles.
+	ShingleWords = 6
+
+	// Window is the winnowing window, in shingles. A wider window keeps fewer
+	// fingerprints and needs a longer shared run before one is guaranteed.
+	Window = 8
+
+	// MinMatched is the fewest shared fingerprints a match is reported on,
+	// whatever its similarity: a short text that shares one sentence with a
+	// document is 100% similar to it by overlap, and is not a copy.
+	MinMatched = 4
+)
+
+// magic begins every store file.
+var magic = []byte("ferret-fingerprints 1\n")
+
+// ErrNotStore is returned for a file that is not a store, or is damaged.
+var ErrNotStore = errors.New("not a ferret-scan fingerprint store")
+
+// ErrTooShort is returned by Add for a text with too few words to fingerprint.
+var ErrTooShort = fmt.Errorf("fewer than %d words of text to fingerprint", ShingleWords)
+
+// entry is one fingerprint of one document.
+type entry struct {
+	hash uint64
+	doc  uint32
+}
+
+// entrySize is the encoded size of an entry.
+const entrySize = 8 + 4
+
+// Document is a registered document: the name it was registered under and the
+// number of distinct fingerprints it has.
+type Document struct {
+	Name         string
+	Fingerprints int
+}
+
+// Store is a set of registered documents' fingerprints. A loaded store is safe
+// for concurrent Compare calls; Add must not run concurrently with anything.
+type Store struct {
+	docs []Document
+	// entries is sorted by hash, then document, with no duplicates.
+	entries []entry
+}
+
+// NewStore returns an empty store.
+func NewStore() *Store {
+	return &Store{}
+}
+
+// Documents returns the registered documents, in the order they were first
+// registered.
+func (s *Store) Documents() []Document { return append([]Document(nil), s.docs...) }
+
+// Add registers text as the document name, replacing a document registered
+// under the same name, and returns its number of fingerprints.
+func (s *Store) Add(name, text string) (int, error) {
+	prints := winnow(shingles(words(text), ShingleWords), Window)
+	if len(prints) == 0 {
+		return 0, ErrTooShort
+	}
+	if len(name) > math.MaxUint16 {
+		return 0, fmt.Errorf("document name is %d bytes; at most %d are supported", len(name), math.MaxUint16)
+	}
+
+	doc := -1
+	for i, d := range s.docs {
+		if d.Name == name {
+			doc = i
+			break
+		}
+	}
+	if doc < 0 {
+		if len(s.docs) == math.MaxUint32 {
+			return 0, fmt.Errorf("the store holds %d documents; no more can be added", len(s.docs))
+		}
+		doc = len(s.docs)
+		s.docs = append(s.docs, Document{Name: name})
+	} else {
+		kept := s.entries[:0]
+		for _, e := range s.entries {
+			if int(e.doc) != doc {
+				kept = append(kept, e)
+			}
+		}
+		s.entries = kept
+	}
+
+	seen := make(map[uint64]bool, len(prints))
+	for _, p := range prints {
+		if !seen[p.hash] {
+			seen[p.hash] = true
+			s.entries = append(s.entries, entry{hash: p.hash, doc: uint32(doc)})
+		}
+	}
+	s.docs[doc].Fingerprints = len(seen)
+	sort.Slice(s.entries, func(i, j int) bool {
+		a, b := s.entries[i], s.entries[j]
+		if a.hash != b.hash {
+			return a.hash < b.hash
+		}
+		return a.doc < b.doc
+	})
+	return len(seen), nil
+}
+
+// Passage is a byte range of a compared text covered by fingerprints shared
+// with one document.
+type Passage struct {
+	Start, End int
+}
+
+// Match is a registered document a compared text overlaps.
+type Match struct {
+	Document string
+	// Similarity is the shared fingerprints over the fingerprints of the
+	// smaller of the two texts, from 0 to 1: an excerpt that is most of a
+	// longer email is as similar to its source as a whole copy is.
+	Similarity float64
+	// Matched is the number of distinct fingerprints shared.
+	Matched int
+	// Passages are the shared stretches of the compared text, in order.
+	Passages []Passage
+}
+
+// Compare returns the registered documents text shares at least MinMatched
+// fingerprints with and a similarity of at least threshold (0 to 1), most
+// similar first.
+func (s *Store) Compare(text string, threshold float64) []Match {
+	ws := words(text)
+	prints := winnow(shingles(ws, ShingleWords), Window)
+	if len(prints) == 0 || len(s.entries) == 0 {
+		return nil
+	}
+
+	distinct := make(map[uint64]bool, len(prints))
+	for _, p := range prints {
+		distinct[p.hash] = true
+	}
+	// Per document: the distinct hashes shared, and every position of the
+	// text they were selected at.
+	matched := map[uint32]int{}
+	positions := map[uint32][]int{}
+	counted := make(map[uint64]bool)
+	for _, p := range prints {
+		lo := sort.Search(len(s.entries), func(i int) bool { return s.entries[i].hash >= p.hash })
+		for i := lo; i < len(s.entries) && s.entries[i].hash == p.hash; i++ {
+			doc := s.entries[i].doc
+			if !counted[p.hash] {
+				matched[doc]++
+			}
+			positions[doc] = append(positions[doc], p.pos)
+		}
+		counted[p.hash] = true
+	}
+
+	var out []Match
+	for doc, n := range matched {
+		if n < MinMatched {
+			continue
+		}
+		denom := s.docs[doc].Fingerprints
+		if len(distinct) < denom {
+			denom = len(distinct)
+		}
+		sim := float64(n) / float64(denom)
+		if sim > 1 {
+			sim = 1
+		}
+		if sim < threshold {
+			continue
+		}
+		out = append(out, Match{
+			Document:   s.docs[doc].Name,
+			Similarity: sim,
+			Matched:    n,
+			Passages:   passages(ws, positions[doc]),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Similarity != out[j].Similarity {
+			return out[i].Similarity > out[j].Similarity
+		}
+		return out[i].Document < out[j].Document
+	})
+	return out
+}
+
+// passages joins the shingles at pos into stretches of text. Two fingerprints
+// at most Window shingles apart are one stretch: winnowing selects at least one
+// fingerprint in every window of a shared run, so a wider gap is text the
+// document does not have.
+func passages(ws []word, pos []int) []Passage {
+	sort.Ints(pos)
+	var out []Passage
+	first, last := pos[0], pos[0]
+	flush := func() {
+		out = append(out, Passage{Start: ws[first].start, End: ws[last+ShingleWords-1].end})
+	}
+	for _, p := range pos[1:] {
+		if p-last > Window {
+			flush()
+			first = p
+		}
+		last = p
+	}
+	flush()
+	return out
+}
+
+// WriteTo writes the store in its file format.
+func (s *Store) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	cw := &countingWriter{w: bw}
+	cw.Write(magic)
+	var buf [entrySize]byte
+	buf[0], buf[1] = ShingleWords, Window
+	cw.Write(buf[:2])
+	binary.LittleEndian.PutUint32(buf[:4], uint32(len(s.docs)))
+	cw.Write(buf[:4])
+	for _, d := range s.docs {
+		binary.LittleEndian.PutUint16(buf[:2], uint16(len(d.Name)))
+		cw.Write(buf[:2])
+		cw.Write([]byte(d.Name))
+		binary.LittleEndian.PutUint32(buf[:4], uint32(d.Fingerprints))
+		cw.Write(buf[:4])
+	}
+	binary.LittleEndian.PutUint64(buf[:8], uint64(len(s.entries)))
+	cw.Write(buf[:8])
+	for _, e := range s.entries {
+		binary.LittleEndian.PutUint64(buf[0:8], e.hash)
+		binary.LittleEndian.PutUint32(buf[8:12], e.doc)
+		cw.Write(buf[:])
+	}
+	if cw.err != nil {
+		return cw.n, cw.err
+	}
+	return cw.n, bw.Flush()
+}
+
+// WriteFile writes the store to path, readable by its owner only, replacing
+// any file there only once the new one is complete.
+func (s *Store) WriteFile(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := s.WriteTo(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
+
+// Read parses a store. Every count in the file is checked against the bytes
+// that follow it before anything is allocated, the entries must be in order,
+// and each document's count must match its entries, so a damaged or hand-made
+// file is refused rather than compared wrong. A store written with other
+// shingle or window sizes is refused too: its fingerprints would never match
+// the ones a scan computes.
+func Read(data []byte) (*Store, error) {
+	if !bytes.HasPrefix(data, magic) {
+		return nil, ErrNotStore
+	}
+	p := data[len(magic):]
+	take := func(n int) []byte {
+		if n > len(p) {
+			return nil
+		}
+		b := p[:n]
+		p = p[n:]
+		return b
+	}
+	b := take(2)
+	if b == nil || b[0] != ShingleWords || b[1] != Window {
+		return nil, ErrNotStore
+	}
+	if b = take(4); b == nil {
+		return nil, ErrNotStore
+	}
+	ndocs := binary.LittleEndian.Uint32(b)
+	if uint64(ndocs)*(2+4) > uint64(len(p)) {
+		return nil, ErrNotStore
+	}
+	s := &Store{docs: make([]Document, 0, ndocs)}
+	for i := uint32(0); i < ndocs; i++ {
+		if b = take(2); b == nil {
+			return nil, ErrNotStore
+		}
+		name := take(int(binary.LittleEndian.Uint16(b)))
+		if name == nil {
+			return nil, ErrNotStore
+		}
+		if b = take(4); b == nil {
+			return nil, ErrNotStore
+		}
+		s.docs = append(s.docs, Document{Name: string(name), Fingerprints: int(binary.LittleEndian.Uint32(b))})
+	}
+	if b = take(8); b == nil {
+		return nil, ErrNotStore
+	}
+	n := binary.LittleEndian.Uint64(b)
+	if n != uint64(len(p))/entrySize || uint64(len(p))%entrySize != 0 {
+		return nil, ErrNotStore
+	}
+	counts := make([]int, ndocs)
+	s.entries = make([]entry, n)
+	for i := range s.entries {
+		e := entry{
+			hash: binary.LittleEndian.Uint64(p[0:8]),
+			doc:  binary.LittleEndian.Uint32(p[8:12]),
+		}
+		p = p[entrySize:]
+		if e.doc >= ndocs {
+			return nil, ErrNotStore
+		}
+		if i > 0 {
+			prev := s.entries[i-1]
+			if e.hash < prev.hash || (e.hash == prev.hash && e.doc <= prev.doc) {
+				return nil, ErrNotStore
+			}
+		}
+		counts[e.doc]++
+		s.entries[i] = e
+	}
+	for i, d := range s.docs {
+		if d.Fingerprints != counts[i] {
+			return nil, ErrNotStore
+		}
+	}
+	return s, nil
+}
+
+// opened caches the stores Open has loaded, by path.
+var (
+	openMu sync.Mutex
+	opened = map[string]openedStore{}
+)
+
+type openedStore struct {
+	size    int64
+	modTime time.Time
+	s       *Store
+}
+
+// Open loads the store at path. A store is loaded once per process for as long
+// as the file is unchanged: the validator set is built per scan, and the web
+// server builds one per request.
+//
+// The store returned is shared: it must not be modified. Load a store to add
+// to with os.ReadFile and Read.
+func Open(path string) (*Store, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := os.Stat(abs)
+	if err != nil {
+		return nil, fmt.Errorf("fingerprint store: %w", err)
+	}
+	openMu.Lock()
+	defer openMu.Unlock()
+	if o, ok := opened[abs]; ok && o.size == fi.Size() && o.modTime.Equal(fi.ModTime()) {
+		return o.s, nil
+	}
+	data, err := os.ReadFile(abs) // #nosec G304 -- the store path is the user's own argument
+	if err != nil {
+		return nil, fmt.Errorf("fingerprint store: %w", err)
+	}
+	s, err := Read(data)
+	if err != nil {
+		return nil, fmt.Errorf("fingerprint store %s: %w", path, err)
+	}
+	opened[abs] = openedStore{size: fi.Size(), modTime: fi.ModTime(), s: s}
+	return s, nil
+}
@@ -0,0 +1,260 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package fingerprint
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var vocabulary = strings.Fields(`the board approved a revised forecast for
+northern region revenue after merger talks with our largest supplier stalled
+in march while legal reviewed pricing terms and margin targets across every
+product line including retail wholesale and licensing channels next quarter`)
+
+// prose returns n words drawn from vocabulary, with a full stop every twelfth:
+// text no run of six words repeats in, so only what was copied is shared.
+func prose(seed int64, n int) string {
+	r := rand.New(rand.NewSource(seed))
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteString(" ")
+		}
+		b.WriteString(vocabulary[r.Intn(len(vocabulary))])
+		if i%12 == 11 {
+			b.WriteString(".")
+		}
+	}
+	return b.String()
+}
+
+func storeOf(t *testing.T, docs map[string]string) *Store {
+	t.Helper()
+	s := NewStore()
+	for name, text := range docs {
+		if _, err := s.Add(name, text); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Every test compares against a store that went through the file format.
+	var buf bytes.Buffer
+	if _, err := s.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	s, err := Read(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestCompareFindsAReformattedCopy(t *testing.T) {
+	plan := prose(1, 400)
+	s := storeOf(t, map[string]string{"plan.docx": plan, "other.pdf": prose(2, 400)})
+
+	// Upper-cased, reflowed into short lines, with its full stops turned into
+	// semicolons: the words are unchanged.
+	var reformatted strings.Builder
+	for i, w := range strings.Fields(strings.ToUpper(strings.ReplaceAll(plan, ".", ";"))) {
+		reformatted.WriteString(w)
+		if i%7 == 6 {
+			reformatted.WriteString("\n")
+		} else {
+			reformatted.WriteString("  ")
+		}
+	}
+	got := s.Compare(reformatted.String(), 0.3)
+	if len(got) != 1 || got[0].Document != "plan.docx" {
+		t.Fatalf("Compare = %+v, want plan.docx alone", got)
+	}
+	if got[0].Similarity != 1 {
+		t.Errorf("similarity = %v, want 1 for a whole reformatted", got[0].Similarity)
+	}
+	// A passage runs from the first shared fingerprint's shingle to the last
+	// one's, which leaves out at most a window of words at either end.
+	if ps := got[0].Passages; len(ps) != 1 || ps[0].End-ps[0].Start < reformatted.Len()*9/10 {
+		t.Errorf("passages = %+v, want one covering most of the %d bytes", ps, reformatted.Len())
+	}
+}
+
+func TestCompareFindsAnExcerpt(t *testing.T) {
+	plan := prose(1, 400)
+	s := storeOf(t, map[string]string{"plan.docx": plan})
+
+	pw := strings.Fields(plan)
+	excerpt := strings.Join(pw[100:180], " ")
+	before, after := prose(3, 60)+"\n", "\n"+prose(4, 60)
+	got := s.Compare(before+excerpt+after, 0.3)
+	if len(got) != 1 {
+		t.Fatalf("Compare = %+v, want the excerpt's source", got)
+	}
+	if got[0].Similarity < 0.3 || got[0].Similarity > 0.7 {
+		t.Errorf("similarity = %v, want about the excerpt's share of the email", got[0].Similarity)
+	}
+	if len(got[0].Passages) != 1 {
+		t.Fatalf("passages = %+v, want one", got[0].Passages)
+	}
+	p := got[0].Passages[0]
+	if p.Start < len(before) || p.End > len(before)+len(excerpt) || p.End-p.Start < len(excerpt)/2 {
+		t.Errorf("passage %d-%d is not inside the excerpt at %d-%d", p.Start, p.End, len(before), len(before)+len(excerpt))
+	}
+}
+
+func TestCompareIgnoresUnrelatedAndBarelyOverlappingText(t *testing.T) {
+	plan := prose(1, 400)
+	s := storeOf(t, map[string]string{"plan.docx": plan})
+	if got := s.Compare(prose(5, 400), 0); got != nil {
+		t.Errorf("unrelated text: Compare = %+v", got)
+	}
+	// One shared sentence is most of a short note by overlap, but too few
+	// fingerprints to call a copy.
+	sentence := strings.Join(strings.Fields(plan)[24:38], " ")
+	if got := s.Compare("see: "+sentence, 0); got != nil {
+		t.Errorf("one sentence: Compare = %+v", got)
+	}
+}
+
+func TestCompareHonoursTheThreshold(t *testing.T) {
+	plan := prose(1, 400)
+	s := storeOf(t, map[string]string{"plan.docx": plan})
+	text := strings.Join(strings.Fields(plan)[:80], " ") + " " + prose(6, 320)
+	got := s.Compare(text, 0)
+	if len(got) != 1 {
+		t.Fatalf("Compare = %+v, want a match at threshold 0", got)
+	}
+	if again := s.Compare(text, got[0].Similarity+0.01); again != nil {
+		t.Errorf("Compare above the similarity = %+v, want nothing", again)
+	}
+}
+
+// Any run of Window+ShingleWords-1 words two texts share yields a fingerprint
+// they share: the guarantee a copied passage is found by.
+func TestWinnowingGuarantee(t *testing.T) {
+	run := Window + ShingleWords - 1
+	for seed := int64(0); seed < 200; seed++ {
+		shared := prose(1000+seed, run)
+		a := words(prose(seed, 50) + " " + shared + " " + prose(seed+500, 50))
+		b := words(prose(seed+300, 30) + " " + shared)
+		fa := map[uint64]bool{}
+		for _, p := range winnow(shingles(a, ShingleWords), Window) {
+			fa[p.hash] = true
+		}
+		found := false
+		for _, p := range winnow(shingles(b, ShingleWords), Window) {
+			found = found || fa[p.hash]
+		}
+		if !found {
+			t.Fatalf("seed %d: a shared run of %d words yielded no shared fingerprint", seed, run)
+		}
+	}
+}
+
+func TestAddReplacesADocumentOfTheSameName(t *testing.T) {
+	s := NewStore()
+	if _, err := s.Add("plan.docx", prose(1, 200)); err != nil {
+		t.Fatal(err)
+	}
+	n, err := s.Add("plan.docx", prose(2, 200))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if docs := s.Documents(); len(docs) != 1 || docs[0].Fingerprints != n {
+		t.Fatalf("Documents = %+v, want the one document with %d fingerprints", docs, n)
+	}
+	if got := s.Compare(prose(1, 200), 0); got != nil {
+		t.Errorf("the replaced text still matches: %+v", got)
+	}
+	if got := s.Compare(prose(2, 200), 0); len(got) != 1 {
+		t.Errorf("the new text does not match: %+v", got)
+	}
+	if _, err := s.Add("empty.txt", "two words"); !errors.Is(err, ErrTooShort) {
+		t.Errorf("Add of two words: err = %v, want ErrTooShort", err)
+	}
+}
+
+func TestStoreHoldsNoText(t *testing.T) {
+	s := NewStore()
+	if _, err := s.Add("plan.docx", prose(1, 200)); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := s.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range []string{"board", "forecast", "merger", "supplier"} {
+		if bytes.Contains(buf.Bytes(), []byte(w)) {
+			t.Errorf("the store file holds %q", w)
+		}
+	}
+}
+
+func TestReadRefusesDamagedStores(t *testing.T) {
+	s := NewStore()
+	if _, err := s.Add("plan.docx", prose(1, 200)); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := s.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	good := buf.Bytes()
+
+	swapped := append([]byte(nil), good...)
+	last := len(swapped) - entrySize
+	copy(swapped[last-entrySize:], good[last:])
+	copy(swapped[last:], good[last-entrySize:last])
+
+	otherWindow := append([]byte(nil), good...)
+	otherWindow[len(magic)+1]++
+
+	for name, data := range map[string][]byte{
+		"not a store":     []byte("name,ssn\n"),
+		"truncated":       good[:len(good)-3],
+		"out of order":    swapped,
+		"other window":    otherWindow,
+		"trailing byte":   append(append([]byte(nil), good...), 0),
+		"header only":     good[:len(magic)+2],
+		"missing entries": good[:len(good)-entrySize],
+	} {
+		if _, err := Read(data); !errors.Is(err, ErrNotStore) {
+			t.Errorf("%s: Read err = %v, want ErrNotStore", name, err)
+		}
+	}
+}
+
+func TestOpenCachesAndRereadsAChangedStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "docs.fp")
+	s := NewStore()
+	if _, err := s.Add("plan.docx", prose(1, 200)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+	a, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := Open(path); b != a {
+		t.Error("Open of an unchanged store loaded it again")
+	}
+	if _, err := s.Add("memo.pdf", prose(2, 200)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+	c, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Documents()) != 2 {
+		t.Errorf("Open after a rewrite = %+v, want the two documents", c.Documents())
+	}
+}
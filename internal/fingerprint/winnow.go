@@ -0,0 +1,123 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package fingerprint
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// word is one run of letters and digits in a text.
+type word struct {
+	start, end int
+	// text is the run case-folded and NFKC-normalised, so a copy retyped with
+	// different capitals or pasted through a full-width font still compares
+	// equal.
+	text string
+}
+
+// words splits s into runs of letters, marks and digits. Everything else —
+// spaces, line breaks, punctuation, the markup a preprocessor leaves — only
+// separates words, so a copy reflowed into other lines or columns, or with its
+// punctuation changed, has the same words.
+func words(s string) []word {
+	var out []word
+	start := -1
+	flush := func(end int) {
+		if start < 0 {
+			return
+		}
+		raw := s[start:end]
+		text := raw
+		for i := 0; i < len(raw); i++ {
+			if raw[i] >= utf8.RuneSelf {
+				text = norm.NFKC.String(raw)
+				break
+			}
+		}
+		out = append(out, word{start: start, end: end, text: strings.ToLower(text)})
+		start = -1
+	}
+	for i, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || (unicode.IsMark(r) && start >= 0) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		flush(i)
+	}
+	flush(len(s))
+	return out
+}
+
+// FNV-1a, inlined so hashing a shingle allocates nothing.
+const (
+	fnvOffset = 14695981039346656037
+	fnvPrime  = 1099511628211
+)
+
+// shingles returns the hash of every run of k consecutive words, in order.
+// Words are separated by a zero byte in the hashed bytes, so "ab c" and
+// "a bc" differ.
+func shingles(ws []word, k int) []uint64 {
+	if len(ws) < k {
+		return nil
+	}
+	out := make([]uint64, 0, len(ws)-k+1)
+	for i := 0; i+k <= len(ws); i++ {
+		h := uint64(fnvOffset)
+		for _, w := range ws[i : i+k] {
+			for j := 0; j < len(w.text); j++ {
+				h ^= uint64(w.text[j])
+				h *= fnvPrime
+			}
+			h *= fnvPrime // the zero byte: h ^= 0 is h
+		}
+		out = append(out, h)
+	}
+	return out
+}
+
+// print is a fingerprint: a selected shingle hash and the index of the
+// shingle it was selected from.
+type print struct {
+	hash uint64
+	pos  int
+}
+
+// winnow selects fingerprints from shingle hashes (Schleimer, Wilkerson and
+// Aiken, "Winnowing: Local Algorithms for Document Fingerprinting"): the
+// smallest hash of every window of w consecutive shingles, the rightmost on a
+// tie, recorded once however many windows select it. Any run of w+k-1 words two
+// texts share yields at least one fingerprint they share, whatever surrounds
+// it, while keeping about 2/(w+1) of the shingles.
+//
+// A text with fewer than w shingles is one window.
+func winnow(hs []uint64, w int) []print {
+	if len(hs) == 0 {
+		return nil
+	}
+	if len(hs) < w {
+		w = len(hs)
+	}
+	var out []print
+	last := -1
+	for i := 0; i+w <= len(hs); i++ {
+		m := i
+		for j := i + 1; j < i+w; j++ {
+			if hs[j] <= hs[m] {
+				m = j
+			}
+		}
+		if m != last {
+			out = append(out, print{hash: hs[m], pos: m})
+			last = m
+		}
+	}
+	return out
+}
@@ -0,0 +1,101 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package formatters_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/awslabs/ferret-scan/v2/internal/detector"
+	"github.com/awslabs/ferret-scan/v2/internal/formatters"
+	csvfmt "github.com/awslabs/ferret-scan/v2/internal/formatters/csv"
+	jsonfmt "github.com/awslabs/ferret-scan/v2/internal/formatters/json"
+	"github.com/awslabs/ferret-scan/v2/internal/formatters/sarif"
+	"github.com/awslabs/ferret-scan/v2/internal/formatters/shared"
+	"github.com/awslabs/ferret-scan/v2/internal/formatters/text"
+)
+
+// A finding from a spreadsheet carries its cell in every output, and a finding
+// without one carries no cell field at all.
+func TestCellFieldInTextJSONSARIFAndCSV(t *testing.T) {
+	matches := []detector.Match{
+		{Text: "536-22-1874", Type: "SSN", Confidence: 95, Filename: "payroll.xlsx", LineNumber: 44, Cell: "'Payroll Q3'!C42", Validator: "ssn"},
+		{Text: "a@example.com", Type: "EMAIL", Confidence: 95, Filename: "notes.txt", LineNumber: 3, Validator: "email"},
+	}
+	opts := formatters.FormatterOptions{
+		ConfidenceLevel: map[string]bool{"high": true, "medium": true, "low": true},
+		NoColor:         true,
+	}
+
+	out, err := text.NewFormatter().Format(matches, nil, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "payroll.xlsx ('Payroll Q3'!C42)") {
+		t.Errorf("text output does not show the cell:\n%s", out)
+	}
+
+	out, err = jsonfmt.NewFormatter().Format(matches, nil, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Results []map[string]any `json:"results"`
+	}
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatal(err)
+	}
+	cells := map[string]any{}
+	for _, r := range doc.Results {
+		cells[r["filename"].(string)] = r["cell"]
+	}
+	if cells["payroll.xlsx"] != "'Payroll Q3'!C42" || cells["notes.txt"] != nil {
+		t.Errorf("JSON cells = %v, want the cell for the workbook and none for the text file", cells)
+	}
+
+	out, err = sarif.NewFormatter().Format(matches, nil, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var log struct {
+		Runs []struct {
+			Results []struct {
+				Properties map[string]any `json:"properties"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal([]byte(out), &log); err != nil {
+		t.Fatal(err)
+	}
+	var found bool
+	for _, r := range log.Runs[0].Results {
+		if r.Properties["cell"] == "'Payroll Q3'!C42" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("SARIF results carry no cell property:\n%s", out)
+	}
+
+	out, err = csvfmt.NewFormatter().Format(matches, nil, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "Cell") || !strings.Contains(out, "'Payroll Q3'!C42") {
+		t.Errorf("CSV output does not carry the cell:\n%s", out)
+	}
+}
+
+// A sheet named after the value it holds would disclose the value through the
+// location; without --show-match the sheet is dropped and the cell kept.
+func TestCellLocationDoesNotLeakTheValueThroughTheSheetName(t *testing.T) {
+	m := detector.Match{Text: "536-22-1874", Cell: "'SSN 536-22-1874'!B2"}
+	if got := shared.CellLocation(m, false); got != "B2" {
+		t.Errorf("masked location = %q, want B2", got)
+	}
+	if got := shared.CellLocation(m, true); got != m.Cell {
+		t.Errorf("unmasked location = %q, want %q", got, m.Cell)
+	}
+}
@@ -61,7 +61,7 @@ func (f *Formatter) Format(matches []detector.Match, suppressedMatches []detecto
 	if options.PrecommitMode {
 		headers = []string{"File", "Issue", "Line", "Confidence"}
 	} else {
-		headers = []string{"Filename", "Type", "Confidence Level", "Confidence %", "Line Number", "Text"}
+		headers = []string{"Filename", "Type", "Confidence Level", "Confidence %", "Line Number", "Text", "Cell"}
 		if options.Verbose {
 			headers = append(headers, "Metadata")
 		}
@@ -121,6 +121,10 @@ func (f *Formatter) createCSVRow(match detector.Match, options formatters.Format
 			fmt.Sprintf("%.1f", match.Confidence),
 			fmt.Sprintf("%d", match.LineNumber),
 			f.escapeCSVField(displayText),
+			// The spreadsheet cell ("Sheet1!C42"), empty for other sources. A
+			// column of its own rather than folded into Line Number, so a
+			// consumer filtering on the line keeps working.
+			f.escapeCSVField(shared.CellLocation(match, options.ShowMatch)),
 		}
 
 		// Add metadata if verbose mode is enabled. Run it through the shared
@@ -69,15 +69,47 @@ type FormatterOptions struct {
 	// nobody asked for, dressed up as a disclosure.
 	FailOnIncomplete bool
 
-	// StreamWriter, when non-nil, causes the text formatter to write output
-	// directly to this writer instead of buffering into a returned string.
+	// StreamWriter, when non-nil, causes a streaming-capable formatter to write
+	// output directly to this writer instead of buffering into a returned string.
 	// The Format call returns "" when streaming is active — the caller must
-	// skip its own fmt.Println(result). Only the text formatter honors this;
-	// structured formats (JSON, SARIF, etc.) ignore it because they require
+	// skip its own fmt.Println(result). The text and jsonl formatters honor
+	// this; document formats (JSON, SARIF, etc.) ignore it because they require
 	// structural integrity of the complete document.
 	StreamWriter io.Writer
 }
 
+// StreamingFormatter is implemented by formatters whose output is a sequence of
+// independent records, so a finding can be written the moment the scan produces
+// it instead of after every file has been read. The caller opens a stream on
+// options.StreamWriter before the scan, feeds it per-file results in completion
+// order, and closes it once the run's stats and not-examined list are known.
+//
+// Format must still work on its own: an output file, the web export and the
+// golden corpus all call it with the complete result set.
+type StreamingFormatter interface {
+	Formatter
+
+	// NewStream starts a stream writing to options.StreamWriter. Confidence
+	// filtering, ShowMatch redaction and Limit are taken from options here; Stats
+	// and NotExamined are read later, by Close.
+	NewStream(options FormatterOptions) FindingStream
+}
+
+// FindingStream receives findings as the scan produces them. Methods are not
+// safe for concurrent use; the caller serialises them.
+type FindingStream interface {
+	// WriteMatch writes one active finding, unless the confidence filter or the
+	// limit excludes it.
+	WriteMatch(match detector.Match) error
+
+	// WriteSuppressed writes one suppressed finding.
+	WriteSuppressed(suppressed detector.SuppressedMatch) error
+
+	// Close writes the trailing records — not-examined files and the summary —
+	// from options.Stats and options.NotExamined. Nothing may be written after it.
+	Close(options FormatterOptions) error
+}
+
 // ScanStats holds aggregate scan statistics rendered in the output summary.
 // Every field carries a yaml tag as well as a json one, and they MUST agree.
 //
@@ -248,6 +280,10 @@ func GetFormatInfo(name string) FormatInfo {
 		info.MimeType = "text/plain"
 	case "sarif":
 		info.MimeType = "application/sarif+json"
+	case "html":
+		info.MimeType = "text/html"
+	case "jsonl":
+		info.MimeType = "application/x-ndjson"
 	default:
 		info.MimeType = "application/octet-stream"
 	}
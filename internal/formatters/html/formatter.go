@@ -0,0 +1,305 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package html renders a scan as a single self-contained HTML report: one file,
+// inline CSS and JavaScript, no fonts, images or scripts fetched from anywhere,
+// so it can be attached to an audit ticket and opened offline by someone who
+// will never run the tool.
+package html
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"sort"
+
+	"github.com/awslabs/ferret-scan/v2/internal/detector"
+	"github.com/awslabs/ferret-scan/v2/internal/explain"
+	"github.com/awslabs/ferret-scan/v2/internal/formatters"
+	"github.com/awslabs/ferret-scan/v2/internal/formatters/shared"
+	"github.com/awslabs/ferret-scan/v2/internal/version"
+)
+
+//go:embed report.html.tmpl
+var reportTemplate string
+
+// reportTmpl is parsed once. html/template is what keeps a hostile filename or
+// rule reason from becoming markup: every value is escaped for the context it
+// lands in, including the data-* attributes the sort and filter code reads.
+var reportTmpl = template.Must(template.New("report").Parse(reportTemplate))
+
+// maxFileBars caps the per-file chart. A recursive scan can put findings in
+// thousands of files; past a screenful the chart stops being a chart, so the
+// remainder is stated as a count instead of drawn.
+const maxFileBars = 15
+
+// hiddenValue is what the report shows in place of a matched value without
+// --show-match — the same placeholder every other formatter uses.
+const hiddenValue = "[HIDDEN]"
+
+// Formatter implements the HTML report.
+type Formatter struct{}
+
+// NewFormatter creates a new HTML formatter
+func NewFormatter() *Formatter {
+	return &Formatter{}
+}
+
+func (f *Formatter) Name() string {
+	return "html"
+}
+
+func (f *Formatter) Description() string {
+	return "Self-contained HTML report with summary charts, for sharing and audit"
+}
+
+func (f *Formatter) FileExtension() string {
+	return ".html"
+}
+
+// Format renders the report. The document carries no timestamp, so two reports
+// of one unchanged scan are byte-identical and can be compared with diff; when
+// the scan was run is the attaching ticket's business.
+func (f *Formatter) Format(matches []detector.Match, suppressedMatches []detector.SuppressedMatch, options formatters.FormatterOptions) (string, error) {
+	filtered := shared.FilterMatchesByConfidence(matches, options)
+	shared.SortMatchesByPriority(filtered)
+	display, total, truncated := shared.ApplyLimit(filtered, options)
+
+	suppressed := append([]detector.SuppressedMatch(nil), suppressedMatches...)
+	shared.SortSuppressedByPriority(suppressed)
+
+	data := reportData{
+		Version:     version.Short(),
+		Total:       total,
+		Shown:       len(display),
+		Truncated:   truncated,
+		ShowMatch:   options.ShowMatch,
+		Stats:       options.Stats,
+		Bands:       bandBars(filtered),
+		Validators:  countBars(filtered, func(m detector.Match) string { return validatorLabel(m) }, 0),
+		Findings:    make([]findingRow, 0, len(display)),
+		Suppressed:  make([]suppressedRow, 0, len(suppressed)),
+		NotExamined: make([]notExaminedRow, 0, len(options.NotExamined)),
+	}
+	data.Files = countBars(filtered, func(m detector.Match) string { return m.Filename }, maxFileBars)
+	data.FilesWithFindings = distinct(filtered, func(m detector.Match) string { return m.Filename })
+	data.FilesOmitted = data.FilesWithFindings - len(data.Files)
+
+	for _, m := range display {
+		row := newFindingRow(m, options)
+		if row.Explanation != nil {
+			data.HasExplanations = true
+		}
+		data.Findings = append(data.Findings, row)
+	}
+	for _, s := range suppressed {
+		row := suppressedRow{
+			findingRow: newFindingRow(s.Match, options),
+			RuleID:     s.SuppressedBy,
+			Reason:     s.RuleReason,
+			Expired:    s.Expired,
+		}
+		if s.ExpiresAt != nil {
+			row.ExpiresAt = s.ExpiresAt.Format("2006-01-02")
+		}
+		data.Suppressed = append(data.Suppressed, row)
+	}
+
+	if len(options.NotExamined) > 0 {
+		shown, totalNE := formatters.CapNotExamined(options.NotExamined)
+		data.NotExaminedSummary = formatters.NotExaminedSummary(len(shown), totalNE)
+		for _, nf := range shown {
+			detail := nf.Detail
+			if detail == "" {
+				detail = "no further detail available"
+			}
+			data.NotExamined = append(data.NotExamined, notExaminedRow{
+				Path:   nf.Path,
+				Cause:  nf.Cause.String(),
+				Detail: detail,
+			})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := reportTmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("rendering html report: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// reportData is everything the template renders. Rows are flattened to strings
+// here so the template holds no redaction logic: a value the template can see
+// is a value the reader may see.
+type reportData struct {
+	Version   string
+	Total     int
+	Shown     int
+	Truncated bool
+	ShowMatch bool
+	Stats     *formatters.ScanStats
+
+	Bands             []bar
+	Validators        []bar
+	Files             []bar
+	FilesWithFindings int
+	FilesOmitted      int
+
+	Findings        []findingRow
+	HasExplanations bool
+	Suppressed      []suppressedRow
+
+	NotExamined        []notExaminedRow
+	NotExaminedSummary string
+}
+
+// bar is one row of a CSS bar chart. Width is relative to the largest bar in
+// the same chart, so the longest bar always spans the track.
+type bar struct {
+	Label string
+	Class string
+	Count int
+	Width string
+}
+
+type findingRow struct {
+	Confidence  string
+	Score       float64
+	Level       string
+	Type        string
+	Validator   string
+	File        string
+	Line        int
+	Value       string
+	Context     string
+	Explanation *explanationRow
+}
+
+type explanationRow struct {
+	Rationale           string
+	Verdict             string
+	DraftSuppressReason string
+}
+
+type suppressedRow struct {
+	findingRow
+	RuleID    string
+	Reason    string
+	ExpiresAt string
+	Expired   bool
+}
+
+type notExaminedRow struct {
+	Path   string
+	Cause  string
+	Detail string
+}
+
+// newFindingRow flattens a match, withholding the value and its line unless the
+// operator asked to see them. The surrounding line needs --verbose as well, the
+// same pairing the JSON formatter requires, because it holds the value too.
+func newFindingRow(m detector.Match, options formatters.FormatterOptions) findingRow {
+	row := findingRow{
+		Confidence: fmt.Sprintf("%.1f", m.Confidence),
+		Score:      m.Confidence,
+		Level:      shared.GetConfidenceLevel(m.Confidence),
+		Type:       m.Type,
+		Validator:  validatorLabel(m),
+		File:       m.Filename,
+		Line:       m.LineNumber,
+		Value:      hiddenValue,
+	}
+	if options.ShowMatch {
+		row.Value = m.Text
+		if options.Verbose {
+			row.Context = m.Context.FullLine
+		}
+	}
+	if ex, ok := explain.FromMatch(m); ok {
+		row.Explanation = &explanationRow{
+			Rationale:           ex.Rationale,
+			Verdict:             string(ex.Verdict),
+			DraftSuppressReason: ex.DraftSuppressReason,
+		}
+	}
+	return row
+}
+
+// validatorLabel is the validator a finding came from, falling back to its type
+// for hand-built matches that do not record one.
+func validatorLabel(m detector.Match) string {
+	if m.Validator != "" {
+		return m.Validator
+	}
+	return m.Type
+}
+
+// bandBars always lists all three bands, in severity order, so a report with no
+// HIGH findings says so rather than silently omitting the row.
+func bandBars(matches []detector.Match) []bar {
+	counts := map[string]int{}
+	for _, m := range matches {
+		counts[shared.GetConfidenceLevel(m.Confidence)]++
+	}
+	bars := []bar{
+		{Label: "HIGH", Class: "high", Count: counts["HIGH"]},
+		{Label: "MEDIUM", Class: "medium", Count: counts["MEDIUM"]},
+		{Label: "LOW", Class: "low", Count: counts["LOW"]},
+	}
+	scaleBars(bars)
+	return bars
+}
+
+// countBars groups matches by key, largest group first and ties by label so the
+// chart is deterministic. limit > 0 keeps only the largest groups.
+func countBars(matches []detector.Match, key func(detector.Match) string, limit int) []bar {
+	counts := map[string]int{}
+	for _, m := range matches {
+		counts[key(m)]++
+	}
+	bars := make([]bar, 0, len(counts))
+	for label, n := range counts {
+		bars = append(bars, bar{Label: label, Count: n})
+	}
+	sort.Slice(bars, func(i, j int) bool {
+		if bars[i].Count != bars[j].Count {
+			return bars[i].Count > bars[j].Count
+		}
+		return bars[i].Label < bars[j].Label
+	})
+	if limit > 0 && len(bars) > limit {
+		bars = bars[:limit]
+	}
+	scaleBars(bars)
+	return bars
+}
+
+func scaleBars(bars []bar) {
+	peak := 0
+	for _, b := range bars {
+		if b.Count > peak {
+			peak = b.Count
+		}
+	}
+	for i := range bars {
+		w := 0.0
+		if peak > 0 {
+			w = float64(bars[i].Count) * 100 / float64(peak)
+		}
+		bars[i].Width = fmt.Sprintf("%.1f%%", w)
+	}
+}
+
+func distinct(matches []detector.Match, key func(detector.Match) string) int {
+	seen := map[string]struct{}{}
+	for _, m := range matches {
+		seen[key(m)] = struct{}{}
+	}
+	return len(seen)
+}
+
+// Register the formatter during package initialization
+func init() {
+	formatters.Register(NewFormatter())
+}
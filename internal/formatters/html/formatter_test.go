@@ -0,0 +1,167 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package html
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/awslabs/ferret-scan/v2/internal/detector"
+	"github.com/awslabs/ferret-scan/v2/internal/explain"
+	"github.com/awslabs/ferret-scan/v2/internal/formatters"
+)
+
+var allLevels = map[string]bool{"high": true, "medium": true, "low": true}
+
+const (
+	secret     = "4929-3813-3266-4295"
+	secretLine = "Robert Aragon\t4929-3813-3266-4295"
+)
+
+func cardMatch() detector.Match {
+	return detector.Match{
+		Text: secret, Type: "VISA", Validator: "CREDIT_CARD", Confidence: 100,
+		Filename: "cards.tsv", LineNumber: 5,
+		Context: detector.ContextInfo{FullLine: secretLine},
+		Metadata: map[string]interface{}{
+			explain.MetadataKey: explain.Explanation{
+				Rationale:           "Sixteen digits passing the Luhn check next to a name column.",
+				Verdict:             "likely-real",
+				DraftSuppressReason: "Synthetic card numbers in fixtures",
+			},
+		},
+	}
+}
+
+// TestHTML_ValuesHiddenWithoutShowMatch is the leak gate: the report is made to
+// be handed to people outside the team, so without --show-match neither the
+// value nor the line around it may appear anywhere in the document.
+func TestHTML_ValuesHiddenWithoutShowMatch(t *testing.T) {
+	suppressed := []detector.SuppressedMatch{{Match: cardMatch(), SuppressedBy: "SUP-00000001", RuleReason: "fixture"}}
+
+	hidden, err := NewFormatter().Format([]detector.Match{cardMatch()}, suppressed,
+		formatters.FormatterOptions{ConfidenceLevel: allLevels, Verbose: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, leak := range []string{secret, "Robert Aragon"} {
+		if strings.Contains(hidden, leak) {
+			t.Errorf("report leaked %q without --show-match", leak)
+		}
+	}
+	if !strings.Contains(hidden, hiddenValue) {
+		t.Error("hidden value placeholder missing")
+	}
+
+	shown, err := NewFormatter().Format([]detector.Match{cardMatch()}, nil,
+		formatters.FormatterOptions{ConfidenceLevel: allLevels, Verbose: true, ShowMatch: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(shown, secret) || !strings.Contains(shown, "Robert Aragon") {
+		t.Error("--show-match --verbose should include the value and its line")
+	}
+}
+
+// TestHTML_SelfContained checks the document references nothing outside itself:
+// no URLs, no src or href attributes, and a CSP that forbids fetching anyway.
+func TestHTML_SelfContained(t *testing.T) {
+	out, err := NewFormatter().Format([]detector.Match{cardMatch()}, nil, formatters.FormatterOptions{ConfidenceLevel: allLevels})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := regexp.MustCompile(`(?i)(https?:)?//[a-z0-9.-]+\.[a-z]{2,}|\b(src|href)\s*=|@import|url\(`).FindString(out); m != "" {
+		t.Errorf("report references an external resource: %q", m)
+	}
+	if !strings.Contains(out, "default-src 'none'") {
+		t.Error("report lacks the Content-Security-Policy that blocks network access")
+	}
+}
+
+func TestHTML_Sections(t *testing.T) {
+	expires := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	matches := []detector.Match{
+		cardMatch(),
+		{Text: "a@example.com", Type: "EMAIL", Validator: "EMAIL", Confidence: 70, Filename: "users.csv", LineNumber: 2},
+		{Text: "b@example.com", Type: "EMAIL", Validator: "EMAIL", Confidence: 40, Filename: "users.csv", LineNumber: 3},
+	}
+	suppressed := []detector.SuppressedMatch{{
+		Match:        detector.Match{Text: "c@example.com", Type: "EMAIL", Confidence: 80, Filename: "old.csv", LineNumber: 1},
+		SuppressedBy: "SUP-00000009",
+		RuleReason:   "Legacy export <reviewed>",
+		ExpiresAt:    &expires,
+		Expired:      true,
+	}}
+	opts := formatters.FormatterOptions{
+		ConfidenceLevel: allLevels,
+		Limit:           2,
+		NotExamined:     []formatters.NotExaminedFile{{Path: "locked.pdf", Cause: formatters.NotExaminedUnreadable, Detail: "permission denied"}},
+	}
+
+	out, err := NewFormatter().Format(matches, suppressed, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Sixteen digits passing the Luhn check", // --explain rationale
+		"Synthetic card numbers in fixtures",
+		"NOT FULLY EXAMINED", "locked.pdf", "cannot read", "permission denied",
+		"SUP-00000009", "Legacy export &lt;reviewed&gt;", "expired 2026-03-01",
+		"first 2 of 3 findings", // --limit disclosure
+		`id="findings-table"`, `data-sort="score"`, "data-filter-text",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report missing %q", want)
+		}
+	}
+	if got := strings.Count(out, `<tr data-score=`); got != 3 {
+		t.Errorf("got %d table rows, want 2 findings (limit) + 1 suppressed", got)
+	}
+	// The charts count every finding, not just the rows --limit kept.
+	if !regexp.MustCompile(`title="users.csv">users.csv</span>.*?<span class="bar-count">2</span>`).MatchString(out) {
+		t.Error("per-file chart should count both users.csv findings despite --limit")
+	}
+}
+
+// TestHTML_EscapesHostileInput feeds markup through every free-text field an
+// attacker controls — a filename in the scanned tree, a rule reason in a shared
+// suppression file — and requires it to arrive inert.
+func TestHTML_EscapesHostileInput(t *testing.T) {
+	const payload = `"><script>alert(1)</script>`
+	matches := []detector.Match{{Text: "x", Type: "SSN", Validator: payload, Confidence: 95, Filename: payload + ".txt", LineNumber: 1}}
+	suppressed := []detector.SuppressedMatch{{Match: matches[0], SuppressedBy: payload, RuleReason: payload}}
+
+	out, err := NewFormatter().Format(matches, suppressed, formatters.FormatterOptions{ConfidenceLevel: allLevels, ShowMatch: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out, "<script>alert(1)") {
+		t.Error("hostile input rendered as markup")
+	}
+	if strings.Count(out, "<script>") != 1 {
+		t.Errorf("want exactly the report's own script block, got %d", strings.Count(out, "<script>"))
+	}
+}
+
+func TestHTML_Deterministic(t *testing.T) {
+	matches := []detector.Match{
+		{Text: "a", Type: "EMAIL", Confidence: 70, Filename: "b.txt", LineNumber: 1},
+		{Text: "b", Type: "SSN", Confidence: 95, Filename: "a.txt", LineNumber: 2},
+		{Text: "c", Type: "EMAIL", Confidence: 70, Filename: "a.txt", LineNumber: 3},
+	}
+	first, err := NewFormatter().Format(append([]detector.Match(nil), matches...), nil, formatters.FormatterOptions{ConfidenceLevel: allLevels})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reversed := []detector.Match{matches[2], matches[1], matches[0]}
+	second, err := NewFormatter().Format(reversed, nil, formatters.FormatterOptions{ConfidenceLevel: allLevels})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Error("report depends on input order")
+	}
+}
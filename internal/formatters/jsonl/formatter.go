@@ -0,0 +1,247 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package jsonl renders a scan as JSON Lines: one self-contained JSON object per
+// line, so a consumer such as jq or a log shipper can act on each finding while
+// the scan is still running instead of waiting for one document at the end.
+//
+// Every line carries a "record" discriminator:
+//
+//	finding       one active finding, in the same shape as a json results entry
+//	suppressed    one suppressed finding (--show-suppressed), plus its rule
+//	not_examined  one file whose contents were not fully read
+//	summary       always the last line; its presence means the run completed
+//
+// When streaming, findings arrive in worker completion order, not the priority
+// order the document formats use, and --limit keeps the first N to arrive.
+package jsonl
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/awslabs/ferret-scan/v2/internal/detector"
+	"github.com/awslabs/ferret-scan/v2/internal/formatters"
+	"github.com/awslabs/ferret-scan/v2/internal/formatters/shared"
+)
+
+// Record discriminators. They are part of the output contract.
+const (
+	RecordFinding     = "finding"
+	RecordSuppressed  = "suppressed"
+	RecordNotExamined = "not_examined"
+	RecordSummary     = "summary"
+)
+
+// Formatter implements JSON Lines output formatting
+type Formatter struct{}
+
+// NewFormatter creates a new JSON Lines formatter
+func NewFormatter() *Formatter {
+	return &Formatter{}
+}
+
+func (f *Formatter) Name() string {
+	return "jsonl"
+}
+
+func (f *Formatter) Description() string {
+	return "JSON Lines, one record per line, streamed as findings are produced"
+}
+
+func (f *Formatter) FileExtension() string {
+	return ".jsonl"
+}
+
+// Format renders a complete result set. Unlike a live stream the findings are
+// known up front, so they are written in the shared priority order and --limit
+// keeps the highest-confidence ones, exactly as the json formatter does.
+//
+// With options.StreamWriter set the records go straight to it and "" is
+// returned; otherwise they are returned as one string without a trailing
+// newline, matching the other formatters.
+func (f *Formatter) Format(matches []detector.Match, suppressedMatches []detector.SuppressedMatch, options formatters.FormatterOptions) (string, error) {
+	filtered := shared.FilterMatchesByConfidence(matches, options)
+
+	// Pre-commit mode stays silent when there is nothing to say, as json does.
+	if len(filtered) == 0 && len(suppressedMatches) == 0 && options.PrecommitMode {
+		return "", nil
+	}
+
+	shared.SortMatchesByPriority(filtered)
+	suppressed := append([]detector.SuppressedMatch(nil), suppressedMatches...)
+	shared.SortSuppressedByPriority(suppressed)
+
+	var buf bytes.Buffer
+	w := io.Writer(&buf)
+	if options.StreamWriter != nil {
+		w = options.StreamWriter
+	}
+	streamOpts := options
+	streamOpts.StreamWriter = w
+
+	s := f.NewStream(streamOpts)
+	for _, m := range filtered {
+		if err := s.WriteMatch(m); err != nil {
+			return "", err
+		}
+	}
+	for _, sm := range suppressed {
+		if err := s.WriteSuppressed(sm); err != nil {
+			return "", err
+		}
+	}
+	if err := s.Close(options); err != nil {
+		return "", err
+	}
+
+	if options.StreamWriter != nil {
+		return "", nil
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// NewStream starts a stream on options.StreamWriter.
+func (f *Formatter) NewStream(options formatters.FormatterOptions) formatters.FindingStream {
+	return &stream{enc: json.NewEncoder(options.StreamWriter), options: options}
+}
+
+// stream writes records as they are handed over. The first write error is
+// sticky: once a reader has gone away (jq exited, a pipe closed) every later
+// call returns it without writing, so the caller can report it once.
+type stream struct {
+	enc     *json.Encoder
+	options formatters.FormatterOptions
+
+	written    int // finding records written
+	total      int // findings that passed the confidence filter
+	suppressed int
+	closed     bool
+	err        error
+}
+
+type findingRecord struct {
+	Record string `json:"record"`
+	shared.JSONMatch
+}
+
+type suppressedRecord struct {
+	Record string `json:"record"`
+	shared.JSONMatch
+	SuppressedBy string     `json:"suppressed_by"`
+	RuleReason   string     `json:"rule_reason"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	Expired      bool       `json:"expired"`
+}
+
+type notExaminedRecord struct {
+	Record  string `json:"record"`
+	Path    string `json:"path"`
+	Cause   string `json:"cause"`
+	Detail  string `json:"detail,omitempty"`
+	Message string `json:"message"`
+}
+
+// summaryRecord closes the stream. Findings counts the finding lines written;
+// TotalFindings is how many passed the confidence filter, so a consumer can see
+// what --limit dropped without counting lines.
+type summaryRecord struct {
+	Record        string                `json:"record"`
+	Findings      int                   `json:"findings"`
+	TotalFindings int                   `json:"total_findings"`
+	Truncated     bool                  `json:"truncated"`
+	Suppressed    int                   `json:"suppressed"`
+	NotExamined   int                   `json:"not_examined"`
+	Stats         *formatters.ScanStats `json:"stats,omitempty"`
+}
+
+// errClosed is returned for a write after Close, which would put a record
+// after the summary a consumer treats as end of stream.
+var errClosed = errors.New("jsonl stream already closed")
+
+func (s *stream) WriteMatch(match detector.Match) error {
+	if s.closed {
+		return errClosed
+	}
+	if !shared.PassesConfidenceFilter(match, s.options) {
+		return s.err
+	}
+	s.total++
+	if s.options.Limit > 0 && s.written >= s.options.Limit {
+		return s.err
+	}
+	if s.write(findingRecord{Record: RecordFinding, JSONMatch: shared.NewJSONMatch(match, s.options)}) == nil {
+		s.written++
+	}
+	return s.err
+}
+
+func (s *stream) WriteSuppressed(sm detector.SuppressedMatch) error {
+	if s.closed {
+		return errClosed
+	}
+	// NewJSONMatch applies the same redaction as an active finding, which is
+	// what SanitizeSuppressedMatches does for the json document's block.
+	rec := suppressedRecord{
+		Record:       RecordSuppressed,
+		JSONMatch:    shared.NewJSONMatch(sm.Match, s.options),
+		SuppressedBy: sm.SuppressedBy,
+		RuleReason:   sm.RuleReason,
+		ExpiresAt:    sm.ExpiresAt,
+		Expired:      sm.Expired,
+	}
+	if s.write(rec) == nil {
+		s.suppressed++
+	}
+	return s.err
+}
+
+// Close writes every not-examined entry and then the summary. The entries are
+// not capped with formatters.CapNotExamined: the cap protects consumers that
+// must hold a whole document, and a line consumer never does.
+func (s *stream) Close(options formatters.FormatterOptions) error {
+	if s.closed {
+		return errClosed
+	}
+	s.closed = true
+
+	for _, nf := range options.NotExamined {
+		s.write(notExaminedRecord{
+			Record:  RecordNotExamined,
+			Path:    nf.Path,
+			Cause:   nf.Cause.String(),
+			Detail:  nf.Detail,
+			Message: nf.Message(),
+		})
+	}
+	s.write(summaryRecord{
+		Record:        RecordSummary,
+		Findings:      s.written,
+		TotalFindings: s.total,
+		Truncated:     s.written < s.total,
+		Suppressed:    s.suppressed,
+		NotExamined:   len(options.NotExamined),
+		Stats:         options.Stats,
+	})
+	return s.err
+}
+
+func (s *stream) write(record interface{}) error {
+	if s.err != nil {
+		return s.err
+	}
+	if err := s.enc.Encode(record); err != nil {
+		s.err = fmt.Errorf("writing jsonl record: %w", err)
+	}
+	return s.err
+}
+
+// Register the formatter during package initialization
+func init() {
+	formatters.Register(NewFormatter())
+}
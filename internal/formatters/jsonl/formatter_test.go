@@ -0,0 +1,214 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package jsonl
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/awslabs/ferret-scan/v2/internal/detector"
+	"github.com/awslabs/ferret-scan/v2/internal/formatters"
+	"github.com/awslabs/ferret-scan/v2/internal/suppressions"
+)
+
+var allLevels = map[string]bool{"high": true, "medium": true, "low": true}
+
+const secret = "123-45-6789"
+
+func ssnMatch(file string, line int) detector.Match {
+	return detector.Match{
+		Text: secret, Type: "SSN", Validator: "SSN", Confidence: 95,
+		Filename: file, LineNumber: line,
+		Context:  detector.ContextInfo{FullLine: "ssn: " + secret},
+		Metadata: map[string]interface{}{"full_field": secret, "pattern_type": "dashed"},
+	}
+}
+
+// records splits output into decoded lines, failing on any line that is not a
+// JSON object on its own.
+func records(t *testing.T, out string) []map[string]interface{} {
+	t.Helper()
+	var recs []map[string]interface{}
+	for i, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+		var rec map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("line %d is not a JSON object: %v\n%s", i+1, err, line)
+		}
+		recs = append(recs, rec)
+	}
+	return recs
+}
+
+// TestStream_WritesEachFindingImmediately is the point of the format: a
+// finding handed to the stream is on the writer before the next one arrives,
+// not buffered until Close.
+func TestStream_WritesEachFindingImmediately(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewFormatter().NewStream(formatters.FormatterOptions{ConfidenceLevel: allLevels, StreamWriter: &buf})
+
+	if err := s.WriteMatch(ssnMatch("a.txt", 1)); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(buf.String(), "\n"); got != 1 {
+		t.Fatalf("after one finding the writer holds %d lines, want 1", got)
+	}
+	if err := s.WriteMatch(ssnMatch("b.txt", 2)); err != nil {
+		t.Fatal(err)
+	}
+	stats := &formatters.ScanStats{TotalFiles: 3, FilesProcessed: 2, FilesNotExamined: 1, TotalFindings: 2}
+	if err := s.Close(formatters.FormatterOptions{
+		Stats:       stats,
+		NotExamined: []formatters.NotExaminedFile{{Path: "locked.pdf", Cause: formatters.NotExaminedUnreadable, Detail: "permission denied"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	recs := records(t, buf.String())
+	var kinds []string
+	for _, r := range recs {
+		kinds = append(kinds, r["record"].(string))
+	}
+	if got, want := strings.Join(kinds, ","), "finding,finding,not_examined,summary"; got != want {
+		t.Fatalf("record sequence = %s, want %s", got, want)
+	}
+	if recs[0]["filename"] != "a.txt" || recs[0]["suppression_hash"] != suppressions.FindingHash(ssnMatch("a.txt", 1)) {
+		t.Errorf("finding record = %v", recs[0])
+	}
+	if recs[2]["cause"] != "cannot read" || recs[2]["path"] != "locked.pdf" {
+		t.Errorf("not_examined record = %v", recs[2])
+	}
+	summary := recs[3]
+	if summary["findings"] != 2.0 || summary["not_examined"] != 1.0 || summary["truncated"] != false {
+		t.Errorf("summary record = %v", summary)
+	}
+	if st, ok := summary["stats"].(map[string]interface{}); !ok || st["files_not_examined"] != 1.0 {
+		t.Errorf("summary stats = %v", summary["stats"])
+	}
+
+	if err := s.WriteMatch(ssnMatch("c.txt", 3)); err == nil {
+		t.Error("write after Close accepted; it would land after the summary")
+	}
+}
+
+// TestStream_ValuesHiddenWithoutShowMatch is the leak gate, for active and
+// suppressed records alike.
+func TestStream_ValuesHiddenWithoutShowMatch(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewFormatter().NewStream(formatters.FormatterOptions{ConfidenceLevel: allLevels, Verbose: true, StreamWriter: &buf})
+	if err := s.WriteMatch(ssnMatch("a.txt", 1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.WriteSuppressed(detector.SuppressedMatch{Match: ssnMatch("b.txt", 2), SuppressedBy: "SUP-00000001", RuleReason: "fixture"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(formatters.FormatterOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(buf.String(), secret) {
+		t.Fatalf("stream leaked the value without --show-match:\n%s", buf.String())
+	}
+	recs := records(t, buf.String())
+	if recs[1]["record"] != RecordSuppressed || recs[1]["suppressed_by"] != "SUP-00000001" || recs[1]["text"] != "[HIDDEN]" {
+		t.Errorf("suppressed record = %v", recs[1])
+	}
+	if recs[2]["suppressed"] != 1.0 {
+		t.Errorf("summary suppressed = %v, want 1", recs[2]["suppressed"])
+	}
+}
+
+// TestStream_LimitAndConfidence checks both filters are applied per record and
+// that the summary discloses what --limit held back.
+func TestStream_LimitAndConfidence(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewFormatter().NewStream(formatters.FormatterOptions{
+		ConfidenceLevel: map[string]bool{"high": true},
+		Limit:           1,
+		StreamWriter:    &buf,
+	})
+	low := ssnMatch("low.txt", 1)
+	low.Confidence = 30
+	for _, m := range []detector.Match{low, ssnMatch("a.txt", 1), ssnMatch("b.txt", 2)} {
+		if err := s.WriteMatch(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Close(formatters.FormatterOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	recs := records(t, buf.String())
+	if len(recs) != 2 || recs[0]["filename"] != "a.txt" {
+		t.Fatalf("want the first HIGH finding then the summary, got %v", recs)
+	}
+	if recs[1]["findings"] != 1.0 || recs[1]["total_findings"] != 2.0 || recs[1]["truncated"] != true {
+		t.Errorf("summary = %v, want 1 of 2 findings, truncated", recs[1])
+	}
+}
+
+type brokenPipe struct{ writes int }
+
+func (b *brokenPipe) Write(p []byte) (int, error) {
+	b.writes++
+	return 0, errors.New("broken pipe")
+}
+
+// TestStream_WriteErrorIsSticky makes sure a reader that went away costs one
+// failed write, not one per remaining finding, and that Close reports it.
+func TestStream_WriteErrorIsSticky(t *testing.T) {
+	w := &brokenPipe{}
+	s := NewFormatter().NewStream(formatters.FormatterOptions{ConfidenceLevel: allLevels, StreamWriter: w})
+	for i := 0; i < 3; i++ {
+		if err := s.WriteMatch(ssnMatch("a.txt", i)); err == nil {
+			t.Fatal("write to a broken pipe reported success")
+		}
+	}
+	if err := s.Close(formatters.FormatterOptions{}); err == nil {
+		t.Error("Close did not report the earlier write error")
+	}
+	if w.writes != 1 {
+		t.Errorf("attempted %d writes, want 1", w.writes)
+	}
+}
+
+// TestFormat_Buffered covers the non-streaming path used by --output, the web
+// export and pre-commit: priority order, and a trailing summary even when there
+// are no findings at all.
+func TestFormat_Buffered(t *testing.T) {
+	medium := ssnMatch("m.txt", 1)
+	medium.Confidence = 70
+	out, err := NewFormatter().Format([]detector.Match{medium, ssnMatch("h.txt", 1)}, nil,
+		formatters.FormatterOptions{ConfidenceLevel: allLevels, ShowMatch: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.HasSuffix(out, "\n") {
+		t.Error("buffered output should not end in a newline; the caller prints one")
+	}
+	recs := records(t, out)
+	if len(recs) != 3 || recs[0]["filename"] != "h.txt" || recs[0]["text"] != secret {
+		t.Errorf("records = %v, want HIGH first with its value shown", recs)
+	}
+
+	empty, err := NewFormatter().Format(nil, nil, formatters.FormatterOptions{ConfidenceLevel: allLevels})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recs := records(t, empty); len(recs) != 1 || recs[0]["record"] != RecordSummary {
+		t.Errorf("clean scan = %q, want a lone summary record", empty)
+	}
+
+	var buf bytes.Buffer
+	streamed, err := NewFormatter().Format([]detector.Match{medium}, nil,
+		formatters.FormatterOptions{ConfidenceLevel: allLevels, StreamWriter: &buf})
+	if err != nil || streamed != "" {
+		t.Fatalf("with a StreamWriter Format returned %q, %v; want \"\" and the records on the writer", streamed, err)
+	}
+	if len(records(t, buf.String())) != 2 {
+		t.Errorf("writer received %q", buf.String())
+	}
+}
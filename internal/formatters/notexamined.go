@@ -89,6 +89,11 @@ const (
 	// Its type WAS one the tool would have processed; an unprocessable type refused
 	// for size is not reported at all, because nobody expected a finding from it.
 	NotExaminedTooLarge
+
+	// NotExaminedEncrypted — a password-protected document that no supplied
+	// password opened, or whose encryption scheme is detected but not decrypted.
+	// Its contents were never read.
+	NotExaminedEncrypted
 )
 
 // String returns the operator-facing cause label.
@@ -114,6 +119,8 @@ func (c NotExaminedCause) String() string {
 		return "symlink not followed"
 	case NotExaminedTooLarge:
 		return "file too large to scan"
+	case NotExaminedEncrypted:
+		return "encrypted"
 	default:
 		return "unknown"
 	}
@@ -13,6 +13,8 @@ import (
 	"github.com/awslabs/ferret-scan/v2/internal/formatters"
 	csvfmt "github.com/awslabs/ferret-scan/v2/internal/formatters/csv"
 	gitlabsast "github.com/awslabs/ferret-scan/v2/internal/formatters/gitlab-sast"
+	htmlfmt "github.com/awslabs/ferret-scan/v2/internal/formatters/html"
+	"github.com/awslabs/ferret-scan/v2/internal/formatters/jsonl"
 	"github.com/awslabs/ferret-scan/v2/internal/formatters/junit"
 	"github.com/awslabs/ferret-scan/v2/internal/formatters/sarif"
 )
@@ -86,6 +88,12 @@ func TestZeroFindingsStillDisclosesNotExamined(t *testing.T) {
 		{"junit", func() (string, error) {
 			return junit.NewFormatter().Format(nil, nil, opts)
 		}},
+		{"html", func() (string, error) {
+			return htmlfmt.NewFormatter().Format(nil, nil, opts)
+		}},
+		{"jsonl", func() (string, error) {
+			return jsonl.NewFormatter().Format(nil, nil, opts)
+		}},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			out, err := tc.out()
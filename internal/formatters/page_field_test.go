@@ -0,0 +1,87 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package formatters_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/awslabs/ferret-scan/v2/internal/detector"
+	"github.com/awslabs/ferret-scan/v2/internal/formatters"
+	jsonfmt "github.com/awslabs/ferret-scan/v2/internal/formatters/json"
+	"github.com/awslabs/ferret-scan/v2/internal/formatters/sarif"
+	"github.com/awslabs/ferret-scan/v2/internal/formatters/text"
+)
+
+// A finding from a paged document carries its page in the text, JSON and SARIF
+// output, and a finding without one carries no page field at all.
+func TestPageFieldInTextJSONAndSARIF(t *testing.T) {
+	matches := []detector.Match{
+		{Text: "536-90-4271", Type: "SSN", Confidence: 95, Filename: "statement.pdf", LineNumber: 1204, Page: 87, Validator: "ssn"},
+		{Text: "a@example.com", Type: "EMAIL", Confidence: 95, Filename: "notes.txt", LineNumber: 3, Validator: "email"},
+	}
+	opts := formatters.FormatterOptions{
+		ConfidenceLevel: map[string]bool{"high": true, "medium": true, "low": true},
+		NoColor:         true,
+	}
+
+	out, err := text.NewFormatter().Format(matches, nil, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "statement.pdf (page 87)") {
+		t.Errorf("text output does not show the page:\n%s", out)
+	}
+	if strings.Contains(out, "notes.txt (page") {
+		t.Errorf("text output shows a page for an unpaged file:\n%s", out)
+	}
+
+	out, err = jsonfmt.NewFormatter().Format(matches, nil, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Results []map[string]any `json:"results"`
+	}
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatal(err)
+	}
+	pages := map[string]any{}
+	for _, r := range doc.Results {
+		pages[r["filename"].(string)] = r["page"]
+	}
+	if pages["statement.pdf"] != float64(87) || pages["notes.txt"] != nil {
+		t.Errorf("JSON pages = %v, want 87 for the PDF and none for the text file", pages)
+	}
+
+	out, err = sarif.NewFormatter().Format(matches, nil, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var log struct {
+		Runs []struct {
+			Results []struct {
+				Properties map[string]any `json:"properties"`
+				Locations  []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal([]byte(out), &log); err != nil {
+		t.Fatal(err)
+	}
+	pages = map[string]any{}
+	for _, r := range log.Runs[0].Results {
+		pages[r.Locations[0].PhysicalLocation.ArtifactLocation.URI] = r.Properties["page"]
+	}
+	if pages["statement.pdf"] != float64(87) || pages["notes.txt"] != nil {
+		t.Errorf("SARIF pages = %v, want 87 for the PDF and none for the text file", pages)
+	}
+}
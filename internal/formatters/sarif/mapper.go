@@ -14,6 +14,7 @@ import (
 	"github.com/awslabs/ferret-scan/v2/internal/explain"
 	"github.com/awslabs/ferret-scan/v2/internal/formatters"
 	"github.com/awslabs/ferret-scan/v2/internal/formatters/shared"
+	"github.com/awslabs/ferret-scan/v2/internal/suppressions"
 )
 
 // VulnerabilityMapper converts ferret-scan detector matches to SARIF results
@@ -79,10 +80,14 @@ func (m *VulnerabilityMapper) MapToSARIFResult(match detector.Match, options for
 	m.ruleManager.GetOrCreateRule(match.Type)
 
 	result := &SARIFResult{
-		RuleID:     match.Type,
-		Level:      LevelError, // All sensitive data findings are errors
-		Message:    m.buildMessage(match, options),
-		Locations:  []SARIFLocation{m.buildLocation(match, options)},
+		RuleID:    match.Type,
+		Level:     LevelError, // All sensitive data findings are errors
+		Message:   m.buildMessage(match, options),
+		Locations: []SARIFLocation{m.buildLocation(match, options)},
+		PartialFingerprints: map[string]string{
+			FingerprintSuppressionHash: suppressions.FindingHash(match),
+			FingerprintLineFreeHash:    suppressions.LineFreeHash(match),
+		},
 		Properties: m.buildProperties(match, options),
 		Rank:       m.calculateRank(match),
 	}
@@ -97,10 +102,14 @@ func (m *VulnerabilityMapper) MapSuppressedMatch(suppressed detector.SuppressedM
 	m.ruleManager.GetOrCreateRule(suppressed.Match.Type)
 
 	result := &SARIFResult{
-		RuleID:     suppressed.Match.Type,
-		Level:      LevelNone, // Suppressed results use "none" level
-		Message:    m.buildMessage(suppressed.Match, options),
-		Locations:  []SARIFLocation{m.buildLocation(suppressed.Match, options)},
+		RuleID:    suppressed.Match.Type,
+		Level:     LevelNone, // Suppressed results use "none" level
+		Message:   m.buildMessage(suppressed.Match, options),
+		Locations: []SARIFLocation{m.buildLocation(suppressed.Match, options)},
+		PartialFingerprints: map[string]string{
+			FingerprintSuppressionHash: suppressions.FindingHash(suppressed.Match),
+			FingerprintLineFreeHash:    suppressions.LineFreeHash(suppressed.Match),
+		},
 		Properties: m.buildPropertiesForSuppressed(suppressed, options),
 		Suppressions: []SARIFSuppression{
 			{
@@ -270,6 +279,18 @@ func (m *VulnerabilityMapper) buildProperties(match detector.Match, options form
 		properties["validator"] = match.Validator
 	}
 
+	// The page of a paged document. SARIF regions have no page coordinate, and
+	// startLine is a line of the whole extraction, so the page travels beside it.
+	if match.Page > 0 {
+		properties["page"] = match.Page
+	}
+
+	// The spreadsheet cell, for the same reason: a worksheet row is one line of
+	// the extraction, and neither the line nor the column names the sheet.
+	if cell := shared.CellLocation(match, options.ShowMatch); cell != "" {
+		properties["cell"] = cell
+	}
+
 	// Add metadata if available (with rounded floats). The shared sanitizer
 	// drops the explanation key (surfaced as a first-class property below) and,
 	// when ShowMatch is false, redacts any value that embeds the raw matched
@@ -121,14 +121,26 @@ type SARIFRule struct {
 
 // SARIFResult represents a single result (finding) from the analysis
 type SARIFResult struct {
-	RuleID       string                 `json:"ruleId"`
-	Level        string                 `json:"level"`
-	Message      SARIFMessage           `json:"message"`
-	Locations    []SARIFLocation        `json:"locations,omitempty"`
-	Properties   map[string]interface{} `json:"properties,omitempty"`
-	Suppressions []SARIFSuppression     `json:"suppressions,omitempty"`
-	Rank         float64                `json:"rank,omitempty"`
-}
+	RuleID              string                 `json:"ruleId"`
+	Level               string                 `json:"level"`
+	Message             SARIFMessage           `json:"message"`
+	Locations           []SARIFLocation        `json:"locations,omitempty"`
+	PartialFingerprints map[string]string      `json:"partialFingerprints,omitempty"`
+	Properties          map[string]interface{} `json:"properties,omitempty"`
+	Suppressions        []SARIFSuppression     `json:"suppressions,omitempty"`
+	Rank                float64                `json:"rank,omitempty"`
+}
+
+// FingerprintSuppressionHash is the partialFingerprints key carrying a result's
+// suppression identity. The "/v2" suffix follows the SARIF convention of
+// versioning fingerprint keys, and matches the current finding-hash version so
+// a future identity change gets a new key instead of silently changing values.
+const FingerprintSuppressionHash = "ferretSuppressionHash/v2"
+
+// FingerprintLineFreeHash is the partialFingerprints key carrying a result's
+// identity without its line (see suppressions.LineFreeHash), which pairs a
+// finding across two scans after lines above it moved it.
+const FingerprintLineFreeHash = "ferretLineFreeHash/v1"
 
 // SARIFLocation represents the location of a result
 type SARIFLocation struct {
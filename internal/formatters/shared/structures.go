@@ -5,10 +5,12 @@ package shared
 
 import (
 	"sort"
+	"strings"
 
 	"github.com/awslabs/ferret-scan/v2/internal/detector"
 	"github.com/awslabs/ferret-scan/v2/internal/explain"
 	"github.com/awslabs/ferret-scan/v2/internal/formatters"
+	"github.com/awslabs/ferret-scan/v2/internal/suppressions"
 )
 
 // redactionPlaceholder is the single token used everywhere a sensitive value is
@@ -106,6 +108,18 @@ var safeMetadataKeys = map[string]bool{
 	"template_risk_level":      true,
 	"template_risk_factors":    true,
 
+	// Document fingerprint source: the registered document's path and the
+	// measured overlap, not the copied text.
+	"fingerprint_document": true,
+	"similarity_percent":   true,
+	"matched_fingerprints": true,
+
+	// File type mismatch: the format the content is in and the names it goes
+	// by, read from the file's signature and not its text.
+	"detected_format":     true,
+	"file_extension":      true,
+	"expected_extensions": true,
+
 	// Provenance — the scanned file path / preprocessor, already exposed via the
 	// top-level filename field; not match content.
 	"source":              true,
@@ -154,6 +168,26 @@ func SanitizeMetadata(meta map[string]interface{}, matchText string, showMatch b
 	return out
 }
 
+// CellLocation returns the spreadsheet cell a finding was extracted from, as it
+// may be emitted given the ShowMatch setting, or "" for a finding from anything
+// but a spreadsheet.
+//
+// The sheet name is document text, and a workbook can name a sheet after the
+// very value on it ("449-87-4100 payroll"). When the value is hidden and the
+// sheet name holds it, only the cell is emitted (C42): the location stays
+// useful and the name cannot repeat what the Text field withholds.
+func CellLocation(match detector.Match, showMatch bool) string {
+	cell := match.Cell
+	if cell == "" || showMatch || match.Text == "" {
+		return cell
+	}
+	bang := strings.LastIndexByte(cell, '!')
+	if bang >= 0 && strings.Contains(cell[:bang], match.Text) {
+		return cell[bang+1:]
+	}
+	return cell
+}
+
 // SanitizeSuppressedMatches returns suppressed matches that are safe to
 // serialize given the ShowMatch setting. The JSON/YAML formatters embed the
 // raw detector.SuppressedMatch (its finding's Text, Metadata, and Context all
@@ -176,6 +210,7 @@ func SanitizeSuppressedMatches(suppressed []detector.SuppressedMatch, showMatch
 		sanitized := s // copy the suppression envelope (SuppressedBy, RuleReason, ...)
 		m := s.Match   // copy the finding so we don't mutate the caller's slice
 		m.Metadata = SanitizeMetadata(m.Metadata, s.Match.Text, false)
+		m.Cell = CellLocation(s.Match, false)
 		m.Text = redactionPlaceholder
 		m.SecureText = nil
 		// Context holds the raw surrounding line and before/after text.
@@ -201,6 +236,8 @@ type JSONResponse struct {
 type JSONMatch struct {
 	Text            string                 `json:"text" yaml:"text"`
 	LineNumber      int                    `json:"line_number" yaml:"line_number"`
+	Page            int                    `json:"page,omitempty" yaml:"page,omitempty"`
+	Cell            string                 `json:"cell,omitempty" yaml:"cell,omitempty"`
 	Type            string                 `json:"type" yaml:"type"`
 	Confidence      float64                `json:"confidence" yaml:"confidence"`
 	ConfidenceLevel string                 `json:"confidence_level" yaml:"confidence_level"`
@@ -211,6 +248,19 @@ type JSONMatch struct {
 	FullLine        string                 `json:"full_line,omitempty" yaml:"full_line,omitempty"`
 	BeforeText      string                 `json:"before_text,omitempty" yaml:"before_text,omitempty"`
 	AfterText       string                 `json:"after_text,omitempty" yaml:"after_text,omitempty"`
+
+	// SuppressionHash is the finding's suppression identity — the hash a rule
+	// for it would record. It is derived through SHA-256 and carries no
+	// recoverable value, so it is emitted with or without --show-match; it is
+	// what lets `ferret-suppress --action import` and `ferret-scan diff` work
+	// from a saved result instead of a re-scan.
+	SuppressionHash string `json:"suppression_hash,omitempty" yaml:"suppression_hash,omitempty"`
+
+	// LineFreeHash is the finding's identity without its line (see
+	// suppressions.LineFreeHash), so `ferret-scan diff` can pair a finding
+	// that lines inserted above it have moved. Like SuppressionHash it carries
+	// no recoverable value.
+	LineFreeHash string `json:"line_free_hash,omitempty" yaml:"line_free_hash,omitempty"`
 }
 
 // JSONExplanation is the first-class, schema-stable rendering of an advisory
@@ -227,15 +277,21 @@ type JSONExplanation struct {
 func FilterMatchesByConfidence(matches []detector.Match, options formatters.FormatterOptions) []detector.Match {
 	var filtered []detector.Match
 	for _, match := range matches {
-		if (match.Confidence >= 90 && options.ConfidenceLevel["high"]) ||
-			(match.Confidence >= 60 && match.Confidence < 90 && options.ConfidenceLevel["medium"]) ||
-			(match.Confidence < 60 && options.ConfidenceLevel["low"]) {
+		if PassesConfidenceFilter(match, options) {
 			filtered = append(filtered, match)
 		}
 	}
 	return filtered
 }
 
+// PassesConfidenceFilter reports whether a single finding falls in a confidence
+// band the options ask to display.
+func PassesConfidenceFilter(match detector.Match, options formatters.FormatterOptions) bool {
+	return (match.Confidence >= 90 && options.ConfidenceLevel["high"]) ||
+		(match.Confidence >= 60 && match.Confidence < 90 && options.ConfidenceLevel["medium"]) ||
+		(match.Confidence < 60 && options.ConfidenceLevel["low"])
+}
+
 // ApplyLimit truncates an already-filtered, already-sorted slice to
 // options.Limit, reporting the pre-truncation total and whether anything was
 // dropped. A Limit of 0 or less means unlimited.
@@ -362,57 +418,7 @@ func ConvertMatchesToJSONFormat(matches []detector.Match, suppressedMatches []de
 	// would become visible.
 	jsonMatches := make([]JSONMatch, 0, len(matches))
 	for _, match := range matches {
-		// Sanitize metadata through the single shared path so a value duplicated
-		// inside metadata (e.g. name_components, full_field) cannot defeat the
-		// Text-field redaction below.
-		metadata := SanitizeMetadata(match.Metadata, match.Text, options.ShowMatch)
-
-		confidenceLevel := GetConfidenceLevel(match.Confidence)
-
-		// Determine display text based on ShowMatch option. When ShowMatch is
-		// false, substitute "[HIDDEN]" so raw sensitive data is never serialized
-		// into JSON/YAML output, matching the text, CSV, SARIF, and JUnit formatters.
-		displayText := redactionPlaceholder
-		if options.ShowMatch {
-			displayText = match.Text
-		}
-
-		jsonMatch := JSONMatch{
-			Text:            displayText,
-			LineNumber:      match.LineNumber,
-			Type:            match.Type,
-			Confidence:      match.Confidence,
-			ConfidenceLevel: confidenceLevel,
-			Filename:        match.Filename,
-			Validator:       match.Validator,
-			Metadata:        metadata,
-		}
-
-		if ex, ok := explain.FromMatch(match); ok {
-			jsonMatch.Explanation = &JSONExplanation{
-				Rationale:           ex.Rationale,
-				Verdict:             string(ex.Verdict),
-				DraftSuppressReason: ex.DraftSuppressReason,
-			}
-		}
-
-		// Verbose context fields (full line, surrounding text) contain the raw
-		// matched value, so they must ALSO be gated on ShowMatch — otherwise
-		// --verbose re-leaks the secret that ShowMatch=false just hid in Text
-		// (e.g. full_line "apiKey := \"sk_live_...\""). Require both.
-		if options.Verbose && options.ShowMatch {
-			if match.Context.FullLine != "" {
-				jsonMatch.FullLine = match.Context.FullLine
-			}
-			if match.Context.BeforeText != "" {
-				jsonMatch.BeforeText = match.Context.BeforeText
-			}
-			if match.Context.AfterText != "" {
-				jsonMatch.AfterText = match.Context.AfterText
-			}
-		}
-
-		jsonMatches = append(jsonMatches, jsonMatch)
+		jsonMatches = append(jsonMatches, NewJSONMatch(match, options))
 	}
 
 	resp := JSONResponse{
@@ -429,3 +435,64 @@ func ConvertMatchesToJSONFormat(matches []detector.Match, suppressedMatches []de
 	}
 	return resp
 }
+
+// NewJSONMatch converts one finding to its JSON/YAML form, applying the same
+// redaction as ConvertMatchesToJSONFormat. It is exported for formatters that
+// emit findings one at a time rather than as a single document.
+func NewJSONMatch(match detector.Match, options formatters.FormatterOptions) JSONMatch {
+	// Sanitize metadata through the single shared path so a value duplicated
+	// inside metadata (e.g. name_components, full_field) cannot defeat the
+	// Text-field redaction below.
+	metadata := SanitizeMetadata(match.Metadata, match.Text, options.ShowMatch)
+
+	confidenceLevel := GetConfidenceLevel(match.Confidence)
+
+	// Determine display text based on ShowMatch option. When ShowMatch is
+	// false, substitute "[HIDDEN]" so raw sensitive data is never serialized
+	// into JSON/YAML output, matching the text, CSV, SARIF, and JUnit formatters.
+	displayText := redactionPlaceholder
+	if options.ShowMatch {
+		displayText = match.Text
+	}
+
+	jsonMatch := JSONMatch{
+		Text:            displayText,
+		LineNumber:      match.LineNumber,
+		Page:            match.Page,
+		Cell:            CellLocation(match, options.ShowMatch),
+		Type:            match.Type,
+		Confidence:      match.Confidence,
+		ConfidenceLevel: confidenceLevel,
+		Filename:        match.Filename,
+		Validator:       match.Validator,
+		Metadata:        metadata,
+		SuppressionHash: suppressions.FindingHash(match),
+		LineFreeHash:    suppressions.LineFreeHash(match),
+	}
+
+	if ex, ok := explain.FromMatch(match); ok {
+		jsonMatch.Explanation = &JSONExplanation{
+			Rationale:           ex.Rationale,
+			Verdict:             string(ex.Verdict),
+			DraftSuppressReason: ex.DraftSuppressReason,
+		}
+	}
+
+	// Verbose context fields (full line, surrounding text) contain the raw
+	// matched value, so they must ALSO be gated on ShowMatch — otherwise
+	// --verbose re-leaks the secret that ShowMatch=false just hid in Text
+	// (e.g. full_line "apiKey := \"sk_live_...\""). Require both.
+	if options.Verbose && options.ShowMatch {
+		if match.Context.FullLine != "" {
+			jsonMatch.FullLine = match.Context.FullLine
+		}
+		if match.Context.BeforeText != "" {
+			jsonMatch.BeforeText = match.Context.BeforeText
+		}
+		if match.Context.AfterText != "" {
+			jsonMatch.AfterText = match.Context.AfterText
+		}
+	}
+
+	return jsonMatch
+}
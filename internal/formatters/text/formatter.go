@@ -477,7 +477,7 @@ func (f *Formatter) appendSummaryLine(w io.Writer, match detector.Match, confide
 	matchStr := matchText
 
 	// Format filename with smart path display
-	filename := f.getSmartFilename(match.Filename, allMatches)
+	filename := f.getSmartFilename(match.Filename, allMatches) + locationSuffix(match, options)
 	filenameStr := filename
 	if !options.NoColor {
 		filenameStr = f.colors["white"].Sprint(filename)
@@ -494,6 +494,20 @@ func (f *Formatter) appendSummaryLine(w io.Writer, match detector.Match, confide
 		filenameStr)
 }
 
+// locationSuffix renders where in a paged document or a spreadsheet a finding
+// sits, after its line: the line counts through the whole extracted text, which
+// on page 40 of a PDF, or in row 42 of a workbook's third sheet, is not a number
+// anyone can find in a viewer.
+func locationSuffix(match detector.Match, options formatters.FormatterOptions) string {
+	if cell := shared.CellLocation(match, options.ShowMatch); cell != "" {
+		return " (" + cell + ")"
+	}
+	if match.Page <= 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (page %d)", match.Page)
+}
+
 // appendDetailedMatch adds detailed match information to the writer
 func (f *Formatter) appendDetailedMatch(w io.Writer, match detector.Match, confidenceLevel string, options formatters.FormatterOptions) {
 	// Title with color
@@ -510,10 +524,10 @@ func (f *Formatter) appendDetailedMatch(w io.Writer, match detector.Match, confi
 		f.colors["cyan"].Fprintf(w, "Match found in ")
 		f.colors["white"].Fprintf(w, "%s", match.Filename)
 		f.colors["cyan"].Fprintf(w, " on ")
-		f.colors["magenta"].Fprintf(w, "line %d", match.LineNumber)
+		f.colors["magenta"].Fprintf(w, "line %d%s", match.LineNumber, locationSuffix(match, options))
 		f.colors["cyan"].Fprintf(w, ": %s\n", shownText)
 	} else {
-		fmt.Fprintf(w, "Match found in %s on line %d: %s\n", match.Filename, match.LineNumber, shownText)
+		fmt.Fprintf(w, "Match found in %s on line %d%s: %s\n", match.Filename, match.LineNumber, locationSuffix(match, options), shownText)
 	}
 
 	// Type
@@ -736,10 +750,10 @@ func (f *Formatter) appendDetailedSuppressedMatch(w io.Writer, suppressed detect
 		f.colors["cyan"].Fprintf(w, "Suppressed match found in ")
 		f.colors["white"].Fprintf(w, "%s", match.Filename)
 		f.colors["cyan"].Fprintf(w, " on ")
-		f.colors["magenta"].Fprintf(w, "line %d", match.LineNumber)
+		f.colors["magenta"].Fprintf(w, "line %d%s", match.LineNumber, locationSuffix(match, options))
 		f.colors["cyan"].Fprintf(w, ": %s\n", shownText)
 	} else {
-		fmt.Fprintf(w, "Suppressed match found in %s on line %d: %s\n", match.Filename, match.LineNumber, shownText)
+		fmt.Fprintf(w, "Suppressed match found in %s on line %d%s: %s\n", match.Filename, match.LineNumber, locationSuffix(match, options), shownText)
 	}
 
 	// Suppression info
@@ -956,8 +970,8 @@ func (f *Formatter) formatPrecommitOutput(matches []detector.Match, suppressedMa
 			// Add specific issue details for actionable guidance
 			for _, match := range fileMatchList {
 				confidenceLevel := f.getConfidenceLevel(match.Confidence)
-				fmt.Fprintf(&builder, "  line %d: %s (%s confidence)\n",
-					match.LineNumber,
+				fmt.Fprintf(&builder, "  line %d%s: %s (%s confidence)\n",
+					match.LineNumber, locationSuffix(match, options),
 					f.getPrecommitIssueDescription(match),
 					strings.ToLower(confidenceLevel))
 
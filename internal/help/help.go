@@ -87,6 +87,9 @@ func (h *System) ShowGeneralHelp() {
 	fmt.Println("  ferret-scan --file <path-to-file> [options]")
 	fmt.Println("  cat input | ferret-scan --stdin [options]    # Stream content from stdin")
 	fmt.Println("  ferret-scan --web [--port <port>]            # Web server mode")
+	fmt.Println("  ferret-scan diff <base> <head> [options]     # Compare two saved results (see diff -h)")
+	fmt.Println("  ferret-scan edm build <table.csv> --out <f>  # Index known records for --edm (see edm -h)")
+	fmt.Println("  ferret-scan fingerprint register <file>... --store <f>  # Register documents for --fingerprints")
 	fmt.Println()
 
 	h.colors["header"].Println("OPTIONS:")
@@ -105,7 +108,7 @@ func (h *System) ShowGeneralHelp() {
 	fmt.Fprintln(w, "\t\t\tNote: Uses glob patterns, not regex - dots and other characters are literal (use '.git', not '\\.git')")
 	fmt.Fprintln(w, "  --respect-gitignore\t\tHonor .gitignore, .git/info/exclude, and global git excludes when scanning (opt-in; .git always skipped when enabled)")
 	fmt.Fprintln(w, "\t\t\tNote: .gitignore often hides .env, *.pem, and credential files — leave off for deep audits")
-	fmt.Fprintln(w, "  --format\t<format>\tOutput format: text, json, csv, yaml, junit, gitlab-sast, sarif (default: text)")
+	fmt.Fprintln(w, "  --format\t<format>\tOutput format: text, json, csv, yaml, junit, gitlab-sast, sarif, html, jsonl (default: text)")
 	fmt.Fprintln(w, "\t\t\tNote: gitlab-sast generates GitLab Security Report format for integration with GitLab Security Dashboard")
 	fmt.Fprintln(w, "\t\t\tNote: sarif generates SARIF 2.1.0 format for integration with GitHub Security and other SARIF-compatible tools")
 	// NOTE: this check-name list must stay in sync with the single source of
@@ -116,7 +119,7 @@ func (h *System) ShowGeneralHelp() {
 	// --checks flag help and the two parseChecksToRun sites in cmd/main.go) are
 	// sourced from core.CheckNames(); this is the one that cannot be. Keep the
 	// no-space comma separators to match historical output.
-	fmt.Fprintln(w, "  --checks\t<checks>\tSpecific checks to run: BANK_ACCOUNT,CLOUD_RESOURCES,CREDIT_CARD,DATE_OF_BIRTH,DRIVERS_LICENSE,EMAIL,INTELLECTUAL_PROPERTY,IP_ADDRESS,MEDICAL_ID,METADATA,OTP,PASSPORT,PERSON_NAME,PHONE,PHYSICAL_ADDRESS,SECRETS,SOCIAL_MEDIA,SSN,VIN,all (default: all)")
+	fmt.Fprintln(w, "  --checks\t<checks>\tSpecific checks to run: BANK_ACCOUNT,CLOUD_RESOURCES,CREDIT_CARD,DATE_OF_BIRTH,DOCUMENT_FINGERPRINT,DRIVERS_LICENSE,EDM_MATCH,EMAIL,FILE_TYPE_MISMATCH,INTELLECTUAL_PROPERTY,IP_ADDRESS,MEDICAL_ID,METADATA,OTP,PASSPORT,PERSON_NAME,PHONE,PHYSICAL_ADDRESS,SECRETS,SOCIAL_MEDIA,SSN,VIN,all (default: all)")
 	fmt.Fprintln(w, "\t\t\tNote: INTELLECTUAL_PROPERTY requires configuration for internal URL detection")
 	fmt.Fprintln(w, "\t\t\tNote: METADATA validator now includes enhanced preprocessor-aware validation for images, documents, audio, and video")
 	fmt.Fprintln(w, "  --confidence\t<levels>\tConfidence levels to display: high,medium,low,all (default: all)")
@@ -136,10 +139,17 @@ func (h *System) ShowGeneralHelp() {
 	fmt.Fprintln(w, "  --disable-ip-types\t<types>\tComma-separated list of IP sub-types to skip: copyright,patent,trademark,trade_secret,internal_url")
 	fmt.Fprintln(w, "  --validator-budget\t<spec>\tPer-validator time budget as NAME=DURATION pairs; DURATION accepts any Go unit — ms, s, m, h (e.g. 'SSN=500ms,IP_ADDRESS=2m'). Use 'all=<dur>' for every validator, specific names override. Over-budget validators are stopped and the scan is marked incomplete. Default: none.")
 	fmt.Fprintln(w, "  --max-live-bytes\t<size>\tCap total extracted content held in memory across concurrently scanned files, e.g. '256MB' or '1GB' (units: B, KB, MB, GB; bare number = bytes). Bounds peak memory on constrained hosts so many large files cannot multiply memory. Default: no cap.")
+	fmt.Fprintln(w, "  --sample-rows\t<n>\tRead only the first N rows of each Parquet or Avro file, for a fast classification of large files. The rows left unread are reported as incomplete coverage. Default: 0 (every row).")
+	fmt.Fprintln(w, "  --edm\t<path>\tReport the values of the table indexed with 'ferret-scan edm build' (check EDM_MATCH, HIGH confidence), including a name or other field found with the last 4 digits of the same record's SSN or account number. Default: none.")
+	fmt.Fprintln(w, "  --fingerprints\t<path>\tReport content copied from the confidential documents registered with 'ferret-scan fingerprint register', in any format, with the source document and similarity (check DOCUMENT_FINGERPRINT). Default: none.")
+	fmt.Fprintln(w, "  --password-file\t<path>\tYAML file of per-glob passwords for encrypted PDF and Office documents. Documents are decrypted in memory only; one no password opens is reported as not examined (encrypted). Password-protected .doc, .xls and .ppt (Office 97-2003) files are not decrypted and are skipped the same way, whatever password is given. Default: none.")
 	fmt.Fprintln(w, "  --enable-redaction\t\tEnable redaction of sensitive data found in documents")
 	fmt.Fprintln(w, "  --redaction-output-dir\t<path>\tDirectory where redacted files will be stored (default: ./redacted)")
 	fmt.Fprintln(w, "  --redaction-strategy\t<strategy>\tDefault redaction strategy: simple, format_preserving, or synthetic (default: format_preserving)")
 	fmt.Fprintln(w, "  --redaction-audit-log\t<path>\tPath to save redaction audit log file (JSON format for compliance)")
+	fmt.Fprintln(w, "  --clear-notebook-outputs\t\tWith --enable-redaction, also empty the outputs of every Jupyter notebook cell holding a HIGH confidence finding")
+	fmt.Fprintln(w, "  --gps-precision\t<n|distance>\tWith --enable-redaction, keep reported GPS positions in image and video metadata coarsened to N decimal places (1-5) or a distance such as 1km or 100m, instead of removing them")
+	fmt.Fprintln(w, "  --sanitize-metadata\t\tWrite a copy of each file to --redaction-output-dir with its personal metadata removed, reported or not (authors, company, template, custom properties, revision IDs, printer names, EXIF, XMP, GPS, tags), and report the fields removed. Nothing is scanned; --format text or json")
 	fmt.Fprintln(w, "  --limit\t<n>\tMaximum findings to display (default: 200, 0 = unlimited)")
 	fmt.Fprintln(w, "  --web\t\tStart web server mode instead of CLI scanning")
 	fmt.Fprintln(w, "  --port\t<port>\tPort for web server (default: 8080, only used with --web)")
@@ -168,6 +178,7 @@ func (h *System) ShowGeneralHelp() {
 	h.colors["header"].Println("Redaction Examples:")
 	h.colors["example"].Println("  ferret-scan --file document.txt --enable-redaction  # Redact sensitive data")
 	h.colors["example"].Println("  ferret-scan --file *.pdf --enable-redaction --redaction-output-dir ./safe-docs")
+	h.colors["example"].Println("  ferret-scan --file ./outgoing --recursive --sanitize-metadata  # Strip authors, EXIF, GPS, rsids")
 
 	fmt.Println()
 	h.colors["header"].Println("Stdin / Streaming Examples:")
@@ -178,6 +189,21 @@ func (h *System) ShowGeneralHelp() {
 	h.colors["example"].Println("  echo \"data\" | ferret-scan --file -                                        # POSIX-style alias")
 	h.colors["example"].Println("  git diff | ferret-scan --stdin --stdin-name '<git-diff>' --suppression-file ./.ferret-stdin.yaml")
 
+	fmt.Println()
+	h.colors["header"].Println("Comparing Scans:")
+	h.colors["example"].Println("  ferret-scan diff base.json head.json                        # New, fixed and band-moved findings")
+	h.colors["example"].Println("  ferret-scan diff --format markdown --confidence high a.sarif b.sarif")
+
+	fmt.Println()
+	h.colors["header"].Println("Exact Data Match:")
+	h.colors["example"].Println("  ferret-scan edm build customers.csv --out customers.edm     # Hash the table once")
+	h.colors["example"].Println("  ferret-scan --file exports/ --recursive --edm customers.edm # Find its values")
+
+	fmt.Println()
+	h.colors["header"].Println("Document Fingerprints:")
+	h.colors["example"].Println("  ferret-scan fingerprint register board-deck.pdf plan.docx --store confidential.fp")
+	h.colors["example"].Println("  ferret-scan --file shared/ --recursive --fingerprints confidential.fp # Find copies")
+
 	fmt.Println()
 	h.colors["header"].Println("Web Server Examples:")
 	h.colors["example"].Println("  ferret-scan --web  # Start web server on default port")
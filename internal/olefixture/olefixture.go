@@ -21,9 +21,10 @@
 // offset (n+1)*512. Sectors are then:
 //
 //	0   : the single FAT sector
-//	1   : the single directory sector
+//	1   : the first directory sector
 //	2   : the single mini FAT sector
-//	3.. : the mini stream chain, then one chain per regular stream
+//	3.. : the mini stream chain, then one chain per regular stream, then any
+//	      further directory sectors
 //
 // One FAT sector holds 128 entries, addressing 64KB of file — far more than any
 // fixture needs, so there is no DIFAT or multi-FAT handling. Build returns an error
@@ -53,6 +54,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"sort"
+	"strings"
 	"time"
 	"unicode/utf16"
 )
@@ -72,8 +74,9 @@ const (
 	endOfChain = 0xFFFFFFFE
 	fatSector  = 0xFFFFFFFD
 
-	typeStream = 2
-	typeRoot   = 5
+	typeStorage = 1
+	typeStream  = 2
+	typeRoot    = 5
 
 	fatLoc     = 0
 	dirLoc     = 1
@@ -95,7 +98,9 @@ var Signature = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
 // Stream is one named stream to place in a container.
 type Stream struct {
 	// Name is the name as Office writes it, including any leading 0x05 byte for a
-	// property stream.
+	// property stream. A "/" separates the storages the stream sits in from its
+	// own name: "__attach_version1.0_#00000000/__substg1.0_3001001F" is a stream
+	// inside one storage, as an Outlook message keeps each attachment.
 	Name string
 	Data []byte
 }
@@ -104,8 +109,9 @@ type Stream struct {
 // under MiniCutoff bytes go through the mini stream, as Office does.
 //
 // It returns an error rather than a malformed container when a fixture would
-// exceed what a single FAT or directory sector can address: a silently corrupt
-// fixture makes every test built on it pass for the wrong reason.
+// exceed what the single mini FAT sector or the header's FAT list can address:
+// a silently corrupt fixture makes every test built on it pass for the wrong
+// reason.
 func Build(streams []Stream) ([]byte, error) {
 	type placement struct {
 		mini       bool
@@ -113,11 +119,7 @@ func Build(streams []Stream) ([]byte, error) {
 		size       uint32
 	}
 	places := make([]placement, len(streams))
-
-	if max := SectorSize / dirEntrySize; len(streams) > max-1 {
-		return nil, fmt.Errorf("olefixture: %d streams requested but one directory "+
-			"sector holds %d entries including the root", len(streams), max)
-	}
+	nodes := directoryTree(streams)
 
 	// Small streams are concatenated into the mini stream on 64-byte boundaries.
 	miniData := new(bytes.Buffer)
@@ -163,6 +165,12 @@ func Build(streams []Stream) ([]byte, error) {
 		next += uint32(n)
 	}
 
+	// The first directory sector is fixed at dirLoc; any more follow the stream
+	// data, so a fixture that fits one sector keeps the layout it always had.
+	dirSectors := (len(nodes)*dirEntrySize + SectorSize - 1) / SectorSize
+	extraDirStart := next
+	next += uint32(dirSectors - 1)
+
 	// The FAT must describe every sector INCLUDING its own, so growing it can push
 	// the total past the next multiple and require another FAT sector. Solve for the
 	// smallest count that covers itself, rather than assuming one.
@@ -186,6 +194,10 @@ func Build(streams []Stream) ([]byte, error) {
 
 	fat := freeTable(numFAT * entriesPerSector)
 	fat[dirLoc] = endOfChain
+	if dirSectors > 1 {
+		fat[dirLoc] = extraDirStart
+		chainRun(fat, extraDirStart, dirSectors-1)
+	}
 	fat[miniFATLoc] = endOfChain
 	chainRun(fat, miniStreamStart, miniStreamSectorCount)
 	for i := range streams {
@@ -221,7 +233,7 @@ func Build(streams []Stream) ([]byte, error) {
 	binary.LittleEndian.PutUint16(hdr[28:], 0xFFFE) // little-endian marker
 	binary.LittleEndian.PutUint16(hdr[30:], 9)      // sector shift: 1<<9 = 512
 	binary.LittleEndian.PutUint16(hdr[32:], 6)      // mini sector shift: 1<<6 = 64
-	binary.LittleEndian.PutUint32(hdr[40:], 1)      // directory sector count
+	binary.LittleEndian.PutUint32(hdr[40:], uint32(dirSectors))
 	binary.LittleEndian.PutUint32(hdr[44:], uint32(numFAT))
 	binary.LittleEndian.PutUint32(hdr[48:], dirLoc)
 	binary.LittleEndian.PutUint32(hdr[56:], MiniCutoff)
@@ -247,21 +259,18 @@ func Build(streams []Stream) ([]byte, error) {
 
 	// The root entry's chain IS the mini stream and its size is the mini stream's
 	// length; a reader walks that chain to resolve every mini sector.
-	dir := make([]byte, SectorSize)
-	child := uint32(freeSector)
-	if len(streams) > 0 {
-		child = 1
-	}
-	writeDirEntry(dir[0:dirEntrySize], "Root Entry", typeRoot,
-		miniStreamStart, uint32(miniData.Len()), child, freeSector)
-	for i, s := range streams {
-		off := (i + 1) * dirEntrySize
-		right := uint32(freeSector)
-		if i+1 < len(streams) {
-			right = uint32(i + 2)
+	dir := make([]byte, dirSectors*SectorSize)
+	for i, n := range nodes {
+		e := dir[i*dirEntrySize : (i+1)*dirEntrySize]
+		switch n.objType {
+		case typeRoot:
+			writeDirEntry(e, n.name, typeRoot, miniStreamStart, uint32(miniData.Len()), n.child, n.right)
+		case typeStorage:
+			writeDirEntry(e, n.name, typeStorage, 0, 0, n.child, n.right)
+		default:
+			p := places[n.stream]
+			writeDirEntry(e, n.name, typeStream, p.startEntry, p.size, n.child, n.right)
 		}
-		writeDirEntry(dir[off:off+dirEntrySize], s.Name, typeStream,
-			places[i].startEntry, places[i].size, freeSector, right)
 	}
 
 	// The FAT is one flat table split across numFAT sectors, in DIFAT order.
@@ -274,7 +283,7 @@ func Build(streams []Stream) ([]byte, error) {
 	out := make([]byte, 0, SectorSize*(1+totalSectors))
 	out = append(out, hdr...)
 	out = append(out, fatSlice(0)...) // sector 0: the first FAT sector
-	out = append(out, dir...)
+	out = append(out, dir[:SectorSize]...)
 	out = append(out, tableSector(miniFAT)...)
 	if miniStreamSectorCount > 0 {
 		padded := make([]byte, miniStreamSectorCount*SectorSize)
@@ -289,6 +298,7 @@ func Build(streams []Stream) ([]byte, error) {
 		copy(padded, s.Data)
 		out = append(out, padded...)
 	}
+	out = append(out, dir[SectorSize:]...)
 	// Remaining FAT sectors go after the data, which is why appending them cannot
 	// shift any sector number assigned above.
 	for i := 1; i < numFAT; i++ {
@@ -297,6 +307,52 @@ func Build(streams []Stream) ([]byte, error) {
 	return out, nil
 }
 
+// dirNode is one directory entry in the order Build writes them, the root first.
+type dirNode struct {
+	name    string
+	objType byte
+	stream  int // index into the streams, for a stream entry
+	child   uint32
+	right   uint32
+}
+
+// directoryTree lays out the directory for streams: the root, then every
+// storage and stream in order of first mention. Siblings are chained through
+// their right pointers alone, which readers walk exactly as they would a
+// balanced tree; a flat list therefore comes out as the root followed by one
+// entry per stream, each pointing at the next.
+func directoryTree(streams []Stream) []dirNode {
+	nodes := []dirNode{{name: "Root Entry", objType: typeRoot, stream: -1, child: freeSector, right: freeSector}}
+	last := map[int]int{}
+	storages := map[string]int{}
+	add := func(parent int, n dirNode) int {
+		idx := len(nodes)
+		nodes = append(nodes, n)
+		if prev, ok := last[parent]; ok {
+			nodes[prev].right = uint32(idx)
+		} else {
+			nodes[parent].child = uint32(idx)
+		}
+		last[parent] = idx
+		return idx
+	}
+	for i, s := range streams {
+		parts := strings.Split(s.Name, "/")
+		parent := 0
+		for d := 0; d < len(parts)-1; d++ {
+			key := strings.Join(parts[:d+1], "/")
+			idx, ok := storages[key]
+			if !ok {
+				idx = add(parent, dirNode{name: parts[d], objType: typeStorage, stream: -1, child: freeSector, right: freeSector})
+				storages[key] = idx
+			}
+			parent = idx
+		}
+		add(parent, dirNode{name: parts[len(parts)-1], objType: typeStream, stream: i, child: freeSector, right: freeSector})
+	}
+	return nodes
+}
+
 // MustBuild is Build for callers that cannot handle an error, such as a package-level
 // fixture in a corpus definition. It panics on a malformed request, which surfaces
 // at test setup rather than as a mysterious empty scan result.
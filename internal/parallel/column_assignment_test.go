@@ -130,3 +130,37 @@ func TestRunValidatorsColumnAssignmentIsDeterministic(t *testing.T) {
 		}
 	}
 }
+
+// RunValidators names the cell of a match from a spreadsheet, using the column
+// it has just assigned: both occurrences of a value on one row sit in different
+// cells, and only the column tells them apart.
+func TestRunValidatorsAssignsSpreadsheetCells(t *testing.T) {
+	const line = "a@b.com\tcopy\ta@b.com\t"
+	v := &columnProbeValidator{line: line, value: "a@b.com", count: 2}
+
+	processed := &preprocessors.ProcessedContent{
+		OriginalPath:  "contacts.xlsx",
+		Filename:      "contacts.xlsx",
+		Text:          line,
+		ProcessorType: "Text Extractor",
+		Success:       true,
+		Sections: []preprocessors.ContentSection{{
+			Kind: preprocessors.SectionKindBody, Text: line,
+			Cells: []preprocessors.CellLine{{Line: 0, Sheet: "Contacts", Cells: []preprocessors.CellPos{
+				{Offset: 0, Row: 7, Column: 1}, {Offset: 8, Row: 7, Column: 2}, {Offset: 13, Row: 7, Column: 3},
+			}}},
+		}},
+	}
+
+	matches, err := RunValidators(context.Background(), []detector.Validator{v}, processed, nil)
+	if err != nil {
+		t.Fatalf("RunValidators: %v", err)
+	}
+	got := map[string]bool{}
+	for _, m := range matches {
+		got[m.Cell] = true
+	}
+	if len(matches) != 2 || !got["Contacts!A7"] || !got["Contacts!C7"] {
+		t.Errorf("cells = %v, want Contacts!A7 and Contacts!C7", got)
+	}
+}
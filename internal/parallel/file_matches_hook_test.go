@@ -0,0 +1,49 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package parallel
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/awslabs/ferret-scan/v2/internal/detector"
+	"github.com/awslabs/ferret-scan/v2/internal/observability"
+)
+
+// TestOnFileMatches_SeesEveryMatchOnce checks the streaming hook delivers each
+// file's matches exactly once, before the batch returns, and that what it saw
+// is what the batch result reports — a streamed finding the final summary does
+// not count (or the reverse) would make the two disagree.
+func TestOnFileMatches_SeesEveryMatchOnce(t *testing.T) {
+	dir := t.TempDir()
+	body := strings.Repeat("abcd0123", 64)
+	files := []string{
+		writeTxt(t, dir, "a.txt", body),
+		writeTxt(t, dir, "b.txt", body),
+		writeTxt(t, dir, "c.txt", body),
+	}
+
+	seen := map[string]int{}
+	var streamed int
+	cfg := &JobConfig{OnFileMatches: func(filePath string, matches []detector.Match) {
+		seen[filePath]++
+		streamed += len(matches)
+	}}
+
+	pp := NewParallelProcessor(observability.NewStandardObserver(observability.ObservabilityMetrics, io.Discard))
+	matches, _, err := pp.ProcessFilesWithProgress(files, []detector.Validator{&liveBytesProbeValidator{}}, newTestFileRouter(t), cfg, nil, nil)
+	if err != nil {
+		t.Fatalf("processing failed: %v", err)
+	}
+
+	if streamed != len(matches) {
+		t.Errorf("hook saw %d matches, batch returned %d", streamed, len(matches))
+	}
+	for _, f := range files {
+		if seen[f] != 1 {
+			t.Errorf("hook called %d times for %s, want 1", seen[f], f)
+		}
+	}
+}
@@ -214,6 +214,9 @@ func (pp *ParallelProcessor) ProcessFilesWithProgress(filePaths []string, valida
 		} else {
 			allMatches = append(allMatches, result.Matches...)
 			processedCount++
+			if config != nil && config.OnFileMatches != nil && len(result.Matches) > 0 {
+				config.OnFileMatches(result.FilePath, result.Matches)
+			}
 		}
 		totalDuration += result.Duration
 
@@ -286,6 +286,16 @@ func RunValidators(
 		// order restored above — assigning occurrences in goroutine-completion
 		// order would hand the same finding a different column run to run.
 		detector.AssignLineColumns(allMatches)
+
+		// Name each spreadsheet match's cell. This needs the column just
+		// assigned: a row is one line, so the line alone says nothing about
+		// which cell on it the value sits in.
+		assignCells(allMatches, processedContent.CellLookup())
+
+		// A database match is reported against the row and column it was read
+		// from, "app.db -> users[rowid=42].ssn", the way an item of any other
+		// container is.
+		assignRecords(allMatches, processedContent.RecordLookup())
 	}
 
 	select {
@@ -328,3 +338,37 @@ func RunValidators(
 		return allMatches, firstErr
 	}
 }
+
+// assignCells stamps each match with the spreadsheet cell its value was
+// extracted from. A match that already names a cell keeps it, and a nil cellOf
+// (content that is not a spreadsheet) leaves every match untouched.
+func assignCells(matches []detector.Match, cellOf func(line, column int, value string) string) {
+	if cellOf == nil {
+		return
+	}
+	for i := range matches {
+		m := &matches[i]
+		if m.Cell == "" && m.LineNumber > 0 && m.StartColumn > 0 {
+			m.Cell = cellOf(m.LineNumber, m.StartColumn, m.Text)
+		}
+	}
+}
+
+// assignRecords labels each match found in a database row with the row and
+// column it was read from. A nil recordOf (content that is not a database)
+// leaves every match untouched.
+func assignRecords(matches []detector.Match, recordOf func(line, column int, value string) string) {
+	if recordOf == nil {
+		return
+	}
+	labels := preprocessors.NewRouterIntegrationHelper()
+	for i := range matches {
+		m := &matches[i]
+		if m.LineNumber <= 0 || m.StartColumn <= 0 {
+			continue
+		}
+		if field := recordOf(m.LineNumber, m.StartColumn, m.Text); field != "" {
+			m.Filename = labels.CreateItemPath(m.Filename, "", field)
+		}
+	}
+}
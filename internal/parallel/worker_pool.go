@@ -82,6 +82,14 @@ type JobConfig struct {
 	// multiply memory past a fixed envelope. Enforced by the worker pool's
 	// shared execguard.BytesLimiter around the validation phase.
 	MaxLiveBytes int64
+
+	// OnFileMatches, when non-nil, receives each successfully processed file's
+	// matches as soon as the collector takes its result, in worker completion
+	// order — before the batch returns. It is how a streaming output format
+	// emits findings while the scan is still running. Calls are made one at a
+	// time from the collecting goroutine, so the callback needs no locking, but
+	// collection waits while it runs.
+	OnFileMatches func(filePath string, matches []detector.Match)
 }
 
 // DefaultJobTimeout is the per-file processing ceiling used when
@@ -269,12 +277,20 @@ func (wp *WorkerPool) processJob(job *Job, workerID int) *Result {
 		// limiter is disabled and Acquire/Release are no-ops, so the default path
 		// is byte-identical. If ctx is cancelled while waiting for budget, skip the
 		// file and record the context error so coverage is reported incomplete.
+		//
+		// Only a file the router reads within its own bound (router.SizeExempt) is
+		// larger than router.MaxFileSize, so that is the most one reserves; sized by
+		// the file, a 5GB Parquet export would run alone.
 		if bl := wp.liveBytesLimiter(job); bl != nil {
-			if aerr := bl.AcquireBytes(ctx, processingCtx.FileSize); aerr != nil {
+			reserve := min(processingCtx.FileSize, router.MaxFileSize)
+			if aerr := bl.AcquireBytes(ctx, reserve); aerr != nil {
 				validationErr = aerr
 				return nil
 			}
-			defer bl.ReleaseBytes(processingCtx.FileSize)
+			defer bl.ReleaseBytes(reserve)
+			// Extractors that hold content of their own while they work charge
+			// the same budget (the PDF page decoder does).
+			processingCtx.LiveBytes = bl
 		}
 
 		// Use standard retry for local file processing
@@ -339,7 +355,12 @@ func (wp *WorkerPool) processJob(job *Job, workerID int) *Result {
 	var redactionResult *redactors.RedactionResult
 	var redactedPath string
 
-	if job.Config.EnableRedaction && job.RedactionManager != nil && len(allMatches) > 0 && processedContent != nil {
+	// A finding about the whole file (detector.FileLevelKey), such as a name that
+	// contradicts the content, has no value in the file to remove; a file with
+	// only those has nothing to redact.
+	redactable := valueMatches(allMatches)
+
+	if job.Config.EnableRedaction && job.RedactionManager != nil && len(redactable) > 0 && processedContent != nil {
 		// Perform redaction using the same extracted content. A failure here is
 		// recorded in redactionErr ONLY — it must never reach lastError. This
 		// step runs after the file has already been read, extracted and
@@ -350,7 +371,7 @@ func (wp *WorkerPool) processJob(job *Job, workerID int) *Result {
 		// file: a source file with no registered redactor (.go, .py, ...) had
 		// its findings erased from every output format while the scan still
 		// reported "0 skipped" and exited 0.
-		redactionResult, redactedPath, err = wp.performInlineRedaction(job, allMatches, processedContent)
+		redactionResult, redactedPath, err = wp.performInlineRedaction(job, redactable, processedContent)
 		if err != nil {
 			redactionErr = err
 		}
@@ -388,6 +409,25 @@ func (wp *WorkerPool) processJob(job *Job, workerID int) *Result {
 	}
 }
 
+// valueMatches returns the matches that locate a value in the file's content,
+// leaving out findings about the file as a whole.
+func valueMatches(matches []detector.Match) []detector.Match {
+	for i, m := range matches {
+		if !m.IsFileLevel() {
+			continue
+		}
+		// Copy only when there is something to leave out, which is rare.
+		out := append([]detector.Match(nil), matches[:i]...)
+		for _, m := range matches[i+1:] {
+			if !m.IsFileLevel() {
+				out = append(out, m)
+			}
+		}
+		return out
+	}
+	return matches
+}
+
 // performInlineRedaction performs redaction using the already-extracted content
 func (wp *WorkerPool) performInlineRedaction(job *Job, matches []detector.Match, processedContent *preprocessors.ProcessedContent) (*redactors.RedactionResult, string, error) {
 	// Parse redaction strategy
@@ -0,0 +1,82 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package pathglob is the one path-glob dialect used by every file that names
+// scanned paths: suppression pattern rules and the password file.
+//
+// `*` and `?` stay inside one path segment and `**` spans any number of
+// segments. A pattern is unanchored: "docs/**" matches "docs/a.md",
+// "./docs/a.md" and "/srv/repo/docs/a.md" alike, because the same file is
+// applied to scans started from different working directories.
+//
+// It is shared rather than copied because an operator writes both files against
+// the same tree. Two dialects would let one glob select a document for a
+// password and not for a suppression, and nothing would say which was wrong.
+package pathglob
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Glob is a compiled pattern.
+type Glob struct {
+	parts []string
+}
+
+// Compile validates a pattern and splits it into segments.
+func Compile(pattern string) (Glob, error) {
+	glob := strings.TrimPrefix(filepath.ToSlash(pattern), "./")
+	parts := strings.Split(strings.Trim(glob, "/"), "/")
+	for _, part := range parts {
+		if part == "**" {
+			continue
+		}
+		if _, err := path.Match(part, ""); err != nil {
+			return Glob{}, fmt.Errorf("invalid path glob %q: %w", pattern, err)
+		}
+	}
+	return Glob{parts: parts}, nil
+}
+
+// Match matches the glob against every suffix of a path's segments, so a
+// relative pattern applies wherever the scanned tree is rooted. The zero Glob
+// matches nothing.
+func (g Glob) Match(filename string) bool {
+	if filename == "" || g.parts == nil {
+		return false
+	}
+	segments := strings.Split(strings.Trim(filepath.ToSlash(filepath.Clean(filename)), "/"), "/")
+	for start := range segments {
+		if matchSegments(g.parts, segments[start:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSegments matches glob segments against path segments, expanding `**`
+// to zero or more whole segments.
+func matchSegments(pattern, segments []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			for skip := 0; skip <= len(segments); skip++ {
+				if matchSegments(rest, segments[skip:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segments) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+			return false
+		}
+		pattern, segments = pattern[1:], segments[1:]
+	}
+	return len(segments) == 0
+}
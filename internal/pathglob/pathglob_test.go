@@ -0,0 +1,45 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package pathglob
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	cases := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"docs/**", "docs/a.md", true},
+		{"docs/**", "./docs/a.md", true},
+		{"docs/**", "/srv/repo/docs/sub/a.md", true},
+		{"*.pdf", "/srv/repo/finance/q3.pdf", true},
+		{"finance/*.pdf", "/srv/repo/finance/q3.pdf", true},
+		{"finance/*.pdf", "/srv/repo/finance/2024/q3.pdf", false},
+		{"finance/**/*.pdf", "/srv/repo/finance/2024/q3.pdf", true},
+		{"finance/**/*.pdf", "/srv/repo/finance/q3.pdf", true},
+		{"*.pdf", "q3.docx", false},
+		{"**", "anything/at/all", true},
+	}
+	for _, c := range cases {
+		g, err := Compile(c.pattern)
+		if err != nil {
+			t.Fatalf("Compile(%q): %v", c.pattern, err)
+		}
+		if got := g.Match(c.path); got != c.want {
+			t.Errorf("%q.Match(%q) = %v, want %v", c.pattern, c.path, got, c.want)
+		}
+	}
+}
+
+func TestCompileRejectsMalformedGlob(t *testing.T) {
+	if _, err := Compile("finance/[a-"); err == nil {
+		t.Error("an unterminated class compiled; it would silently match nothing")
+	}
+}
+
+func TestZeroGlobMatchesNothing(t *testing.T) {
+	if (Glob{}).Match("a.pdf") {
+		t.Error("zero Glob matched")
+	}
+}
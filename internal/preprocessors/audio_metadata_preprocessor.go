@@ -257,6 +257,12 @@ func (amp *AudioMetadataPreprocessor) createMinimalAudioMetadata(filePath string
 		audioMeta.MimeType = "audio/wav"
 	case ".m4a":
 		audioMeta.MimeType = "audio/mp4"
+	case ".ogg", ".oga":
+		audioMeta.MimeType = "audio/ogg"
+	case ".opus":
+		audioMeta.MimeType = "audio/opus"
+	case ".aiff", ".aif", ".aifc":
+		audioMeta.MimeType = "audio/aiff"
 	default:
 		audioMeta.MimeType = "audio/unknown"
 	}
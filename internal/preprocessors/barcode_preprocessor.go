@@ -0,0 +1,87 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package preprocessors
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/awslabs/ferret-scan/v2/internal/observability"
+	textextractbarcodelib "github.com/awslabs/ferret-scan/v2/internal/preprocessors/text-extractors/text-extract-barcodelib"
+)
+
+// BarcodePreprocessor decodes the QR, Data Matrix, Aztec and PDF417 symbols
+// in an image and reads their payloads as body text.
+//
+// An image was scanned for its metadata only, so a screenshot of an
+// authenticator enrolment QR code, whose payload is an otpauth:// URI carrying
+// the TOTP secret, or a photo of a shipping label whose Data Matrix encodes a
+// name and address, scanned clean. Each symbol is now a section of its own, so
+// a finding names the symbol it was read from: "screenshot.png -> qr[0]". An
+// image embedded in an Office document or attached to a PDF routes here like any
+// other, and is reported as "report.docx -> image1.png -> qr[0]".
+type BarcodePreprocessor struct {
+	*BaseMetadataPreprocessor
+}
+
+// NewBarcodePreprocessor creates a new barcode preprocessor
+func NewBarcodePreprocessor() *BarcodePreprocessor {
+	return &BarcodePreprocessor{
+		BaseMetadataPreprocessor: NewBaseMetadataPreprocessor("barcode", "barcode"),
+	}
+}
+
+// CanProcess checks if this preprocessor can handle the given file
+func (bp *BarcodePreprocessor) CanProcess(filePath string) bool {
+	return bp.GetUtilities().ExtensionValidator.IsImageFile(filePath)
+}
+
+// Process decodes the symbols in an image
+func (bp *BarcodePreprocessor) Process(filePath string) (*ProcessedContent, error) {
+	return bp.ProcessWithRetry(filePath, func() (*ProcessedContent, error) {
+		return bp.processBarcodes(filePath)
+	})
+}
+
+// processBarcodes builds the section-per-symbol text for one image.
+//
+// An image with no symbol, or in a format the decoder cannot read (HEIC, AVIF),
+// is a success with no text: its metadata is still read by image_metadata.
+func (bp *BarcodePreprocessor) processBarcodes(filePath string) (*ProcessedContent, error) {
+	if err := bp.ValidateFileSize(filePath, false); err != nil {
+		return bp.HandleError(filePath, "barcode", err), err
+	}
+
+	symbols, err := textextractbarcodelib.DecodeFile(filePath)
+	if errors.Is(err, textextractbarcodelib.ErrTooLarge) {
+		// Disclosed, not failed: the image's metadata is still scanned, but a
+		// symbol in it was never looked for.
+		content := bp.BuildSuccessContent(filePath, "", "barcode", 0)
+		content.ExtractionWarning = err.Error()
+		return content, nil
+	}
+	if err != nil {
+		err = fmt.Errorf("failed to read image: %w", err)
+		return bp.BuildErrorContent(filePath, "barcode", err), err
+	}
+
+	b := structureText{name: "barcode"}
+	for _, sym := range symbols {
+		b.add(bp.GetUtilities().RouterHelper.CreateItemPath(filePath, "", sym.Label()), 0, sym.ScanText())
+	}
+	content := bp.BuildSuccessContent(filePath, b.text.String(), "barcode", 0)
+	content.Sections = b.sections
+	content.Metadata["symbol_count"] = len(symbols)
+	return content, nil
+}
+
+// GetSupportedExtensions returns the file extensions this preprocessor supports
+func (bp *BarcodePreprocessor) GetSupportedExtensions() []string {
+	return bp.GetUtilities().ExtensionValidator.GetImageExtensions()
+}
+
+// SetObserver sets the observability component
+func (bp *BarcodePreprocessor) SetObserver(observer observability.Observer) {
+	bp.BaseMetadataPreprocessor.SetObserver(observer)
+}
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/awslabs/ferret-scan/v2/internal/embedded"
+	"github.com/awslabs/ferret-scan/v2/internal/encryption"
 	"github.com/awslabs/ferret-scan/v2/internal/observability"
 )
 
@@ -63,6 +64,12 @@ type RouterInterface interface {
 // working.
 var ErrEmbeddedTooDeep = embedded.ErrTooDeep
 
+// ErrEncrypted is wrapped by the router's error for a document that stays
+// encrypted: no supplied password opened it, or its scheme is detected but not
+// decrypted. Aliased to encryption.ErrEncrypted for the same reason as
+// ErrEmbeddedTooDeep: one sentinel, so errors.Is holds wherever it was raised.
+var ErrEncrypted = encryption.ErrEncrypted
+
 // BaseMetadataPreprocessor provides common functionality for all specialized metadata preprocessors
 type BaseMetadataPreprocessor struct {
 	name            string
@@ -314,7 +321,9 @@ func (bmp *BaseMetadataPreprocessor) ProcessEmbeddedMedia(originalFilePath strin
 		}
 
 		processed, perr := bmp.router.ProcessEmbedded(media.TempFilePath, originalFilePath)
-		if errors.Is(perr, ErrEmbeddedTooDeep) {
+		// An encrypted attachment is the same gap as one past the depth bound:
+		// its content was never read, so it is disclosed, not dropped.
+		if errors.Is(perr, ErrEmbeddedTooDeep) || errors.Is(perr, ErrEncrypted) {
 			// DISCLOSE rather than skip. Hitting the bound means this item's content
 			// was never examined; saying nothing would reproduce the exact bug the
 			// bound was added alongside.
@@ -360,9 +369,19 @@ func (bmp *BaseMetadataPreprocessor) ProcessEmbeddedMedia(originalFilePath strin
 			// onto this text and onto the "container -> item" source label. This
 			// composes: a section nested two levels deep still carries the rule
 			// set of the extractor that actually produced it.
+			//
+			// A section that names an item WITHIN the child ("tmp.png -> qr[0]",
+			// labelled from the temp path the router was handed) keeps that item
+			// under the new label, "container.docx -> image1.png -> qr[0]":
+			// dropping it would report every symbol in an image as the image.
+			childPrefix := filepath.Base(media.TempFilePath) + " -> "
 			if len(processed.Sections) > 0 {
 				for _, sub := range processed.Sections {
-					sub.SourceFile = sectionSource
+					if item, ok := strings.CutPrefix(sub.SourceFile, childPrefix); ok {
+						sub.SourceFile = sectionSource + " -> " + item
+					} else {
+						sub.SourceFile = sectionSource
+					}
 					sub.LineOffset += contentLine
 					sections = append(sections, sub)
 				}
@@ -0,0 +1,193 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package preprocessors
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CellLine records the spreadsheet cells on line Line (0-based) of a section's
+// Text: which sheet the line belongs to, and which cell begins at which byte of
+// it. A line continuing a multi-line cell value starts with that cell at Offset
+// 0.
+//
+// A line of a database table is recorded the same way, and also names its row
+// in Record ("users[rowid=42]") and the row's columns in Fields, so a value in
+// column c is located as Record + "." + Fields[c-1]. See RecordLookup.
+//
+// A line of a columnar table (Parquet, Avro) records no Cells: its values are
+// separated by tabs and never contain one, so a value's column is counted from
+// the tabs before it, and a file of millions of rows costs one CellLine a row.
+// Fields names the columns and Row is the row's 1-based number in the file.
+type CellLine struct {
+	Line   int
+	Sheet  string
+	Cells  []CellPos
+	Record string
+	Fields []string
+	Row    int
+}
+
+// CellPos records that the cell at Row and Column (both 1-based) begins at byte
+// Offset of its line. Column 0 marks text that belongs to no cell.
+type CellPos struct {
+	Offset int
+	Row    int
+	Column int
+}
+
+// CellLookup returns a function naming the spreadsheet cell, as "Sheet1!C42",
+// that the value at a 1-based line and column of Text was extracted from, or ""
+// when the position lies in no located cell. It returns nil when no section
+// declares cells, so callers can skip the pass entirely for anything but a
+// spreadsheet.
+//
+// The value is checked against Text at that position before a cell is named.
+// Matches from the metadata path count lines and columns within their own
+// metadata item, not within Text, and a coordinate from one system read in the
+// other would name a cell the value never came from; the check turns that into
+// no location rather than a wrong one.
+//
+// Line starts are indexed once here rather than per lookup: a lookup per
+// finding over a large workbook would otherwise rescan the text for every
+// finding.
+func (pc *ProcessedContent) CellLookup() func(line, column int, value string) string {
+	locate := pc.cellLocator()
+	if locate == nil {
+		return nil
+	}
+	return func(line, column int, value string) string {
+		cl, c, ok := locate(line, column, value)
+		if !ok || cl.Record != "" || cl.Fields != nil {
+			return ""
+		}
+		return CellReference(cl.Sheet, c.Row, c.Column)
+	}
+}
+
+// RecordLookup returns a function naming the database field, as
+// "users[rowid=42].ssn", or the columnar value, as "column email, row 10231",
+// that the value at a 1-based line and column of Text was read from, or "" when
+// the position lies in no database or columnar row. It returns nil when no
+// section declares cells. The value is checked as CellLookup checks it.
+func (pc *ProcessedContent) RecordLookup() func(line, column int, value string) string {
+	locate := pc.cellLocator()
+	if locate == nil {
+		return nil
+	}
+	return func(line, column int, value string) string {
+		cl, c, ok := locate(line, column, value)
+		if !ok || c.Column < 1 || c.Column > len(cl.Fields) {
+			return ""
+		}
+		if cl.Record == "" {
+			return fmt.Sprintf("column %s, row %d", cl.Fields[c.Column-1], cl.Row)
+		}
+		return cl.Record + "." + cl.Fields[c.Column-1]
+	}
+}
+
+// cellLocator returns a function finding the located cell that holds the value
+// at a 1-based line and column of Text, or nil when no section declares cells.
+func (pc *ProcessedContent) cellLocator() func(line, column int, value string) (CellLine, CellPos, bool) {
+	type locatedSection struct {
+		start int // 0-based line of Text where the section begins
+		text  string
+		lines []int // byte offset in text where each line begins
+		cells []CellLine
+	}
+	var located []locatedSection
+	for _, s := range pc.Sections {
+		if len(s.Cells) == 0 {
+			continue
+		}
+		starts := []int{0}
+		for i := 0; i < len(s.Text); i++ {
+			if s.Text[i] == '\n' {
+				starts = append(starts, i+1)
+			}
+		}
+		located = append(located, locatedSection{start: s.LineOffset, text: s.Text, lines: starts, cells: s.Cells})
+	}
+	if len(located) == 0 {
+		return nil
+	}
+
+	return func(line, column int, value string) (CellLine, CellPos, bool) {
+		idx, off := line-1, column-1
+		if off < 0 || value == "" {
+			return CellLine{}, CellPos{}, false
+		}
+		for _, s := range located {
+			rel := idx - s.start
+			if rel < 0 || rel >= len(s.lines) {
+				continue
+			}
+			lineText := s.text[s.lines[rel]:]
+			if end := strings.IndexByte(lineText, '\n'); end >= 0 {
+				lineText = lineText[:end]
+			}
+			if off > len(lineText) || !strings.HasPrefix(lineText[off:], value) {
+				return CellLine{}, CellPos{}, false
+			}
+			i := sort.Search(len(s.cells), func(i int) bool { return s.cells[i].Line >= rel })
+			if i == len(s.cells) || s.cells[i].Line != rel {
+				return CellLine{}, CellPos{}, false
+			}
+			cl := s.cells[i]
+			if cl.Cells == nil && cl.Fields != nil {
+				start := strings.LastIndexByte(lineText[:off], '\t') + 1
+				col := strings.Count(lineText[:off], "\t") + 1
+				return cl, CellPos{Offset: start, Row: cl.Row, Column: col}, true
+			}
+			// The last cell starting at or before the value holds it.
+			j := sort.Search(len(cl.Cells), func(j int) bool { return cl.Cells[j].Offset > off })
+			if j == 0 || cl.Cells[j-1].Column == 0 {
+				return CellLine{}, CellPos{}, false
+			}
+			return cl, cl.Cells[j-1], true
+		}
+		return CellLine{}, CellPos{}, false
+	}
+}
+
+// plainSheetNameRe matches a sheet name a spreadsheet formula may write without
+// quotes.
+var plainSheetNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// cellLikeRe matches a name that would read as a cell reference ("AB12") and so
+// needs quoting even though every character in it is plain.
+var cellLikeRe = regexp.MustCompile(`^[A-Za-z]{1,3}[0-9]+$`)
+
+// CellReference renders a sheet, row and column (both 1-based) the way a
+// spreadsheet formula refers to the cell, so a location can be pasted into the
+// application's Go To box: Sheet1!C42, or 'Payroll Q3'!C42 for a name that
+// needs quoting, with any quote inside the name doubled. A sheet with no name
+// yields the bare cell, C42.
+func CellReference(sheet string, row, column int) string {
+	ref := columnName(column) + strconv.Itoa(row)
+	switch {
+	case sheet == "":
+		return ref
+	case plainSheetNameRe.MatchString(sheet) && !cellLikeRe.MatchString(sheet):
+		return sheet + "!" + ref
+	default:
+		return "'" + strings.ReplaceAll(sheet, "'", "''") + "'!" + ref
+	}
+}
+
+// columnName renders a 1-based column number as its letters: 1 is A, 27 is AA.
+func columnName(column int) string {
+	var b []byte
+	for column > 0 {
+		column--
+		b = append([]byte{byte('A' + column%26)}, b...)
+		column /= 26
+	}
+	return string(b)
+}
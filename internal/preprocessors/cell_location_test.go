@@ -0,0 +1,65 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package preprocessors
+
+import "testing"
+
+// A finding's line and column name the cell its value was read from, and only
+// when the value is really there: a coordinate from another line space, such
+// as a metadata item's, names no cell rather than a wrong one.
+func TestCellLookup(t *testing.T) {
+	text := "intro\n--- Payroll Q3 ---\nname\tssn\t\nJane\t536-22-1874\t\n"
+	pc := &ProcessedContent{Sections: []ContentSection{
+		{Text: "preamble\n"},
+		{LineOffset: 1, Text: text, Cells: []CellLine{
+			{Line: 2, Sheet: "Payroll Q3", Cells: []CellPos{{Offset: 0, Row: 1, Column: 1}, {Offset: 5, Row: 1, Column: 2}}},
+			{Line: 3, Sheet: "Payroll Q3", Cells: []CellPos{{Offset: 0, Row: 42, Column: 1}, {Offset: 5, Row: 42, Column: 3}}},
+		}},
+	}}
+	cellOf := pc.CellLookup()
+	if cellOf == nil {
+		t.Fatal("CellLookup returned nil for content with cells")
+	}
+
+	for _, c := range []struct {
+		line, column int
+		value, want  string
+	}{
+		{5, 6, "536-22-1874", "'Payroll Q3'!C42"},
+		{5, 10, "22-1874", "'Payroll Q3'!C42"},
+		{5, 1, "Jane", "'Payroll Q3'!A42"},
+		{4, 6, "ssn", "'Payroll Q3'!B1"},
+		{5, 6, "449-87-4100", ""}, // not the value at that position
+		{3, 1, "--- Payroll Q3 ---", ""},
+		{2, 1, "intro", ""},
+		{5, 0, "Jane", ""},
+	} {
+		if got := cellOf(c.line, c.column, c.value); got != c.want {
+			t.Errorf("cellOf(%d, %d, %q) = %q, want %q", c.line, c.column, c.value, got, c.want)
+		}
+	}
+
+	if (&ProcessedContent{Sections: []ContentSection{{Text: "x"}}}).CellLookup() != nil {
+		t.Error("CellLookup on content without cells is not nil")
+	}
+}
+
+func TestCellReference(t *testing.T) {
+	for _, c := range []struct {
+		sheet       string
+		row, column int
+		want        string
+	}{
+		{"Sheet1", 42, 3, "Sheet1!C42"},
+		{"Payroll Q3", 42, 3, "'Payroll Q3'!C42"},
+		{"Bob's", 1, 1, "'Bob''s'!A1"},
+		{"AB12", 1, 1, "'AB12'!A1"},
+		{"data_2026.v1", 7, 28, "data_2026.v1!AB7"},
+		{"", 1048576, 16384, "XFD1048576"},
+	} {
+		if got := CellReference(c.sheet, c.row, c.column); got != c.want {
+			t.Errorf("CellReference(%q, %d, %d) = %q, want %q", c.sheet, c.row, c.column, got, c.want)
+		}
+	}
+}
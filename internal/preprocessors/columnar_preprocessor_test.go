@@ -0,0 +1,128 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package preprocessors
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeAvro writes an uncompressed Avro container of string-only records, one
+// block per call's rows.
+func writeAvro(t *testing.T, path string, columns []string, rows [][]string) {
+	t.Helper()
+	long := func(b []byte, v int64) []byte { return binary.AppendUvarint(b, uint64(v<<1)^uint64(v>>63)) }
+	str := func(b []byte, s string) []byte { return append(long(b, int64(len(s))), s...) }
+	var fields []string
+	for _, c := range columns {
+		fields = append(fields, `{"name": "`+c+`", "type": "string"}`)
+	}
+	schema := `{"type": "record", "name": "r", "fields": [` + strings.Join(fields, ", ") + `]}`
+	sync := []byte("0123456789abcdef")
+	b := []byte("Obj\x01")
+	b = str(str(long(b, 1), "avro.schema"), schema)
+	b = append(long(b, 0), sync...)
+	var block []byte
+	for _, r := range rows {
+		for _, v := range r {
+			block = str(block, v)
+		}
+	}
+	b = long(long(b, int64(len(rows))), int64(len(block)))
+	b = append(append(b, block...), sync...)
+	if err := os.WriteFile(path, b, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// A finding in a columnar row is named by its column and row in the file.
+func TestColumnarFindingsNameColumnAndRow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "people.avro")
+	writeAvro(t, path, []string{"name", "ssn"}, [][]string{
+		{"Alice", "536-22-1874"}, {"Bob", "449-87-4100"}, {"Carol", ""},
+	})
+	if !IsColumnarFile(path) {
+		t.Fatal("IsColumnarFile = false")
+	}
+	pc, err := NewColumnarPreprocessor().Process(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "name\tssn\nAlice\t536-22-1874\nBob\t449-87-4100\nCarol\t\n"; pc.Text != want {
+		t.Fatalf("Text = %q, want %q", pc.Text, want)
+	}
+	if pc.ExtractionWarning != "" {
+		t.Errorf("ExtractionWarning = %q", pc.ExtractionWarning)
+	}
+	fieldOf := pc.RecordLookup()
+	for _, c := range []struct {
+		line, column int
+		value, want  string
+	}{
+		{3, 5, "449-87-4100", "column ssn, row 2"},
+		{2, 1, "Alice", "column name, row 1"},
+		{2, 7, "536-22-1874", "column ssn, row 1"},
+		{1, 6, "ssn", ""},
+		{3, 5, "536-22-1874", ""},
+	} {
+		if got := fieldOf(c.line, c.column, c.value); got != c.want {
+			t.Errorf("fieldOf(%d, %d, %q) = %q, want %q", c.line, c.column, c.value, got, c.want)
+		}
+	}
+	// A columnar row is not a spreadsheet cell.
+	if got := pc.CellLookup()(3, 5, "449-87-4100"); got != "" {
+		t.Errorf("CellLookup named %q", got)
+	}
+}
+
+// A sampled file discloses the rows it did not read.
+func TestColumnarSampleIsDisclosed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "people.avro")
+	writeAvro(t, path, []string{"ssn"}, [][]string{{"536-22-1874"}, {"449-87-4100"}, {"212-09-4321"}})
+	cp := NewColumnarPreprocessor()
+	cp.SetSampleRows(1)
+	pc, err := cp.Process(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pc.Text != "ssn\n536-22-1874\n" {
+		t.Errorf("Text = %q", pc.Text)
+	}
+	want := "rows 2-3 of 3 were NOT scanned: only the first 1 were sampled (--sample-rows)"
+	if pc.ExtractionWarning != want {
+		t.Errorf("ExtractionWarning = %q, want %q", pc.ExtractionWarning, want)
+	}
+}
+
+// A text file named .parquet is text; a real Avro file is not, though its
+// header and schema are printable.
+func TestColumnarNeedsMagic(t *testing.T) {
+	dir := t.TempDir()
+	text := filepath.Join(dir, "export.parquet")
+	if err := os.WriteFile(text, []byte("ssn 536-22-1874\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if IsColumnarFile(text) {
+		t.Error("a text file named .parquet was taken for Parquet")
+	}
+	if !NewPlainTextPreprocessor().CanProcess(text) {
+		t.Error("the plaintext preprocessor declined a text file named .parquet")
+	}
+
+	avro := filepath.Join(dir, "wide.avro")
+	cols := make([]string, 40)
+	for i := range cols {
+		cols[i] = "customer_field_" + strings.Repeat("x", i%5)
+	}
+	writeAvro(t, avro, cols, nil)
+	if NewPlainTextPreprocessor().CanProcess(avro) {
+		t.Error("the plaintext preprocessor claimed an Avro file")
+	}
+	if !IsColumnarFile(avro) {
+		t.Error("an Avro file was declined")
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package preprocessors
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/awslabs/ferret-scan/v2/internal/observability"
+	textextractemaillib "github.com/awslabs/ferret-scan/v2/internal/preprocessors/text-extractors/text-extract-emaillib"
+)
+
+// EmailPreprocessor extracts the messages of an email file: an RFC 5322 message
+// (.eml), an mbox of them (.mbox), or an Outlook message (.msg).
+//
+// Before it, .eml and .mbox were scanned as plain text, so a base64 or
+// quoted-printable body was scanned as its encoding and an attachment as a wall
+// of base64; a .msg is a compound file and was not scanned at all.
+//
+// Each message's headers and body are sections of their own. In an mbox they
+// are labelled with the message's position ("inbox.mbox -> msg 57") so a finding
+// says which message to look at; a single-message file keeps its own name.
+// Attachments route back through the router like a PDF's embedded files and are
+// labelled under their message: "inbox.mbox -> msg 57 -> invoice.pdf".
+type EmailPreprocessor struct {
+	*BaseMetadataPreprocessor
+}
+
+// NewEmailPreprocessor creates a new email preprocessor
+func NewEmailPreprocessor() *EmailPreprocessor {
+	return &EmailPreprocessor{
+		BaseMetadataPreprocessor: NewBaseMetadataPreprocessor("email", "email"),
+	}
+}
+
+// CanProcess checks if this preprocessor can handle the given file
+func (ep *EmailPreprocessor) CanProcess(filePath string) bool {
+	return ep.GetUtilities().ExtensionValidator.IsEmailFile(filePath)
+}
+
+// Process extracts the headers, bodies and attachments of every message in an
+// email file
+func (ep *EmailPreprocessor) Process(filePath string) (*ProcessedContent, error) {
+	return ep.ProcessWithRetry(filePath, func() (*ProcessedContent, error) {
+		return ep.processEmail(filePath)
+	})
+}
+
+// processEmail builds the section-per-message text for one email file.
+func (ep *EmailPreprocessor) processEmail(filePath string) (*ProcessedContent, error) {
+	if err := ep.ValidateFileSize(filePath, false); err != nil {
+		return ep.HandleError(filePath, "email", err), err
+	}
+
+	mb, err := textextractemaillib.Extract(filePath)
+	if err != nil {
+		return ep.BuildErrorContent(filePath, "email", err), err
+	}
+	defer textextractemaillib.CleanupAttachments(mb)
+
+	// Only a mailbox gets per-message labels. Numbering the one message of an
+	// .eml would add "-> msg 1" to every finding in it, and change the reported
+	// file of every finding already recorded against it.
+	numbered := len(mb.Messages) > 1 || strings.EqualFold(filepath.Ext(filePath), ".mbox")
+
+	b := structureText{name: "email"}
+	warnings := mb.Notes
+	for i, m := range mb.Messages {
+		var source, prefix string
+		if numbered {
+			source = ep.GetUtilities().RouterHelper.CreateItemPath(filePath, "msg ", strconv.Itoa(i+1))
+			prefix = fmt.Sprintf("msg %d -> ", i+1)
+		}
+
+		var headers strings.Builder
+		for _, h := range m.Headers {
+			headers.WriteString(h.Name + ": " + h.Value + "\n")
+		}
+		b.add(source, 0, headers.String())
+		b.add(source, 0, m.Body)
+
+		if len(m.Attachments) == 0 {
+			continue
+		}
+		media := make([]EmbeddedMedia, len(m.Attachments))
+		for j, a := range m.Attachments {
+			media[j] = EmbeddedMedia{OriginalName: prefix + a.Name, TempFilePath: a.TempFilePath, MediaType: "attachment"}
+		}
+		// Routed exactly like a PDF's embedded files, so a chain of forwarded
+		// messages stops at the router's nesting bound like any other container,
+		// and an attachment it declines is disclosed the same way.
+		text, sections, embeddedWarnings := ep.ProcessEmbeddedMedia(filePath, media)
+		b.addEmbedded(text, sections)
+		warnings = append(warnings, embeddedWarnings...)
+	}
+
+	content := ep.BuildSuccessContent(filePath, b.text.String(), "email", 0)
+	content.Sections = b.sections
+	content.Metadata["message_count"] = len(mb.Messages)
+	content.ExtractionWarning = strings.Join(warnings, "; ")
+	return content, nil
+}
+
+// GetSupportedExtensions returns the file extensions this preprocessor supports
+func (ep *EmailPreprocessor) GetSupportedExtensions() []string {
+	return ep.GetUtilities().ExtensionValidator.GetEmailExtensions()
+}
+
+// SetObserver sets the observability component
+func (ep *EmailPreprocessor) SetObserver(observer observability.Observer) {
+	ep.BaseMetadataPreprocessor.SetObserver(observer)
+}
+
+// SetRouter sets the router instance for routing attachments
+func (ep *EmailPreprocessor) SetRouter(router RouterInterface) {
+	ep.BaseMetadataPreprocessor.SetRouter(router)
+}
@@ -0,0 +1,89 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package preprocessors
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/awslabs/ferret-scan/v2/internal/observability"
+	textextractmarkuplib "github.com/awslabs/ferret-scan/v2/internal/preprocessors/text-extractors/text-extract-markuplib"
+)
+
+// MarkupPreprocessor extracts the readable text of HTML, XML and RTF documents.
+//
+// Before it these were scanned as raw text, so a value written with entities
+// ("&#53;36-22-1874"), split by a tag ("123-<b>45</b>-6789") or broken up by RTF
+// control words was never seen whole, and was missed. The text is decoded and
+// stripped of markup, with a map back to the source bytes the markup redactor
+// uses to rewrite the character data and nothing else. In HTML and XML a line
+// of the text is the same line of the file, so line numbers still point at the
+// source.
+type MarkupPreprocessor struct {
+	*BaseMetadataPreprocessor
+}
+
+// NewMarkupPreprocessor creates a new markup preprocessor
+func NewMarkupPreprocessor() *MarkupPreprocessor {
+	return &MarkupPreprocessor{
+		BaseMetadataPreprocessor: NewBaseMetadataPreprocessor("markup", "markup"),
+	}
+}
+
+// CanProcess checks if this preprocessor can handle the given file
+func (mp *MarkupPreprocessor) CanProcess(filePath string) bool {
+	return mp.GetUtilities().ExtensionValidator.IsMarkupFile(filePath)
+}
+
+// Process extracts the text of an HTML, XML or RTF document
+func (mp *MarkupPreprocessor) Process(filePath string) (*ProcessedContent, error) {
+	return mp.ProcessWithRetry(filePath, func() (*ProcessedContent, error) {
+		return mp.processMarkup(filePath)
+	})
+}
+
+// processMarkup reads and extracts one markup document.
+func (mp *MarkupPreprocessor) processMarkup(filePath string) (*ProcessedContent, error) {
+	if err := mp.ValidateFileSize(filePath, false); err != nil {
+		return mp.HandleError(filePath, "markup", err), err
+	}
+
+	raw, err := os.ReadFile(filePath)
+	if err != nil {
+		err = fmt.Errorf("failed to read file: %w", err)
+		return mp.BuildErrorContent(filePath, "markup", err), err
+	}
+
+	format, _ := textextractmarkuplib.FormatFor(filePath)
+	_, _, ex := ReadMarkup(raw, format)
+
+	content := mp.BuildSuccessContent(filePath, ex.Text, format.String(), 0)
+	content.Metadata["markup_format"] = format.String()
+	return content, nil
+}
+
+// ReadMarkup decodes a markup document's bytes to UTF-8 and extracts its text.
+// It returns the decoded source the extraction maps into and the encoding to
+// write a rewritten source back in.
+//
+// The markup redactor reads documents through this too, so the text it locates
+// findings in is byte for byte the text they were found in.
+func ReadMarkup(raw []byte, format textextractmarkuplib.Format) (string, TextEncoding, *textextractmarkuplib.Extraction) {
+	enc := DetectTextEncoding(raw)
+	source, ok := DecodeToUTF8(raw, enc)
+	if !ok {
+		source = string(raw)
+	}
+	return source, enc, textextractmarkuplib.Extract([]byte(source), format)
+}
+
+// GetSupportedExtensions returns the file extensions this preprocessor supports
+func (mp *MarkupPreprocessor) GetSupportedExtensions() []string {
+	return mp.GetUtilities().ExtensionValidator.GetMarkupExtensions()
+}
+
+// SetObserver sets the observability component
+func (mp *MarkupPreprocessor) SetObserver(observer observability.Observer) {
+	mp.BaseMetadataPreprocessor.SetObserver(observer)
+}
@@ -0,0 +1,267 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package audiolib
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// AIFFExtractor handles AIFF and AIFF-C file metadata extraction
+type AIFFExtractor struct{}
+
+// aiffTextChunkLimit bounds one text chunk. NAME, AUTH and ANNO hold a line of text; a chunk
+// declaring more is skipped and disclosed rather than allocated.
+const aiffTextChunkLimit = MaxMetadataRead
+
+// aiffPadByteNote is the AIFF counterpart of padByteNote.
+const aiffPadByteNote = "the AIFF chunk layout omits a required pad byte after an odd-length " +
+	"chunk; metadata was recovered by realigning, but the file is malformed and may be " +
+	"truncated"
+
+// ExtractMetadata extracts metadata from an AIFF or AIFF-C file.
+//
+// The chunk layout is RIFF's with the byte order reversed: a four-character ID, a big-endian
+// size, and a pad byte after an odd-length chunk. The text lives in NAME, AUTH, "(c) ", ANNO
+// and COMT chunks, and in an ID3 chunk that DAWs and iTunes append after the sound data.
+func (e *AIFFExtractor) ExtractMetadata(filePath string) (*AudioMetadata, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open AIFF file: %w", err)
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file stats: %w", err)
+	}
+
+	metadata := &AudioMetadata{
+		Filename:   stat.Name(),
+		FileSize:   stat.Size(),
+		ModTime:    stat.ModTime(),
+		MimeType:   "audio/aiff",
+		Properties: make(map[string]string),
+	}
+
+	header := make([]byte, 12)
+	if _, err := file.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("failed to read FORM header: %w", err)
+	}
+	form := string(header[8:12])
+	if string(header[0:4]) != "FORM" || (form != "AIFF" && form != "AIFC") {
+		return nil, fmt.Errorf("not a valid AIFF file")
+	}
+
+	if err := e.parseChunks(file, stat.Size(), form == "AIFC", metadata); err != nil {
+		return metadata, fmt.Errorf("failed to parse AIFF chunks: %w", err)
+	}
+
+	return metadata, nil
+}
+
+// parseChunks walks the chunks of the FORM. Like the WAV walk, each chunk is located from its
+// header alone and every size is clamped to the file, so a declaration the file's author chose
+// can neither derail the walk nor size an allocation.
+func (e *AIFFExtractor) parseChunks(file *os.File, fileSize int64, aifc bool, metadata *AudioMetadata) error {
+	sawMissingPad := false
+	annotations := 0
+
+	pos := int64(12)
+	for pos+8 <= fileSize {
+		var header [8]byte
+		if _, err := file.ReadAt(header[:], pos); err != nil {
+			return err
+		}
+		var id [4]byte
+		copy(id[:], header[:4])
+		if !isPrintableChunkID(id) {
+			metadata.ExtractionWarning = "audio metadata may be incomplete: the AIFF chunk " +
+				"layout could not be followed past an unrecognized chunk header, so any " +
+				"metadata after that point was not read"
+			return nil
+		}
+		chunkID := string(id[:])
+		size := int64(binary.BigEndian.Uint32(header[4:8]))
+		dataStart := pos + 8
+		if dataStart+size > fileSize {
+			if metadata.ExtractionWarning == "" && isAIFFTextChunk(chunkID) {
+				metadata.ExtractionWarning = "audio metadata may be incomplete: an AIFF " +
+					chunkID + " chunk declares more data than the file contains, so it was " +
+					"read only to the end of the file"
+			}
+			size = fileSize - dataStart
+		}
+
+		switch {
+		case chunkID == "COMM":
+			e.parseCommonChunk(file, dataStart, size, aifc, metadata)
+		case isAIFFTextChunk(chunkID):
+			if size > aiffTextChunkLimit {
+				if metadata.ExtractionWarning == "" {
+					metadata.ExtractionWarning = "audio metadata may be incomplete: an AIFF " +
+						chunkID + " chunk exceeds the size limit, so it was not read"
+				}
+				break
+			}
+			data := make([]byte, size)
+			if _, err := file.ReadAt(data, dataStart); err != nil {
+				return err
+			}
+			e.parseTextChunk(chunkID, data, &annotations, metadata)
+		}
+
+		next := dataStart + size
+		if size%2 == 1 && next < fileSize {
+			// Pad byte, DETECTED rather than assumed, for the reason consumePadByte gives.
+			var pad [1]byte
+			if _, err := file.ReadAt(pad[:], next); err == nil && pad[0] == 0x00 {
+				next++
+			} else {
+				sawMissingPad = true
+			}
+		}
+		pos = next
+	}
+
+	if sawMissingPad && metadata.ExtractionWarning == "" {
+		metadata.ExtractionWarning = aiffPadByteNote
+	}
+	return nil
+}
+
+// isAIFFTextChunk reports whether a chunk holds text this extractor reports. The audio
+// redactor scopes its overwrite to the same set.
+func isAIFFTextChunk(id string) bool {
+	switch id {
+	case "NAME", "AUTH", "(c) ", "ANNO", "COMT", "ID3 ", "id3 ":
+		return true
+	}
+	return false
+}
+
+// parseCommonChunk reads channels, frame count and the 80-bit extended sample rate, and the
+// compression type of an AIFF-C file.
+func (e *AIFFExtractor) parseCommonChunk(file *os.File, start, size int64, aifc bool, metadata *AudioMetadata) {
+	if size < 18 {
+		return
+	}
+	data := make([]byte, min(size, 22))
+	if _, err := file.ReadAt(data, start); err != nil {
+		return
+	}
+	metadata.Channels = int(binary.BigEndian.Uint16(data[0:2]))
+	frames := binary.BigEndian.Uint32(data[2:6])
+	metadata.Properties["BitsPerSample"] = fmt.Sprint(binary.BigEndian.Uint16(data[6:8]))
+	rate := extendedToFloat(data[8:18])
+	if rate > 0 && rate < math.MaxInt32 {
+		metadata.SampleRate = int(rate)
+		metadata.Duration = time.Duration(float64(frames) / rate * float64(time.Second))
+	}
+	if aifc && len(data) >= 22 {
+		metadata.Codec = strings.TrimSpace(string(data[18:22]))
+	} else {
+		metadata.Codec = "PCM"
+	}
+}
+
+// extendedToFloat decodes an IEEE 754 80-bit extended value, the form COMM stores the sample
+// rate in: a sign bit, a 15-bit exponent biased by 16383 and a 64-bit mantissa with an explicit
+// integer bit.
+func extendedToFloat(b []byte) float64 {
+	exp := int(binary.BigEndian.Uint16(b[0:2]) & 0x7FFF)
+	mantissa := binary.BigEndian.Uint64(b[2:10])
+	if exp == 0 && mantissa == 0 {
+		return 0
+	}
+	v := math.Ldexp(float64(mantissa), exp-16383-63)
+	if b[0]&0x80 != 0 {
+		v = -v
+	}
+	return v
+}
+
+// parseTextChunk maps one text chunk onto the report.
+func (e *AIFFExtractor) parseTextChunk(chunkID string, data []byte, annotations *int, metadata *AudioMetadata) {
+	switch chunkID {
+	case "ID3 ", "id3 ":
+		// An ID3v2 tag in a chunk of its own, as a WAV may also carry one.
+		if len(data) < 10 || string(data[0:3]) != "ID3" {
+			return
+		}
+		end := min(10+int(synchsafeToUint32(data[6:10])), len(data))
+		var mp3 MP3Extractor
+		_ = mp3.parseID3v2Frames(data[10:end], metadata, data[3])
+		return
+	case "COMT":
+		e.parseCommentsChunk(data, annotations, metadata)
+		return
+	}
+
+	value := strings.TrimSpace(strings.TrimRight(string(data), "\x00"))
+	if value == "" {
+		return
+	}
+	switch chunkID {
+	case "NAME":
+		metadata.Title = value
+	case "AUTH":
+		metadata.Artist = value
+	case "(c) ":
+		metadata.Copyright = value
+	case "ANNO":
+		addAIFFComment(value, annotations, metadata)
+	}
+}
+
+// parseCommentsChunk reads a COMT chunk: a count, then per comment a timestamp(4), a marker
+// ID(2), a length(2) and that many bytes of text, padded to an even length.
+func (e *AIFFExtractor) parseCommentsChunk(data []byte, annotations *int, metadata *AudioMetadata) {
+	if len(data) < 2 {
+		return
+	}
+	count := int(binary.BigEndian.Uint16(data[0:2]))
+	off := 2
+	for i := 0; i < count && off+8 <= len(data); i++ {
+		n := int(binary.BigEndian.Uint16(data[off+6 : off+8]))
+		off += 8
+		if off+n > len(data) {
+			n = len(data) - off
+		}
+		if value := strings.TrimSpace(strings.TrimRight(string(data[off:off+n]), "\x00")); value != "" {
+			addAIFFComment(value, annotations, metadata)
+		}
+		off += n + n%2
+	}
+}
+
+// addAIFFComment keeps the first annotation as the comment and numbers the rest, since a file
+// may carry any number of ANNO chunks and COMT entries.
+func addAIFFComment(value string, annotations *int, metadata *AudioMetadata) {
+	*annotations++
+	if metadata.Comment == "" {
+		metadata.Comment = value
+		return
+	}
+	metadata.Properties[fmt.Sprintf("Annotation %d", *annotations)] = value
+}
+
+// CanProcess checks if the file can be processed as AIFF
+func (e *AIFFExtractor) CanProcess(filePath string) bool {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".aiff", ".aif", ".aifc":
+		return true
+	}
+	return false
+}
+
+// GetSupportedFormats returns supported file formats
+func (e *AIFFExtractor) GetSupportedFormats() []string {
+	return []string{".aiff", ".aif", ".aifc"}
+}
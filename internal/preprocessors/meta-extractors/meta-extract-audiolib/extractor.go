@@ -91,6 +91,12 @@ func NewAudioExtractor() *AudioExtractor {
 			".flac": &FLACExtractor{},
 			".wav":  &WAVExtractor{},
 			".m4a":  &M4AExtractor{},
+			".ogg":  &OGGExtractor{},
+			".oga":  &OGGExtractor{},
+			".opus": &OGGExtractor{},
+			".aiff": &AIFFExtractor{},
+			".aif":  &AIFFExtractor{},
+			".aifc": &AIFFExtractor{},
 		},
 	}
 }
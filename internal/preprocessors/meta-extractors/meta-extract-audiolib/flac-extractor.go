@@ -235,6 +235,9 @@ func (e *FLACExtractor) parseVorbisComment(comment string, metadata *AudioMetada
 		metadata.Studio = value
 	case "CONTACT":
 		metadata.Properties["Contact"] = value
+	case "METADATA_BLOCK_PICTURE", "COVERART":
+		// Cover art stored as a base64 comment, as Ogg files carry it: image data, not text.
+		return
 	default:
 		// Store unknown fields in properties
 		metadata.Properties[field] = value
@@ -0,0 +1,130 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package audiolib
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/awslabs/ferret-scan/v2/internal/redactors/ogg"
+)
+
+// OGGExtractor handles Ogg Vorbis and Opus file metadata extraction
+type OGGExtractor struct{}
+
+// opusGranuleRate is the rate an Opus granule position counts at, whatever the input rate was.
+const opusGranuleRate = 48000
+
+// ExtractMetadata extracts metadata from an Ogg Vorbis or Opus file.
+//
+// The comment packet is read by the same page walk the audio redactor rewrites it with, so a
+// value reported here is a value the redactor can find: see internal/redactors/ogg.
+func (e *OGGExtractor) ExtractMetadata(filePath string) (*AudioMetadata, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open Ogg file: %w", err)
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file stats: %w", err)
+	}
+
+	metadata := &AudioMetadata{
+		Filename:   stat.Name(),
+		FileSize:   stat.Size(),
+		ModTime:    stat.ModTime(),
+		MimeType:   "audio/ogg",
+		Properties: make(map[string]string),
+	}
+
+	stream, err := ogg.Read(file, stat.Size())
+	switch {
+	case err == nil:
+	case stream != nil && errors.Is(err, ogg.ErrTruncated):
+		// The part of the comment header that is present is still worth reading, but the
+		// fields past the end of the file were not: say so.
+		metadata.ExtractionWarning = "audio metadata may be incomplete: the Ogg file ends " +
+			"inside its comment header, so the comments after that point were not read"
+	case stream != nil:
+		// A budget refusal: nothing was read that the redactor would also accept.
+		metadata.ExtractionWarning = "audio metadata may be incomplete: the Ogg comment " +
+			"header could not be read in full, so its comments were not read"
+		return metadata, nil
+	default:
+		return nil, fmt.Errorf("not a valid Ogg Vorbis or Opus file: %w", err)
+	}
+
+	metadata.Codec = string(stream.Codec)
+	if stream.Codec == ogg.Opus {
+		metadata.MimeType = "audio/opus"
+	}
+	preSkip := e.parseIdentification(stream, metadata)
+
+	if stream.LastGranule > 0 {
+		rate, granule := int64(metadata.SampleRate), stream.LastGranule
+		if stream.Codec == ogg.Opus {
+			rate, granule = opusGranuleRate, granule-preSkip
+		}
+		if rate > 0 && granule > 0 {
+			metadata.Duration = time.Duration(float64(granule) / float64(rate) * float64(time.Second))
+		}
+	}
+
+	// The comment packet holds the same structure FLAC stores in its VORBIS_COMMENT block,
+	// behind a codec-specific magic.
+	if n := stream.CommentMagicLen(); len(stream.Comment) > n {
+		var flac FLACExtractor
+		flac.parseVorbisComments(stream.Comment[n:], metadata)
+	}
+
+	return metadata, nil
+}
+
+// parseIdentification reads channels and sample rate from the identification header, and
+// returns an Opus stream's pre-skip: the granule position counts samples the decoder discards.
+func (e *OGGExtractor) parseIdentification(stream *ogg.Stream, metadata *AudioMetadata) int64 {
+	id := stream.Identification
+	switch stream.Codec {
+	case ogg.Vorbis:
+		// "\x01vorbis", version(4), channels(1), rate(4), bitrate max/nominal/min (4 each).
+		if len(id) < 24 {
+			return 0
+		}
+		metadata.Channels = int(id[11])
+		metadata.SampleRate = int(binary.LittleEndian.Uint32(id[12:16]))
+		if nominal := int32(binary.LittleEndian.Uint32(id[20:24])); nominal > 0 {
+			metadata.Bitrate = int(nominal)
+		}
+	case ogg.Opus:
+		// "OpusHead", version(1), channels(1), pre-skip(2), input rate(4).
+		if len(id) < 16 {
+			return 0
+		}
+		metadata.Channels = int(id[9])
+		metadata.SampleRate = int(binary.LittleEndian.Uint32(id[12:16]))
+		return int64(binary.LittleEndian.Uint16(id[10:12]))
+	}
+	return 0
+}
+
+// CanProcess checks if the file can be processed as Ogg
+func (e *OGGExtractor) CanProcess(filePath string) bool {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".ogg", ".oga", ".opus":
+		return true
+	}
+	return false
+}
+
+// GetSupportedFormats returns supported file formats
+func (e *OGGExtractor) GetSupportedFormats() []string {
+	return []string{".ogg", ".oga", ".opus"}
+}
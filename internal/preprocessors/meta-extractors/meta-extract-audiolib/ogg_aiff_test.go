@@ -0,0 +1,168 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package audiolib
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/awslabs/ferret-scan/v2/internal/redactors/ogg"
+)
+
+func writeAudioFixture(t *testing.T, name string, b []byte) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(p, b, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// oggTestPage builds one page holding a whole packet, with a valid checksum.
+func oggTestPage(headerType byte, granule int64, body []byte) []byte {
+	var lacing []byte
+	n := len(body)
+	for ; n >= 255; n -= 255 {
+		lacing = append(lacing, 255)
+	}
+	lacing = append(lacing, byte(n))
+	h := make([]byte, 27)
+	copy(h, "OggS")
+	h[5] = headerType
+	binary.LittleEndian.PutUint64(h[6:], uint64(granule))
+	binary.LittleEndian.PutUint32(h[14:], 1)
+	h[26] = byte(len(lacing))
+	page := append(append(h, lacing...), body...)
+	binary.LittleEndian.PutUint32(page[22:], ogg.Checksum(page))
+	return page
+}
+
+func vorbisComments(magic string, comments ...string) []byte {
+	out := binary.LittleEndian.AppendUint32([]byte(magic), 4)
+	out = append(out, "Lavf"...)
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(comments)))
+	for _, c := range comments {
+		out = binary.LittleEndian.AppendUint32(out, uint32(len(c)))
+		out = append(out, c...)
+	}
+	return out
+}
+
+func TestExtractOpusVoiceNoteComments(t *testing.T) {
+	head := []byte("OpusHead\x01\x01")
+	head = binary.LittleEndian.AppendUint16(head, 312)
+	head = binary.LittleEndian.AppendUint32(head, 16000)
+	head = append(head, 0, 0, 0)
+
+	file := oggTestPage(0x02, 0, head)
+	file = append(file, oggTestPage(0, 0, vorbisComments("OpusTags",
+		"TITLE=Voice note", "ARTIST=Jane Doe", "COMMENT=call me on 415-555-0142",
+		"METADATA_BLOCK_PICTURE=AAAAAwAAAAlpbWFnZS9wbmc="))...)
+	file = append(file, oggTestPage(0x04, 312+48000*3, make([]byte, 40))...)
+
+	meta, err := NewAudioExtractor().ExtractMetadata(writeAudioFixture(t, "note.opus", file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.MimeType != "audio/opus" || meta.Codec != "Opus" || meta.Channels != 1 || meta.SampleRate != 16000 {
+		t.Errorf("technical = %+v", meta)
+	}
+	if meta.Title != "Voice note" || meta.Artist != "Jane Doe" || meta.Comment != "call me on 415-555-0142" {
+		t.Errorf("tags = %q %q %q", meta.Title, meta.Artist, meta.Comment)
+	}
+	if meta.Duration != 3*time.Second {
+		t.Errorf("Duration = %v; the pre-skip must be subtracted at 48 kHz", meta.Duration)
+	}
+	if _, ok := meta.Properties["METADATA_BLOCK_PICTURE"]; ok {
+		t.Error("base64 cover art was reported as text")
+	}
+}
+
+func TestExtractOggVorbisTruncatedCommentIsDisclosed(t *testing.T) {
+	ident := append([]byte("\x01vorbis"), 0, 0, 0, 0, 2)
+	ident = binary.LittleEndian.AppendUint32(ident, 44100)
+	ident = append(ident, make([]byte, 30-len(ident))...)
+	comments := vorbisComments("\x03vorbis", "TITLE=Interview")
+
+	file := oggTestPage(0x02, 0, ident)
+	file = append(file, oggTestPage(0, 0, comments)...)
+	file = file[:len(file)-3]
+
+	meta, err := NewAudioExtractor().ExtractMetadata(writeAudioFixture(t, "cut.ogg", file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.ExtractionWarning == "" {
+		t.Error("a comment header cut short by the end of the file was not disclosed")
+	}
+	if meta.SampleRate != 44100 || meta.Channels != 2 {
+		t.Errorf("technical = %d Hz, %d channels", meta.SampleRate, meta.Channels)
+	}
+}
+
+func aiffChunk(id string, payload []byte) []byte {
+	out := append([]byte(id), binary.BigEndian.AppendUint32(nil, uint32(len(payload)))...)
+	out = append(out, payload...)
+	if len(payload)%2 == 1 {
+		out = append(out, 0)
+	}
+	return out
+}
+
+func TestExtractAIFFTextChunks(t *testing.T) {
+	comm := make([]byte, 18)
+	binary.BigEndian.PutUint16(comm[0:], 2)
+	binary.BigEndian.PutUint32(comm[2:], 88200)
+	binary.BigEndian.PutUint16(comm[6:], 16)
+	copy(comm[8:], []byte{0x40, 0x0E, 0xAC, 0x44}) // 44100
+
+	comt := binary.BigEndian.AppendUint16(nil, 2)
+	for _, c := range []string{"take 3", "mic owner Sam Lee"} {
+		comt = append(comt, 0, 0, 0, 0, 0, 0)
+		comt = binary.BigEndian.AppendUint16(comt, uint16(len(c)))
+		comt = append(comt, c...)
+		if len(c)%2 == 1 {
+			comt = append(comt, 0)
+		}
+	}
+
+	frame := append([]byte("TPUB"), binary.BigEndian.AppendUint32(nil, 13)...)
+	frame = append(frame, 0, 0, 0)
+	frame = append(frame, "Acme Studios"...)
+	id3 := append([]byte("ID3\x03\x00\x00"), 0, 0, 0, byte(len(frame)))
+	id3 = append(id3, frame...)
+
+	body := append([]byte("AIFF"), aiffChunk("COMM", comm)...)
+	body = append(body, aiffChunk("NAME", []byte("Session"))...)
+	body = append(body, aiffChunk("AUTH", []byte("Jane Doe"))...)
+	body = append(body, aiffChunk("(c) ", []byte("2024 Jane Doe"))...)
+	body = append(body, aiffChunk("COMT", comt)...)
+	body = append(body, aiffChunk("SSND", make([]byte, 16))...)
+	body = append(body, aiffChunk("ID3 ", id3)...)
+	file := append([]byte("FORM"), binary.BigEndian.AppendUint32(nil, uint32(len(body)))...)
+	file = append(file, body...)
+
+	meta, err := NewAudioExtractor().ExtractMetadata(writeAudioFixture(t, "session.aif", file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Title != "Session" || meta.Artist != "Jane Doe" || meta.Copyright != "2024 Jane Doe" {
+		t.Errorf("tags = %+v", meta)
+	}
+	if meta.Comment != "take 3" || meta.Properties["Annotation 2"] != "mic owner Sam Lee" {
+		t.Errorf("comments = %q, %v", meta.Comment, meta.Properties)
+	}
+	if meta.Publisher != "Acme Studios" {
+		t.Errorf("Publisher = %q; the ID3 chunk after the sound data was not read", meta.Publisher)
+	}
+	if meta.SampleRate != 44100 || meta.Duration != 2*time.Second || meta.Channels != 2 {
+		t.Errorf("technical = %d Hz, %v, %d channels", meta.SampleRate, meta.Duration, meta.Channels)
+	}
+	if meta.ExtractionWarning != "" {
+		t.Errorf("a well-formed file produced a warning: %s", meta.ExtractionWarning)
+	}
+}
@@ -0,0 +1,595 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package metaextractexiflib
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxEmbeddedPayload bounds one XMP packet, IPTC block, text chunk or comment.
+// A payload larger than this is skipped, not truncated: a cut XMP packet does
+// not parse, and a zTXt chunk is a zlib stream whose declared size means
+// nothing until it has been inflated.
+const maxEmbeddedPayload = 4 << 20
+
+// ExtractEmbedded reads the metadata an image carries outside EXIF into tags
+// and reports how many fields it found:
+//
+//   - XMP packets: the JPEG APP1 segment, the PNG iTXt "XML:com.adobe.xmp"
+//     chunk, the WebP "XMP " chunk and the GIF "XMP DataXMP" extension
+//   - IPTC IIM records, from the Photoshop resources of a JPEG APP13 segment
+//     and from ImageMagick's "Raw profile type" PNG chunks
+//   - PNG tEXt, zTXt and iTXt chunks, as PNG_<keyword>
+//   - JPEG COM segments and GIF comment extensions
+//
+// Keys are prefixed by where they came from: XMP_Creator, IPTC_Byline,
+// PNG_Author, GIF_Comment. Design tools and screenshot utilities write names
+// and e-mail addresses to exactly these places, and none of them is EXIF.
+//
+// The walk follows each container's own structure and seeks past pixel data,
+// so a large image costs a few reads, not its size in memory. A malformed
+// container ends the walk; what was read before it is kept.
+func ExtractEmbedded(r io.ReadSeeker, tags map[string]string) int {
+	before := len(tags)
+	head := make([]byte, 12)
+	n, _ := io.ReadFull(r, head)
+	head = head[:n]
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return 0
+	}
+	switch {
+	case bytes.HasPrefix(head, []byte{0xFF, 0xD8}):
+		walkJPEG(r, tags)
+	case bytes.HasPrefix(head, pngSignature):
+		walkPNG(r, tags)
+	case len(head) == 12 && string(head[:4]) == "RIFF" && string(head[8:12]) == "WEBP":
+		walkWebP(r, tags)
+	case bytes.HasPrefix(head, []byte("GIF87a")) || bytes.HasPrefix(head, []byte("GIF89a")):
+		walkGIF(r, tags)
+	}
+	return len(tags) - before
+}
+
+var (
+	pngSignature = []byte{0x89, 'P', 'N', 'G', 0x0D, 0x0A, 0x1A, 0x0A}
+	xmpJPEGID    = []byte("http://ns.adobe.com/xap/1.0/\x00")
+	photoshopID  = []byte("Photoshop 3.0\x00")
+)
+
+// readPayload reads n bytes, or seeks past them when n is over the payload
+// bound. The bool is false when the payload was skipped.
+func readPayload(r io.ReadSeeker, n int64) ([]byte, bool, error) {
+	if n > maxEmbeddedPayload {
+		_, err := r.Seek(n, io.SeekCurrent)
+		return nil, false, err
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, false, err
+	}
+	return buf, true, nil
+}
+
+// walkJPEG reads the marker segments up to the first scan.
+func walkJPEG(r io.ReadSeeker, tags map[string]string) {
+	if _, err := r.Seek(2, io.SeekStart); err != nil {
+		return
+	}
+	var marker [2]byte
+	for {
+		if _, err := io.ReadFull(r, marker[:1]); err != nil {
+			return
+		}
+		if marker[0] != 0xFF {
+			return
+		}
+		// Any number of 0xFF fill bytes may precede a marker.
+		for marker[0] == 0xFF {
+			if _, err := io.ReadFull(r, marker[:1]); err != nil {
+				return
+			}
+		}
+		code := marker[0]
+		if code == 0xD9 || code == 0xDA { // EOI, SOS: no metadata past here
+			return
+		}
+		if code == 0x01 || (code >= 0xD0 && code <= 0xD7) { // no length
+			continue
+		}
+		var size [2]byte
+		if _, err := io.ReadFull(r, size[:]); err != nil {
+			return
+		}
+		length := int64(binary.BigEndian.Uint16(size[:])) - 2
+		if length < 0 {
+			return
+		}
+		if code != 0xE1 && code != 0xED && code != 0xFE {
+			if _, err := r.Seek(length, io.SeekCurrent); err != nil {
+				return
+			}
+			continue
+		}
+		data, ok, err := readPayload(r, length)
+		if err != nil {
+			return
+		}
+		if !ok {
+			continue
+		}
+		switch code {
+		case 0xE1:
+			if bytes.HasPrefix(data, xmpJPEGID) {
+				parseXMP(data[len(xmpJPEGID):], tags)
+			}
+		case 0xED:
+			if bytes.HasPrefix(data, photoshopID) {
+				tags["Photoshop_Resources"] = "Present"
+				parsePhotoshopResources(data[len(photoshopID):], tags)
+			}
+		case 0xFE:
+			addTag(tags, "JFIF_Comment", decodeText(data))
+		}
+	}
+}
+
+// walkPNG reads the text chunks of a PNG, wherever they are: tools append
+// tEXt after the image data as often as before it.
+func walkPNG(r io.ReadSeeker, tags map[string]string) {
+	if _, err := r.Seek(int64(len(pngSignature)), io.SeekStart); err != nil {
+		return
+	}
+	var hdr [8]byte
+	for {
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			return
+		}
+		length := int64(binary.BigEndian.Uint32(hdr[:4]))
+		typ := string(hdr[4:8])
+		if typ == "IEND" {
+			return
+		}
+		if typ != "tEXt" && typ != "zTXt" && typ != "iTXt" {
+			if _, err := r.Seek(length+4, io.SeekCurrent); err != nil {
+				return
+			}
+			continue
+		}
+		data, ok, err := readPayload(r, length)
+		if err != nil {
+			return
+		}
+		if _, err := r.Seek(4, io.SeekCurrent); err != nil { // CRC
+			return
+		}
+		if !ok {
+			continue
+		}
+		keyword, text, err := pngText(typ, data)
+		if err != nil {
+			continue
+		}
+		switch {
+		case keyword == "XML:com.adobe.xmp":
+			parseXMP(text, tags)
+		case strings.HasPrefix(keyword, "Raw profile type "):
+			parseRawProfile(strings.TrimPrefix(keyword, "Raw profile type "), text, tags)
+		default:
+			addTag(tags, "PNG_"+fieldName(keyword), decodeText(text))
+		}
+	}
+}
+
+// pngText splits a text chunk into its keyword and its (inflated) text.
+func pngText(typ string, data []byte) (string, []byte, error) {
+	nul := bytes.IndexByte(data, 0)
+	if nul < 0 {
+		return "", nil, errors.New("text chunk without a keyword")
+	}
+	keyword, rest := string(data[:nul]), data[nul+1:]
+	switch typ {
+	case "tEXt":
+		return keyword, latin1(rest), nil
+	case "zTXt":
+		if len(rest) < 1 || rest[0] != 0 {
+			return "", nil, errors.New("unknown zTXt compression method")
+		}
+		text, err := inflate(rest[1:])
+		return keyword, latin1(text), err
+	default: // iTXt: flag, method, language\0, translated keyword\0, text
+		if len(rest) < 2 {
+			return "", nil, errors.New("short iTXt chunk")
+		}
+		compressed := rest[0] == 1
+		rest = rest[2:]
+		for i := 0; i < 2; i++ {
+			nul := bytes.IndexByte(rest, 0)
+			if nul < 0 {
+				return "", nil, errors.New("short iTXt chunk")
+			}
+			rest = rest[nul+1:]
+		}
+		if !compressed {
+			return keyword, rest, nil
+		}
+		text, err := inflate(rest)
+		return keyword, text, err
+	}
+}
+
+// inflate decompresses a zlib stream, refusing one that inflates past the
+// payload bound.
+func inflate(data []byte) ([]byte, error) {
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	out, err := io.ReadAll(io.LimitReader(zr, maxEmbeddedPayload+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(out) > maxEmbeddedPayload {
+		return nil, errors.New("text chunk inflates past the payload bound")
+	}
+	return out, nil
+}
+
+// parseRawProfile reads ImageMagick's hex-encoded profile chunks:
+// "\n<type>\n<length>\n<hex lines>".
+func parseRawProfile(kind string, text []byte, tags map[string]string) {
+	fields := strings.Fields(string(text))
+	if len(fields) < 3 {
+		return
+	}
+	raw, err := hex.DecodeString(strings.Join(fields[2:], ""))
+	if err != nil {
+		return
+	}
+	switch kind {
+	case "xmp":
+		parseXMP(raw, tags)
+	case "iptc":
+		if bytes.HasPrefix(raw, []byte("8BIM")) {
+			parsePhotoshopResources(raw, tags)
+		} else {
+			parseIPTC(raw, tags)
+		}
+	case "8bim":
+		parsePhotoshopResources(raw, tags)
+	}
+}
+
+// walkWebP reads the RIFF chunks of a WebP for its XMP packet.
+func walkWebP(r io.ReadSeeker, tags map[string]string) {
+	if _, err := r.Seek(12, io.SeekStart); err != nil {
+		return
+	}
+	var hdr [8]byte
+	for {
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			return
+		}
+		length := int64(binary.LittleEndian.Uint32(hdr[4:]))
+		padded := length + length&1
+		if string(hdr[:4]) != "XMP " {
+			if _, err := r.Seek(padded, io.SeekCurrent); err != nil {
+				return
+			}
+			continue
+		}
+		data, ok, err := readPayload(r, length)
+		if err != nil {
+			return
+		}
+		if _, err := r.Seek(padded-length, io.SeekCurrent); err != nil {
+			return
+		}
+		if ok {
+			parseXMP(data, tags)
+		}
+	}
+}
+
+// walkGIF reads the comment and application extensions of a GIF, skipping
+// image data a sub-block at a time.
+func walkGIF(r io.ReadSeeker, tags map[string]string) {
+	br := bufio.NewReader(r)
+	if _, err := br.Discard(6); err != nil {
+		return
+	}
+	var lsd [7]byte
+	if _, err := io.ReadFull(br, lsd[:]); err != nil {
+		return
+	}
+	if lsd[4]&0x80 != 0 {
+		if _, err := br.Discard(3 << (lsd[4]&0x07 + 1)); err != nil {
+			return
+		}
+	}
+	for {
+		block, err := br.ReadByte()
+		if err != nil {
+			return
+		}
+		switch block {
+		case 0x3B: // trailer
+			return
+		case 0x2C: // image descriptor
+			var desc [9]byte
+			if _, err := io.ReadFull(br, desc[:]); err != nil {
+				return
+			}
+			if desc[8]&0x80 != 0 {
+				if _, err := br.Discard(3 << (desc[8]&0x07 + 1)); err != nil {
+					return
+				}
+			}
+			if _, err := br.Discard(1); err != nil { // LZW minimum code size
+				return
+			}
+			if _, err := gifSubBlocks(br, false); err != nil {
+				return
+			}
+		case 0x21: // extension
+			label, err := br.ReadByte()
+			if err != nil {
+				return
+			}
+			switch label {
+			case 0xFE:
+				data, err := gifSubBlocks(br, true)
+				if err != nil {
+					return
+				}
+				addTag(tags, "GIF_Comment", decodeText(data))
+			case 0xFF:
+				id, err := gifSubBlock(br)
+				if err != nil {
+					return
+				}
+				if string(id) != "XMP DataXMP" {
+					if _, err := gifSubBlocks(br, false); err != nil {
+						return
+					}
+					continue
+				}
+				// The XMP packet is stored raw, its bytes doubling as sub-block
+				// lengths, with a "magic trailer" that walks any reader back to
+				// the terminator. Read it with the length bytes kept.
+				data, err := gifRawSubBlocks(br)
+				if err != nil {
+					return
+				}
+				parseXMP(data, tags)
+			default:
+				if _, err := gifSubBlocks(br, false); err != nil {
+					return
+				}
+			}
+		default:
+			return
+		}
+	}
+}
+
+// gifSubBlock reads one data sub-block.
+func gifSubBlock(br *bufio.Reader) ([]byte, error) {
+	n, err := br.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, n)
+	_, err = io.ReadFull(br, buf)
+	return buf, err
+}
+
+// gifSubBlocks reads sub-blocks up to the terminator, keeping their data when
+// keep is set and while it is within the payload bound.
+func gifSubBlocks(br *bufio.Reader, keep bool) ([]byte, error) {
+	var out []byte
+	for {
+		n, err := br.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			return out, nil
+		}
+		if !keep || len(out)+int(n) > maxEmbeddedPayload {
+			keep = false
+			out = nil
+			if _, err := br.Discard(int(n)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		start := len(out)
+		out = append(out, make([]byte, n)...)
+		if _, err := io.ReadFull(br, out[start:]); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// gifRawSubBlocks reads sub-blocks up to the terminator with their length
+// bytes, as the GIF embedding of XMP requires.
+func gifRawSubBlocks(br *bufio.Reader) ([]byte, error) {
+	var out []byte
+	for {
+		n, err := br.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			return out, nil
+		}
+		if len(out) > maxEmbeddedPayload {
+			if _, err := br.Discard(int(n)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		out = append(out, n)
+		start := len(out)
+		out = append(out, make([]byte, n)...)
+		if _, err := io.ReadFull(br, out[start:]); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parsePhotoshopResources reads the 8BIM image resource blocks of a Photoshop
+// APP13 segment; resource 0x0404 holds the IPTC IIM records.
+func parsePhotoshopResources(data []byte, tags map[string]string) {
+	for len(data) >= 12 && string(data[:4]) == "8BIM" {
+		id := binary.BigEndian.Uint16(data[4:6])
+		// A Pascal-string name, padded so name and length byte are even.
+		nameLen := int(data[6])
+		off := 6 + 1 + nameLen
+		if off%2 != 0 {
+			off++
+		}
+		if off+4 > len(data) {
+			return
+		}
+		size := int(binary.BigEndian.Uint32(data[off : off+4]))
+		off += 4
+		if size < 0 || off+size > len(data) {
+			return
+		}
+		tags["Photoshop_8BIM"] = "Present"
+		if id == 0x0404 {
+			parseIPTC(data[off:off+size], tags)
+		}
+		off += size + size&1
+		if off > len(data) {
+			return
+		}
+		data = data[off:]
+	}
+}
+
+// iptcFields names the IPTC IIM application record (record 2) datasets
+// that carry people, places and free text.
+var iptcFields = map[byte]string{
+	5:   "IPTC_ObjectName",
+	25:  "IPTC_Keywords",
+	40:  "IPTC_SpecialInstructions",
+	55:  "IPTC_DateCreated",
+	60:  "IPTC_TimeCreated",
+	80:  "IPTC_Byline",
+	85:  "IPTC_BylineTitle",
+	90:  "IPTC_City",
+	92:  "IPTC_Sublocation",
+	95:  "IPTC_ProvinceState",
+	101: "IPTC_Country",
+	105: "IPTC_Headline",
+	110: "IPTC_Credit",
+	115: "IPTC_Source",
+	116: "IPTC_CopyrightNotice",
+	118: "IPTC_Contact",
+	120: "IPTC_Caption",
+	122: "IPTC_CaptionWriter",
+}
+
+// parseIPTC reads IPTC IIM datasets: 0x1C, record, dataset, 16-bit length.
+// Extended-length datasets only hold binary objects and are stepped over.
+func parseIPTC(data []byte, tags map[string]string) {
+	for len(data) >= 5 && data[0] == 0x1C {
+		record, dataset := data[1], data[2]
+		length := int(binary.BigEndian.Uint16(data[3:5]))
+		data = data[5:]
+		if length&0x8000 != 0 {
+			n := length & 0x7FFF
+			if n > 4 || n > len(data) {
+				return
+			}
+			length = 0
+			for _, b := range data[:n] {
+				length = length<<8 | int(b)
+			}
+			data = data[n:]
+		}
+		if length > len(data) {
+			return
+		}
+		value := data[:length]
+		data = data[length:]
+		if record != 2 {
+			continue
+		}
+		if key, ok := iptcFields[dataset]; ok {
+			addTag(tags, key, decodeText(value))
+		}
+	}
+}
+
+// addTag records a value, joining distinct repeated values with "; ". Runs of
+// whitespace, line breaks included, become one space: a field is one line of
+// the metadata text.
+func addTag(tags map[string]string, key, value string) {
+	value = strings.Join(strings.Fields(value), " ")
+	if value == "" {
+		return
+	}
+	existing, ok := tags[key]
+	if !ok || existing == "" {
+		tags[key] = value
+		return
+	}
+	for _, v := range strings.Split(existing, "; ") {
+		if v == value {
+			return
+		}
+	}
+	tags[key] = existing + "; " + value
+}
+
+// decodeText reads text of unstated encoding: UTF-8 when it is valid UTF-8,
+// Latin-1 otherwise, which is what IIM without a charset declaration and
+// JPEG and GIF comments are in practice. NULs are dropped.
+func decodeText(b []byte) string {
+	b = bytes.TrimRight(b, "\x00")
+	if utf8.Valid(b) {
+		return strings.ReplaceAll(string(b), "\x00", "")
+	}
+	return strings.ReplaceAll(string(latin1(b)), "\x00", "")
+}
+
+// latin1 converts ISO 8859-1 bytes to UTF-8.
+func latin1(b []byte) []byte {
+	out := make([]byte, 0, len(b))
+	for _, c := range b {
+		out = utf8.AppendRune(out, rune(c))
+	}
+	return out
+}
+
+// fieldName turns a free-form keyword into a metadata field name: letters and
+// digits kept, anything else an underscore, so "Creation Time" reads
+// "Creation_Time" and the field's ": " separator stays unambiguous.
+func fieldName(keyword string) string {
+	var b strings.Builder
+	for _, r := range keyword {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	if b.Len() == 0 {
+		return "Text"
+	}
+	return b.String()
+}
@@ -0,0 +1,189 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package metaextractexiflib
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"hash/crc32"
+	"testing"
+)
+
+const packet = `<?xpacket begin="" id="W5M0MpCehiHzreSzNTczkc9d"?>
+<x:xmpmeta xmlns:x="adobe:ns:meta/" x:xmptk="Adobe XMP Core 9.1">
+ <rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+  <rdf:Description rdf:about=""
+    xmlns:dc="http://purl.org/dc/elements/1.1/"
+    xmlns:xmp="http://ns.adobe.com/xap/1.0/"
+    xmlns:xmpMM="http://ns.adobe.com/xap/1.0/mm/"
+    xmlns:stEvt="http://ns.adobe.com/xap/1.0/sType/ResourceEvent#"
+    xmlns:photoshop="http://ns.adobe.com/photoshop/1.0/"
+    xmlns:Iptc4xmpCore="http://iptc.org/std/Iptc4xmpCore/1.0/xmlns/"
+    xmp:CreatorTool="Figma" photoshop:City="Leeds"
+    xmpMM:DocumentID="xmp.did:0a1b2c">
+   <dc:creator><rdf:Seq><rdf:li>Ann Lee</rdf:li><rdf:li>Bo Chen</rdf:li></rdf:Seq></dc:creator>
+   <dc:rights><rdf:Alt><rdf:li xml:lang="x-default">© Acme Ltd</rdf:li></rdf:Alt></dc:rights>
+   <Iptc4xmpCore:Location>Head office,
+    floor 3</Iptc4xmpCore:Location>
+   <Iptc4xmpCore:CreatorContactInfo Iptc4xmpCore:CiEmailWork="ann@acme.test"/>
+   <xmpMM:History><rdf:Seq>
+    <rdf:li stEvt:action="saved" stEvt:softwareAgent="Adobe Photoshop 25.0" stEvt:instanceID="xmp.iid:1"/>
+    <rdf:li rdf:parseType="Resource"><stEvt:action>exported</stEvt:action><stEvt:softwareAgent>Figma</stEvt:softwareAgent></rdf:li>
+   </rdf:Seq></xmpMM:History>
+  </rdf:Description>
+ </rdf:RDF>
+</x:xmpmeta>
+<?xpacket end="w"?>`
+
+// wantXMP is what packet reads as.
+var wantXMP = map[string]string{
+	"XMP_Creator":                        "Ann Lee; Bo Chen",
+	"XMP_CreatorTool":                    "Figma",
+	"XMP_City":                           "Leeds",
+	"XMP_Rights":                         "© Acme Ltd",
+	"XMP_Location":                       "Head office, floor 3",
+	"XMP_CreatorContactInfo_CiEmailWork": "ann@acme.test",
+	"XMP_History_Action":                 "saved; exported",
+	"XMP_History_SoftwareAgent":          "Adobe Photoshop 25.0; Figma",
+}
+
+func extract(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	tags := map[string]string{}
+	if n := ExtractEmbedded(bytes.NewReader(data), tags); n != len(tags) {
+		t.Errorf("ExtractEmbedded reported %d fields, found %d", n, len(tags))
+	}
+	return tags
+}
+
+func expect(t *testing.T, tags, want map[string]string) {
+	t.Helper()
+	for k, v := range want {
+		if tags[k] != v {
+			t.Errorf("%s = %q, want %q", k, tags[k], v)
+		}
+	}
+}
+
+func TestParseXMP(t *testing.T) {
+	tags := map[string]string{}
+	parseXMP([]byte(packet), tags)
+	expect(t, tags, wantXMP)
+	if len(tags) != len(wantXMP) {
+		t.Errorf("tags = %v", tags)
+	}
+}
+
+func jpegSegment(marker byte, data []byte) []byte {
+	return append([]byte{0xFF, marker, byte((len(data) + 2) >> 8), byte(len(data) + 2)}, data...)
+}
+
+func TestExtractJPEG(t *testing.T) {
+	iim := func(dataset byte, value string) []byte {
+		return append([]byte{0x1C, 2, dataset, 0, byte(len(value))}, value...)
+	}
+	var records []byte
+	records = append(records, 0x1C, 1, 90, 0, 3, 0x1B, '%', 'G') // UTF-8
+	records = append(records, iim(80, "Ann Lee")...)
+	records = append(records, iim(118, "ann@acme.test")...)
+	records = append(records, iim(120, "Team offsite")...)
+	records = append(records, iim(25, "offsite")...)
+	records = append(records, iim(25, "team")...)
+	resource := append([]byte("8BIM\x04\x04\x00\x00"), binary.BigEndian.AppendUint32(nil, uint32(len(records)))...)
+	resource = append(resource, records...)
+
+	data := []byte{0xFF, 0xD8}
+	data = append(data, jpegSegment(0xE0, []byte("JFIF\x00\x01\x01\x00\x00\x01\x00\x01\x00\x00"))...)
+	data = append(data, jpegSegment(0xE1, append([]byte("http://ns.adobe.com/xap/1.0/\x00"), packet...))...)
+	data = append(data, jpegSegment(0xED, append([]byte("Photoshop 3.0\x00"), resource...))...)
+	data = append(data, jpegSegment(0xFE, []byte("Screenshot by ann\xe9"))...) // Latin-1
+	data = append(data, 0xFF, 0xDA, 0, 2, 0xFF, 0xFE, 0, 6, 'l', 'a', 't', 'e', 0xFF, 0xD9)
+
+	tags := extract(t, data)
+	expect(t, tags, wantXMP)
+	expect(t, tags, map[string]string{
+		"IPTC_Byline":   "Ann Lee",
+		"IPTC_Contact":  "ann@acme.test",
+		"IPTC_Caption":  "Team offsite",
+		"IPTC_Keywords": "offsite; team",
+		"JFIF_Comment":  "Screenshot by anné",
+	})
+}
+
+func pngChunk(typ string, data []byte) []byte {
+	out := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	out = append(append(out, typ...), data...)
+	return binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(append([]byte(typ), data...)))
+}
+
+func TestExtractPNG(t *testing.T) {
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	zw.Write([]byte("Taken on Ann's laptop"))
+	zw.Close()
+
+	data := []byte{0x89, 'P', 'N', 'G', 0x0D, 0x0A, 0x1A, 0x0A}
+	data = append(data, pngChunk("IHDR", make([]byte, 13))...)
+	data = append(data, pngChunk("iTXt", append([]byte("XML:com.adobe.xmp\x00\x00\x00\x00\x00"), packet...))...)
+	data = append(data, pngChunk("IDAT", []byte{1, 2, 3})...)
+	data = append(data, pngChunk("tEXt", []byte("Author\x00ann@acme.test"))...)
+	data = append(data, pngChunk("zTXt", append([]byte("Comment\x00\x00"), z.Bytes()...))...)
+	data = append(data, pngChunk("iTXt", []byte("Creation Time\x00\x00\x00en\x00\x002024-05-01"))...)
+	data = append(data, pngChunk("IEND", nil)...)
+
+	tags := extract(t, data)
+	expect(t, tags, wantXMP)
+	expect(t, tags, map[string]string{
+		"PNG_Author":        "ann@acme.test",
+		"PNG_Comment":       "Taken on Ann's laptop",
+		"PNG_Creation_Time": "2024-05-01",
+	})
+}
+
+func TestExtractGIF(t *testing.T) {
+	data := append([]byte("GIF89a"), 1, 0, 1, 0, 0x80, 0, 0) // 2-entry global table
+	data = append(data, 0, 0, 0, 255, 255, 255)
+	data = append(data, 0x21, 0xFE, 9, 'b', 'y', ' ', 'a', 'n', 'n', '@', 'x', 'y', 0)
+	data = append(data, 0x21, 0xFF, 11)
+	data = append(data, "XMP DataXMP"...)
+	data = append(data, packet...)
+	for i := 0xFF; i >= 0; i-- {
+		data = append(data, byte(i))
+	}
+	data = append(data, 0)
+	data = append(data, 0x2C, 0, 0, 0, 0, 1, 0, 1, 0, 0, 2, 2, 0x4C, 0x01, 0, 0x3B)
+
+	tags := extract(t, data)
+	expect(t, tags, wantXMP)
+	expect(t, tags, map[string]string{"GIF_Comment": "by ann@xy"})
+}
+
+func TestExtractWebP(t *testing.T) {
+	chunk := func(fourCC string, data []byte) []byte {
+		out := append([]byte(fourCC), binary.LittleEndian.AppendUint32(nil, uint32(len(data)))...)
+		out = append(out, data...)
+		if len(data)%2 == 1 {
+			out = append(out, 0)
+		}
+		return out
+	}
+	body := append(chunk("VP8X", make([]byte, 10)), chunk("VP8L", []byte{0x2F, 0, 0})...)
+	body = append(body, chunk("XMP ", []byte(packet))...)
+	data := append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(4+len(body)))...)
+	data = append(append(data, "WEBP"...), body...)
+
+	expect(t, extract(t, data), wantXMP)
+}
+
+// A container cut short keeps what was read before the cut.
+func TestExtractTruncated(t *testing.T) {
+	data := []byte{0x89, 'P', 'N', 'G', 0x0D, 0x0A, 0x1A, 0x0A}
+	data = append(data, pngChunk("tEXt", []byte("Author\x00Ann Lee"))...)
+	data = append(data, pngChunk("tEXt", []byte("Comment\x00never read"))[:12]...)
+	tags := extract(t, data)
+	if tags["PNG_Author"] != "Ann Lee" || len(tags) != 1 {
+		t.Errorf("tags = %v", tags)
+	}
+}
@@ -4,12 +4,11 @@
 package metaextractexiflib
 
 import (
-	"bytes"
 	"fmt"
+	"io"
+	"math"
 	"os"
-	"regexp"
 	"sort"
-	"strings"
 
 	"github.com/rwcarlsen/goexif/exif"
 	"github.com/rwcarlsen/goexif/tiff"
@@ -43,31 +42,35 @@ func ExtractExif(filePath string) (*ExifData, error) {
 	}
 	defer f.Close()
 
-	// Decode EXIF data
-	x, err := exif.Decode(f)
-	if err != nil {
-		return nil, fmt.Errorf("no EXIF data found: %v", err)
-	}
-
 	// Create result structure
 	result := &ExifData{
 		FilePath: filePath,
 		Tags:     make(map[string]string),
 	}
 
-	// Create a custom walker to extract all available tags
-	walker := &exifWalker{tags: result.Tags}
-	x.Walk(walker)
-
-	// Extract IPTC, XMP, and other metadata from raw file data
-	f.Seek(0, 0)
-	rawData := make([]byte, 1024*1024) // Read first 1MB
-	n, _ := f.Read(rawData)
-	if n > 0 {
-		extractIPTC(rawData[:n], result.Tags)
-		extractXMP(rawData[:n], result.Tags)
-		extractJFIFComment(rawData[:n], result.Tags)
-		extractPhotoshopResources(rawData[:n], result.Tags)
+	// Decode EXIF data. A HEIF or AVIF image keeps it, and its XMP, in items
+	// located through the container's item tables rather than at a fixed place.
+	var x *exif.Exif
+	var exifErr error
+	if isHEIF(f) {
+		x, exifErr = decodeHEIF(f, result.Tags)
+	} else {
+		x, exifErr = exif.Decode(f)
+	}
+
+	if exifErr == nil {
+		// Create a custom walker to extract all available tags
+		walker := &exifWalker{tags: result.Tags}
+		x.Walk(walker)
+	}
+
+	// Extract XMP, IPTC, text chunks and comments, which PNG, GIF and WebP
+	// files carry without any EXIF at all
+	if _, err := f.Seek(0, io.SeekStart); err == nil {
+		ExtractEmbedded(f, result.Tags)
+	}
+	if exifErr != nil && len(result.Tags) == 0 {
+		return nil, fmt.Errorf("no EXIF data found: %v", exifErr)
 	}
 
 	// Extract file system metadata
@@ -76,9 +79,19 @@ func ExtractExif(filePath string) (*ExifData, error) {
 		result.Tags["FileModTime"] = stat.ModTime().Format("2006:01:02 15:04:05")
 	}
 
+	if exifErr != nil {
+		return result, nil
+	}
+
 	// Calculate GPS coordinates
 	lat, long, err := x.LatLong()
-	if err == nil {
+	if err == nil && (math.IsNaN(lat) || math.IsNaN(long)) {
+		// A 0/0 rational is no number at all: what a redacted position
+		// decodes to, and never a place
+		for _, name := range []exif.FieldName{exif.GPSLatitude, exif.GPSLongitude, exif.GPSLatitudeRef, exif.GPSLongitudeRef} {
+			delete(result.Tags, string(name))
+		}
+	} else if err == nil {
 		result.Tags["GPSLatitudeDecimal"] = fmt.Sprintf("%.6f", lat)
 		result.Tags["GPSLongitudeDecimal"] = fmt.Sprintf("%.6f", long)
 
@@ -103,10 +116,13 @@ func ExtractExif(filePath string) (*ExifData, error) {
 	}
 
 	// Format GPS altitude
-	if alt, err := x.Get(exif.GPSAltitude); err == nil {
-		altVal, _ := alt.Rat(0)
-		num := altVal.Num().Int64()
-		denom := altVal.Denom().Int64()
+	if alt, err := x.Get(exif.GPSAltitude); err == nil && alt.Count > 0 {
+		num, denom, err := alt.Rat2(0)
+		if err != nil || denom == 0 {
+			// Rat panics on a zero denominator, and 0/0 is no altitude
+			delete(result.Tags, string(exif.GPSAltitude))
+			return result, nil
+		}
 		altitude := float64(num) / float64(denom)
 
 		// Check if altitude is below sea level
@@ -138,102 +154,3 @@ func (e *ExifData) GetSortedKeys() []string {
 	sort.Strings(sortedKeys)
 	return sortedKeys
 }
-
-// extractIPTC extracts IPTC metadata from raw image data
-func extractIPTC(data []byte, tags map[string]string) {
-	// Look for IPTC data marker
-	iptcMarker := []byte{0x1C, 0x02}
-	for i := 0; i < len(data)-10; i++ {
-		if bytes.HasPrefix(data[i:], iptcMarker) {
-			// Found IPTC record, extract basic fields
-			recordType := data[i+2]
-			if i+4 < len(data) {
-				length := int(data[i+3])<<8 | int(data[i+4])
-				if i+5+length <= len(data) {
-					value := string(data[i+5 : i+5+length])
-					// Skip corrupted or non-printable data
-					if !isPrintableString(value) {
-						continue
-					}
-					switch recordType {
-					case 0x50: // By-line (Author)
-						tags["IPTC_Byline"] = value
-					case 0x37: // Date Created
-						tags["IPTC_DateCreated"] = value
-					case 0x3C: // Time Created
-						tags["IPTC_TimeCreated"] = value
-					case 0x78: // Caption
-						tags["IPTC_Caption"] = value
-					}
-				}
-			}
-		}
-	}
-}
-
-// extractXMP extracts XMP metadata from raw image data
-func extractXMP(data []byte, tags map[string]string) {
-	// Look for XMP packet
-	xmpStart := bytes.Index(data, []byte("<?xpacket"))
-	if xmpStart == -1 {
-		return
-	}
-	xmpEnd := bytes.Index(data[xmpStart:], []byte("<?xpacket end"))
-	if xmpEnd == -1 {
-		return
-	}
-	xmpData := string(data[xmpStart : xmpStart+xmpEnd])
-
-	// Extract common XMP fields using regex
-	extractXMPField(xmpData, `dc:creator[^>]*>([^<]+)`, "XMP_Creator", tags)
-	extractXMPField(xmpData, `xmp:CreatorTool[^>]*>([^<]+)`, "XMP_CreatorTool", tags)
-	extractXMPField(xmpData, `photoshop:DateCreated[^>]*>([^<]+)`, "XMP_DateCreated", tags)
-}
-
-// extractXMPField extracts a specific XMP field using regex
-func extractXMPField(xmpData, pattern, fieldName string, tags map[string]string) {
-	re := regexp.MustCompile(pattern)
-	if match := re.FindStringSubmatch(xmpData); len(match) > 1 {
-		tags[fieldName] = strings.TrimSpace(match[1])
-	}
-}
-
-// extractJFIFComment extracts JFIF comment segments
-func extractJFIFComment(data []byte, tags map[string]string) {
-	// Look for JPEG comment marker (0xFFFE)
-	for i := 0; i < len(data)-4; i++ {
-		if data[i] == 0xFF && data[i+1] == 0xFE {
-			length := int(data[i+2])<<8 | int(data[i+3])
-			if i+4+length <= len(data) && length > 0 {
-				comment := string(data[i+4 : i+4+length])
-				if strings.TrimSpace(comment) != "" {
-					tags["JFIF_Comment"] = comment
-					break
-				}
-			}
-		}
-	}
-}
-
-// extractPhotoshopResources extracts Photoshop resource blocks
-func extractPhotoshopResources(data []byte, tags map[string]string) {
-	// Look for Photoshop 3.0 signature
-	psMarker := []byte("Photoshop 3.0")
-	if idx := bytes.Index(data, psMarker); idx != -1 {
-		tags["Photoshop_Resources"] = "Present"
-		// Look for common resource blocks like layer names, etc.
-		if layerIdx := bytes.Index(data[idx:], []byte("8BIM")); layerIdx != -1 {
-			tags["Photoshop_8BIM"] = "Present"
-		}
-	}
-}
-
-// isPrintableString checks if a string contains only printable characters
-func isPrintableString(s string) bool {
-	for _, r := range s {
-		if r < 32 || r > 126 {
-			return false
-		}
-	}
-	return len(s) > 0
-}
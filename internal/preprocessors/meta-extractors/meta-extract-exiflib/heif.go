@@ -0,0 +1,68 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package metaextractexiflib
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/awslabs/ferret-scan/v2/internal/redactors/isobmff"
+	"github.com/rwcarlsen/goexif/exif"
+)
+
+// heifHeadBytes is how much of a file is read to find its ftyp brands.
+const heifHeadBytes = 64
+
+// errNoHEIFExif reports a HEIF or AVIF image without an Exif item.
+var errNoHEIFExif = errors.New("no Exif item in HEIF image")
+
+// isHEIF reports whether f is a HEIF or AVIF image, leaving it positioned at the start.
+func isHEIF(f io.ReadSeeker) bool {
+	head := make([]byte, heifHeadBytes)
+	n, _ := io.ReadFull(f, head)
+	_, _ = f.Seek(0, io.SeekStart)
+	return isobmff.IsHEIF(head[:n])
+}
+
+// decodeHEIF reads the Exif and XMP items of a HEIF or AVIF image.
+//
+// The items are located from the meta box's item tables by the same isobmff code the HEIF
+// redactor overwrites them with, so a value reported here is in a range the redactor knows.
+// XMP items are parsed into tags directly; the Exif item is decoded as TIFF and returned for the
+// caller to walk like any other EXIF block.
+func decodeHEIF(f *os.File, tags map[string]string) (*exif.Exif, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	items, err := isobmff.MetadataItems(f, info.Size())
+	if err != nil {
+		return nil, err
+	}
+
+	var x *exif.Exif
+	exifErr := errNoHEIFExif
+	for _, it := range items {
+		data, err := isobmff.ReadItem(f, it, maxEmbeddedPayload)
+		if err != nil {
+			continue
+		}
+		switch it.Kind {
+		case isobmff.ItemXMP:
+			parseXMP(data, tags)
+		case isobmff.ItemExif:
+			if x != nil {
+				continue
+			}
+			tiffData, _, ok := isobmff.ExifTIFF(data)
+			if !ok {
+				continue
+			}
+			x, exifErr = exif.Decode(bytes.NewReader(tiffData))
+		}
+	}
+	return x, exifErr
+}
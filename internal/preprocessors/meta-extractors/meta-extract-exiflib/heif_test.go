@@ -0,0 +1,109 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package metaextractexiflib
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func box(kind string, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	out := binary.BigEndian.AppendUint32(nil, uint32(8+len(body)))
+	return append(append(out, kind...), body...)
+}
+
+// heifExifItem is an iPhone-style Exif item: a little-endian TIFF with Make in IFD0 and a
+// position in the GPS IFD, behind the 4-byte offset and "Exif\0\0".
+func heifExifItem() []byte {
+	le := binary.LittleEndian
+	entry := func(tag, typ, count, value int) []byte {
+		e := le.AppendUint16(nil, uint16(tag))
+		e = le.AppendUint16(e, uint16(typ))
+		e = le.AppendUint32(e, uint32(count))
+		return le.AppendUint32(e, uint32(value))
+	}
+	tiff := []byte("II*\x00\x08\x00\x00\x00")
+	tiff = append(tiff, 2, 0)
+	tiff = append(tiff, entry(0x010F, 2, 6, 140)...) // Make
+	tiff = append(tiff, entry(0x8825, 4, 1, 38)...)  // GPSInfo
+	tiff = append(tiff, 0, 0, 0, 0)
+	tiff = append(tiff, 4, 0) // GPS IFD at 38, ends at 92
+	tiff = append(tiff, entry(1, 2, 2, 'N')...)
+	tiff = append(tiff, entry(2, 5, 3, 92)...)
+	tiff = append(tiff, entry(3, 2, 2, 'W')...)
+	tiff = append(tiff, entry(4, 5, 3, 116)...)
+	tiff = append(tiff, 0, 0, 0, 0)
+	for _, v := range []int{37, 1, 46, 1, 2964, 100, 122, 1, 25, 1, 984, 100} {
+		tiff = le.AppendUint32(tiff, uint32(v))
+	}
+	tiff = append(tiff, "Apple\x00"...) // at 140
+	return append([]byte("\x00\x00\x00\x06Exif\x00\x00"), tiff...)
+}
+
+func writeHEIC(t *testing.T, exifItem, xmp []byte) string {
+	t.Helper()
+	u16 := func(v int) []byte { return binary.BigEndian.AppendUint16(nil, uint16(v)) }
+	u32 := func(v int) []byte { return binary.BigEndian.AppendUint32(nil, uint32(v)) }
+	infe := func(id int, itemType, contentType string) []byte {
+		body := bytes.Join([][]byte{{2, 0, 0, 0}, u16(id), u16(0), []byte(itemType), {0}}, nil)
+		if contentType != "" {
+			body = append(append(body, contentType...), 0)
+		}
+		return box("infe", body)
+	}
+	ftyp := box("ftyp", []byte("heic"), u32(0), []byte("mif1heic"))
+	build := func(at int) []byte {
+		loc := func(id, off, n int) []byte {
+			return bytes.Join([][]byte{u16(id), u16(0), u16(1), u32(off), u32(n)}, nil)
+		}
+		return box("meta", []byte{0, 0, 0, 0},
+			box("iinf", []byte{0, 0, 0, 0}, u16(2), infe(1, "Exif", ""), infe(2, "mime", "application/rdf+xml")),
+			box("iloc", []byte{0, 0, 0, 0, 0x44, 0x00}, u16(2), loc(1, at, len(exifItem)), loc(2, at+len(exifItem), len(xmp))))
+	}
+	meta := build(0)
+	meta = build(len(ftyp) + len(meta) + 8)
+	p := filepath.Join(t.TempDir(), "IMG_0001.heic")
+	if err := os.WriteFile(p, bytes.Join([][]byte{ftyp, meta, box("mdat", exifItem, xmp)}, nil), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestExtractHEIF(t *testing.T) {
+	data, err := ExtractExif(writeHEIC(t, heifExifItem(), []byte(packet)))
+	if err != nil {
+		t.Fatalf("ExtractExif: %v", err)
+	}
+	expect(t, data.Tags, map[string]string{
+		"Make":                `"Apple"`,
+		"GPSLatitudeDecimal":  "37.774900",
+		"GPSLongitudeDecimal": "-122.419400",
+		"XMP_Creator":         "Ann Lee; Bo Chen",
+		"XMP_CreatorTool":     "Figma",
+	})
+}
+
+// A position whose rationals were zeroed — what the HEIF redactor leaves — is no position.
+func TestExtractHEIFZeroedPositionIsNotReported(t *testing.T) {
+	item := heifExifItem()
+	for i := 10 + 92; i < 10+140; i++ { // the two positions
+		item[i] = 0
+	}
+	data, err := ExtractExif(writeHEIC(t, item, []byte("<x:xmpmeta/>")))
+	if err != nil {
+		t.Fatalf("ExtractExif: %v", err)
+	}
+	for _, k := range []string{"GPSLatitudeDecimal", "GPSLongitudeDecimal", "GPSLatitudeRaw", "GPSLatitude", "GPSLatitudeRef"} {
+		if v, ok := data.Tags[k]; ok {
+			t.Errorf("%s = %q after the position was zeroed", k, v)
+		}
+	}
+	if data.Tags["Make"] != `"Apple"` {
+		t.Errorf("Make = %q", data.Tags["Make"])
+	}
+}
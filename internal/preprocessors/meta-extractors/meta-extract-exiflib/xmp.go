@@ -0,0 +1,131 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package metaextractexiflib
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	rdfNS = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+	xmlNS = "http://www.w3.org/XML/1998/namespace"
+)
+
+// xmpSkipped are XMP properties that identify a file rather than describe it,
+// or hold binary: document and instance GUIDs, ancestor lists that run to
+// thousands of entries, and base64 thumbnails.
+var xmpSkipped = map[string]bool{
+	"DocumentID":         true,
+	"InstanceID":         true,
+	"OriginalDocumentID": true,
+	"DocumentAncestors":  true,
+	"History_InstanceID": true,
+	"DerivedFrom":        true,
+	"Thumbnails":         true,
+	"Manifest":           true,
+	"Ingredients":        true,
+	"Pantry":             true,
+}
+
+// xmpFrame is one open element of an XMP packet.
+type xmpFrame struct {
+	path     string // property path, "" outside any property
+	property bool   // a property or array item, whose text is a value
+	children bool
+	text     strings.Builder
+}
+
+// parseXMP reads the properties of an XMP packet into XMP_<name> tags.
+//
+// Every property is read, in either RDF form: element content
+// (<dc:rights>...</dc:rights>) and attribute shorthand
+// (<rdf:Description xmp:CreatorTool="...">). Array items (rdf:Seq, rdf:Bag,
+// rdf:Alt) are joined with "; ". Struct fields are named after their property:
+// Iptc4xmpCore:CreatorContactInfo/CiEmailWork is XMP_CreatorContactInfo_CiEmailWork,
+// and every xmpMM:History event's softwareAgent is XMP_History_SoftwareAgent.
+// Namespaces are dropped from the name; the same local name in two namespaces
+// shares a tag.
+func parseXMP(data []byte, tags map[string]string) {
+	if start := bytes.Index(data, []byte("<x:xmpmeta")); start >= 0 {
+		data = data[start:]
+	} else if start := bytes.Index(data, []byte("<rdf:RDF")); start >= 0 {
+		data = data[start:]
+	}
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+	stack := []*xmpFrame{{}}
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return
+		}
+		top := stack[len(stack)-1]
+		switch t := tok.(type) {
+		case xml.StartElement:
+			top.children = true
+			frame := &xmpFrame{path: top.path}
+			switch {
+			case t.Name.Space == rdfNS && t.Name.Local == "li":
+				frame.property = true
+			case t.Name.Space == rdfNS, t.Name.Local == "xmpmeta", t.Name.Local == "xapmeta":
+				// rdf:RDF, rdf:Description, the array containers: structure only.
+			default:
+				frame.path = joinXMPPath(top.path, t.Name.Local)
+				frame.property = true
+			}
+			// Non-RDF attributes of a description, property or array item are
+			// properties (or struct fields) in shorthand.
+			if t.Name.Local != "xmpmeta" && t.Name.Local != "xapmeta" {
+				for _, a := range t.Attr {
+					if a.Name.Space == rdfNS || a.Name.Space == xmlNS || a.Name.Space == "xmlns" || a.Name.Local == "xmlns" || a.Name.Space == "" {
+						continue
+					}
+					emitXMP(tags, joinXMPPath(frame.path, a.Name.Local), a.Value)
+				}
+			}
+			stack = append(stack, frame)
+		case xml.CharData:
+			if top.property && top.text.Len() < maxEmbeddedPayload {
+				top.text.Write(t)
+			}
+		case xml.EndElement:
+			if len(stack) == 1 {
+				return
+			}
+			stack = stack[:len(stack)-1]
+			if top.property && !top.children {
+				emitXMP(tags, top.path, top.text.String())
+			}
+		}
+	}
+}
+
+// joinXMPPath names a property inside a struct: "CreatorContactInfo" and
+// "CiEmailWork" make "CreatorContactInfo_CiEmailWork".
+func joinXMPPath(parent, local string) string {
+	local = upperFirst(local)
+	if parent == "" {
+		return local
+	}
+	return parent + "_" + local
+}
+
+func emitXMP(tags map[string]string, path, value string) {
+	if path == "" || xmpSkipped[path] || xmpSkipped[strings.SplitN(path, "_", 2)[0]] {
+		return
+	}
+	addTag(tags, "XMP_"+path, value)
+}
+
+func upperFirst(s string) string {
+	r, n := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError {
+		return s
+	}
+	return string(unicode.ToUpper(r)) + s[n:]
+}
@@ -17,6 +17,7 @@ import (
 	"time"
 
 	"github.com/awslabs/ferret-scan/v2/internal/embedded"
+	"github.com/awslabs/ferret-scan/v2/internal/encryption"
 )
 
 // Security constants
@@ -296,14 +297,30 @@ func ExtractMetadata(filePath string) (*Metadata, error) {
 	}
 }
 
+// openArchive opens the document's zip container, from the plaintext the router
+// holds when it decrypted the document (the file on disk is then the encrypted
+// OLE wrapper). decrypted tells the caller that nothing read from the archive may
+// be written to disk.
+func openArchive(filePath string) (reader *zip.Reader, closeArchive func() error, decrypted bool, err error) {
+	if data, ok := encryption.Plaintext(filePath); ok {
+		reader, err = zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		return reader, func() error { return nil }, true, err
+	}
+	rc, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	return &rc.Reader, rc.Close, false, nil
+}
+
 // extractOfficeOpenXMLMetadata extracts metadata from Office Open XML documents
 func extractOfficeOpenXMLMetadata(filePath string, metadata *Metadata) (*Metadata, error) {
 	// Open the file as a ZIP archive
-	reader, err := zip.OpenReader(filePath)
+	reader, closeArchive, decrypted, err := openArchive(filePath)
 	if err != nil {
 		return metadata, newSanitizedError("error opening file as ZIP", err)
 	}
-	defer reader.Close()
+	defer closeArchive()
 
 	// Create file index for efficient lookup
 	fileIndex := createFileIndex(reader)
@@ -419,7 +436,7 @@ func extractOfficeOpenXMLMetadata(filePath string, metadata *Metadata) (*Metadat
 	}
 
 	// Extract embedded images
-	if err := extractEmbeddedImages(reader, metadata); err != nil {
+	if err := extractEmbeddedImages(reader, decrypted, metadata); err != nil {
 		// Log error but don't fail the entire extraction
 		metadata.Properties["ImageExtractionError"] = err.Error()
 	}
@@ -493,7 +510,7 @@ type CustomProperties struct {
 // word/document.xml (see text-extract-officetextlib/ooxml_parts.go). Lookups
 // therefore go through lookupPart. First entry wins, so a package carrying two
 // spellings of the same part resolves deterministically to the earlier one.
-func createFileIndex(reader *zip.ReadCloser) map[string]*zip.File {
+func createFileIndex(reader *zip.Reader) map[string]*zip.File {
 	// Pre-allocate map with capacity to reduce rehashing
 	fileIndex := make(map[string]*zip.File, len(reader.File))
 	for _, file := range reader.File {
@@ -671,13 +688,13 @@ func extractCustomPropertyValue(innerXML string) string {
 }
 
 // extractWordMetadata extracts Word-specific metadata
-func extractWordMetadata(_ *zip.ReadCloser, metadata *Metadata) {
+func extractWordMetadata(_ *zip.Reader, metadata *Metadata) {
 	// Add Word-specific metadata extraction here if needed
 	metadata.Properties["DocumentType"] = "Word Document"
 }
 
 // extractExcelMetadata extracts Excel-specific metadata
-func extractExcelMetadata(reader *zip.ReadCloser, metadata *Metadata) {
+func extractExcelMetadata(reader *zip.Reader, metadata *Metadata) {
 	metadata.Properties["DocumentType"] = "Excel Spreadsheet"
 
 	// Count worksheets
@@ -691,7 +708,7 @@ func extractExcelMetadata(reader *zip.ReadCloser, metadata *Metadata) {
 }
 
 // extractPowerPointMetadata extracts PowerPoint-specific metadata
-func extractPowerPointMetadata(reader *zip.ReadCloser, metadata *Metadata) {
+func extractPowerPointMetadata(reader *zip.Reader, metadata *Metadata) {
 	metadata.Properties["DocumentType"] = "PowerPoint Presentation"
 
 	// Count slides
@@ -769,7 +786,7 @@ type EmbeddedMedia struct {
 }
 
 // extractEmbeddedImages extracts embedded media files for further processing
-func extractEmbeddedImages(reader *zip.ReadCloser, metadata *Metadata) error {
+func extractEmbeddedImages(reader *zip.Reader, decrypted bool, metadata *Metadata) error {
 	var tempFiles []string
 	var embeddedMedia []EmbeddedMedia
 
@@ -805,6 +822,13 @@ func extractEmbeddedImages(reader *zip.ReadCloser, metadata *Metadata) error {
 			mediaType = "unclassified"
 		}
 
+		// A decrypted document's parts are plaintext and stay in memory. This loop
+		// only records them, so the part is listed without being extracted.
+		if decrypted {
+			embeddedMedia = append(embeddedMedia, EmbeddedMedia{OriginalName: file.Name, MediaType: mediaType})
+			continue
+		}
+
 		// Extract media to temp file
 		tempFile, err := extractImageToTemp(file)
 		if err != nil {
@@ -927,11 +951,11 @@ func extractImageToTemp(file *zip.File) (string, error) {
 // path and never any bytes from the part. It reaches stderr and every machine format.
 func ExtractEmbeddedMediaForProcessing(filePath string) ([]EmbeddedMedia, []string, error) {
 	// Open the file as a ZIP archive
-	reader, err := zip.OpenReader(filePath)
+	reader, closeArchive, decrypted, err := openArchive(filePath)
 	if err != nil {
 		return nil, nil, fmt.Errorf("error opening file as ZIP: %v", err)
 	}
-	defer reader.Close()
+	defer closeArchive()
 
 	var embeddedMedia []EmbeddedMedia
 	var notExamined []string
@@ -942,6 +966,20 @@ func ExtractEmbeddedMediaForProcessing(filePath string) ([]EmbeddedMedia, []stri
 			continue
 		}
 
+		// The router descends into a part through a temporary file, and for a
+		// decrypted document that file would be decrypted content on disk -- the one
+		// thing decrypting in memory exists to prevent. The part is declined and
+		// disclosed like any other part that never reached the router.
+		if decrypted {
+			refused++
+			if len(notExamined) < maxEmbeddedNotes {
+				notExamined = append(notExamined, fmt.Sprintf("embedded part %q was not examined: "+
+					"the document is encrypted, and extracting the part would write its decrypted content to disk",
+					filepath.Base(file.Name)))
+			}
+			continue
+		}
+
 		// Extract EVERY part under media/ or embeddings/ and let the ROUTER decide
 		// whether it can process it.
 		//
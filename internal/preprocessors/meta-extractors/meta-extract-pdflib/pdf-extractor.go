@@ -11,6 +11,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/awslabs/ferret-scan/v2/internal/encryption"
 )
 
 // Metadata represents PDF document metadata
@@ -60,10 +62,15 @@ func ExtractMetadata(filePath string) (*Metadata, error) {
 		return metadata, fmt.Errorf("PDF file too large: %d bytes (max: %d)", fileInfo.Size(), maxSafeFileSize)
 	}
 
-	// Read the PDF file - for very large files, consider streaming in future
-	data, err := os.ReadFile(filePath)
-	if err != nil {
-		return metadata, fmt.Errorf("error reading file: %v", err)
+	// Read the PDF file - for very large files, consider streaming in future.
+	// A document the router decrypted is read from the plaintext it holds: on
+	// disk the Info dictionary strings are ciphertext.
+	data, decrypted := encryption.Plaintext(filePath)
+	if !decrypted {
+		data, err = os.ReadFile(filePath)
+		if err != nil {
+			return metadata, fmt.Errorf("error reading file: %v", err)
+		}
 	}
 
 	// Extract PDF version
@@ -113,7 +120,7 @@ func ExtractMetadata(filePath string) (*Metadata, error) {
 	metadata.PageCount = countPages(data)
 
 	// Check if PDF is encrypted
-	metadata.Encrypted = isEncrypted(data)
+	metadata.Encrypted = decrypted || isEncrypted(data)
 
 	return metadata, nil
 }
@@ -0,0 +1,279 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package metaextractvideolib
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/awslabs/ferret-scan/v2/internal/embedded"
+	"github.com/awslabs/ferret-scan/v2/internal/redactors/ebml"
+	"github.com/awslabs/ferret-scan/v2/internal/redactors/riff"
+)
+
+// Attachment is a Matroska attachment materialized to a temporary file for the caller to route
+// as an embedded file. The caller releases it with CleanupAttachments.
+type Attachment struct {
+	// Name is the file name the container gives the attachment. It is producer-controlled and
+	// is never used to build a path.
+	Name         string
+	TempFilePath string
+	Size         int64
+}
+
+// CleanupAttachments removes the temporary files behind every attachment in metadata.
+func CleanupAttachments(metadata *VideoMetadata) {
+	if metadata == nil {
+		return
+	}
+	for _, a := range metadata.Attachments {
+		if a.TempFilePath != "" {
+			os.Remove(a.TempFilePath)
+		}
+	}
+	metadata.Attachments = nil
+}
+
+// extractTagContainer reads a Matroska, WebM or AVI file, and reports whether the file was one.
+//
+// Recognised by magic rather than by extension, as the redactor does: a .webm that is really an
+// MP4 still goes down the atom walk, and the two sides agree about which walk applies because
+// both decide by the same bytes.
+//
+// The raw-byte scrape the atom path finishes with is deliberately not run here. Every value this
+// path reports comes from an element or chunk the shared walk locates, so the redactor can find
+// each one at the offset it came from; a value scraped out of the frames would be a finding
+// nothing could remove.
+func extractTagContainer(file *os.File, size int64, metadata *VideoMetadata) (bool, error) {
+	head := make([]byte, 12)
+	n, _ := file.ReadAt(head, 0)
+	head = head[:n]
+
+	switch {
+	case ebml.HasHeader(head):
+		md, err := ebml.Read(file, size)
+		if err != nil {
+			return true, err
+		}
+		applyMatroska(md, metadata)
+		materializeAttachments(file, md.Attachments, metadata)
+	case riff.IsAVI(head):
+		md, err := riff.ReadAVI(file, size)
+		if err != nil {
+			return true, err
+		}
+		applyAVI(md, metadata)
+	default:
+		return false, nil
+	}
+
+	searchForGPSInMetadata(metadata)
+	return true, nil
+}
+
+// applyMatroska copies what the EBML walk found into the report.
+//
+// Tag names are the file's own ("ARTIST", "COMMENT"); the common ones fill the same fields the
+// atom path fills, and the rest keep their names as properties so a LOCATION tag still reaches
+// the GPS search.
+func applyMatroska(md *ebml.Metadata, metadata *VideoMetadata) {
+	if md.DocType == "webm" {
+		metadata.MimeType = "video/webm"
+	} else {
+		metadata.MimeType = "video/x-matroska"
+	}
+	metadata.Duration = md.Duration
+	metadata.Codec = md.Codec
+	metadata.Width = md.Width
+	metadata.Height = md.Height
+	if !md.DateUTC.IsZero() {
+		metadata.CreatedDate = md.DateUTC
+	}
+
+	for _, f := range md.Fields {
+		if strings.TrimSpace(f.Value) == "" {
+			continue
+		}
+		switch strings.ToUpper(f.Name) {
+		case "TITLE":
+			setField(metadata, &metadata.Title, f.Name, f.Value)
+		case "WRITINGAPP", "ENCODER":
+			setField(metadata, &metadata.Software, f.Name, f.Value)
+		case "ARTIST", "AUTHOR":
+			setField(metadata, &metadata.Author, f.Name, f.Value)
+		case "COMMENT", "DESCRIPTION", "SUMMARY":
+			setField(metadata, &metadata.Description, f.Name, f.Value)
+		case "COPYRIGHT":
+			setField(metadata, &metadata.Copyright, f.Name, f.Value)
+		case "DIRECTOR", "PRODUCER", "COMPOSER", "WRITTEN_BY":
+			setField(metadata, &metadata.Creator, f.Name, f.Value)
+		default:
+			setProperty(metadata, f.Name, f.Value)
+		}
+	}
+}
+
+// aviInfoNames gives the INFO chunks the names the WAV extractor already reports them under, so
+// the same chunk in an .avi and a .wav reads the same.
+var aviInfoNames = map[string]string{
+	"IENG": "Engineer",
+	"ITCH": "Technician",
+	"ISBJ": "Subject",
+	"ISRC": "Source",
+	"IPRD": "Product",
+	"IGNR": "Genre",
+	"IKEY": "Keywords",
+	"ICMS": "Commissioned",
+	"IARL": "ArchivalLocation",
+	"strn": "StreamName",
+	"IDIT": "DateTimeOriginal",
+}
+
+// applyAVI copies what the RIFF walk found into the report.
+func applyAVI(md *riff.Metadata, metadata *VideoMetadata) {
+	metadata.MimeType = "video/x-msvideo"
+	metadata.Duration = md.Duration
+	metadata.Codec = md.Codec
+	metadata.Width = md.Width
+	metadata.Height = md.Height
+
+	for _, f := range md.Fields {
+		value := strings.TrimSpace(f.Value)
+		if value == "" {
+			continue
+		}
+		switch f.ID {
+		case "INAM":
+			setField(metadata, &metadata.Title, "Title", value)
+		case "IART":
+			setField(metadata, &metadata.Author, "Artist", value)
+		case "ICMT":
+			setField(metadata, &metadata.Description, "Comment", value)
+		case "ICOP":
+			setField(metadata, &metadata.Copyright, "Copyright", value)
+		case "ISFT":
+			setField(metadata, &metadata.Software, "Software", value)
+		case "ICRD":
+			if date, err := parseDate(value); err == nil && metadata.CreatedDate.IsZero() {
+				metadata.CreatedDate = date
+			} else {
+				setProperty(metadata, "CreationDate", value)
+			}
+		default:
+			name := aviInfoNames[f.ID]
+			if name == "" {
+				name = f.ID
+			}
+			setProperty(metadata, name, value)
+		}
+	}
+
+	if md.MissingPad {
+		noteTruncation(metadata, "the AVI chunk layout omits a required pad byte after an "+
+			"odd-length chunk; metadata was recovered by realigning, but the file is malformed "+
+			"and may be truncated")
+	}
+}
+
+// setField fills a report field, or keeps a second value under its own name rather than
+// overwriting the first: a file with a Title element AND a TITLE tag has two values to report.
+func setField(metadata *VideoMetadata, dst *string, name, value string) {
+	if *dst == "" {
+		*dst = value
+		return
+	}
+	if *dst != value {
+		setProperty(metadata, name, value)
+	}
+}
+
+// setProperty records a property, numbering a repeated name ("TrackName", "TrackName 2") so a
+// second track or chapter does not overwrite the first.
+func setProperty(metadata *VideoMetadata, name, value string) {
+	key := name
+	for i := 2; ; i++ {
+		existing, ok := metadata.Properties[key]
+		if !ok {
+			metadata.Properties[key] = value
+			return
+		}
+		if existing == value {
+			return
+		}
+		key = fmt.Sprintf("%s %d", name, i)
+	}
+}
+
+// materializeAttachments writes each examinable attachment to a temporary file for the caller to
+// route as an embedded file.
+//
+// Fonts are skipped — see ebml.Attachment.IsFont — and the rest are bounded by count, by size
+// and by the traversal budget every other container shares. Each bound that bites is DISCLOSED:
+// an attachment nobody examined is missing coverage, and saying nothing would read as clean.
+func materializeAttachments(src io.ReaderAt, attachments []ebml.Attachment, metadata *VideoMetadata) {
+	var total int64
+	examined := 0
+	for _, a := range attachments {
+		if a.IsFont() {
+			continue
+		}
+		name := cleanAttachmentName(a.Name)
+		if examined >= ebml.MaxAttachments {
+			noteTruncation(metadata, fmt.Sprintf("attachments past the first %d were not examined", ebml.MaxAttachments))
+			return
+		}
+		examined++
+		if a.Data.Len() > ebml.MaxAttachmentBytes {
+			noteTruncation(metadata, fmt.Sprintf("attachment %q was not examined: exceeds the %d-byte embedded extraction cap",
+				name, int64(ebml.MaxAttachmentBytes)))
+			continue
+		}
+		if total+a.Data.Len() > embedded.BudgetBytes {
+			noteTruncation(metadata, fmt.Sprintf("attachments past the %dMB per-file budget were not examined",
+				embedded.BudgetBytes/(1024*1024)))
+			return
+		}
+		total += a.Data.Len()
+
+		path, err := writeAttachment(name, io.NewSectionReader(src, a.Data.Start, a.Data.Len()))
+		if err != nil {
+			noteTruncation(metadata, fmt.Sprintf("attachment %q was not examined: %v", name, err))
+			continue
+		}
+		metadata.Attachments = append(metadata.Attachments, Attachment{Name: name, TempFilePath: path, Size: a.Data.Len()})
+	}
+}
+
+// writeAttachment copies r to a temporary file. Its extension comes from embedded.SafeExt, never
+// from the attachment's name, which the file's author controls.
+func writeAttachment(name string, r io.Reader) (path string, err error) {
+	ext, _ := embedded.SafeExt(name)
+	tmp, err := os.CreateTemp("", "video_attachment_*"+ext)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		tmp.Close()
+		if err != nil {
+			os.Remove(tmp.Name())
+		}
+	}()
+	if _, err = io.Copy(tmp, r); err != nil {
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
+// cleanAttachmentName reduces an attachment's name to its final element, so a report never
+// repeats a path the file's author chose.
+func cleanAttachmentName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(strings.TrimSpace(name), `\`, "/"))
+	if name == "." || name == "/" || name == "" {
+		return "attachment"
+	}
+	return name
+}
@@ -0,0 +1,140 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package metaextractvideolib
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func ebmlEl(id uint32, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	var out []byte
+	for shift := 24; shift >= 0; shift -= 8 {
+		if b := byte(id >> shift); b != 0 || len(out) > 0 {
+			out = append(out, b)
+		}
+	}
+	out = append(out, 0x01)
+	size := binary.BigEndian.AppendUint64(nil, uint64(len(body)))
+	return append(append(out, size[1:]...), body...)
+}
+
+func ebmlStr(id uint32, s string) []byte { return ebmlEl(id, []byte(s)) }
+
+func ebmlTag(name, value string) []byte {
+	return ebmlEl(0x67C8, ebmlStr(0x45A3, name), ebmlStr(0x4487, value))
+}
+
+func writeFixture(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(p, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestExtractMatroskaTagsAndAttachments(t *testing.T) {
+	file := append(ebmlEl(0x1A45DFA3, ebmlStr(0x4282, "webm")), ebmlEl(0x18538067,
+		ebmlEl(0x1549A966,
+			ebmlEl(0x4489, binary.BigEndian.AppendUint64(nil, math.Float64bits(2000))),
+			ebmlStr(0x7BA9, "Standup recording"),
+			ebmlStr(0x5741, "Chrome")),
+		ebmlEl(0x1654AE6B, ebmlEl(0xAE, ebmlEl(0x83, []byte{1}), ebmlStr(0x536E, "Jane's screen"), ebmlStr(0x86, "V_VP8"))),
+		ebmlEl(0x1F43B675, ebmlEl(0xA3, bytes.Repeat([]byte{0xCD}, 64))),
+		ebmlEl(0x1254C367, ebmlEl(0x7373,
+			ebmlTag("COMMENT", "SSN 452-11-9384"),
+			ebmlTag("LOCATION", "+37.7749-122.4194/"),
+			ebmlTag("PURCHASE_OWNER", "Jane Doe"))),
+		ebmlEl(0x1941A469,
+			ebmlEl(0x61A7, ebmlStr(0x466E, "../notes.txt"), ebmlStr(0x4660, "text/plain"), ebmlEl(0x465C, []byte("call 555-0100"))),
+			ebmlEl(0x61A7, ebmlStr(0x466E, "Sans.ttf"), ebmlStr(0x4660, "font/ttf"), ebmlEl(0x465C, []byte("glyphs")))),
+	)...)
+	p := writeFixture(t, "standup.webm", file)
+
+	meta, err := ExtractVideoMetadata(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer CleanupAttachments(meta)
+
+	if meta.MimeType != "video/webm" || meta.Title != "Standup recording" || meta.Software != "Chrome" ||
+		meta.Description != "SSN 452-11-9384" || meta.Codec != "V_VP8" {
+		t.Errorf("meta = %+v", meta)
+	}
+	if meta.Duration.Seconds() != 2 {
+		t.Errorf("Duration = %v", meta.Duration)
+	}
+	if meta.Properties["TrackName"] != "Jane's screen" || meta.Properties["PURCHASE_OWNER"] != "Jane Doe" {
+		t.Errorf("Properties = %v", meta.Properties)
+	}
+	if math.Abs(meta.GPSLatitude-37.7749) > 1e-6 || math.Abs(meta.GPSLongitude+122.4194) > 1e-6 {
+		t.Errorf("GPS = %f, %f; a LOCATION tag must reach the position search", meta.GPSLatitude, meta.GPSLongitude)
+	}
+
+	// The font is skipped; the text file is materialized under its base name only.
+	if len(meta.Attachments) != 1 {
+		t.Fatalf("Attachments = %+v, want only the text file", meta.Attachments)
+	}
+	a := meta.Attachments[0]
+	if a.Name != "notes.txt" || !strings.HasSuffix(a.TempFilePath, ".txt") {
+		t.Errorf("attachment = %+v", a)
+	}
+	if got, _ := os.ReadFile(a.TempFilePath); string(got) != "call 555-0100" {
+		t.Errorf("attachment bytes = %q", got)
+	}
+	CleanupAttachments(meta)
+	if _, err := os.Stat(a.TempFilePath); !os.IsNotExist(err) {
+		t.Error("CleanupAttachments left the temporary file behind")
+	}
+}
+
+func riffChunk(id string, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	out := append([]byte(id), binary.LittleEndian.AppendUint32(nil, uint32(len(body)))...)
+	out = append(out, body...)
+	if len(body)%2 == 1 {
+		out = append(out, 0)
+	}
+	return out
+}
+
+func TestExtractAVIInfo(t *testing.T) {
+	avih := make([]byte, 56)
+	binary.LittleEndian.PutUint32(avih[0:], 40000)
+	binary.LittleEndian.PutUint32(avih[16:], 25)
+	binary.LittleEndian.PutUint32(avih[32:], 320)
+	binary.LittleEndian.PutUint32(avih[36:], 240)
+	file := riffChunk("RIFF", []byte("AVI "),
+		riffChunk("LIST", []byte("hdrl"), riffChunk("avih", avih),
+			riffChunk("LIST", []byte("strl"), riffChunk("strn", []byte("Front door\x00")))),
+		riffChunk("LIST", []byte("INFO"),
+			riffChunk("INAM", []byte("Delivery\x00")),
+			riffChunk("ICMT", []byte("SSN 452-11-9384\x00")),
+			riffChunk("ICRD", []byte("2024-05-01\x00")),
+			riffChunk("ISFT", []byte("Lavf58\x00"))),
+		riffChunk("LIST", []byte("movi"), riffChunk("00dc", bytes.Repeat([]byte{0xCD}, 64))))
+	p := writeFixture(t, "door.avi", file)
+
+	meta, err := ExtractVideoMetadata(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.MimeType != "video/x-msvideo" || meta.Title != "Delivery" || meta.Description != "SSN 452-11-9384" ||
+		meta.Software != "Lavf58" || meta.Properties["StreamName"] != "Front door" {
+		t.Errorf("meta = %+v", meta)
+	}
+	if meta.Width != 320 || meta.Height != 240 || meta.Duration.Seconds() != 1 {
+		t.Errorf("technical = %dx%d %v", meta.Width, meta.Height, meta.Duration)
+	}
+	if meta.CreatedDate.Year() != 2024 {
+		t.Errorf("CreatedDate = %v", meta.CreatedDate)
+	}
+}
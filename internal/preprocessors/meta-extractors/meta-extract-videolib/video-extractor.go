@@ -66,6 +66,10 @@ type VideoMetadata struct {
 	// Additional properties
 	Properties map[string]string
 
+	// Attachments are a Matroska file's attached files, materialized for the caller to route as
+	// embedded files. Released with CleanupAttachments.
+	Attachments []Attachment
+
 	// ExtractionWarning is a payload-free note that extraction finished but covered less than the
 	// whole file, so a value may be missing. It carries box types, byte offsets and limit
 	// constants only — never a metadata value, and never matched text.
@@ -168,6 +172,12 @@ func ExtractVideoMetadataWithContext(ctx context.Context, filePath string) (*Vid
 		metadata.MimeType = "video/mp4"
 	case ".mov":
 		metadata.MimeType = "video/quicktime"
+	case ".mkv":
+		metadata.MimeType = "video/x-matroska"
+	case ".webm":
+		metadata.MimeType = "video/webm"
+	case ".avi":
+		metadata.MimeType = "video/x-msvideo"
 	default:
 		metadata.MimeType = "video/unknown"
 	}
@@ -179,6 +189,14 @@ func ExtractVideoMetadataWithContext(ctx context.Context, filePath string) (*Vid
 	}
 	defer optimizedReader.Close()
 
+	if handled, err := extractTagContainer(optimizedReader.file, optimizedReader.fileSize, metadata); handled {
+		if err != nil {
+			CleanupAttachments(metadata)
+			return nil, NewVideoProcessingError(filePath, "parsing", "failed to parse container", err)
+		}
+		return metadata, nil
+	}
+
 	// Parse MP4/MOV container with optimized reading
 	err = parseMP4ContainerOptimized(processCtx, optimizedReader, metadata)
 	if err != nil {
@@ -994,9 +1012,12 @@ func parseDate(dateStr string) (time.Time, error) {
 func CanProcessVideo(filePath string) bool {
 	ext := strings.ToLower(filepath.Ext(filePath))
 	supportedExtensions := map[string]bool{
-		".mp4": true,
-		".m4v": true,
-		".mov": true,
+		".mp4":  true,
+		".m4v":  true,
+		".mov":  true,
+		".mkv":  true,
+		".webm": true,
+		".avi":  true,
 	}
 	return supportedExtensions[ext]
 }
@@ -1375,7 +1396,7 @@ func (vm *VideoMetadata) ToProcessedContent() string {
 
 // GetSupportedVideoFormats returns the list of supported video formats
 func GetSupportedVideoFormats() []string {
-	return []string{".mp4", ".m4v", ".mov"}
+	return []string{".mp4", ".m4v", ".mov", ".mkv", ".webm", ".avi"}
 }
 
 // OptimizedVideoReader provides optimized reading for video files
@@ -0,0 +1,118 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package preprocessors
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/awslabs/ferret-scan/v2/internal/observability"
+	textextractnotebooklib "github.com/awslabs/ferret-scan/v2/internal/preprocessors/text-extractors/text-extract-notebooklib"
+)
+
+// NotebookPreprocessor extracts the cells and text outputs of Jupyter
+// notebooks (.ipynb).
+//
+// A notebook was scanned as one JSON document, so a value was seen with its
+// JSON escapes, a finding's line was a line of the JSON, and every base64 plot
+// was scanned as text. Credentials and customer rows turn up in cell outputs
+// more often than in source. Each cell's source and each of its outputs are
+// now a section of their own, with stream text, error tracebacks and the
+// text/* entries of each result (text/plain, text/html) read, and image
+// payloads skipped. A finding names the cell it is in and the cell's type:
+// "analysis.ipynb -> cell 7 (code output)".
+type NotebookPreprocessor struct {
+	*BaseMetadataPreprocessor
+}
+
+// NewNotebookPreprocessor creates a new Jupyter notebook preprocessor
+func NewNotebookPreprocessor() *NotebookPreprocessor {
+	return &NotebookPreprocessor{
+		BaseMetadataPreprocessor: NewBaseMetadataPreprocessor("notebook", "notebook"),
+	}
+}
+
+// CanProcess checks if this preprocessor can handle the given file
+func (np *NotebookPreprocessor) CanProcess(filePath string) bool {
+	return IsNotebookFile(filePath)
+}
+
+// IsNotebookFile reports whether a file has the .ipynb extension and begins
+// like JSON. A text file so named that is not JSON is left to the plaintext
+// preprocessor.
+func IsNotebookFile(filePath string) bool {
+	if !mediaExtValidator.IsNotebookFile(filePath) {
+		return false
+	}
+	f, err := os.Open(filepath.Clean(filePath)) // #nosec G304 -- path vetted by the router
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(f, head)
+	return textextractnotebooklib.IsNotebook(head[:n])
+}
+
+// Process extracts the cells and outputs of a notebook
+func (np *NotebookPreprocessor) Process(filePath string) (*ProcessedContent, error) {
+	return np.ProcessWithRetry(filePath, func() (*ProcessedContent, error) {
+		return np.processNotebook(filePath)
+	})
+}
+
+// processNotebook builds the section-per-cell text for one notebook.
+func (np *NotebookPreprocessor) processNotebook(filePath string) (*ProcessedContent, error) {
+	if err := np.ValidateFileSize(filePath, false); err != nil {
+		return np.HandleError(filePath, "notebook", err), err
+	}
+
+	raw, err := os.ReadFile(filePath) // #nosec G304 -- path vetted by the router
+	if err != nil {
+		err = fmt.Errorf("failed to read file: %w", err)
+		return np.BuildErrorContent(filePath, "notebook", err), err
+	}
+	ex, err := textextractnotebooklib.Extract(raw)
+	if err != nil {
+		err = fmt.Errorf("failed to read notebook: %w", err)
+		return np.BuildErrorContent(filePath, "notebook", err), err
+	}
+
+	content := np.BuildSuccessContent(filePath, ex.Text, "notebook", 0)
+	for _, seg := range ex.Segments {
+		content.Sections = append(content.Sections, ContentSection{
+			Name:          "notebook",
+			Kind:          SectionKindBody,
+			SourceFile:    np.GetUtilities().RouterHelper.CreateItemPath(filePath, "cell ", notebookCellLabel(seg)),
+			Text:          seg.Text,
+			LineOffset:    seg.Line,
+			AttributeBody: true,
+		})
+	}
+	content.Metadata["cell_count"] = ex.Cells
+	content.Metadata["skipped_payloads"] = ex.SkippedPayloads
+	return content, nil
+}
+
+// notebookCellLabel names a segment's cell: "7 (code)", "7 (code output)".
+func notebookCellLabel(seg textextractnotebooklib.Segment) string {
+	kind := seg.CellType
+	if seg.Output {
+		kind += " output"
+	}
+	return strconv.Itoa(seg.Cell) + " (" + kind + ")"
+}
+
+// GetSupportedExtensions returns the file extensions this preprocessor supports
+func (np *NotebookPreprocessor) GetSupportedExtensions() []string {
+	return np.GetUtilities().ExtensionValidator.GetNotebookExtensions()
+}
+
+// SetObserver sets the observability component
+func (np *NotebookPreprocessor) SetObserver(observer observability.Observer) {
+	np.BaseMetadataPreprocessor.SetObserver(observer)
+}
@@ -0,0 +1,65 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package preprocessors
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// A finding in a notebook names its cell and the cell's type.
+func TestNotebookFindingsNameTheCell(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "analysis.ipynb")
+	nb := `{"cells": [
+  {"cell_type": "markdown", "metadata": {}, "source": ["# Load\n", "From s3://bucket"]},
+  {"cell_type": "code", "metadata": {}, "execution_count": 1, "source": "rows = load()",
+   "outputs": [{"output_type": "stream", "name": "stdout", "text": "ssn 536-22-1874\n"},
+               {"output_type": "display_data", "metadata": {}, "data": {"image/png": "iVBORw0KGgo="}}]}
+ ], "metadata": {}, "nbformat": 4, "nbformat_minor": 5}`
+	if err := os.WriteFile(path, []byte(nb), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if !IsNotebookFile(path) {
+		t.Fatal("IsNotebookFile = false")
+	}
+	if NewPlainTextPreprocessor().CanProcess(path) {
+		t.Error("the plaintext preprocessor claimed a notebook")
+	}
+
+	pc, err := NewNotebookPreprocessor().Process(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "# Load\nFrom s3://bucket\nrows = load()\nssn 536-22-1874\n"; pc.Text != want {
+		t.Fatalf("Text = %q, want %q", pc.Text, want)
+	}
+	sourceOf := pc.SourceLookup()
+	for line, want := range map[int]string{
+		2: "analysis.ipynb -> cell 1 (markdown)",
+		3: "analysis.ipynb -> cell 2 (code)",
+		4: "analysis.ipynb -> cell 2 (code output)",
+	} {
+		if got := sourceOf(line); got != want {
+			t.Errorf("line %d: source = %q, want %q", line, got, want)
+		}
+	}
+	if pc.Metadata["skipped_payloads"] != 1 {
+		t.Errorf("skipped_payloads = %v", pc.Metadata["skipped_payloads"])
+	}
+}
+
+// A text file named .ipynb is text.
+func TestNotebookNeedsJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.ipynb")
+	if err := os.WriteFile(path, []byte("ssn 536-22-1874\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if IsNotebookFile(path) {
+		t.Error("a text file named .ipynb was taken for a notebook")
+	}
+	if !NewPlainTextPreprocessor().CanProcess(path) {
+		t.Error("the plaintext preprocessor declined a text file named .ipynb")
+	}
+}
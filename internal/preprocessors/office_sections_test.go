@@ -0,0 +1,113 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package preprocessors
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	textextractofficetextlib "github.com/awslabs/ferret-scan/v2/internal/preprocessors/text-extractors/text-extract-officetextlib"
+)
+
+// Each origin span becomes a section of its own: body text keeps the scanned
+// file as its source, anything else is labelled and attributed, and cells go to
+// the section holding their line, relative to it.
+func TestOfficeSectionsSplitsByOrigin(t *testing.T) {
+	text := "--- sheet1 ---\na\t\n\n--- sheet2 ---\nb\t\n\n--- COMMENTS ---\nnote\n"
+	cells := []CellLine{
+		{Line: 1, Sheet: "Visible", Cells: []CellPos{{Row: 1, Column: 1}}},
+		{Line: 4, Sheet: "Raw/2026", Cells: []CellPos{{Row: 1, Column: 1}}},
+		{Line: 7, Sheet: "Visible", Cells: []CellPos{{Row: 1, Column: 1}}},
+	}
+	origins := []textextractofficetextlib.OriginSpan{
+		{Line: 3, Origin: textextractofficetextlib.OriginHiddenSheet, Name: "Raw/2026"},
+		{Line: 6, Origin: textextractofficetextlib.OriginBody},
+		{Line: 7, Origin: textextractofficetextlib.OriginComment, Name: "Visible!A1"},
+	}
+
+	got := tpForTest().officeSections("/data/book.xlsx", text, cells, origins)
+
+	want := []struct {
+		source string
+		text   string
+		line   int
+		cell   int // Line of the section's only cell
+	}{
+		{"", "--- sheet1 ---\na\t\n\n", 0, 1},
+		{"book.xlsx -> hidden-sheet:Raw_2026", "--- sheet2 ---\nb\t\n\n", 3, 1},
+		{"", "--- COMMENTS ---\n", 6, -1},
+		{"book.xlsx -> comment:Visible!A1", "note\n", 7, 0},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("%d sections, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		s := got[i]
+		if s.SourceFile != w.source || s.Text != w.text || s.LineOffset != w.line || s.AttributeBody != (w.source != "") {
+			t.Errorf("section %d = {%q %q line %d attribute %v}, want {%q %q line %d}",
+				i, s.SourceFile, s.Text, s.LineOffset, s.AttributeBody, w.source, w.text, w.line)
+		}
+		switch {
+		case w.cell < 0 && len(s.Cells) != 0:
+			t.Errorf("section %d has cells %+v, want none", i, s.Cells)
+		case w.cell >= 0 && (len(s.Cells) != 1 || s.Cells[0].Line != w.cell):
+			t.Errorf("section %d has cells %+v, want one on line %d", i, s.Cells, w.cell)
+		}
+	}
+}
+
+// processOffice declares the labelled sections for a real document, so a
+// value in a Word comment is attributed to the comment.
+func TestProcessOfficeLabelsComments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "memo.docx")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for name, body := range map[string]string{
+		"_rels/.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/></Relationships>`,
+		"word/document.xml": `<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>` +
+			`<w:p><w:r><w:t>Quarterly memo.</w:t></w:r></w:p></w:body></w:document>`,
+		"word/comments.xml": `<w:comments xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">` +
+			`<w:comment w:id="0"><w:p><w:r><w:t>SSN 536-22-1874</w:t></w:r></w:p></w:comment></w:comments>`,
+	} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	got, err := tpForTest().processOffice(path, &ProcessedContent{OriginalPath: path, Filename: "memo.docx"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sourceOf := got.SourceLookup()
+	if sourceOf == nil {
+		t.Fatal("no attributed sections declared")
+	}
+	for n, line := range strings.Split(got.Text, "\n") {
+		switch {
+		case strings.Contains(line, "536-22-1874"):
+			if s := sourceOf(n + 1); s != "memo.docx -> comment:0" {
+				t.Errorf("comment attributed to %q", s)
+			}
+		case strings.Contains(line, "Quarterly memo."):
+			if s := sourceOf(n + 1); s != "" {
+				t.Errorf("body attributed to %q", s)
+			}
+		}
+	}
+}
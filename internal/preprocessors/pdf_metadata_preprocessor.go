@@ -134,7 +134,7 @@ func (pmp *PDFMetadataPreprocessor) handlePDFSpecificErrors(err error, filePath
 	if strings.Contains(errStr, "encrypted") || strings.Contains(errStr, "password") {
 		return NewMediaProcessingError(filePath, "pdf", ErrorTypeFileCorrupted,
 			"PDF is encrypted or password-protected - limited metadata extraction available", err).
-			WithContext("suggestion", "Encrypted PDFs may have limited metadata available")
+			WithContext("suggestion", "Supply the document's password with --password-file to have it decrypted and scanned in full")
 	}
 
 	// Handle corrupted PDF structure
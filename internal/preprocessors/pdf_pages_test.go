@@ -0,0 +1,82 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package preprocessors
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	textextractpdftextlib "github.com/awslabs/ferret-scan/v2/internal/preprocessors/text-extractors/text-extract-pdftextlib"
+)
+
+// processPDF declares its own body section so the page boundaries travel with
+// it, and position mappings take the page from those boundaries.
+func TestProcessPDFDeclaresPages(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ok.pdf")
+	if err := os.WriteFile(path, []byte(validPDFWithText()), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := tpForTest().processPDF(path, &ProcessedContent{OriginalPath: path, Filename: "ok.pdf"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Sections) != 1 {
+		t.Fatalf("%d sections, want 1", len(got.Sections))
+	}
+	s := got.Sections[0]
+	if s.Name != "Text Extractor" || s.Kind != SectionKindBody || s.Text != got.Text {
+		t.Errorf("section = {%q %v len %d}, want the whole text as a Text Extractor body section", s.Name, s.Kind, len(s.Text))
+	}
+	if len(s.Pages) != 1 || s.Pages[0] != (PageSpan{Page: 1, Line: 0}) {
+		t.Errorf("Pages = %+v, want page 1 at line 0", s.Pages)
+	}
+	for _, m := range got.PositionMappings {
+		if m.OriginalPosition.Page != 1 {
+			t.Errorf("mapping for line %d has page %d, want 1", m.ExtractedPosition.Line, m.OriginalPosition.Page)
+		}
+	}
+}
+
+func TestPageLookup(t *testing.T) {
+	pc := &ProcessedContent{Sections: []ContentSection{
+		{Text: "a\nb\nc\nd\ne", LineOffset: 0, Pages: []PageSpan{{Page: 1, Line: 1}, {Page: 2, Line: 3}, {Page: 0, Line: 4}}},
+		{Text: "meta", LineOffset: 7},
+	}}
+	pageOf := pc.PageLookup()
+	for line, want := range map[int]int{1: 0, 2: 1, 3: 1, 4: 2, 5: 0, 6: 0, 8: 0} {
+		if got := pageOf(line); got != want {
+			t.Errorf("line %d: page %d, want %d", line, got, want)
+		}
+	}
+
+	if (&ProcessedContent{Sections: []ContentSection{{Text: "x"}}}).PageLookup() != nil {
+		t.Error("PageLookup on unpaged content is not nil")
+	}
+}
+
+// Pages that were not read must be disclosed, and as coverage cut short rather
+// than as a document with no text: the rest of the file was scanned.
+func TestPDFPageCoverageWarning(t *testing.T) {
+	if got := pdfPageCoverageWarning("/tmp/a.pdf", &textextractpdftextlib.TextContent{PageCount: 9}); got != "" {
+		t.Errorf("a fully read PDF carries a warning: %q", got)
+	}
+
+	got := pdfPageCoverageWarning("/tmp/x/statement.pdf", &textextractpdftextlib.TextContent{
+		PageCount: 900, FailedPages: 2, PagesNotExtracted: 100,
+	})
+	for _, want := range []string{"2 of 900 pages", "pages 801-900 of 900", "NOT scanned"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("warning %q does not contain %q", got, want)
+		}
+	}
+	if strings.Contains(got, "no text extracted from") {
+		t.Errorf("warning %q uses the no-text prefix, so a partly scanned file would be reported as having no body text", got)
+	}
+	if strings.Contains(got, "/tmp/x") {
+		t.Errorf("warning %q embeds the path", got)
+	}
+}
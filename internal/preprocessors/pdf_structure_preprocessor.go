@@ -0,0 +1,210 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package preprocessors
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/awslabs/ferret-scan/v2/internal/observability"
+	textextractbarcodelib "github.com/awslabs/ferret-scan/v2/internal/preprocessors/text-extractors/text-extract-barcodelib"
+	textextractpdftextlib "github.com/awslabs/ferret-scan/v2/internal/preprocessors/text-extractors/text-extract-pdftextlib"
+)
+
+// PDFStructurePreprocessor extracts the content of a PDF that is not page
+// content: AcroForm field values, annotation text, outline titles, embedded
+// files, and the barcodes in the images drawn on its pages.
+//
+// None of it reached the validators before. The text extractor reads page
+// content streams and the metadata preprocessor reads the Info dictionary, so an
+// SSN typed into a form field, a reviewer's sticky note naming a patient, or a
+// spreadsheet attached to a PDF portfolio scanned clean.
+//
+// Each item is declared as its own section with its own source, so a finding is
+// reported against the item it came from ("form.pdf -> field:applicant_ssn")
+// rather than against the whole document. The sections are body sections and
+// set AttributeBody: their text is scanned by the full validator set, and the
+// label carries through to document-path findings.
+type PDFStructurePreprocessor struct {
+	*BaseMetadataPreprocessor
+}
+
+// NewPDFStructurePreprocessor creates a new PDF structure preprocessor
+func NewPDFStructurePreprocessor() *PDFStructurePreprocessor {
+	return &PDFStructurePreprocessor{
+		BaseMetadataPreprocessor: NewBaseMetadataPreprocessor("pdf_structure", "pdf_structure"),
+	}
+}
+
+// CanProcess checks if this preprocessor can handle the given file
+func (psp *PDFStructurePreprocessor) CanProcess(filePath string) bool {
+	return psp.GetUtilities().ExtensionValidator.IsPDFFile(filePath)
+}
+
+// Process extracts the form fields, annotations, bookmarks and embedded files
+// of a PDF
+func (psp *PDFStructurePreprocessor) Process(filePath string) (*ProcessedContent, error) {
+	return psp.ProcessWithRetry(filePath, func() (*ProcessedContent, error) {
+		return psp.processPDFStructure(filePath)
+	})
+}
+
+// processPDFStructure builds the section-per-item text for one PDF.
+func (psp *PDFStructurePreprocessor) processPDFStructure(filePath string) (*ProcessedContent, error) {
+	if err := psp.ValidateFileSize(filePath, false); err != nil {
+		return psp.HandleError(filePath, "pdf", err), err
+	}
+
+	st, err := textextractpdftextlib.ExtractStructure(filePath)
+	if err != nil {
+		// Not disclosed as a warning: the text extractor opens the same file with
+		// the same library and already reports a PDF it cannot parse.
+		return psp.BuildErrorContent(filePath, "pdf_structure",
+			fmt.Errorf("failed to extract PDF structure: %w", err)), err
+	}
+	defer textextractpdftextlib.CleanupAttachments(st.Attachments)
+
+	b := structureText{name: "pdf_structure"}
+	for _, f := range st.Fields {
+		b.add(psp.itemSource(filePath, "field:", f.Name), 0, f.Name+": "+f.Value)
+	}
+	for _, a := range st.Annotations {
+		var text strings.Builder
+		if a.Author != "" {
+			text.WriteString("Author: " + a.Author + "\n")
+		}
+		if a.Subject != "" {
+			text.WriteString("Subject: " + a.Subject + "\n")
+		}
+		text.WriteString(a.Contents)
+		b.add(psp.itemSource(filePath, "annotation:", fmt.Sprintf("page%d", a.Page)), a.Page, text.String())
+	}
+	if len(st.Bookmarks) > 0 {
+		titles := make([]string, len(st.Bookmarks))
+		for i, bm := range st.Bookmarks {
+			titles[i] = strings.Repeat("  ", bm.Depth) + bm.Title
+		}
+		b.add(psp.itemSource(filePath, "", "bookmarks"), 0, strings.Join(titles, "\n"))
+	}
+
+	warnings := append(st.Notes, psp.pageBarcodes(filePath, &b)...)
+	if len(st.Attachments) > 0 {
+		media := make([]EmbeddedMedia, len(st.Attachments))
+		for i, a := range st.Attachments {
+			media[i] = EmbeddedMedia{OriginalName: a.Name, TempFilePath: a.TempFilePath, MediaType: "attachment"}
+		}
+		// Routed exactly like an Office document's embedded parts, so the router
+		// owns the nesting bound (embedded.MaxDepth) for a PDF attached to a PDF
+		// attached to a .docx, and an attachment it declines is disclosed the same
+		// way.
+		text, sections, embeddedWarnings := psp.ProcessEmbeddedMedia(filePath, media)
+		b.addEmbedded(text, sections)
+		warnings = append(warnings, embeddedWarnings...)
+	}
+
+	content := psp.BuildSuccessContent(filePath, b.text.String(), "pdf_structure", 0)
+	content.Sections = b.sections
+	content.ExtractionWarning = strings.Join(warnings, "; ")
+	return content, nil
+}
+
+// pageBarcodes adds the symbols in the images drawn on the pages, one section
+// per symbol: "scan.pdf -> page2:qr[0]". Symbols are numbered across the page,
+// not the image, so two images on one page cannot both hold a qr[0].
+//
+// It returns the notes to disclose. Unlike a document the structure reader
+// cannot open, a failure here is disclosed: the structure was read, so nothing
+// else reports that the page images were not.
+func (psp *PDFStructurePreprocessor) pageBarcodes(filePath string, b *structureText) []string {
+	type key struct {
+		page   int
+		format textextractbarcodelib.Format
+	}
+	next := make(map[key]int)
+	notes, err := textextractpdftextlib.ExtractPageImages(filePath, textextractbarcodelib.MaxPixels, func(pi textextractpdftextlib.PageImage) {
+		symbols, err := textextractbarcodelib.DecodeBytes(pi.Data)
+		if err != nil {
+			return
+		}
+		for _, sym := range symbols {
+			k := key{pi.Page, sym.Format}
+			label := fmt.Sprintf("page%d:%s[%d]", pi.Page, sym.Format, next[k])
+			next[k]++
+			b.add(psp.itemSource(filePath, "", label), pi.Page, sym.ScanText())
+		}
+	})
+	if err != nil {
+		notes = append(notes, fmt.Sprintf("page images were not searched for barcodes: %v", err))
+	}
+	return notes
+}
+
+// itemSource labels one item of the document the way embedded media is
+// labelled: "form.pdf -> field:applicant_ssn". See CreateItemPath.
+func (psp *PDFStructurePreprocessor) itemSource(filePath, kind, name string) string {
+	return psp.GetUtilities().RouterHelper.CreateItemPath(filePath, kind, name)
+}
+
+// structureText accumulates a preprocessor's text and the sections that
+// describe it, keeping the two in step. name is the preprocessor's, and names
+// every section it adds.
+type structureText struct {
+	name     string
+	text     strings.Builder
+	sections []ContentSection
+	line     int
+}
+
+// add appends one item as its own section. page is the item's 1-based page, or
+// 0 when it belongs to none. An empty source leaves the section reported
+// against the scanned file.
+func (st *structureText) add(source string, page int, text string) {
+	text = strings.TrimSpace(strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(text))
+	if text == "" {
+		return
+	}
+	text += "\n"
+	section := ContentSection{
+		Name:          st.name,
+		Kind:          SectionKindBody,
+		SourceFile:    source,
+		Text:          text,
+		LineOffset:    st.line,
+		AttributeBody: source != "",
+	}
+	if page > 0 {
+		section.Pages = []PageSpan{{Page: page, Line: 0}}
+	}
+	st.sections = append(st.sections, section)
+	st.text.WriteString(text)
+	st.line += strings.Count(text, "\n")
+}
+
+// addEmbedded appends the text ProcessEmbeddedMedia produced, re-anchoring its
+// sections (which count from the start of that text) and attributing
+// document-path findings in them to the attachment.
+func (st *structureText) addEmbedded(text string, sections []ContentSection) {
+	for _, s := range sections {
+		s.LineOffset += st.line
+		s.AttributeBody = true
+		st.sections = append(st.sections, s)
+	}
+	st.text.WriteString(text)
+	st.line += strings.Count(text, "\n")
+}
+
+// GetSupportedExtensions returns the file extensions this preprocessor supports
+func (psp *PDFStructurePreprocessor) GetSupportedExtensions() []string {
+	return psp.GetUtilities().ExtensionValidator.GetPDFExtensions()
+}
+
+// SetObserver sets the observability component
+func (psp *PDFStructurePreprocessor) SetObserver(observer observability.Observer) {
+	psp.BaseMetadataPreprocessor.SetObserver(observer)
+}
+
+// SetRouter sets the router instance for routing embedded files
+func (psp *PDFStructurePreprocessor) SetRouter(router RouterInterface) {
+	psp.BaseMetadataPreprocessor.SetRouter(router)
+}
@@ -0,0 +1,47 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package preprocessors
+
+import (
+	"strings"
+	"testing"
+)
+
+// A field name is producer-controlled and becomes the reported file name and
+// part of the suppression identity, which hashes the base name: a "/" in it
+// would silently cut the label down to whatever followed.
+func TestPDFStructureItemSource(t *testing.T) {
+	psp := NewPDFStructurePreprocessor()
+	cases := map[string]string{
+		"applicant_ssn":          "form.pdf -> field:applicant_ssn",
+		"page1/ssn":              "form.pdf -> field:page1_ssn",
+		"a\nb\x00c":              "form.pdf -> field:abc",
+		strings.Repeat("x", 100): "form.pdf -> field:" + strings.Repeat("x", 64) + "...",
+	}
+	for name, want := range cases {
+		if got := psp.itemSource("/data/in/form.pdf", "field:", name); got != want {
+			t.Errorf("itemSource(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestSourceLookup(t *testing.T) {
+	pc := &ProcessedContent{Sections: []ContentSection{
+		{Text: "body\nbody\n", SourceFile: "doc.pdf"},
+		{Text: "f1: v\n", SourceFile: "doc.pdf -> field:f1", LineOffset: 2, AttributeBody: true},
+		{Text: "f2: v\nmore", SourceFile: "doc.pdf -> field:f2", LineOffset: 3, AttributeBody: true},
+		{Text: "Author: x\n", SourceFile: "doc.pdf", LineOffset: 5},
+	}}
+	sourceOf := pc.SourceLookup()
+	want := map[int]string{1: "", 2: "", 3: "doc.pdf -> field:f1", 4: "doc.pdf -> field:f2", 5: "doc.pdf -> field:f2", 6: ""}
+	for line, w := range want {
+		if got := sourceOf(line); got != w {
+			t.Errorf("line %d: %q, want %q", line, got, w)
+		}
+	}
+
+	if (&ProcessedContent{Sections: pc.Sections[:1]}).SourceLookup() != nil {
+		t.Error("SourceLookup without an attributed section should be nil")
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"unicode/utf8"
 
 	"github.com/awslabs/ferret-scan/v2/internal/observability"
+	textextractcolumnarlib "github.com/awslabs/ferret-scan/v2/internal/preprocessors/text-extractors/text-extract-columnarlib"
 )
 
 // PlainTextPreprocessor handles plain text files by passing their content through
@@ -50,10 +51,10 @@ func (ptp *PlainTextPreprocessor) GetSupportedExtensions() []string {
 		// Plain text files
 		".txt", ".text", ".log", ".md", ".markdown", ".rst",
 		// Configuration files
-		".yaml", ".yml", ".json", ".xml", ".toml", ".ini", ".conf", ".config", ".cfg",
+		".yaml", ".yml", ".json", ".toml", ".ini", ".conf", ".config", ".cfg",
 		// Source code files
 		".py", ".js", ".ts", ".java", ".c", ".cpp", ".h", ".hpp", ".cs", ".go", ".rs", ".rb", ".php",
-		".html", ".htm", ".css", ".scss", ".sass", ".less",
+		".css", ".scss", ".sass", ".less",
 		".sql", ".sh", ".bash", ".zsh", ".ps1", ".bat", ".cmd",
 		// Data files
 		".csv", ".tsv", ".jsonl", ".ndjson",
@@ -135,8 +136,17 @@ func (ptp *PlainTextPreprocessor) CanProcess(filePath string) bool {
 	// Deliberately NOT claimed: extensions belonging to the image, video and audio
 	// metadata extractors. Those route to a preprocessor that handles them, so they
 	// are not the failing case, and claiming them would change behavior for every
-	// media file rather than recovering an unscanned one.
-	if !claimedByAnotherPreprocessor(ext) {
+	// media file rather than recovering an unscanned one. Email files are excluded
+	// for the opposite reason: an .eml IS text, and claiming it would scan every
+	// message a second time in its transfer encoding, alongside the decoded copy
+	// the email preprocessor produces. HTML, XML and RTF are excluded for the same
+	// reason: the raw markup would be scanned next to its decoded text, and a
+	// value the markup splits would be found in one and missed in the other.
+	//
+	// A notebook is JSON text, but the notebook preprocessor reads it cell by
+	// cell. Scanning the raw JSON as well would report every value twice, once
+	// with its JSON escapes and a line number of the JSON.
+	if !claimedByAnotherPreprocessor(ext) && !IsNotebookFile(filePath) {
 		return ptp.isTextFile(filePath)
 	}
 
@@ -154,14 +164,17 @@ var mediaExtValidator = NewFileExtensionValidator()
 // Office and PDF are excluded from the check because containerExtensions has
 // already handled them above with a sniff: they are claimed by another
 // preprocessor AND claimable here when the bytes turn out to be text. What
-// remains are the media types, which this preprocessor should not claim.
+// remains are the media, email and markup types, which this preprocessor should
+// not claim.
 func claimedByAnotherPreprocessor(ext string) bool {
 	// The Is*File predicates run filepath.Ext internally, which returns "" for a
 	// bare ".heic", so give them a filename to inspect.
 	probe := "f" + ext
 	return mediaExtValidator.IsImageFile(probe) ||
 		mediaExtValidator.IsVideoFile(probe) ||
-		mediaExtValidator.IsAudioFile(probe)
+		mediaExtValidator.IsAudioFile(probe) ||
+		mediaExtValidator.IsEmailFile(probe) ||
+		mediaExtValidator.IsMarkupFile(probe)
 }
 
 // containerExtensions are the extensions whose files are normally binary
@@ -170,6 +183,9 @@ func claimedByAnotherPreprocessor(ext string) bool {
 //
 // PDF is included for the same reason as the rest: a text file named .pdf is
 // exactly as unscannable as one named .docx, and the sniff is what decides.
+//
+// The SQLite extensions are here too. ".db" in particular names text as often
+// as a database, and a real database is binary and fails the sniff.
 // pdfMagic is the header every PDF is required to begin with (PDF 1.7 §7.5.2). It is the
 // discriminator LooksLikeText uses, because a PDF's header is ASCII and a printability sniff
 // therefore cannot tell one from a text file.
@@ -181,6 +197,8 @@ var containerExtensions = map[string]bool{
 	".doc": true, ".xls": true, ".ppt": true,
 	".odt": true, ".ods": true, ".odp": true,
 	".pdf": true,
+
+	".sqlite": true, ".sqlite3": true, ".db": true, ".db3": true,
 }
 
 // Process extracts text content from the file
@@ -372,7 +390,7 @@ func LooksLikeText(buf []byte) bool {
 	// A format whose header is ASCII BY SPECIFICATION cannot be judged by a printability
 	// sniff, so it is judged by its magic bytes instead.
 	//
-	// PDF is the only such container in this tree. Every PDF opens "%PDF-1.x", and one written
+	// PDF is the case that prompted it. Every PDF opens "%PDF-1.x", and one written
 	// by a browser or by Office follows that with a large ASCII metadata dictionary (/Title,
 	// /Creator, /Producer), so the first 512 bytes carry no NUL and are ~100% printable — and
 	// the tests below therefore returned TRUE for a file that is mostly compressed streams.
@@ -398,7 +416,12 @@ func LooksLikeText(buf []byte) bool {
 	// #271 exists for, and it keeps working. Nor does it stop PDFs being scanned: the router
 	// accepts them as "Binary document" (isBinaryDocument -> IsPDFFile) before this sniff is
 	// ever consulted, and the PDF text and metadata extractors have their own parsers.
-	if bytes.HasPrefix(buf, pdfMagic) {
+	//
+	// Parquet and Avro open with ASCII magic too ("PAR1", "Obj\x01"), and an Avro file goes on
+	// to spell its JSON schema in the clear, so a file with a long schema sniffs as text the
+	// same way. Scanning one as text would read its binary rows as noise, and redacting it as
+	// text would write a file no reader could open.
+	if bytes.HasPrefix(buf, pdfMagic) || textextractcolumnarlib.IsParquet(buf) || textextractcolumnarlib.IsAvro(buf) {
 		return false
 	}
 
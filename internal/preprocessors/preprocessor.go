@@ -5,6 +5,8 @@ package preprocessors
 
 import (
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/awslabs/ferret-scan/v2/internal/observability"
 )
@@ -104,6 +106,37 @@ type ContentSection struct {
 	// ProcessedContent.Text, so a finding's line number inside a section can be
 	// reported against the whole extracted document.
 	LineOffset int
+
+	// Pages locates page boundaries within Text for a paged document, in
+	// ascending Line order; nil for anything else. Lines are relative to this
+	// section, so the router's re-anchoring of LineOffset carries them along
+	// unchanged. See ProcessedContent.PageLookup.
+	Pages []PageSpan
+
+	// Cells locates the spreadsheet cell of every value within Text, in
+	// ascending Line order; nil for anything but a spreadsheet. Lines are
+	// relative to this section, like Pages. See ProcessedContent.CellLookup.
+	Cells []CellLine
+
+	// AttributeBody makes SourceFile the reported source of document-path
+	// findings on this section's lines, not only of metadata-path findings.
+	//
+	// The document path scans the whole extraction as one text, so without this
+	// a finding in a section is reported against the scanned file however
+	// precisely the section was labelled. It is opt-in rather than implied by
+	// SourceFile because the suppression identity includes the reported file
+	// name: turning it on for sections that already existed would move every
+	// finding in an embedded Office part to a new name and stop every rule
+	// written against it from matching. See ProcessedContent.SourceLookup.
+	AttributeBody bool
+}
+
+// PageSpan records that page Page of a document begins at line Line (0-based)
+// of a section's Text. A span with Page 0 marks where text that belongs to no
+// page begins.
+type PageSpan struct {
+	Page int
+	Line int
 }
 
 // SectionKind is the routing classification of a ContentSection.
@@ -443,3 +476,101 @@ func ShouldPreprocess(filePath string) bool {
 	// document preprocessor handles documents, and metadata preprocessor handles images/media
 	return true
 }
+
+// SourceLookup returns a function mapping a 1-based line of Text to the source
+// a document-path finding on it should be reported against, or "" when the line
+// lies outside every section that sets AttributeBody. It returns nil when no
+// section sets it, so callers can skip the pass entirely.
+func (pc *ProcessedContent) SourceLookup() func(line int) string {
+	type attributedSection struct {
+		start, end int // 0-based lines of Text, end exclusive
+		source     string
+	}
+	var attributed []attributedSection
+	for _, s := range pc.Sections {
+		if !s.AttributeBody || s.SourceFile == "" {
+			continue
+		}
+		// A trailing newline ends the section's last line; the line after it
+		// belongs to whatever follows.
+		lines := strings.Count(s.Text, "\n")
+		if !strings.HasSuffix(s.Text, "\n") {
+			lines++
+		}
+		attributed = append(attributed, attributedSection{
+			start:  s.LineOffset,
+			end:    s.LineOffset + lines,
+			source: s.SourceFile,
+		})
+	}
+	if len(attributed) == 0 {
+		return nil
+	}
+
+	return func(line int) string {
+		idx := line - 1
+		// Sections are declared in Text order, so the first one starting past
+		// the line ends the search.
+		i := sort.Search(len(attributed), func(i int) bool { return attributed[i].start > idx })
+		for i--; i >= 0; i-- {
+			if s := attributed[i]; idx < s.end {
+				return s.source
+			}
+		}
+		return ""
+	}
+}
+
+// PageLookup returns a function mapping a 1-based line of Text to the 1-based
+// page it was extracted from, or 0 when the line lies outside every paged
+// section. It returns nil when no section declares pages, so callers can skip
+// the pass entirely for unpaged content.
+//
+// Section line counts are computed once here rather than per lookup: a lookup
+// per finding over a multi-megabyte extraction would otherwise rescan the text
+// for every finding.
+func (pc *ProcessedContent) PageLookup() func(line int) int {
+	type pagedSection struct {
+		start, end int // 0-based lines of Text, end exclusive
+		pages      []PageSpan
+	}
+	var paged []pagedSection
+	for _, s := range pc.Sections {
+		if len(s.Pages) == 0 {
+			continue
+		}
+		// As in SourceLookup: a trailing newline ends the section's last line,
+		// and the line after it belongs to whatever follows. Counting it here
+		// gave the first line of a PDF item on page 2 the page of the item
+		// before it.
+		lines := strings.Count(s.Text, "\n")
+		if !strings.HasSuffix(s.Text, "\n") {
+			lines++
+		}
+		paged = append(paged, pagedSection{
+			start: s.LineOffset,
+			end:   s.LineOffset + lines,
+			pages: s.Pages,
+		})
+	}
+	if len(paged) == 0 {
+		return nil
+	}
+
+	return func(line int) int {
+		idx := line - 1
+		for _, s := range paged {
+			if idx < s.start || idx >= s.end {
+				continue
+			}
+			rel := idx - s.start
+			// The last span starting at or before rel holds the line.
+			i := sort.Search(len(s.pages), func(i int) bool { return s.pages[i].Line > rel })
+			if i == 0 {
+				return 0
+			}
+			return s.pages[i-1].Page
+		}
+		return 0
+	}
+}
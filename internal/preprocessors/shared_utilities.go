@@ -9,6 +9,7 @@ import (
 	"sort"
 	"strings"
 	"time"
+	"unicode"
 )
 
 // MetadataFormatter provides common metadata formatting functions
@@ -90,11 +91,16 @@ func (mf *MetadataFormatter) CalculateTextMetrics(text string) (wordCount, charC
 
 // FileExtensionValidator provides common file extension validation functions
 type FileExtensionValidator struct {
-	imageExtensions  map[string]bool
-	pdfExtensions    map[string]bool
-	officeExtensions map[string]bool
-	audioExtensions  map[string]bool
-	videoExtensions  map[string]bool
+	imageExtensions    map[string]bool
+	pdfExtensions      map[string]bool
+	officeExtensions   map[string]bool
+	audioExtensions    map[string]bool
+	videoExtensions    map[string]bool
+	emailExtensions    map[string]bool
+	markupExtensions   map[string]bool
+	sqliteExtensions   map[string]bool
+	columnarExtensions map[string]bool
+	notebookExtensions map[string]bool
 }
 
 // NewFileExtensionValidator creates a new file extension validator
@@ -109,6 +115,9 @@ func NewFileExtensionValidator() *FileExtensionValidator {
 			".gif":  true,
 			".bmp":  true,
 			".webp": true,
+			".heic": true,
+			".heif": true,
+			".avif": true,
 		},
 		pdfExtensions: map[string]bool{
 			".pdf": true,
@@ -133,11 +142,45 @@ func NewFileExtensionValidator() *FileExtensionValidator {
 			".flac": true,
 			".wav":  true,
 			".m4a":  true,
+			".ogg":  true,
+			".oga":  true,
+			".opus": true,
+			".aiff": true,
+			".aif":  true,
+			".aifc": true,
 		},
 		videoExtensions: map[string]bool{
-			".mp4": true,
-			".m4v": true,
-			".mov": true,
+			".mp4":  true,
+			".m4v":  true,
+			".mov":  true,
+			".mkv":  true,
+			".webm": true,
+			".avi":  true,
+		},
+		emailExtensions: map[string]bool{
+			".eml":  true,
+			".mbox": true,
+			".msg":  true,
+		},
+		markupExtensions: map[string]bool{
+			".html":  true,
+			".htm":   true,
+			".xhtml": true,
+			".xml":   true,
+			".rtf":   true,
+		},
+		sqliteExtensions: map[string]bool{
+			".sqlite":  true,
+			".sqlite3": true,
+			".db":      true,
+			".db3":     true,
+		},
+		columnarExtensions: map[string]bool{
+			".parquet": true,
+			".avro":    true,
+		},
+		notebookExtensions: map[string]bool{
+			".ipynb": true,
 		},
 	}
 }
@@ -172,6 +215,36 @@ func (fev *FileExtensionValidator) IsVideoFile(filePath string) bool {
 	return fev.videoExtensions[ext]
 }
 
+// IsEmailFile checks if the file is an email message or mailbox
+func (fev *FileExtensionValidator) IsEmailFile(filePath string) bool {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	return fev.emailExtensions[ext]
+}
+
+// IsMarkupFile checks if the file is an HTML, XML or RTF document
+func (fev *FileExtensionValidator) IsMarkupFile(filePath string) bool {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	return fev.markupExtensions[ext]
+}
+
+// IsSQLiteFile checks if the file has an SQLite database extension
+func (fev *FileExtensionValidator) IsSQLiteFile(filePath string) bool {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	return fev.sqliteExtensions[ext]
+}
+
+// IsColumnarFile checks if the file has a Parquet or Avro extension
+func (fev *FileExtensionValidator) IsColumnarFile(filePath string) bool {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	return fev.columnarExtensions[ext]
+}
+
+// IsNotebookFile checks if the file has a Jupyter notebook extension
+func (fev *FileExtensionValidator) IsNotebookFile(filePath string) bool {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	return fev.notebookExtensions[ext]
+}
+
 // GetImageExtensions returns all supported image extensions
 func (fev *FileExtensionValidator) GetImageExtensions() []string {
 	return fev.getExtensionsFromMap(fev.imageExtensions)
@@ -197,6 +270,31 @@ func (fev *FileExtensionValidator) GetVideoExtensions() []string {
 	return fev.getExtensionsFromMap(fev.videoExtensions)
 }
 
+// GetEmailExtensions returns all supported email extensions
+func (fev *FileExtensionValidator) GetEmailExtensions() []string {
+	return fev.getExtensionsFromMap(fev.emailExtensions)
+}
+
+// GetMarkupExtensions returns all supported markup extensions
+func (fev *FileExtensionValidator) GetMarkupExtensions() []string {
+	return fev.getExtensionsFromMap(fev.markupExtensions)
+}
+
+// GetSQLiteExtensions returns all supported SQLite database extensions
+func (fev *FileExtensionValidator) GetSQLiteExtensions() []string {
+	return fev.getExtensionsFromMap(fev.sqliteExtensions)
+}
+
+// GetColumnarExtensions returns all supported Parquet and Avro extensions
+func (fev *FileExtensionValidator) GetColumnarExtensions() []string {
+	return fev.getExtensionsFromMap(fev.columnarExtensions)
+}
+
+// GetNotebookExtensions returns all supported Jupyter notebook extensions
+func (fev *FileExtensionValidator) GetNotebookExtensions() []string {
+	return fev.getExtensionsFromMap(fev.notebookExtensions)
+}
+
 // getExtensionsFromMap converts a map of extensions to a slice
 func (fev *FileExtensionValidator) getExtensionsFromMap(extMap map[string]bool) []string {
 	var extensions []string
@@ -277,6 +375,33 @@ func (rih *RouterIntegrationHelper) CreateEmbeddedMediaPath(originalFilePath, em
 	return fmt.Sprintf("%s -> %s", filepath.Base(originalFilePath), embeddedFileName)
 }
 
+// CreateItemPath labels one item of a document, such as a form field or a
+// comment, the way embedded media is labelled: "form.pdf -> field:applicant_ssn".
+// kind is the item's prefix, "field:" for instance, and may be empty.
+//
+// The name is producer-controlled and becomes the FILE column of every report
+// and part of the finding's suppression identity, so control characters are
+// dropped, path separators are replaced (the identity hashes the base name of
+// the reported file) and the length is capped.
+func (rih *RouterIntegrationHelper) CreateItemPath(originalFilePath, kind, name string) string {
+	const maxLabelRunes = 64
+	var clean []rune
+	for _, r := range name {
+		switch {
+		case r == '/' || r == '\\':
+			clean = append(clean, '_')
+		case unicode.IsControl(r):
+			continue
+		default:
+			clean = append(clean, r)
+		}
+	}
+	if len(clean) > maxLabelRunes {
+		clean = append(clean[:maxLabelRunes], []rune("...")...)
+	}
+	return rih.CreateEmbeddedMediaPath(originalFilePath, kind+string(clean))
+}
+
 // FormatEmbeddedMediaSection formats embedded media content for inclusion in
 // metadata text.
 //
@@ -0,0 +1,136 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package preprocessors
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/awslabs/ferret-scan/v2/internal/observability"
+	textextractsqlitelib "github.com/awslabs/ferret-scan/v2/internal/preprocessors/text-extractors/text-extract-sqlitelib"
+)
+
+// SQLitePreprocessor extracts the tables of an SQLite database.
+//
+// Mobile app backups and local application caches are SQLite files, and the
+// router treated them as unsupported binary. Each table is extracted the way a
+// spreadsheet sheet is, a "--- name ---" heading, a header row of column names
+// and a line per row, so a column name labels the values beneath it for the
+// label-gated validators exactly as a CSV header does. Every row line records
+// its row and columns, and a finding in it is reported against the field it was
+// read from: "app.db -> users[rowid=42].ssn".
+type SQLitePreprocessor struct {
+	*BaseMetadataPreprocessor
+}
+
+// NewSQLitePreprocessor creates a new SQLite preprocessor
+func NewSQLitePreprocessor() *SQLitePreprocessor {
+	return &SQLitePreprocessor{
+		BaseMetadataPreprocessor: NewBaseMetadataPreprocessor("sqlite", "sqlite"),
+	}
+}
+
+// CanProcess checks if this preprocessor can handle the given file
+func (sp *SQLitePreprocessor) CanProcess(filePath string) bool {
+	return IsSQLiteDatabase(filePath)
+}
+
+// IsSQLiteDatabase reports whether a file has an SQLite extension and begins
+// with the SQLite header. ".db" is a name many formats use (a Windows
+// Thumbs.db is a compound file), so the name alone does not decide; a file the
+// header rules out is left to whatever would have handled it before.
+func IsSQLiteDatabase(filePath string) bool {
+	if !mediaExtValidator.IsSQLiteFile(filePath) {
+		return false
+	}
+	f, err := os.Open(filepath.Clean(filePath)) // #nosec G304 -- path vetted by the router
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	head := make([]byte, len(textextractsqlitelib.Magic))
+	if _, err := io.ReadFull(f, head); err != nil {
+		return false
+	}
+	return textextractsqlitelib.IsSQLite(head)
+}
+
+// Process extracts the text of every table of an SQLite database
+func (sp *SQLitePreprocessor) Process(filePath string) (*ProcessedContent, error) {
+	return sp.ProcessWithRetry(filePath, func() (*ProcessedContent, error) {
+		return sp.processSQLite(filePath)
+	})
+}
+
+// processSQLite builds the section-per-table text for one database.
+func (sp *SQLitePreprocessor) processSQLite(filePath string) (*ProcessedContent, error) {
+	if err := sp.ValidateFileSize(filePath, false); err != nil {
+		return sp.HandleError(filePath, "sqlite", err), err
+	}
+
+	// The database is read a page at a time, not loaded whole.
+	f, err := os.Open(filepath.Clean(filePath)) // #nosec G304 -- path vetted by the router
+	if err != nil {
+		err = fmt.Errorf("failed to read file: %w", err)
+		return sp.BuildErrorContent(filePath, "sqlite", err), err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		err = fmt.Errorf("failed to read file: %w", err)
+		return sp.BuildErrorContent(filePath, "sqlite", err), err
+	}
+	ex, err := textextractsqlitelib.ExtractReader(f, info.Size())
+	if err != nil {
+		err = fmt.Errorf("failed to read database: %w", err)
+		return sp.BuildErrorContent(filePath, "sqlite", err), err
+	}
+
+	sections := make([]ContentSection, 0, len(ex.Tables))
+	for _, t := range ex.Tables {
+		cells := make([]CellLine, len(t.Rows))
+		for i, r := range t.Rows {
+			pos := make([]CellPos, len(r.Offsets))
+			for c, off := range r.Offsets {
+				pos[c] = CellPos{Offset: off, Row: r.Line, Column: c + 1}
+			}
+			// Fields shares one slice per table; a row carries only its label.
+			cells[i] = CellLine{Line: r.Line, Sheet: t.Name, Cells: pos, Record: r.Label, Fields: t.Columns}
+		}
+		sections = append(sections, ContentSection{
+			Name:       "sqlite",
+			Kind:       SectionKindBody,
+			Text:       t.Text,
+			LineOffset: t.Line,
+			Cells:      cells,
+		})
+	}
+
+	warnings := ex.Notes
+	// Changes not yet checkpointed live in the write-ahead log beside the
+	// database, not in the file read here.
+	if info, err := os.Stat(filePath + "-wal"); err == nil && info.Size() > 0 {
+		warnings = append(warnings, fmt.Sprintf("write-ahead log %s not scanned: checkpoint the database to include it",
+			filepath.Base(filePath)+"-wal"))
+	}
+
+	content := sp.BuildSuccessContent(filePath, ex.Text, "sqlite", 0)
+	content.Sections = sections
+	content.Metadata["table_count"] = len(ex.Tables)
+	content.ExtractionWarning = strings.Join(warnings, "; ")
+	return content, nil
+}
+
+// GetSupportedExtensions returns the file extensions this preprocessor supports
+func (sp *SQLitePreprocessor) GetSupportedExtensions() []string {
+	return sp.GetUtilities().ExtensionValidator.GetSQLiteExtensions()
+}
+
+// SetObserver sets the observability component
+func (sp *SQLitePreprocessor) SetObserver(observer observability.Observer) {
+	sp.BaseMetadataPreprocessor.SetObserver(observer)
+}
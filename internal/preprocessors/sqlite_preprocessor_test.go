@@ -0,0 +1,64 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package preprocessors
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// A finding in a database row is named by its row and column; any other
+// position names no field.
+func TestRecordLookup(t *testing.T) {
+	text := "--- users ---\nid\tssn\n42\t536-22-1874\n"
+	fields := []string{"id", "ssn"}
+	pc := &ProcessedContent{Sections: []ContentSection{
+		{Text: text, Cells: []CellLine{
+			{Line: 2, Sheet: "users", Cells: []CellPos{{Offset: 0, Row: 3, Column: 1}, {Offset: 3, Row: 3, Column: 2}},
+				Record: "users[rowid=42]", Fields: fields},
+		}},
+	}}
+	fieldOf := pc.RecordLookup()
+	for _, c := range []struct {
+		line, column int
+		value, want  string
+	}{
+		{3, 4, "536-22-1874", "users[rowid=42].ssn"},
+		{3, 1, "42", "users[rowid=42].id"},
+		{3, 4, "449-87-4100", ""},
+		{2, 4, "ssn", ""},
+	} {
+		if got := fieldOf(c.line, c.column, c.value); got != c.want {
+			t.Errorf("fieldOf(%d, %d, %q) = %q, want %q", c.line, c.column, c.value, got, c.want)
+		}
+	}
+	// A database row is not a spreadsheet cell.
+	if got := pc.CellLookup()(3, 4, "536-22-1874"); got != "" {
+		t.Errorf("CellLookup named %q", got)
+	}
+}
+
+// ".db" alone does not make a database: a text file so named is left to the
+// plaintext preprocessor.
+func TestSQLiteNeedsHeader(t *testing.T) {
+	dir := t.TempDir()
+	text := filepath.Join(dir, "notes.db")
+	db := filepath.Join(dir, "app.sqlite")
+	if err := os.WriteFile(text, []byte("ssn 536-22-1874\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(db, append([]byte("SQLite format 3\x00"), make([]byte, 100)...), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if IsSQLiteDatabase(text) {
+		t.Error("a text file named .db was taken for a database")
+	}
+	if !NewPlainTextPreprocessor().CanProcess(text) {
+		t.Error("the plaintext preprocessor declined a text file named .db")
+	}
+	if !IsSQLiteDatabase(db) {
+		t.Error("a file with the SQLite header was declined")
+	}
+}
@@ -0,0 +1,473 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package textextractbarcodelib finds the two-dimensional barcodes in an image
+// and decodes them: QR codes, Data Matrix, Aztec and PDF417 symbols.
+//
+// A barcode is text that no other extractor sees. The OTP validator was written
+// for screenshots of authenticator setup pages, and the secret on such a page is
+// in the QR code, not in the words around it; a shipping label or a boarding
+// pass carries a name and a booking reference the same way. Each symbol's
+// payload is returned with the rectangle it occupies, so the scanner can report
+// a finding against the symbol it came from and the redactor can black that
+// symbol out.
+//
+// PDF417 is the symbology of North American driving licences and most printed
+// boarding passes. The decoder this package is built on has no PDF417 reader,
+// so this package has its own (pdf417.go).
+package textextractbarcodelib
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	// Decoders for every image format the scanner admits, registered for
+	// image.Decode. HEIF and AVIF have no pure-Go decoder and are not read.
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/aztec"
+	"github.com/makiuchi-d/gozxing/datamatrix"
+	multidetector "github.com/makiuchi-d/gozxing/multi/qrcode/detector"
+	qrdecoder "github.com/makiuchi-d/gozxing/qrcode/decoder"
+)
+
+// Format is a barcode symbology, spelled the way it appears in a finding's
+// source label.
+type Format string
+
+const (
+	// FormatQR is a QR code, model 2, including mirrored symbols.
+	FormatQR Format = "qr"
+	// FormatDataMatrix is an ECC 200 Data Matrix symbol.
+	FormatDataMatrix Format = "datamatrix"
+	// FormatAztec is a compact or full-range Aztec symbol.
+	FormatAztec Format = "aztec"
+	// FormatPDF417 is a PDF417 symbol.
+	FormatPDF417 Format = "pdf417"
+)
+
+// formats is the order symbols are searched for and reported in.
+var formats = []Format{FormatQR, FormatDataMatrix, FormatAztec, FormatPDF417}
+
+// MaxSymbols bounds how many symbols of one format are read from one image. A
+// sheet of labels can carry dozens; past this the image is not a document a
+// person reads but a symbol grid, and every further symbol costs another full
+// pass over the pixels.
+const MaxSymbols = 64
+
+// MaxPixels bounds the declared width x height of an image this package
+// decodes.
+//
+// Finding a symbol means decoding every pixel, and the scan of an image never
+// did that before: it read metadata only. The budget is 32M pixels, which
+// covers every phone camera and screenshot in use, at roughly 3 bytes a pixel
+// for the decoded image, its luminance and the binarized bits. An image past it
+// is refused with ErrTooLarge, which callers disclose rather than treating as
+// an image without symbols.
+//
+// A var rather than a const only so a test can lower it.
+var MaxPixels int64 = 1 << 25
+
+// ErrTooLarge reports an image whose declared size is past MaxPixels.
+var ErrTooLarge = errors.New("image is too large to search for barcodes")
+
+// Symbol is one decoded barcode.
+type Symbol struct {
+	// Format is the symbology.
+	Format Format
+	// Index is the symbol's position among the symbols of its format, in
+	// reading order: top to bottom, then left to right.
+	Index int
+	// Text is the decoded payload.
+	Text string
+	// Bounds covers the whole symbol, with a margin of at least one module, in
+	// the coordinates of the decoded image.
+	Bounds image.Rectangle
+}
+
+// Label names the symbol within its image: "qr[0]", "datamatrix[1]".
+func (s Symbol) Label() string {
+	return fmt.Sprintf("%s[%d]", s.Format, s.Index)
+}
+
+// ScanText is the payload as lines of text, the form it is scanned in. GS1
+// element strings and AAMVA driver's licence records separate their fields
+// with control characters (GS, RS, the ASCII separators); left in place, a date
+// of birth and a licence number run together into one token no validator reads.
+func (s Symbol) ScanText() string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && r != '\n' && r != '\t' {
+			return '\n'
+		}
+		return r
+	}, s.Text)
+}
+
+// labelPattern matches a symbol label, "qr[0]".
+var labelPattern = regexp.MustCompile(`^(qr|datamatrix|aztec|pdf417)\[[0-9]+\]$`)
+
+// LabelOf returns the symbol label that ends a finding's source,
+// "screenshot.png -> qr[0]", and whether it has one.
+func LabelOf(source string) (string, bool) {
+	i := strings.LastIndex(source, " -> ")
+	if i < 0 || !labelPattern.MatchString(source[i+4:]) {
+		return "", false
+	}
+	return source[i+4:], true
+}
+
+// DecodeFile decodes the image at path and returns its symbols. A file that is
+// not an image any registered decoder reads returns no symbols and no error:
+// the metadata extractor already reports an unreadable image, and this package
+// is one more reader of the same file.
+func DecodeFile(path string) ([]Symbol, error) {
+	data, err := os.ReadFile(filepath.Clean(path)) // #nosec G304 -- path vetted by the caller
+	if err != nil {
+		return nil, err
+	}
+	return DecodeBytes(data)
+}
+
+// DecodeBytes is DecodeFile for an image already in memory, such as a part of
+// an Office document.
+func DecodeBytes(data []byte) ([]Symbol, error) {
+	img, err := DecodeImage(data)
+	if err != nil || img == nil {
+		return nil, err
+	}
+	return Decode(img), nil
+}
+
+// DecodeImage decodes an image's pixels after checking its declared size
+// against MaxPixels. It returns nil and no error for bytes no registered
+// decoder recognises.
+func DecodeImage(data []byte) (image.Image, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil
+	}
+	if px := int64(cfg.Width) * int64(cfg.Height); px > MaxPixels {
+		return nil, fmt.Errorf("%w: %dx%d is %d pixels, over the %d-pixel budget",
+			ErrTooLarge, cfg.Width, cfg.Height, px, MaxPixels)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil
+	}
+	return img, nil
+}
+
+// Decode finds and decodes every symbol in img. The result is in a fixed order,
+// QR codes first, and Index is assigned from that order, so the same pixels
+// always give the same labels: a label is part of a finding's suppression
+// identity.
+//
+// The Data Matrix and Aztec detectors look for one symbol across the whole
+// image, and a QR code already read is exactly the kind of dense square that
+// draws them away from a real one, so each format searches an image with the
+// symbols of the formats before it painted out.
+func Decode(img image.Image) []Symbol {
+	work := cloneGray(toGray(img))
+	origin := img.Bounds().Min
+	var out []Symbol
+	for _, f := range formats {
+		found := decodeFormat(work, f)
+		sort.SliceStable(found, func(i, j int) bool {
+			a, b := found[i].Bounds.Min, found[j].Bounds.Min
+			if a.Y != b.Y {
+				return a.Y < b.Y
+			}
+			return a.X < b.X
+		})
+		for i := range found {
+			found[i].Index = i
+			found[i].Bounds = found[i].Bounds.Add(origin)
+		}
+		out = append(out, found...)
+	}
+	return out
+}
+
+// decodeFormat reads every symbol of one format. The readers find one symbol
+// per pass (the QR reader a handful), so each symbol found is painted white in
+// work and the search repeats until a pass finds nothing new. work is left
+// with every symbol found painted out.
+func decodeFormat(work *image.Gray, f Format) []Symbol {
+	var found []Symbol
+	for len(found) < MaxSymbols {
+		pass := readOnce(work, f)
+		added := false
+		for _, s := range pass {
+			if len(found) >= MaxSymbols || overlapsFound(found, s) {
+				continue
+			}
+			found = append(found, s)
+			added = true
+		}
+		if !added {
+			break
+		}
+		for _, s := range found {
+			draw.Draw(work, s.Bounds, image.White, image.Point{}, draw.Src)
+		}
+	}
+	return found
+}
+
+// overlapsFound reports whether s is a symbol already read: the mask painted
+// over a symbol is its bounds, so a second read of the same payload inside them
+// means the mask fell short, not that there are two symbols.
+func overlapsFound(found []Symbol, s Symbol) bool {
+	for _, f := range found {
+		if f.Text == s.Text && f.Bounds.Overlaps(s.Bounds) {
+			return true
+		}
+	}
+	return false
+}
+
+// hints asks every reader to try harder: a QR finder otherwise samples every
+// few rows, and a small symbol in a large photograph falls between them.
+var hints = map[gozxing.DecodeHintType]interface{}{
+	gozxing.DecodeHintType_TRY_HARDER: true,
+}
+
+// readOnce runs one reader over img.
+//
+// The Data Matrix and Aztec detectors grow a search rectangle outwards from the
+// centre of what they are given and settle on the first symbol they meet, so a
+// symbol towards the edge of a screenshot is never reached from the centre of
+// the whole image. When the whole image gives nothing they are run again over
+// overlapping windows, so that every part of the image is near the centre of
+// one of them.
+func readOnce(img *image.Gray, f Format) []Symbol {
+	whole := img.Bounds()
+	if f == FormatQR || f == FormatPDF417 {
+		return readWindow(img, f, whole)
+	}
+	if out := readWindow(img, f, whole); len(out) > 0 {
+		return out
+	}
+	for _, div := range []int{2, 3} {
+		w, h := whole.Dx()/div, whole.Dy()/div
+		if w < 32 || h < 32 {
+			break
+		}
+		for y := 0; y+h <= whole.Dy(); y += h / 2 {
+			for x := 0; x+w <= whole.Dx(); x += w / 2 {
+				if out := readWindow(img, f, image.Rect(x, y, x+w, y+h)); len(out) > 0 {
+					return out
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// readWindow runs one reader over the window of img, reporting bounds in img's
+// coordinates.
+//
+// The readers panic on some malformed input rather than returning an error. An
+// image is producer-controlled, so a panic here is recovered and read as "no
+// symbol found" rather than failing the file, whose metadata is still worth
+// scanning.
+func readWindow(img *image.Gray, f Format, window image.Rectangle) (out []Symbol) {
+	defer func() {
+		if recover() != nil {
+			out = nil
+		}
+	}()
+	bounds := img.Bounds()
+	src, err := gozxing.NewPlanarYUVLuminanceSource(img.Pix, bounds.Dx(), bounds.Dy(),
+		window.Min.X, window.Min.Y, window.Dx(), window.Dy(), false)
+	if err != nil {
+		return nil
+	}
+	bmp, err := gozxing.NewBinaryBitmap(gozxing.NewHybridBinarizer(src))
+	if err != nil {
+		return nil
+	}
+	switch f {
+	case FormatQR:
+		matrix, err := bmp.GetBlackMatrix()
+		if err != nil {
+			return nil
+		}
+		results, err := multidetector.NewMultiDetector(matrix).DetectMulti(hints)
+		if err != nil {
+			return nil
+		}
+		for _, r := range results {
+			decoded, err := qrdecoder.NewDecoder().Decode(r.GetBits(), hints)
+			if err != nil {
+				continue
+			}
+			points := r.GetPoints()
+			if len(points) < 3 {
+				continue
+			}
+			out = append(out, Symbol{
+				Format: f,
+				Text:   decoded.GetText(),
+				Bounds: qrBounds(points, r.GetBits().GetWidth()).Add(window.Min).Intersect(bounds),
+			})
+		}
+	case FormatDataMatrix, FormatAztec:
+		var result *gozxing.Result
+		var err error
+		if f == FormatDataMatrix {
+			result, err = datamatrix.NewDataMatrixReader().Decode(bmp, hints)
+		} else {
+			result, err = aztec.NewAztecReader().Decode(bmp, hints)
+		}
+		if err != nil || len(result.GetResultPoints()) < 3 {
+			return nil
+		}
+		out = append(out, Symbol{
+			Format: f,
+			Text:   result.GetText(),
+			Bounds: cornerBounds(result.GetResultPoints()).Add(window.Min).Intersect(bounds),
+		})
+	case FormatPDF417:
+		matrix, err := bmp.GetBlackMatrix()
+		if err != nil {
+			return nil
+		}
+		for _, s := range decodePDF417(matrix) {
+			s.Bounds = s.Bounds.Add(window.Min).Intersect(bounds)
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// qrBounds is the rectangle covering a QR symbol from the centres of its three
+// finder patterns and its size in modules.
+//
+// A finder centre lies 3.5 modules inside the symbol's edge, so the symbol is
+// those three points pushed outwards along the two module axes, plus the
+// fourth corner the finders imply. One more module is added all round: the
+// mask must cover the whole symbol even when the estimate is a little short,
+// because a sliver of a QR code left behind can be enough for it to decode.
+func qrBounds(points []gozxing.ResultPoint, dimension int) image.Rectangle {
+	bl, tl, tr := points[0], points[1], points[2]
+	span := float64(dimension - 7)
+	if span <= 0 {
+		return cornerBounds(points[:3])
+	}
+	ux, uy := (tr.GetX()-tl.GetX())/span, (tr.GetY()-tl.GetY())/span
+	vx, vy := (bl.GetX()-tl.GetX())/span, (bl.GetY()-tl.GetY())/span
+	const margin = 4.5 // 3.5 modules to the edge, one beyond it
+	far := float64(dimension) - 7 + margin
+	var xs, ys []float64
+	for _, c := range [][2]float64{{-margin, -margin}, {far, -margin}, {-margin, far}, {far, far}} {
+		xs = append(xs, tl.GetX()+c[0]*ux+c[1]*vx)
+		ys = append(ys, tl.GetY()+c[0]*uy+c[1]*vy)
+	}
+	return rectOf(xs, ys, 0)
+}
+
+// cornerBounds is the rectangle covering a Data Matrix or Aztec symbol from its
+// corner points. The readers report the centres of the corner modules, and
+// the smallest symbols are 10 (Data Matrix) and 15 (Aztec) modules across, so a
+// margin of a tenth of the larger side is always at least a module.
+func cornerBounds(points []gozxing.ResultPoint) image.Rectangle {
+	var xs, ys []float64
+	for _, p := range points {
+		xs = append(xs, p.GetX())
+		ys = append(ys, p.GetY())
+	}
+	r := rectOf(xs, ys, 0)
+	side := r.Dx()
+	if r.Dy() > side {
+		side = r.Dy()
+	}
+	return r.Inset(-(side/10 + 2))
+}
+
+// rectOf is the smallest integer rectangle holding every point, grown by pad.
+func rectOf(xs, ys []float64, pad int) image.Rectangle {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for i := range xs {
+		minX, maxX = math.Min(minX, xs[i]), math.Max(maxX, xs[i])
+		minY, maxY = math.Min(minY, ys[i]), math.Max(maxY, ys[i])
+	}
+	return image.Rect(int(math.Floor(minX)), int(math.Floor(minY)),
+		int(math.Ceil(maxX))+1, int(math.Ceil(maxY))+1).Inset(-pad)
+}
+
+// toGray converts img to an 8-bit luminance plane with its origin at (0, 0)
+// and no row padding, the layout the readers take without copying. It is done
+// once, so the repeated passes do not each convert the image again.
+//
+// Transparent pixels are composited over white, as a viewer shows them: a QR
+// code drawn on a transparent background is dark modules on nothing, and
+// reading nothing as black would leave no contrast to find.
+//
+// The common decoded types are read from their pixel buffers directly; going
+// through At costs an interface call and an allocation per pixel, which on a
+// phone photograph is most of the time spent here.
+func toGray(img image.Image) *image.Gray {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if g, ok := img.(*image.Gray); ok && b.Min == (image.Point{}) && g.Stride == w {
+		return g
+	}
+	g := image.NewGray(image.Rect(0, 0, w, h))
+	switch src := img.(type) {
+	case *image.YCbCr:
+		for y := 0; y < h; y++ {
+			row := src.YOffset(b.Min.X, b.Min.Y+y)
+			copy(g.Pix[y*w:(y+1)*w], src.Y[row:row+w])
+		}
+	case *image.Gray:
+		for y := 0; y < h; y++ {
+			row := src.PixOffset(b.Min.X, b.Min.Y+y)
+			copy(g.Pix[y*w:(y+1)*w], src.Pix[row:row+w])
+		}
+	case *image.NRGBA:
+		for y := 0; y < h; y++ {
+			row := src.PixOffset(b.Min.X, b.Min.Y+y)
+			for x := 0; x < w; x++ {
+				p := src.Pix[row+4*x : row+4*x+4]
+				a := uint32(p[3])
+				lum := (uint32(p[0]) + 2*uint32(p[1]) + uint32(p[2])) / 4
+				g.Pix[y*w+x] = uint8((lum*a + 255*(255-a)) / 255)
+			}
+		}
+	default:
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				r, gg, bb, a := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+				// Premultiplied, so compositing over white adds the uncovered share of white.
+				lum := (r + 2*gg + bb) / 4
+				g.Pix[y*w+x] = uint8((lum + (0xffff - a)) >> 8)
+			}
+		}
+	}
+	return g
+}
+
+func cloneGray(g *image.Gray) *image.Gray {
+	c := image.NewGray(g.Bounds())
+	copy(c.Pix, g.Pix)
+	return c
+}
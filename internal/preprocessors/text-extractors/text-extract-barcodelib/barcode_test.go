@@ -0,0 +1,141 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package textextractbarcodelib
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"testing"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/datamatrix"
+	"github.com/makiuchi-d/gozxing/qrcode"
+)
+
+const otpURI = "otpauth://totp/Example:alice@example.com?secret=JBSWY3DPEHPK3PXP&issuer=Example"
+
+// symbolImage renders one symbol at size x size pixels with its quiet zone.
+func symbolImage(t *testing.T, format gozxing.BarcodeFormat, text string, size int) *gozxing.BitMatrix {
+	t.Helper()
+	var w gozxing.Writer = qrcode.NewQRCodeWriter()
+	if format == gozxing.BarcodeFormat_DATA_MATRIX {
+		w = datamatrix.NewDataMatrixWriter()
+	}
+	m, err := w.Encode(text, format, size, size, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// inkBounds is the rectangle of the dark pixels of a rendered symbol: the
+// symbol itself, without its quiet zone.
+func inkBounds(m *gozxing.BitMatrix, at image.Point) image.Rectangle {
+	var r image.Rectangle
+	for y := 0; y < m.GetHeight(); y++ {
+		for x := 0; x < m.GetWidth(); x++ {
+			if m.Get(x, y) {
+				r = r.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return r.Add(at)
+}
+
+// canvas is a white image of the given size.
+func canvas(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	return img
+}
+
+func TestDecodeReadsEverySymbolInReadingOrder(t *testing.T) {
+	img := canvas(900, 700)
+	lower := symbolImage(t, gozxing.BarcodeFormat_QR_CODE, "second: 078-05-1120", 240)
+	upper := symbolImage(t, gozxing.BarcodeFormat_QR_CODE, otpURI, 300)
+	dm := symbolImage(t, gozxing.BarcodeFormat_DATA_MATRIX, "LOT 4471 SN 88213", 160)
+	lowerAt, upperAt, dmAt := image.Pt(40, 400), image.Pt(520, 30), image.Pt(620, 480)
+	draw.Draw(img, lower.Bounds().Add(lowerAt), lower, image.Point{}, draw.Src)
+	draw.Draw(img, upper.Bounds().Add(upperAt), upper, image.Point{}, draw.Src)
+	draw.Draw(img, dm.Bounds().Add(dmAt), dm, image.Point{}, draw.Src)
+
+	got := Decode(img)
+	if len(got) != 3 {
+		t.Fatalf("Decode found %d symbols, want 3: %+v", len(got), got)
+	}
+	want := []struct {
+		label, text string
+		ink         image.Rectangle
+	}{
+		{"qr[0]", otpURI, inkBounds(upper, upperAt)},
+		{"qr[1]", "second: 078-05-1120", inkBounds(lower, lowerAt)},
+		{"datamatrix[0]", "LOT 4471 SN 88213", inkBounds(dm, dmAt)},
+	}
+	for i, w := range want {
+		s := got[i]
+		if s.Label() != w.label || s.Text != w.text {
+			t.Errorf("symbol %d = %s %q, want %s %q", i, s.Label(), s.Text, w.label, w.text)
+		}
+		if !w.ink.In(s.Bounds) {
+			t.Errorf("%s: bounds %v do not cover the symbol at %v", w.label, s.Bounds, w.ink)
+		}
+		// Covering the symbol must not mean covering the page: a margin of a
+		// few modules, not a multiple of the symbol's size.
+		if s.Bounds.Dx() > w.ink.Dx()*3/2 || s.Bounds.Dy() > w.ink.Dy()*3/2 {
+			t.Errorf("%s: bounds %v are far larger than the symbol at %v", w.label, s.Bounds, w.ink)
+		}
+	}
+
+	// Painting the bounds out leaves nothing to read.
+	for _, s := range got {
+		draw.Draw(img, s.Bounds, image.Black, image.Point{}, draw.Src)
+	}
+	if left := Decode(img); len(left) != 0 {
+		t.Errorf("symbols still decode after their bounds were painted out: %+v", left)
+	}
+}
+
+// TestDecodeCompositesTransparencyOverWhite: a QR code exported with a
+// transparent background is dark modules on alpha 0. Read as black, the
+// background has no contrast with the modules and nothing decodes.
+func TestDecodeCompositesTransparencyOverWhite(t *testing.T) {
+	m := symbolImage(t, gozxing.BarcodeFormat_QR_CODE, otpURI, 200)
+	img := image.NewNRGBA(m.Bounds())
+	for y := 0; y < m.GetHeight(); y++ {
+		for x := 0; x < m.GetWidth(); x++ {
+			if m.Get(x, y) {
+				img.SetNRGBA(x, y, color.NRGBA{A: 255})
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	got, err := DecodeBytes(buf.Bytes())
+	if err != nil || len(got) != 1 || got[0].Text != otpURI {
+		t.Fatalf("DecodeBytes = %+v, %v", got, err)
+	}
+}
+
+func TestDecodeBytesRefusesAnImageOverTheBudget(t *testing.T) {
+	saved := MaxPixels
+	MaxPixels = 100 * 100
+	defer func() { MaxPixels = saved }()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, canvas(200, 200)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecodeBytes(buf.Bytes()); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("DecodeBytes over the budget = %v, want ErrTooLarge", err)
+	}
+	if got, err := DecodeBytes([]byte("not an image")); got != nil || err != nil {
+		t.Errorf("DecodeBytes(text) = %+v, %v; want nothing", got, err)
+	}
+}
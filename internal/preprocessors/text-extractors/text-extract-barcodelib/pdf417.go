@@ -0,0 +1,992 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package textextractbarcodelib
+
+import (
+	"image"
+	"math"
+	"math/big"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/makiuchi-d/gozxing"
+)
+
+// PDF417 is read here rather than by gozxing, which has no PDF417 reader.
+//
+// A PDF417 symbol is a stack of rows, each a start pattern, a left row
+// indicator, one to thirty data codewords, a right row indicator and a stop
+// pattern, every codeword 17 modules of four bars and four spaces. The reader
+// follows the symbol's own design rather than locating its corners: every
+// pixel row of the binarized image is read as a sequence of bar widths, a
+// start pattern anchors a run of codewords, and each codeword names its
+// cluster (its row modulo 3) and its value. The row indicators give the row
+// number and the symbol's rows, columns and error correction level, so each
+// line's codewords are filed under a row and a column of the codeword matrix
+// and the lines crossing the same cell vote on its value. A symbol is reported
+// only when the matrix passes its Reed-Solomon check, which also repairs the
+// cells no line read.
+//
+// Each line is read in both directions: from a start pattern, and from a stop
+// pattern back towards the start, which recovers the far side of a row whose
+// middle is scratched or folded and is also how a symbol turned half round is
+// read. The columns of the image are read the same way when its rows give no
+// symbol, for a symbol on its side. A line may cross from one row of a skewed
+// symbol into the next, and does so at a change of cluster, so the row is
+// followed along the line rather than fixed by its first codeword; past a
+// skew of a few degrees a line crosses rows faster than codewords and the
+// symbol is not read. MicroPDF417, a separate symbology, is not read either.
+
+// pdf417Start and pdf417Stop are the widths, in modules, of the bars and
+// spaces of the start and stop patterns, bar first.
+var (
+	pdf417Start = []int{8, 1, 1, 1, 1, 1, 1, 3}
+	pdf417Stop  = []int{7, 1, 1, 3, 1, 1, 1, 2, 1}
+	// pdf417StopBack is the stop pattern read from its far end.
+	pdf417StopBack = []int{1, 2, 1, 1, 1, 3, 1, 1, 7}
+)
+
+// pdf417Word is one codeword read from a line: its cluster (0, 1 or 2, the row
+// modulo 3) and its value. value is -1 for bars that are no codeword.
+type pdf417Word struct {
+	cluster, value int
+}
+
+// pdf417Lookup maps a codeword's 17-module pattern to its cluster and value.
+var pdf417Lookup = func() map[uint32]pdf417Word {
+	m := make(map[uint32]pdf417Word, 3*929)
+	for c := range pdf417Patterns {
+		for v, p := range pdf417Patterns[c] {
+			m[p] = pdf417Word{cluster: c, value: v}
+		}
+	}
+	return m
+}()
+
+// pdf417Line is the run of codewords read from one scan line after a start
+// pattern or, read towards the start, before a stop pattern.
+type pdf417Line struct {
+	line int
+	// from and to are the extent of the patterns and codewords read.
+	from, to int
+	module   float64
+	// reversed is set for a line read from its far end. from and to are in
+	// the line's own coordinates all the same.
+	reversed bool
+	// fromStop is set for a line read from a stop pattern.
+	fromStop bool
+	// words begins with the row indicator beside the pattern the line was
+	// read from. When ended, the line reached the pattern at the other end of
+	// the row and its last word is the other row indicator.
+	words []pdf417Word
+	ended bool
+}
+
+// decodePDF417 reads every PDF417 symbol in matrix. Bounds are in matrix
+// coordinates and cover the whole symbol with a margin of a row and two
+// modules.
+func decodePDF417(matrix *gozxing.BitMatrix) []Symbol {
+	w, h := matrix.GetWidth(), matrix.GetHeight()
+	out := scanPDF417(w, h, func(along, line int) bool { return matrix.Get(along, line) },
+		func(line, from, to int) image.Rectangle { return image.Rect(from, line, to, line+1) })
+	if len(out) > 0 {
+		return out
+	}
+	return scanPDF417(h, w, func(along, line int) bool { return matrix.Get(line, along) },
+		func(line, from, to int) image.Rectangle { return image.Rect(line, from, line+1, to) })
+}
+
+// scanPDF417 reads the symbols whose rows run along the lines of a length x
+// count grid. black reports a pixel; place maps a span of a line back to the
+// image.
+func scanPDF417(length, count int, black func(along, line int) bool,
+	place func(line, from, to int) image.Rectangle) []Symbol {
+	var groups []*pdf417Group
+	var runs []int
+	for line := 0; line < count; line++ {
+		runs = pdf417Runs(runs[:0], length, func(i int) bool { return black(i, line) })
+		for dir, rs := range [][]int{runs, reverseRuns(runs)} {
+			for _, l := range readPDF417Runs(rs) {
+				l.line = line
+				if dir == 1 {
+					l.from, l.to, l.reversed = length-l.to, length-l.from, true
+				}
+				groups = addPDF417Line(groups, l)
+			}
+		}
+	}
+	var out []Symbol
+	for _, g := range mergePDF417Groups(groups) {
+		if text, bounds, ok := g.decode(place); ok {
+			out = append(out, Symbol{Format: FormatPDF417, Text: text, Bounds: bounds})
+		}
+	}
+	return out
+}
+
+// pdf417Runs appends to runs the widths of the alternating spaces and bars of
+// a line of n pixels, a space first (of width 0 when the line starts black).
+func pdf417Runs(runs []int, n int, black func(int) bool) []int {
+	cur, width := false, 0
+	for i := 0; i < n; i++ {
+		if b := black(i); b != cur {
+			runs = append(runs, width)
+			cur, width = b, 0
+		}
+		width++
+	}
+	return append(runs, width)
+}
+
+// reverseRuns is runs read from the other end, still a space first.
+func reverseRuns(runs []int) []int {
+	out := make([]int, 0, len(runs)+1)
+	if len(runs)%2 == 0 {
+		out = append(out, 0)
+	}
+	for i := len(runs) - 1; i >= 0; i-- {
+		out = append(out, runs[i])
+	}
+	return out
+}
+
+// readPDF417Runs reads the lines that begin among runs, spaces at even
+// indexes.
+func readPDF417Runs(runs []int) []*pdf417Line {
+	at := make([]int, len(runs)+1)
+	for i, r := range runs {
+		at[i+1] = at[i] + r
+	}
+	var out []*pdf417Line
+	for k := 0; k+9 <= len(runs); {
+		l, next := readPDF417Line(runs, at, k)
+		if l == nil {
+			k += 2
+			continue
+		}
+		out = append(out, l)
+		k = next
+	}
+	return out
+}
+
+// readPDF417Line reads the line beginning at runs[k+1], after the space
+// runs[k], if a start pattern or a stop pattern read backwards is there. at
+// holds the position of each run. It returns the line and the index of the
+// space to look for the next one from, which may be the quiet zone before a
+// second symbol on the same line.
+//
+// The codewords are read until the pattern at the other end of the row, or
+// until the bars stop adding up to 17 modules: a scratch or a fold loses the
+// rest of the line, which the line read from the other end picks up.
+func readPDF417Line(runs, at []int, k int) (*pdf417Line, int) {
+	var l *pdf417Line
+	j := 0
+	if m, ok := matchPDF417(runs[k+1:k+9], pdf417Start); ok {
+		l, j = &pdf417Line{module: m}, k+9
+	} else if k+10 <= len(runs) {
+		if m, ok := matchPDF417(runs[k+1:k+10], pdf417StopBack); ok {
+			l, j = &pdf417Line{module: m, fromStop: true}, k+10
+		}
+	}
+	if l == nil || (k > 0 && float64(runs[k]) < l.module) {
+		return nil, 0
+	}
+	l.from = at[k+1]
+	near := func(m float64) bool { return m > l.module*0.7 && m < l.module*1.3 }
+	var back [8]int
+	for j+8 <= len(runs) {
+		widths := runs[j : j+8]
+		if l.fromStop {
+			// A space first: read backwards, a codeword ends with its bar.
+			for i, w := range widths {
+				back[7-i] = w
+			}
+			widths = back[:]
+			if m, ok := matchPDF417(widths, pdf417Start); ok && near(m) {
+				l.ended = true
+				j += 8
+				break
+			}
+		} else if j+9 <= len(runs) {
+			if m, ok := matchPDF417(runs[j:j+9], pdf417Stop); ok && near(m) {
+				l.ended = true
+				j += 9
+				break
+			}
+		}
+		total := at[j+8] - at[j]
+		if n := float64(total) / l.module; n < 17*0.75 || n > 17*1.25 {
+			// A codeword ends with a space, which runs on into a gap in
+			// the line; it is whatever the bars before it leave of 17
+			// modules.
+			if bars := at[j+7] - at[j]; !l.fromStop && n > 17 && float64(bars) < 16.5*l.module {
+				last := [8]int(widths)
+				last[7] = int(math.Round(17*l.module)) - bars
+				if w := readPDF417Word(last[:]); w.value >= 0 {
+					l.words = append(l.words, w)
+					j += 7
+				}
+			}
+			break
+		}
+		l.words = append(l.words, readPDF417Word(widths))
+		l.module = (3*l.module + float64(total)/17) / 4
+		j += 8
+	}
+	if len(l.words) == 0 {
+		return nil, 0
+	}
+	l.to = at[j]
+	return l, j &^ 1
+}
+
+// matchPDF417 reports whether widths are the pattern of module counts want,
+// and the module width they imply. It is tried at every bar of every line, so
+// the wide bars, which most bars fail, are checked first.
+func matchPDF417(widths, want []int) (float64, bool) {
+	module := float64(sum(widths)) / float64(sum(want))
+	if module <= 0 {
+		return 0, false
+	}
+	for _, wide := range []bool{true, false} {
+		for i, w := range widths {
+			if (want[i] >= 3) != wide {
+				continue
+			}
+			tolerance := 0.6
+			if want[i] == 8 {
+				tolerance = 1.2
+			}
+			if math.Abs(float64(w)/module-float64(want[i])) > tolerance {
+				return 0, false
+			}
+		}
+	}
+	return module, true
+}
+
+// readPDF417Word decodes the eight widths of a codeword, bar first. The
+// pattern is sampled at the centre of each of its 17 modules; when that is no
+// codeword, the widths are rounded to whole modules instead, which recovers a
+// bar grown or shrunk by ink spread or blur.
+func readPDF417Word(widths []int) pdf417Word {
+	total := sum(widths)
+	var bits uint32
+	k, edge := 0, widths[0]
+	for i := 0; i < 17; i++ {
+		for k < 7 && 34*edge <= (2*i+1)*total {
+			k++
+			edge += widths[k]
+		}
+		bits <<= 1
+		if k%2 == 0 {
+			bits |= 1
+		}
+	}
+	if w, ok := pdf417Lookup[bits]; ok {
+		return w
+	}
+	var mods [8]int
+	n := 0
+	for i, w := range widths {
+		mods[i] = max(1, int(math.Round(float64(w*17)/float64(total))))
+		n += mods[i]
+	}
+	for n != 17 {
+		// Move the element whose rounding went furthest the wrong way.
+		best, bestErr := -1, 0.0
+		for i, w := range widths {
+			e := float64(mods[i]) - float64(w*17)/float64(total)
+			if n < 17 {
+				e = -e
+			}
+			if (n > 17 && mods[i] == 1) || (best >= 0 && e <= bestErr) {
+				continue
+			}
+			best, bestErr = i, e
+		}
+		if best < 0 {
+			return pdf417Word{value: -1}
+		}
+		if n > 17 {
+			mods[best]--
+			n--
+		} else {
+			mods[best]++
+			n++
+		}
+	}
+	bits = 0
+	for i, m := range mods {
+		for ; m > 0; m-- {
+			bits <<= 1
+			if i%2 == 0 {
+				bits |= 1
+			}
+		}
+	}
+	if w, ok := pdf417Lookup[bits]; ok {
+		return w
+	}
+	return pdf417Word{value: -1}
+}
+
+func sum(xs []int) int {
+	n := 0
+	for _, x := range xs {
+		n += x
+	}
+	return n
+}
+
+// edge is the outer edge of the pattern the line was read from.
+func (l *pdf417Line) edge() int {
+	if l.reversed {
+		return l.to
+	}
+	return l.from
+}
+
+// flipped reports whether the symbol the line crosses is turned half round,
+// its start pattern at the far end of the line.
+func (l *pdf417Line) flipped() bool { return l.reversed != l.fromStop }
+
+// indicators returns the line's left and right row indicators, nil for one it
+// did not reach.
+func (l *pdf417Line) indicators() (left, right *pdf417Word) {
+	near := &l.words[0]
+	var far *pdf417Word
+	if l.ended && len(l.words) >= 2 {
+		far = &l.words[len(l.words)-1]
+	}
+	if l.fromStop {
+		return far, near
+	}
+	return near, far
+}
+
+// column is the position in its row of the line's i'th word, in a symbol of
+// cols data columns: 0 for the left row indicator, cols+1 for the right.
+func (l *pdf417Line) column(i, cols int) int {
+	if l.fromStop {
+		return cols + 1 - i
+	}
+	return i
+}
+
+// pdf417Group is the lines read from one symbol.
+type pdf417Group struct {
+	lines []*pdf417Line
+}
+
+// addPDF417Line files l with the group of lines read from the same pattern of
+// the same symbol, which line up on lines near each other, or starts a new
+// group.
+func addPDF417Line(groups []*pdf417Group, l *pdf417Line) []*pdf417Group {
+	for _, g := range groups {
+		last := g.lines[len(g.lines)-1]
+		if last.fromStop == l.fromStop && last.flipped() == l.flipped() &&
+			math.Abs(float64(l.edge()-last.edge())) <= 4*l.module &&
+			float64(l.line-last.line) <= 10*l.module+2 {
+			g.lines = append(g.lines, l)
+			return groups
+		}
+	}
+	return append(groups, &pdf417Group{lines: []*pdf417Line{l}})
+}
+
+// mergePDF417Groups joins each group of lines read from a start pattern with
+// the group read from the same symbol's stop pattern: the nearest group of
+// stop lines the same way round, beyond the start patterns by at least the
+// narrowest row, across the same lines.
+func mergePDF417Groups(groups []*pdf417Group) []*pdf417Group {
+	taken := make([]bool, len(groups))
+	var out []*pdf417Group
+	for i, g := range groups {
+		s := g.lines[0]
+		if s.fromStop {
+			continue
+		}
+		first, last := g.lines[0], g.lines[len(g.lines)-1]
+		gap := 10*s.module + 2
+		best, bestDist := -1, 0.0
+		for j, h := range groups {
+			t := h.lines[0]
+			if taken[j] || !t.fromStop || t.flipped() != s.flipped() ||
+				float64(h.lines[0].line) > float64(last.line)+gap ||
+				float64(h.lines[len(h.lines)-1].line) < float64(first.line)-gap {
+				continue
+			}
+			dist := float64(t.edge() - s.edge())
+			if s.flipped() {
+				dist = -dist
+			}
+			if dist < 86*s.module || (best >= 0 && dist >= bestDist) {
+				continue
+			}
+			best, bestDist = j, dist
+		}
+		merged := &pdf417Group{lines: g.lines}
+		if best >= 0 {
+			taken[best] = true
+			merged.lines = append(merged.lines, groups[best].lines...)
+		}
+		taken[i] = true
+		out = append(out, merged)
+	}
+	for j, h := range groups {
+		if !taken[j] {
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+// pdf417Votes counts the readings of one value.
+type pdf417Votes map[int]int
+
+// ranked is the values read, most often first, at most n of them.
+func (v pdf417Votes) ranked(n int) []int {
+	out := make([]int, 0, len(v))
+	for x := range v {
+		out = append(out, x)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if v[out[i]] != v[out[j]] {
+			return v[out[i]] > v[out[j]]
+		}
+		return out[i] < out[j]
+	})
+	if len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
+
+// decode assembles the group's codeword matrix and decodes it.
+//
+// The row count, column count and error correction level are voted on by
+// every row indicator read, and the two leading candidates for each are tried
+// in turn: a misread indicator, or an encoder that writes one of them wrongly,
+// gives a matrix the Reed-Solomon check rejects rather than a wrong payload.
+func (g *pdf417Group) decode(place func(line, from, to int) image.Rectangle) (string, image.Rectangle, bool) {
+	rowsHi, rowsLo, level, cols := pdf417Votes{}, pdf417Votes{}, pdf417Votes{}, pdf417Votes{}
+	for _, l := range g.lines {
+		if l.ended && len(l.words) >= 3 {
+			cols[len(l.words)-2]++
+		}
+		left, right := l.indicators()
+		if left != nil && left.value >= 0 {
+			x := left.value % 30
+			switch left.cluster {
+			case 0:
+				rowsHi[x]++
+			case 1:
+				level[x/3]++
+				rowsLo[x%3]++
+			case 2:
+				cols[x+1]++
+			}
+		}
+		if right != nil && right.value >= 0 {
+			x := right.value % 30
+			switch right.cluster {
+			case 0:
+				cols[x+1]++
+			case 1:
+				rowsHi[x]++
+			case 2:
+				level[x/3]++
+				rowsLo[x%3]++
+			}
+		}
+	}
+	for _, c := range cols.ranked(2) {
+		for _, hi := range rowsHi.ranked(2) {
+			for _, lo := range rowsLo.ranked(2) {
+				for _, ec := range level.ranked(2) {
+					rows := 3*hi + lo + 1
+					if text, ok := g.decodeMatrix(rows, c, ec); ok {
+						return text, g.bounds(rows, c, place), true
+					}
+				}
+			}
+		}
+	}
+	return "", image.Rectangle{}, false
+}
+
+// decodeMatrix votes the group's data codewords into a rows x cols matrix and
+// decodes it at error correction level ec.
+func (g *pdf417Group) decodeMatrix(rows, cols, ec int) (string, bool) {
+	if rows < 3 || rows > 90 || cols < 1 || cols > 30 || ec > 8 || rows*cols > 928 {
+		return "", false
+	}
+	numEC := 2 << ec
+	if numEC >= rows*cols {
+		return "", false
+	}
+	cells := make([]pdf417Votes, rows*cols)
+	for _, l := range g.lines {
+		for i, row := range pdf417Rows(l.words, cols) {
+			w, col := l.words[i], l.column(i, cols)
+			if col < 1 || col > cols || row < 0 || row >= rows || w.value < 0 {
+				continue
+			}
+			cell := row*cols + col - 1
+			if cells[cell] == nil {
+				cells[cell] = pdf417Votes{}
+			}
+			cells[cell][w.value]++
+		}
+	}
+	codewords := make([]int, rows*cols)
+	for i, v := range cells {
+		if best := v.ranked(1); len(best) == 1 {
+			codewords[i] = best[0]
+		}
+	}
+	if !pdf417Correct(codewords, numEC) {
+		return "", false
+	}
+	n := codewords[0]
+	if n < 1 || n > len(codewords)-numEC {
+		return "", false
+	}
+	return pdf417Text(codewords[1:n])
+}
+
+// pdf417Rows is the row of each of a line's words, -1 where it is not known.
+// A row indicator, at either end, states its row outright; from there the row
+// follows the cluster, which steps by one at each row the line crosses.
+func pdf417Rows(words []pdf417Word, cols int) []int {
+	out := make([]int, len(words))
+	for i := range out {
+		out[i] = -1
+	}
+	anchor := -1
+	switch last := len(words) - 1; {
+	case words[0].value >= 0:
+		anchor = 0
+	case last == cols+1 && words[last].value >= 0:
+		anchor = last
+	default:
+		return out
+	}
+	w := words[anchor]
+	out[anchor] = 3*(w.value/30) + w.cluster
+	for _, step := range []int{1, -1} {
+		row := out[anchor]
+		for i := anchor + step; i >= 0 && i < len(words); i += step {
+			switch c := words[i].cluster; {
+			case words[i].value < 0:
+			case c == (row+1)%3:
+				row++
+			case c == (row+2)%3:
+				row--
+			}
+			out[i] = row
+		}
+	}
+	return out
+}
+
+// bounds is the rectangle covering the symbol: the extent of its lines, taken
+// out to the symbol's width in modules from an end no line reached, and a row
+// and two modules beyond.
+func (g *pdf417Group) bounds(rows, cols int, place func(line, from, to int) image.Rectangle) image.Rectangle {
+	first, last := g.lines[0].line, g.lines[0].line
+	from, to := g.lines[0].from, g.lines[0].to
+	module := 0.0
+	low, high := math.MaxInt, math.MinInt
+	for _, l := range g.lines {
+		first, last = min(first, l.line), max(last, l.line)
+		from, to = min(from, l.from), max(to, l.to)
+		module += l.module
+		if l.reversed {
+			high = max(high, l.to)
+		} else {
+			low = min(low, l.from)
+		}
+	}
+	module /= float64(len(g.lines))
+	// Start pattern, row indicators, data columns and stop pattern.
+	span := int(math.Ceil(float64(17*(cols+4)+1) * module))
+	if low != math.MaxInt {
+		to = max(to, low+span)
+	}
+	if high != math.MinInt {
+		from = min(from, high-span)
+	}
+	pad := int(math.Ceil(2*module)) + 1
+	rowHeight := int(math.Ceil(float64(last-first+1) / float64(rows)))
+	r := place(first, from, to).Union(place(last, from, to))
+	return r.Inset(-(rowHeight + pad))
+}
+
+// pdf417Exp and pdf417Log are the powers of 3, the generator PDF417's error
+// correction uses, in GF(929) and their logarithms.
+var pdf417Exp, pdf417Log = func() (exp, log [929]int) {
+	x := 1
+	for i := range exp {
+		exp[i] = x
+		x = x * 3 % 929
+	}
+	for i := 0; i < 928; i++ {
+		log[exp[i]] = i
+	}
+	return exp, log
+}()
+
+func gfMul(a, b int) int {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return pdf417Exp[(pdf417Log[a]+pdf417Log[b])%928]
+}
+
+func gfInv(a int) int { return pdf417Exp[928-pdf417Log[a]] }
+
+func gfSub(a, b int) int { return (929 + a - b) % 929 }
+
+// gfPoly is a polynomial over GF(929), highest degree first, without leading
+// zeros except in the zero polynomial.
+type gfPoly []int
+
+func newGFPoly(c []int) gfPoly {
+	for len(c) > 1 && c[0] == 0 {
+		c = c[1:]
+	}
+	return gfPoly(c)
+}
+
+func (p gfPoly) degree() int { return len(p) - 1 }
+
+func (p gfPoly) isZero() bool { return p[0] == 0 }
+
+func (p gfPoly) coeff(d int) int { return p[len(p)-1-d] }
+
+func (p gfPoly) eval(x int) int {
+	r := 0
+	for _, c := range p {
+		r = (gfMul(x, r) + c) % 929
+	}
+	return r
+}
+
+func (p gfPoly) sub(q gfPoly) gfPoly {
+	n := max(len(p), len(q))
+	out := make([]int, n)
+	for i := range out {
+		a, b := 0, 0
+		if j := i - (n - len(p)); j >= 0 {
+			a = p[j]
+		}
+		if j := i - (n - len(q)); j >= 0 {
+			b = q[j]
+		}
+		out[i] = gfSub(a, b)
+	}
+	return newGFPoly(out)
+}
+
+func (p gfPoly) mul(q gfPoly) gfPoly {
+	out := make([]int, len(p)+len(q)-1)
+	for i, a := range p {
+		for j, b := range q {
+			out[i+j] = (out[i+j] + gfMul(a, b)) % 929
+		}
+	}
+	return newGFPoly(out)
+}
+
+// term is the polynomial c*x^d.
+func term(d, c int) gfPoly {
+	out := make([]int, d+1)
+	out[0] = c
+	return newGFPoly(out)
+}
+
+// pdf417Correct repairs codewords in place with its last numEC error
+// correction codewords and reports whether it could: the syndromes of the
+// corrected matrix must all be zero. Cells no line read are zero, and are
+// found and repaired like any other error.
+func pdf417Correct(codewords []int, numEC int) bool {
+	received := gfPoly(codewords)
+	syndromes := func() ([]int, bool) {
+		s := make([]int, numEC)
+		clean := true
+		for i := numEC; i > 0; i-- {
+			s[numEC-i] = received.eval(pdf417Exp[i])
+			clean = clean && s[numEC-i] == 0
+		}
+		return s, clean
+	}
+	s, clean := syndromes()
+	if clean {
+		return true
+	}
+	// The Euclidean algorithm gives the error locator sigma and the error
+	// evaluator omega from the syndromes.
+	rLast, r := term(numEC, 1), newGFPoly(s)
+	tLast, t := gfPoly{0}, gfPoly{1}
+	for r.degree() >= numEC/2 {
+		rLastLast, tLastLast := rLast, tLast
+		rLast, tLast = r, t
+		if rLast.isZero() {
+			return false
+		}
+		r = rLastLast
+		q := gfPoly{0}
+		inv := gfInv(rLast.coeff(rLast.degree()))
+		for r.degree() >= rLast.degree() && !r.isZero() {
+			d := r.degree() - rLast.degree()
+			scale := gfMul(r.coeff(r.degree()), inv)
+			q = q.sub(term(d, gfSub(0, scale)))
+			r = r.sub(rLast.mul(term(d, scale)))
+		}
+		t = gfPoly{0}.sub(q.mul(tLast).sub(tLastLast))
+	}
+	at0 := t.coeff(0)
+	if at0 == 0 {
+		return false
+	}
+	inv := gfPoly{gfInv(at0)}
+	sigma, omega := t.mul(inv), r.mul(inv)
+	var locations []int
+	for x := 1; x < 929; x++ {
+		if sigma.eval(x) == 0 {
+			locations = append(locations, gfInv(x))
+		}
+	}
+	if len(locations) != sigma.degree() {
+		return false
+	}
+	// Forney's formula gives each error's magnitude.
+	deriv := make([]int, sigma.degree())
+	for i := 1; i <= sigma.degree(); i++ {
+		deriv[sigma.degree()-i] = gfMul(i, sigma.coeff(i))
+	}
+	sigmaPrime := newGFPoly(deriv)
+	for _, loc := range locations {
+		pos := len(codewords) - 1 - pdf417Log[loc]
+		if pos < 0 {
+			return false
+		}
+		xi := gfInv(loc)
+		d := sigmaPrime.eval(xi)
+		if d == 0 {
+			return false
+		}
+		magnitude := gfMul(gfSub(0, omega.eval(xi)), gfInv(d))
+		codewords[pos] = gfSub(codewords[pos], magnitude)
+	}
+	_, clean = syndromes()
+	return clean
+}
+
+// The codewords that switch PDF417's compaction modes, and the ones that
+// begin the control blocks and character set designations.
+const (
+	pdf417TextLatch     = 900
+	pdf417ByteLatch     = 901
+	pdf417NumericLatch  = 902
+	pdf417ByteShift     = 913
+	pdf417MacroOptional = 923
+	pdf417MacroEnd      = 922
+	pdf417ECIUser       = 925
+	pdf417ECIGeneral    = 926
+	pdf417ECICharset    = 927
+	pdf417MacroBegin    = 928
+	pdf417ByteLatch6    = 924
+)
+
+// The text compaction submodes.
+const (
+	textAlpha = iota
+	textLower
+	textMixed
+	textPunct
+	textAlphaShift
+	textPunctShift
+)
+
+var (
+	pdf417Mixed = "0123456789&\r\t,:#-.$/+%*=^"
+	pdf417Punct = ";<>@[\\]_`~!\r\t,:\n-.$/\"|*()?{}'"
+)
+
+// pdf417Text decodes a symbol's data codewords, the length descriptor
+// dropped. Bytes are read as UTF-8 when the symbol says so or when they are
+// valid UTF-8, and as ISO 8859-1, PDF417's default, otherwise. A Macro PDF417
+// control block ends the data: it describes the file a symbol is a segment
+// of, and is not part of the payload.
+func pdf417Text(cw []int) (string, bool) {
+	var raw []byte
+	eci := -1
+	for i := 0; i < len(cw); {
+		c := cw[i]
+		switch {
+		case c < pdf417TextLatch:
+			i = pdf417TextRun(cw, i, &raw)
+		case c == pdf417TextLatch:
+			i = pdf417TextRun(cw, i+1, &raw)
+		case c == pdf417ByteLatch || c == pdf417ByteLatch6:
+			i = pdf417ByteRun(cw, i+1, c == pdf417ByteLatch6, &raw)
+		case c == pdf417NumericLatch:
+			var ok bool
+			if i, ok = pdf417NumericRun(cw, i+1, &raw); !ok {
+				return "", false
+			}
+		case c == pdf417ByteShift:
+			if i+1 < len(cw) && cw[i+1] < 256 {
+				raw = append(raw, byte(cw[i+1]))
+			}
+			i += 2
+		case c == pdf417ECICharset:
+			if i+1 < len(cw) {
+				eci = cw[i+1]
+			}
+			i += 2
+		case c == pdf417ECIGeneral:
+			i += 3
+		case c == pdf417ECIUser:
+			i += 2
+		case c == pdf417MacroBegin || c == pdf417MacroOptional || c == pdf417MacroEnd:
+			i = len(cw)
+		default:
+			// Reserved.
+			i++
+		}
+	}
+	if eci == 26 || (eci < 0 && utf8.Valid(raw)) {
+		return string(raw), true
+	}
+	runes := make([]rune, len(raw))
+	for i, b := range raw {
+		runes[i] = rune(b)
+	}
+	return string(runes), true
+}
+
+// pdf417TextRun decodes text compaction from cw[i] up to the next mode
+// codeword, whose index it returns. Each codeword is two characters of
+// one of four submodes, switched by latches and shifts among the values.
+func pdf417TextRun(cw []int, i int, raw *[]byte) int {
+	mode, prior := textAlpha, textAlpha
+	for ; i < len(cw); i++ {
+		c := cw[i]
+		if c == pdf417ByteShift {
+			if i+1 < len(cw) && cw[i+1] < 256 {
+				*raw = append(*raw, byte(cw[i+1]))
+			}
+			i++
+			continue
+		}
+		if c >= pdf417TextLatch {
+			return i
+		}
+		for _, v := range [2]int{c / 30, c % 30} {
+			switch mode {
+			case textAlpha, textLower:
+				switch {
+				case v < 26 && mode == textAlpha:
+					*raw = append(*raw, byte('A'+v))
+				case v < 26:
+					*raw = append(*raw, byte('a'+v))
+				case v == 26:
+					*raw = append(*raw, ' ')
+				case v == 27 && mode == textAlpha:
+					mode = textLower
+				case v == 27:
+					prior, mode = mode, textAlphaShift
+				case v == 28:
+					mode = textMixed
+				default:
+					prior, mode = mode, textPunctShift
+				}
+			case textMixed:
+				switch {
+				case v < 25:
+					*raw = append(*raw, pdf417Mixed[v])
+				case v == 25:
+					mode = textPunct
+				case v == 26:
+					*raw = append(*raw, ' ')
+				case v == 27:
+					mode = textLower
+				case v == 28:
+					mode = textAlpha
+				default:
+					prior, mode = mode, textPunctShift
+				}
+			case textPunct:
+				if v < 29 {
+					*raw = append(*raw, pdf417Punct[v])
+				} else {
+					mode = textAlpha
+				}
+			case textAlphaShift:
+				mode = prior
+				if v < 26 {
+					*raw = append(*raw, byte('A'+v))
+				} else if v == 26 {
+					*raw = append(*raw, ' ')
+				}
+			case textPunctShift:
+				mode = prior
+				if v < 29 {
+					*raw = append(*raw, pdf417Punct[v])
+				} else {
+					mode = textAlpha
+				}
+			}
+		}
+	}
+	return i
+}
+
+// pdf417ByteRun decodes byte compaction from cw[i] up to the next mode
+// codeword, whose index it returns. Five codewords carry six bytes, base 900
+// to base 256; after latch 901 a last group of five or fewer codewords is a
+// byte each, and after latch 924 every group is whole.
+func pdf417ByteRun(cw []int, i int, whole bool, raw *[]byte) int {
+	end := i
+	for end < len(cw) && cw[end] < pdf417TextLatch {
+		end++
+	}
+	for ; end-i > 5 || (whole && end-i == 5); i += 5 {
+		var v uint64
+		for _, c := range cw[i : i+5] {
+			v = v*900 + uint64(c)
+		}
+		for shift := 40; shift >= 0; shift -= 8 {
+			*raw = append(*raw, byte(v>>shift))
+		}
+	}
+	for ; i < end; i++ {
+		*raw = append(*raw, byte(cw[i]))
+	}
+	return end
+}
+
+// pdf417NumericRun decodes numeric compaction from cw[i] up to the next mode
+// codeword, whose index it returns. Up to fifteen codewords are a base 900
+// number whose decimal digits, after a leading 1, are the data.
+func pdf417NumericRun(cw []int, i int, raw *[]byte) (int, bool) {
+	for i < len(cw) && cw[i] < pdf417TextLatch {
+		v := new(big.Int)
+		for n := 0; n < 15 && i < len(cw) && cw[i] < pdf417TextLatch; n++ {
+			v.Mul(v, big.NewInt(900)).Add(v, big.NewInt(int64(cw[i])))
+			i++
+		}
+		digits := v.String()
+		if digits[0] != '1' {
+			return i, false
+		}
+		*raw = append(*raw, digits[1:]...)
+	}
+	return i, true
+}
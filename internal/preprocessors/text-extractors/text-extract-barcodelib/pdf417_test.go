@@ -0,0 +1,180 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package textextractbarcodelib
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/boombuler/barcode/pdf417"
+)
+
+// aamva is the shape of the PDF417 record on the back of a North American
+// driving licence: a header, then fields separated by line feeds, the record
+// ended by a carriage return, with the record separator and file separator
+// bytes of the header in place.
+const aamva = "@\n\x1e\rANSI 636014040002DL00410278ZC03190024DLDAQD1234567\n" +
+	"DCSSAMPLE\nDACJANE\nDBB01151990\nDBA01152030\nDAG123 MAIN ST\n" +
+	"DAISACRAMENTO\nDAJCA\nDAK958220000\r"
+
+// pdf417Image renders text as a PDF417 symbol of module x module pixels per
+// module and rowHeight pixels per row, with a quiet zone of four modules,
+// and returns it with the rectangle of the symbol itself.
+func pdf417Image(t *testing.T, text string, level byte, module, rowHeight int) (*image.Gray, image.Rectangle) {
+	t.Helper()
+	code, err := pdf417.Encode(text, level)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The encoder draws one pixel a module and two a row.
+	cols, rows := code.Bounds().Dx(), code.Bounds().Dy()/2
+	quiet := 4 * module
+	ink := image.Rect(quiet, quiet, quiet+cols*module, quiet+rows*rowHeight)
+	img := image.NewGray(ink.Inset(-quiet).Sub(image.Point{}))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			if code.At(c, 2*r) == color.Black || isDark(code.At(c, 2*r)) {
+				cell := image.Rect(c*module, r*rowHeight, (c+1)*module, (r+1)*rowHeight).Add(ink.Min)
+				draw.Draw(img, cell, image.Black, image.Point{}, draw.Src)
+			}
+		}
+	}
+	return img, ink
+}
+
+func isDark(c color.Color) bool {
+	y, _, _, _ := color.GrayModel.Convert(c).RGBA()
+	return y < 0x8000
+}
+
+// rotate turns img a quarter turn clockwise quarters times.
+func rotate(img *image.Gray, quarters int) *image.Gray {
+	for ; quarters > 0; quarters-- {
+		b := img.Bounds()
+		out := image.NewGray(image.Rect(0, 0, b.Dy(), b.Dx()))
+		for y := 0; y < b.Dy(); y++ {
+			for x := 0; x < b.Dx(); x++ {
+				out.SetGray(b.Dy()-1-y, x, img.GrayAt(x, y))
+			}
+		}
+		img = out
+	}
+	return img
+}
+
+// tilt turns img by degrees about its centre onto a white canvas with room
+// for the corners.
+func tilt(img *image.Gray, degrees float64) *image.Gray {
+	b := img.Bounds()
+	out := image.NewGray(b.Inset(-b.Dx() / 4))
+	draw.Draw(out, out.Bounds(), image.White, image.Point{}, draw.Src)
+	cx, cy := float64(b.Dx())/2, float64(b.Dy())/2
+	sin, cos := math.Sincos(degrees * math.Pi / 180)
+	for y := out.Rect.Min.Y; y < out.Rect.Max.Y; y++ {
+		for x := out.Rect.Min.X; x < out.Rect.Max.X; x++ {
+			dx, dy := float64(x)-cx, float64(y)-cy
+			src := image.Pt(int(math.Round(cos*dx+sin*dy+cx)), int(math.Round(-sin*dx+cos*dy+cy)))
+			if src.In(b) {
+				out.SetGray(x, y, img.GrayAt(src.X, src.Y))
+			}
+		}
+	}
+	return out
+}
+
+func TestDecodeReadsPDF417(t *testing.T) {
+	for _, tc := range []struct {
+		name              string
+		text              string
+		level             byte
+		module, rowHeight int
+	}{
+		{"driving licence", aamva, 3, 2, 6},
+		{"boarding pass", "M1DOE/JANE            EABC123 SFOJFKUA 0837 123Y012A0025 100", 2, 3, 9},
+		{"numeric", "4111111111111111 exp 12/29 cvv 123", 1, 2, 5},
+		{"binary", "café — naïve", 2, 3, 8},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			symbol, ink := pdf417Image(t, tc.text, tc.level, tc.module, tc.rowHeight)
+			img := canvas(symbol.Bounds().Dx()+200, symbol.Bounds().Dy()+150)
+			at := image.Pt(120, 60)
+			draw.Draw(img, symbol.Bounds().Add(at), symbol, image.Point{}, draw.Src)
+			ink = ink.Add(at)
+
+			got := Decode(img)
+			if len(got) != 1 || got[0].Label() != "pdf417[0]" || got[0].Text != tc.text {
+				t.Fatalf("Decode = %+v, want pdf417[0] %q", got, tc.text)
+			}
+			if !ink.In(got[0].Bounds) {
+				t.Errorf("bounds %v do not cover the symbol at %v", got[0].Bounds, ink)
+			}
+			if got[0].Bounds.Dx() > ink.Dx()*3/2 || got[0].Bounds.Dy() > ink.Dy()*3/2 {
+				t.Errorf("bounds %v are far larger than the symbol at %v", got[0].Bounds, ink)
+			}
+
+			draw.Draw(img, got[0].Bounds, image.Black, image.Point{}, draw.Src)
+			if left := Decode(img); len(left) != 0 {
+				t.Errorf("symbols still decode after their bounds were painted out: %+v", left)
+			}
+		})
+	}
+}
+
+func TestDecodePDF417ScanTextSplitsAAMVAFields(t *testing.T) {
+	symbol, _ := pdf417Image(t, aamva, 3, 2, 6)
+	got := Decode(symbol)
+	if len(got) != 1 {
+		t.Fatalf("Decode found %d symbols, want 1", len(got))
+	}
+	lines := strings.Split(got[0].ScanText(), "\n")
+	for _, want := range []string{"DBB01151990", "DAQD1234567", "DCSSAMPLE"} {
+		found := false
+		for _, l := range lines {
+			found = found || strings.HasSuffix(l, want)
+		}
+		if !found {
+			t.Errorf("ScanText has no line ending %q: %q", want, lines)
+		}
+	}
+}
+
+// TestDecodePDF417TurnedOrDamaged: a licence photographed upside down, on its
+// side or a little askew is the same symbol, and a blot over a few codewords is what
+// the error correction codewords are for.
+func TestDecodePDF417TurnedOrDamaged(t *testing.T) {
+	symbol, ink := pdf417Image(t, aamva, 4, 2, 6)
+	for quarters := 1; quarters < 4; quarters++ {
+		if got := Decode(rotate(symbol, quarters)); len(got) != 1 || got[0].Text != aamva {
+			t.Errorf("turned %d quarters: Decode = %+v", quarters, got)
+		}
+	}
+	askew := tilt(symbol, 3)
+	got := Decode(askew)
+	if len(got) != 1 || got[0].Text != aamva {
+		t.Fatalf("tilted 3 degrees: Decode = %+v", got)
+	}
+	draw.Draw(askew, got[0].Bounds, image.Black, image.Point{}, draw.Src)
+	if left := Decode(askew); len(left) != 0 {
+		t.Errorf("tilted 3 degrees: symbols still decode after their bounds were painted out: %+v", left)
+	}
+
+	// A blot over two columns of codewords in five rows: no line through it
+	// reads its row from end to end.
+	blot := image.Rect(ink.Min.X+17*2*3, ink.Min.Y+6*4, ink.Min.X+17*2*5, ink.Min.Y+6*9)
+	draw.Draw(symbol, blot, image.White, image.Point{}, draw.Src)
+	if got := Decode(symbol); len(got) != 1 || got[0].Text != aamva {
+		t.Errorf("blotted: Decode = %+v", got)
+	}
+}
+
+func TestLabelOfPDF417(t *testing.T) {
+	if got, ok := LabelOf("licence.jpg -> pdf417[0]"); !ok || got != "pdf417[0]" {
+		t.Errorf("LabelOf = %q, %v", got, ok)
+	}
+}
@@ -0,0 +1,646 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package textextractcolumnarlib
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/flate"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// AvroMagic begins every Avro object container file.
+const AvroMagic = "Obj\x01"
+
+// IsAvro reports whether data begins with the Avro container magic.
+func IsAvro(data []byte) bool {
+	return len(data) >= len(AvroMagic) && string(data[:len(AvroMagic)]) == AvroMagic
+}
+
+// maxAvroDepth bounds schema and value nesting, which a recursive named type
+// would otherwise leave unbounded.
+const maxAvroDepth = 64
+
+// maxAvroItems bounds the elements of one array or map. A null element takes no
+// bytes, so the block size alone does not bound a forged count.
+const maxAvroItems = 1 << 20
+
+// maxFlattenDepth bounds how deep nested records are flattened into columns;
+// past it a record is written as one value.
+const maxFlattenDepth = 8
+
+// avroType is one node of a parsed schema.
+type avroType struct {
+	kind     string // a primitive name, or record, enum, array, map, union, fixed
+	logical  string
+	scale    int
+	size     int
+	fields   []avroField
+	symbols  []string
+	items    *avroType // array items and map values
+	branches []*avroType
+}
+
+type avroField struct {
+	name string
+	typ  *avroType
+}
+
+// avroPlan decodes one record into columns: a field is either a column or a
+// record flattened into columns of its own.
+type avroPlan struct {
+	typ    *avroType
+	col    int
+	nested []avroPlan
+}
+
+// ExtractAvro reads the rows of an Avro object container file, one block at a
+// time.
+func ExtractAvro(r io.Reader, opts Options) (*Extraction, error) {
+	br := bufio.NewReader(r)
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(br, magic); err != nil || !IsAvro(magic) {
+		return nil, errors.New("not an Avro container file: missing Obj magic")
+	}
+	meta, err := readAvroMeta(br)
+	if err != nil {
+		return nil, fmt.Errorf("invalid header: %w", err)
+	}
+	var sync [16]byte
+	if _, err := io.ReadFull(br, sync[:]); err != nil {
+		return nil, fmt.Errorf("invalid header: %w", err)
+	}
+	schema, err := parseAvroSchema(meta["avro.schema"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	codec := string(meta["avro.codec"])
+	switch codec {
+	case "", "null", "deflate", "snappy", "bzip2":
+	default:
+		return nil, fmt.Errorf("%s compression is not supported", codec)
+	}
+
+	var names []string
+	plan := avroColumns(schema, "", &names, 0)
+	w := newTableWriter(names, opts)
+	values := make([]string, len(names))
+	var row int64
+
+	for {
+		count, size, err := readBlockHeader(br)
+		if err == io.EOF {
+			w.ex.TotalRows = row
+			break
+		}
+		if err != nil {
+			w.note(fmt.Sprintf("rows after %d: %v, so the rest of the file was NOT scanned", row, err))
+			break
+		}
+		if !w.wantRow() {
+			// Count what is left without decoding it, for the disclosure.
+			if rest, err := skipAvroBlocks(br, count, size); err == nil {
+				w.ex.TotalRows = row + rest
+			}
+			break
+		}
+		block, err := readAvroBlock(br, size, codec, sync)
+		if err != nil {
+			w.note(fmt.Sprintf("rows after %d: %v, so the rest of the file was NOT scanned", row, err))
+			break
+		}
+		d := &avroDecoder{b: block}
+		var k int64
+		for ; k < count && w.wantRow(); k++ {
+			clear(values)
+			if err = d.record(plan, values, 0); err != nil {
+				break
+			}
+			if !w.addRow(row+1, values) {
+				break
+			}
+			row++
+		}
+		if err != nil {
+			w.note(fmt.Sprintf("rows after %d: %v, so the rest of the file was NOT scanned", row, err))
+			break
+		}
+		if k < count {
+			if rest, err := skipAvroBlocks(br, -1, 0); err == nil {
+				w.ex.TotalRows = row + (count - k) + rest
+			}
+			break
+		}
+	}
+	return w.finish(), nil
+}
+
+// readAvroMeta reads the header's metadata map.
+func readAvroMeta(br *bufio.Reader) (map[string][]byte, error) {
+	meta := map[string][]byte{}
+	for {
+		n, err := readLong(br)
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			return meta, nil
+		}
+		if n < 0 {
+			// A negative count is followed by the block's size in bytes.
+			n = -n
+			if _, err := readLong(br); err != nil {
+				return nil, err
+			}
+		}
+		if n > 1<<16 {
+			return nil, errors.New("too many metadata entries")
+		}
+		for i := int64(0); i < n; i++ {
+			k, err := readBytes(br, 1<<20)
+			if err != nil {
+				return nil, err
+			}
+			v, err := readBytes(br, 64<<20)
+			if err != nil {
+				return nil, err
+			}
+			meta[string(k)] = v
+		}
+	}
+}
+
+// readBlockHeader reads a data block's object count and byte size; io.EOF
+// means the file ended cleanly between blocks.
+func readBlockHeader(br *bufio.Reader) (int64, int64, error) {
+	if _, err := br.Peek(1); err == io.EOF {
+		return 0, 0, io.EOF
+	}
+	count, err := readLong(br)
+	if err != nil {
+		return 0, 0, err
+	}
+	size, err := readLong(br)
+	if err != nil {
+		return 0, 0, err
+	}
+	if count < 0 || size < 0 || size > maxBlockBytes {
+		return 0, 0, fmt.Errorf("invalid block header: %d objects in %d bytes", count, size)
+	}
+	return count, size, nil
+}
+
+// readAvroBlock reads and decompresses one data block and checks the sync
+// marker after it.
+func readAvroBlock(br *bufio.Reader, size int64, codec string, sync [16]byte) ([]byte, error) {
+	raw := make([]byte, size)
+	if _, err := io.ReadFull(br, raw); err != nil {
+		return nil, err
+	}
+	var marker [16]byte
+	if _, err := io.ReadFull(br, marker[:]); err != nil {
+		return nil, err
+	}
+	if marker != sync {
+		return nil, errors.New("sync marker mismatch")
+	}
+	switch codec {
+	case "deflate":
+		return readAllLimited(flate.NewReader(bytes.NewReader(raw)))
+	case "bzip2":
+		return readAllLimited(bzip2.NewReader(bytes.NewReader(raw)))
+	case "snappy":
+		if len(raw) < 4 {
+			return nil, errSnappyCorrupt
+		}
+		out, err := decodeSnappy(raw[:len(raw)-4], maxBlockBytes)
+		if err != nil {
+			return nil, err
+		}
+		if crc32.ChecksumIEEE(out) != binary.BigEndian.Uint32(raw[len(raw)-4:]) {
+			return nil, errors.New("snappy: checksum mismatch")
+		}
+		return out, nil
+	}
+	return raw, nil
+}
+
+func readAllLimited(r io.Reader) ([]byte, error) {
+	out, err := io.ReadAll(io.LimitReader(r, maxBlockBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(out) > maxBlockBytes {
+		return nil, fmt.Errorf("block decompresses to more than %d bytes", maxBlockBytes)
+	}
+	return out, nil
+}
+
+// skipAvroBlocks counts the objects in the rest of the file without
+// decompressing them. With count >= 0, a block whose header has already been
+// read is counted and skipped first.
+func skipAvroBlocks(br *bufio.Reader, count, size int64) (int64, error) {
+	var total int64
+	for {
+		if count >= 0 {
+			total += count
+			if _, err := br.Discard(int(size) + 16); err != nil {
+				return 0, err
+			}
+		}
+		var err error
+		count, size, err = readBlockHeader(br)
+		if err == io.EOF {
+			return total, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+func readLong(br io.ByteReader) (int64, error) {
+	u, err := binary.ReadUvarint(br)
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, err
+	}
+	return int64(u>>1) ^ -int64(u&1), nil
+}
+
+func readBytes(br *bufio.Reader, limit int64) ([]byte, error) {
+	n, err := readLong(br)
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 || n > limit {
+		return nil, fmt.Errorf("invalid length %d", n)
+	}
+	b := make([]byte, n)
+	_, err = io.ReadFull(br, b)
+	return b, err
+}
+
+// parseAvroSchema parses the writer's schema. Named types may be referred to
+// by name after their definition, by full or short name.
+func parseAvroSchema(data []byte) (*avroType, error) {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	p := &schemaParser{named: map[string]*avroType{}}
+	t, err := p.parse(v, "", 0)
+	if err != nil {
+		return nil, err
+	}
+	if t.kind != "record" {
+		// A file of bare values is one column.
+		return &avroType{kind: "record", fields: []avroField{{name: "value", typ: t}}}, nil
+	}
+	return t, nil
+}
+
+type schemaParser struct {
+	named map[string]*avroType
+}
+
+func (p *schemaParser) parse(v any, namespace string, depth int) (*avroType, error) {
+	if depth > maxAvroDepth {
+		return nil, errors.New("schema nested too deeply")
+	}
+	switch v := v.(type) {
+	case string:
+		switch v {
+		case "null", "boolean", "int", "long", "float", "double", "bytes", "string":
+			return &avroType{kind: v}, nil
+		}
+		if t := p.named[v]; t != nil {
+			return t, nil
+		}
+		if t := p.named[namespace+"."+v]; t != nil {
+			return t, nil
+		}
+		return nil, fmt.Errorf("unknown type %q", v)
+	case []any:
+		t := &avroType{kind: "union"}
+		for _, b := range v {
+			bt, err := p.parse(b, namespace, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			t.branches = append(t.branches, bt)
+		}
+		return t, nil
+	case map[string]any:
+		kind, _ := v["type"].(string)
+		if kind == "" {
+			// {"type": {...}} wraps another schema.
+			return p.parse(v["type"], namespace, depth+1)
+		}
+		t := &avroType{kind: kind}
+		t.logical, _ = v["logicalType"].(string)
+		if s, ok := v["scale"].(float64); ok {
+			t.scale = int(s)
+		}
+		switch kind {
+		case "record", "error", "enum", "fixed":
+			t.kind = kind
+			if kind == "error" {
+				t.kind = "record"
+			}
+			name, _ := v["name"].(string)
+			if ns, ok := v["namespace"].(string); ok {
+				namespace = ns
+			}
+			if name != "" {
+				p.named[name] = t
+				if i := strings.LastIndexByte(name, '.'); i >= 0 {
+					namespace = name[:i]
+					p.named[name[i+1:]] = t
+				} else if namespace != "" {
+					p.named[namespace+"."+name] = t
+				}
+			}
+		}
+		switch t.kind {
+		case "record":
+			fields, _ := v["fields"].([]any)
+			for _, f := range fields {
+				fm, _ := f.(map[string]any)
+				name, _ := fm["name"].(string)
+				ft, err := p.parse(fm["type"], namespace, depth+1)
+				if err != nil {
+					return nil, fmt.Errorf("field %q: %w", name, err)
+				}
+				t.fields = append(t.fields, avroField{name: name, typ: ft})
+			}
+		case "enum":
+			syms, _ := v["symbols"].([]any)
+			for _, s := range syms {
+				str, _ := s.(string)
+				t.symbols = append(t.symbols, str)
+			}
+		case "fixed":
+			size, _ := v["size"].(float64)
+			if size < 0 || size > 1<<20 {
+				return nil, errors.New("invalid fixed size")
+			}
+			t.size = int(size)
+		case "array":
+			items, err := p.parse(v["items"], namespace, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			t.items = items
+		case "map":
+			values, err := p.parse(v["values"], namespace, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			t.items = values
+		case "null", "boolean", "int", "long", "float", "double", "bytes", "string":
+		default:
+			return p.parse(kind, namespace, depth+1)
+		}
+		return t, nil
+	}
+	return nil, errors.New("invalid schema")
+}
+
+// avroColumns lays out a record's fields as columns, flattening a nested
+// record, or an optional one, into dotted names.
+func avroColumns(t *avroType, prefix string, names *[]string, depth int) []avroPlan {
+	plan := make([]avroPlan, 0, len(t.fields))
+	for _, f := range t.fields {
+		name := prefix + f.name
+		if rec := flattenable(f.typ); rec != nil && depth < maxFlattenDepth {
+			plan = append(plan, avroPlan{typ: f.typ, col: -1, nested: avroColumns(rec, name+".", names, depth+1)})
+			continue
+		}
+		plan = append(plan, avroPlan{typ: f.typ, col: len(*names)})
+		*names = append(*names, name)
+	}
+	return plan
+}
+
+// flattenable returns the record a field holds when it is a record or a union
+// of null and one record.
+func flattenable(t *avroType) *avroType {
+	if t.kind == "record" {
+		return t
+	}
+	if t.kind == "union" && len(t.branches) == 2 {
+		for i, b := range t.branches {
+			if b.kind == "record" && t.branches[1-i].kind == "null" {
+				return b
+			}
+		}
+	}
+	return nil
+}
+
+type avroDecoder struct {
+	b     []byte
+	pos   int
+	items int
+}
+
+var errAvroTruncated = errors.New("record runs past its block")
+
+// record decodes one record into its columns.
+func (d *avroDecoder) record(plan []avroPlan, out []string, depth int) error {
+	d.items = 0
+	return d.fields(plan, out, depth)
+}
+
+func (d *avroDecoder) fields(plan []avroPlan, out []string, depth int) error {
+	for _, p := range plan {
+		if p.nested == nil {
+			s, err := d.value(p.typ, depth)
+			if err != nil {
+				return err
+			}
+			out[p.col] = s
+			continue
+		}
+		if p.typ.kind == "union" {
+			i, err := d.long()
+			if err != nil {
+				return err
+			}
+			if i < 0 || i >= int64(len(p.typ.branches)) {
+				return errors.New("invalid union branch")
+			}
+			if p.typ.branches[i].kind == "null" {
+				continue
+			}
+		}
+		if err := d.fields(p.nested, out, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// value decodes one value and writes it as text.
+func (d *avroDecoder) value(t *avroType, depth int) (string, error) {
+	if depth > maxAvroDepth {
+		return "", errors.New("value nested too deeply")
+	}
+	switch t.kind {
+	case "null":
+		return "", nil
+	case "boolean":
+		if d.pos >= len(d.b) {
+			return "", errAvroTruncated
+		}
+		d.pos++
+		return strconv.FormatBool(d.b[d.pos-1] != 0), nil
+	case "int", "long":
+		v, err := d.long()
+		if err != nil {
+			return "", err
+		}
+		switch t.logical {
+		case "date":
+			return formatDate(v), nil
+		case "timestamp-millis", "local-timestamp-millis":
+			return formatTimestamp(v, unitMillis, strings.HasPrefix(t.logical, "local")), nil
+		case "timestamp-micros", "local-timestamp-micros":
+			return formatTimestamp(v, unitMicros, strings.HasPrefix(t.logical, "local")), nil
+		case "timestamp-nanos", "local-timestamp-nanos":
+			return formatTimestamp(v, unitNanos, strings.HasPrefix(t.logical, "local")), nil
+		}
+		return strconv.FormatInt(v, 10), nil
+	case "float":
+		if d.pos+4 > len(d.b) {
+			return "", errAvroTruncated
+		}
+		v := math.Float32frombits(binary.LittleEndian.Uint32(d.b[d.pos:]))
+		d.pos += 4
+		return formatFloat(float64(v), 32), nil
+	case "double":
+		if d.pos+8 > len(d.b) {
+			return "", errAvroTruncated
+		}
+		v := math.Float64frombits(binary.LittleEndian.Uint64(d.b[d.pos:]))
+		d.pos += 8
+		return formatFloat(v, 64), nil
+	case "bytes", "string", "fixed":
+		var b []byte
+		if t.kind == "fixed" {
+			if d.pos+t.size > len(d.b) {
+				return "", errAvroTruncated
+			}
+			b = d.b[d.pos : d.pos+t.size]
+			d.pos += t.size
+		} else {
+			n, err := d.long()
+			if err != nil {
+				return "", err
+			}
+			if n < 0 || n > int64(len(d.b)-d.pos) {
+				return "", errAvroTruncated
+			}
+			b = d.b[d.pos : d.pos+int(n)]
+			d.pos += int(n)
+		}
+		switch {
+		case t.logical == "decimal" && t.kind != "string":
+			return formatDecimal(b, t.scale), nil
+		case t.logical == "uuid" && t.kind == "fixed" && len(b) == 16:
+			return formatUUID(b), nil
+		}
+		return bytesText(b), nil
+	case "enum":
+		i, err := d.long()
+		if err != nil {
+			return "", err
+		}
+		if i < 0 || i >= int64(len(t.symbols)) {
+			return "", errors.New("invalid enum symbol")
+		}
+		return t.symbols[i], nil
+	case "union":
+		i, err := d.long()
+		if err != nil {
+			return "", err
+		}
+		if i < 0 || i >= int64(len(t.branches)) {
+			return "", errors.New("invalid union branch")
+		}
+		return d.value(t.branches[i], depth+1)
+	case "record":
+		var parts []string
+		for _, f := range t.fields {
+			s, err := d.value(f.typ, depth+1)
+			if err != nil {
+				return "", err
+			}
+			if s != "" {
+				parts = append(parts, f.name+": "+s)
+			}
+		}
+		return strings.Join(parts, ", "), nil
+	case "array", "map":
+		var parts []string
+		for {
+			n, err := d.long()
+			if err != nil {
+				return "", err
+			}
+			if n == 0 {
+				break
+			}
+			if n < 0 {
+				n = -n
+				if _, err := d.long(); err != nil {
+					return "", err
+				}
+			}
+			for i := int64(0); i < n; i++ {
+				if d.items++; d.items > maxAvroItems {
+					return "", errors.New("too many array or map elements")
+				}
+				key := ""
+				if t.kind == "map" {
+					k, err := d.value(&avroType{kind: "string"}, depth+1)
+					if err != nil {
+						return "", err
+					}
+					key = k + ": "
+				}
+				s, err := d.value(t.items, depth+1)
+				if err != nil {
+					return "", err
+				}
+				if s != "" {
+					parts = append(parts, key+s)
+				}
+			}
+		}
+		return strings.Join(parts, ", "), nil
+	}
+	return "", fmt.Errorf("unsupported type %q", t.kind)
+}
+
+func (d *avroDecoder) long() (int64, error) {
+	u, n := binary.Uvarint(d.b[d.pos:])
+	if n <= 0 {
+		return 0, errAvroTruncated
+	}
+	d.pos += n
+	return int64(u>>1) ^ -int64(u&1), nil
+}
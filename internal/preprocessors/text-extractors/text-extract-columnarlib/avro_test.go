@@ -0,0 +1,192 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package textextractcolumnarlib
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const personSchema = `{
+  "type": "record", "name": "Person", "namespace": "com.example",
+  "fields": [
+    {"name": "id", "type": "long"},
+    {"name": "name", "type": "string"},
+    {"name": "ssn", "type": ["null", "string"]},
+    {"name": "dob", "type": {"type": "int", "logicalType": "date"}},
+    {"name": "balance", "type": {"type": "bytes", "logicalType": "decimal", "precision": 10, "scale": 2}},
+    {"name": "address", "type": ["null", {"type": "record", "name": "Address", "fields": [
+      {"name": "street", "type": "string"},
+      {"name": "city", "type": "string"}
+    ]}]},
+    {"name": "phones", "type": {"type": "array", "items": "string"}},
+    {"name": "status", "type": {"type": "enum", "name": "Status", "symbols": ["ACTIVE", "CLOSED"]}},
+    {"name": "attrs", "type": {"type": "map", "values": "double"}},
+    {"name": "home", "type": ["null", "com.example.Address"]}
+  ]
+}`
+
+type person struct {
+	id      int64
+	name    string
+	ssn     string // "" is null
+	dob     int64
+	balance []byte
+	street  string // "" is a null address
+	city    string
+	phones  []string
+	status  int64
+	attrs   map[string]float64
+}
+
+func (p person) encode() []byte {
+	b := avroLong(nil, p.id)
+	b = avroString(b, p.name)
+	if p.ssn == "" {
+		b = avroLong(b, 0)
+	} else {
+		b = avroString(avroLong(b, 1), p.ssn)
+	}
+	b = avroLong(b, p.dob)
+	b = append(avroLong(b, int64(len(p.balance))), p.balance...)
+	if p.street == "" {
+		b = avroLong(b, 0)
+	} else {
+		b = avroString(avroString(avroLong(b, 1), p.street), p.city)
+	}
+	if len(p.phones) > 0 {
+		// A negative count carries the block's size.
+		var items []byte
+		for _, ph := range p.phones {
+			items = avroString(items, ph)
+		}
+		b = avroLong(avroLong(b, -int64(len(p.phones))), int64(len(items)))
+		b = append(b, items...)
+	}
+	b = avroLong(b, 0)
+	b = avroLong(b, p.status)
+	if len(p.attrs) > 0 {
+		b = avroLong(b, int64(len(p.attrs)))
+		for k, v := range p.attrs {
+			b = avroDouble(avroString(b, k), v)
+		}
+	}
+	b = avroLong(b, 0)
+	return avroLong(b, 0) // home: null
+}
+
+var people = []person{
+	{1, "Alice", "123-45-6789", 0, []byte{0x30, 0x39}, "1 Main St", "Springfield", []string{"555-0100", "555-0101"}, 0, map[string]float64{"score": 4111111111111111}},
+	{2, "Bob", "", 10957, []byte{0xfb}, "", "", nil, 1, nil},
+	{3, "Carol", "987-65-4321", 19000, []byte{0x00}, "2 Oak Ave", "Shelbyville", []string{"555-0199"}, 0, nil},
+}
+
+const peopleAvroText = "id\tname\tssn\tdob\tbalance\taddress.street\taddress.city\tphones\tstatus\tattrs\thome.street\thome.city\n" +
+	"1\tAlice\t123-45-6789\t1970-01-01\t123.45\t1 Main St\tSpringfield\t555-0100, 555-0101\tACTIVE\tscore: 4111111111111111\t\t\n" +
+	"2\tBob\t\t2000-01-01\t-0.05\t\t\t\tCLOSED\t\t\t\n" +
+	"3\tCarol\t987-65-4321\t2022-01-08\t0.00\t2 Oak Ave\tShelbyville\t555-0199\tACTIVE\t\t\t\n"
+
+func encodePeople(ps ...person) [][]byte {
+	var out [][]byte
+	for _, p := range ps {
+		out = append(out, p.encode())
+	}
+	return out
+}
+
+func extractAvro(t *testing.T, data []byte, opts Options) *Extraction {
+	t.Helper()
+	ex, err := ExtractAvro(bytes.NewReader(data), opts)
+	if err != nil {
+		t.Fatalf("ExtractAvro: %v", err)
+	}
+	return ex
+}
+
+func TestExtractAvro(t *testing.T) {
+	for _, codec := range []string{"", "null", "deflate", "snappy"} {
+		w := &avroWriter{schema: personSchema, codec: codec, blocks: [][][]byte{
+			encodePeople(people[0], people[1]), encodePeople(people[2]),
+		}}
+		data := w.bytes(t)
+		if !IsAvro(data) {
+			t.Fatal("IsAvro = false")
+		}
+		ex := extractAvro(t, data, Options{})
+		if ex.Text != peopleAvroText {
+			t.Errorf("codec %q: Text =\n%s\nwant\n%s", codec, ex.Text, peopleAvroText)
+		}
+		if ex.TotalRows != 3 || ex.RowsRead != 3 || ex.Stopped != Complete || len(ex.Notes) != 0 {
+			t.Errorf("codec %q: TotalRows %d, RowsRead %d, Stopped %v, Notes %q",
+				codec, ex.TotalRows, ex.RowsRead, ex.Stopped, ex.Notes)
+		}
+	}
+}
+
+func TestAvroSampleRowsCountsTheRest(t *testing.T) {
+	w := &avroWriter{schema: personSchema, codec: "deflate", blocks: [][][]byte{
+		encodePeople(people[0], people[1]), encodePeople(people[2], people[0]),
+	}}
+	data := w.bytes(t)
+	for _, n := range []int64{1, 2, 3} {
+		ex := extractAvro(t, data, Options{SampleRows: n})
+		if ex.RowsRead != n || ex.TotalRows != 4 || ex.Stopped != StoppedAtSample {
+			t.Errorf("SampleRows %d: RowsRead %d, TotalRows %d, Stopped %v", n, ex.RowsRead, ex.TotalRows, ex.Stopped)
+		}
+		if got := len(ex.Rows); int64(got) != n || ex.Rows[got-1].Row != n {
+			t.Errorf("SampleRows %d: Rows = %+v", n, ex.Rows)
+		}
+	}
+}
+
+func TestAvroUnsupportedCodecIsAnError(t *testing.T) {
+	w := &avroWriter{schema: personSchema, codec: "zstandard"}
+	_, err := ExtractAvro(bytes.NewReader(w.bytes(t)), Options{})
+	if err == nil || err.Error() != "zstandard compression is not supported" {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestAvroDamageIsDisclosed(t *testing.T) {
+	w := &avroWriter{schema: personSchema, blocks: [][][]byte{encodePeople(people[0]), encodePeople(people[1])}}
+	data := w.bytes(t)
+	// Break the second block's sync marker.
+	data[len(data)-1] ^= 0xff
+	ex := extractAvro(t, data, Options{})
+	if ex.RowsRead != 1 || ex.TotalRows != -1 {
+		t.Errorf("RowsRead %d, TotalRows %d", ex.RowsRead, ex.TotalRows)
+	}
+	want := "rows after 1: sync marker mismatch, so the rest of the file was NOT scanned"
+	if len(ex.Notes) != 1 || ex.Notes[0] != want {
+		t.Errorf("Notes = %q, want %q", ex.Notes, want)
+	}
+
+	// A record that runs past its block.
+	w.blocks = [][][]byte{{people[0].encode()[:10]}}
+	ex = extractAvro(t, w.bytes(t), Options{})
+	if ex.RowsRead != 0 || len(ex.Notes) != 1 || !strings.Contains(ex.Notes[0], "record runs past its block") {
+		t.Errorf("RowsRead %d, Notes %q", ex.RowsRead, ex.Notes)
+	}
+}
+
+func TestAvroBareValues(t *testing.T) {
+	w := &avroWriter{schema: `"string"`, blocks: [][][]byte{{avroString(nil, "a\tb"), avroString(nil, "c")}}}
+	ex := extractAvro(t, w.bytes(t), Options{})
+	if ex.Text != "value\na b\nc\n" {
+		t.Errorf("Text = %q", ex.Text)
+	}
+}
+
+func TestAvroRejectsBadHeader(t *testing.T) {
+	for name, b := range map[string][]byte{
+		"no magic":   []byte("Obj\x02"),
+		"empty":      nil,
+		"bad schema": (&avroWriter{schema: `{"type": "record", "fields": [{"name": "x", "type": "Nope"}]}`}).bytes(t),
+	} {
+		if _, err := ExtractAvro(bytes.NewReader(b), Options{}); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package textextractcolumnarlib reads the rows of Parquet and Avro files.
+//
+// Both formats are read directly, with no Arrow or Avro library: a Parquet file
+// through its Thrift footer, one row group at a time and, within a row group,
+// one page per column at a time; an Avro object container file one block at a
+// time. Memory therefore follows the largest page or block, not the file,
+// which matters because a data-lake export can decompress to many times its
+// size.
+//
+// A file becomes one table of text, a header line of column names and a line
+// per row, tab-separated, so a column name labels the values beneath it for the
+// label-gated validators exactly as a CSV header does. Nested Parquet columns
+// and nested Avro records are flattened to dotted names ("address.city"), and a
+// repeated value is written as its elements joined by ", ".
+package textextractcolumnarlib
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// MaxTextBytes caps the text extracted from one file, as the PDF extractor's
+// per-document limit does. Rows past it are not read, and Extraction.Stopped
+// says so.
+const MaxTextBytes = 200 * 1024 * 1024
+
+// maxBlockBytes bounds one decompressed Parquet page or Avro block. A real
+// writer's pages are a megabyte or so, and its blocks rarely reach tens of
+// megabytes; the bound is what a forged size field cannot get past.
+const maxBlockBytes = 256 * 1024 * 1024
+
+// Options controls how much of a file is read.
+type Options struct {
+	// SampleRows, when positive, stops after that many rows, for a fast
+	// classification of a file too large to read through.
+	SampleRows int64
+}
+
+// StopReason says why rows were left unread.
+type StopReason int
+
+const (
+	// Complete means every row was read.
+	Complete StopReason = iota
+	// StoppedAtSample means Options.SampleRows rows were read.
+	StoppedAtSample
+	// StoppedAtTextLimit means the text reached MaxTextBytes.
+	StoppedAtTextLimit
+)
+
+// Extraction is the text of one file.
+type Extraction struct {
+	// Text is a header line of column names and one line per row. Values are
+	// separated by tabs and never contain one.
+	Text string
+	// Columns names the columns of Text, in order.
+	Columns []string
+	// Rows locates each row of Text.
+	Rows []RowText
+	// TotalRows is the file's row count, or -1 when it could not be counted.
+	TotalRows int64
+	// RowsRead is the number of rows in Text.
+	RowsRead int64
+	// Stopped says why rows were left unread; see also Notes.
+	Stopped StopReason
+	// Notes discloses what was not read: a column in a compression the reader
+	// does not support, a damaged row group.
+	Notes []string
+}
+
+// RowText records that row Row (1-based, counted through the file) is line
+// Line (0-based) of Extraction.Text.
+type RowText struct {
+	Row  int64
+	Line int
+}
+
+// tableWriter builds an Extraction row by row, within the text limit and the
+// sample.
+type tableWriter struct {
+	b     strings.Builder
+	ex    *Extraction
+	opts  Options
+	line  int
+	noted map[string]bool
+}
+
+func newTableWriter(columns []string, opts Options) *tableWriter {
+	w := &tableWriter{ex: &Extraction{Columns: columns, TotalRows: -1}, opts: opts, noted: map[string]bool{}}
+	for i, c := range columns {
+		if i > 0 {
+			w.b.WriteByte('\t')
+		}
+		w.b.WriteString(cleanCell(c))
+	}
+	w.b.WriteByte('\n')
+	w.line = 1
+	return w
+}
+
+// wantRow reports whether another row should be read.
+func (w *tableWriter) wantRow() bool {
+	if w.ex.Stopped != Complete {
+		return false
+	}
+	if w.opts.SampleRows > 0 && w.ex.RowsRead >= w.opts.SampleRows {
+		w.ex.Stopped = StoppedAtSample
+		return false
+	}
+	return true
+}
+
+// addRow writes one row, or reports false when the text limit leaves no room
+// for it.
+func (w *tableWriter) addRow(row int64, values []string) bool {
+	size := len(values)
+	for _, v := range values {
+		size += len(v)
+	}
+	if w.b.Len()+size > MaxTextBytes {
+		w.ex.Stopped = StoppedAtTextLimit
+		return false
+	}
+	for i, v := range values {
+		if i > 0 {
+			w.b.WriteByte('\t')
+		}
+		w.b.WriteString(cleanCell(v))
+	}
+	w.b.WriteByte('\n')
+	w.ex.Rows = append(w.ex.Rows, RowText{Row: row, Line: w.line})
+	w.line++
+	w.ex.RowsRead++
+	return true
+}
+
+// note records a disclosure once.
+func (w *tableWriter) note(s string) {
+	if !w.noted[s] {
+		w.noted[s] = true
+		w.ex.Notes = append(w.ex.Notes, s)
+	}
+}
+
+func (w *tableWriter) finish() *Extraction {
+	w.ex.Text = w.b.String()
+	return w.ex
+}
+
+// cleanCell keeps a value on its line and in its column.
+func cleanCell(s string) string {
+	if !strings.ContainsAny(s, "\t\r\n") {
+		return s
+	}
+	return strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\r' || r == '\n' {
+			return ' '
+		}
+		return r
+	}, s)
+}
+
+// formatFloat writes a number the way a person would type it: an account
+// number stored as a double reads 4111111111111111, not 4.111111111111111e+15.
+func formatFloat(v float64, bits int) string {
+	if math.Abs(v) < 1e21 {
+		return strconv.FormatFloat(v, 'f', -1, bits)
+	}
+	return strconv.FormatFloat(v, 'g', -1, bits)
+}
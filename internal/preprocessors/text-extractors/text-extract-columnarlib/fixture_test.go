@@ -0,0 +1,428 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package textextractcolumnarlib
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"encoding/binary"
+	"hash/crc32"
+	"math"
+	"sort"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Minimal Parquet and Avro writers, for tests only.
+//
+// The repo carries no binary fixtures, and neither format has a writer in the
+// standard library. These write the formats from their specifications: a
+// Parquet footer in the Thrift compact protocol with pages built by the tests,
+// and an Avro container whose records the tests encode. Snappy output is all
+// literals, which is valid snappy; the decoder's copy paths are covered by
+// hand-made vectors instead.
+
+// encodeThrift writes a struct in the compact protocol. Integers are written
+// as i64 whatever the IDL says; the zigzag varint is the same, and only the
+// type nibble differs.
+func encodeThrift(s tstruct) []byte {
+	var b []byte
+	ids := make([]int, 0, len(s))
+	for id := range s {
+		ids = append(ids, int(id))
+	}
+	sort.Ints(ids)
+	last := 0
+	for _, id := range ids {
+		v := s[int16(id)]
+		typ := thriftType(v)
+		if b2, ok := v.(bool); ok {
+			typ = ctFalse
+			if b2 {
+				typ = ctTrue
+			}
+		}
+		if d := id - last; d > 0 && d <= 15 {
+			b = append(b, byte(d<<4)|typ)
+		} else {
+			b = append(b, typ)
+			b = binary.AppendUvarint(b, zz(int64(id)))
+		}
+		last = id
+		if typ != ctTrue && typ != ctFalse {
+			b = appendThriftValue(b, v)
+		}
+	}
+	return append(b, ctStop)
+}
+
+func thriftType(v any) byte {
+	switch v.(type) {
+	case int, int64:
+		return ctI64
+	case bool:
+		return ctTrue
+	case string, []byte:
+		return ctBinary
+	case []any:
+		return ctList
+	case tstruct:
+		return ctStruct
+	}
+	panic("fixture: thrift value")
+}
+
+func appendThriftValue(b []byte, v any) []byte {
+	switch v := v.(type) {
+	case int:
+		return binary.AppendUvarint(b, zz(int64(v)))
+	case int64:
+		return binary.AppendUvarint(b, zz(v))
+	case string:
+		return append(binary.AppendUvarint(b, uint64(len(v))), v...)
+	case []byte:
+		return append(binary.AppendUvarint(b, uint64(len(v))), v...)
+	case tstruct:
+		return append(b, encodeThrift(v)...)
+	case []any:
+		typ := byte(ctI64)
+		if len(v) > 0 {
+			typ = thriftType(v[0])
+		}
+		if len(v) < 15 {
+			b = append(b, byte(len(v)<<4)|typ)
+		} else {
+			b = append(b, 0xf0|typ)
+			b = binary.AppendUvarint(b, uint64(len(v)))
+		}
+		for _, e := range v {
+			b = appendThriftValue(b, e)
+		}
+		return b
+	}
+	panic("fixture: thrift value")
+}
+
+func zz(v int64) uint64 { return uint64(v<<1) ^ uint64(v>>63) }
+
+// pqChunk is one column chunk: its codec and its pages, header and body.
+type pqChunk struct {
+	codec     int64
+	numValues int64
+	pages     [][]byte
+}
+
+type pqRowGroup struct {
+	rows   int64
+	chunks []pqChunk
+}
+
+// writeParquet lays out the row groups' pages and writes the footer.
+func writeParquet(t *testing.T, schema []tstruct, rowGroups []pqRowGroup) []byte {
+	t.Helper()
+	out := []byte(ParquetMagic)
+	var groups []any
+	var total int64
+	for _, rg := range rowGroups {
+		var chunks []any
+		for _, c := range rg.chunks {
+			start := int64(len(out))
+			for _, p := range c.pages {
+				out = append(out, p...)
+			}
+			chunks = append(chunks, tstruct{
+				2: start,
+				3: tstruct{
+					1: 0, 4: c.codec, 5: c.numValues,
+					6: int64(len(out)) - start, 7: int64(len(out)) - start,
+					9: start,
+				},
+			})
+		}
+		groups = append(groups, tstruct{1: chunks, 2: 0, 3: rg.rows})
+		total += rg.rows
+	}
+	var elems []any
+	for _, e := range schema {
+		elems = append(elems, e)
+	}
+	footer := encodeThrift(tstruct{1: 1, 2: elems, 3: total, 4: groups})
+	out = append(out, footer...)
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(footer)))
+	return append(out, ParquetMagic...)
+}
+
+// compress compresses a page body with a codec.
+func compress(t *testing.T, codec int64, b []byte) []byte {
+	switch codec {
+	case 0:
+		return b
+	case 1:
+		return snappyLiterals(b)
+	case 2:
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write(b)
+		zw.Close()
+		return buf.Bytes()
+	case 4:
+		// BROTLI: never decoded, since the reader refuses the chunk first.
+		return b
+	case 6:
+		zw, err := zstd.NewWriter(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer zw.Close()
+		return zw.EncodeAll(b, nil)
+	}
+	t.Fatalf("fixture codec %d", codec)
+	return nil
+}
+
+// snappyLiterals encodes b as one snappy block of literals.
+func snappyLiterals(b []byte) []byte {
+	out := binary.AppendUvarint(nil, uint64(len(b)))
+	for len(b) > 0 {
+		n := min(len(b), 1<<16)
+		// Tag 61: a two-byte length follows.
+		out = append(out, 61<<2, byte(n-1), byte((n-1)>>8))
+		out = append(out, b[:n]...)
+		b = b[n:]
+	}
+	return out
+}
+
+// dataPageV1 builds a v1 data page: length-prefixed RLE levels, then values.
+func dataPageV1(t *testing.T, codec int64, n int, enc int64, reps, defs []int32, maxRep, maxDef int32, values []byte) []byte {
+	var body []byte
+	if maxRep > 0 {
+		l := encodeHybrid(reps, bitWidth(maxRep))
+		body = append(binary.LittleEndian.AppendUint32(body, uint32(len(l))), l...)
+	}
+	if maxDef > 0 {
+		l := encodeHybrid(defs, bitWidth(maxDef))
+		body = append(binary.LittleEndian.AppendUint32(body, uint32(len(l))), l...)
+	}
+	body = append(body, values...)
+	z := compress(t, codec, body)
+	hdr := encodeThrift(tstruct{1: pageData, 2: len(body), 3: len(z), 5: tstruct{1: n, 2: enc, 3: encRLE, 4: encRLE}})
+	return append(hdr, z...)
+}
+
+// dataPageV2 builds a v2 data page: bare levels, uncompressed, then values.
+func dataPageV2(t *testing.T, codec int64, n int, enc int64, reps, defs []int32, maxRep, maxDef int32, values []byte) []byte {
+	var rl, dl []byte
+	if maxRep > 0 {
+		rl = encodeHybrid(reps, bitWidth(maxRep))
+	}
+	if maxDef > 0 {
+		dl = encodeHybrid(defs, bitWidth(maxDef))
+	}
+	z := compress(t, codec, values)
+	rows := n
+	if reps != nil {
+		rows = 0
+		for _, r := range reps {
+			if r == 0 {
+				rows++
+			}
+		}
+	}
+	hdr := encodeThrift(tstruct{
+		1: pageDataV2, 2: len(rl) + len(dl) + len(values), 3: len(rl) + len(dl) + len(z),
+		8: tstruct{1: n, 2: 0, 3: rows, 4: enc, 5: len(dl), 6: len(rl)},
+	})
+	return append(append(append(hdr, rl...), dl...), z...)
+}
+
+// dictPage builds a dictionary page of PLAIN values.
+func dictPage(t *testing.T, codec int64, n int, values []byte) []byte {
+	z := compress(t, codec, values)
+	hdr := encodeThrift(tstruct{1: pageDictionary, 2: len(values), 3: len(z), 7: tstruct{1: n, 2: encPlain}})
+	return append(hdr, z...)
+}
+
+// encodeHybrid writes values as bit-packed runs.
+func encodeHybrid(values []int32, width int) []byte {
+	groups := (len(values) + 7) / 8
+	out := binary.AppendUvarint(nil, uint64(groups)<<1|1)
+	packed := make([]byte, groups*width)
+	bit := 0
+	for _, v := range values {
+		for i := 0; i < width; i++ {
+			if v>>i&1 != 0 {
+				packed[bit/8] |= 1 << (bit % 8)
+			}
+			bit++
+		}
+	}
+	return append(out, packed...)
+}
+
+// dictIndices writes dictionary indices: the bit width, then the hybrid.
+func dictIndices(idx []int32, width int) []byte {
+	return append([]byte{byte(width)}, encodeHybrid(idx, width)...)
+}
+
+func plainByteArrays(vals ...string) []byte {
+	var b []byte
+	for _, v := range vals {
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(v)))
+		b = append(b, v...)
+	}
+	return b
+}
+
+func plainInt32s(vals ...int32) []byte {
+	var b []byte
+	for _, v := range vals {
+		b = binary.LittleEndian.AppendUint32(b, uint32(v))
+	}
+	return b
+}
+
+func plainInt64s(vals ...int64) []byte {
+	var b []byte
+	for _, v := range vals {
+		b = binary.LittleEndian.AppendUint64(b, uint64(v))
+	}
+	return b
+}
+
+func plainDoubles(vals ...float64) []byte {
+	var b []byte
+	for _, v := range vals {
+		b = binary.LittleEndian.AppendUint64(b, math.Float64bits(v))
+	}
+	return b
+}
+
+// encodeDeltaBinaryPacked writes DELTA_BINARY_PACKED with blocks of 128
+// values in four miniblocks.
+func encodeDeltaBinaryPacked(vals []int64) []byte {
+	const blockSize, miniblocks = 128, 4
+	out := binary.AppendUvarint(nil, blockSize)
+	out = binary.AppendUvarint(out, miniblocks)
+	out = binary.AppendUvarint(out, uint64(len(vals)))
+	if len(vals) == 0 {
+		return binary.AppendUvarint(out, 0)
+	}
+	out = binary.AppendUvarint(out, zz(vals[0]))
+	deltas := make([]int64, 0, len(vals))
+	for i := 1; i < len(vals); i++ {
+		deltas = append(deltas, vals[i]-vals[i-1])
+	}
+	for len(deltas) > 0 {
+		block := deltas[:min(len(deltas), blockSize)]
+		deltas = deltas[len(block):]
+		minDelta := block[0]
+		for _, d := range block {
+			minDelta = min(minDelta, d)
+		}
+		out = binary.AppendUvarint(out, zz(minDelta))
+		per := blockSize / miniblocks
+		var widths []byte
+		var minis [][]byte
+		for m := 0; m*per < len(block); m++ {
+			mini := block[m*per : min(len(block), (m+1)*per)]
+			width := 0
+			for _, d := range mini {
+				width = max(width, 64-leadingZeros(uint64(d-minDelta)))
+			}
+			widths = append(widths, byte(width))
+			packed := make([]byte, per*width/8)
+			bit := 0
+			for i := 0; i < per; i++ {
+				var v uint64
+				if i < len(mini) {
+					v = uint64(mini[i] - minDelta)
+				}
+				for k := 0; k < width; k++ {
+					if v>>k&1 != 0 {
+						packed[bit/8] |= 1 << (bit % 8)
+					}
+					bit++
+				}
+			}
+			minis = append(minis, packed)
+		}
+		for len(widths) < miniblocks {
+			widths = append(widths, 0)
+		}
+		out = append(out, widths...)
+		for _, m := range minis {
+			out = append(out, m...)
+		}
+	}
+	return out
+}
+
+func leadingZeros(v uint64) int {
+	n := 0
+	for i := 63; i >= 0 && v>>i&1 == 0; i-- {
+		n++
+	}
+	return n
+}
+
+// Avro.
+
+// avroWriter builds an object container file.
+type avroWriter struct {
+	schema string
+	codec  string
+	sync   [16]byte
+	blocks [][][]byte // records, each already encoded
+}
+
+func (w *avroWriter) bytes(t *testing.T) []byte {
+	t.Helper()
+	copy(w.sync[:], "0123456789abcdef")
+	out := []byte(AvroMagic)
+	meta := map[string]string{"avro.schema": w.schema}
+	if w.codec != "" {
+		meta["avro.codec"] = w.codec
+	}
+	out = avroLong(out, int64(len(meta)))
+	for _, k := range []string{"avro.codec", "avro.schema"} {
+		if v, ok := meta[k]; ok {
+			out = avroString(avroString(out, k), v)
+		}
+	}
+	out = avroLong(out, 0)
+	out = append(out, w.sync[:]...)
+	for _, recs := range w.blocks {
+		var raw []byte
+		for _, r := range recs {
+			raw = append(raw, r...)
+		}
+		switch w.codec {
+		case "deflate":
+			var buf bytes.Buffer
+			fw, _ := flate.NewWriter(&buf, flate.BestCompression)
+			fw.Write(raw)
+			fw.Close()
+			raw = buf.Bytes()
+		case "snappy":
+			crc := crc32.ChecksumIEEE(raw)
+			raw = binary.BigEndian.AppendUint32(snappyLiterals(raw), crc)
+		}
+		out = avroLong(out, int64(len(recs)))
+		out = avroLong(out, int64(len(raw)))
+		out = append(out, raw...)
+		out = append(out, w.sync[:]...)
+	}
+	return out
+}
+
+func avroLong(b []byte, v int64) []byte { return binary.AppendUvarint(b, zz(v)) }
+
+func avroString(b []byte, s string) []byte { return append(avroLong(b, int64(len(s))), s...) }
+
+func avroDouble(b []byte, v float64) []byte {
+	return binary.LittleEndian.AppendUint64(b, math.Float64bits(v))
+}
@@ -0,0 +1,1094 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package textextractcolumnarlib
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// ParquetMagic begins and ends every Parquet file.
+const ParquetMagic = "PAR1"
+
+// IsParquet reports whether data begins with the Parquet magic.
+func IsParquet(data []byte) bool {
+	return len(data) >= len(ParquetMagic) && string(data[:len(ParquetMagic)]) == ParquetMagic
+}
+
+// Physical types.
+const (
+	ptBoolean = iota
+	ptInt32
+	ptInt64
+	ptInt96
+	ptFloat
+	ptDouble
+	ptByteArray
+	ptFixedLenByteArray
+)
+
+// Encodings.
+const (
+	encPlain                = 0
+	encPlainDictionary      = 2
+	encRLE                  = 3
+	encBitPacked            = 4
+	encDeltaBinaryPacked    = 5
+	encDeltaLengthByteArray = 6
+	encDeltaByteArray       = 7
+	encRLEDictionary        = 8
+	encByteStreamSplit      = 9
+)
+
+// Page types.
+const (
+	pageData       = 0
+	pageDictionary = 2
+	pageDataV2     = 3
+)
+
+// codecNames names the compression codecs, for the note on one that is not
+// supported.
+var codecNames = map[int64]string{
+	0: "UNCOMPRESSED", 1: "SNAPPY", 2: "GZIP", 3: "LZO", 4: "BROTLI", 5: "LZ4", 6: "ZSTD", 7: "LZ4_RAW",
+}
+
+// maxPageHeaderBytes bounds one page header. A header is tens of bytes plus
+// its statistics, which writers truncate well below this.
+const maxPageHeaderBytes = 16 * 1024 * 1024
+
+// maxSchemaDepth bounds schema nesting.
+const maxSchemaDepth = 64
+
+// valueKind is what a column's stored values mean.
+type valueKind int
+
+const (
+	kindPlain valueKind = iota
+	kindDate
+	kindTimestamp
+	kindDecimal
+	kindUUID
+	kindUnsigned
+)
+
+// column is one leaf of the schema, which is one column of the table.
+type column struct {
+	name       string
+	physical   int64
+	typeLength int
+	maxDef     int32
+	maxRep     int32
+	kind       valueKind
+	unit       timeUnit
+	local      bool
+	scale      int
+}
+
+type parquetFile struct {
+	r         io.ReaderAt
+	size      int64
+	columns   []column
+	rowGroups []tstruct
+	numRows   int64
+}
+
+// ExtractParquet reads the rows of a Parquet file of the given size.
+func ExtractParquet(r io.ReaderAt, size int64, opts Options) (*Extraction, error) {
+	f, err := openParquet(r, size)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(f.columns))
+	for i, c := range f.columns {
+		names[i] = c.name
+	}
+	w := newTableWriter(names, opts)
+	w.ex.TotalRows = f.numRows
+
+	var first int64 = 1 // the first row of the current row group
+	for gi, rg := range f.rowGroups {
+		n, _ := rg.int(3)
+		if n < 0 {
+			w.note(fmt.Sprintf("row group %d declares %d rows, so it and the row groups after it were NOT scanned", gi+1, n))
+			break
+		}
+		last := first + n - 1
+		if !w.wantRow() {
+			break
+		}
+		chunks := rg.list(1)
+		if len(chunks) != len(f.columns) {
+			w.note(fmt.Sprintf("row group %d has %d column chunks for %d columns, so rows %d-%d were NOT scanned",
+				gi+1, len(chunks), len(f.columns), first, last))
+			first += n
+			continue
+		}
+		cursors := make([]*columnCursor, len(f.columns))
+		for ci := range f.columns {
+			cc, _ := chunks[ci].(tstruct)
+			c, err := f.cursor(cc, &f.columns[ci])
+			if err != nil {
+				w.note(fmt.Sprintf("column %s, rows %d-%d: %v, so those values were NOT scanned",
+					f.columns[ci].name, first, last, err))
+				continue
+			}
+			cursors[ci] = c
+		}
+		values := make([]string, len(f.columns))
+		for row := first; row <= last; row++ {
+			if !w.wantRow() {
+				break
+			}
+			for ci, c := range cursors {
+				values[ci] = ""
+				if c == nil {
+					continue
+				}
+				v, err := c.next()
+				if err != nil {
+					if err == io.EOF {
+						err = errors.New("column chunk ended early")
+					}
+					w.note(fmt.Sprintf("column %s, rows %d-%d: %v, so those values were NOT scanned",
+						f.columns[ci].name, row, last, err))
+					cursors[ci] = nil
+					continue
+				}
+				values[ci] = v
+			}
+			if !w.addRow(row, values) {
+				break
+			}
+		}
+		first += n
+	}
+	return w.finish(), nil
+}
+
+// openParquet reads the footer: the schema and the row group index.
+func openParquet(r io.ReaderAt, size int64) (*parquetFile, error) {
+	if size < 12 {
+		return nil, errors.New("not a Parquet file: too short")
+	}
+	head := make([]byte, 4)
+	tail := make([]byte, 8)
+	if _, err := r.ReadAt(head, 0); err != nil {
+		return nil, err
+	}
+	if _, err := r.ReadAt(tail, size-8); err != nil {
+		return nil, err
+	}
+	if string(head) != ParquetMagic || string(tail[4:]) != ParquetMagic {
+		return nil, errors.New("not a Parquet file: missing PAR1 magic")
+	}
+	footerLen := int64(binary.LittleEndian.Uint32(tail))
+	if footerLen <= 0 || footerLen > size-12 {
+		return nil, fmt.Errorf("invalid footer length %d", footerLen)
+	}
+	footer := make([]byte, footerLen)
+	if _, err := r.ReadAt(footer, size-8-footerLen); err != nil {
+		return nil, err
+	}
+	meta, _, err := readStruct(footer)
+	if err != nil {
+		return nil, fmt.Errorf("invalid footer: %w", err)
+	}
+
+	var elems []tstruct
+	for _, e := range meta.list(2) {
+		s, ok := e.(tstruct)
+		if !ok {
+			return nil, errors.New("invalid schema")
+		}
+		elems = append(elems, s)
+	}
+	if len(elems) == 0 {
+		return nil, errors.New("empty schema")
+	}
+	sw := &schemaWalk{elems: elems, pos: 1}
+	root, _ := elems[0].int(5)
+	for i := int64(0); i < root; i++ {
+		if err := sw.node(nil, nil, 0, 0, annNone, annNone, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	f := &parquetFile{r: r, size: size, columns: sw.cols}
+	f.numRows, _ = meta.int(3)
+	for _, g := range meta.list(4) {
+		if s, ok := g.(tstruct); ok {
+			f.rowGroups = append(f.rowGroups, s)
+		}
+	}
+	return f, nil
+}
+
+// Group annotations that wrap a repeated level in the schema.
+const (
+	annNone = iota
+	annList
+	annMap
+)
+
+type schemaWalk struct {
+	elems []tstruct
+	pos   int
+	cols  []column
+}
+
+// node reads one schema element and its children. label is the column name
+// built so far; the levels a LIST or MAP annotation adds around its elements
+// are left out of it, so a list of strings named tags is the column "tags",
+// not "tags.list.element".
+func (sw *schemaWalk) node(path, label []string, def, rep int32, parent, grand int, depth int) error {
+	if depth > maxSchemaDepth {
+		return errors.New("schema nested too deeply")
+	}
+	if sw.pos >= len(sw.elems) {
+		return errors.New("schema ends early")
+	}
+	e := sw.elems[sw.pos]
+	sw.pos++
+	name := e.str(4)
+	switch repetition, _ := e.int(3); repetition {
+	case 1:
+		def++
+	case 2:
+		def++
+		rep++
+	}
+	path = append(path[:len(path):len(path)], name)
+	if parent != annList && parent != annMap && grand != annList {
+		label = append(label[:len(label):len(label)], name)
+	}
+
+	ann := annNone
+	converted, hasConverted := e.int(6)
+	logical := e.st(10)
+	switch {
+	case logical[3] != nil || (hasConverted && converted == 3):
+		ann = annList
+	case logical[2] != nil || (hasConverted && (converted == 1 || converted == 2)):
+		ann = annMap
+	}
+
+	children, _ := e.int(5)
+	if children > 0 {
+		if children > int64(len(sw.elems)) {
+			return errors.New("invalid schema")
+		}
+		for i := int64(0); i < children; i++ {
+			if err := sw.node(path, label, def, rep, ann, parent, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	c := column{maxDef: def, maxRep: rep}
+	c.name = strings.Join(label, ".")
+	if c.name == "" {
+		c.name = strings.Join(path, ".")
+	}
+	c.physical, _ = e.int(1)
+	tl, _ := e.int(2)
+	c.typeLength = int(tl)
+	switch {
+	case logical[6] != nil || (hasConverted && converted == 6):
+		c.kind = kindDate
+	case logical[8] != nil:
+		c.kind = kindTimestamp
+		ts := logical.st(8)
+		switch u := ts.st(2); {
+		case u[1] != nil:
+			c.unit = unitMillis
+		case u[2] != nil:
+			c.unit = unitMicros
+		default:
+			c.unit = unitNanos
+		}
+		utc, ok := ts.bool(1)
+		c.local = ok && !utc
+	case hasConverted && converted == 9:
+		c.kind, c.unit = kindTimestamp, unitMillis
+	case hasConverted && converted == 10:
+		c.kind, c.unit = kindTimestamp, unitMicros
+	case logical[5] != nil:
+		c.kind = kindDecimal
+		s, _ := logical.st(5).int(1)
+		c.scale = int(s)
+	case hasConverted && converted == 5:
+		c.kind = kindDecimal
+		s, _ := e.int(7)
+		c.scale = int(s)
+	case logical[14] != nil:
+		c.kind = kindUUID
+	case logical[10] != nil:
+		if signed, ok := logical.st(10).bool(2); ok && !signed {
+			c.kind = kindUnsigned
+		}
+	case hasConverted && converted >= 11 && converted <= 14:
+		c.kind = kindUnsigned
+	}
+	if c.physical == ptInt96 {
+		c.kind = kindTimestamp
+	}
+	sw.cols = append(sw.cols, c)
+	return nil
+}
+
+// columnCursor reads one column chunk row by row, holding one page at a time.
+type columnCursor struct {
+	f         *parquetFile
+	col       *column
+	pos, end  int64
+	remaining int64 // level entries not yet loaded
+	codec     int64
+	dict      []string
+
+	defs, reps []int32 // nil when the column has no such levels
+	vals       []string
+	n, i, vi   int
+}
+
+// cursor opens a column chunk.
+func (f *parquetFile) cursor(cc tstruct, col *column) (*columnCursor, error) {
+	if cc == nil {
+		return nil, errors.New("missing column chunk")
+	}
+	if p := cc.str(1); p != "" {
+		return nil, errors.New("the column chunk is stored in another file")
+	}
+	md := cc.st(3)
+	if md == nil {
+		return nil, errors.New("missing column metadata")
+	}
+	codec, _ := md.int(4)
+	switch codec {
+	case 0, 1, 2, 5, 6, 7:
+	default:
+		name := codecNames[codec]
+		if name == "" {
+			name = "codec " + strconv.FormatInt(codec, 10)
+		}
+		return nil, fmt.Errorf("%s compression is not supported", name)
+	}
+	numValues, _ := md.int(5)
+	compressed, _ := md.int(7)
+	start, _ := md.int(9)
+	if dict, ok := md.int(11); ok && dict > 0 && dict < start {
+		start = dict
+	}
+	if start < 4 || compressed < 0 || start > f.size-8 || compressed > f.size-8-start {
+		return nil, errors.New("column chunk lies outside the file")
+	}
+	return &columnCursor{f: f, col: col, pos: start, end: start + compressed, remaining: numValues, codec: codec}, nil
+}
+
+// next returns the value of the next row: a single value, the elements of a
+// repeated one joined by ", ", or "" for a null.
+func (c *columnCursor) next() (string, error) {
+	var parts []string
+	started := false
+	for {
+		if c.i >= c.n {
+			if c.remaining <= 0 || c.pos >= c.end {
+				break
+			}
+			if err := c.loadPage(); err != nil {
+				return "", err
+			}
+			continue
+		}
+		rep := int32(0)
+		if c.reps != nil {
+			rep = c.reps[c.i]
+		}
+		if started && rep == 0 {
+			break
+		}
+		started = true
+		def := c.col.maxDef
+		if c.defs != nil {
+			def = c.defs[c.i]
+		}
+		if def == c.col.maxDef {
+			if c.vi >= len(c.vals) {
+				return "", errors.New("page holds fewer values than its levels declare")
+			}
+			if v := c.vals[c.vi]; v != "" {
+				parts = append(parts, v)
+			}
+			c.vi++
+		}
+		c.i++
+		if c.col.maxRep == 0 {
+			break
+		}
+	}
+	if !started {
+		return "", io.EOF
+	}
+	return strings.Join(parts, ", "), nil
+}
+
+// loadPage reads the next page of the chunk. A dictionary page is kept for the
+// data pages after it; an index page is skipped.
+func (c *columnCursor) loadPage() error {
+	hdr, hdrLen, err := c.pageHeader()
+	if err != nil {
+		return err
+	}
+	typ, _ := hdr.int(1)
+	uncompressed, _ := hdr.int(2)
+	compressed, _ := hdr.int(3)
+	if compressed < 0 || compressed > c.end-c.pos-hdrLen {
+		return errors.New("page runs past its column chunk")
+	}
+	if uncompressed < 0 || uncompressed > maxBlockBytes {
+		return fmt.Errorf("page declares %d bytes, over the %d-byte limit", uncompressed, maxBlockBytes)
+	}
+	body := make([]byte, compressed)
+	if _, err := c.f.r.ReadAt(body, c.pos+hdrLen); err != nil {
+		return err
+	}
+	c.pos += hdrLen + compressed
+
+	switch typ {
+	case pageDictionary:
+		dh := hdr.st(7)
+		n, _ := dh.int(1)
+		data, err := c.decompress(body, int(uncompressed))
+		if err != nil {
+			return err
+		}
+		if n < 0 || n > int64(len(data))+1 {
+			return errors.New("invalid dictionary size")
+		}
+		c.dict, err = c.plain(data, int(n))
+		return err
+
+	case pageData:
+		dh := hdr.st(5)
+		n, _ := dh.int(1)
+		enc, _ := dh.int(2)
+		levelEnc, _ := dh.int(3)
+		if err := c.checkCount(n); err != nil {
+			return err
+		}
+		data, err := c.decompress(body, int(uncompressed))
+		if err != nil {
+			return err
+		}
+		pos := 0
+		c.reps, c.defs = nil, nil
+		if c.col.maxRep > 0 {
+			if c.reps, pos, err = levelsV1(data, pos, c.col.maxRep, int(n), levelEnc); err != nil {
+				return fmt.Errorf("repetition levels: %w", err)
+			}
+		}
+		if c.col.maxDef > 0 {
+			if c.defs, pos, err = levelsV1(data, pos, c.col.maxDef, int(n), levelEnc); err != nil {
+				return fmt.Errorf("definition levels: %w", err)
+			}
+		}
+		return c.setPage(int(n), data[pos:], enc)
+
+	case pageDataV2:
+		dh := hdr.st(8)
+		n, _ := dh.int(1)
+		enc, _ := dh.int(4)
+		defLen, _ := dh.int(5)
+		repLen, _ := dh.int(6)
+		if err := c.checkCount(n); err != nil {
+			return err
+		}
+		if defLen < 0 || repLen < 0 || defLen+repLen > int64(len(body)) {
+			return errors.New("invalid level lengths")
+		}
+		c.reps, c.defs = nil, nil
+		if c.col.maxRep > 0 {
+			if c.reps, err = decodeHybrid(body[:repLen], bitWidth(c.col.maxRep), int(n)); err != nil {
+				return fmt.Errorf("repetition levels: %w", err)
+			}
+		}
+		if c.col.maxDef > 0 {
+			if c.defs, err = decodeHybrid(body[repLen:repLen+defLen], bitWidth(c.col.maxDef), int(n)); err != nil {
+				return fmt.Errorf("definition levels: %w", err)
+			}
+		}
+		data := body[repLen+defLen:]
+		if isCompressed, ok := dh.bool(7); !ok || isCompressed {
+			if data, err = c.decompress(data, int(uncompressed-defLen-repLen)); err != nil {
+				return err
+			}
+		}
+		return c.setPage(int(n), data, enc)
+	}
+	return nil
+}
+
+// checkCount bounds a data page's entry count by what the chunk declared.
+func (c *columnCursor) checkCount(n int64) error {
+	if n < 0 || n > c.remaining {
+		return fmt.Errorf("page declares %d values, more than its column chunk holds", n)
+	}
+	c.remaining -= n
+	return nil
+}
+
+// setPage decodes a data page's values and makes it current.
+func (c *columnCursor) setPage(n int, data []byte, enc int64) error {
+	present := n
+	if c.defs != nil {
+		present = 0
+		for _, d := range c.defs {
+			if d == c.col.maxDef {
+				present++
+			}
+		}
+	}
+	vals, err := c.values(data, enc, present)
+	if err != nil {
+		return err
+	}
+	c.vals, c.n, c.i, c.vi = vals, n, 0, 0
+	return nil
+}
+
+// pageHeader reads the page header at pos, reading more of the chunk only as a
+// large header needs it.
+func (c *columnCursor) pageHeader() (tstruct, int64, error) {
+	avail := c.end - c.pos
+	size := min(avail, 8*1024)
+	for {
+		buf := make([]byte, size)
+		if _, err := c.f.r.ReadAt(buf, c.pos); err != nil && err != io.EOF {
+			return nil, 0, err
+		}
+		hdr, n, err := readStruct(buf)
+		if err == nil {
+			return hdr, int64(n), nil
+		}
+		if err != errThriftTruncated || size >= avail || size >= maxPageHeaderBytes {
+			return nil, 0, fmt.Errorf("invalid page header: %w", err)
+		}
+		size = min(avail, size*4, maxPageHeaderBytes)
+	}
+}
+
+func (c *columnCursor) decompress(b []byte, size int) ([]byte, error) {
+	switch c.codec {
+	case 0:
+		return b, nil
+	case 1:
+		return decodeSnappy(b, maxBlockBytes)
+	case 2:
+		zr, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		out, err := io.ReadAll(io.LimitReader(zr, int64(size)+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(out) != size {
+			return nil, errors.New("gzip: page size mismatch")
+		}
+		return out, nil
+	case 5:
+		return decodeHadoopLZ4(b, size)
+	case 6:
+		out, err := zstdDecoder.DecodeAll(b, make([]byte, 0, size))
+		if err != nil {
+			return nil, fmt.Errorf("zstd: %w", err)
+		}
+		if len(out) != size {
+			return nil, errors.New("zstd: page size mismatch")
+		}
+		return out, nil
+	case 7:
+		return decodeLZ4Block(b, size)
+	}
+	return nil, fmt.Errorf("%s compression is not supported", codecNames[c.codec])
+}
+
+// zstdDecoder decodes ZSTD pages; DecodeAll is safe for concurrent use. Its
+// memory is bounded like every other codec's, so a frame declaring a larger
+// window or content size is refused rather than allocated.
+var zstdDecoder, _ = zstd.NewReader(nil,
+	zstd.WithDecoderConcurrency(0),
+	zstd.WithDecoderMaxMemory(maxBlockBytes),
+	zstd.WithDecoderMaxWindow(maxBlockBytes))
+
+// decodeHadoopLZ4 decodes Parquet's older LZ4 codec: LZ4 blocks, each behind
+// Hadoop's big-endian decompressed and compressed lengths. Some writers wrote a
+// bare block instead, and that is tried when the framing does not fit.
+func decodeHadoopLZ4(b []byte, size int) ([]byte, error) {
+	var out []byte
+	rest := b
+	for len(rest) >= 8 {
+		raw := int(binary.BigEndian.Uint32(rest))
+		n := int(binary.BigEndian.Uint32(rest[4:]))
+		if n > len(rest)-8 || raw > size-len(out) {
+			return decodeLZ4Block(b, size)
+		}
+		block, err := decodeLZ4Block(rest[8:8+n], raw)
+		if err != nil {
+			return decodeLZ4Block(b, size)
+		}
+		out = append(out, block...)
+		rest = rest[8+n:]
+	}
+	if len(rest) != 0 || len(out) != size {
+		return decodeLZ4Block(b, size)
+	}
+	return out, nil
+}
+
+// values decodes n non-null values in an encoding.
+func (c *columnCursor) values(data []byte, enc int64, n int) ([]string, error) {
+	switch enc {
+	case encPlain:
+		return c.plain(data, n)
+	case encPlainDictionary, encRLEDictionary:
+		if n == 0 {
+			return nil, nil
+		}
+		if c.dict == nil {
+			return nil, errors.New("dictionary-encoded page without a dictionary")
+		}
+		if len(data) == 0 || data[0] > 32 {
+			return nil, errors.New("invalid dictionary index width")
+		}
+		idx, err := decodeHybrid(data[1:], int(data[0]), n)
+		if err != nil {
+			return nil, err
+		}
+		out := make([]string, n)
+		for i, k := range idx {
+			if k < 0 || int(k) >= len(c.dict) {
+				return nil, errors.New("dictionary index out of range")
+			}
+			out[i] = c.dict[k]
+		}
+		return out, nil
+	case encRLE:
+		if c.col.physical != ptBoolean {
+			break
+		}
+		if len(data) < 4 {
+			return nil, errors.New("truncated boolean run")
+		}
+		bits, err := decodeHybrid(data[4:], 1, n)
+		if err != nil {
+			return nil, err
+		}
+		out := make([]string, n)
+		for i, b := range bits {
+			out[i] = strconv.FormatBool(b != 0)
+		}
+		return out, nil
+	case encDeltaBinaryPacked:
+		if c.col.physical != ptInt32 && c.col.physical != ptInt64 {
+			break
+		}
+		ints, _, err := decodeDeltaBinaryPacked(data, n)
+		if err != nil {
+			return nil, err
+		}
+		if len(ints) < n {
+			return nil, errors.New("page holds fewer values than its levels declare")
+		}
+		out := make([]string, n)
+		for i, v := range ints[:n] {
+			if c.col.physical == ptInt32 {
+				v = int64(int32(v))
+			}
+			out[i] = c.col.intString(v)
+		}
+		return out, nil
+	case encDeltaLengthByteArray, encDeltaByteArray:
+		if c.col.physical != ptByteArray {
+			break
+		}
+		var vals [][]byte
+		var err error
+		if enc == encDeltaByteArray {
+			vals, err = decodeDeltaByteArray(data, n)
+		} else {
+			vals, _, err = decodeDeltaLengthByteArray(data, n)
+		}
+		if err != nil {
+			return nil, err
+		}
+		out := make([]string, len(vals))
+		for i, v := range vals {
+			out[i] = c.col.bytesString(v)
+		}
+		return out, nil
+	case encByteStreamSplit:
+		width := c.col.typeLength
+		switch c.col.physical {
+		case ptInt32, ptFloat:
+			width = 4
+		case ptInt64, ptDouble:
+			width = 8
+		case ptFixedLenByteArray:
+		default:
+			width = 0
+		}
+		if width <= 0 || len(data) < n*width {
+			return nil, errors.New("invalid byte-stream-split page")
+		}
+		plain := make([]byte, n*width)
+		for i := 0; i < n; i++ {
+			for k := 0; k < width; k++ {
+				plain[i*width+k] = data[k*n+i]
+			}
+		}
+		return c.plain(plain, n)
+	}
+	return nil, fmt.Errorf("encoding %d is not supported for this column", enc)
+}
+
+// plain decodes n PLAIN-encoded values.
+func (c *columnCursor) plain(data []byte, n int) ([]string, error) {
+	col := c.col
+	out := make([]string, 0, n)
+	short := errors.New("page holds fewer values than its levels declare")
+	pos := 0
+	for i := 0; i < n; i++ {
+		switch col.physical {
+		case ptBoolean:
+			if i/8 >= len(data) {
+				return nil, short
+			}
+			out = append(out, strconv.FormatBool(data[i/8]>>(i%8)&1 != 0))
+			continue
+		case ptInt32:
+			if pos+4 > len(data) {
+				return nil, short
+			}
+			out = append(out, col.intString(int64(int32(binary.LittleEndian.Uint32(data[pos:])))))
+			pos += 4
+		case ptInt64:
+			if pos+8 > len(data) {
+				return nil, short
+			}
+			out = append(out, col.intString(int64(binary.LittleEndian.Uint64(data[pos:]))))
+			pos += 8
+		case ptInt96:
+			if pos+12 > len(data) {
+				return nil, short
+			}
+			nanos := int64(binary.LittleEndian.Uint64(data[pos:]))
+			julian := int64(binary.LittleEndian.Uint32(data[pos+8:]))
+			// Julian day 2440588 is 1970-01-01.
+			out = append(out, formatTimestamp((julian-2440588)*86400*1e9+nanos, unitNanos, false))
+			pos += 12
+		case ptFloat:
+			if pos+4 > len(data) {
+				return nil, short
+			}
+			out = append(out, formatFloat(float64(math.Float32frombits(binary.LittleEndian.Uint32(data[pos:]))), 32))
+			pos += 4
+		case ptDouble:
+			if pos+8 > len(data) {
+				return nil, short
+			}
+			out = append(out, formatFloat(math.Float64frombits(binary.LittleEndian.Uint64(data[pos:])), 64))
+			pos += 8
+		case ptByteArray:
+			if pos+4 > len(data) {
+				return nil, short
+			}
+			l := int(binary.LittleEndian.Uint32(data[pos:]))
+			pos += 4
+			if l < 0 || l > len(data)-pos {
+				return nil, short
+			}
+			out = append(out, col.bytesString(data[pos:pos+l]))
+			pos += l
+		case ptFixedLenByteArray:
+			l := col.typeLength
+			if l < 0 || l > len(data)-pos {
+				return nil, short
+			}
+			out = append(out, col.bytesString(data[pos:pos+l]))
+			pos += l
+		default:
+			return nil, fmt.Errorf("physical type %d is not supported", col.physical)
+		}
+	}
+	return out, nil
+}
+
+// intString writes a stored integer as what it means.
+func (c *column) intString(v int64) string {
+	switch c.kind {
+	case kindDate:
+		return formatDate(v)
+	case kindTimestamp:
+		return formatTimestamp(v, c.unit, c.local)
+	case kindDecimal:
+		return scaleDecimal(strconv.FormatInt(v, 10), c.scale)
+	case kindUnsigned:
+		if c.physical == ptInt32 {
+			return strconv.FormatUint(uint64(uint32(v)), 10)
+		}
+		return strconv.FormatUint(uint64(v), 10)
+	}
+	return strconv.FormatInt(v, 10)
+}
+
+// bytesString writes a stored byte string as what it means.
+func (c *column) bytesString(b []byte) string {
+	switch {
+	case c.kind == kindDecimal:
+		return formatDecimal(b, c.scale)
+	case c.kind == kindUUID && len(b) == 16:
+		return formatUUID(b)
+	}
+	return bytesText(b)
+}
+
+// levelsV1 reads a data page v1's levels: a length-prefixed RLE run, or the
+// deprecated BIT_PACKED form.
+func levelsV1(data []byte, pos int, max int32, n int, enc int64) ([]int32, int, error) {
+	width := bitWidth(max)
+	if enc == encBitPacked {
+		size := (n*width + 7) / 8
+		if size > len(data)-pos {
+			return nil, 0, errors.New("truncated")
+		}
+		out := make([]int32, n)
+		br := bitReader{b: data[pos : pos+size], msbFirst: true}
+		for i := range out {
+			out[i] = int32(br.read(width))
+		}
+		return out, pos + size, nil
+	}
+	if pos+4 > len(data) {
+		return nil, 0, errors.New("truncated")
+	}
+	size := int(binary.LittleEndian.Uint32(data[pos:]))
+	pos += 4
+	if size < 0 || size > len(data)-pos {
+		return nil, 0, errors.New("truncated")
+	}
+	out, err := decodeHybrid(data[pos:pos+size], width, n)
+	return out, pos + size, err
+}
+
+// bitWidth is the number of bits needed for values up to max.
+func bitWidth(max int32) int {
+	w := 0
+	for max > 0 {
+		w++
+		max >>= 1
+	}
+	return w
+}
+
+// decodeHybrid decodes n values of the RLE / bit-packing hybrid.
+func decodeHybrid(b []byte, width, n int) ([]int32, error) {
+	if width > 32 {
+		return nil, errors.New("invalid bit width")
+	}
+	out := make([]int32, 0, n)
+	pos := 0
+	byteWidth := (width + 7) / 8
+	for len(out) < n {
+		h, k := binary.Uvarint(b[pos:])
+		if k <= 0 {
+			return nil, errors.New("truncated run")
+		}
+		pos += k
+		if h&1 == 1 {
+			groups := h >> 1
+			if groups == 0 || groups > uint64(len(b)) {
+				return nil, errors.New("invalid bit-packed run")
+			}
+			size := int(groups) * width
+			// The last run of a page is sometimes cut at the page's last value.
+			avail := min(size, len(b)-pos)
+			br := bitReader{b: b[pos : pos+avail]}
+			for i := 0; i < int(groups)*8 && len(out) < n; i++ {
+				if br.bits() < width {
+					return nil, errors.New("truncated bit-packed run")
+				}
+				out = append(out, int32(br.read(width)))
+			}
+			pos += avail
+			continue
+		}
+		count := h >> 1
+		if count == 0 || pos+byteWidth > len(b) {
+			return nil, errors.New("invalid RLE run")
+		}
+		var v int32
+		for i := 0; i < byteWidth; i++ {
+			v |= int32(b[pos+i]) << (8 * i)
+		}
+		pos += byteWidth
+		for i := uint64(0); i < count && len(out) < n; i++ {
+			out = append(out, v)
+		}
+	}
+	return out, nil
+}
+
+// bitReader reads bit-packed values, least significant bit first unless
+// msbFirst.
+type bitReader struct {
+	b        []byte
+	pos      int // in bits
+	msbFirst bool
+}
+
+func (r *bitReader) bits() int { return len(r.b)*8 - r.pos }
+
+func (r *bitReader) read(width int) uint64 {
+	var v uint64
+	for i := 0; i < width; i++ {
+		byteIdx, bit := r.pos/8, r.pos%8
+		if byteIdx >= len(r.b) {
+			break
+		}
+		if r.msbFirst {
+			v = v<<1 | uint64(r.b[byteIdx]>>(7-bit)&1)
+		} else {
+			v |= uint64(r.b[byteIdx]>>bit&1) << i
+		}
+		r.pos++
+	}
+	return v
+}
+
+// decodeDeltaBinaryPacked decodes up to max integers of the
+// DELTA_BINARY_PACKED encoding and returns them with the bytes they took.
+func decodeDeltaBinaryPacked(b []byte, max int) ([]int64, int, error) {
+	bad := errors.New("invalid delta-binary-packed data")
+	pos := 0
+	uv := func() (uint64, bool) {
+		v, k := binary.Uvarint(b[pos:])
+		if k <= 0 {
+			return 0, false
+		}
+		pos += k
+		return v, true
+	}
+	zz := func() (int64, bool) {
+		u, ok := uv()
+		return int64(u>>1) ^ -int64(u&1), ok
+	}
+	blockSize, ok1 := uv()
+	miniblocks, ok2 := uv()
+	total, ok3 := uv()
+	first, ok4 := zz()
+	if !ok1 || !ok2 || !ok3 || !ok4 || blockSize == 0 || blockSize%128 != 0 || miniblocks == 0 ||
+		blockSize%miniblocks != 0 || (blockSize/miniblocks)%32 != 0 || blockSize > 1<<20 {
+		return nil, 0, bad
+	}
+	if total == 0 {
+		return nil, pos, nil
+	}
+	// A miniblock of width zero takes no bytes, so only the caller's count
+	// bounds a forged total.
+	want := min(total, uint64(max))
+	out := make([]int64, 0, want)
+	out = append(out, first)
+	prev := uint64(first)
+	perMini := int(blockSize / miniblocks)
+	for uint64(len(out)) < total {
+		minDelta, ok := zz()
+		if !ok || pos+int(miniblocks) > len(b) {
+			return nil, 0, bad
+		}
+		widths := b[pos : pos+int(miniblocks)]
+		pos += int(miniblocks)
+		for m := 0; m < int(miniblocks) && uint64(len(out)) < total; m++ {
+			width := int(widths[m])
+			if width > 64 {
+				return nil, 0, bad
+			}
+			size := perMini * width / 8
+			if size > len(b)-pos {
+				return nil, 0, bad
+			}
+			br := bitReader{b: b[pos : pos+size]}
+			for i := 0; i < perMini && uint64(len(out)) < total; i++ {
+				prev += uint64(minDelta) + br.read(width)
+				if uint64(len(out)) < want {
+					out = append(out, int64(prev))
+				}
+			}
+			pos += size
+			if uint64(len(out)) >= want && want < total {
+				return out, pos, nil
+			}
+		}
+	}
+	return out, pos, nil
+}
+
+// decodeDeltaLengthByteArray decodes n byte strings of the
+// DELTA_LENGTH_BYTE_ARRAY encoding and returns them with the bytes they took.
+func decodeDeltaLengthByteArray(b []byte, n int) ([][]byte, int, error) {
+	lengths, pos, err := decodeDeltaBinaryPacked(b, n)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(lengths) < n {
+		return nil, 0, errors.New("page holds fewer values than its levels declare")
+	}
+	out := make([][]byte, n)
+	for i, l := range lengths[:n] {
+		if l < 0 || l > int64(len(b)-pos) {
+			return nil, 0, errors.New("invalid delta-length-byte-array data")
+		}
+		out[i] = b[pos : pos+int(l)]
+		pos += int(l)
+	}
+	return out, pos, nil
+}
+
+// decodeDeltaByteArray decodes n byte strings of the DELTA_BYTE_ARRAY
+// encoding: each a prefix of the one before it plus a suffix.
+func decodeDeltaByteArray(b []byte, n int) ([][]byte, error) {
+	prefixes, pos, err := decodeDeltaBinaryPacked(b, n)
+	if err != nil {
+		return nil, err
+	}
+	suffixes, _, err := decodeDeltaLengthByteArray(b[pos:], n)
+	if err != nil {
+		return nil, err
+	}
+	if len(prefixes) < n {
+		return nil, errors.New("page holds fewer values than its levels declare")
+	}
+	out := make([][]byte, n)
+	var prev []byte
+	for i := range out {
+		p := prefixes[i]
+		if p < 0 || p > int64(len(prev)) {
+			return nil, errors.New("invalid delta-byte-array prefix")
+		}
+		v := make([]byte, 0, int(p)+len(suffixes[i]))
+		v = append(append(v, prev[:p]...), suffixes[i]...)
+		out[i], prev = v, v
+	}
+	return out, nil
+}
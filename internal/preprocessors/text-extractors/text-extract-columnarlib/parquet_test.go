@@ -0,0 +1,303 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package textextractcolumnarlib
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// peopleSchema is a flat table plus a list column:
+//
+//	id INT64 REQUIRED, name STRING, ssn STRING, dob DATE,
+//	balance DECIMAL(10,2) REQUIRED, tags LIST<STRING>
+func peopleSchema() []tstruct {
+	return []tstruct{
+		{4: "schema", 5: 6},
+		{1: ptInt64, 3: 0, 4: "id"},
+		{1: ptByteArray, 3: 1, 4: "name", 6: 0, 10: tstruct{1: tstruct{}}},
+		{1: ptByteArray, 3: 1, 4: "ssn", 6: 0},
+		{1: ptInt32, 3: 1, 4: "dob", 6: 6},
+		{1: ptInt64, 3: 0, 4: "balance", 6: 5, 7: 2, 8: 10},
+		{3: 1, 4: "tags", 5: 1, 6: 3},
+		{3: 2, 4: "list", 5: 1},
+		{1: ptByteArray, 3: 1, 4: "element"},
+	}
+}
+
+// peopleParquet writes five rows in two row groups: the first snappy with v1
+// pages, the second gzip with v2 pages, both dictionary-encoding ssn.
+func peopleParquet(t *testing.T, nameCodec int64) []byte {
+	t.Helper()
+	const snappy, gz = 1, 2
+	rg1 := pqRowGroup{rows: 3, chunks: []pqChunk{
+		{snappy, 3, [][]byte{dataPageV1(t, snappy, 3, encPlain, nil, nil, 0, 0, plainInt64s(1, 2, 3))}},
+		{nameCodec, 3, [][]byte{dataPageV1(t, nameCodec, 3, encPlain, nil, []int32{1, 0, 1}, 0, 1, plainByteArrays("Alice", "Carol"))}},
+		{snappy, 3, [][]byte{
+			dictPage(t, snappy, 2, plainByteArrays("123-45-6789", "987-65-4321")),
+			dataPageV1(t, snappy, 3, encRLEDictionary, nil, []int32{1, 1, 1}, 0, 1, dictIndices([]int32{0, 1, 0}, 1)),
+		}},
+		{snappy, 3, [][]byte{dataPageV1(t, snappy, 3, encPlain, nil, []int32{1, 1, 0}, 0, 1, plainInt32s(0, 10957))}},
+		{snappy, 3, [][]byte{dataPageV1(t, snappy, 3, encPlain, nil, nil, 0, 0, plainInt64s(12345, -5, 100))}},
+		// ["a", "b"], [], null
+		{snappy, 4, [][]byte{dataPageV1(t, snappy, 4, encPlain, []int32{0, 1, 0, 0}, []int32{3, 3, 1, 0}, 1, 3, plainByteArrays("a", "b"))}},
+	}}
+	rg2 := pqRowGroup{rows: 2, chunks: []pqChunk{
+		{gz, 2, [][]byte{dataPageV2(t, gz, 2, encPlain, nil, nil, 0, 0, plainInt64s(4, 5))}},
+		{gz, 2, [][]byte{dataPageV2(t, gz, 2, encPlain, nil, []int32{1, 1}, 0, 1, plainByteArrays("Dave", "Eve"))}},
+		{gz, 2, [][]byte{
+			dictPage(t, gz, 1, plainByteArrays("111-22-3333")),
+			dataPageV2(t, gz, 2, encPlainDictionary, nil, []int32{1, 1}, 0, 1, dictIndices([]int32{0, 0}, 1)),
+		}},
+		{gz, 2, [][]byte{dataPageV2(t, gz, 2, encPlain, nil, []int32{1, 0}, 0, 1, plainInt32s(19000))}},
+		{gz, 2, [][]byte{dataPageV2(t, gz, 2, encPlain, nil, nil, 0, 0, plainInt64s(0, 7))}},
+		// ["x"], ["y", "z"]
+		{gz, 3, [][]byte{dataPageV2(t, gz, 3, encPlain, []int32{0, 0, 1}, []int32{3, 3, 3}, 1, 3, plainByteArrays("x", "y", "z"))}},
+	}}
+	return writeParquet(t, peopleSchema(), []pqRowGroup{rg1, rg2})
+}
+
+const peopleText = "id\tname\tssn\tdob\tbalance\ttags\n" +
+	"1\tAlice\t123-45-6789\t1970-01-01\t123.45\ta, b\n" +
+	"2\t\t987-65-4321\t2000-01-01\t-0.05\t\n" +
+	"3\tCarol\t123-45-6789\t\t1.00\t\n" +
+	"4\tDave\t111-22-3333\t2022-01-08\t0.00\tx\n" +
+	"5\tEve\t111-22-3333\t\t0.07\ty, z\n"
+
+func extractParquet(t *testing.T, data []byte, opts Options) *Extraction {
+	t.Helper()
+	ex, err := ExtractParquet(bytes.NewReader(data), int64(len(data)), opts)
+	if err != nil {
+		t.Fatalf("ExtractParquet: %v", err)
+	}
+	return ex
+}
+
+func TestExtractParquet(t *testing.T) {
+	data := peopleParquet(t, 1)
+	if !IsParquet(data) {
+		t.Fatal("IsParquet = false")
+	}
+	ex := extractParquet(t, data, Options{})
+	if ex.Text != peopleText {
+		t.Errorf("Text =\n%s\nwant\n%s", ex.Text, peopleText)
+	}
+	if got := strings.Join(ex.Columns, ","); got != "id,name,ssn,dob,balance,tags" {
+		t.Errorf("Columns = %s", got)
+	}
+	if ex.TotalRows != 5 || ex.RowsRead != 5 || ex.Stopped != Complete || len(ex.Notes) != 0 {
+		t.Errorf("TotalRows %d, RowsRead %d, Stopped %v, Notes %q", ex.TotalRows, ex.RowsRead, ex.Stopped, ex.Notes)
+	}
+	for i, r := range ex.Rows {
+		if r.Row != int64(i+1) || r.Line != i+1 {
+			t.Errorf("Rows[%d] = %+v", i, r)
+		}
+	}
+}
+
+// ZSTD is the default codec of recent Spark and Arrow writers.
+func TestParquetZSTD(t *testing.T) {
+	ex := extractParquet(t, peopleParquet(t, 6), Options{})
+	if ex.Text != peopleText || len(ex.Notes) != 0 {
+		t.Errorf("Text =\n%s\nwant\n%s\nNotes %q", ex.Text, peopleText, ex.Notes)
+	}
+}
+
+func TestParquetUnsupportedCodecIsDisclosed(t *testing.T) {
+	ex := extractParquet(t, peopleParquet(t, 4), Options{})
+	want := "column name, rows 1-3: BROTLI compression is not supported, so those values were NOT scanned"
+	if len(ex.Notes) != 1 || ex.Notes[0] != want {
+		t.Fatalf("Notes = %q, want %q", ex.Notes, want)
+	}
+	// The other columns, and the column's other row group, are still read.
+	lines := strings.Split(ex.Text, "\n")
+	if lines[1] != "1\t\t123-45-6789\t1970-01-01\t123.45\ta, b" || lines[4] != "4\tDave\t111-22-3333\t2022-01-08\t0.00\tx" {
+		t.Errorf("Text =\n%s", ex.Text)
+	}
+}
+
+func TestParquetSampleRows(t *testing.T) {
+	data := peopleParquet(t, 1)
+	for _, n := range []int64{2, 3, 4} {
+		ex := extractParquet(t, data, Options{SampleRows: n})
+		if ex.RowsRead != n || ex.TotalRows != 5 || ex.Stopped != StoppedAtSample {
+			t.Errorf("SampleRows %d: RowsRead %d, TotalRows %d, Stopped %v", n, ex.RowsRead, ex.TotalRows, ex.Stopped)
+		}
+		if !strings.HasPrefix(peopleText, ex.Text) {
+			t.Errorf("SampleRows %d: Text =\n%s", n, ex.Text)
+		}
+	}
+	ex := extractParquet(t, data, Options{SampleRows: 5})
+	if ex.Stopped != Complete || ex.RowsRead != 5 {
+		t.Errorf("SampleRows 5: Stopped %v, RowsRead %d", ex.Stopped, ex.RowsRead)
+	}
+}
+
+func TestParquetDamagedPageIsDisclosed(t *testing.T) {
+	// A snappy page whose body is not snappy.
+	body := []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+	bad := append(encodeThrift(tstruct{1: pageData, 2: 20, 3: len(body), 5: tstruct{1: 3, 2: encPlain, 3: encRLE, 4: encRLE}}), body...)
+	schema := append([]tstruct{{4: "schema", 5: 2}}, peopleSchema()[1:3]...)
+	data := writeParquet(t, schema, []pqRowGroup{{rows: 3, chunks: []pqChunk{
+		{0, 3, [][]byte{dataPageV1(t, 0, 3, encPlain, nil, nil, 0, 0, plainInt64s(1, 2, 3))}},
+		{1, 3, [][]byte{bad}},
+	}}})
+	ex := extractParquet(t, data, Options{})
+	if ex.Text != "id\tname\n1\t\n2\t\n3\t\n" {
+		t.Errorf("Text = %q", ex.Text)
+	}
+	if len(ex.Notes) != 1 || !strings.HasPrefix(ex.Notes[0], "column name, rows 1-3: snappy") {
+		t.Errorf("Notes = %q", ex.Notes)
+	}
+}
+
+func TestParquetRejectsDamagedFooter(t *testing.T) {
+	data := peopleParquet(t, 1)
+	for name, b := range map[string][]byte{
+		"truncated":  data[:len(data)-20],
+		"short":      []byte("PAR1PAR1"),
+		"no magic":   append([]byte("PAR0"), data[4:]...),
+		"bad length": append(append(data[:len(data)-8:len(data)-8], 0xff, 0xff, 0xff, 0x7f), ParquetMagic...),
+	} {
+		if _, err := ExtractParquet(bytes.NewReader(b), int64(len(b)), Options{}); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+func TestParquetTypes(t *testing.T) {
+	schema := []tstruct{
+		{4: "schema", 5: 6},
+		{1: ptBoolean, 3: 0, 4: "active"},
+		{1: ptDouble, 3: 0, 4: "card"},
+		{1: ptInt64, 3: 0, 4: "seen", 10: tstruct{8: tstruct{1: true, 2: tstruct{2: tstruct{}}}}},
+		{1: ptFixedLenByteArray, 2: 16, 3: 0, 4: "uuid", 10: tstruct{14: tstruct{}}},
+		{1: ptInt96, 3: 0, 4: "legacy"},
+		{1: ptByteArray, 3: 0, 4: "blob"},
+	}
+	uuid := []byte{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3, 0xa4, 0x56, 0x42, 0x66, 0x14, 0x17, 0x40, 0x00}
+	int96 := binary.LittleEndian.AppendUint64(nil, 3600*1e9)   // 01:00 on
+	int96 = binary.LittleEndian.AppendUint32(int96, 2440588+1) // 1970-01-02
+	data := writeParquet(t, schema, []pqRowGroup{{rows: 1, chunks: []pqChunk{
+		{0, 1, [][]byte{dataPageV1(t, 0, 1, encPlain, nil, nil, 0, 0, []byte{1})}},
+		{0, 1, [][]byte{dataPageV1(t, 0, 1, encPlain, nil, nil, 0, 0, plainDoubles(4111111111111111))}},
+		{0, 1, [][]byte{dataPageV1(t, 0, 1, encPlain, nil, nil, 0, 0, plainInt64s(1_500_000))}},
+		{0, 1, [][]byte{dataPageV1(t, 0, 1, encPlain, nil, nil, 0, 0, uuid)}},
+		{0, 1, [][]byte{dataPageV1(t, 0, 1, encPlain, nil, nil, 0, 0, int96)}},
+		{0, 1, [][]byte{dataPageV1(t, 0, 1, encPlain, nil, nil, 0, 0, plainByteArrays("\x00\x01\xff"))}},
+	}}})
+	ex := extractParquet(t, data, Options{})
+	want := "active\tcard\tseen\tuuid\tlegacy\tblob\n" +
+		"true\t4111111111111111\t1970-01-01T00:00:01.5Z\t123e4567-e89b-12d3-a456-426614174000\t1970-01-02T01:00:00Z\t\n"
+	if ex.Text != want {
+		t.Errorf("Text = %q, want %q", ex.Text, want)
+	}
+}
+
+func TestParquetDeltaEncodings(t *testing.T) {
+	const n = 300
+	ids := make([]int64, n)
+	names := make([]string, n)
+	for i := range ids {
+		ids[i] = int64(1000 + i*i - 7*i)
+		names[i] = "customer-" + strconv.Itoa(i/3)
+	}
+	// DELTA_BYTE_ARRAY: shared prefixes, then the suffixes.
+	var prefixes, lengths []int64
+	var suffixes []byte
+	prev := ""
+	for _, s := range names {
+		p := 0
+		for p < len(prev) && p < len(s) && prev[p] == s[p] {
+			p++
+		}
+		prefixes = append(prefixes, int64(p))
+		lengths = append(lengths, int64(len(s)-p))
+		suffixes = append(suffixes, s[p:]...)
+		prev = s
+	}
+	dba := append(encodeDeltaBinaryPacked(prefixes), encodeDeltaBinaryPacked(lengths)...)
+	dba = append(dba, suffixes...)
+	// DELTA_LENGTH_BYTE_ARRAY: the lengths, then the bytes.
+	var full []int64
+	var all []byte
+	for _, s := range names {
+		full = append(full, int64(len(s)))
+		all = append(all, s...)
+	}
+	dlba := append(encodeDeltaBinaryPacked(full), all...)
+
+	schema := []tstruct{
+		{4: "schema", 5: 3},
+		{1: ptInt64, 3: 0, 4: "id"},
+		{1: ptByteArray, 3: 0, 4: "a"},
+		{1: ptByteArray, 3: 0, 4: "b"},
+	}
+	data := writeParquet(t, schema, []pqRowGroup{{rows: n, chunks: []pqChunk{
+		{0, n, [][]byte{dataPageV2(t, 0, n, encDeltaBinaryPacked, nil, nil, 0, 0, encodeDeltaBinaryPacked(ids))}},
+		{0, n, [][]byte{dataPageV2(t, 0, n, encDeltaByteArray, nil, nil, 0, 0, dba)}},
+		{0, n, [][]byte{dataPageV2(t, 0, n, encDeltaLengthByteArray, nil, nil, 0, 0, dlba)}},
+	}}})
+	ex := extractParquet(t, data, Options{})
+	if len(ex.Notes) != 0 {
+		t.Fatalf("Notes = %q", ex.Notes)
+	}
+	lines := strings.Split(strings.TrimSuffix(ex.Text, "\n"), "\n")
+	if len(lines) != n+1 {
+		t.Fatalf("%d lines, want %d", len(lines), n+1)
+	}
+	for i := 0; i < n; i++ {
+		want := strconv.FormatInt(ids[i], 10) + "\t" + names[i] + "\t" + names[i]
+		if lines[i+1] != want {
+			t.Fatalf("row %d = %q, want %q", i+1, lines[i+1], want)
+		}
+	}
+}
+
+func TestParquetNestedColumnNames(t *testing.T) {
+	// A MAP column is named for the map, and a struct's fields are dotted.
+	schema := []tstruct{
+		{4: "schema", 5: 2},
+		{3: 1, 4: "address", 5: 1},
+		{1: ptByteArray, 3: 1, 4: "city"},
+		{3: 1, 4: "attrs", 5: 1, 6: 1},
+		{3: 2, 4: "key_value", 5: 2},
+		{1: ptByteArray, 3: 0, 4: "key"},
+		{1: ptByteArray, 3: 1, 4: "value"},
+	}
+	data := writeParquet(t, schema, nil)
+	ex := extractParquet(t, data, Options{})
+	if got := strings.Join(ex.Columns, ","); got != "address.city,attrs.key,attrs.value" {
+		t.Errorf("Columns = %s", got)
+	}
+}
+
+func TestSnappyAndLZ4(t *testing.T) {
+	// "abc", then a copy of 6 bytes from 3 back.
+	got, err := decodeSnappy([]byte{9, 2 << 2, 'a', 'b', 'c', 1 | 2<<2, 3}, 1<<20)
+	if err != nil || string(got) != "abcabcabc" {
+		t.Errorf("decodeSnappy = %q, %v", got, err)
+	}
+	if _, err := decodeSnappy([]byte{9, 2 << 2, 'a', 'b', 'c', 1 | 2<<2, 9}, 1<<20); err == nil {
+		t.Error("decodeSnappy accepted a copy from before the start")
+	}
+	if _, err := decodeSnappy([]byte{0xff, 0xff, 0xff, 0xff, 0x0f}, 1<<20); err == nil {
+		t.Error("decodeSnappy accepted a length over its limit")
+	}
+	// "abc", a match of 8 from 3 back, then the literal "c".
+	block := []byte{0x34, 'a', 'b', 'c', 3, 0, 0x10, 'c'}
+	got, err = decodeLZ4Block(block, 12)
+	if err != nil || string(got) != "abcabcabcabc" {
+		t.Errorf("decodeLZ4Block = %q, %v", got, err)
+	}
+	framed := binary.BigEndian.AppendUint32(nil, 12)
+	framed = binary.BigEndian.AppendUint32(framed, uint32(len(block)))
+	got, err = decodeHadoopLZ4(append(framed, block...), 12)
+	if err != nil || string(got) != "abcabcabcabc" {
+		t.Errorf("decodeHadoopLZ4 = %q, %v", got, err)
+	}
+}
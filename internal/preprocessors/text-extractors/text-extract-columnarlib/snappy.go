@@ -0,0 +1,152 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package textextractcolumnarlib
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+var errSnappyCorrupt = errors.New("snappy: corrupt input")
+
+// decodeSnappy decodes one snappy block, the unframed format Parquet pages and
+// Avro blocks use. The declared length is checked against limit before
+// anything is allocated.
+func decodeSnappy(src []byte, limit int) ([]byte, error) {
+	n, k := binary.Uvarint(src)
+	if k <= 0 {
+		return nil, errSnappyCorrupt
+	}
+	if n > uint64(limit) {
+		return nil, fmt.Errorf("snappy: block declares %d bytes, over the %d-byte limit", n, limit)
+	}
+	dst := make([]byte, 0, n)
+	s := k
+	for s < len(src) {
+		tag := src[s]
+		var length, offset int
+		switch tag & 3 {
+		case 0: // literal
+			length = int(tag >> 2)
+			s++
+			if length >= 60 {
+				extra := length - 59
+				if s+extra > len(src) {
+					return nil, errSnappyCorrupt
+				}
+				length = 0
+				for i := 0; i < extra; i++ {
+					length |= int(src[s+i]) << (8 * i)
+				}
+				s += extra
+			}
+			length++
+			if length > len(src)-s || length > int(n)-len(dst) {
+				return nil, errSnappyCorrupt
+			}
+			dst = append(dst, src[s:s+length]...)
+			s += length
+			continue
+		case 1:
+			if s+2 > len(src) {
+				return nil, errSnappyCorrupt
+			}
+			length = 4 + int(tag>>2)&7
+			offset = int(tag>>5)<<8 | int(src[s+1])
+			s += 2
+		case 2:
+			if s+3 > len(src) {
+				return nil, errSnappyCorrupt
+			}
+			length = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint16(src[s+1:]))
+			s += 3
+		case 3:
+			if s+5 > len(src) {
+				return nil, errSnappyCorrupt
+			}
+			length = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint32(src[s+1:]))
+			s += 5
+		}
+		if offset <= 0 || offset > len(dst) || length > int(n)-len(dst) {
+			return nil, errSnappyCorrupt
+		}
+		// Copies may overlap their own output, so byte by byte.
+		from := len(dst) - offset
+		for i := 0; i < length; i++ {
+			dst = append(dst, dst[from+i])
+		}
+	}
+	if uint64(len(dst)) != n {
+		return nil, errSnappyCorrupt
+	}
+	return dst, nil
+}
+
+// decodeLZ4Block decodes one raw LZ4 block (Parquet's LZ4_RAW) of a known
+// decompressed size.
+func decodeLZ4Block(src []byte, size int) ([]byte, error) {
+	corrupt := errors.New("lz4: corrupt input")
+	dst := make([]byte, 0, size)
+	s := 0
+	for s < len(src) {
+		token := src[s]
+		s++
+		lit := int(token >> 4)
+		if lit == 15 {
+			for {
+				if s >= len(src) {
+					return nil, corrupt
+				}
+				b := src[s]
+				s++
+				lit += int(b)
+				if b != 255 {
+					break
+				}
+			}
+		}
+		if lit > len(src)-s || lit > size-len(dst) {
+			return nil, corrupt
+		}
+		dst = append(dst, src[s:s+lit]...)
+		s += lit
+		if s == len(src) {
+			break // the last sequence has literals only
+		}
+		if s+2 > len(src) {
+			return nil, corrupt
+		}
+		offset := int(binary.LittleEndian.Uint16(src[s:]))
+		s += 2
+		length := int(token & 15)
+		if length == 15 {
+			for {
+				if s >= len(src) {
+					return nil, corrupt
+				}
+				b := src[s]
+				s++
+				length += int(b)
+				if b != 255 {
+					break
+				}
+			}
+		}
+		length += 4
+		if offset == 0 || offset > len(dst) || length > size-len(dst) {
+			return nil, corrupt
+		}
+		from := len(dst) - offset
+		for i := 0; i < length; i++ {
+			dst = append(dst, dst[from+i])
+		}
+	}
+	if len(dst) != size {
+		return nil, corrupt
+	}
+	return dst, nil
+}
@@ -0,0 +1,226 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package textextractcolumnarlib
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Parquet's footer and page headers are Thrift structs in the compact protocol.
+// Rather than generated code for the whole parquet.thrift IDL, they are read
+// generically, into a map from field id to value, and the few fields the reader
+// needs are picked out by id. A field it does not know is skipped as any Thrift
+// reader skips it.
+
+// Compact protocol type ids.
+const (
+	ctStop   = 0
+	ctTrue   = 1
+	ctFalse  = 2
+	ctByte   = 3
+	ctI16    = 4
+	ctI32    = 5
+	ctI64    = 6
+	ctDouble = 7
+	ctBinary = 8
+	ctList   = 9
+	ctSet    = 10
+	ctMap    = 11
+	ctStruct = 12
+)
+
+// maxThriftDepth bounds struct nesting; Parquet's deepest is a handful.
+const maxThriftDepth = 32
+
+var errThriftTruncated = errors.New("thrift: truncated")
+
+// tstruct is a decoded struct: field id to int64, float64, bool, []byte,
+// []any or tstruct.
+type tstruct map[int16]any
+
+func (s tstruct) int(id int16) (int64, bool) {
+	v, ok := s[id].(int64)
+	return v, ok
+}
+
+func (s tstruct) str(id int16) string {
+	b, _ := s[id].([]byte)
+	return string(b)
+}
+
+func (s tstruct) bool(id int16) (bool, bool) {
+	v, ok := s[id].(bool)
+	return v, ok
+}
+
+func (s tstruct) st(id int16) tstruct {
+	v, _ := s[id].(tstruct)
+	return v
+}
+
+func (s tstruct) list(id int16) []any {
+	v, _ := s[id].([]any)
+	return v
+}
+
+type thriftReader struct {
+	b   []byte
+	pos int
+}
+
+// readStruct decodes one struct and returns it with the bytes it took.
+func readStruct(b []byte) (tstruct, int, error) {
+	r := &thriftReader{b: b}
+	s, err := r.readStruct(0)
+	return s, r.pos, err
+}
+
+func (r *thriftReader) readStruct(depth int) (tstruct, error) {
+	if depth > maxThriftDepth {
+		return nil, errors.New("thrift: structs nested too deeply")
+	}
+	s := tstruct{}
+	var last int16
+	for {
+		h, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		typ := h & 0x0f
+		if typ == ctStop {
+			return s, nil
+		}
+		id := last + int16(h>>4)
+		if h>>4 == 0 {
+			v, err := r.zigzag()
+			if err != nil {
+				return nil, err
+			}
+			id = int16(v)
+		}
+		last = id
+		switch typ {
+		case ctTrue:
+			s[id] = true
+		case ctFalse:
+			s[id] = false
+		default:
+			v, err := r.value(typ, depth)
+			if err != nil {
+				return nil, err
+			}
+			s[id] = v
+		}
+	}
+}
+
+func (r *thriftReader) value(typ byte, depth int) (any, error) {
+	switch typ {
+	case ctTrue, ctFalse:
+		// Only inside a list or map: one byte, 1 for true.
+		b, err := r.byte()
+		return b == ctTrue, err
+	case ctByte:
+		b, err := r.byte()
+		return int64(int8(b)), err
+	case ctI16, ctI32, ctI64:
+		return r.zigzag()
+	case ctDouble:
+		if r.pos+8 > len(r.b) {
+			return nil, errThriftTruncated
+		}
+		v := math.Float64frombits(binary.LittleEndian.Uint64(r.b[r.pos:]))
+		r.pos += 8
+		return v, nil
+	case ctBinary:
+		n, err := r.uvarint()
+		if err != nil {
+			return nil, err
+		}
+		if n > uint64(len(r.b)-r.pos) {
+			return nil, errThriftTruncated
+		}
+		v := r.b[r.pos : r.pos+int(n)]
+		r.pos += int(n)
+		return v, nil
+	case ctList, ctSet:
+		h, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		n := uint64(h >> 4)
+		if n == 15 {
+			if n, err = r.uvarint(); err != nil {
+				return nil, err
+			}
+		}
+		// Every element takes at least one byte, which bounds a forged count.
+		if n > uint64(len(r.b)-r.pos) {
+			return nil, errThriftTruncated
+		}
+		out := make([]any, 0, n)
+		for i := uint64(0); i < n; i++ {
+			v, err := r.value(h&0x0f, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+		}
+		return out, nil
+	case ctMap:
+		n, err := r.uvarint()
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			return []any(nil), nil
+		}
+		if n > uint64(len(r.b)-r.pos) {
+			return nil, errThriftTruncated
+		}
+		kv, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		// Parquet reads no map; the entries are decoded only to be skipped.
+		for i := uint64(0); i < n; i++ {
+			if _, err := r.value(kv>>4, depth+1); err != nil {
+				return nil, err
+			}
+			if _, err := r.value(kv&0x0f, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return []any(nil), nil
+	case ctStruct:
+		return r.readStruct(depth + 1)
+	}
+	return nil, fmt.Errorf("thrift: unknown type %d", typ)
+}
+
+func (r *thriftReader) byte() (byte, error) {
+	if r.pos >= len(r.b) {
+		return 0, errThriftTruncated
+	}
+	b := r.b[r.pos]
+	r.pos++
+	return b, nil
+}
+
+func (r *thriftReader) uvarint() (uint64, error) {
+	v, n := binary.Uvarint(r.b[r.pos:])
+	if n <= 0 {
+		return 0, errThriftTruncated
+	}
+	r.pos += n
+	return v, nil
+}
+
+func (r *thriftReader) zigzag() (int64, error) {
+	u, err := r.uvarint()
+	return int64(u>>1) ^ -int64(u&1), err
+}
@@ -0,0 +1,92 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package textextractcolumnarlib
+
+import (
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Both formats annotate a stored integer or byte string with what it means, and
+// a value is written as that meaning: a date of birth stored as days since
+// 1970 reads as a date, and a decimal stored unscaled reads with its point.
+
+// timeUnit is the resolution of a stored timestamp.
+type timeUnit int
+
+const (
+	unitMillis timeUnit = iota + 1
+	unitMicros
+	unitNanos
+)
+
+// formatDate writes days since 1970-01-01.
+func formatDate(days int64) string {
+	return time.Unix(days*86400, 0).UTC().Format("2006-01-02")
+}
+
+// formatTimestamp writes a count of unit since the epoch. A local timestamp
+// has no zone and is written without one.
+func formatTimestamp(v int64, unit timeUnit, local bool) string {
+	var t time.Time
+	switch unit {
+	case unitMillis:
+		t = time.UnixMilli(v)
+	case unitMicros:
+		t = time.UnixMicro(v)
+	default:
+		t = time.Unix(0, v)
+	}
+	if local {
+		return t.UTC().Format("2006-01-02T15:04:05.999999999")
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// formatDecimal writes an unscaled big-endian two's-complement integer with
+// scale digits after the point.
+func formatDecimal(b []byte, scale int) string {
+	v := new(big.Int).SetBytes(b)
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		v.Sub(v, new(big.Int).Lsh(big.NewInt(1), uint(len(b)*8)))
+	}
+	return scaleDecimal(v.String(), scale)
+}
+
+// scaleDecimal places the point in a decimal integer string.
+func scaleDecimal(digits string, scale int) string {
+	if scale <= 0 {
+		return digits
+	}
+	neg := strings.HasPrefix(digits, "-")
+	digits = strings.TrimPrefix(digits, "-")
+	if len(digits) <= scale {
+		digits = strings.Repeat("0", scale-len(digits)+1) + digits
+	}
+	s := digits[:len(digits)-scale] + "." + digits[len(digits)-scale:]
+	if neg {
+		s = "-" + s
+	}
+	return s
+}
+
+// formatUUID writes 16 bytes in the canonical 8-4-4-4-12 form.
+func formatUUID(b []byte) string {
+	h := hex.EncodeToString(b)
+	return h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
+
+// bytesText writes a byte string that is text, and nothing for one that is
+// not: binary columns (images, hashes, serialized objects) carry no value a
+// text validator could read, and decoding them as Latin-1 would only make
+// noise for the validators to misread.
+func bytesText(b []byte) string {
+	if !utf8.Valid(b) || strings.IndexByte(string(b), 0) >= 0 {
+		return ""
+	}
+	return string(b)
+}
@@ -0,0 +1,232 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package textextractemaillib
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/awslabs/ferret-scan/v2/internal/embedded"
+)
+
+// Email bounds.
+//
+// A mailbox is a container whose producer decides how many messages, parts and
+// attachments it holds, and nothing in the formats stops a multipart from
+// nesting itself a thousand levels deep in a few kilobytes. These bounds are
+// what make every walk terminate. Reaching one is DISCLOSED through
+// Mailbox.Notes, never silent: the items past it were not examined.
+const (
+	// MaxMessages bounds the messages read from one mbox. A 100MB mailbox of
+	// real mail holds a few thousand.
+	MaxMessages = 100000
+
+	// MaxAttachments bounds the attachments extracted from one file, across
+	// all of its messages.
+	MaxAttachments = 256
+
+	// MaxAttachmentSize bounds a single attachment, mirroring the Office and
+	// PDF extractors' per-part cap.
+	MaxAttachmentSize = 50 * 1024 * 1024 // 50MB
+
+	// maxPartDepth bounds recursion through nested multiparts and through the
+	// messages an Outlook message embeds in itself.
+	maxPartDepth = 32
+)
+
+// Mailbox is the scannable content of one email file: a single message for
+// .eml and .msg, every message for .mbox.
+type Mailbox struct {
+	Messages []Message
+
+	// Notes are payload-free statements about content that was NOT examined: an
+	// attachment refused for size, or a walk that reached its bound.
+	Notes []string
+}
+
+// Message is one email message.
+type Message struct {
+	// Headers are the headers that name people or say what the message is
+	// about, decoded, in the order of addressHeaders.
+	Headers []Header
+
+	// Body is the message's text: its text parts in order, HTML reduced to
+	// text, and of each multipart/alternative only the plain-text rendering.
+	Body string
+
+	// Attachments are the message's attachments written to temporary files
+	// for the caller to route. Extract's caller releases them with
+	// CleanupAttachments.
+	Attachments []Attachment
+}
+
+// Header is one decoded message header.
+type Header struct {
+	Name  string
+	Value string
+}
+
+// Attachment is an attachment materialized to a temporary file.
+type Attachment struct {
+	// Name is the file name the message gives the attachment. It is
+	// producer-controlled and is never used to build a path.
+	Name         string
+	TempFilePath string
+	Size         int64
+}
+
+// addressHeaders are the headers reported, in report order. Routing headers
+// (Received, DKIM-Signature, Message-ID) are left out: they name servers, not
+// people, and every one of them is a line of IP addresses and hashes that would
+// be reported from every message in a mailbox.
+var addressHeaders = []string{"From", "Sender", "Reply-To", "To", "Cc", "Bcc", "Subject"}
+
+// Extract reads an email file: an RFC 5322 message (.eml), an mbox of them
+// (.mbox), or an Outlook message (.msg).
+//
+// The format is decided by the bytes where they are unambiguous: a .msg that
+// is not a compound file is read as RFC 5322 text, which is what some tools
+// write under that name, and any file whose first line is an mbox "From "
+// separator is split into messages whatever it is called.
+func Extract(filePath string) (mb *Mailbox, err error) {
+	x := &extractor{mb: &Mailbox{}}
+	defer func() {
+		if err != nil {
+			CleanupAttachments(x.mb)
+			x.mb.Messages = nil
+		}
+	}()
+
+	data, err := os.ReadFile(filePath) // #nosec G304 -- path already vetted by the router
+	if err != nil {
+		return x.mb, fmt.Errorf("failed to read email file: %w", err)
+	}
+
+	if bytes.HasPrefix(data, oleSignature) {
+		if err := x.readMSG(data); err != nil {
+			return x.mb, err
+		}
+	} else {
+		x.readMbox(data)
+	}
+	x.disclose()
+	return x.mb, nil
+}
+
+// CleanupAttachments removes the temporary files behind every attachment in
+// mb.
+func CleanupAttachments(mb *Mailbox) {
+	if mb == nil {
+		return
+	}
+	for _, m := range mb.Messages {
+		for _, a := range m.Attachments {
+			if a.TempFilePath != "" {
+				os.Remove(a.TempFilePath)
+			}
+		}
+	}
+}
+
+// extractor carries the bookkeeping of one Extract call.
+type extractor struct {
+	mb *Mailbox
+
+	// attachmentCount counts extraction attempts and attachmentBytes every
+	// byte written, so a file of many refused attachments still stops.
+	attachmentCount int
+	attachmentBytes int64
+
+	messagesCut             bool
+	attachmentsCut          bool
+	attachmentBudgetReached bool
+	depthCut                bool
+}
+
+// attach writes one attachment to a temporary file and adds it to msg.
+func (x *extractor) attach(msg *Message, name string, r io.Reader) {
+	name = cleanName(name)
+	if x.attachmentCount >= MaxAttachments {
+		x.attachmentsCut = true
+		return
+	}
+	if x.attachmentBytes >= embedded.BudgetBytes {
+		x.attachmentBudgetReached = true
+		return
+	}
+	x.attachmentCount++
+
+	path, size, err := writeAttachment(name, r)
+	if err != nil {
+		x.mb.Notes = append(x.mb.Notes, fmt.Sprintf("attachment %q was not examined: %v", name, err))
+		return
+	}
+	x.attachmentBytes += size
+	if size == 0 {
+		os.Remove(path)
+		return
+	}
+	msg.Attachments = append(msg.Attachments, Attachment{Name: name, TempFilePath: path, Size: size})
+}
+
+// writeAttachment copies r to a temporary file, returning its path and size.
+func writeAttachment(name string, r io.Reader) (path string, size int64, err error) {
+	// The temporary file's extension comes from embedded.SafeExt, never from
+	// the name itself, which the sender controls.
+	ext, _ := embedded.SafeExt(name)
+	tmp, err := os.CreateTemp("", "email_attachment_*"+ext)
+	if err != nil {
+		return "", 0, err
+	}
+	defer func() {
+		tmp.Close()
+		if err != nil {
+			os.Remove(tmp.Name())
+			path = ""
+		}
+	}()
+
+	// Read one byte past the cap to tell an over-cap attachment from one
+	// exactly at it. Base64 inflates by a third, so the encoded size in the
+	// message says little about this.
+	size, err = io.Copy(tmp, io.LimitReader(r, MaxAttachmentSize+1))
+	if err != nil {
+		return "", 0, err
+	}
+	if size > MaxAttachmentSize {
+		return "", 0, fmt.Errorf("exceeds the %d-byte embedded extraction cap", MaxAttachmentSize)
+	}
+	return tmp.Name(), size, nil
+}
+
+// cleanName reduces an attachment's name to its final element, with either
+// separator, so a report never repeats a path the sender chose.
+func cleanName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(strings.TrimSpace(name), `\`, "/"))
+	if name == "." || name == "/" || name == "" {
+		return "attachment"
+	}
+	return name
+}
+
+// disclose turns every bound reached during the walk into a note.
+func (x *extractor) disclose() {
+	if x.messagesCut {
+		x.mb.Notes = append(x.mb.Notes, fmt.Sprintf("messages past the first %d were not examined", MaxMessages))
+	}
+	if x.attachmentsCut {
+		x.mb.Notes = append(x.mb.Notes, fmt.Sprintf("attachments past the first %d were not examined", MaxAttachments))
+	}
+	if x.attachmentBudgetReached {
+		x.mb.Notes = append(x.mb.Notes, fmt.Sprintf("attachments past the %dMB per-file budget were not examined",
+			embedded.BudgetBytes/(1024*1024)))
+	}
+	if x.depthCut {
+		x.mb.Notes = append(x.mb.Notes, fmt.Sprintf("message parts nested deeper than %d levels were not examined", maxPartDepth))
+	}
+}
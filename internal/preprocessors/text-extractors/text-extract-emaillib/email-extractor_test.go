@@ -0,0 +1,246 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package textextractemaillib
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/awslabs/ferret-scan/v2/internal/olefixture"
+)
+
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func extract(t *testing.T, name string, data []byte) *Mailbox {
+	t.Helper()
+	mb, err := Extract(writeFile(t, name, data))
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	t.Cleanup(func() { CleanupAttachments(mb) })
+	return mb
+}
+
+func header(m Message, name string) string {
+	for _, h := range m.Headers {
+		if h.Name == name {
+			return h.Value
+		}
+	}
+	return ""
+}
+
+// multipartEML has an encoded-word subject, a multipart/alternative body whose
+// plain rendering is quoted-printable and whose HTML rendering repeats it, and
+// a base64 attachment.
+const multipartEML = "From: Jane Doe <jane@example.com>\r\n" +
+	"To: legal@example.com\r\n" +
+	"Subject: =?UTF-8?B?Q2xhaW0gZsO8ciBKYW5l?=\r\n" +
+	"Received: from mx.example.com (10.0.0.1)\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/mixed; boundary=\"outer\"\r\n" +
+	"\r\n" +
+	"--outer\r\n" +
+	"Content-Type: multipart/alternative; boundary=\"inner\"\r\n" +
+	"\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"My SSN is 536-22-=\r\n" +
+	"1874, caf=C3=A9 receipt attached.\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/html; charset=utf-8\r\n" +
+	"\r\n" +
+	"<p>My SSN is 536-22-1874, caf&eacute; receipt attached.</p>\r\n" +
+	"--inner--\r\n" +
+	"--outer\r\n" +
+	"Content-Type: text/csv; name=\"claims.csv\"\r\n" +
+	"Content-Disposition: attachment; filename=\"../../claims.csv\"\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"bmFtZSxzc24KSmFuZSw0MTItNjgt\r\n" +
+	"MzMyMQo=\r\n" +
+	"--outer--\r\n"
+
+func TestExtractEMLDecodesPartsAndAttachments(t *testing.T) {
+	mb := extract(t, "claim.eml", []byte(multipartEML))
+	if len(mb.Messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(mb.Messages))
+	}
+	m := mb.Messages[0]
+
+	if got := header(m, "Subject"); got != "Claim für Jane" {
+		t.Errorf("Subject = %q", got)
+	}
+	if got := header(m, "From"); got != "Jane Doe <jane@example.com>" {
+		t.Errorf("From = %q", got)
+	}
+	if got := header(m, "Received"); got != "" {
+		t.Errorf("routing header reported: %q", got)
+	}
+
+	// Quoted-printable soft breaks are joined, and of the alternative only the
+	// plain rendering is kept.
+	if m.Body != "My SSN is 536-22-1874, café receipt attached." {
+		t.Errorf("Body = %q", m.Body)
+	}
+
+	if len(m.Attachments) != 1 {
+		t.Fatalf("got %d attachments, want 1", len(m.Attachments))
+	}
+	a := m.Attachments[0]
+	if a.Name != "claims.csv" {
+		t.Errorf("attachment name = %q, want the final element only", a.Name)
+	}
+	data, err := os.ReadFile(a.TempFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "name,ssn\nJane,412-68-3321\n" {
+		t.Errorf("attachment content = %q", data)
+	}
+}
+
+// mbox framing: a "From " line opens a message only at the start of the file
+// or after a blank line, and one ">" is removed from an escaped body line.
+func TestExtractMboxSplitsAndUnescapes(t *testing.T) {
+	mbox := "From jane@example.com Mon Jan  1 00:00:00 2024\n" +
+		"From: jane@example.com\n" +
+		"Subject: first\n" +
+		"\n" +
+		">From the desk of Jane\n" +
+		"From here on it is still the first message.\n" +
+		"\n" +
+		"From bob@example.com Mon Jan  1 00:00:01 2024\n" +
+		"From: bob@example.com\n" +
+		"Subject: second\n" +
+		"\n" +
+		">>From quoted twice\n"
+
+	mb := extract(t, "inbox.mbox", []byte(mbox))
+	if len(mb.Messages) != 2 {
+		t.Fatalf("got %d messages, want 2", len(mb.Messages))
+	}
+	if got := header(mb.Messages[0], "Subject"); got != "first" {
+		t.Errorf("message 1 Subject = %q", got)
+	}
+	if want := "From the desk of Jane\nFrom here on it is still the first message."; mb.Messages[0].Body != want {
+		t.Errorf("message 1 Body = %q, want %q", mb.Messages[0].Body, want)
+	}
+	if want := ">From quoted twice"; mb.Messages[1].Body != want {
+		t.Errorf("message 2 Body = %q, want %q", mb.Messages[1].Body, want)
+	}
+}
+
+// A message whose header block does not parse is scanned as text rather than
+// dropped.
+func TestExtractUnparseableMessageKeepsText(t *testing.T) {
+	mb := extract(t, "broken.eml", []byte("no header here, SSN 536-22-1874\n"))
+	if len(mb.Messages) != 1 || !strings.Contains(mb.Messages[0].Body, "536-22-1874") {
+		t.Fatalf("messages = %+v", mb.Messages)
+	}
+}
+
+func TestExtractAttachmentBoundIsDisclosed(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("From: a@example.com\r\nContent-Type: multipart/mixed; boundary=b\r\n\r\n")
+	for i := 0; i < MaxAttachments+1; i++ {
+		b.WriteString("--b\r\nContent-Type: application/octet-stream\r\n\r\nx\r\n")
+	}
+	b.WriteString("--b--\r\n")
+
+	mb := extract(t, "many.eml", []byte(b.String()))
+	if got := len(mb.Messages[0].Attachments); got != MaxAttachments {
+		t.Errorf("got %d attachments, want %d", got, MaxAttachments)
+	}
+	if len(mb.Notes) == 0 || !strings.Contains(strings.Join(mb.Notes, "; "), "not examined") {
+		t.Errorf("bound not disclosed: %v", mb.Notes)
+	}
+}
+
+func unicodeProp(s string) []byte {
+	u := utf16.Encode([]rune(s))
+	out := make([]byte, 2*len(u))
+	for i, c := range u {
+		binary.LittleEndian.PutUint16(out[2*i:], c)
+	}
+	return out
+}
+
+// recipientProps is a recipient's fixed-size property stream: an 8-byte header
+// and one PR_RECIPIENT_TYPE entry.
+func recipientProps(kind uint32) []byte {
+	out := make([]byte, 8+16)
+	binary.LittleEndian.PutUint32(out[8:], uint32(prRecipientType)<<16|ptLong)
+	binary.LittleEndian.PutUint32(out[16:], kind)
+	return out
+}
+
+// An Outlook message read with the compound-file reader: subject, sender,
+// recipients by type, body, and a binary attachment in its own storage.
+func TestExtractMSG(t *testing.T) {
+	const recip0 = msgRecipPrefix + "00000000/"
+	const recip1 = msgRecipPrefix + "00000001/"
+	const attach0 = msgAttachPrefix + "00000000/"
+	data := olefixture.MustBuild([]olefixture.Stream{
+		{Name: "__substg1.0_0037001F", Data: unicodeProp("Payroll question")},
+		{Name: "__substg1.0_0C1A001F", Data: unicodeProp("Jane Doe")},
+		{Name: "__substg1.0_5D01001F", Data: unicodeProp("jane@example.com")},
+		{Name: "__substg1.0_1000001F", Data: unicodeProp("Account 12345678, routing 021000021.\r\nThanks")},
+		{Name: recip0 + msgFixedProps, Data: recipientProps(1)},
+		{Name: recip0 + "__substg1.0_3001001F", Data: unicodeProp("Bob")},
+		{Name: recip0 + "__substg1.0_39FE001F", Data: unicodeProp("bob@example.com")},
+		{Name: recip1 + msgFixedProps, Data: recipientProps(2)},
+		{Name: recip1 + "__substg1.0_39FE001F", Data: unicodeProp("carol@example.com")},
+		{Name: attach0 + "__substg1.0_3707001F", Data: unicodeProp("notes.txt")},
+		{Name: attach0 + "__substg1.0_37010102", Data: []byte("SSN 536-22-1874\n")},
+	})
+
+	mb := extract(t, "question.msg", data)
+	if len(mb.Messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(mb.Messages))
+	}
+	m := mb.Messages[0]
+	for name, want := range map[string]string{
+		"Subject": "Payroll question",
+		"From":    "Jane Doe <jane@example.com>",
+		"To":      "Bob <bob@example.com>",
+		"Cc":      "carol@example.com",
+	} {
+		if got := header(m, name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if m.Body != "Account 12345678, routing 021000021.\nThanks" {
+		t.Errorf("Body = %q", m.Body)
+	}
+	if len(m.Attachments) != 1 || m.Attachments[0].Name != "notes.txt" {
+		t.Fatalf("attachments = %+v", m.Attachments)
+	}
+	got, _ := os.ReadFile(m.Attachments[0].TempFilePath)
+	if string(got) != "SSN 536-22-1874\n" {
+		t.Errorf("attachment content = %q", got)
+	}
+}
+
+// A .msg that is not a compound file is read as RFC 5322 text, which is what
+// some tools write under that name.
+func TestExtractMSGNamedTextIsRFC5322(t *testing.T) {
+	mb := extract(t, "export.msg", []byte("Subject: hello\r\n\r\nbody text\r\n"))
+	if len(mb.Messages) != 1 || mb.Messages[0].Body != "body text" {
+		t.Fatalf("messages = %+v", mb.Messages)
+	}
+}
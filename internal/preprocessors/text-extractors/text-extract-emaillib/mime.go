@@ -0,0 +1,311 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package textextractemaillib
+
+import (
+	"bytes"
+	"encoding/base64"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// readMbox splits an mbox into messages and reads each one.
+//
+// A message begins at a line starting "From " that opens the file or follows a
+// blank line. Writers escape such a line inside a body as ">From " (mboxo) or
+// add one more ">" to any run of them (mboxrd); one ">" is removed from each,
+// which restores the original text under either convention for every line a
+// writer could have escaped. Input with no separator at all is one message,
+// which is what an .eml is.
+func (x *extractor) readMbox(data []byte) {
+	var (
+		cur       bytes.Buffer
+		started   bool
+		prevBlank = true
+	)
+	flush := func() {
+		if !started && len(bytes.TrimSpace(cur.Bytes())) == 0 {
+			return
+		}
+		if len(x.mb.Messages) >= MaxMessages {
+			x.messagesCut = true
+			return
+		}
+		x.mb.Messages = append(x.mb.Messages, x.parseMessage(cur.Bytes()))
+	}
+
+	for len(data) > 0 && !x.messagesCut {
+		line := data
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			line = data[:i+1]
+		}
+		data = data[len(line):]
+
+		blank := len(bytes.TrimRight(line, "\r\n")) == 0
+		switch {
+		case prevBlank && bytes.HasPrefix(line, []byte("From ")):
+			flush()
+			cur.Reset()
+			started = true
+		case started && escapedFrom.Match(line):
+			cur.Write(line[1:])
+		default:
+			cur.Write(line)
+		}
+		prevBlank = blank
+	}
+	if !x.messagesCut {
+		flush()
+	}
+}
+
+// escapedFrom matches a body line an mbox writer escaped.
+var escapedFrom = regexp.MustCompile(`^>+From `)
+
+// parseMessage reads one RFC 5322 message.
+//
+// A message whose header block cannot be parsed is not skipped: its bytes are
+// scanned as text, which is how the file was scanned before this reader
+// existed, so a malformed export is never worse off for it.
+func (x *extractor) parseMessage(raw []byte) Message {
+	m, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return Message{Body: decodeCharset(raw, "")}
+	}
+
+	var msg Message
+	for _, name := range addressHeaders {
+		for _, v := range m.Header[name] {
+			if v = decodeHeader(v); v != "" {
+				msg.Headers = append(msg.Headers, Header{Name: name, Value: v})
+			}
+		}
+	}
+	var body strings.Builder
+	x.walk(&msg, textproto.MIMEHeader(m.Header), m.Body, &body, 0)
+	msg.Body = strings.TrimSpace(body.String())
+	return msg
+}
+
+// walk reads one MIME entity into msg: text parts onto body, anything else as
+// an attachment.
+func (x *extractor) walk(msg *Message, header textproto.MIMEHeader, r io.Reader, body *strings.Builder, depth int) {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		// RFC 2045 §5.2: no usable Content-Type means text/plain.
+		mediaType, params = "text/plain", nil
+	}
+	disposition, dispParams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	name := dispParams["filename"]
+	if name == "" {
+		name = params["name"]
+	}
+	name = decodeHeader(name)
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		if params["boundary"] == "" {
+			return
+		}
+		if depth >= maxPartDepth {
+			x.depthCut = true
+			return
+		}
+		x.walkMultipart(msg, mediaType, params["boundary"], r, body, depth)
+		return
+	}
+
+	content := transferDecoder(header, r)
+	switch {
+	case mediaType == "message/rfc822" || mediaType == "message/global":
+		// Routed back through the router as a message of its own, so its
+		// attachments nest under the embedding bound like any other container.
+		if name == "" {
+			name = "message"
+		}
+		if !strings.EqualFold(filepath.Ext(name), ".eml") {
+			name += ".eml"
+		}
+		x.attach(msg, name, content)
+
+	case strings.HasPrefix(mediaType, "text/") && !strings.EqualFold(disposition, "attachment") &&
+		(name == "" || mediaType == "text/plain" || mediaType == "text/html"):
+		data, _ := io.ReadAll(io.LimitReader(content, MaxAttachmentSize))
+		text := decodeCharset(data, params["charset"])
+		if mediaType == "text/html" {
+			text = htmlToText(text)
+		}
+		if text = strings.TrimSpace(text); text != "" {
+			if body.Len() > 0 {
+				body.WriteString("\n\n")
+			}
+			body.WriteString(text)
+		}
+
+	default:
+		x.attach(msg, name, content)
+	}
+}
+
+// walkMultipart reads the parts of one multipart entity.
+//
+// Of a multipart/alternative only one rendering is kept, the plain text when
+// there is one and otherwise the last (RFC 2046 orders them plainest first). The
+// renderings carry the same text, and reading both would report every value in
+// the message twice.
+func (x *extractor) walkMultipart(msg *Message, mediaType, boundary string, r io.Reader, body *strings.Builder, depth int) {
+	mr := multipart.NewReader(r, boundary)
+	alternative := mediaType == "multipart/alternative"
+	var chosen, last string
+	for {
+		// NextRawPart, not NextPart: NextPart decodes quoted-printable itself
+		// and then deletes the header saying so, which transferDecoder needs to
+		// see for every other encoding.
+		part, err := mr.NextRawPart()
+		if err != nil {
+			break
+		}
+		if !alternative {
+			x.walk(msg, part.Header, part, body, depth+1)
+			continue
+		}
+		var sub strings.Builder
+		x.walk(msg, part.Header, part, &sub, depth+1)
+		if sub.Len() == 0 {
+			continue
+		}
+		last = sub.String()
+		if t, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type")); t == "text/plain" && chosen == "" {
+			chosen = last
+		}
+	}
+	if chosen == "" {
+		chosen = last
+	}
+	if chosen != "" {
+		if body.Len() > 0 {
+			body.WriteString("\n\n")
+		}
+		body.WriteString(chosen)
+	}
+}
+
+// transferDecoder undoes a part's Content-Transfer-Encoding.
+func transferDecoder(header textproto.MIMEHeader, r io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(header.Get("Content-Transfer-Encoding"))) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, base64Filter{r})
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	default:
+		return r
+	}
+}
+
+// base64Filter drops everything outside the base64 alphabet. The decoder
+// already skips line breaks; mail also carries spaces, tabs and stray
+// characters that a lenient reader is expected to ignore (RFC 2045 §6.8).
+type base64Filter struct{ r io.Reader }
+
+func (f base64Filter) Read(p []byte) (int, error) {
+	for {
+		n, err := f.r.Read(p)
+		kept := 0
+		for _, c := range p[:n] {
+			if c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '+' || c == '/' || c == '=' {
+				p[kept] = c
+				kept++
+			}
+		}
+		if kept > 0 || err != nil {
+			return kept, err
+		}
+	}
+}
+
+// wordDecoder decodes RFC 2047 encoded words in headers and attachment names,
+// in any charset the WHATWG encoding index knows.
+var wordDecoder = &mime.WordDecoder{
+	CharsetReader: func(charset string, input io.Reader) (io.Reader, error) {
+		enc, err := htmlindex.Get(charset)
+		if err != nil {
+			return nil, err
+		}
+		return enc.NewDecoder().Reader(input), nil
+	},
+}
+
+// decodeHeader decodes a header value's encoded words, leaving a value that
+// does not decode as it is.
+func decodeHeader(v string) string {
+	if d, err := wordDecoder.DecodeHeader(v); err == nil {
+		v = d
+	}
+	return strings.TrimSpace(v)
+}
+
+// decodeCharset converts text in the named charset to UTF-8. Text with no
+// charset, or one nothing here recognises, is kept when it is valid UTF-8 and
+// otherwise read as Windows-1252, the charset undeclared 8-bit mail almost
+// always turns out to be.
+func decodeCharset(b []byte, charset string) string {
+	switch cs := strings.ToLower(strings.TrimSpace(charset)); cs {
+	case "", "utf-8", "utf8", "us-ascii", "ascii":
+	default:
+		if enc, err := htmlindex.Get(cs); err == nil {
+			if out, err := enc.NewDecoder().Bytes(b); err == nil {
+				return string(out)
+			}
+		}
+	}
+	if utf8.Valid(b) {
+		return string(b)
+	}
+	out, _ := charmap.Windows1252.NewDecoder().Bytes(b)
+	return string(out)
+}
+
+var (
+	htmlInvisibleRe = regexp.MustCompile(`(?is)<(script|style|head)\b.*?</(?:script|style|head)\s*>|<!--.*?-->`)
+	htmlBreakRe     = regexp.MustCompile(`(?i)<br\s*/?>|</(?:p|div|tr|li|h[1-6]|blockquote|table)\s*>`)
+	htmlCellRe      = regexp.MustCompile(`(?i)</t[dh]\s*>`)
+	htmlTagRe       = regexp.MustCompile(`(?s)<[^>]*>`)
+	htmlSpaceRe     = regexp.MustCompile(`[ \t\f\v\x{00a0}]+`)
+)
+
+// htmlToText reduces an HTML body to the text a reader of the message sees,
+// one line per block.
+//
+// Deliberately simple. An HTML mail body is a rendering of what its sender
+// typed, not a document to be laid out; what matters is that the values in it
+// come out whole and that text from separate blocks does not run together into
+// something that looks like a number.
+func htmlToText(s string) string {
+	s = htmlInvisibleRe.ReplaceAllString(s, "")
+	s = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ").Replace(s)
+	s = htmlBreakRe.ReplaceAllString(s, "\n")
+	s = htmlCellRe.ReplaceAllString(s, "\t")
+	s = htmlTagRe.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+
+	lines := strings.Split(s, "\n")
+	out := lines[:0]
+	for _, line := range lines {
+		if line = strings.TrimSpace(htmlSpaceRe.ReplaceAllString(line, " ")); line != "" {
+			out = append(out, line)
+		}
+	}
+	return strings.Join(out, "\n")
+}
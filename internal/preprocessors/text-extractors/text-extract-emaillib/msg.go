@@ -0,0 +1,403 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package textextractemaillib
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/textproto"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/richardlehane/mscfb"
+)
+
+// Outlook .msg support.
+//
+// A .msg is an OLE compound file (MS-OXMSG), read with the same reader as a
+// legacy .doc. Each MAPI property of the message is its own stream, named
+// "__substg1.0_" followed by the property tag in hex: "__substg1.0_0037001F" is
+// PR_SUBJECT (0x0037) as a UTF-16 string (0x001F). Recipients and attachments
+// are storages holding their own property streams, and an attached message is a
+// storage holding a whole message of its own.
+
+// oleSignature is the header every compound file begins with.
+var oleSignature = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+const (
+	msgPropertyPrefix = "__substg1.0_"
+	msgRecipPrefix    = "__recip_version1.0_#"
+	msgAttachPrefix   = "__attach_version1.0_#"
+	msgFixedProps     = "__properties_version1.0"
+	// msgEmbeddedMessage is the storage an attached message lives in:
+	// PR_ATTACH_DATA_OBJ (0x3701) as an object (0x000D).
+	msgEmbeddedMessage = msgPropertyPrefix + "3701000D"
+)
+
+// MAPI property types read here.
+const (
+	ptLong    = 0x0003
+	ptString8 = 0x001E
+	ptUnicode = 0x001F
+	ptBinary  = 0x0102
+)
+
+// MAPI property ids read here.
+const (
+	prSubject                 = 0x0037
+	prSentRepresentingName    = 0x0042
+	prSentRepresentingAddress = 0x0065
+	prTransportHeaders        = 0x007D
+	prRecipientType           = 0x0C15
+	prSenderName              = 0x0C1A
+	prSenderEmailAddress      = 0x0C1F
+	prDisplayBcc              = 0x0E02
+	prDisplayCc               = 0x0E03
+	prDisplayTo               = 0x0E04
+	prBody                    = 0x1000
+	prHTML                    = 0x1013
+	prDisplayName             = 0x3001
+	prEmailAddress            = 0x3003
+	prAttachFilename          = 0x3704
+	prAttachData              = 0x3701
+	prAttachLongFilename      = 0x3707
+	prSMTPAddress             = 0x39FE
+	prSenderSMTPAddress       = 0x5D01
+)
+
+// msgStorage is one storage of a .msg: the message itself, a recipient, an
+// attachment, or an attached message.
+type msgStorage struct {
+	props    map[uint16]msgValue
+	children map[string]*msgStorage
+	fixed    []byte // the __properties_version1.0 stream
+}
+
+// msgValue is one variable-length property value.
+type msgValue struct {
+	typ  uint16
+	data []byte
+}
+
+func newMSGStorage() *msgStorage {
+	return &msgStorage{props: make(map[uint16]msgValue), children: make(map[string]*msgStorage)}
+}
+
+// child returns the named child storage, creating it.
+func (s *msgStorage) child(name string) *msgStorage {
+	c, ok := s.children[name]
+	if !ok {
+		c = newMSGStorage()
+		s.children[name] = c
+	}
+	return c
+}
+
+// childrenWithPrefix returns the child storages whose names begin with prefix,
+// in name order, which for recipients and attachments is their order in the
+// message.
+func (s *msgStorage) childrenWithPrefix(prefix string) []*msgStorage {
+	var names []string
+	for name := range s.children {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	out := make([]*msgStorage, len(names))
+	for i, name := range names {
+		out[i] = s.children[name]
+	}
+	return out
+}
+
+// str returns a string property, or "".
+func (s *msgStorage) str(id uint16) string {
+	v, ok := s.props[id]
+	if !ok {
+		return ""
+	}
+	switch v.typ {
+	case ptUnicode:
+		u := make([]uint16, len(v.data)/2)
+		for i := range u {
+			u[i] = binary.LittleEndian.Uint16(v.data[2*i:])
+		}
+		return strings.TrimSpace(strings.TrimRight(string(utf16.Decode(u)), "\x00"))
+	case ptString8:
+		return strings.TrimSpace(strings.TrimRight(decodeCharset(v.data, ""), "\x00"))
+	}
+	return ""
+}
+
+// long returns a 32-bit property from the fixed-size property stream. The
+// stream is a header of headerLen bytes (32 for the top-level message, 24 for
+// an attached one, 8 for a recipient or attachment) followed by 16-byte
+// entries: the tag, flags, and an 8-byte value whose first four bytes hold a
+// PT_LONG.
+func (s *msgStorage) long(id uint16, headerLen int) (uint32, bool) {
+	for off := headerLen; off+16 <= len(s.fixed); off += 16 {
+		tag := binary.LittleEndian.Uint32(s.fixed[off:])
+		if uint16(tag>>16) == id && uint16(tag) == ptLong {
+			return binary.LittleEndian.Uint32(s.fixed[off+8:]), true
+		}
+	}
+	return 0, false
+}
+
+// readMSG reads an Outlook message.
+func (x *extractor) readMSG(data []byte) error {
+	doc, err := mscfb.New(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("not a readable Outlook message: %w", err)
+	}
+
+	root := newMSGStorage()
+	for entry, err := doc.Next(); err == nil; entry, err = doc.Next() {
+		parent := root
+		for _, p := range entry.Path {
+			parent = parent.child(p)
+		}
+		if entry.FileInfo().IsDir() {
+			parent.child(entry.Name)
+			continue
+		}
+		if entry.Name == msgFixedProps {
+			parent.fixed, _ = io.ReadAll(io.LimitReader(entry, MaxAttachmentSize))
+			continue
+		}
+		id, typ, ok := parsePropertyStreamName(entry.Name)
+		if !ok || (typ != ptUnicode && typ != ptString8 && typ != ptBinary) {
+			continue
+		}
+		// One byte past the cap, so an over-cap attachment is refused rather
+		// than scanned truncated.
+		value, rerr := io.ReadAll(io.LimitReader(entry, MaxAttachmentSize+1))
+		if rerr != nil {
+			continue
+		}
+		parent.props[id] = msgValue{typ: typ, data: value}
+	}
+
+	msg := x.msgMessage(root)
+	for _, att := range root.childrenWithPrefix(msgAttachPrefix) {
+		name := attachmentName(att)
+		if v, ok := att.props[prAttachData]; ok && v.typ == ptBinary {
+			if len(v.data) > MaxAttachmentSize {
+				x.mb.Notes = append(x.mb.Notes, fmt.Sprintf("attachment %q was not examined: exceeds the %d-byte embedded extraction cap",
+					cleanName(name), MaxAttachmentSize))
+				continue
+			}
+			x.attach(&msg, name, bytes.NewReader(v.data))
+			continue
+		}
+		if sub, ok := att.children[msgEmbeddedMessage]; ok {
+			// Rewritten as RFC 5322 and routed back through the router, so an
+			// attached message and its own attachments are read, and bounded,
+			// exactly as a forwarded .eml is.
+			if !strings.HasSuffix(strings.ToLower(name), ".eml") {
+				name += ".eml"
+			}
+			x.attach(&msg, name, bytes.NewReader(x.msgAsRFC5322(sub, 1)))
+		}
+	}
+	x.mb.Messages = append(x.mb.Messages, msg)
+	return nil
+}
+
+// parsePropertyStreamName splits "__substg1.0_0037001F" into the property id
+// and type.
+func parsePropertyStreamName(name string) (id, typ uint16, ok bool) {
+	hex, found := strings.CutPrefix(name, msgPropertyPrefix)
+	if !found || len(hex) != 8 {
+		return 0, 0, false
+	}
+	tag, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, 0, false
+	}
+	return uint16(tag >> 16), uint16(tag), true
+}
+
+// attachmentName is the name Outlook shows for an attachment.
+func attachmentName(att *msgStorage) string {
+	for _, id := range []uint16{prAttachLongFilename, prAttachFilename, prDisplayName} {
+		if name := att.str(id); name != "" {
+			return name
+		}
+	}
+	return "attachment"
+}
+
+// msgMessage reads the headers and body of the message in s.
+//
+// The transport headers, when the message went through a server, are the
+// message's own RFC 5322 headers and are preferred: they carry addresses where
+// the display properties carry only names. Anything they lack comes from the
+// properties, and the recipients table supplies the addresses of To, Cc and Bcc
+// (a Bcc never appears in transport headers at all).
+func (x *extractor) msgMessage(s *msgStorage) Message {
+	values := make(map[string][]string)
+	if raw := s.str(prTransportHeaders); raw != "" {
+		if m, err := mail.ReadMessage(strings.NewReader(strings.TrimRight(raw, "\r\n") + "\r\n\r\n")); err == nil {
+			for _, name := range addressHeaders {
+				for _, v := range m.Header[name] {
+					if v = decodeHeader(v); v != "" {
+						values[name] = append(values[name], v)
+					}
+				}
+			}
+		}
+	}
+
+	if len(values["From"]) == 0 {
+		if from := mailbox(s.str(prSenderName), firstNonEmpty(s.str(prSenderSMTPAddress), s.str(prSenderEmailAddress))); from != "" {
+			values["From"] = []string{from}
+		} else if from := mailbox(s.str(prSentRepresentingName), s.str(prSentRepresentingAddress)); from != "" {
+			values["From"] = []string{from}
+		}
+	}
+
+	recipients := map[uint32][]string{}
+	for _, r := range s.childrenWithPrefix(msgRecipPrefix) {
+		kind, ok := r.long(prRecipientType, 8)
+		if !ok {
+			kind = 1
+		}
+		if m := mailbox(r.str(prDisplayName), firstNonEmpty(r.str(prSMTPAddress), r.str(prEmailAddress))); m != "" {
+			recipients[kind&0x0F] = append(recipients[kind&0x0F], m)
+		}
+	}
+	for kind, spec := range map[uint32]struct {
+		name    string
+		display uint16
+	}{1: {"To", prDisplayTo}, 2: {"Cc", prDisplayCc}, 3: {"Bcc", prDisplayBcc}} {
+		if len(values[spec.name]) > 0 {
+			continue
+		}
+		if list := recipients[kind]; len(list) > 0 {
+			values[spec.name] = []string{strings.Join(list, ", ")}
+		} else if v := s.str(spec.display); v != "" {
+			values[spec.name] = []string{v}
+		}
+	}
+
+	if len(values["Subject"]) == 0 {
+		if v := s.str(prSubject); v != "" {
+			values["Subject"] = []string{v}
+		}
+	}
+
+	var msg Message
+	for _, name := range addressHeaders {
+		for _, v := range values[name] {
+			msg.Headers = append(msg.Headers, Header{Name: name, Value: v})
+		}
+	}
+	msg.Body = s.str(prBody)
+	if msg.Body == "" {
+		if v, ok := s.props[prHTML]; ok {
+			if v.typ == ptBinary {
+				msg.Body = htmlToText(decodeCharset(v.data, ""))
+			} else {
+				msg.Body = htmlToText(s.str(prHTML))
+			}
+		}
+	}
+	msg.Body = strings.TrimSpace(strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(msg.Body))
+	return msg
+}
+
+// msgAsRFC5322 renders the attached message in s, and its own attachments, as
+// an RFC 5322 message. depth counts the attached messages above s.
+func (x *extractor) msgAsRFC5322(s *msgStorage, depth int) []byte {
+	msg := x.msgMessage(s)
+
+	var b bytes.Buffer
+	for _, h := range msg.Headers {
+		value := strings.NewReplacer("\r", " ", "\n", " ").Replace(h.Value)
+		b.WriteString(h.Name + ": " + mime.QEncoding.Encode("utf-8", value) + "\r\n")
+	}
+	// A fixed boundary keeps the output deterministic, and cannot collide with
+	// the content: every part below is base64, whose alphabet has no "-".
+	const boundary = "----ferret-scan-attached-message"
+	b.WriteString("MIME-Version: 1.0\r\nContent-Type: multipart/mixed; boundary=\"" + boundary + "\"\r\n\r\n")
+
+	w := multipart.NewWriter(&b)
+	_ = w.SetBoundary(boundary)
+	part := func(header textproto.MIMEHeader, data []byte) {
+		header.Set("Content-Transfer-Encoding", "base64")
+		pw, err := w.CreatePart(header)
+		if err != nil {
+			return
+		}
+		enc := base64.StdEncoding.EncodeToString(data)
+		for len(enc) > 76 {
+			io.WriteString(pw, enc[:76]+"\r\n")
+			enc = enc[76:]
+		}
+		io.WriteString(pw, enc+"\r\n")
+	}
+	part(textproto.MIMEHeader{"Content-Type": {"text/plain; charset=utf-8"}}, []byte(msg.Body))
+
+	for _, att := range s.childrenWithPrefix(msgAttachPrefix) {
+		name := attachmentName(att)
+		var data []byte
+		if v, ok := att.props[prAttachData]; ok && v.typ == ptBinary {
+			if len(v.data) > MaxAttachmentSize {
+				x.mb.Notes = append(x.mb.Notes, fmt.Sprintf("attachment %q was not examined: exceeds the %d-byte embedded extraction cap",
+					cleanName(name), MaxAttachmentSize))
+				continue
+			}
+			data = v.data
+		} else if sub, ok := att.children[msgEmbeddedMessage]; ok {
+			if depth >= maxPartDepth {
+				x.depthCut = true
+				continue
+			}
+			if !strings.HasSuffix(strings.ToLower(name), ".eml") {
+				name += ".eml"
+			}
+			data = x.msgAsRFC5322(sub, depth+1)
+		} else {
+			continue
+		}
+		part(textproto.MIMEHeader{
+			"Content-Type":        {"application/octet-stream"},
+			"Content-Disposition": {mime.FormatMediaType("attachment", map[string]string{"filename": cleanName(name)})},
+		}, data)
+	}
+	w.Close()
+	return b.Bytes()
+}
+
+// mailbox formats a display name and address as one mailbox, with whichever
+// of the two is present.
+func mailbox(name, address string) string {
+	switch {
+	case name != "" && address != "" && !strings.EqualFold(name, address):
+		return name + " <" + address + ">"
+	case address != "":
+		return address
+	default:
+		return name
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
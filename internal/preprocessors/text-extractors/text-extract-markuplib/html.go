@@ -0,0 +1,337 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package textextractmarkuplib
+
+import (
+	"bytes"
+	"html"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// blockTags separate HTML text; cellTags separate table cells.
+var (
+	blockTags = map[string]bool{
+		"address": true, "article": true, "aside": true, "blockquote": true, "body": true,
+		"br": true, "caption": true, "dd": true, "div": true, "dl": true, "dt": true,
+		"fieldset": true, "figcaption": true, "figure": true, "footer": true, "form": true,
+		"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+		"head": true, "header": true, "hr": true, "li": true, "main": true, "nav": true,
+		"ol": true, "option": true, "p": true, "pre": true, "section": true, "table": true,
+		"tbody": true, "textarea": true, "tfoot": true, "thead": true, "title": true,
+		"tr": true, "ul": true,
+	}
+	cellTags = map[string]bool{"td": true, "th": true}
+
+	// rawTextTags hold text no tag or entity is recognised in.
+	rawTextTags = map[string]bool{"script": true, "style": true}
+
+	// skippedAttrs never hold HTML document text, and scanning them would only
+	// add noise: a class list or an inline style is not something anyone
+	// redacts. In XML they are ordinary names and are kept.
+	skippedAttrs = map[string]bool{"class": true, "id": true, "style": true}
+)
+
+// xmlEntities are the only named entities XML defines.
+var xmlEntities = map[string]string{"amp": "&", "lt": "<", "gt": ">", "quot": `"`, "apos": "'"}
+
+// attrValue is the source range of an attribute value worth scanning.
+type attrValue struct {
+	start, end int
+	ctx        Context
+}
+
+// extractTagged extracts the text of an HTML or XML document.
+//
+// Character data is decoded in place, and so are attribute values, so an alt
+// text or a data-ssn value is scanned where it sits. Comments, CDATA sections
+// and script or style bodies are kept as raw text: a credential in a comment
+// is still in the file.
+//
+// Every line break inside dropped markup is kept, so line N of the text is
+// line N of the file and a finding's line number points at the source. Other
+// markup becomes at most a tab: HTML block elements, table cells, attribute
+// values and XML elements with nothing between them are separated, so
+// "<td>Jane</td><td>536-22-1874</td>" does not read as one token. Inline
+// elements are not: "123-<b>45</b>-6789" reads as the value it displays.
+func extractTagged(src []byte, xml bool) *Extraction {
+	var b builder
+
+	textStart := 0
+	adjacentTags := true // XML: no character data since the last tag
+	flush := func(end int) {
+		if textStart < end {
+			decodeEntities(&b, src, textStart, end, ContextText, xml)
+			adjacentTags = false
+		}
+	}
+
+	i := 0
+	for i < len(src) {
+		if src[i] != '<' {
+			i++
+			continue
+		}
+		rest := src[i:]
+		switch {
+		case bytes.HasPrefix(rest, []byte("<!--")):
+			flush(i)
+			end := indexFrom(src, i+4, "-->")
+			b.separate("\t", i)
+			b.literal(src, i+4, end, ContextRaw, false)
+			b.separate("\t", end)
+			i = min(end+3, len(src))
+		case bytes.HasPrefix(rest, []byte("<![CDATA[")):
+			flush(i)
+			end := indexFrom(src, i+9, "]]>")
+			b.literal(src, i+9, end, ContextRaw, false)
+			adjacentTags = false
+			i = min(end+3, len(src))
+		case bytes.HasPrefix(rest, []byte("<!")), bytes.HasPrefix(rest, []byte("<?")):
+			flush(i)
+			end := skipTag(src, i)
+			b.newlines(src, i, end)
+			i = end
+		case len(rest) > 2 && rest[1] == '/' && isNameStart(rest[2]):
+			flush(i)
+			name, _ := tagName(src, i+2)
+			end := skipTag(src, i)
+			separateTag(&b, name, i, xml, adjacentTags, true)
+			b.newlines(src, i, end)
+			adjacentTags = true
+			i = end
+		case len(rest) > 1 && isNameStart(rest[1]):
+			flush(i)
+			name, end, attrs := parseStartTag(src, i+1, xml)
+			separateTag(&b, name, i, xml, adjacentTags, false)
+			pos := i
+			for _, a := range attrs {
+				b.newlines(src, pos, a.start)
+				b.separate("\t", a.start)
+				decodeEntities(&b, src, a.start, a.end, a.ctx, xml)
+				pos = a.end
+			}
+			b.newlines(src, pos, end)
+			if len(attrs) > 0 {
+				b.separate("\t", end)
+			}
+			adjacentTags = true
+			i = end
+			if !xml && rawTextTags[name] {
+				closeAt := indexFoldFrom(src, i, "</"+name)
+				b.literal(src, i, closeAt, ContextRaw, false)
+				b.separate("\t", closeAt)
+				i = closeAt
+			}
+		default:
+			// A "<" that opens no tag is text.
+			i++
+			continue
+		}
+		textStart = i
+	}
+	flush(len(src))
+	return b.extraction()
+}
+
+// separateTag writes the separator a tag implies, if any. A cell is separated
+// from the one before it when it opens; a block element on either side.
+func separateTag(b *builder, name string, at int, xml, adjacentTags, closing bool) {
+	switch {
+	case xml:
+		if adjacentTags {
+			b.separate("\t", at)
+		}
+	case cellTags[name]:
+		if !closing {
+			b.separate("\t", at)
+		}
+	case blockTags[name]:
+		b.separate("\t", at)
+	}
+}
+
+// parseStartTag reads a start tag whose name begins at i and returns its
+// lower-cased name (XML names keep their case), the offset after its closing
+// ">", and the attribute values worth scanning.
+func parseStartTag(src []byte, i int, xml bool) (string, int, []attrValue) {
+	name, i := tagName(src, i)
+	if !xml {
+		name = strings.ToLower(name)
+	}
+	var attrs []attrValue
+	for i < len(src) {
+		for i < len(src) && isSpace(src[i]) {
+			i++
+		}
+		if i >= len(src) {
+			break
+		}
+		if src[i] == '>' {
+			return name, i + 1, attrs
+		}
+		if src[i] == '/' {
+			i++
+			continue
+		}
+		nameStart := i
+		for i < len(src) && !isSpace(src[i]) && src[i] != '=' && src[i] != '>' && src[i] != '/' {
+			i++
+		}
+		attrName := strings.ToLower(string(src[nameStart:i]))
+		if i == nameStart {
+			i++
+			continue
+		}
+		for i < len(src) && isSpace(src[i]) {
+			i++
+		}
+		if i >= len(src) || src[i] != '=' {
+			continue
+		}
+		i++
+		for i < len(src) && isSpace(src[i]) {
+			i++
+		}
+		if i >= len(src) {
+			break
+		}
+		var v attrValue
+		if q := src[i]; q == '"' || q == '\'' {
+			end := bytes.IndexByte(src[i+1:], q)
+			if end < 0 {
+				end = len(src) - i - 1
+			}
+			v = attrValue{start: i + 1, end: i + 1 + end, ctx: ContextAttr}
+			i = min(v.end+1, len(src))
+		} else {
+			start := i
+			for i < len(src) && !isSpace(src[i]) && src[i] != '>' {
+				i++
+			}
+			v = attrValue{start: start, end: i, ctx: ContextAttrUnquoted}
+		}
+		if v.start < v.end && !(skippedAttrs[attrName] && !xml) && !strings.HasPrefix(attrName, "xmlns") {
+			attrs = append(attrs, v)
+		}
+	}
+	return name, len(src), attrs
+}
+
+// decodeEntities appends src[start:end] with its character references
+// decoded. An "&" that does not begin a reference the format defines is text.
+func decodeEntities(b *builder, src []byte, start, end int, ctx Context, xml bool) {
+	run := start
+	for i := start; i < end; i++ {
+		if src[i] != '&' {
+			continue
+		}
+		semi := -1
+		for j := i + 1; j < end && j-i <= 32; j++ {
+			c := src[j]
+			if c == ';' {
+				semi = j
+				break
+			}
+			if !isNameByte(c) && c != '#' {
+				break
+			}
+		}
+		if semi < 0 {
+			continue
+		}
+		decoded, ok := decodeReference(string(src[i+1:semi]), xml)
+		if !ok {
+			continue
+		}
+		b.literal(src, run, i, ctx, false)
+		b.escaped(decoded, i, semi+1, ctx)
+		run = semi + 1
+		i = semi
+	}
+	b.literal(src, run, end, ctx, false)
+}
+
+// decodeReference decodes the body of a character reference ("amp", "#57",
+// "#x39").
+func decodeReference(ref string, xml bool) (string, bool) {
+	if ref == "" {
+		return "", false
+	}
+	if ref[0] == '#' {
+		var n uint64
+		var err error
+		if len(ref) > 1 && (ref[1] == 'x' || ref[1] == 'X') {
+			n, err = strconv.ParseUint(ref[2:], 16, 32)
+		} else {
+			n, err = strconv.ParseUint(ref[1:], 10, 32)
+		}
+		if err != nil || n == 0 || !utf8.ValidRune(rune(n)) {
+			return "", false
+		}
+		return string(rune(n)), true
+	}
+	if xml {
+		s, ok := xmlEntities[ref]
+		return s, ok
+	}
+	// UnescapeString also decodes a legacy entity that is only a prefix of
+	// the name ("&ampx;" becomes "&x;"), which is not this reference.
+	raw := "&" + ref + ";"
+	if s := html.UnescapeString(raw); s != raw && (ref == "semi" || !strings.HasSuffix(s, ";")) {
+		return s, true
+	}
+	return "", false
+}
+
+// tagName reads an element name starting at i.
+func tagName(src []byte, i int) (string, int) {
+	start := i
+	for i < len(src) && !isSpace(src[i]) && src[i] != '>' && src[i] != '/' {
+		i++
+	}
+	return string(src[start:i]), i
+}
+
+// skipTag returns the offset just past the ">" closing the markup at i.
+func skipTag(src []byte, i int) int {
+	if end := bytes.IndexByte(src[i:], '>'); end >= 0 {
+		return i + end + 1
+	}
+	return len(src)
+}
+
+// indexFrom returns the offset of sep at or after i, or len(src).
+func indexFrom(src []byte, i int, sep string) int {
+	if i >= len(src) {
+		return len(src)
+	}
+	if n := bytes.Index(src[i:], []byte(sep)); n >= 0 {
+		return i + n
+	}
+	return len(src)
+}
+
+// indexFoldFrom is indexFrom ignoring ASCII case, for closing raw-text tags.
+func indexFoldFrom(src []byte, i int, sep string) int {
+	for j := i; j+len(sep) <= len(src); j++ {
+		if src[j] == '<' && bytes.EqualFold(src[j:j+len(sep)], []byte(sep)) {
+			return j
+		}
+	}
+	return len(src)
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+func isNameStart(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c == ':' || c >= 0x80
+}
+
+func isNameByte(c byte) bool {
+	return isNameStart(c) || c >= '0' && c <= '9' || c == '-' || c == '.'
+}
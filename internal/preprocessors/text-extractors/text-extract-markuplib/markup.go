@@ -0,0 +1,328 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package textextractmarkuplib extracts the readable text of HTML, XML and RTF
+// documents and keeps a map from every byte of that text back to the source
+// bytes it came from, so a redactor can rewrite the character data and nothing
+// else.
+package textextractmarkuplib
+
+import (
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Format is the markup language of a document.
+type Format int
+
+const (
+	FormatHTML Format = iota
+	FormatXML
+	FormatRTF
+)
+
+// String returns the short name used in content metadata.
+func (f Format) String() string {
+	switch f {
+	case FormatXML:
+		return "xml"
+	case FormatRTF:
+		return "rtf"
+	default:
+		return "html"
+	}
+}
+
+// FormatFor returns the format a file is read as, by extension.
+func FormatFor(path string) (Format, bool) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".html", ".htm", ".xhtml":
+		return FormatHTML, true
+	case ".xml":
+		return FormatXML, true
+	case ".rtf":
+		return FormatRTF, true
+	}
+	return 0, false
+}
+
+// SegmentKind says how a piece of extracted text relates to its source bytes.
+type SegmentKind uint8
+
+const (
+	// Literal text is the source bytes themselves, byte for byte, so any
+	// sub-range of it maps to the same sub-range of the source.
+	Literal SegmentKind = iota
+	// Escaped text was decoded from one escape (an entity, an RTF \'hh or \u),
+	// so it maps to the whole escape and is rewritten whole.
+	Escaped
+	// Synthetic text stands for markup (a line break for </p>, a tab for a
+	// table cell). It has no character data behind it and is never rewritten.
+	Synthetic
+)
+
+// Context is the syntax a segment's source bytes sit in, which decides how
+// replacement text is escaped when it is written back.
+type Context uint8
+
+const (
+	// ContextText is HTML or XML character data.
+	ContextText Context = iota
+	// ContextAttr is a quoted attribute value.
+	ContextAttr
+	// ContextAttrUnquoted is an unquoted HTML attribute value.
+	ContextAttrUnquoted
+	// ContextRaw is text no escape is recognised in: a script or style body, a
+	// comment, a CDATA section, or a file that only claims to be RTF.
+	ContextRaw
+	// ContextRTF is RTF body text.
+	ContextRTF
+)
+
+// Segment maps Text[Start:End] of an Extraction to Source[SrcStart:SrcEnd].
+type Segment struct {
+	Start, End       int
+	SrcStart, SrcEnd int
+	Kind             SegmentKind
+	Context          Context
+	// AfterControlWord is set on an RTF literal run that directly follows a
+	// control word with no delimiting space. Whatever is written at its start
+	// would otherwise be read as part of that control word.
+	AfterControlWord bool
+}
+
+// Extraction is the readable text of a markup document and its source map.
+// Segments are in text order and cover Text without gaps.
+type Extraction struct {
+	Text     string
+	Segments []Segment
+}
+
+// Extract returns the readable text of src read as format. It never fails:
+// markup that does not parse is kept as text, which is what a scan of the raw
+// bytes would have seen.
+func Extract(src []byte, format Format) *Extraction {
+	switch format {
+	case FormatRTF:
+		return extractRTF(src)
+	case FormatXML:
+		return extractTagged(src, true)
+	default:
+		return extractTagged(src, false)
+	}
+}
+
+// builder accumulates extracted text and its segments.
+type builder struct {
+	text strings.Builder
+	segs []Segment
+}
+
+// literal appends src[start:end] as literal text, extending the previous
+// segment when the two are contiguous in both text and source.
+func (b *builder) literal(src []byte, start, end int, ctx Context, afterControlWord bool) {
+	if start >= end {
+		return
+	}
+	pos := b.text.Len()
+	b.text.Write(src[start:end])
+	if n := len(b.segs); n > 0 && !afterControlWord {
+		last := &b.segs[n-1]
+		if last.Kind == Literal && last.Context == ctx && last.SrcEnd == start && last.End == pos {
+			last.End, last.SrcEnd = b.text.Len(), end
+			return
+		}
+	}
+	b.segs = append(b.segs, Segment{Start: pos, End: b.text.Len(), SrcStart: start, SrcEnd: end,
+		Kind: Literal, Context: ctx, AfterControlWord: afterControlWord})
+}
+
+// escaped appends s, decoded from the escape at src[start:end].
+func (b *builder) escaped(s string, start, end int, ctx Context) {
+	if s == "" {
+		return
+	}
+	pos := b.text.Len()
+	b.text.WriteString(s)
+	b.segs = append(b.segs, Segment{Start: pos, End: b.text.Len(), SrcStart: start, SrcEnd: end,
+		Kind: Escaped, Context: ctx})
+}
+
+// synthetic appends s standing for the markup at source offset at.
+func (b *builder) synthetic(s string, at int) {
+	pos := b.text.Len()
+	b.text.WriteString(s)
+	b.segs = append(b.segs, Segment{Start: pos, End: b.text.Len(), SrcStart: at, SrcEnd: at, Kind: Synthetic})
+}
+
+// separate appends a separator unless the text is empty or already ends with
+// whitespace, so runs of markup produce one separator and not many.
+func (b *builder) separate(s string, at int) {
+	n := b.text.Len()
+	if n == 0 {
+		return
+	}
+	switch b.text.String()[n-1] {
+	case '\n', '\t', ' ':
+		return
+	}
+	b.synthetic(s, at)
+}
+
+// newlines appends a line break for every one in src[start:end], markup that
+// is otherwise dropped, so each line of the text is the same line of the
+// source.
+func (b *builder) newlines(src []byte, start, end int) {
+	for i := start; i < end; i++ {
+		if src[i] == '\n' {
+			b.synthetic("\n", i)
+		}
+	}
+}
+
+func (b *builder) extraction() *Extraction {
+	return &Extraction{Text: b.text.String(), Segments: b.segs}
+}
+
+// Edit replaces Text[Start:End] of an Extraction with Replacement.
+type Edit struct {
+	Start, End  int
+	Replacement string
+}
+
+// sourceEdit replaces Source[start:end] with text.
+type sourceEdit struct {
+	start, end int
+	text       string
+}
+
+// Rewrite applies edits made to the extracted text to src, the document it was
+// extracted from, and returns the rewritten document. Only the character data
+// behind each edit changes: tags, attributes' names, RTF control words and
+// groups are copied through untouched.
+//
+// An edit that spans several segments (a value split by a tag, or partly
+// written as entities) is spread over them. Each piece takes as many runes of
+// the replacement as the text it replaces, and the last piece takes whatever
+// is left, so a format-preserving replacement lines up with the original
+// character for character. Synthetic text is skipped, so a tag inside a value
+// survives with the value's pieces on either side of it. An escape is always
+// replaced whole.
+//
+// Replacement text is escaped for where it lands: "<" becomes "&lt;" in
+// character data, a quote becomes "&quot;" in an attribute, "{" becomes "\{"
+// in RTF. Edits that overlap in the source after widening to whole escapes are
+// applied first-come; the caller's residue check is what tells it whether a
+// value survived.
+func (ex *Extraction) Rewrite(src []byte, edits []Edit) []byte {
+	sorted := make([]Edit, len(edits))
+	copy(sorted, edits)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+
+	var out []sourceEdit
+	for _, e := range sorted {
+		out = append(out, ex.sourceEdits(e)...)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].start < out[j].start })
+
+	var buf strings.Builder
+	buf.Grow(len(src))
+	pos := 0
+	for _, e := range out {
+		if e.start < pos {
+			continue
+		}
+		buf.Write(src[pos:e.start])
+		buf.WriteString(e.text)
+		pos = e.end
+	}
+	buf.Write(src[pos:])
+	return []byte(buf.String())
+}
+
+// piece is the part of one segment an edit covers.
+type piece struct {
+	srcStart, srcEnd int
+	runes            int
+	ctx              Context
+	afterControlWord bool
+}
+
+// sourceEdits maps one text edit onto the source.
+func (ex *Extraction) sourceEdits(e Edit) []sourceEdit {
+	if e.Start < 0 || e.End > len(ex.Text) || e.Start >= e.End {
+		return nil
+	}
+	first := sort.Search(len(ex.Segments), func(i int) bool { return ex.Segments[i].End > e.Start })
+
+	var pieces []piece
+	for i := first; i < len(ex.Segments) && ex.Segments[i].Start < e.End; i++ {
+		seg := ex.Segments[i]
+		switch seg.Kind {
+		case Synthetic:
+			continue
+		case Escaped:
+			pieces = append(pieces, piece{srcStart: seg.SrcStart, srcEnd: seg.SrcEnd,
+				runes: utf8.RuneCountInString(ex.Text[seg.Start:seg.End]), ctx: seg.Context})
+		default:
+			lo, hi := max(e.Start, seg.Start), min(e.End, seg.End)
+			srcStart := seg.SrcStart + lo - seg.Start
+			pieces = append(pieces, piece{srcStart: srcStart, srcEnd: seg.SrcStart + hi - seg.Start,
+				runes: utf8.RuneCountInString(ex.Text[lo:hi]), ctx: seg.Context,
+				afterControlWord: seg.AfterControlWord && srcStart == seg.SrcStart})
+		}
+	}
+
+	repl := []rune(e.Replacement)
+	out := make([]sourceEdit, 0, len(pieces))
+	for i, p := range pieces {
+		n := min(p.runes, len(repl))
+		if i == len(pieces)-1 {
+			n = len(repl)
+		}
+		out = append(out, sourceEdit{start: p.srcStart, end: p.srcEnd,
+			text: escape(string(repl[:n]), p.ctx, p.afterControlWord)})
+		repl = repl[n:]
+	}
+	return out
+}
+
+// escape encodes s for the syntax it is written into.
+func escape(s string, ctx Context, afterControlWord bool) string {
+	var b strings.Builder
+	switch ctx {
+	case ContextRaw:
+		return s
+	case ContextRTF:
+		// The space is consumed as the control word's delimiter, so it is
+		// written even when s is empty: deleting the run would otherwise join
+		// the control word to whatever follows it.
+		if afterControlWord {
+			b.WriteByte(' ')
+		}
+		writeRTF(&b, s)
+		return b.String()
+	}
+	for _, r := range s {
+		switch {
+		case r == '&':
+			b.WriteString("&amp;")
+		case r == '<':
+			b.WriteString("&lt;")
+		case r == '>':
+			b.WriteString("&gt;")
+		case r == '"' && ctx != ContextText:
+			b.WriteString("&quot;")
+		case r == '\'' && ctx != ContextText:
+			b.WriteString("&#39;")
+		case ctx == ContextAttrUnquoted && (r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '\f' || r == '=' || r == '`'):
+			b.WriteString("&#" + strconv.Itoa(int(r)) + ";")
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
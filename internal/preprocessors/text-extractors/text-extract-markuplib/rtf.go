@@ -0,0 +1,304 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package textextractmarkuplib
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// skippedDestinations are RTF groups that hold no document text: tables of
+// fonts, colours and styles, pictures and embedded objects as hex, and field
+// instructions (the text a field displays is in its \fldrslt, which is kept).
+var skippedDestinations = map[string]bool{
+	"colorschememapping": true, "colortbl": true, "datastore": true, "filetbl": true,
+	"fldinst": true, "fonttbl": true, "generator": true, "latentstyles": true,
+	"listoverridetable": true, "listtable": true, "nonshppict": true, "object": true,
+	"objdata": true, "pict": true, "rsidtbl": true, "shppict": true, "stylesheet": true,
+	"themedata": true, "xmlnstbl": true,
+}
+
+// rtfBreaks are control words that end a line or a table cell.
+var rtfBreaks = map[string]string{
+	"par": "\n", "line": "\n", "sect": "\n", "page": "\n", "row": "\n",
+	"tab": "\t", "cell": "\t",
+}
+
+// rtfSymbols are control words that stand for one character.
+var rtfSymbols = map[string]string{
+	"emdash": "—", "endash": "–", "bullet": "•",
+	"lquote": "‘", "rquote": "’", "ldblquote": "“", "rdblquote": "”",
+	"emspace": " ", "enspace": " ", "qmspace": " ",
+}
+
+// rtfCodePages are the ANSI code pages \ansicpg may name, for \'hh escapes.
+var rtfCodePages = map[int]*charmap.Charmap{
+	437: charmap.CodePage437, 850: charmap.CodePage850, 866: charmap.CodePage866,
+	1250: charmap.Windows1250, 1251: charmap.Windows1251, 1252: charmap.Windows1252,
+	1253: charmap.Windows1253, 1254: charmap.Windows1254, 1255: charmap.Windows1255,
+	1256: charmap.Windows1256, 1257: charmap.Windows1257, 1258: charmap.Windows1258,
+	10000: charmap.Macintosh,
+}
+
+// rtfGroup is the state a group restores when it closes.
+type rtfGroup struct {
+	skip bool // inside a destination that holds no text
+	uc   int  // fallback characters that follow a \u escape
+}
+
+// extractRTF extracts the text of an RTF document.
+//
+// Control words, groups and the destinations in skippedDestinations are
+// dropped; \par and \cell become line breaks and tabs; \'hh and \uN escapes
+// are decoded, \'hh through the document's \ansicpg code page. A file that
+// does not open with "{\rtf" is not RTF whatever its name, and is kept as
+// text.
+func extractRTF(src []byte) *Extraction {
+	var b builder
+	if !bytes.HasPrefix(bytes.TrimLeft(src, " \t\r\n"), []byte(`{\rtf`)) {
+		b.literal(src, 0, len(src), ContextRaw, false)
+		return b.extraction()
+	}
+
+	codepage := charmap.Windows1252
+	cur := rtfGroup{uc: 1}
+	var stack []rtfGroup
+	groupStart := false // no token yet in the current group
+	afterWord := false  // the last token was a control word with no delimiter
+	highStart := -1     // source offset of a pending high surrogate
+	var high rune
+
+	// flushHigh emits a high surrogate no low surrogate followed.
+	flushHigh := func(end int) {
+		if highStart >= 0 {
+			b.escaped("�", highStart, end, ContextRTF)
+			highStart = -1
+		}
+	}
+
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch c {
+		case '{':
+			stack = append(stack, cur)
+			groupStart = true
+			afterWord = false
+			i++
+			continue
+		case '}':
+			if n := len(stack); n > 0 {
+				cur = stack[n-1]
+				stack = stack[:n-1]
+			}
+			groupStart = false
+			afterWord = false
+			i++
+			continue
+		case '\r', '\n':
+			i++
+			continue
+		case '\\':
+		default:
+			start := i
+			for i < len(src) && src[i] != '\\' && src[i] != '{' && src[i] != '}' && src[i] != '\r' && src[i] != '\n' {
+				i++
+			}
+			if !cur.skip {
+				flushHigh(start)
+				b.literal(src, start, i, ContextRTF, afterWord)
+			}
+			groupStart = false
+			afterWord = false
+			continue
+		}
+
+		// A control symbol or control word.
+		start := i
+		if i+1 >= len(src) {
+			break
+		}
+		next := src[i+1]
+		if !isASCIILetter(next) {
+			i += 2
+			first := groupStart
+			groupStart = false
+			afterWord = false
+			switch next {
+			case '*':
+				if first {
+					cur.skip = true
+				}
+			case '\'':
+				if i+2 > len(src) {
+					i = len(src)
+					continue
+				}
+				v, err := strconv.ParseUint(string(src[i:i+2]), 16, 8)
+				i += 2
+				if err != nil || cur.skip {
+					continue
+				}
+				flushHigh(start)
+				b.escaped(string(codepage.DecodeByte(byte(v))), start, i, ContextRTF)
+			case '\\', '{', '}':
+				if !cur.skip {
+					flushHigh(start)
+					b.escaped(string(next), start, i, ContextRTF)
+				}
+			case '~':
+				if !cur.skip {
+					b.escaped(" ", start, i, ContextRTF)
+				}
+			case '_':
+				if !cur.skip {
+					b.escaped("-", start, i, ContextRTF)
+				}
+			case '\r', '\n':
+				if !cur.skip {
+					b.synthetic("\n", start)
+				}
+			}
+			continue
+		}
+
+		i++
+		nameStart := i
+		for i < len(src) && isASCIILetter(src[i]) {
+			i++
+		}
+		word := string(src[nameStart:i])
+		param, hasParam := 0, false
+		if i < len(src) && (src[i] == '-' || isASCIIDigit(src[i])) {
+			numStart := i
+			i++
+			for i < len(src) && isASCIIDigit(src[i]) {
+				i++
+			}
+			if n, err := strconv.Atoi(string(src[numStart:i])); err == nil {
+				param, hasParam = n, true
+			}
+		}
+		delimited := i < len(src) && src[i] == ' '
+		if delimited {
+			i++
+		}
+		first := groupStart
+		groupStart = false
+		afterWord = !delimited
+
+		if first && skippedDestinations[word] {
+			cur.skip = true
+		}
+		if cur.skip {
+			if word == "bin" && hasParam && param > 0 {
+				i = min(i+param, len(src))
+			}
+			continue
+		}
+
+		switch word {
+		case "bin":
+			if hasParam && param > 0 {
+				i = min(i+param, len(src))
+			}
+			afterWord = false
+		case "ansicpg":
+			if cm, ok := rtfCodePages[param]; ok {
+				codepage = cm
+			}
+		case "uc":
+			if hasParam && param >= 0 {
+				cur.uc = param
+			}
+		case "u":
+			r := rune(param)
+			if r < 0 {
+				r += 0x10000
+			}
+			i = skipFallback(src, i, cur.uc)
+			afterWord = false
+			switch {
+			case utf16.IsSurrogate(r) && r < 0xDC00:
+				flushHigh(start)
+				highStart, high = start, r
+			case utf16.IsSurrogate(r) && highStart >= 0:
+				b.escaped(string(utf16.DecodeRune(high, r)), highStart, i, ContextRTF)
+				highStart = -1
+			case utf16.IsSurrogate(r):
+				b.escaped("�", start, i, ContextRTF)
+			default:
+				flushHigh(start)
+				b.escaped(string(r), start, i, ContextRTF)
+			}
+		default:
+			if s, ok := rtfBreaks[word]; ok {
+				flushHigh(start)
+				b.synthetic(s, start)
+			} else if s, ok := rtfSymbols[word]; ok {
+				flushHigh(start)
+				b.escaped(s, start, i, ContextRTF)
+			}
+		}
+	}
+	flushHigh(len(src))
+	return b.extraction()
+}
+
+// skipFallback skips the n characters that stand in for a \u escape in a
+// reader that does not understand it. Each is a byte of text or one \'hh; a
+// group boundary or another control word ends them early.
+func skipFallback(src []byte, i, n int) int {
+	for ; n > 0 && i < len(src); n-- {
+		switch src[i] {
+		case '{', '}':
+			return i
+		case '\\':
+			if i+3 < len(src) && src[i+1] == '\'' {
+				i += 4
+				continue
+			}
+			return i
+		default:
+			i++
+		}
+	}
+	return i
+}
+
+// writeRTF writes s as RTF body text. Printable ASCII is written as itself,
+// the three RTF special characters are escaped, and anything else as a \u
+// escape with "?" for readers that do not understand it.
+func writeRTF(b *strings.Builder, s string) {
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '{' || r == '}':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\par `)
+		case r == '\t':
+			b.WriteString(`\tab `)
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r > 0xFFFF:
+			r1, r2 := utf16.EncodeRune(r)
+			b.WriteString(`{\uc1\u` + strconv.Itoa(int(int16(r1))) + `?\u` + strconv.Itoa(int(int16(r2))) + `?}`)
+		default:
+			b.WriteString(`{\uc1\u` + strconv.Itoa(int(int16(r))) + `?}`)
+		}
+	}
+}
+
+func isASCIILetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isASCIIDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
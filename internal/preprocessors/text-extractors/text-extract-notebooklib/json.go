@@ -0,0 +1,372 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package textextractnotebooklib
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// kind is the JSON type of a value.
+type kind uint8
+
+const (
+	kindNull kind = iota
+	kindBool
+	kindNumber
+	kindString
+	kindArray
+	kindObject
+)
+
+// value is one parsed JSON value and the bytes of the document it was read
+// from. encoding/json discards positions, and the redactor rewrites a string
+// by splicing over exactly the bytes of its token, so the notebook is read
+// with this small parser instead.
+type value struct {
+	kind       kind
+	start, end int // the value's bytes, quotes and brackets included
+
+	str    string   // a string's decoded contents
+	keys   []string // an object's keys, in document order
+	fields []*value // an object's values, parallel to keys
+	items  []*value // an array's elements
+}
+
+// field returns an object's value for key, or nil.
+func (v *value) field(key string) *value {
+	if v == nil || v.kind != kindObject {
+		return nil
+	}
+	for i, k := range v.keys {
+		if k == key {
+			return v.fields[i]
+		}
+	}
+	return nil
+}
+
+// maxDepth bounds nesting, so a hostile file cannot exhaust the stack.
+const maxDepth = 512
+
+// parser reads one JSON document.
+type parser struct {
+	data  []byte
+	pos   int
+	depth int
+}
+
+// parse reads a whole JSON document. A UTF-8 byte order mark is skipped.
+func parse(data []byte) (*value, error) {
+	p := &parser{data: data}
+	if strings.HasPrefix(string(data[:min(len(data), 3)]), "\xef\xbb\xbf") {
+		p.pos = 3
+	}
+	v, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	p.space()
+	if p.pos != len(p.data) {
+		return nil, p.errorf("unexpected data after the top-level value")
+	}
+	return v, nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid JSON at byte %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) space() {
+	for p.pos < len(p.data) {
+		switch p.data[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (p *parser) value() (*value, error) {
+	p.space()
+	if p.pos >= len(p.data) {
+		return nil, p.errorf("unexpected end of input")
+	}
+	switch c := p.data[p.pos]; {
+	case c == '{':
+		return p.object()
+	case c == '[':
+		return p.array()
+	case c == '"':
+		start := p.pos
+		s, err := p.string()
+		if err != nil {
+			return nil, err
+		}
+		return &value{kind: kindString, start: start, end: p.pos, str: s}, nil
+	case c == 't':
+		return p.literal("true", kindBool)
+	case c == 'f':
+		return p.literal("false", kindBool)
+	case c == 'n':
+		return p.literal("null", kindNull)
+	case c == '-' || (c >= '0' && c <= '9'):
+		return p.number()
+	default:
+		return nil, p.errorf("unexpected character %q", c)
+	}
+}
+
+func (p *parser) literal(word string, k kind) (*value, error) {
+	if !strings.HasPrefix(string(p.data[p.pos:min(len(p.data), p.pos+len(word))]), word) {
+		return nil, p.errorf("invalid literal")
+	}
+	start := p.pos
+	p.pos += len(word)
+	return &value{kind: k, start: start, end: p.pos}, nil
+}
+
+func (p *parser) number() (*value, error) {
+	start := p.pos
+	for p.pos < len(p.data) && strings.IndexByte("+-.0123456789eE", p.data[p.pos]) >= 0 {
+		p.pos++
+	}
+	if _, err := strconv.ParseFloat(string(p.data[start:p.pos]), 64); err != nil {
+		var numErr *strconv.NumError
+		if !errors.As(err, &numErr) || numErr.Err != strconv.ErrRange {
+			p.pos = start
+			return nil, p.errorf("invalid number")
+		}
+	}
+	return &value{kind: kindNumber, start: start, end: p.pos}, nil
+}
+
+func (p *parser) enter() error {
+	p.depth++
+	if p.depth > maxDepth {
+		return p.errorf("nested more than %d deep", maxDepth)
+	}
+	return nil
+}
+
+func (p *parser) object() (*value, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { p.depth-- }()
+	v := &value{kind: kindObject, start: p.pos}
+	p.pos++
+	p.space()
+	if p.pos < len(p.data) && p.data[p.pos] == '}' {
+		p.pos++
+		v.end = p.pos
+		return v, nil
+	}
+	for {
+		p.space()
+		if p.pos >= len(p.data) || p.data[p.pos] != '"' {
+			return nil, p.errorf("expected an object key")
+		}
+		key, err := p.string()
+		if err != nil {
+			return nil, err
+		}
+		p.space()
+		if p.pos >= len(p.data) || p.data[p.pos] != ':' {
+			return nil, p.errorf("expected ':' after an object key")
+		}
+		p.pos++
+		field, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		v.keys = append(v.keys, key)
+		v.fields = append(v.fields, field)
+		p.space()
+		if p.pos >= len(p.data) {
+			return nil, p.errorf("unexpected end of input in an object")
+		}
+		switch p.data[p.pos] {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			v.end = p.pos
+			return v, nil
+		default:
+			return nil, p.errorf("expected ',' or '}' in an object")
+		}
+	}
+}
+
+func (p *parser) array() (*value, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { p.depth-- }()
+	v := &value{kind: kindArray, start: p.pos}
+	p.pos++
+	p.space()
+	if p.pos < len(p.data) && p.data[p.pos] == ']' {
+		p.pos++
+		v.end = p.pos
+		return v, nil
+	}
+	for {
+		item, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		v.items = append(v.items, item)
+		p.space()
+		if p.pos >= len(p.data) {
+			return nil, p.errorf("unexpected end of input in an array")
+		}
+		switch p.data[p.pos] {
+		case ',':
+			p.pos++
+		case ']':
+			p.pos++
+			v.end = p.pos
+			return v, nil
+		default:
+			return nil, p.errorf("expected ',' or ']' in an array")
+		}
+	}
+}
+
+// string reads a string token, leaving pos after its closing quote.
+func (p *parser) string() (string, error) {
+	p.pos++ // opening quote
+	var b strings.Builder
+	for {
+		if p.pos >= len(p.data) {
+			return "", p.errorf("unterminated string")
+		}
+		c := p.data[p.pos]
+		switch {
+		case c == '"':
+			p.pos++
+			return b.String(), nil
+		case c == '\\':
+			if err := p.escape(&b); err != nil {
+				return "", err
+			}
+		case c < 0x20:
+			return "", p.errorf("control character in a string")
+		default:
+			// Copy the run up to the next quote or escape in one go.
+			end := p.pos
+			for end < len(p.data) && p.data[end] != '"' && p.data[end] != '\\' && p.data[end] >= 0x20 {
+				end++
+			}
+			run := p.data[p.pos:end]
+			if !utf8.Valid(run) {
+				return "", p.errorf("invalid UTF-8 in a string")
+			}
+			b.Write(run)
+			p.pos = end
+		}
+	}
+}
+
+func (p *parser) escape(b *strings.Builder) error {
+	if p.pos+1 >= len(p.data) {
+		return p.errorf("unterminated escape")
+	}
+	c := p.data[p.pos+1]
+	p.pos += 2
+	switch c {
+	case '"', '\\', '/':
+		b.WriteByte(c)
+	case 'b':
+		b.WriteByte('\b')
+	case 'f':
+		b.WriteByte('\f')
+	case 'n':
+		b.WriteByte('\n')
+	case 'r':
+		b.WriteByte('\r')
+	case 't':
+		b.WriteByte('\t')
+	case 'u':
+		r, ok := p.hex4()
+		if !ok {
+			return p.errorf("invalid \\u escape")
+		}
+		if utf16.IsSurrogate(r) {
+			// A lone surrogate decodes to U+FFFD, as in encoding/json.
+			r2 := rune(utf8.RuneError)
+			if p.pos+1 < len(p.data) && p.data[p.pos] == '\\' && p.data[p.pos+1] == 'u' {
+				save := p.pos
+				p.pos += 2
+				if lo, ok := p.hex4(); ok {
+					if d := utf16.DecodeRune(r, lo); d != utf8.RuneError {
+						r2 = d
+					} else {
+						p.pos = save
+					}
+				} else {
+					p.pos = save
+				}
+			}
+			r = r2
+		}
+		b.WriteRune(r)
+	default:
+		return p.errorf("invalid escape \\%c", c)
+	}
+	return nil
+}
+
+func (p *parser) hex4() (rune, bool) {
+	if p.pos+4 > len(p.data) {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(string(p.data[p.pos:p.pos+4]), 16, 32)
+	if err != nil {
+		return 0, false
+	}
+	p.pos += 4
+	return rune(n), true
+}
+
+// quote encodes s as a JSON string the way Jupyter writes one: non-ASCII text
+// as itself, and only quotes, backslashes and control characters escaped.
+func quote(s string) string {
+	var b strings.Builder
+	b.Grow(len(s) + 2)
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		default:
+			if c < 0x20 {
+				fmt.Fprintf(&b, `\u%04x`, c)
+			} else {
+				b.WriteByte(c)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
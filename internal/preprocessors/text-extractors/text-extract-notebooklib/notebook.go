@@ -0,0 +1,349 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package textextractnotebooklib extracts the cells and text outputs of a
+// Jupyter notebook (.ipynb) and keeps a map from that text back to the JSON
+// strings it came from, so a redactor can rewrite those strings and nothing
+// else.
+//
+// A notebook is JSON (nbformat 4): a list of cells, each with its source and,
+// for a code cell, the outputs of its last run. Each cell's source is one
+// segment of the extracted text and each of its outputs another. An output's
+// text is a stream's text, an error's name, value and traceback (with the
+// terminal colour codes removed), or the text/* entries of a result's MIME
+// bundle: text/plain, text/html and the like. Images and other binary entries
+// are base64 payloads that say nothing to a text validator, and are skipped.
+package textextractnotebooklib
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// Segment is the text of one cell's source or one of its outputs.
+type Segment struct {
+	// Cell is the cell's 1-based position in the notebook.
+	Cell int
+	// CellType is the cell's cell_type: "code", "markdown" or "raw".
+	CellType string
+	// Output marks the text of an output rather than the cell's source.
+	Output bool
+	// Line is the 0-based line of Extraction.Text the segment begins on.
+	Line int
+	// Text is the segment's text. It ends with a newline.
+	Text string
+}
+
+// Extraction is the text of a notebook.
+type Extraction struct {
+	// Text is every segment's text, in notebook order.
+	Text     string
+	Segments []Segment
+	// Cells is the number of cells in the notebook.
+	Cells int
+	// SkippedPayloads counts the binary MIME entries not extracted.
+	SkippedPayloads int
+
+	cells    []*value
+	pieces   []piece
+	segStart []int // byte offset of each segment in Text
+}
+
+// piece is a run of Text read from one JSON string.
+type piece struct {
+	start, end int // bytes of Text
+	tok        *value
+	cell       int // 0-based
+	output     bool
+	// idx maps each byte of the run, and its end, to an offset in tok.str. It is
+	// nil when the run is tok.str itself.
+	idx []int
+}
+
+// Edit replaces the bytes [Start, End) of Extraction.Text.
+type Edit struct {
+	Start, End  int
+	Replacement string
+}
+
+// IsNotebook reports whether data could begin a notebook: its first
+// non-blank byte opens a JSON object.
+func IsNotebook(head []byte) bool {
+	head = []byte(strings.TrimPrefix(string(head), "\xef\xbb\xbf"))
+	for _, c := range head {
+		switch c {
+		case ' ', '\t', '\n', '\r':
+			continue
+		case '{':
+			return true
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// Extract reads a notebook and extracts the text of its cells and outputs.
+func Extract(data []byte) (*Extraction, error) {
+	root, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+	cells := root.field("cells")
+	if cells == nil || cells.kind != kindArray {
+		if root.field("worksheets") != nil {
+			return nil, errors.New("nbformat 3 notebooks are not supported")
+		}
+		return nil, errors.New("not a Jupyter notebook: no cells")
+	}
+
+	b := &builder{ex: &Extraction{Cells: len(cells.items), cells: cells.items}}
+	for i, cell := range cells.items {
+		cellType := "code"
+		if t := cell.field("cell_type"); t != nil && t.kind == kindString {
+			cellType = t.str
+		}
+
+		b.begin(i, cellType, false)
+		b.multiline(cell.field("source"), false)
+		b.end()
+
+		outputs := cell.field("outputs")
+		if outputs == nil || outputs.kind != kindArray {
+			continue
+		}
+		for _, out := range outputs.items {
+			b.begin(i, cellType, true)
+			b.addOutput(out)
+			b.end()
+		}
+	}
+	b.ex.Text = b.text.String()
+	return b.ex, nil
+}
+
+// builder accumulates the text and its pieces.
+type builder struct {
+	ex   *Extraction
+	text strings.Builder
+	line int
+
+	cell     int
+	cellType string
+	output   bool
+	start    int
+}
+
+func (b *builder) begin(cell int, cellType string, output bool) {
+	b.cell, b.cellType, b.output = cell, cellType, output
+	b.start = b.text.Len()
+}
+
+// end closes the segment, ending it with a newline, or drops it if it is empty.
+func (b *builder) end() {
+	if b.text.Len() == b.start {
+		return
+	}
+	if !strings.HasSuffix(b.text.String()[b.start:], "\n") {
+		b.literal("\n")
+	}
+	text := b.text.String()[b.start:]
+	b.ex.Segments = append(b.ex.Segments, Segment{
+		Cell:     b.cell + 1,
+		CellType: b.cellType,
+		Output:   b.output,
+		Line:     b.line,
+		Text:     text,
+	})
+	b.ex.segStart = append(b.ex.segStart, b.start)
+	b.line += strings.Count(text, "\n")
+}
+
+func (b *builder) literal(s string) {
+	b.text.WriteString(s)
+}
+
+// str appends one JSON string, with terminal escape sequences removed when
+// stripANSI is set.
+func (b *builder) str(tok *value, stripANSI bool) {
+	if tok == nil || tok.kind != kindString || tok.str == "" {
+		return
+	}
+	p := piece{start: b.text.Len(), tok: tok, cell: b.cell, output: b.output}
+	text := tok.str
+	if stripANSI && strings.IndexByte(text, 0x1b) >= 0 {
+		text, p.idx = stripEscapes(tok.str)
+	}
+	b.text.WriteString(text)
+	p.end = b.text.Len()
+	b.ex.pieces = append(b.ex.pieces, p)
+}
+
+// multiline appends a field, which nbformat stores either as one
+// string or as a list of lines.
+func (b *builder) multiline(v *value, stripANSI bool) {
+	if v == nil {
+		return
+	}
+	switch v.kind {
+	case kindString:
+		b.str(v, stripANSI)
+	case kindArray:
+		for _, item := range v.items {
+			b.str(item, stripANSI)
+		}
+	}
+}
+
+// lineOf appends a field as a line of its own.
+func (b *builder) lineOf(v *value, stripANSI bool) {
+	before := b.text.Len()
+	b.multiline(v, stripANSI)
+	if b.text.Len() > before && !strings.HasSuffix(b.text.String(), "\n") {
+		b.literal("\n")
+	}
+}
+
+// addOutput appends the text of one output.
+func (b *builder) addOutput(out *value) {
+	kind := ""
+	if t := out.field("output_type"); t != nil {
+		kind = t.str
+	}
+	switch kind {
+	case "stream":
+		b.multiline(out.field("text"), true)
+	case "error":
+		b.lineOf(out.field("ename"), false)
+		b.lineOf(out.field("evalue"), false)
+		if tb := out.field("traceback"); tb != nil && tb.kind == kindArray {
+			for _, frame := range tb.items {
+				b.lineOf(frame, true)
+			}
+		}
+	case "execute_result", "display_data", "update_display_data":
+		data := out.field("data")
+		if data == nil || data.kind != kindObject {
+			return
+		}
+		for i, mime := range data.keys {
+			if !strings.HasPrefix(mime, "text/") {
+				b.ex.SkippedPayloads++
+				continue
+			}
+			b.lineOf(data.fields[i], true)
+		}
+	}
+}
+
+// stripEscapes removes ANSI escape sequences (colour codes in tracebacks and
+// progress output) and returns the offsets of the kept bytes in s.
+func stripEscapes(s string) (string, []int) {
+	var out strings.Builder
+	idx := make([]int, 0, len(s)+1)
+	for i := 0; i < len(s); {
+		if s[i] == 0x1b && i+1 < len(s) && s[i+1] == '[' {
+			j := i + 2
+			for j < len(s) && (s[j] < 0x40 || s[j] > 0x7e) {
+				j++
+			}
+			if j < len(s) {
+				i = j + 1
+				continue
+			}
+		}
+		out.WriteByte(s[i])
+		idx = append(idx, i)
+		i++
+	}
+	return out.String(), append(idx, len(s))
+}
+
+// CellAt returns the segment the byte at offset of Text belongs to.
+func (ex *Extraction) CellAt(offset int) (Segment, bool) {
+	i := sort.Search(len(ex.segStart), func(i int) bool { return ex.segStart[i] > offset }) - 1
+	if i < 0 || offset >= ex.segStart[i]+len(ex.Segments[i].Text) {
+		return Segment{}, false
+	}
+	return ex.Segments[i], true
+}
+
+// Rewrite returns the notebook with each edit applied to the JSON strings its
+// text came from, and with the outputs of every cell in clear (1-based) emptied.
+// Every other byte of the document is unchanged.
+//
+// An edit that spans more than one string puts its replacement in the first
+// and removes the rest. Text the extraction added itself, such as the newline
+// that closes a segment, has no source and is not edited.
+func (ex *Extraction) Rewrite(data []byte, edits []Edit, clear map[int]bool) []byte {
+	type local struct {
+		lo, hi int
+		repl   string
+	}
+	byToken := map[*value][]local{}
+	for _, e := range edits {
+		first := true
+		i := sort.Search(len(ex.pieces), func(i int) bool { return ex.pieces[i].end > e.Start })
+		for ; i < len(ex.pieces) && ex.pieces[i].start < e.End; i++ {
+			p := ex.pieces[i]
+			if p.output && clear[p.cell+1] {
+				continue
+			}
+			s, t := max(e.Start, p.start)-p.start, min(e.End, p.end)-p.start
+			if p.idx != nil {
+				// The end maps to just past the last byte edited, so an escape
+				// sequence after the value is kept, like one before it.
+				if t > s {
+					s, t = p.idx[s], p.idx[t-1]+1
+				} else {
+					s, t = p.idx[s], p.idx[s]
+				}
+			}
+			repl := ""
+			if first {
+				repl, first = e.Replacement, false
+			}
+			byToken[p.tok] = append(byToken[p.tok], local{s, t, repl})
+		}
+	}
+
+	type splice struct {
+		start, end int
+		with       string
+	}
+	var splices []splice
+	for tok, ls := range byToken {
+		sort.Slice(ls, func(i, j int) bool { return ls[i].lo < ls[j].lo })
+		var s strings.Builder
+		at := 0
+		for _, l := range ls {
+			if l.lo < at {
+				continue // overlaps an edit already applied
+			}
+			s.WriteString(tok.str[at:l.lo])
+			s.WriteString(l.repl)
+			at = l.hi
+		}
+		s.WriteString(tok.str[at:])
+		splices = append(splices, splice{tok.start, tok.end, quote(s.String())})
+	}
+	for n := range clear {
+		if n < 1 || n > len(ex.cells) {
+			continue
+		}
+		if outputs := ex.cells[n-1].field("outputs"); outputs != nil && len(outputs.items) > 0 {
+			splices = append(splices, splice{outputs.start, outputs.end, "[]"})
+		}
+	}
+	sort.Slice(splices, func(i, j int) bool { return splices[i].start < splices[j].start })
+
+	var out []byte
+	at := 0
+	for _, s := range splices {
+		out = append(out, data[at:s.start]...)
+		out = append(out, s.with...)
+		at = s.end
+	}
+	return append(out, data[at:]...)
+}
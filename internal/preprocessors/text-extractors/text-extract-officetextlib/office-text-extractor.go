@@ -5,6 +5,7 @@ package textextractofficetextlib
 
 import (
 	"archive/zip"
+	"bytes"
 	"fmt"
 	"io"
 	"os"
@@ -13,6 +14,8 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+
+	"github.com/awslabs/ferret-scan/v2/internal/encryption"
 )
 
 // Decompression-amplification bounds. Office files are zip archives; a small
@@ -77,6 +80,16 @@ type TextContent struct {
 	// so a file whose body was skipped was indistinguishable from an empty one and
 	// the run looked clean. Callers surface this and count it as a coverage gap.
 	ExtractionWarning string
+
+	// Cells locates the cell of every spreadsheet value within Text, in
+	// ascending Line order; nil for documents that are not spreadsheets. See
+	// spreadsheet_cells.go.
+	Cells []CellLine
+
+	// Origins marks where comments, tracked deletions, hidden sheets and hidden
+	// slides begin within Text, in ascending Line order; nil when the document
+	// has none. See review_content.go.
+	Origins []OriginSpan
 }
 
 // ExtractText extracts text from an Office document
@@ -121,6 +134,22 @@ func ExtractText(filePath string) (*TextContent, error) {
 	return content, err
 }
 
+// openArchive opens the document's zip container. When the router decrypted the
+// document, the file on disk is the encrypted OLE wrapper and the zip exists only
+// as the plaintext held in memory, so it is read from there; it must not be
+// spilled to a temporary file for zip.OpenReader's sake.
+func openArchive(filePath string) (*zip.Reader, func() error, error) {
+	if data, ok := encryption.Plaintext(filePath); ok {
+		r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		return r, func() error { return nil }, err
+	}
+	rc, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &rc.Reader, rc.Close, nil
+}
+
 // noteEmptyExtraction records an ExtractionWarning when a container whose format
 // carries a document body produced no body text.
 //
@@ -152,11 +181,11 @@ func noteEmptyExtraction(content *TextContent, ext string) {
 // extractDocxText extracts text from a Word document
 func extractDocxText(filePath string, content *TextContent) (*TextContent, error) {
 	// Open the docx file (it's a zip archive)
-	reader, err := zip.OpenReader(filePath)
+	reader, closeArchive, err := openArchive(filePath)
 	if err != nil {
 		return content, fmt.Errorf("error opening file: %v", err)
 	}
-	defer reader.Close()
+	defer closeArchive()
 
 	// Find document content files. Part names are producer-controlled, so the main
 	// document is located through the package relationships and by conventional
@@ -167,6 +196,11 @@ func extractDocxText(filePath string, content *TextContent) (*TextContent, error
 		pkg.relatedParts("", "officeDocument"),
 		[]*zip.File{pkg.lookup("word/document.xml"), pkg.lookup("word/main.xml")},
 	)
+	var relComments []*zip.File
+	for _, doc := range documentFiles {
+		relComments = append(relComments, pkg.relatedParts(doc.Name, "comments")...)
+	}
+	commentFiles := unionParts(relComments, []*zip.File{pkg.lookup("word/comments.xml")})
 	headerFiles := pkg.matching("word/header", ".xml")
 	footerFiles := pkg.matching("word/footer", ".xml")
 	corePropsFile := pkg.lookup("docProps/core.xml")
@@ -197,8 +231,9 @@ func extractDocxText(filePath string, content *TextContent) (*TextContent, error
 		rawDoc.Write(part)
 	}
 
-	// First, remove all XML tags that aren't text content
-	cleanedXML := rawDoc.String()
+	// Tracked deletions come out first, into a section of their own: left in
+	// place they read as body text no one opening the document can see.
+	cleanedXML, deletions := takeTrackedDeletions(rawDoc.String())
 
 	// Handle table cells and tabs - preserve tabular structure
 	// Convert table cell boundaries to tabs
@@ -260,7 +295,8 @@ func extractDocxText(filePath string, content *TextContent) (*TextContent, error
 		if allText.Len() > MaxTotalTextBytes {
 			break
 		}
-		headerText, err := extractWordXMLText(headerFile)
+		headerText, deleted, err := extractWordXMLText(headerFile)
+		deletions = append(deletions, deleted...)
 		if err == nil && headerText != "" {
 			allText.WriteString("--- HEADER ---\n")
 			allText.WriteString(headerText)
@@ -277,14 +313,42 @@ func extractDocxText(filePath string, content *TextContent) (*TextContent, error
 		if allText.Len() > MaxTotalTextBytes {
 			break
 		}
-		footerText, err := extractWordXMLText(footerFile)
+		footerText, deleted, err := extractWordXMLText(footerFile)
+		deletions = append(deletions, deleted...)
 		if err == nil && footerText != "" {
 			allText.WriteString("\n\n--- FOOTER ---\n")
 			allText.WriteString(footerText)
 		}
 	}
 
+	// Comments, then tracked deletions, each under its own heading and origin.
+	var spans originSpans
+	lines := strings.Count(allText.String(), "\n")
+	wroteComments := false
+	for _, commentFile := range commentFiles {
+		for i, c := range readComments(commentFile) {
+			if allText.Len() > MaxTotalTextBytes {
+				break
+			}
+			if !wroteComments {
+				lines += writeHeading(&allText, "COMMENTS")
+				wroteComments = true
+			}
+			name := c.id
+			if name == "" {
+				name = strconv.Itoa(i + 1)
+			}
+			lines += writeComment(&allText, &spans, lines, name, c)
+		}
+	}
+	if len(deletions) > 0 && allText.Len() <= MaxTotalTextBytes {
+		lines += writeHeading(&allText, "TRACKED DELETIONS")
+		spans.mark(lines, OriginTrackedDeletion, "")
+		allText.WriteString(strings.Join(deletions, "\n") + "\n")
+	}
+
 	content.Text = allText.String()
+	content.Origins = spans
 
 	// Extract metadata from core.xml if available
 	if corePropsFile != nil {
@@ -303,11 +367,11 @@ func extractDocxText(filePath string, content *TextContent) (*TextContent, error
 // extractXlsxText extracts text from an Excel spreadsheet
 func extractXlsxText(filePath string, content *TextContent) (*TextContent, error) {
 	// Open the xlsx file (it's a zip archive)
-	reader, err := zip.OpenReader(filePath)
+	reader, closeArchive, err := openArchive(filePath)
 	if err != nil {
 		return content, fmt.Errorf("error opening file: %v", err)
 	}
-	defer reader.Close()
+	defer closeArchive()
 
 	// Find shared strings and worksheets. Resolved through the workbook's
 	// relationships and by conventional name case-insensitively, then unioned —
@@ -342,8 +406,32 @@ func extractXlsxText(filePath string, content *TextContent) (*TextContent, error
 	// Extract shared strings
 	sharedStrings := extractSharedStringsSimple(sharedStringsFile)
 
+	// The names the workbook gives its sheets, for cell locations, and which of
+	// them it hides. The section separator keeps the part label: it is what the
+	// rest of the pipeline has always seen, and a sheet name is as
+	// producer-controlled as a part name.
+	sheets := make(map[string]workbookSheet)
+	for _, wb := range workbookParts {
+		for part, sheet := range workbookSheets(pkg, wb) {
+			if _, seen := sheets[part]; !seen {
+				sheets[part] = sheet
+			}
+		}
+	}
+
 	// Process worksheets
 	var allText strings.Builder
+	var spans originSpans
+	lines := 0
+
+	// Comments are written after every sheet, so the sheets stay one run of
+	// tables; each remembers the sheet it was anchored in.
+	type sheetComment struct {
+		sheet string
+		officeComment
+	}
+	var comments []sheetComment
+	seenComments := make(map[string]bool)
 
 	// Sort worksheets by name
 	sortWorksheets(worksheets)
@@ -357,14 +445,66 @@ func extractXlsxText(filePath string, content *TextContent) (*TextContent, error
 		// Get sheet name (case-insensitive trim: a capitalized "xl/Worksheets/"
 		// otherwise left the directory in the emitted section label).
 		sheetName := trimPartLabel(worksheet.Name, "xl/worksheets/")
+		sheet, named := sheets[worksheet.Name]
+		if named {
+			sheetName = sheet.name
+		}
+		if sheet.hidden {
+			spans.mark(lines, OriginHiddenSheet, sheetName)
+		} else {
+			spans.mark(lines, OriginBody, "")
+		}
 
-		allText.WriteString("--- " + sheetName + " ---\n")
+		allText.WriteString("--- " + trimPartLabel(worksheet.Name, "xl/worksheets/") + " ---\n")
+		lines++
 
 		// Extract text from worksheet
-		sheetText := extractWorksheetText(worksheet, sharedStrings)
+		sheetText, cells := extractWorksheetText(worksheet, sharedStrings)
+		content.Cells = append(content.Cells, shiftCellLines(cells, lines, sheetName)...)
 		allText.WriteString(sheetText)
 		allText.WriteString("\n\n")
+		lines += strings.Count(sheetText, "\n") + 2
+
+		for _, part := range xlsxCommentParts(pkg, worksheet) {
+			seenComments[part.Name] = true
+			for _, c := range part.comments {
+				comments = append(comments, sheetComment{sheetName, c})
+			}
+		}
+	}
+
+	// Comment parts no worksheet points at are still bytes in the file.
+	for _, file := range unionParts(pkg.matching("xl/threadedComments/", ".xml"), pkg.matching("xl/comments", ".xml")) {
+		if !seenComments[file.Name] {
+			for _, c := range readComments(file) {
+				comments = append(comments, sheetComment{"", c})
+			}
+		}
+	}
+
+	if len(comments) > 0 && allText.Len() <= MaxTotalTextBytes {
+		lines += writeHeading(&allText, "COMMENTS")
+		for i, c := range comments {
+			if allText.Len() > MaxTotalTextBytes {
+				break
+			}
+			// A comment is named by its cell, which also locates every line of
+			// it: a finding in a comment on C42 is reported at C42.
+			name := strconv.Itoa(i + 1)
+			col, row, ok := parseCellRef(c.ref)
+			if ok && c.sheet != "" {
+				name = c.sheet + "!" + c.ref
+			}
+			n := writeComment(&allText, &spans, lines, name, c.officeComment)
+			if ok {
+				for l := lines; l < lines+n; l++ {
+					content.Cells = append(content.Cells, CellLine{Line: l, Sheet: c.sheet, Cells: []CellPos{{Row: row, Column: col}}})
+				}
+			}
+			lines += n
+		}
 	}
+	content.Origins = spans
 
 	content.Text = allText.String()
 
@@ -384,11 +524,11 @@ func extractXlsxText(filePath string, content *TextContent) (*TextContent, error
 // extractPptxText extracts text from a PowerPoint presentation
 func extractPptxText(filePath string, content *TextContent) (*TextContent, error) {
 	// Open the pptx file (it's a zip archive)
-	reader, err := zip.OpenReader(filePath)
+	reader, closeArchive, err := openArchive(filePath)
 	if err != nil {
 		return content, fmt.Errorf("error opening file: %v", err)
 	}
-	defer reader.Close()
+	defer closeArchive()
 
 	// Find all presentation content files. Slides come from the presentation's
 	// relationships unioned with the conventional name matched case-insensitively
@@ -422,6 +562,16 @@ func extractPptxText(filePath string, content *TextContent) (*TextContent, error
 
 	// Process slides, notes, and masters
 	var allText strings.Builder
+	var spans originSpans
+	lines := 0
+
+	// Each slide's comments, written after the masters under their own heading.
+	type slideComments struct {
+		name     string
+		comments []officeComment
+	}
+	var comments []slideComments
+	seenComments := make(map[string]bool)
 
 	// Process slides
 	for i, slide := range slides {
@@ -430,6 +580,12 @@ func extractPptxText(filePath string, content *TextContent) (*TextContent, error
 			break
 		}
 		slideNum := i + 1
+		if slideHidden(slide) {
+			spans.mark(lines, OriginHiddenSlide, strconv.Itoa(slideNum))
+		} else {
+			spans.mark(lines, OriginBody, "")
+		}
+		start := allText.Len()
 		allText.WriteString(fmt.Sprintf("--- Slide %d ---\n", slideNum))
 
 		slideText, err := extractTextFromXML(slide, "//a:t")
@@ -451,6 +607,20 @@ func extractPptxText(filePath string, content *TextContent) (*TextContent, error
 			}
 		}
 		allText.WriteString("\n\n")
+		lines += strings.Count(allText.String()[start:], "\n")
+
+		for _, file := range pkg.relatedParts(slide.Name, "comments") {
+			seenComments[file.Name] = true
+			comments = append(comments, slideComments{fmt.Sprintf("slide%d", slideNum), readComments(file)})
+		}
+	}
+	spans.mark(lines, OriginBody, "")
+
+	// Comment parts no slide points at are still bytes in the file.
+	for _, file := range pkg.matching("ppt/comments/", ".xml") {
+		if !seenComments[file.Name] {
+			comments = append(comments, slideComments{trimPartLabel(file.Name, "ppt/comments/"), readComments(file)})
+		}
 	}
 
 	// Process master slides
@@ -467,7 +637,23 @@ func extractPptxText(filePath string, content *TextContent) (*TextContent, error
 		allText.WriteString("\n\n")
 	}
 
+	lines = strings.Count(allText.String(), "\n")
+	wroteComments := false
+	for _, sc := range comments {
+		for _, c := range sc.comments {
+			if allText.Len() > MaxTotalTextBytes {
+				break
+			}
+			if !wroteComments {
+				lines += writeHeading(&allText, "COMMENTS")
+				wroteComments = true
+			}
+			lines += writeComment(&allText, &spans, lines, sc.name, c)
+		}
+	}
+
 	content.Text = allText.String()
+	content.Origins = spans
 	content.PageCount = len(slides)
 
 	// Extract metadata from core.xml if available
@@ -486,11 +672,11 @@ func extractPptxText(filePath string, content *TextContent) (*TextContent, error
 // extractOdtText extracts text from an OpenDocument Text file
 func extractOdtText(filePath string, content *TextContent) (*TextContent, error) {
 	// Open the odt file (it's a zip archive)
-	reader, err := zip.OpenReader(filePath)
+	reader, closeArchive, err := openArchive(filePath)
 	if err != nil {
 		return content, fmt.Errorf("error opening file: %v", err)
 	}
-	defer reader.Close()
+	defer closeArchive()
 
 	// Find content and style files. ODF has no relationship parts, but the entry
 	// names are still producer-controlled, so match them case-insensitively for the
@@ -543,11 +729,11 @@ func extractOdtText(filePath string, content *TextContent) (*TextContent, error)
 // extractOdsText extracts text from an OpenDocument Spreadsheet
 func extractOdsText(filePath string, content *TextContent) (*TextContent, error) {
 	// Open the ods file (it's a zip archive)
-	reader, err := zip.OpenReader(filePath)
+	reader, closeArchive, err := openArchive(filePath)
 	if err != nil {
 		return content, fmt.Errorf("error opening file: %v", err)
 	}
-	defer reader.Close()
+	defer closeArchive()
 
 	// Find the content.xml file which contains the spreadsheet data, matched
 	// case-insensitively like the other container paths (ooxml_parts.go).
@@ -560,13 +746,8 @@ func extractOdsText(filePath string, content *TextContent) (*TextContent, error)
 	}
 	content.BodyParts = 1
 
-	// Extract text from content.xml
-	// For ODS, we need to extract cell values
-	docText, err := extractTextFromXML(contentFile, "//table:table-cell")
-	if err != nil {
-		return content, err
-	}
-	content.Text = docText
+	// Extract the cell values table by table, row by row.
+	content.Text, content.Cells = extractOdsSheets(contentFile)
 
 	// Extract metadata from meta.xml if available
 	if metaFile != nil {
@@ -584,11 +765,11 @@ func extractOdsText(filePath string, content *TextContent) (*TextContent, error)
 // extractOdpText extracts text from an OpenDocument Presentation
 func extractOdpText(filePath string, content *TextContent) (*TextContent, error) {
 	// Open the odp file (it's a zip archive)
-	reader, err := zip.OpenReader(filePath)
+	reader, closeArchive, err := openArchive(filePath)
 	if err != nil {
 		return content, fmt.Errorf("error opening file: %v", err)
 	}
-	defer reader.Close()
+	defer closeArchive()
 
 	// Find content and style files, matched case-insensitively like the other
 	// container paths (ooxml_parts.go).
@@ -665,8 +846,6 @@ func extractTextFromXML(file *zip.File, pattern string) (string, error) {
 		re = regexp.MustCompile(`<a:t[^>]*>(.*?)</a:t>`)
 	} else if pattern == "//text:p" {
 		re = regexp.MustCompile(`<text:p[^>]*>(.*?)</text:p>`)
-	} else if pattern == "//table:table-cell" {
-		re = regexp.MustCompile(`<table:table-cell[^>]*>.*?<text:p[^>]*>(.*?)</text:p>.*?</table:table-cell>`)
 	} else {
 		re = regexp.MustCompile(`<[^>]*>(.*?)</[^>]*>`)
 	}
@@ -782,7 +961,11 @@ var (
 	sharedStringSiRe = regexp.MustCompile(`(?s)<si>(.*?)</si>`)
 	sharedStringTRe  = regexp.MustCompile(`(?s)<t[^>]*>(.*?)</t>`)
 
-	worksheetRowRe = regexp.MustCompile(`(?s)<row[^>]*>(.*?)</row>`)
+	// Rows are matched like cells below: the attribute group may not end in
+	// `/`, so a self-closing `<row r="5"/>` (an empty but formatted row) is not
+	// taken for an opening tag that runs on into the next row and lends it its
+	// row number.
+	worksheetRowRe = regexp.MustCompile(`(?s)<row((?:[^>]*[^/>])?)>(.*?)</row>`)
 
 	// Cells are matched in two stages because Go's RE2 has no lookahead and a
 	// self-closing `<c r="B2"/>` must not be treated as an opening tag. The
@@ -854,38 +1037,52 @@ func decodeXMLEntities(s string) string {
 	})
 }
 
-// extractWorksheetText extracts text from a worksheet
-func extractWorksheetText(file *zip.File, sharedStrings []string) string {
+// extractWorksheetText extracts text from a worksheet, one tab-separated line
+// per row, and locates each value's cell on its line. Lines are counted from the
+// start of the returned text; the caller labels them with the sheet.
+//
+// A value is padded out to its column (see maxColumnPadding), so a row whose
+// leading cells are blank still lines up with the header row.
+func extractWorksheetText(file *zip.File, sharedStrings []string) (string, []CellLine) {
 	if file == nil {
-		return ""
+		return "", nil
 	}
 
 	// Open the worksheet file
 	rc, err := file.Open()
 	if err != nil {
-		return ""
+		return "", nil
 	}
 	defer rc.Close()
 
 	// Read the content
 	content, err := readZipEntryLimited(rc)
 	if err != nil {
-		return ""
+		return "", nil
 	}
 
 	// Extract rows
-	var result strings.Builder
+	var result cellText
 
 	// Find rows
 	rowMatches := worksheetRowRe.FindAllSubmatch(content, -1)
 
+	rowNum, width := 0, 0
 	for _, rowMatch := range rowMatches {
-		if len(rowMatch) < 2 {
+		if len(rowMatch) < 3 {
 			continue
 		}
 
-		var rowText strings.Builder
-		rowContent := rowMatch[1]
+		// Rows and cells carry their own reference; one without (the schema
+		// makes r optional) follows its predecessor.
+		rowNum++
+		if m := rowNumberRe.FindSubmatch(rowMatch[1]); m != nil {
+			if n, err := strconv.Atoi(string(m[1])); err == nil && n > 0 {
+				rowNum = n
+			}
+		}
+		rowContent := rowMatch[2]
+		col, prev, wrote := 0, 0, false
 
 		// Find cells. This is a two-stage match: locate each non-self-closing
 		// <c>…</c> first, then pull the value out of its body. The previous
@@ -911,6 +1108,13 @@ func extractWorksheetText(file *zip.File, sharedStrings []string) string {
 
 			attrs, body := cellMatch[1], cellMatch[2]
 
+			col++
+			if m := cellRefRe.FindSubmatch(attrs); m != nil {
+				if c, _, ok := parseCellRef(string(m[1])); ok {
+					col = c
+				}
+			}
+
 			// Check if it's a shared string. Matched on a word boundary so a
 			// different attribute ending in `t="s"` cannot be mistaken for the
 			// cell type.
@@ -936,8 +1140,10 @@ func extractWorksheetText(file *zip.File, sharedStrings []string) string {
 			}
 
 			if cellText != "" {
-				rowText.WriteString(cellText)
-				rowText.WriteString("\t")
+				result.pad(prev, col)
+				result.cell(CellPos{Row: rowNum, Column: col}, cellText)
+				result.raw("\t")
+				prev, wrote = col, true
 			}
 		}
 
@@ -947,20 +1153,27 @@ func extractWorksheetText(file *zip.File, sharedStrings []string) string {
 				if formMatch[i] != nil {
 					formValue := string(formMatch[i])
 					if formValue != "" {
-						rowText.WriteString("[FORM:" + formValue + "]\t")
+						result.cell(CellPos{Row: rowNum}, "[FORM:"+formValue+"]")
+						result.raw("\t")
+						wrote = true
 					}
 				}
 			}
 		}
 
-		// Add row to result
-		if rowText.Len() > 0 {
-			result.WriteString(rowText.String())
-			result.WriteString("\n")
+		// End the row's line, padded out to the width of the sheet's first row
+		// so a row whose trailing cells are blank still has the header's field
+		// count.
+		if wrote {
+			if width == 0 {
+				width = prev
+			}
+			result.pad(prev, width+1)
+			result.raw("\n")
 		}
 	}
 
-	return result.String()
+	return result.finish()
 }
 
 // numericSuffixRe pulls the trailing integer out of an OOXML part name such as
@@ -1119,21 +1332,22 @@ func extractOdfMeta(file *zip.File, content *TextContent) {
 	}
 }
 
-// extractWordXMLText extracts text from Word XML files (headers/footers)
-func extractWordXMLText(file *zip.File) (string, error) {
+// extractWordXMLText extracts text from Word XML files (headers/footers), and
+// the text of the tracked deletions in them, which it leaves out of the text.
+func extractWordXMLText(file *zip.File) (string, []string, error) {
 	rc, err := file.Open()
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 	defer rc.Close()
 
 	docContent, err := readZipEntryLimited(rc)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
 	// Apply same cleaning as main document
-	cleanedXML := string(docContent)
+	cleanedXML, deletions := takeTrackedDeletions(string(docContent))
 	cleanedXML = regexp.MustCompile(`<w:p[^>]*>|</w:p>`).ReplaceAllString(cleanedXML, "\n")
 
 	// Extract form fields from headers/footers
@@ -1158,7 +1372,7 @@ func extractWordXMLText(file *zip.File) (string, error) {
 	cleanedXML = regexp.MustCompile(`\n\s*\n\s*\n+`).ReplaceAllString(cleanedXML, "\n\n")
 	cleanedXML = strings.TrimSpace(cleanedXML)
 
-	return cleanedXML, nil
+	return cleanedXML, deletions, nil
 }
 
 // countWords counts the number of words in a text
@@ -116,11 +116,15 @@ func resolveTarget(ownerPart, target string) string {
 	return strings.TrimPrefix(path.Join(dir, target), "/")
 }
 
-// relatedParts returns the parts reachable from ownerPart by a relationship whose
-// Type ends in "/"+relType (e.g. "officeDocument", "worksheet", "slide"). Targets
-// that do not resolve to a part in this archive, and external targets, are
-// skipped — a relationship is a claim about the package, not a guarantee.
-func (p *ooxmlPackage) relatedParts(ownerPart, relType string) []*zip.File {
+// relationship is one <Relationship> element of a .rels part.
+type relationship struct {
+	id, relType, target string
+	external            bool
+}
+
+// relationships parses the .rels part describing ownerPart's relationships. A
+// missing or unreadable .rels part yields none.
+func (p *ooxmlPackage) relationships(ownerPart string) []relationship {
 	relsFile := p.lookup(relsPartFor(ownerPart))
 	if relsFile == nil {
 		return nil
@@ -136,32 +140,62 @@ func (p *ooxmlPackage) relatedParts(ownerPart, relType string) []*zip.File {
 		return nil
 	}
 
-	wantSuffix := "/" + strings.ToLower(relType)
-
-	var out []*zip.File
+	var out []relationship
 	for _, elem := range relElemRe.FindAll(data, -1) {
-		var relTypeAttr, target string
-		external := false
+		var rel relationship
 		for _, attr := range relAttrRe.FindAllSubmatch(elem, -1) {
 			switch strings.ToLower(string(attr[1])) {
+			case "id":
+				rel.id = string(attr[2])
 			case "type":
-				relTypeAttr = string(attr[2])
+				rel.relType = string(attr[2])
 			case "target":
-				target = string(attr[2])
+				rel.target = string(attr[2])
 			case "targetmode":
-				external = strings.EqualFold(string(attr[2]), "External")
+				rel.external = strings.EqualFold(string(attr[2]), "External")
 			}
 		}
-		if external || !strings.HasSuffix(strings.ToLower(relTypeAttr), wantSuffix) {
-			continue
-		}
-		if f := p.lookup(resolveTarget(ownerPart, target)); f != nil {
+		out = append(out, rel)
+	}
+	return out
+}
+
+// relatedParts returns the parts reachable from ownerPart by a relationship whose
+// Type ends in "/"+relType (e.g. "officeDocument", "worksheet", "slide"). Targets
+// that do not resolve to a part in this archive, and external targets, are
+// skipped — a relationship is a claim about the package, not a guarantee.
+func (p *ooxmlPackage) relatedParts(ownerPart, relType string) []*zip.File {
+	var out []*zip.File
+	for _, rel := range p.relationships(ownerPart) {
+		if f := p.resolveRelationship(ownerPart, rel, relType); f != nil {
 			out = append(out, f)
 		}
 	}
 	return out
 }
 
+// relatedPartsByID is relatedParts keyed by relationship Id, for an owner part
+// that refers to its targets by Id — a workbook names each sheet's part as
+// r:id="rId3".
+func (p *ooxmlPackage) relatedPartsByID(ownerPart, relType string) map[string]*zip.File {
+	out := make(map[string]*zip.File)
+	for _, rel := range p.relationships(ownerPart) {
+		if f := p.resolveRelationship(ownerPart, rel, relType); f != nil && rel.id != "" {
+			out[rel.id] = f
+		}
+	}
+	return out
+}
+
+// resolveRelationship returns the part rel points at, or nil when rel is
+// external, of another type, or names a part this archive does not hold.
+func (p *ooxmlPackage) resolveRelationship(ownerPart string, rel relationship, relType string) *zip.File {
+	if rel.external || !strings.HasSuffix(strings.ToLower(rel.relType), "/"+strings.ToLower(relType)) {
+		return nil
+	}
+	return p.lookup(resolveTarget(ownerPart, rel.target))
+}
+
 // unionParts concatenates part lists, keeping the first occurrence of each part
 // and preserving the order given. Relationship-resolved parts are passed first so
 // the authoritative ordering leads.
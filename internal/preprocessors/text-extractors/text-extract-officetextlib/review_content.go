@@ -0,0 +1,306 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package textextractofficetextlib
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Review content: comments, tracked deletions, hidden sheets and hidden slides.
+//
+// These are where a value survives after someone believed it was removed. A
+// reviewer writes "use her SSN 449-87-4100" in a comment, an author deletes a
+// paragraph with change tracking on, a sheet of raw data is hidden rather than
+// deleted, a slide is hidden rather than cut. Word comments and every Excel and
+// PowerPoint comment were never read at all; deleted text, hidden sheets and
+// hidden slides were read but reported as if they were ordinary body text, so a
+// finding gave no hint that the value is invisible to anyone opening the file.
+//
+// The extractors therefore write this text under its own heading and record an
+// OriginSpan where each item begins. The preprocessor turns every span into a
+// section with its own source ("memo.docx -> comment:3", "book.xlsx ->
+// hidden-sheet:Raw"), which is how the PDF structure preprocessor labels form
+// fields and annotations.
+
+// Origins of text that is not ordinary document body.
+const (
+	OriginBody            = ""
+	OriginComment         = "comment"
+	OriginTrackedDeletion = "tracked-deletion"
+	OriginHiddenSheet     = "hidden-sheet"
+	OriginHiddenSlide     = "hidden-slide"
+)
+
+// OriginSpan records that the text from line Line (0-based) of Text up to the
+// next span came from Origin, one of the Origin constants. Name identifies the
+// item within its origin: a comment's id or cell, a sheet's name, a slide's
+// number. Text before the first span is document body.
+type OriginSpan struct {
+	Line   int
+	Origin string
+	Name   string
+}
+
+// originSpans accumulates OriginSpans in line order.
+type originSpans []OriginSpan
+
+// mark begins a span at line. A span marked where the previous one begins
+// replaces it, so an item that wrote nothing leaves no empty span behind, and a
+// span that changes nothing (body at the start, or the same item again) is not
+// recorded.
+func (o *originSpans) mark(line int, origin, name string) {
+	spans := *o
+	if n := len(spans); n > 0 && spans[n-1].Line == line {
+		spans = spans[:n-1]
+	}
+	prev := OriginSpan{Origin: OriginBody}
+	if n := len(spans); n > 0 {
+		prev = spans[n-1]
+	}
+	if prev.Origin != origin || prev.Name != name {
+		spans = append(spans, OriginSpan{Line: line, Origin: origin, Name: name})
+	}
+	*o = spans
+}
+
+var (
+	// trackedDeletionRe matches one <w:del> revision with its runs. The
+	// attribute group may not end in "/": a self-closing <w:del .../> marks a
+	// deleted paragraph mark, holds no text, and taken for an opening tag would
+	// swallow everything up to the next deletion.
+	trackedDeletionRe = regexp.MustCompile(`(?s)<w:del\b(?:[^>]*[^/>])?>(.*?)</w:del>`)
+	deletedTextRe     = regexp.MustCompile(`(?s)<w:delText\b[^>]*>(.*?)</w:delText>`)
+)
+
+// takeTrackedDeletions removes the tracked deletions from a Word part's XML and
+// returns the part without them, as Word displays it once the changes are
+// accepted, and the deleted text.
+//
+// The deleted text is joined per paragraph rather than per revision: Word
+// splits a deletion into one revision per run of formatting, so an SSN whose
+// digits were deleted in two formats would otherwise be cut across two lines and
+// found in neither.
+func takeTrackedDeletions(part string) (string, []string) {
+	locs := trackedDeletionRe.FindAllStringSubmatchIndex(part, -1)
+	if len(locs) == 0 {
+		return part, nil
+	}
+	var (
+		kept    strings.Builder
+		deleted []string
+		cur     strings.Builder
+		last    int
+	)
+	flush := func() {
+		if text := strings.TrimSpace(cur.String()); text != "" {
+			deleted = append(deleted, text)
+		}
+		cur.Reset()
+	}
+	for _, loc := range locs {
+		between := part[last:loc[0]]
+		if strings.Contains(between, "</w:p>") {
+			flush()
+		}
+		kept.WriteString(between)
+		for _, t := range deletedTextRe.FindAllStringSubmatch(part[loc[2]:loc[3]], -1) {
+			cur.WriteString(decodeXMLEntities(t[1]))
+		}
+		last = loc[1]
+	}
+	flush()
+	kept.WriteString(part[last:])
+	return kept.String(), deleted
+}
+
+// officeComment is one comment read from a comments part.
+type officeComment struct {
+	id     string // Word's w:id
+	ref    string // the cell an Excel comment is anchored to ("C42")
+	author string
+	text   string
+}
+
+// commentElements are the local names of the element holding one comment, in
+// each format: Word's w:comment and Excel's legacy comment, Excel's threaded
+// comment, and PowerPoint's p:cm and modern p188:cm.
+var commentElements = map[string]bool{"comment": true, "threadedComment": true, "cm": true}
+
+// readComments reads every comment in a comments part, in document order, with
+// paragraphs separated by newlines.
+//
+// One decoder covers every format because they differ only in where the text
+// sits: Word, legacy Excel and modern PowerPoint put it in t elements under
+// paragraphs or runs, Excel's threaded comments and legacy PowerPoint directly
+// in a text element. An author is reported only where the part itself names
+// one, Word's w:author attribute or the author list of a legacy Excel part;
+// resolving the person parts the newer formats point at would add a part read
+// for a name the metadata preprocessor already reports.
+func readComments(file *zip.File) []officeComment {
+	rc, err := file.Open()
+	if err != nil {
+		return nil
+	}
+	defer rc.Close()
+
+	dec := xml.NewDecoder(io.LimitReader(rc, MaxZipEntryBytes))
+	dec.Strict = false
+
+	var (
+		out     []officeComment
+		authors []string
+		cur     *officeComment
+		depth   int // of the current comment element; 0 outside one
+		stack   []string
+		text    strings.Builder
+		size    int
+	)
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		switch el := tok.(type) {
+		case xml.StartElement:
+			stack = append(stack, el.Name.Local)
+			if cur == nil && el.Name.Local == "author" && len(stack) >= 2 && stack[len(stack)-2] == "authors" {
+				authors = append(authors, "")
+			}
+			if cur == nil && commentElements[el.Name.Local] {
+				cur, depth = &officeComment{}, len(stack)
+				for _, a := range el.Attr {
+					switch a.Name.Local {
+					case "id":
+						cur.id = a.Value
+					case "ref":
+						cur.ref = a.Value
+					case "author":
+						cur.author = a.Value
+					case "authorId":
+						if i, err := strconv.Atoi(a.Value); err == nil && i >= 0 && i < len(authors) {
+							cur.author = strings.TrimSpace(authors[i])
+						}
+					}
+				}
+			}
+		case xml.CharData:
+			if len(stack) == 0 {
+				continue
+			}
+			switch inner := stack[len(stack)-1]; {
+			case inner == "author" && cur == nil && len(authors) > 0:
+				authors[len(authors)-1] += string(el)
+			case cur == nil:
+			case inner == "t":
+				text.Write(el)
+			case inner == "text" && strings.TrimSpace(string(el)) != "":
+				text.Write(el)
+			}
+		case xml.EndElement:
+			if cur != nil && el.Name.Local == "p" && text.Len() > 0 && !strings.HasSuffix(text.String(), "\n") {
+				text.WriteByte('\n')
+			}
+			if cur != nil && len(stack) == depth {
+				cur.text = strings.TrimSpace(strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(text.String()))
+				if cur.text != "" && size < MaxTotalTextBytes {
+					out = append(out, *cur)
+					size += len(cur.text)
+				}
+				cur, depth = nil, 0
+				text.Reset()
+			}
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+	return out
+}
+
+// writeComment appends c to b as one span beginning at line, its author first
+// when known, and returns the number of lines written.
+func writeComment(b *strings.Builder, spans *originSpans, line int, name string, c officeComment) int {
+	spans.mark(line, OriginComment, name)
+	var s strings.Builder
+	if c.author != "" {
+		s.WriteString("Author: " + c.author + "\n")
+	}
+	s.WriteString(c.text + "\n")
+	b.WriteString(s.String())
+	return strings.Count(s.String(), "\n")
+}
+
+// slideRootRe isolates a slide part's root element, whose show attribute hides
+// the slide from the slide show.
+var (
+	slideRootRe   = regexp.MustCompile(`<(?:\w+:)?sld\b[^>]*>`)
+	slideHiddenRe = regexp.MustCompile(`\sshow\s*=\s*["'](?:0|false)["']`)
+)
+
+// slideHidden reports whether a slide is hidden. Only the start of the part is
+// read: the root element comes first, and its namespace declarations are the
+// only thing that can make it long.
+func slideHidden(slide *zip.File) bool {
+	rc, err := slide.Open()
+	if err != nil {
+		return false
+	}
+	defer rc.Close()
+	head, _ := io.ReadAll(io.LimitReader(rc, 64<<10))
+	root := slideRootRe.Find(head)
+	return root != nil && slideHiddenRe.Match(root)
+}
+
+// writeHeading starts a block under "--- title ---", separated by a blank line
+// from whatever came before, and returns the number of lines written.
+func writeHeading(b *strings.Builder, title string) int {
+	s := "--- " + title + " ---\n"
+	switch {
+	case b.Len() == 0:
+	case strings.HasSuffix(b.String(), "\n"):
+		s = "\n" + s
+	default:
+		s = "\n\n" + s
+	}
+	b.WriteString(s)
+	return strings.Count(s, "\n")
+}
+
+// commentPart is one comments part and the comments read from it.
+type commentPart struct {
+	*zip.File
+	comments []officeComment
+}
+
+// xlsxCommentParts returns the comment parts a worksheet points at, threaded
+// comments first. Excel writes a legacy comment beside every threaded one, so
+// that older versions show something, holding the same text under a
+// "[Threaded comment]" banner; a legacy comment on a cell that has a threaded
+// one is dropped rather than reported twice.
+func xlsxCommentParts(pkg *ooxmlPackage, worksheet *zip.File) []commentPart {
+	var out []commentPart
+	threaded := make(map[string]bool)
+	for _, file := range pkg.relatedParts(worksheet.Name, "threadedComment") {
+		comments := readComments(file)
+		for _, c := range comments {
+			threaded[c.ref] = true
+		}
+		out = append(out, commentPart{file, comments})
+	}
+	for _, file := range pkg.relatedParts(worksheet.Name, "comments") {
+		var comments []officeComment
+		for _, c := range readComments(file) {
+			if !threaded[c.ref] {
+				comments = append(comments, c)
+			}
+		}
+		out = append(out, commentPart{file, comments})
+	}
+	return out
+}
@@ -0,0 +1,221 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package textextractofficetextlib
+
+import (
+	"strings"
+	"testing"
+)
+
+const relsNS = `http://schemas.openxmlformats.org/officeDocument/2006/relationships/`
+
+// rels builds a .rels part from type/target pairs, numbering the Ids rId1...
+func rels(pairs ...string) string {
+	var b strings.Builder
+	b.WriteString(xmlDecl + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		id := "rId" + string(rune('1'+i/2))
+		b.WriteString(`<Relationship Id="` + id + `" Type="` + pairs[i] + `" Target="` + pairs[i+1] + `"/>`)
+	}
+	b.WriteString(`</Relationships>`)
+	return b.String()
+}
+
+// originOf returns the origin span holding the line of text that contains
+// value, and that line.
+func originOf(t *testing.T, got *TextContent, value string) (OriginSpan, string) {
+	t.Helper()
+	for n, line := range strings.Split(got.Text, "\n") {
+		if !strings.Contains(line, value) {
+			continue
+		}
+		span := OriginSpan{Origin: OriginBody}
+		for _, o := range got.Origins {
+			if o.Line <= n {
+				span = o
+			}
+		}
+		return span, line
+	}
+	t.Fatalf("%q not in the extracted text:\n%s", value, got.Text)
+	return OriginSpan{}, ""
+}
+
+// A Word comment is read into its own span, named by its id and carrying its
+// author, and text deleted with change tracking on leaves the body for a span of
+// its own, joined across the revisions Word split it into.
+func TestDocxCommentsAndTrackedDeletions(t *testing.T) {
+	document := xmlDecl + `<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>` +
+		`<w:p><w:r><w:t>Visible paragraph.</w:t></w:r></w:p>` +
+		`<w:p><w:r><w:t xml:space="preserve">Applicant </w:t></w:r>` +
+		`<w:del w:id="7" w:author="Editor"><w:r><w:delText>SSN 449-87-</w:delText></w:r></w:del>` +
+		`<w:del w:id="8" w:author="Editor"><w:r><w:rPr><w:b/></w:rPr><w:delText>4100</w:delText></w:r></w:del>` +
+		`<w:r><w:t xml:space="preserve"> approved.</w:t></w:r></w:p>` +
+		`<w:p><w:pPr><w:rPr><w:del w:id="9" w:author="Editor"/></w:rPr></w:pPr><w:r><w:t>Card on file.</w:t></w:r></w:p>` +
+		`</w:body></w:document>`
+	comments := xmlDecl + `<w:comments xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">` +
+		`<w:comment w:id="3" w:author="Reviewer"><w:p><w:r><w:t>Use card</w:t></w:r></w:p>` +
+		`<w:p><w:r><w:t>` + testCard + `</w:t></w:r></w:p></w:comment></w:comments>`
+	path := writeZip(t, t.TempDir(), "memo.docx", []part{
+		{"_rels/.rels", pkgRels("word/document.xml")},
+		{"word/document.xml", document},
+		{"word/_rels/document.xml.rels", rels(relsNS+"comments", "comments.xml")},
+		{"word/comments.xml", comments},
+	})
+
+	got, err := ExtractText(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if span, line := originOf(t, got, testCard); span.Origin != OriginComment || span.Name != "3" || line != testCard {
+		t.Errorf("comment value in span %+v on line %q", span, line)
+	}
+	if span, _ := originOf(t, got, "Author: Reviewer"); span.Origin != OriginComment {
+		t.Errorf("comment author in span %+v", span)
+	}
+	if span, line := originOf(t, got, testSSN); span.Origin != OriginTrackedDeletion || line != "SSN "+testSSN {
+		t.Errorf("deleted value in span %+v on line %q", span, line)
+	}
+	if span, line := originOf(t, got, "Applicant"); span.Origin != OriginBody || line != "Applicant approved." {
+		t.Errorf("body reads %q in span %+v, want the deletion accepted", line, span)
+	}
+	// A deleted paragraph mark is self-closing and holds no text: it must not
+	// take the paragraph after it along.
+	if span, _ := originOf(t, got, "Card on file."); span.Origin != OriginBody {
+		t.Errorf("paragraph after a deleted paragraph mark moved to span %+v", span)
+	}
+}
+
+// Hidden and very hidden worksheets are spans of their own, named as the
+// workbook names them, and keep their cell locations. Comments are read from the
+// threaded and legacy parts each sheet points at, named and located by their
+// cell, and the legacy copy Excel writes beside a threaded comment is not
+// reported twice.
+func TestXlsxHiddenSheetsAndComments(t *testing.T) {
+	workbook := xmlDecl + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>` +
+		`<sheet name="Summary" sheetId="1" r:id="rId1"/>` +
+		`<sheet name="Raw" sheetId="2" state="hidden" r:id="rId2"/>` +
+		`<sheet name="Keys" sheetId="3" state="veryHidden" r:id="rId3"/>` +
+		`</sheets></workbook>`
+	threaded := xmlDecl + `<ThreadedComments xmlns="http://schemas.microsoft.com/office/spreadsheetml/2018/threadedcomments">` +
+		`<threadedComment ref="B2" id="{1}" personId="{9}"><text>card ` + testCard + `</text></threadedComment></ThreadedComments>`
+	legacy := xmlDecl + `<comments xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<authors><author>tc={1}</author><author>Pat Jones</author></authors><commentList>` +
+		`<comment ref="B2" authorId="0"><text><t>[Threaded comment] card ` + testCard + `</t></text></comment>` +
+		`<comment ref="A1" authorId="1"><text><r><t>ssn </t></r><r><t>` + testSSN + `</t></r></text></comment>` +
+		`</commentList></comments>`
+	path := writeZip(t, t.TempDir(), "book.xlsx", []part{
+		{"_rels/.rels", pkgRels("xl/workbook.xml")},
+		{"xl/workbook.xml", workbook},
+		{"xl/_rels/workbook.xml.rels", rels(
+			relsNS+"worksheet", "worksheets/sheet1.xml",
+			relsNS+"worksheet", "worksheets/sheet2.xml",
+			relsNS+"worksheet", "worksheets/sheet3.xml")},
+		{"xl/worksheets/sheet1.xml", sheetXML(`<row r="1">` + inline("A1", "totals") + `</row>`)},
+		{"xl/worksheets/_rels/sheet1.xml.rels", rels(
+			"http://schemas.microsoft.com/office/2017/10/relationships/threadedComment", "../threadedComments/threadedComment1.xml",
+			relsNS+"comments", "../comments1.xml")},
+		{"xl/threadedComments/threadedComment1.xml", threaded},
+		{"xl/comments1.xml", legacy},
+		{"xl/worksheets/sheet2.xml", sheetXML(`<row r="4">` + inline("C4", "raw "+testSSN) + `</row>`)},
+		{"xl/worksheets/sheet3.xml", sheetXML(`<row r="1">` + inline("A1", "key value") + `</row>`)},
+	})
+
+	got, err := ExtractText(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if span, _ := originOf(t, got, "totals"); span.Origin != OriginBody {
+		t.Errorf("visible sheet in span %+v", span)
+	}
+	if span, _ := originOf(t, got, "raw "+testSSN); span.Origin != OriginHiddenSheet || span.Name != "Raw" {
+		t.Errorf("hidden sheet in span %+v", span)
+	}
+	if sheet, row, col := locate(t, got.Text, got.Cells, "raw "+testSSN); sheet != "Raw" || row != 4 || col != 3 {
+		t.Errorf("hidden sheet value located at %q row %d column %d", sheet, row, col)
+	}
+	if span, _ := originOf(t, got, "key value"); span.Origin != OriginHiddenSheet || span.Name != "Keys" {
+		t.Errorf("very hidden sheet in span %+v", span)
+	}
+
+	if n := strings.Count(got.Text, testCard); n != 1 {
+		t.Errorf("threaded comment written %d times, want once:\n%s", n, got.Text)
+	}
+	if span, _ := originOf(t, got, "card "+testCard); span.Origin != OriginComment || span.Name != "Summary!B2" {
+		t.Errorf("threaded comment in span %+v", span)
+	}
+	if sheet, row, col := locate(t, got.Text, got.Cells, testCard); sheet != "Summary" || row != 2 || col != 2 {
+		t.Errorf("comment located at %q row %d column %d, want Summary B2", sheet, row, col)
+	}
+	if !strings.Contains(got.Text, "Author: Pat Jones\nssn "+testSSN+"\n") {
+		t.Errorf("legacy comment or its author missing:\n%s", got.Text)
+	}
+}
+
+// A slide hidden from the show is a span of its own, with its notes, and slide
+// comments, legacy and modern, are read and named by their slide.
+func TestPptxHiddenSlidesAndComments(t *testing.T) {
+	slide := func(show, text string) string {
+		return xmlDecl + `<p:sld xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" ` +
+			`xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main"` + show + `><p:cSld><p:spTree><p:sp><p:txBody>` +
+			`<a:p><a:r><a:t>` + text + `</a:t></a:r></a:p></p:txBody></p:sp></p:spTree></p:cSld></p:sld>`
+	}
+	legacy := xmlDecl + `<p:cmLst xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main">` +
+		`<p:cm authorId="0" idx="1"><p:pos x="10" y="10"/><p:text>call ` + testSSN + `</p:text></p:cm></p:cmLst>`
+	modern := xmlDecl + `<p188:cmLst xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" ` +
+		`xmlns:p188="http://schemas.microsoft.com/office/powerpoint/2018/8/main">` +
+		`<p188:cm id="{1}" authorId="{2}"><p188:txBody><a:p><a:r><a:t>card ` + testCard + `</a:t></a:r></a:p></p188:txBody></p188:cm></p188:cmLst>`
+	path := writeZip(t, t.TempDir(), "deck.pptx", []part{
+		{"_rels/.rels", pkgRels("ppt/presentation.xml")},
+		{"ppt/presentation.xml", xmlDecl + `<p:presentation xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main"/>`},
+		{"ppt/_rels/presentation.xml.rels", rels(relsNS+"slide", "slides/slide1.xml", relsNS+"slide", "slides/slide2.xml")},
+		{"ppt/slides/slide1.xml", slide("", "Agenda")},
+		{"ppt/slides/slide2.xml", slide(` show="0"`, "Backup numbers")},
+		{"ppt/slides/_rels/slide2.xml.rels", rels(
+			relsNS+"comments", "../comments/comment1.xml",
+			"http://schemas.microsoft.com/office/2018/10/relationships/comments", "../comments/modernComment_1.xml")},
+		{"ppt/comments/comment1.xml", legacy},
+		{"ppt/comments/modernComment_1.xml", modern},
+	})
+
+	got, err := ExtractText(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if span, _ := originOf(t, got, "Agenda"); span.Origin != OriginBody {
+		t.Errorf("visible slide in span %+v", span)
+	}
+	if span, _ := originOf(t, got, "Backup numbers"); span.Origin != OriginHiddenSlide || span.Name != "2" {
+		t.Errorf("hidden slide in span %+v", span)
+	}
+	for _, value := range []string{"call " + testSSN, "card " + testCard} {
+		if span, _ := originOf(t, got, value); span.Origin != OriginComment || span.Name != "slide2" {
+			t.Errorf("%q in span %+v", value, span)
+		}
+	}
+}
+
+func TestOriginSpansMark(t *testing.T) {
+	var spans originSpans
+	spans.mark(0, OriginBody, "")         // body at the start is implicit
+	spans.mark(3, OriginHiddenSheet, "A") // replaced: nothing was written
+	spans.mark(3, OriginHiddenSheet, "B")
+	spans.mark(5, OriginHiddenSheet, "B") // the same item again
+	spans.mark(7, OriginBody, "")
+	spans.mark(9, OriginComment, "1")
+	spans.mark(10, OriginComment, "1")
+	want := []OriginSpan{{3, OriginHiddenSheet, "B"}, {7, OriginBody, ""}, {9, OriginComment, "1"}}
+	if len(spans) != len(want) {
+		t.Fatalf("spans = %+v, want %+v", spans, want)
+	}
+	for i := range want {
+		if spans[i] != want[i] {
+			t.Errorf("spans = %+v, want %+v", spans, want)
+			break
+		}
+	}
+}
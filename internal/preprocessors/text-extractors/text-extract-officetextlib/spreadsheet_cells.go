@@ -0,0 +1,353 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package textextractofficetextlib
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Cell positions for spreadsheets.
+//
+// A spreadsheet is flattened into tab-separated lines, one per row, so a finding
+// used to be reported as "line 1204" of text nobody but the scanner ever saw. The
+// extractors therefore record, for every line they write, which sheet it belongs
+// to and which cell begins at which byte of it; the preprocessor hands that to
+// the router and a finding's line and column are turned back into "Sheet1!C42".
+//
+// Offsets rather than field indexes, because a cell value can itself hold a tab
+// or a newline: counting tabs would attribute everything after such a value to
+// the wrong column, while an offset recorded as the value is written cannot
+// drift.
+
+// CellLine locates the spreadsheet cells on one line of Text. Line is 0-based.
+// Cells is in ascending Offset order; a line that continues a multi-line cell
+// value starts with that cell at Offset 0.
+type CellLine struct {
+	Line  int
+	Sheet string
+	Cells []CellPos
+}
+
+// CellPos records that the cell at Row and Column (both 1-based) begins at byte
+// Offset of its line. Column 0 marks text that belongs to no cell, such as an
+// Excel form control's value appended to its row, so the cell before it does
+// not claim it.
+type CellPos struct {
+	Offset int
+	Row    int
+	Column int
+}
+
+// maxColumnPadding bounds the empty fields written for the blank cells before a
+// value. Padding is what keeps a value under its header when the cells to its
+// left are empty, but a hostile sheet can place every value in column XFD, and
+// 16,383 tabs per twenty-byte cell would multiply the worksheet a thousandfold.
+// Past the bound one empty field stands for the gap: columns further right
+// stop lining up with the header, and the recorded offsets still locate every
+// cell exactly.
+const maxColumnPadding = 256
+
+// cellText accumulates a sheet's text and the cell positions on each line.
+type cellText struct {
+	b         strings.Builder
+	lineStart int    // b.Len() where the current line began
+	sheet     string // labels each line as it is started
+	cur       CellLine
+	lines     []CellLine
+}
+
+// cell records pos at the current offset and writes the value. Each newline in
+// the value starts a line attributed, from its first byte, to the same cell.
+func (t *cellText) cell(pos CellPos, value string) {
+	pos.Offset = t.b.Len() - t.lineStart
+	t.cur.Cells = append(t.cur.Cells, pos)
+	for {
+		i := strings.IndexByte(value, '\n')
+		if i < 0 {
+			t.b.WriteString(value)
+			return
+		}
+		t.b.WriteString(value[:i+1])
+		t.newline()
+		pos.Offset = 0
+		t.cur.Cells = append(t.cur.Cells, pos)
+		value = value[i+1:]
+	}
+}
+
+// raw writes text that carries no cell of its own: separators, padding, row
+// ends. Only tabs and newlines are ever written this way.
+func (t *cellText) raw(s string) {
+	for _, c := range []byte(s) {
+		t.b.WriteByte(c)
+		if c == '\n' {
+			t.newline()
+		}
+	}
+}
+
+func (t *cellText) newline() {
+	if len(t.cur.Cells) > 0 {
+		t.lines = append(t.lines, t.cur)
+	}
+	t.cur = CellLine{Line: t.cur.Line + 1, Sheet: t.sheet}
+	t.lineStart = t.b.Len()
+}
+
+// finish returns the text and the located lines.
+func (t *cellText) finish() (string, []CellLine) {
+	if len(t.cur.Cells) > 0 {
+		t.lines = append(t.lines, t.cur)
+		t.cur.Cells = nil
+	}
+	return t.b.String(), t.lines
+}
+
+// pad writes the empty fields for the blank cells between column prev and
+// column next, bounded by maxColumnPadding.
+func (t *cellText) pad(prev, next int) {
+	gap := next - prev - 1
+	if gap > maxColumnPadding {
+		gap = 1
+	}
+	if gap > 0 {
+		t.raw(strings.Repeat("\t", gap))
+	}
+}
+
+// shiftCellLines moves lines located in a text of their own to where that text
+// begins in the document (startLine lines in), labelling them with the sheet.
+func shiftCellLines(lines []CellLine, startLine int, sheet string) []CellLine {
+	for i := range lines {
+		lines[i].Line += startLine
+		lines[i].Sheet = sheet
+	}
+	return lines
+}
+
+// parseCellRef reads an A1-style reference ("C42") into its 1-based column and
+// row. ok is false for anything else, including a reference past Excel's
+// column XFD, which is not a cell any producer can have written.
+func parseCellRef(ref string) (column, row int, ok bool) {
+	i := 0
+	for i < len(ref) && i < 3 && ref[i] >= 'A' && ref[i] <= 'Z' {
+		column = column*26 + int(ref[i]-'A'+1)
+		i++
+	}
+	if i == 0 || column > 16384 {
+		return 0, 0, false
+	}
+	row, err := strconv.Atoi(ref[i:])
+	if err != nil || row < 1 {
+		return 0, 0, false
+	}
+	return column, row, true
+}
+
+var (
+	// workbookSheetRe isolates each <sheet .../> of workbook.xml; relAttrRe then
+	// reads its name, r:id and state in any order.
+	workbookSheetRe = regexp.MustCompile(`<sheet\b[^>]*>`)
+
+	rowNumberRe = regexp.MustCompile(`\br="(\d+)"`)
+	cellRefRe   = regexp.MustCompile(`\br="([A-Z]{1,3}\d+)"`)
+)
+
+// workbookSheet is what a workbook says about one of its worksheets.
+type workbookSheet struct {
+	name   string // the tab label a user sees
+	hidden bool   // state="hidden" or "veryHidden"
+}
+
+// workbookSheets maps each worksheet part to what its workbook says about it,
+// through the workbook's r:id relationships. A part the workbook does not name
+// is absent, and the caller falls back to the part's own label.
+//
+// A very hidden sheet is hidden the same way as a hidden one as far as a reader
+// of the file is concerned; the difference is only that Excel's Unhide dialog
+// does not list it.
+func workbookSheets(pkg *ooxmlPackage, workbook *zip.File) map[string]workbookSheet {
+	sheets := make(map[string]workbookSheet)
+	if workbook == nil {
+		return sheets
+	}
+	byID := pkg.relatedPartsByID(workbook.Name, "worksheet")
+	if len(byID) == 0 {
+		return sheets
+	}
+	rc, err := workbook.Open()
+	if err != nil {
+		return sheets
+	}
+	data, err := readZipEntryLimited(rc)
+	rc.Close()
+	if err != nil {
+		return sheets
+	}
+	for _, elem := range workbookSheetRe.FindAll(data, -1) {
+		var sheet workbookSheet
+		var id string
+		for _, attr := range relAttrRe.FindAllSubmatch(elem, -1) {
+			switch strings.ToLower(string(attr[1])) {
+			case "name":
+				sheet.name = decodeXMLEntities(string(attr[2]))
+			case "r:id":
+				id = string(attr[2])
+			case "state":
+				state := string(attr[2])
+				sheet.hidden = state == "hidden" || state == "veryHidden"
+			}
+		}
+		if part := byID[id]; part != nil && sheet.name != "" {
+			if _, seen := sheets[part.Name]; !seen {
+				sheets[part.Name] = sheet
+			}
+		}
+	}
+	return sheets
+}
+
+// extractOdsSheets walks an OpenDocument spreadsheet's content.xml table by
+// table, writing each as the XLSX extractor writes a worksheet: a "--- name ---"
+// separator, then one tab-separated line per row, with every cell located.
+//
+// The previous extraction matched each cell's first paragraph with a regular
+// expression and joined every value in the document with a space, so a whole
+// workbook became one line and no value could be placed in a row, a column or
+// even a sheet. ODF also compresses runs of identical rows and cells into one
+// element with a repeat count (a sheet ends in a row repeated a million times),
+// so positions can only be counted by honouring those counts. A repeated
+// element that carries a value is written once, at its first position: the
+// repeat multiplies the value, not the information in it.
+//
+// A malformed document yields what was read before the error, like the other
+// extractors here.
+func extractOdsSheets(file *zip.File) (string, []CellLine) {
+	rc, err := file.Open()
+	if err != nil {
+		return "", nil
+	}
+	defer rc.Close()
+
+	dec := xml.NewDecoder(io.LimitReader(rc, MaxZipEntryBytes))
+	dec.Strict = false
+
+	var (
+		t      cellText
+		row    int // 1-based row of the current table-row
+		col    int // 1-based column of the current cell
+		prev   int // column of the last value written on this row
+		width  int // last column of the table's first written row
+		wrote  bool
+		inCell bool
+		inPara bool
+		paras  int // paragraphs seen in the current cell
+		value  strings.Builder
+		rowRep int
+		colRep int
+	)
+	flushCell := func() {
+		if v := value.String(); strings.TrimSpace(v) != "" && t.b.Len() < MaxTotalTextBytes {
+			t.pad(prev, col)
+			t.cell(CellPos{Row: row, Column: col}, v)
+			t.raw("\t")
+			prev, wrote = col, true
+		}
+		value.Reset()
+	}
+
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		switch el := tok.(type) {
+		case xml.StartElement:
+			switch el.Name.Local {
+			case "table":
+				if t.b.Len() > 0 {
+					t.raw("\n")
+				}
+				t.sheet = odfAttr(el, "name")
+				t.raw("--- " + t.sheet + " ---\n")
+				row, width = 0, 0
+			case "table-row":
+				rowRep = odfRepeat(el, "number-rows-repeated")
+				row++
+				col, prev, wrote = 0, 0, false
+			case "table-cell", "covered-table-cell":
+				colRep = odfRepeat(el, "number-columns-repeated")
+				col++
+				inCell, paras = true, 0
+			case "p", "h":
+				if inCell {
+					if paras > 0 {
+						value.WriteByte('\n')
+					}
+					paras++
+					inPara = true
+				}
+			case "s":
+				if inCell {
+					value.WriteString(strings.Repeat(" ", min(odfRepeat(el, "c"), maxColumnPadding)))
+				}
+			case "tab":
+				if inCell {
+					value.WriteByte('\t')
+				}
+			case "line-break":
+				if inCell {
+					value.WriteByte('\n')
+				}
+			}
+		case xml.CharData:
+			if inCell && inPara {
+				value.Write(el)
+			}
+		case xml.EndElement:
+			switch el.Name.Local {
+			case "p", "h":
+				inPara = false
+			case "table-cell", "covered-table-cell":
+				flushCell()
+				inCell = false
+				col += colRep - 1
+			case "table-row":
+				if wrote {
+					if width == 0 {
+						width = prev
+					}
+					t.pad(prev, width+1)
+					t.raw("\n")
+				}
+				row += rowRep - 1
+			}
+		}
+	}
+	return t.finish()
+}
+
+// odfAttr returns the value of el's attribute with the given local name.
+func odfAttr(el xml.StartElement, local string) string {
+	for _, a := range el.Attr {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// odfRepeat returns a repeat count attribute, 1 when absent or unusable.
+func odfRepeat(el xml.StartElement, local string) int {
+	n, err := strconv.Atoi(odfAttr(el, local))
+	if err != nil || n < 1 {
+		return 1
+	}
+	return n
+}
@@ -0,0 +1,220 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package textextractofficetextlib
+
+import (
+	"strings"
+	"testing"
+)
+
+// locate finds value in text and returns the sheet, row and column the
+// extractor recorded for it, the way the router resolves a finding.
+func locate(t *testing.T, text string, cells []CellLine, value string) (string, int, int) {
+	t.Helper()
+	for n, line := range strings.Split(text, "\n") {
+		off := strings.Index(line, value)
+		if off < 0 {
+			continue
+		}
+		for _, cl := range cells {
+			if cl.Line != n {
+				continue
+			}
+			var hit *CellPos
+			for i := range cl.Cells {
+				if cl.Cells[i].Offset <= off {
+					hit = &cl.Cells[i]
+				}
+			}
+			if hit == nil {
+				t.Fatalf("no cell starts at or before %q on line %d", value, n)
+			}
+			return cl.Sheet, hit.Row, hit.Column
+		}
+		t.Fatalf("line %d holding %q has no recorded cells", n, value)
+	}
+	t.Fatalf("%q not in the extracted text:\n%s", value, text)
+	return "", 0, 0
+}
+
+func sheetXML(rows string) string {
+	return xmlDecl + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` +
+		rows + `</sheetData></worksheet>`
+}
+
+func inline(ref, value string) string {
+	return `<c r="` + ref + `" t="inlineStr"><is><t>` + value + `</t></is></c>`
+}
+
+// The location names the sheet as the workbook does, and the row and column
+// come from the cell references rather than from counting what was written:
+// rows 2-41 are absent and B42 is blank.
+func TestXlsxCellsCarryWorkbookSheetNameAndReference(t *testing.T) {
+	workbook := xmlDecl + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Summary" sheetId="1" r:id="rId1"/><sheet r:id="rId2" name="Payroll &amp; Tax"/></sheets></workbook>`
+	wbRels := xmlDecl + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet2.xml"/>` +
+		`</Relationships>`
+	path := writeZip(t, t.TempDir(), "payroll.xlsx", []part{
+		{"_rels/.rels", pkgRels("xl/workbook.xml")},
+		{"xl/workbook.xml", workbook},
+		{"xl/_rels/workbook.xml.rels", wbRels},
+		{"xl/worksheets/sheet1.xml", sheetXML(`<row r="1">` + inline("A1", "quarterly totals") + `</row>`)},
+		{"xl/worksheets/sheet2.xml", sheetXML(
+			`<row r="1">` + inline("A1", "name") + inline("B1", "dept") + inline("C1", "ssn") + `</row>` +
+				`<row r="42">` + inline("A42", "Jane") + `<c r="B42" s="1"/>` + inline("C42", testSSN) + `</row>`)},
+	})
+
+	got, err := ExtractText(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sheet, row, col := locate(t, got.Text, got.Cells, testSSN)
+	if sheet != "Payroll & Tax" || row != 42 || col != 3 {
+		t.Errorf("SSN located at %q row %d column %d, want \"Payroll & Tax\" row 42 column 3", sheet, row, col)
+	}
+	if sheet, row, col := locate(t, got.Text, got.Cells, "quarterly totals"); sheet != "Summary" || row != 1 || col != 1 {
+		t.Errorf("first sheet's value located at %q row %d column %d", sheet, row, col)
+	}
+
+	// The blank B42 is written as an empty field, so the SSN stays under its
+	// header.
+	if !strings.Contains(got.Text, "name\tdept\tssn\t\nJane\t\t"+testSSN+"\t\n") {
+		t.Errorf("data row does not line up with the header row:\n%q", got.Text)
+	}
+}
+
+// A value with line breaks spans lines of the text; every one of them is the
+// same cell, and the cell after it is still located by its own reference.
+func TestXlsxMultilineCellKeepsItsLocationOnEveryLine(t *testing.T) {
+	text, cells := extractWorksheetText(zipEntry(t, "xl/worksheets/sheet1.xml", sheetXML(
+		`<row r="3">`+inline("B3", "address line\nSSN "+testSSN)+inline("D3", "Card "+testCard)+`</row>`)), nil)
+
+	if _, row, col := locate(t, text, cells, testSSN); row != 3 || col != 2 {
+		t.Errorf("continuation line located at row %d column %d, want B3", row, col)
+	}
+	if _, row, col := locate(t, text, cells, testCard); row != 3 || col != 4 {
+		t.Errorf("following cell located at row %d column %d, want D3", row, col)
+	}
+}
+
+// An empty, formatted row is written self-closing. Taken for an opening tag it
+// ran on into the next row, which was then reported under the empty row's
+// number.
+func TestXlsxSelfClosingRowDoesNotLendItsNumber(t *testing.T) {
+	text, cells := extractWorksheetText(zipEntry(t, "xl/worksheets/sheet1.xml", sheetXML(
+		`<row r="5" spans="1:3" ht="20" customHeight="1"/>`+
+			`<row r="6">`+inline("A6", testSSN)+`</row>`)), nil)
+
+	if _, row, _ := locate(t, text, cells, testSSN); row != 6 {
+		t.Errorf("value located in row %d, want 6", row)
+	}
+}
+
+// A form control's value has no cell of its own and must not be attributed to
+// the cell written before it.
+func TestXlsxFormControlIsNotAttributedToACell(t *testing.T) {
+	text, cells := extractWorksheetText(zipEntry(t, "xl/worksheets/sheet1.xml", sheetXML(
+		`<row r="1">`+inline("A1", "label")+`<formControlPr defaultValue="`+testSSN+`"/></row>`)), nil)
+
+	if _, _, col := locate(t, text, cells, testSSN); col != 0 {
+		t.Errorf("form control value attributed to column %d", col)
+	}
+}
+
+// A value far to the right is padded by at most maxColumnPadding fields, so a
+// sheet of values in column XFD cannot multiply into gigabytes of tabs; it is
+// still located in its real column.
+func TestXlsxColumnPaddingIsBounded(t *testing.T) {
+	text, cells := extractWorksheetText(zipEntry(t, "xl/worksheets/sheet1.xml", sheetXML(
+		`<row r="1">`+inline("XFD1", testSSN)+`</row>`)), nil)
+
+	if len(text) > maxColumnPadding+len(testSSN)+2 {
+		t.Errorf("extracted %d bytes for a single value", len(text))
+	}
+	if _, _, col := locate(t, text, cells, testSSN); col != 16384 {
+		t.Errorf("value located in column %d, want 16384 (XFD)", col)
+	}
+}
+
+func TestParseCellRef(t *testing.T) {
+	for ref, want := range map[string][2]int{
+		"A1":       {1, 1},
+		"C42":      {3, 42},
+		"Z9":       {26, 9},
+		"AA10":     {27, 10},
+		"XFD13":    {16384, 13},
+		"XFE1":     {0, 0},
+		"A0":       {0, 0},
+		"42":       {0, 0},
+		"AAAA1":    {0, 0},
+		"a1":       {0, 0},
+		"B":        {0, 0},
+		"A1048576": {1, 1048576},
+	} {
+		col, row, ok := parseCellRef(ref)
+		if ok != (want[0] != 0) || col != want[0] || row != want[1] {
+			t.Errorf("parseCellRef(%q) = (%d, %d, %v), want (%d, %d)", ref, col, row, ok, want[0], want[1])
+		}
+	}
+}
+
+// An OpenDocument spreadsheet is written table by table and row by row, with
+// repeated rows and cells counted — a blank run of rows is one element with a
+// repeat count, and the rows after it must not be numbered as if it were one.
+func TestOdsCellsHonourRepeatCounts(t *testing.T) {
+	content := `<?xml version="1.0" encoding="UTF-8"?>` +
+		`<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" ` +
+		`xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0" ` +
+		`xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0"><office:body><office:spreadsheet>` +
+		`<table:table table:name="Contacts">` +
+		`<table:table-row><table:table-cell><text:p>name</text:p></table:table-cell>` +
+		`<table:table-cell><text:p>email</text:p></table:table-cell>` +
+		`<table:table-cell><text:p>ssn</text:p></table:table-cell></table:table-row>` +
+		`<table:table-row table:number-rows-repeated="8"><table:table-cell table:number-columns-repeated="3"/></table:table-row>` +
+		`<table:table-row><table:table-cell><text:p>Jane</text:p></table:table-cell>` +
+		`<table:table-cell table:number-columns-repeated="1"/>` +
+		`<table:table-cell><text:p>SSN <text:span>` + testSSN + `</text:span></text:p></table:table-cell></table:table-row>` +
+		`</table:table>` +
+		`<table:table table:name="Cards">` +
+		`<table:table-row><table:table-cell table:number-columns-repeated="4"/>` +
+		`<table:table-cell><text:p>first line</text:p><text:p>Card ` + testCard + `</text:p></table:table-cell></table:table-row>` +
+		`<table:table-row table:number-rows-repeated="1048570"><table:table-cell table:number-columns-repeated="1024"/></table:table-row>` +
+		`</table:table></office:spreadsheet></office:body></office:document-content>`
+	path := writeZip(t, t.TempDir(), "contacts.ods", []part{
+		{"mimetype", "application/vnd.oasis.opendocument.spreadsheet"},
+		{"content.xml", content},
+	})
+
+	got, err := ExtractText(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		value    string
+		sheet    string
+		row, col int
+	}{
+		{testSSN, "Contacts", 10, 3},
+		{"Jane", "Contacts", 10, 1},
+		{testCard, "Cards", 1, 5},
+	} {
+		sheet, row, col := locate(t, got.Text, got.Cells, c.value)
+		if sheet != c.sheet || row != c.row || col != c.col {
+			t.Errorf("%q located at %q row %d column %d, want %q row %d column %d",
+				c.value, sheet, row, col, c.sheet, c.row, c.col)
+		}
+	}
+	if !strings.Contains(got.Text, "name\temail\tssn\t\nJane\t\tSSN "+testSSN) {
+		t.Errorf("rows are not written one per line under their header:\n%q", got.Text)
+	}
+	if len(got.Text) > 4096 {
+		t.Errorf("a million repeated blank rows produced %d bytes of text", len(got.Text))
+	}
+	if n := strings.Count(got.Text, "\n--- ") + 1; !strings.HasPrefix(got.Text, "--- Contacts ---") || n != 2 {
+		t.Errorf("got %d sheet separators, want Contacts then Cards:\n%s", n, got.Text)
+	}
+}
@@ -99,7 +99,7 @@ func TestExtractWorksheet_SelfClosingCellDoesNotSwallowNeighbour(t *testing.T) {
 		`<row r="3"><c r="A3" t="s"><v>2</v></c></row>` +
 		`</sheetData></worksheet>`
 
-	got := extractWorksheetText(zipEntry(t, "xl/worksheets/sheet1.xml", sheet), shared)
+	got, _ := extractWorksheetText(zipEntry(t, "xl/worksheets/sheet1.xml", sheet), shared)
 
 	for _, want := range shared {
 		if !strings.Contains(got, want) {
@@ -125,7 +125,7 @@ func TestExtractWorksheet_SelfClosingValueDoesNotSwallowNeighbour(t *testing.T)
 		`<row r="1"><c r="A1" t="s"><v/></c><c r="B1" t="s"><v>0</v></c></row>` +
 		`</sheetData></worksheet>`
 
-	got := extractWorksheetText(zipEntry(t, "xl/worksheets/sheet1.xml", sheet), []string{"real value"})
+	got, _ := extractWorksheetText(zipEntry(t, "xl/worksheets/sheet1.xml", sheet), []string{"real value"})
 
 	if !strings.Contains(got, "real value") {
 		t.Errorf("cell after self-closing <v/> was lost; got %q", got)
@@ -149,7 +149,7 @@ func TestExtractWorksheet_AttributedAndInlineText(t *testing.T) {
 		`<row r="2"><c r="A2" t="str"><t xml:space="preserve"> trailing space value </t></c></row>` +
 		`</sheetData></worksheet>`
 
-	got := extractWorksheetText(zipEntry(t, "xl/worksheets/sheet1.xml", sheet), nil)
+	got, _ := extractWorksheetText(zipEntry(t, "xl/worksheets/sheet1.xml", sheet), nil)
 
 	for _, want := range []string{"Inline phone 415-555-0199", "trailing space value"} {
 		if !strings.Contains(got, want) {
@@ -168,7 +168,7 @@ func TestExtractWorksheet_MultilineInlineString(t *testing.T) {
 		"<row r=\"1\"><c r=\"A1\" t=\"inlineStr\"><is><t>line one\nSSN 536-22-8765\nline three</t></is></c></row>" +
 		`</sheetData></worksheet>`
 
-	got := extractWorksheetText(zipEntry(t, "xl/worksheets/sheet1.xml", sheet), nil)
+	got, _ := extractWorksheetText(zipEntry(t, "xl/worksheets/sheet1.xml", sheet), nil)
 
 	if !strings.Contains(got, "536-22-8765") {
 		t.Errorf("multi-line inline string lost its content; got:\n%s", got)
@@ -216,7 +216,7 @@ func TestExtractWorksheet_SharedStringIndexOutOfRange(t *testing.T) {
 		`<row r="1"><c r="A1" t="s"><v>99</v></c><c r="B1" t="s"><v>0</v></c><c r="C1" t="s"><v>-3</v></c></row>` +
 		`</sheetData></worksheet>`
 
-	got := extractWorksheetText(zipEntry(t, "xl/worksheets/sheet1.xml", sheet), []string{"only entry"})
+	got, _ := extractWorksheetText(zipEntry(t, "xl/worksheets/sheet1.xml", sheet), []string{"only entry"})
 
 	if !strings.Contains(got, "only entry") {
 		t.Errorf("valid shared-string reference lost; got %q", got)
@@ -235,7 +235,7 @@ func TestExtractWorksheet_NumericCellsAreNotSharedStrings(t *testing.T) {
 		`<row r="1"><c r="A1"><v>4532015112830366</v></c><c r="B1" t="n"><v>42</v></c></row>` +
 		`</sheetData></worksheet>`
 
-	got := extractWorksheetText(zipEntry(t, "xl/worksheets/sheet1.xml", sheet), []string{"decoy", "another decoy"})
+	got, _ := extractWorksheetText(zipEntry(t, "xl/worksheets/sheet1.xml", sheet), []string{"decoy", "another decoy"})
 
 	for _, want := range []string{"4532015112830366", "42"} {
 		if !strings.Contains(got, want) {
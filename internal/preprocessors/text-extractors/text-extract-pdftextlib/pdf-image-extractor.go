@@ -0,0 +1,135 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package textextractpdftextlib
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/awslabs/ferret-scan/v2/internal/embedded"
+	"github.com/awslabs/ferret-scan/v2/internal/encryption"
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+// MaxPageImages bounds the image objects read from one document. A scanned
+// document has one per page; a document past this is a picture book, and every
+// further image costs a decode.
+const MaxPageImages = 1000
+
+// PageImage is one image drawn on a page, as a file an image decoder reads.
+type PageImage struct {
+	// Page is the 1-based page the image is first drawn on.
+	Page int
+	// Data is a PNG, JPEG or TIFF file.
+	Data []byte
+}
+
+// ExtractPageImages calls visit for each image drawn on the pages of a PDF, in
+// page order. An image drawn on several pages (a letterhead logo) is visited
+// once, on the first.
+//
+// ledongthuc/pdf, which the rest of this package reads with, cannot decode a
+// DCTDecode stream, and a scanned page is almost always one, so the images are
+// read with pdfcpu. An image whose declared width x height is past maxPixels is
+// not decoded at all: decoding it would cost memory set by a number in the
+// file. Images skipped for that reason, or past MaxPageImages or the
+// per-document byte budget, are DISCLOSED through the returned notes.
+//
+// An image in a format no Go decoder reads (JPXDecode, JBIG2Decode) is passed
+// over without a note: it is a picture the scan cannot see, like any other.
+func ExtractPageImages(filePath string, maxPixels int64, visit func(PageImage)) (notes []string, err error) {
+	// pdfcpu reports most failures as errors, but it is a large parser fed
+	// untrusted bytes.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("PDF library panic on %s: %v", filepath.Base(filePath), r)
+		}
+	}()
+
+	data, ok := encryption.Plaintext(filePath)
+	if !ok {
+		data, err = os.ReadFile(filePath) // #nosec G304 -- path vetted by the router
+		if err != nil {
+			return nil, err
+		}
+	}
+	conf := model.NewDefaultConfiguration()
+	conf.Cmd = model.EXTRACTIMAGES
+	// Read without a validation pass, for the reason internal/encryption gives:
+	// the extractors read documents the specification would reject.
+	ctx, err := api.ReadContext(bytes.NewReader(data), conf)
+	if err != nil {
+		return nil, fmt.Errorf("error opening PDF: %v", err)
+	}
+	// Validation is what counts the pages, so they are counted here instead.
+	if err := ctx.EnsurePageCount(); err != nil {
+		return nil, fmt.Errorf("error reading PDF pages: %v", err)
+	}
+	if err := api.OptimizeContext(ctx); err != nil {
+		return nil, fmt.Errorf("error reading PDF images: %v", err)
+	}
+
+	seen := make(map[int]bool)
+	var count, oversize int
+	var decoded int64
+	cut, budgetReached := false, false
+pages:
+	for page := 1; page <= ctx.PageCount; page++ {
+		for _, objNr := range pdfcpu.ImageObjNrs(ctx, page) {
+			if seen[objNr] {
+				continue
+			}
+			seen[objNr] = true
+			obj := ctx.Optimize.ImageObjects[objNr]
+			if obj == nil {
+				continue
+			}
+			name := obj.ResourceNames[page-1]
+			stub, err := pdfcpu.ExtractImage(ctx, obj.ImageDict, false, name, objNr, true)
+			if err != nil || stub == nil {
+				continue
+			}
+			if int64(stub.Width)*int64(stub.Height) > maxPixels {
+				oversize++
+				continue
+			}
+			if count >= MaxPageImages {
+				cut = true
+				break pages
+			}
+			if decoded >= embedded.BudgetBytes {
+				budgetReached = true
+				break pages
+			}
+			count++
+			img, err := pdfcpu.ExtractImage(ctx, obj.ImageDict, false, name, objNr, false)
+			if err != nil || img == nil || img.Reader == nil {
+				continue
+			}
+			b, err := io.ReadAll(io.LimitReader(img, MaxAttachmentSize))
+			if err != nil {
+				continue
+			}
+			decoded += int64(len(b))
+			visit(PageImage{Page: page, Data: b})
+		}
+	}
+
+	if oversize > 0 {
+		notes = append(notes, fmt.Sprintf("%d page image(s) over %d pixels were not examined", oversize, maxPixels))
+	}
+	if cut {
+		notes = append(notes, fmt.Sprintf("page images past the first %d were not examined", MaxPageImages))
+	}
+	if budgetReached {
+		notes = append(notes, fmt.Sprintf("page images past the %dMB per-document budget were not examined",
+			embedded.BudgetBytes/(1024*1024)))
+	}
+	return notes, nil
+}
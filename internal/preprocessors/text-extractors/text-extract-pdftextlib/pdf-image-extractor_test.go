@@ -0,0 +1,69 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package textextractpdftextlib
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+// grayImageObject is an uncompressed DeviceGray image XObject of w x h pixels,
+// dark on the left half and light on the right.
+func grayImageObject(w, h int) string {
+	var pix bytes.Buffer
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if x < w/2 {
+				pix.WriteByte(0x20)
+			} else {
+				pix.WriteByte(0xe0)
+			}
+		}
+	}
+	return streamObject(fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d "+
+		"/ColorSpace /DeviceGray /BitsPerComponent 8", w, h), pix.String())
+}
+
+// TestExtractPageImages draws one image on both pages and a second, larger
+// image on page 2: the shared image is visited once, on page 1, and the large
+// one is refused by the pixel budget and disclosed.
+func TestExtractPageImages(t *testing.T) {
+	resources := func(names string) string { return "/Resources << /XObject << " + names + " >> >>" }
+	path := writeStructurePDF(t, objectsPDF([]string{
+		/* 1 */ "<< /Type /Catalog /Pages 2 0 R >>",
+		/* 2 */ "<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 >>",
+		/* 3 */ "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 5 0 R " + resources("/Im1 6 0 R") + " >>",
+		/* 4 */ "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 8 0 R " + resources("/Im1 6 0 R /Im2 7 0 R") + " >>",
+		/* 5 */ streamObject("", "q 100 0 0 100 0 0 cm /Im1 Do Q"),
+		/* 6 */ grayImageObject(40, 30),
+		/* 7 */ grayImageObject(100, 100),
+		/* 8 */ streamObject("", "q 100 0 0 100 0 0 cm /Im1 Do Q q 200 0 0 200 0 300 cm /Im2 Do Q"),
+	}))
+
+	var got []PageImage
+	notes, err := ExtractPageImages(path, 50*50, func(pi PageImage) { got = append(got, pi) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Page != 1 {
+		t.Fatalf("visited %d images (%+v), want the shared one once, on page 1", len(got), got)
+	}
+	img, err := png.Decode(bytes.NewReader(got[0].Data))
+	if err != nil {
+		t.Fatalf("page image is not a readable PNG: %v", err)
+	}
+	if img.Bounds() != image.Rect(0, 0, 40, 30) {
+		t.Errorf("page image bounds = %v, want 40x30", img.Bounds())
+	}
+	if r, _, _, _ := img.At(5, 5).RGBA(); r>>8 != 0x20 {
+		t.Errorf("pixel (5,5) = %#x, want 0x20", r>>8)
+	}
+	if len(notes) != 1 || !strings.Contains(notes[0], "1 page image(s) over 2500 pixels") {
+		t.Errorf("notes = %q, want the refused image disclosed", notes)
+	}
+}
@@ -0,0 +1,502 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package textextractpdftextlib
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/awslabs/ferret-scan/v2/internal/embedded"
+	"github.com/ledongthuc/pdf"
+)
+
+// Structure bounds.
+//
+// Everything walked here is a graph the document's producer wrote, and nothing
+// in the format stops it from being cyclic: an outline entry whose /Next points
+// back at itself, or a field whose /Kids contains its parent, is a few bytes of
+// PDF. The library resolves references without remembering what it has seen, so
+// these bounds are what make every walk terminate. Reaching one is DISCLOSED
+// through Structure.Notes, never silent: the items past it were not examined.
+const (
+	// MaxStructureItems bounds each of the form-field, annotation and bookmark
+	// walks separately. Real documents carry tens to hundreds of each.
+	MaxStructureItems = 10000
+
+	// MaxAttachments bounds the embedded files extracted from one document.
+	MaxAttachments = 256
+
+	// MaxAttachmentSize bounds a single embedded file, mirroring the Office
+	// extractor's per-part cap. Embedded file streams are almost always
+	// FlateDecode, so the declared /Length says nothing about the decoded size.
+	MaxAttachmentSize = 50 * 1024 * 1024 // 50MB
+
+	// maxTreeDepth bounds recursion through field /Kids, outline /First and
+	// name-tree /Kids chains.
+	maxTreeDepth = 32
+)
+
+// Structure is the content of a PDF that lives outside its page content
+// streams, and which ExtractText therefore never sees.
+type Structure struct {
+	// Fields are the AcroForm fields that carry a value, in document order.
+	Fields []FormField
+
+	// Annotations are the page annotations that carry text, in page order.
+	// Widget annotations are omitted (their values are Fields), as are Link and
+	// Popup annotations (a popup shows its parent's text).
+	Annotations []Annotation
+
+	// Bookmarks are the document outline's entries, depth first.
+	Bookmarks []Bookmark
+
+	// Attachments are the embedded files written to temporary files for the
+	// caller to route. The caller must release them with CleanupAttachments.
+	Attachments []Attachment
+
+	// Notes are payload-free statements about content that was NOT examined: an
+	// embedded file refused for size, or a walk that reached its bound.
+	Notes []string
+}
+
+// FormField is one AcroForm field.
+type FormField struct {
+	// Name is the fully qualified field name: the partial names (/T) of the
+	// field and its ancestors joined with ".".
+	Name  string
+	Value string
+}
+
+// Annotation is one text-bearing page annotation.
+type Annotation struct {
+	// Page is the 1-based page the annotation is on.
+	Page int
+	// Subtype is the annotation's /Subtype, e.g. "Text" for a sticky note.
+	Subtype  string
+	Author   string
+	Subject  string
+	Contents string
+}
+
+// Bookmark is one document outline entry.
+type Bookmark struct {
+	Title string
+	// Depth is 0 for a top-level entry.
+	Depth int
+}
+
+// Attachment is an embedded file materialized to a temporary file.
+type Attachment struct {
+	// Name is the file name the document gives the attachment. It is
+	// producer-controlled and is never used to build a path.
+	Name         string
+	TempFilePath string
+	Size         int64
+}
+
+// ExtractStructure reads the form fields, annotations, outline and embedded
+// files of a PDF.
+//
+// It is separate from ExtractText because none of this is page content: a
+// value typed into a form field, a reviewer's sticky note, a bookmark title and
+// a spreadsheet attached to a portfolio all live in the document catalog or the
+// page dictionaries, where the text extractor does not look.
+func ExtractStructure(filePath string) (s *Structure, err error) {
+	s = &Structure{}
+
+	// The PDF library reports malformed input by panicking. Anything extracted
+	// to a temporary file before the panic is released here rather than handed
+	// to a caller that received an error.
+	defer func() {
+		if r := recover(); r != nil {
+			CleanupAttachments(s.Attachments)
+			s.Attachments = nil
+			err = fmt.Errorf("PDF library panic on %s: %v", filepath.Base(filePath), r)
+		}
+	}()
+
+	r, closePDF, decrypted, err := openPDF(filePath)
+	if err != nil {
+		return s, fmt.Errorf("error opening PDF: %v", err)
+	}
+	defer closePDF()
+
+	root := r.Trailer().Key("Root")
+	if root.Kind() != pdf.Dict {
+		return s, fmt.Errorf("no document catalog found")
+	}
+
+	x := &structureWalker{s: s, decrypted: decrypted, seen: make(map[[sha256.Size]byte]bool)}
+	x.fields(root.Key("AcroForm").Key("Fields"))
+	x.pages(root.Key("Pages"))
+	x.outline(root.Key("Outlines"))
+	x.embeddedFiles(root.Key("Names").Key("EmbeddedFiles"), 0)
+	x.disclose()
+
+	return s, nil
+}
+
+// CleanupAttachments removes the temporary files behind attachments.
+func CleanupAttachments(attachments []Attachment) {
+	for _, a := range attachments {
+		if a.TempFilePath != "" {
+			os.Remove(a.TempFilePath)
+		}
+	}
+}
+
+// structureWalker carries the bookkeeping of one ExtractStructure call.
+type structureWalker struct {
+	s *Structure
+
+	// Per-walk counters, compared against the bounds above. A walk that stops
+	// at its bound sets its truncated flag so disclose can say so.
+	//
+	// attachmentCount counts extraction ATTEMPTS and attachmentBytes counts every
+	// byte decoded, duplicates included: a name tree that lists one large file
+	// thousands of times costs a decode each time, whether or not the result is
+	// kept.
+	fieldCount, annotCount, bookmarkCount, nameNodeCount int
+	fieldsCut, annotsCut, bookmarksCut, nameTreeCut      bool
+	attachmentCount                                      int
+	attachmentsCut, attachmentBudgetReached              bool
+	attachmentBytes                                      int64
+
+	// decrypted is set when the document was decrypted in memory. Its embedded
+	// files are then declined rather than extracted: extraction writes a
+	// temporary file, which would put decrypted content on disk.
+	decrypted bool
+
+	// seen holds the digest of every attachment already extracted. A portfolio
+	// commonly lists a file in the /EmbeddedFiles name tree AND attaches it to a
+	// page through a FileAttachment annotation; scanning it twice would report
+	// every finding in it twice.
+	seen map[[sha256.Size]byte]bool
+}
+
+// fields walks an AcroForm field array and the field hierarchy under it.
+func (x *structureWalker) fields(arr pdf.Value) {
+	for i := 0; i < arr.Len(); i++ {
+		if !x.field(arr.Index(i), "", 0) {
+			return
+		}
+	}
+}
+
+// field records one field and descends into its kids. It returns false once
+// the field bound is reached.
+//
+// A kid without a partial name (/T) is a widget annotation of its parent rather
+// than a field of its own, so it is not descended into; its value is the
+// parent's.
+func (x *structureWalker) field(v pdf.Value, parent string, depth int) bool {
+	if v.Kind() != pdf.Dict || depth > maxTreeDepth {
+		return true
+	}
+	name := parent
+	if t := v.Key("T").Text(); t != "" {
+		if name != "" {
+			name += "."
+		}
+		name += t
+	}
+
+	if x.fieldCount >= MaxStructureItems {
+		x.fieldsCut = true
+		return false
+	}
+	x.fieldCount++
+
+	if value := fieldValue(v); name != "" && value != "" {
+		x.s.Fields = append(x.s.Fields, FormField{Name: name, Value: value})
+	}
+
+	kids := v.Key("Kids")
+	for i := 0; i < kids.Len(); i++ {
+		kid := kids.Index(i)
+		if kid.Key("T").IsNull() {
+			continue
+		}
+		if !x.field(kid, name, depth+1) {
+			return false
+		}
+	}
+	return true
+}
+
+// fieldValue returns a field's value as text: /V, or the default value /DV when
+// the field was never filled in. A multiple-selection list's value is an array
+// of its selected options.
+func fieldValue(v pdf.Value) string {
+	value := valueText(v.Key("V"))
+	if value == "" {
+		value = valueText(v.Key("DV"))
+	}
+	return value
+}
+
+func valueText(v pdf.Value) string {
+	switch v.Kind() {
+	case pdf.String:
+		return v.Text()
+	case pdf.Name:
+		return v.Name()
+	case pdf.Array:
+		var parts []string
+		for i := 0; i < v.Len(); i++ {
+			if s := valueText(v.Index(i)); s != "" {
+				parts = append(parts, s)
+			}
+		}
+		return strings.Join(parts, ", ")
+	}
+	return ""
+}
+
+// pages walks the page tree once, in page order, reading each page's
+// annotations.
+//
+// It walks the tree itself rather than calling Reader.Page per page: that call
+// searches the tree from the root every time, which is quadratic in the page
+// count for the flat trees most producers write.
+//
+// A well-formed tree has fewer intermediate nodes than pages, so visiting more
+// than twice the declared page count means the tree loops back on itself, and
+// the walk stops there.
+func (x *structureWalker) pages(root pdf.Value) {
+	pageNum := 0
+	visited, maxVisits := 0, 2*int(root.Key("Count").Int64())+maxTreeDepth
+	var walk func(node pdf.Value, depth int) bool
+	walk = func(node pdf.Value, depth int) bool {
+		if depth > maxTreeDepth || visited >= maxVisits {
+			return false
+		}
+		visited++
+		if node.Key("Type").Name() == "Pages" {
+			kids := node.Key("Kids")
+			for i := 0; i < kids.Len(); i++ {
+				if !walk(kids.Index(i), depth+1) {
+					return false
+				}
+			}
+			return true
+		}
+		pageNum++
+		return x.annotations(pageNum, node.Key("Annots"))
+	}
+	walk(root, 0)
+}
+
+// annotations records the text-bearing annotations of one page and extracts
+// files attached through FileAttachment annotations. It returns false once the
+// annotation bound is reached.
+func (x *structureWalker) annotations(pageNum int, annots pdf.Value) bool {
+	for i := 0; i < annots.Len(); i++ {
+		a := annots.Index(i)
+		if a.Kind() != pdf.Dict {
+			continue
+		}
+		if x.annotCount >= MaxStructureItems {
+			x.annotsCut = true
+			return false
+		}
+		x.annotCount++
+
+		subtype := a.Key("Subtype").Name()
+		switch subtype {
+		case "Widget", "Link", "Popup":
+			continue
+		case "FileAttachment":
+			x.attachment(a.Key("FS"), "")
+		}
+
+		annot := Annotation{
+			Page:     pageNum,
+			Subtype:  subtype,
+			Author:   a.Key("T").Text(),
+			Subject:  a.Key("Subj").Text(),
+			Contents: a.Key("Contents").Text(),
+		}
+		if annot.Author != "" || annot.Subject != "" || annot.Contents != "" {
+			x.s.Annotations = append(x.s.Annotations, annot)
+		}
+	}
+	return true
+}
+
+// outline walks the document outline depth first.
+func (x *structureWalker) outline(outlines pdf.Value) {
+	var walk func(first pdf.Value, depth int) bool
+	walk = func(first pdf.Value, depth int) bool {
+		if depth > maxTreeDepth {
+			return true
+		}
+		for item := first; item.Kind() == pdf.Dict; item = item.Key("Next") {
+			if x.bookmarkCount >= MaxStructureItems {
+				x.bookmarksCut = true
+				return false
+			}
+			x.bookmarkCount++
+			if title := item.Key("Title").Text(); title != "" {
+				x.s.Bookmarks = append(x.s.Bookmarks, Bookmark{Title: title, Depth: depth})
+			}
+			if !walk(item.Key("First"), depth+1) {
+				return false
+			}
+		}
+		return true
+	}
+	walk(outlines.Key("First"), 0)
+}
+
+// embeddedFiles walks the /EmbeddedFiles name tree. A leaf node lists
+// alternating keys and file specifications in /Names; an intermediate node
+// lists its children in /Kids.
+func (x *structureWalker) embeddedFiles(node pdf.Value, depth int) {
+	if node.Kind() != pdf.Dict || depth > maxTreeDepth {
+		return
+	}
+	if x.nameNodeCount >= MaxStructureItems {
+		x.nameTreeCut = true
+		return
+	}
+	x.nameNodeCount++
+	names := node.Key("Names")
+	for i := 0; i+1 < names.Len(); i += 2 {
+		x.attachment(names.Index(i+1), names.Index(i).Text())
+	}
+	kids := node.Key("Kids")
+	for i := 0; i < kids.Len(); i++ {
+		x.embeddedFiles(kids.Index(i), depth+1)
+	}
+}
+
+// attachment extracts the file a file specification embeds. key is the name
+// tree key it was listed under, used as the name when the specification gives
+// none.
+func (x *structureWalker) attachment(spec pdf.Value, key string) {
+	if spec.Kind() != pdf.Dict {
+		return
+	}
+	ef := spec.Key("EF")
+	stream := ef.Key("UF")
+	if stream.Kind() != pdf.Stream {
+		stream = ef.Key("F")
+	}
+	if stream.Kind() != pdf.Stream {
+		// A reference to an external file: nothing is embedded to scan.
+		return
+	}
+
+	name := spec.Key("UF").Text()
+	if name == "" {
+		name = spec.Key("F").Text()
+	}
+	if name == "" {
+		name = key
+	}
+	// The name is producer-controlled. Only its final element is kept, with
+	// either separator, so a report never repeats a path the producer chose.
+	name = filepath.Base(strings.ReplaceAll(name, `\`, "/"))
+	if name == "." || name == "/" {
+		name = "attachment"
+	}
+
+	if x.attachmentCount >= MaxAttachments {
+		x.attachmentsCut = true
+		return
+	}
+	if x.attachmentBytes >= embedded.BudgetBytes {
+		x.attachmentBudgetReached = true
+		return
+	}
+	x.attachmentCount++
+
+	if x.decrypted {
+		x.s.Notes = append(x.s.Notes, fmt.Sprintf("embedded file %q was not examined: "+
+			"the document is encrypted, and extracting the file would write its decrypted content to disk", name))
+		return
+	}
+
+	path, size, digest, err := extractAttachment(stream, name)
+	if err != nil {
+		x.s.Notes = append(x.s.Notes, fmt.Sprintf("embedded file %q was not examined: %v", name, err))
+		return
+	}
+	x.attachmentBytes += size
+	if x.seen[digest] {
+		os.Remove(path)
+		return
+	}
+	x.seen[digest] = true
+	x.s.Attachments = append(x.s.Attachments, Attachment{Name: name, TempFilePath: path, Size: size})
+}
+
+// extractAttachment decodes an embedded file stream to a temporary file,
+// returning its path, size and digest.
+//
+// A panic while decoding (the library panics on a filter it does not support)
+// fails this attachment only, and is reported like any other refusal.
+func extractAttachment(stream pdf.Value, name string) (path string, size int64, digest [sha256.Size]byte, err error) {
+	// The temporary file's extension comes from embedded.SafeExt, never from
+	// the name itself, which the producer controls.
+	ext, _ := embedded.SafeExt(name)
+	tmp, err := os.CreateTemp("", "pdf_embedded_*"+ext)
+	if err != nil {
+		return "", 0, digest, err
+	}
+	defer func() {
+		tmp.Close()
+		if r := recover(); r != nil {
+			err = fmt.Errorf("could not be decoded: %v", r)
+		}
+		if err != nil {
+			os.Remove(tmp.Name())
+			path = ""
+		}
+	}()
+
+	h := sha256.New()
+	rc := stream.Reader()
+	defer rc.Close()
+	// Read one byte past the cap to tell an over-cap stream from one exactly at
+	// it.
+	size, err = io.Copy(io.MultiWriter(tmp, h), io.LimitReader(rc, MaxAttachmentSize+1))
+	if err != nil {
+		return "", 0, digest, err
+	}
+	if size > MaxAttachmentSize {
+		return "", 0, digest, fmt.Errorf("exceeds the %d-byte embedded extraction cap while being read (possible decompression bomb)",
+			MaxAttachmentSize)
+	}
+	copy(digest[:], h.Sum(nil))
+	return tmp.Name(), size, digest, nil
+}
+
+// disclose turns every bound reached during the walk into a note.
+func (x *structureWalker) disclose() {
+	if x.fieldsCut {
+		x.s.Notes = append(x.s.Notes, fmt.Sprintf("form fields past the first %d were not examined", MaxStructureItems))
+	}
+	if x.annotsCut {
+		x.s.Notes = append(x.s.Notes, fmt.Sprintf("annotations past the first %d were not examined", MaxStructureItems))
+	}
+	if x.bookmarksCut {
+		x.s.Notes = append(x.s.Notes, fmt.Sprintf("bookmarks past the first %d were not examined", MaxStructureItems))
+	}
+	if x.nameTreeCut {
+		x.s.Notes = append(x.s.Notes, fmt.Sprintf("embedded files listed past the first %d name tree nodes were not examined", MaxStructureItems))
+	}
+	if x.attachmentsCut {
+		x.s.Notes = append(x.s.Notes, fmt.Sprintf("embedded files past the first %d were not examined", MaxAttachments))
+	}
+	if x.attachmentBudgetReached {
+		x.s.Notes = append(x.s.Notes, fmt.Sprintf("embedded files past the %dMB per-document budget were not examined",
+			embedded.BudgetBytes/(1024*1024)))
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package textextractpdftextlib
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// objectsPDF builds a PDF from raw object bodies, numbered from 1, with object
+// 1 as the catalog. A body may be a stream; its /Length is the caller's to get
+// right.
+func objectsPDF(objs []string) []byte {
+	var sb strings.Builder
+	sb.WriteString("%PDF-1.7\n")
+	offsets := make([]int, len(objs))
+	for i, o := range objs {
+		offsets[i] = sb.Len()
+		fmt.Fprintf(&sb, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	xref := sb.Len()
+	fmt.Fprintf(&sb, "xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&sb, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&sb, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objs)+1, xref)
+	return []byte(sb.String())
+}
+
+func streamObject(dict, data string) string {
+	return fmt.Sprintf("<< %s /Length %d >>\nstream\n%s\nendstream", dict, len(data), data)
+}
+
+// structuredPDF has one of each kind of structure: a flat field, a field with
+// a child field, a sticky note, a two-level outline, and an embedded file that
+// is listed in the name tree AND attached to the page.
+func structuredPDF() []byte {
+	return objectsPDF([]string{
+		/* 1 */ "<< /Type /Catalog /Pages 2 0 R /AcroForm << /Fields [5 0 R 6 0 R] >> /Outlines 8 0 R " +
+			"/Names << /EmbeddedFiles << /Names [(data.csv) 11 0 R] >> >> >>",
+		/* 2 */ "<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		/* 3 */ "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R /Annots [7 0 R 13 0 R 15 0 R] >>",
+		/* 4 */ streamObject("", "BT ET"),
+		/* 5 */ "<< /T (applicant_ssn) /FT /Tx /V (123-45-6789) >>",
+		/* 6 */ "<< /T (applicant) /Kids [14 0 R] >>",
+		/* 7 */ "<< /Type /Annot /Subtype /Text /Rect [0 0 10 10] /T (Jane Reviewer) /Contents (Call back on 555-0100) >>",
+		/* 8 */ "<< /Type /Outlines /First 9 0 R /Last 9 0 R >>",
+		/* 9 */ "<< /Title (Claimant details) /Parent 8 0 R /First 10 0 R /Last 10 0 R >>",
+		/* 10 */ "<< /Title (Bank account) /Parent 9 0 R >>",
+		/* 11 */ "<< /Type /Filespec /F (data.csv) /UF (data.csv) /EF << /F 12 0 R >> >>",
+		/* 12 */ streamObject("/Type /EmbeddedFile", "name,ssn\nJane,078-05-1120\n"),
+		/* 13 */ "<< /Type /Annot /Subtype /FileAttachment /Rect [0 0 10 10] /FS 11 0 R >>",
+		/* 14 */ "<< /T (email) /Parent 6 0 R /V (jane@example.com) >>",
+		/* 15 */ "<< /Type /Annot /Subtype /Widget /Rect [0 0 10 10] /Parent 5 0 R >>",
+	})
+}
+
+func writeStructurePDF(t *testing.T, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "form.pdf")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExtractStructure(t *testing.T) {
+	s, err := ExtractStructure(writeStructurePDF(t, structuredPDF()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer CleanupAttachments(s.Attachments)
+
+	wantFields := []FormField{
+		{Name: "applicant_ssn", Value: "123-45-6789"},
+		{Name: "applicant.email", Value: "jane@example.com"},
+	}
+	if fmt.Sprint(s.Fields) != fmt.Sprint(wantFields) {
+		t.Errorf("Fields = %v, want %v", s.Fields, wantFields)
+	}
+
+	// The widget and the file attachment carry no text, so only the sticky
+	// note is reported.
+	wantAnnots := []Annotation{{Page: 1, Subtype: "Text", Author: "Jane Reviewer", Contents: "Call back on 555-0100"}}
+	if fmt.Sprint(s.Annotations) != fmt.Sprint(wantAnnots) {
+		t.Errorf("Annotations = %v, want %v", s.Annotations, wantAnnots)
+	}
+
+	wantBookmarks := []Bookmark{{Title: "Claimant details"}, {Title: "Bank account", Depth: 1}}
+	if fmt.Sprint(s.Bookmarks) != fmt.Sprint(wantBookmarks) {
+		t.Errorf("Bookmarks = %v, want %v", s.Bookmarks, wantBookmarks)
+	}
+
+	// Listed twice, extracted once.
+	if len(s.Attachments) != 1 {
+		t.Fatalf("%d attachments, want 1: %+v", len(s.Attachments), s.Attachments)
+	}
+	a := s.Attachments[0]
+	if a.Name != "data.csv" || filepath.Ext(a.TempFilePath) != ".csv" {
+		t.Errorf("attachment = %+v, want data.csv in a .csv temp file", a)
+	}
+	data, err := os.ReadFile(a.TempFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "078-05-1120") {
+		t.Errorf("attachment content = %q", data)
+	}
+	if len(s.Notes) != 0 {
+		t.Errorf("Notes = %v, want none", s.Notes)
+	}
+
+	CleanupAttachments(s.Attachments)
+	if _, err := os.Stat(a.TempFilePath); !os.IsNotExist(err) {
+		t.Errorf("temp file survived cleanup: %v", err)
+	}
+}
+
+// An outline entry whose /Next is itself is a few bytes of PDF. The walk must
+// stop at its bound and say that it did.
+func TestExtractStructure_CyclicOutlineIsBoundedAndDisclosed(t *testing.T) {
+	path := writeStructurePDF(t, objectsPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R /Outlines 4 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>",
+		"<< /Type /Outlines /First 5 0 R >>",
+		"<< /Title (Again) /Parent 4 0 R /Next 5 0 R >>",
+	}))
+
+	s, err := ExtractStructure(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Bookmarks) != MaxStructureItems {
+		t.Errorf("%d bookmarks, want the bound %d", len(s.Bookmarks), MaxStructureItems)
+	}
+	if len(s.Notes) != 1 || !strings.Contains(s.Notes[0], "bookmarks past the first") {
+		t.Errorf("Notes = %v, want the bookmark bound disclosed", s.Notes)
+	}
+}
+
+func TestExtractStructure_OversizeAttachmentIsDisclosed(t *testing.T) {
+	// A FlateDecode stream would be the realistic bomb; an uncompressed one over
+	// the cap exercises the same read-side check without a 50MB fixture in the
+	// repository.
+	big := strings.Repeat("x", MaxAttachmentSize+1)
+	path := writeStructurePDF(t, objectsPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R /Names << /EmbeddedFiles << /Names [(big.txt) 4 0 R] >> >> >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>",
+		"<< /Type /Filespec /F (big.txt) /EF << /F 5 0 R >> >>",
+		streamObject("/Type /EmbeddedFile", big),
+	}))
+
+	s, err := ExtractStructure(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Attachments) != 0 {
+		CleanupAttachments(s.Attachments)
+		t.Fatalf("oversize attachment was extracted")
+	}
+	if len(s.Notes) != 1 || !strings.Contains(s.Notes[0], `"big.txt" was not examined`) {
+		t.Errorf("Notes = %v, want the refusal disclosed", s.Notes)
+	}
+}
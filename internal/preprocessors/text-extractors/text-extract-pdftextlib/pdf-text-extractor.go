@@ -5,14 +5,47 @@ package textextractpdftextlib
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"path/filepath"
+	"runtime"
 	"sort"
 	"strings"
+	"sync"
 
+	"github.com/awslabs/ferret-scan/v2/internal/encryption"
+	"github.com/awslabs/ferret-scan/v2/internal/execguard"
 	"github.com/ledongthuc/pdf"
 )
 
+// Extraction bounds. A PDF's page count is not a proxy for its cost — a
+// 2,000-page statement is routine, and one page can carry a multi-megabyte
+// content stream — so neither is capped by count any more. What is bounded is
+// memory:
+//
+//   - The encoded content streams being decoded at once are bounded. Pages are
+//     handed out in order and each one's stream length is admitted through an
+//     execguard.BytesLimiter before a worker touches it, so a run of huge pages
+//     queues instead of being decoded concurrently. The limiter is the run's
+//     shared --max-live-bytes budget when the file is processed under one, and
+//     otherwise one of MaxInFlightPageBytes for this document.
+//   - MaxTotalTextBytes bounds the text accumulated from one document, mirroring
+//     the Office extractor's cap of the same name. It is generous enough that no
+//     realistic document reaches it; when one does, the pages past it are
+//     counted in PagesNotExtracted so the caller can disclose them, rather than
+//     being dropped silently the way pages 51+ used to be.
+const (
+	MaxInFlightPageBytes = 32 * 1024 * 1024  // 32MB
+	MaxTotalTextBytes    = 200 * 1024 * 1024 // 200MB
+)
+
+// maxTotalTextBytes is the cap ExtractText enforces; a variable only so tests
+// can reach the truncation path without a 200MB fixture.
+var maxTotalTextBytes = MaxTotalTextBytes
+
+// pageBreak is the line written between the text of consecutive pages.
+const pageBreak = "--- PAGE BREAK ---"
+
 // TextContent represents the extracted text content from a PDF document
 type TextContent struct {
 	Filename  string
@@ -21,6 +54,27 @@ type TextContent struct {
 	WordCount int
 	CharCount int
 	LineCount int
+
+	// Pages locates each page's text within Text, in page order. A page that
+	// yielded no text has no entry.
+	Pages []PageSpan
+
+	// FailedPages counts pages the PDF library could not read. Their text is
+	// absent from Text.
+	FailedPages int
+
+	// PagesNotExtracted counts pages that were never read because the extracted
+	// text reached MaxTotalTextBytes. They are always the document's last pages.
+	PagesNotExtracted int
+}
+
+// PageSpan records that the text of one page begins at a line of
+// TextContent.Text.
+type PageSpan struct {
+	// Page is the 1-based page number.
+	Page int
+	// Line is the 0-based line of Text at which the page's text begins.
+	Line int
 }
 
 // ExtractText extracts text from a PDF document using ledongthuc/pdf
@@ -38,179 +92,292 @@ func ExtractText(filePath string) (content *TextContent, err error) {
 	}()
 
 	// Open the PDF file
-	f, r, err := pdf.Open(filePath)
+	r, closePDF, _, err := openPDF(filePath)
 	if err != nil {
 		return content, fmt.Errorf("error opening PDF: %v", err)
 	}
-	defer f.Close()
+	defer closePDF()
 
-	// Get the number of pages
 	content.PageCount = r.NumPage()
 
-	// Performance optimization: limit processing for very large PDFs
-	maxPages := 50 // Configurable limit to prevent excessive processing time
-	if content.PageCount > maxPages {
-		content.PageCount = maxPages
-		// Note: This truncates processing but maintains reasonable performance
+	var out pageWriter
+	extracted := 0
+	truncated := false
+	streamPages(r, content.PageCount, pageAdmissionFor(filePath), func(pageNum int, text string, err error) bool {
+		extracted++
+		if err != nil {
+			content.FailedPages++
+			return true
+		}
+		out.addPage(pageNum, text)
+		if out.size() >= maxTotalTextBytes {
+			truncated = true
+			return false
+		}
+		return true
+	})
+	if truncated {
+		content.PagesNotExtracted = content.PageCount - extracted
 	}
 
-	// Extract text from all pages with parallel processing for better performance
-	type pageResult struct {
+	// Form field values are not page content and are not read here: the PDF
+	// structure preprocessor reports each field as its own source (see
+	// ExtractStructure), and reading them here as well reported every finding
+	// in a filled-in form twice.
+
+	// The writer cleans each page as it is added, so Text is already in the
+	// shape cleanTextPreservingStructure used to produce over the whole
+	// document, and the recorded page lines are exact.
+	content.Text = out.String()
+	content.Pages = out.pages
+
+	// Validate extraction quality (silent check)
+	validateExtractionQuality(content.Text)
+
+	// Count words, characters, and lines
+	content.WordCount = len(strings.Fields(content.Text))
+	content.CharCount = len(content.Text)
+	content.LineCount = strings.Count(content.Text, "\n") + 1
+
+	return content, nil
+}
+
+// streamPages extracts pages 1..pageCount and hands each page's text to emit in
+// page order, stopping early when emit returns false.
+//
+// A bounded set of workers decodes pages concurrently, and only a window of pages
+// is ever in flight: the dispatcher admits each page's encoded size through
+// admission before handing it to a worker, and the page is released only once
+// emit has consumed it. Admission happens in page order on a single goroutine, so
+// every page ahead of one waiting for budget has already been admitted and will
+// be released — the wait always ends, and a page larger than the whole budget is
+// decoded alone. The previous version started one goroutine per page at once and
+// held every page's text until all of them finished, which is why it had to cap
+// the page count.
+//
+// A panic while decoding one page fails that page only; it used to escape the
+// goroutine it ran on, where no recover could reach it.
+func streamPages(r *pdf.Reader, pageCount int, admission pageAdmission, emit func(pageNum int, text string, err error) bool) {
+	if pageCount <= 0 {
+		return
+	}
+
+	type result struct {
+		text string
+		err  error
+	}
+	type job struct {
 		pageNum int
-		text    string
-		err     error
+		charged int64
+		done    chan result
 	}
 
-	// Use parallel processing for multi-page PDFs
-	resultChan := make(chan pageResult, content.PageCount)
+	workers := min(runtime.GOMAXPROCS(0), pageCount)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	jobs := make(chan job)
+	// ordered carries the same jobs to the consumer in page order; its buffer is
+	// the in-flight window.
+	ordered := make(chan job, workers)
 
-	// Process pages in parallel
-	for i := 1; i <= content.PageCount; i++ {
-		go func(pageNum int) {
-			p := r.Page(pageNum)
-			if p.V.IsNull() {
-				resultChan <- pageResult{pageNum: pageNum, err: fmt.Errorf("null page")}
+	go func() {
+		defer close(jobs)
+		defer close(ordered)
+		for n := 1; n <= pageCount; n++ {
+			charged, err := admission.acquire(ctx, pageCost(r, n))
+			if err != nil {
 				return
 			}
+			j := job{pageNum: n, charged: charged, done: make(chan result, 1)}
+			select {
+			case ordered <- j:
+			case <-ctx.Done():
+				admission.release(j.charged)
+				return
+			}
+			jobs <- j
+		}
+	}()
 
-			text, err := extractTextWithProperSpacing(p)
-			resultChan <- pageResult{pageNum: pageNum, text: text, err: err}
-		}(i)
+	for range workers {
+		go func() {
+			for j := range jobs {
+				text, err := extractPage(r, j.pageNum)
+				j.done <- result{text: text, err: err}
+			}
+		}()
 	}
 
-	// Collect results in order
-	pageTexts := make(map[int]string)
-	failedPages := 0
-
-	for i := 0; i < content.PageCount; i++ {
-		result := <-resultChan
-		if result.err != nil {
-			failedPages++
+	stopped := false
+	for j := range ordered {
+		res := <-j.done
+		admission.release(j.charged)
+		if stopped {
 			continue
 		}
-		pageTexts[result.pageNum] = result.text
-	}
-
-	// Assemble pages in correct order
-	var buf bytes.Buffer
-	for i := 1; i <= content.PageCount; i++ {
-		if text, exists := pageTexts[i]; exists {
-			// Preserve page structure with clear page boundaries
-			if buf.Len() > 0 {
-				buf.WriteString("\n--- PAGE BREAK ---\n")
-			}
-			buf.WriteString(text)
+		if !emit(j.pageNum, res.text, res.err) {
+			stopped = true
+			cancel()
 		}
 	}
+}
 
-	// Silent tracking of extraction completeness (no output)
-	// failedPages is tracked but not reported to stderr
+// pageAdmission admits a document's pages into the decoding window.
+type pageAdmission interface {
+	// acquire waits until a page of cost bytes may be decoded and returns what
+	// was charged for it, which release must be given once the page is done.
+	acquire(ctx context.Context, cost int64) (charged int64, err error)
+	release(charged int64)
+}
 
-	// Extract form data (AcroForm fields)
-	formData, err := extractFormData(r)
-	if err == nil && formData != "" {
-		buf.WriteString("\n--- PDF Form Data ---\n")
-		buf.WriteString(formData)
-		buf.WriteString("\n")
+// pageAdmissionFor charges the run's shared live-bytes budget when filePath is
+// processed under one, and a budget of MaxInFlightPageBytes for this document
+// alone otherwise.
+func pageAdmissionFor(filePath string) pageAdmission {
+	if shared := execguard.LiveBytesFor(filePath); shared != nil {
+		return &sharedAdmission{limiter: shared, released: make(chan struct{}, 1)}
 	}
-	// Silent handling of form data extraction errors
-
-	// Set the extracted text
-	content.Text = buf.String()
-
-	// Clean up the text while preserving structure
-	content.Text = cleanTextPreservingStructure(content.Text)
+	return ownAdmission{execguard.NewBytesLimiter(MaxInFlightPageBytes)}
+}
 
-	// Validate extraction quality (silent check)
-	validateExtractionQuality(content.Text)
+// ownAdmission is a budget of the document's own.
+type ownAdmission struct{ limiter *execguard.BytesLimiter }
 
-	// Count words, characters, and lines
-	content.WordCount = len(strings.Fields(content.Text))
-	content.CharCount = len(content.Text)
-	content.LineCount = strings.Count(content.Text, "\n") + 1
-
-	return content, nil
+func (a ownAdmission) acquire(ctx context.Context, cost int64) (int64, error) {
+	return cost, a.limiter.AcquireBytes(ctx, cost)
 }
 
-// extractFormData extracts form field data from PDF AcroForms
-func extractFormData(r *pdf.Reader) (string, error) {
-	var buf bytes.Buffer
+func (a ownAdmission) release(charged int64) { a.limiter.ReleaseBytes(charged) }
+
+// sharedAdmission charges the run's budget, which other files hold too.
+//
+// It must not wait for that budget with nothing of its own in flight: the
+// worker processing this file already holds the file's size against the same
+// budget, so when every worker does, waiting would deadlock them all. A page is
+// therefore admitted uncharged when none of this document's are in flight — its
+// encoded stream is part of the file whose size is already held — and further
+// pages only when the budget has room for them now; otherwise admission waits
+// for one of this document's own pages to finish, which always happens.
+type sharedAdmission struct {
+	limiter  *execguard.BytesLimiter
+	mu       sync.Mutex
+	inFlight int
+	released chan struct{}
+}
 
-	// Try to access the document catalog
-	root := r.Trailer().Key("Root")
-	if root.IsNull() {
-		return "", fmt.Errorf("no document catalog found")
+func (a *sharedAdmission) acquire(ctx context.Context, cost int64) (int64, error) {
+	for {
+		a.mu.Lock()
+		if a.limiter.TryAcquireBytes(cost) {
+			a.inFlight++
+			a.mu.Unlock()
+			return cost, nil
+		}
+		if a.inFlight == 0 {
+			a.inFlight++
+			a.mu.Unlock()
+			return 0, nil
+		}
+		a.mu.Unlock()
+		select {
+		case <-a.released:
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
 	}
+}
 
-	// Look for AcroForm dictionary
-	acroForm := root.Key("AcroForm")
-	if acroForm.IsNull() {
-		return "", nil // No forms in this PDF
+func (a *sharedAdmission) release(charged int64) {
+	a.limiter.ReleaseBytes(charged)
+	a.mu.Lock()
+	a.inFlight--
+	a.mu.Unlock()
+	select {
+	case a.released <- struct{}{}:
+	default:
 	}
+}
 
-	// Try to extract form fields
-	fields := acroForm.Key("Fields")
-	if fields.IsNull() {
-		return "", nil
+// extractPage decodes one page, converting a library panic into an error.
+func extractPage(r *pdf.Reader, pageNum int) (text string, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("page %d: PDF library panic: %v", pageNum, rec)
+		}
+	}()
+	p := r.Page(pageNum)
+	if p.V.IsNull() {
+		return "", fmt.Errorf("page %d: null page", pageNum)
 	}
+	return extractTextWithProperSpacing(p)
+}
 
-	// Process form fields array
-	if fields.Kind() == pdf.Array {
-		array := fields
-		for i := 0; i < array.Len(); i++ {
-			field := array.Index(i)
-			if !field.IsNull() {
-				name, value := extractFieldNameValue(field)
-				if name != "" && value != "" {
-					// Include both field name and value for context and PII detection
-					buf.WriteString(fmt.Sprintf("Name: %s Value: %s\n", name, value))
-				}
-			}
+// pageCost is the admission weight of a page: the declared length of its content
+// streams, the bytes a worker will inflate and walk. A page whose length cannot
+// be read costs nothing, so a malformed dictionary cannot stall the dispatcher.
+func pageCost(r *pdf.Reader, pageNum int) (cost int64) {
+	defer func() {
+		if recover() != nil {
+			cost = 0
+		}
+	}()
+	contents := r.Page(pageNum).V.Key("Contents")
+	switch contents.Kind() {
+	case pdf.Stream:
+		cost = contents.Key("Length").Int64()
+	case pdf.Array:
+		for i := 0; i < contents.Len(); i++ {
+			cost += contents.Index(i).Key("Length").Int64()
 		}
 	}
+	return max(cost, 0)
+}
 
-	return buf.String(), nil
+// pageWriter assembles the document text page by page while recording where
+// each page begins.
+//
+// It reproduces exactly the text the extractor produced when it joined raw pages
+// with page-break markers and cleaned the whole result once: a marker precedes
+// every page after the first non-empty one, and blank lines vanish. Cleaning
+// per page instead is what lets the line of each page be known as it is written,
+// rather than re-derived by counting lines in a multi-megabyte string.
+type pageWriter struct {
+	buf     strings.Builder
+	line    int  // lines written so far
+	started bool // a page with any raw text has been seen
+	pages   []PageSpan
 }
 
-// extractFieldNameValue extracts name and value from a single form field
-func extractFieldNameValue(field pdf.Value) (string, string) {
-	if field.Kind() != pdf.Dict {
-		return "", ""
-	}
+func (w *pageWriter) size() int { return w.buf.Len() }
 
-	var fieldName, fieldValue string
+func (w *pageWriter) String() string { return w.buf.String() }
 
-	// Get field name
-	t := field.Key("T")
-	if !t.IsNull() && t.Kind() == pdf.String {
-		fieldName = t.Text()
+// writeLines appends already-cleaned text as whole lines.
+func (w *pageWriter) writeLines(text string) {
+	if text == "" {
+		return
 	}
-
-	// Get field value - try different value keys
-	v := field.Key("V")
-	if !v.IsNull() {
-		switch v.Kind() {
-		case pdf.String:
-			fieldValue = v.Text()
-		case pdf.Name:
-			fieldValue = v.Name()
-		}
+	if w.buf.Len() > 0 {
+		w.buf.WriteByte('\n')
 	}
+	w.buf.WriteString(text)
+	w.line += strings.Count(text, "\n") + 1
+}
 
-	// If no value in V, try DV (default value)
-	if fieldValue == "" {
-		dv := field.Key("DV")
-		if !dv.IsNull() {
-			switch dv.Kind() {
-			case pdf.String:
-				fieldValue = dv.Text()
-			case pdf.Name:
-				fieldValue = dv.Name()
-			}
-		}
+func (w *pageWriter) addPage(pageNum int, raw string) {
+	if w.started {
+		w.writeLines(pageBreak)
 	}
-
-	return fieldName, fieldValue
+	if raw != "" {
+		w.started = true
+	}
+	cleaned := cleanTextPreservingStructure(raw)
+	if cleaned == "" {
+		return
+	}
+	w.pages = append(w.pages, PageSpan{Page: pageNum, Line: w.line})
+	w.writeLines(cleaned)
 }
 
 // addParagraphBreaks adds paragraph breaks at logical boundaries
@@ -446,3 +613,19 @@ func shouldBreakLine(word string, index int, words []string) bool {
 
 	return false
 }
+
+// openPDF opens a PDF for reading. When the router decrypted the document it is
+// read from the plaintext held in memory; the file on disk is still encrypted,
+// and the library could not open it. decrypted tells the caller that nothing
+// read from the document may be written to disk.
+func openPDF(filePath string) (r *pdf.Reader, closePDF func() error, decrypted bool, err error) {
+	if data, ok := encryption.Plaintext(filePath); ok {
+		r, err = pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+		return r, func() error { return nil }, true, err
+	}
+	f, r, err := pdf.Open(filePath)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	return r, f.Close, false, nil
+}
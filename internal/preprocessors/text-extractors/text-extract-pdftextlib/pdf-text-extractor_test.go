@@ -0,0 +1,219 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package textextractpdftextlib
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/awslabs/ferret-scan/v2/internal/execguard"
+)
+
+// multiPagePDF builds a structurally valid PDF (xref and startxref both correct)
+// with one page per entry of pages, each showing its string as a single line.
+func multiPagePDF(pages []string) []byte {
+	n := len(pages)
+	// Objects: 1 catalog, 2 pages tree, 3 font, then a page and its content
+	// stream for each page.
+	kids := make([]string, n)
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	objs := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), n),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	}
+	for i, text := range pages {
+		content := fmt.Sprintf("BT /F1 12 Tf 72 700 Td (%s) Tj ET\n", text)
+		objs = append(objs,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents %d 0 R "+
+				"/Resources << /Font << /F1 3 0 R >> >> >>", 5+2*i),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(content), content))
+	}
+
+	var sb strings.Builder
+	sb.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objs))
+	for i, o := range objs {
+		offsets[i] = sb.Len()
+		fmt.Fprintf(&sb, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	xref := sb.Len()
+	fmt.Fprintf(&sb, "xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&sb, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&sb, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objs)+1, xref)
+	return []byte(sb.String())
+}
+
+func writePDF(t *testing.T, pages []string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "doc.pdf")
+	if err := os.WriteFile(path, multiPagePDF(pages), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func numberedPages(n int) []string {
+	pages := make([]string, n)
+	for i := range pages {
+		pages[i] = fmt.Sprintf("Text of page %d", i+1)
+	}
+	return pages
+}
+
+// The extractor used to stop at page 50, so a finding on page 51 of a long
+// statement was never reported and nothing said so.
+func TestExtractText_ReadsEveryPage(t *testing.T) {
+	path := writePDF(t, numberedPages(60))
+
+	content, err := ExtractText(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content.PageCount != 60 {
+		t.Errorf("PageCount = %d, want 60", content.PageCount)
+	}
+	if !strings.Contains(content.Text, "Text of page 60") {
+		t.Fatal("page 60 was not extracted")
+	}
+	if content.FailedPages != 0 || content.PagesNotExtracted != 0 {
+		t.Errorf("FailedPages = %d, PagesNotExtracted = %d, want 0 and 0", content.FailedPages, content.PagesNotExtracted)
+	}
+
+	// Every recorded span must point at its own page's text.
+	lines := strings.Split(content.Text, "\n")
+	if len(content.Pages) != 60 {
+		t.Fatalf("%d page spans, want 60", len(content.Pages))
+	}
+	for i, span := range content.Pages {
+		if span.Page != i+1 {
+			t.Fatalf("span %d is page %d, want pages in order", i, span.Page)
+		}
+		if want := fmt.Sprintf("Text of page %d", span.Page); lines[span.Line] != want {
+			t.Errorf("page %d span points at line %d = %q, want %q", span.Page, span.Line, lines[span.Line], want)
+		}
+	}
+}
+
+// The page writer cleans page by page so it can record exact page lines. Its
+// output must stay byte-identical to the old join-then-clean, or every line
+// number — and with it every suppression rule — for an existing PDF would move.
+func TestPageWriter_MatchesJoinThenClean(t *testing.T) {
+	cases := [][]string{
+		{"a\n", "b\n"},
+		{"", "b\n", "c"},
+		{"a\n", "", "c\n"},
+		{"  \n", "b\n"},
+		{"a\t\tb  c\n\n\n d \n", "\n\n", "e"},
+		{"", "", ""},
+		{"only\n"},
+	}
+	for _, pages := range cases {
+		var old strings.Builder
+		var w pageWriter
+		for i, raw := range pages {
+			if old.Len() > 0 {
+				old.WriteString("\n" + pageBreak + "\n")
+			}
+			old.WriteString(raw)
+			w.addPage(i+1, raw)
+		}
+		if want := cleanTextPreservingStructure(old.String()); w.String() != want {
+			t.Errorf("pages %q:\n got %q\nwant %q", pages, w.String(), want)
+		}
+		lines := strings.Split(w.String(), "\n")
+		for _, span := range w.pages {
+			if want := cleanTextPreservingStructure(pages[span.Page-1]); !strings.HasPrefix(want, lines[span.Line]) {
+				t.Errorf("pages %q: page %d starts at line %d = %q", pages, span.Page, span.Line, lines[span.Line])
+			}
+		}
+	}
+}
+
+// Reaching the text cap must stop extraction at a page boundary and count the
+// pages that were not read, so the caller can disclose them.
+func TestExtractText_CountsPagesPastTheTextCap(t *testing.T) {
+	defer func(prev int) { maxTotalTextBytes = prev }(maxTotalTextBytes)
+	maxTotalTextBytes = len("Text of page 1\n--- PAGE BREAK ---\nText of page 2")
+
+	path := writePDF(t, numberedPages(10))
+	content, err := ExtractText(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content.PagesNotExtracted != 8 {
+		t.Errorf("PagesNotExtracted = %d, want 8", content.PagesNotExtracted)
+	}
+	if strings.Contains(content.Text, "Text of page 3") {
+		t.Error("text past the cap was kept")
+	}
+	if !strings.Contains(content.Text, "Text of page 2") {
+		t.Error("the page that reached the cap was dropped")
+	}
+}
+
+// Under a shared --max-live-bytes budget the decoder charges that budget, and
+// returns every byte it charged.
+func TestExtractText_ChargesTheSharedBudget(t *testing.T) {
+	path := writePDF(t, numberedPages(40))
+	const budget = 1 << 20
+	shared := execguard.NewBytesLimiter(budget)
+	defer execguard.HoldLiveBytes(path, shared)()
+
+	if _, ok := pageAdmissionFor(path).(*sharedAdmission); !ok {
+		t.Fatal("a file held under a shared budget must be admitted against it")
+	}
+	content, err := ExtractText(path)
+	if err != nil || !strings.Contains(content.Text, "Text of page 40") {
+		t.Fatalf("ExtractText = %v; page 40 extracted: %v", err, content != nil && strings.Contains(content.Text, "Text of page 40"))
+	}
+	if !shared.TryAcquireBytes(budget) {
+		t.Error("the shared budget was not returned in full")
+	}
+}
+
+// The worker processing a file already holds the file's size against the
+// shared budget. When every worker does, the budget is spent, and the decoder
+// must still finish — one page at a time — instead of waiting for bytes that
+// only its own completion would release.
+func TestExtractText_SpentSharedBudgetDoesNotDeadlock(t *testing.T) {
+	path := writePDF(t, numberedPages(20))
+	shared := execguard.NewBytesLimiter(64)
+	if !shared.TryAcquireBytes(64) {
+		t.Fatal("could not spend the budget")
+	}
+	defer execguard.HoldLiveBytes(path, shared)()
+
+	done := make(chan error, 1)
+	go func() {
+		content, err := ExtractText(path)
+		if err == nil && !strings.Contains(content.Text, "Text of page 20") {
+			err = fmt.Errorf("page 20 was not extracted")
+		}
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(30 * time.Second):
+		t.Fatal("ExtractText is waiting on a budget only its own pages could release")
+	}
+}
+
+// Without a shared budget the document bounds its pages itself.
+func TestPageAdmissionFor_FallsBackToTheDocument(t *testing.T) {
+	if _, ok := pageAdmissionFor(filepath.Join(t.TempDir(), "doc.pdf")).(ownAdmission); !ok {
+		t.Error("a file outside a shared budget must use a budget of its own")
+	}
+}
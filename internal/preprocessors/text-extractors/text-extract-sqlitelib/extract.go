@@ -0,0 +1,211 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package textextractsqlitelib
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Extraction is the text of a database's tables.
+type Extraction struct {
+	// Text is every table in schema order. Each is written as a spreadsheet
+	// sheet is: a "--- name ---" heading line, a tab-separated header row of
+	// column names, then a line per row, so a column name labels the values
+	// beneath it the way a CSV header does.
+	Text string
+	// Tables locate each table in Text. A table with no rows is left out.
+	Tables []TableText
+	// Notes disclose what could not be read: a table whose b-tree is damaged,
+	// say. Each names the table but never its content.
+	Notes []string
+}
+
+// TableText is one table of an Extraction.
+type TableText struct {
+	Name string
+	// Columns are the names in the header row.
+	Columns []string
+	// Line is the 0-based line of Extraction.Text the table's heading is on.
+	Line int
+	// Text is the table's heading, header row and rows.
+	Text string
+	// Rows locate each row in Text.
+	Rows []RowText
+}
+
+// RowText is one row of a table's text.
+type RowText struct {
+	// Label names the row: "users[rowid=42]". A WITHOUT ROWID table has no
+	// rowid and its rows are numbered in key order instead, "tags[row=3]",
+	// rather than named by a key that may itself be the sensitive value.
+	Label string
+	// Line is the 0-based line of TableText.Text the row is on.
+	Line int
+	// Offsets are the byte offsets on the line where each column's value
+	// begins.
+	Offsets []int
+}
+
+// Extract reads the text of every table of the database in data. Tables
+// SQLite maintains itself are skipped.
+func Extract(data []byte) (*Extraction, error) {
+	return ExtractReader(bytes.NewReader(data), int64(len(data)))
+}
+
+// ExtractReader is Extract for a database of size bytes read through src, a
+// page at a time.
+func ExtractReader(src io.ReaderAt, size int64) (*Extraction, error) {
+	db, err := OpenReader(src, size)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := db.Schema()
+	if err != nil {
+		return nil, fmt.Errorf("schema: %w", err)
+	}
+
+	ex := &Extraction{}
+	var text strings.Builder
+	line := 0
+	for _, t := range schema.Tables {
+		if t.Internal() {
+			continue
+		}
+		tt, err := db.tableText(t)
+		if err != nil {
+			// What was read before the damage is kept, and said to be partial.
+			ex.Notes = append(ex.Notes, fmt.Sprintf("table %s: %v", cleanCell(t.Name), err))
+		}
+		if tt == nil {
+			continue
+		}
+		tt.Line = line
+		text.WriteString(tt.Text)
+		line += strings.Count(tt.Text, "\n")
+		ex.Tables = append(ex.Tables, *tt)
+	}
+	ex.Text = text.String()
+	return ex, nil
+}
+
+// Values calls visit with every value of every table and index b-tree the
+// schema names, internal tables included, for a caller that must see each
+// stored copy of a value rather than each row. It stops at the first b-tree
+// that cannot be read.
+func (db *Database) Values(visit func(Value)) error {
+	schema, err := db.Schema()
+	if err != nil {
+		return fmt.Errorf("schema: %w", err)
+	}
+	// keyFields of each entry's values order the b-tree; -1 is all of them.
+	each := func(tree string, keyFields int) func(Entry) bool {
+		return func(e Entry) bool {
+			for i, v := range e.Values {
+				v.Tree, v.Key = tree, keyFields < 0 || i < keyFields
+				visit(v)
+			}
+			return true
+		}
+	}
+	for _, t := range schema.Tables {
+		walk, keyFields := db.WalkTable, 0
+		if t.WithoutRowID {
+			walk, keyFields = db.WalkIndex, -1
+			if len(t.primaryKey) > 0 {
+				keyFields = len(t.primaryKey)
+			}
+		}
+		if err := walk(t.RootPage, each(t.Name, keyFields)); err != nil {
+			return fmt.Errorf("table %s: %w", cleanCell(t.Name), err)
+		}
+	}
+	for _, ix := range schema.Indexes {
+		if err := db.WalkIndex(ix.RootPage, each(ix.Name, -1)); err != nil {
+			return fmt.Errorf("index %s: %w", cleanCell(ix.Name), err)
+		}
+	}
+	return nil
+}
+
+// tableText renders one table. It returns nil for a table with no rows, and
+// on an error the rows read before it.
+func (db *Database) tableText(t Table) (*TableText, error) {
+	type row struct {
+		label  string
+		values []string
+	}
+	var rows []row
+	width := len(t.Columns)
+	n := 0
+	visit := func(e Entry) bool {
+		n++
+		label := cleanCell(t.Name) + "[rowid=" + strconv.FormatInt(e.RowID, 10) + "]"
+		if t.WithoutRowID {
+			label = cleanCell(t.Name) + "[row=" + strconv.Itoa(n) + "]"
+		}
+		order := t.recordColumns(len(e.Values))
+		values := make([]string, len(order))
+		for i, v := range e.Values {
+			values[order[i]] = cleanCell(v.String())
+		}
+		if t.RowIDColumn >= 0 && t.RowIDColumn < len(e.Values) && e.Values[t.RowIDColumn].Kind == Null {
+			values[t.RowIDColumn] = strconv.FormatInt(e.RowID, 10)
+		}
+		width = max(width, len(values))
+		rows = append(rows, row{label: label, values: values})
+		return true
+	}
+	var err error
+	if t.WithoutRowID {
+		err = db.WalkIndex(t.RootPage, visit)
+	} else {
+		err = db.WalkTable(t.RootPage, visit)
+	}
+	if len(rows) == 0 {
+		return nil, err
+	}
+
+	tt := &TableText{Name: t.Name}
+	var b strings.Builder
+	b.WriteString("--- " + cleanCell(t.Name) + " ---\n")
+	for i := 0; i < width; i++ {
+		tt.Columns = append(tt.Columns, cleanCell(t.ColumnName(i)))
+	}
+	b.WriteString(strings.Join(tt.Columns, "\t") + "\n")
+	for i, r := range rows {
+		rt := RowText{Label: r.label, Line: i + 2, Offsets: make([]int, width)}
+		lineStart := b.Len()
+		for c := 0; c < width; c++ {
+			if c > 0 {
+				b.WriteByte('\t')
+			}
+			rt.Offsets[c] = b.Len() - lineStart
+			if c < len(r.values) {
+				b.WriteString(r.values[c])
+			}
+		}
+		b.WriteByte('\n')
+		tt.Rows = append(tt.Rows, rt)
+	}
+	tt.Text = b.String()
+	return tt, err
+}
+
+// cleanCell makes a value or name safe to write as one field of one line:
+// tabs and line breaks, which would split it, become spaces.
+func cleanCell(s string) string {
+	if !strings.ContainsAny(s, "\t\r\n") {
+		return s
+	}
+	return strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\r' || r == '\n' {
+			return ' '
+		}
+		return r
+	}, s)
+}
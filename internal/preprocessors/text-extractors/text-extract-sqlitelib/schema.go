@@ -0,0 +1,336 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package textextractsqlitelib
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Table is a table of the database schema.
+type Table struct {
+	Name     string
+	RootPage int
+	// Columns are the column names, in declaration order. A table whose
+	// statement could not be parsed has none, and its columns are named by
+	// position when its rows are read.
+	Columns []string
+	// RowIDColumn is the column that aliases the rowid (an INTEGER PRIMARY
+	// KEY), or -1. SQLite stores NULL in the record for it, and the value is
+	// the row's rowid.
+	RowIDColumn int
+	// WithoutRowID marks a table stored as an index b-tree, keyed by its
+	// primary key rather than by rowid.
+	WithoutRowID bool
+
+	// primaryKey is the declared primary key, as column indexes in key order.
+	primaryKey []int
+}
+
+// Index is an index of the database schema. An index b-tree holds its own
+// copy of every value of the columns it indexes.
+type Index struct {
+	Name     string
+	Table    string
+	RootPage int
+}
+
+// Schema is the tables and indexes of a database, in schema order.
+type Schema struct {
+	Tables  []Table
+	Indexes []Index
+}
+
+// Schema reads the schema table. Virtual tables have no b-tree of their own
+// and are left out; the tables a virtual table keeps its data in are ordinary
+// tables and are listed.
+func (db *Database) Schema() (*Schema, error) {
+	s := &Schema{}
+	err := db.WalkTable(1, func(e Entry) bool {
+		if len(e.Values) < 5 {
+			return true
+		}
+		kind, name, table := e.Values[0].Text, e.Values[1].Text, e.Values[2].Text
+		root := int(e.Values[3].Int)
+		if root <= 0 {
+			return true
+		}
+		switch kind {
+		case "table":
+			t := parseCreateTable(e.Values[4].Text)
+			t.Name, t.RootPage = name, root
+			s.Tables = append(s.Tables, t)
+		case "index":
+			s.Indexes = append(s.Indexes, Index{Name: name, Table: table, RootPage: root})
+		}
+		return true
+	})
+	return s, err
+}
+
+// Internal reports whether a table is one SQLite maintains itself
+// (sqlite_sequence, sqlite_stat1 and the like) rather than one holding data.
+func (t Table) Internal() bool {
+	return strings.HasPrefix(strings.ToLower(t.Name), "sqlite_")
+}
+
+// ColumnName returns the name of column i, or "column<i+1>" for a column the
+// statement did not name: one added to the record by a later ALTER TABLE the
+// parser missed, or any column of a table created with AS SELECT.
+func (t Table) ColumnName(i int) string {
+	if i < len(t.Columns) {
+		return t.Columns[i]
+	}
+	return "column" + strconv.Itoa(i+1)
+}
+
+// tableConstraints are the words that open a table constraint rather than a
+// column definition.
+var tableConstraints = map[string]bool{
+	"CONSTRAINT": true, "PRIMARY": true, "UNIQUE": true, "CHECK": true, "FOREIGN": true,
+}
+
+// parseCreateTable reads the column names of a CREATE TABLE statement, which
+// rowid-aliasing column there is, and whether the table is WITHOUT ROWID.
+func parseCreateTable(sql string) Table {
+	t := Table{RowIDColumn: -1}
+	toks := tokenize(sql)
+
+	open := -1
+	for i, tok := range toks {
+		if tok == "(" {
+			open = i
+			break
+		}
+		if strings.EqualFold(tok, "AS") {
+			return t // CREATE TABLE ... AS SELECT: no column list
+		}
+	}
+	if open < 0 {
+		return t
+	}
+
+	var defs [][]string
+	var cur []string
+	depth := 0
+	end := len(toks)
+	for i := open + 1; i < len(toks); i++ {
+		tok := toks[i]
+		switch {
+		case tok == "(":
+			depth++
+		case tok == ")" && depth == 0:
+			end = i
+		case tok == ")":
+			depth--
+		case tok == "," && depth == 0:
+			defs = append(defs, cur)
+			cur = nil
+			continue
+		}
+		if end != len(toks) {
+			break
+		}
+		cur = append(cur, tok)
+	}
+	if len(cur) > 0 {
+		defs = append(defs, cur)
+	}
+
+	types := map[string]string{}
+	var tablePK []string
+	columnPK := -1
+	for _, def := range defs {
+		if len(def) == 0 {
+			continue
+		}
+		word := strings.ToUpper(def[0])
+		if tableConstraints[word] {
+			if pk := primaryKeyColumns(def); pk != nil {
+				tablePK = pk
+			}
+			continue
+		}
+		name := unquote(def[0])
+		declType := ""
+		if len(def) > 1 && isWord(def[1]) {
+			declType = strings.ToUpper(def[1])
+		}
+		types[strings.ToLower(name)] = declType
+		if pk, desc := primaryKeyClause(def[1:]); pk {
+			columnPK = len(t.Columns)
+			if declType == "INTEGER" && !desc {
+				t.RowIDColumn = columnPK
+			}
+		}
+		t.Columns = append(t.Columns, name)
+	}
+	if columnPK >= 0 {
+		t.primaryKey = []int{columnPK}
+	}
+	for _, name := range tablePK {
+		for i, c := range t.Columns {
+			if strings.EqualFold(c, name) {
+				t.primaryKey = append(t.primaryKey, i)
+			}
+		}
+	}
+	if t.RowIDColumn < 0 && len(tablePK) == 1 && len(t.primaryKey) == 1 && types[strings.ToLower(tablePK[0])] == "INTEGER" {
+		t.RowIDColumn = t.primaryKey[0]
+	}
+
+	for i := end + 1; i+1 < len(toks); i++ {
+		if strings.EqualFold(toks[i], "WITHOUT") && strings.EqualFold(toks[i+1], "ROWID") {
+			t.WithoutRowID = true
+			t.RowIDColumn = -1
+		}
+	}
+	return t
+}
+
+// primaryKeyClause reports whether a column definition's constraints declare
+// it the primary key, and whether in descending order: "INTEGER PRIMARY KEY
+// DESC" is, for historical reasons, not a rowid alias.
+func primaryKeyClause(def []string) (pk, desc bool) {
+	for i := 0; i+1 < len(def); i++ {
+		if strings.EqualFold(def[i], "PRIMARY") && strings.EqualFold(def[i+1], "KEY") {
+			return true, i+2 < len(def) && strings.EqualFold(def[i+2], "DESC")
+		}
+	}
+	return false, false
+}
+
+// recordColumns maps each field of a stored record to its column. A rowid
+// table stores its columns in declaration order; a WITHOUT ROWID table stores
+// the primary key columns first, in key order, then the rest in declaration
+// order.
+func (t Table) recordColumns(fields int) []int {
+	out := make([]int, 0, max(fields, len(t.Columns)))
+	if t.WithoutRowID && len(t.primaryKey) > 0 {
+		inKey := make(map[int]bool, len(t.primaryKey))
+		for _, c := range t.primaryKey {
+			if !inKey[c] {
+				inKey[c] = true
+				out = append(out, c)
+			}
+		}
+		for c := range t.Columns {
+			if !inKey[c] {
+				out = append(out, c)
+			}
+		}
+	} else {
+		for c := range t.Columns {
+			out = append(out, c)
+		}
+	}
+	for c := len(t.Columns); len(out) < fields; c++ {
+		out = append(out, c)
+	}
+	return out
+}
+
+// primaryKeyColumns returns the columns of a PRIMARY KEY table constraint, or
+// nil for any other constraint.
+func primaryKeyColumns(def []string) []string {
+	for i := 0; i+2 < len(def); i++ {
+		if !strings.EqualFold(def[i], "PRIMARY") || !strings.EqualFold(def[i+1], "KEY") || def[i+2] != "(" {
+			continue
+		}
+		var cols []string
+		expectName := true
+		for _, tok := range def[i+3:] {
+			switch {
+			case tok == ")":
+				return cols
+			case tok == ",":
+				expectName = true
+			case expectName:
+				cols = append(cols, unquote(tok))
+				expectName = false
+			}
+		}
+		return cols
+	}
+	return nil
+}
+
+// tokenize splits SQL into words, quoted names and strings, and single
+// punctuation characters, dropping whitespace and comments.
+func tokenize(sql string) []string {
+	var toks []string
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f':
+			i++
+		case c == '-' && strings.HasPrefix(sql[i:], "--"):
+			if j := strings.IndexByte(sql[i:], '\n'); j >= 0 {
+				i += j + 1
+			} else {
+				i = len(sql)
+			}
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			if j := strings.Index(sql[i+2:], "*/"); j >= 0 {
+				i += j + 4
+			} else {
+				i = len(sql)
+			}
+		case c == '"' || c == '\'' || c == '`' || c == '[':
+			closer := c
+			if c == '[' {
+				closer = ']'
+			}
+			j := i + 1
+			for j < len(sql) {
+				if sql[j] == closer {
+					// A doubled quote is the quote character itself.
+					if closer != ']' && j+1 < len(sql) && sql[j+1] == closer {
+						j += 2
+						continue
+					}
+					break
+				}
+				j++
+			}
+			end := min(j+1, len(sql))
+			toks = append(toks, sql[i:end])
+			i = end
+		case isWordByte(c):
+			j := i
+			for j < len(sql) && isWordByte(sql[j]) {
+				j++
+			}
+			toks = append(toks, sql[i:j])
+			i = j
+		default:
+			toks = append(toks, sql[i:i+1])
+			i++
+		}
+	}
+	return toks
+}
+
+// unquote strips the quotes from a quoted name.
+func unquote(tok string) string {
+	if len(tok) < 2 {
+		return tok
+	}
+	switch open, last := tok[0], tok[len(tok)-1]; {
+	case open == '[' && last == ']':
+		return tok[1 : len(tok)-1]
+	case (open == '"' || open == '`' || open == '\'') && last == open:
+		q := string(open)
+		return strings.ReplaceAll(tok[1:len(tok)-1], q+q, q)
+	}
+	return tok
+}
+
+func isWord(tok string) bool {
+	return tok != "" && isWordByte(tok[0])
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c == '$' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
@@ -0,0 +1,531 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package textextractsqlitelib reads the tables of an SQLite database file.
+//
+// It is a reader of the file format (https://www.sqlite.org/fileformat.html),
+// not a database engine: it walks the b-trees the schema names and decodes
+// their records, and knows nothing of SQL beyond the column list of a CREATE
+// TABLE statement. That is all a scan needs, it keeps the build free of cgo,
+// and it can say exactly which bytes of the file each value was read from,
+// which is what lets the SQLite redactor overwrite a value in place.
+//
+// Pages are read one at a time from an io.ReaderAt, so a database is never
+// held in memory whole. Every page is visited at most once per walk and every
+// overflow chain is bounded by the page count, so a damaged or hostile file
+// costs no more than reading it once. A b-tree that cannot be read is reported as an error for
+// that table; the tables that can be read still are.
+package textextractsqlitelib
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Magic is the header string every SQLite 3 database file begins with.
+const Magic = "SQLite format 3\x00"
+
+const (
+	headerSize = 100
+
+	pageIndexInterior = 0x02
+	pageTableInterior = 0x05
+	pageIndexLeaf     = 0x0A
+	pageTableLeaf     = 0x0D
+
+	// maxDepth bounds b-tree recursion. A real database of any size is a few
+	// levels deep; a page that points back up its own path is caught by the
+	// visited set first, and this stops a merely very deep chain.
+	maxDepth = 64
+)
+
+// TextEncoding is the encoding a database stores its text values in.
+type TextEncoding int
+
+// The text encodings of the database header.
+const (
+	UTF8 TextEncoding = iota + 1
+	UTF16LE
+	UTF16BE
+)
+
+// ValueKind is the storage class of a value.
+type ValueKind int
+
+// The storage classes a record value can have.
+const (
+	Null ValueKind = iota
+	Integer
+	Real
+	Text
+	Blob
+)
+
+// Span is a run of bytes of the database file. A value stored partly on its
+// b-tree page and partly on overflow pages has a span per page.
+type Span struct {
+	Offset int
+	Length int
+}
+
+// Value is one field of a record.
+type Value struct {
+	Kind  ValueKind
+	Int   int64
+	Float float64
+	// Text holds a Text value decoded to UTF-8, and a Blob value's bytes.
+	Text string
+	// Spans locate the value's stored bytes in the file, in order.
+	Spans []Span
+	// Tree names the table or index whose b-tree the value was read from, and
+	// Key marks a value that orders it: any field of an index entry, and the
+	// primary key fields of a WITHOUT ROWID table. Only Values sets them.
+	Tree string
+	Key  bool
+}
+
+// String renders the value the way the sqlite3 shell prints it: NULL as
+// nothing, numbers in decimal, text as itself. A blob is rendered only when its
+// bytes are text (a JSON document, say); anything else renders as nothing.
+func (v Value) String() string {
+	switch v.Kind {
+	case Integer:
+		return strconv.FormatInt(v.Int, 10)
+	case Real:
+		s := strconv.FormatFloat(v.Float, 'g', -1, 64)
+		if !strings.ContainsAny(s, ".eIN") {
+			s += ".0"
+		}
+		return s
+	case Text:
+		return v.Text
+	case Blob:
+		if utf8.ValidString(v.Text) && !bytes.ContainsRune([]byte(v.Text), 0) {
+			return v.Text
+		}
+	}
+	return ""
+}
+
+// Database is an SQLite database file, read a page at a time.
+type Database struct {
+	src       io.ReaderAt
+	size      int64
+	pageSize  int
+	usable    int
+	pageCount int
+	encoding  TextEncoding
+}
+
+// ErrNotSQLite is returned by Open for a file without the SQLite header.
+var ErrNotSQLite = errors.New("not an SQLite 3 database")
+
+// IsSQLite reports whether data begins with the SQLite header.
+func IsSQLite(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(Magic))
+}
+
+// Open reads the header of an SQLite database file held in memory.
+func Open(data []byte) (*Database, error) {
+	return OpenReader(bytes.NewReader(data), int64(len(data)))
+}
+
+// OpenReader reads the header of an SQLite database file of size bytes read
+// through src. Pages are read as they are needed, and not kept.
+func OpenReader(src io.ReaderAt, size int64) (*Database, error) {
+	data := make([]byte, headerSize)
+	if _, err := src.ReadAt(data, 0); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if !IsSQLite(data) || size < headerSize {
+		return nil, ErrNotSQLite
+	}
+	pageSize := int(binary.BigEndian.Uint16(data[16:18]))
+	if pageSize == 1 {
+		pageSize = 65536
+	}
+	if pageSize < 512 || pageSize&(pageSize-1) != 0 {
+		return nil, fmt.Errorf("invalid page size %d", pageSize)
+	}
+	usable := pageSize - int(data[20])
+	if usable < 480 {
+		return nil, fmt.Errorf("invalid reserved space %d", data[20])
+	}
+
+	// The header's page count is only valid when the change counter matches
+	// the version-valid-for number; the file length is the fallback the
+	// library itself uses.
+	pageCount := int(min(size/int64(pageSize), math.MaxInt32))
+	if n := int(binary.BigEndian.Uint32(data[28:32])); n > 0 && n <= pageCount &&
+		binary.BigEndian.Uint32(data[24:28]) == binary.BigEndian.Uint32(data[92:96]) {
+		pageCount = n
+	}
+	if pageCount < 1 {
+		return nil, errors.New("truncated database")
+	}
+
+	enc := TextEncoding(binary.BigEndian.Uint32(data[56:60]))
+	if enc == 0 {
+		enc = UTF8
+	}
+	if enc < UTF8 || enc > UTF16BE {
+		return nil, fmt.Errorf("invalid text encoding %d", enc)
+	}
+	return &Database{src: src, size: size, pageSize: pageSize, usable: usable, pageCount: pageCount, encoding: enc}, nil
+}
+
+// Encoding returns the encoding the database stores text in.
+func (db *Database) Encoding() TextEncoding { return db.encoding }
+
+// EncodeText encodes s the way the database stores text.
+func (db *Database) EncodeText(s string) []byte {
+	if db.encoding == UTF8 {
+		return []byte(s)
+	}
+	units := utf16.Encode([]rune(s))
+	out := make([]byte, len(units)*2)
+	for i, u := range units {
+		if db.encoding == UTF16LE {
+			binary.LittleEndian.PutUint16(out[i*2:], u)
+		} else {
+			binary.BigEndian.PutUint16(out[i*2:], u)
+		}
+	}
+	return out
+}
+
+// decodeText decodes stored text to UTF-8.
+func (db *Database) decodeText(b []byte) string {
+	if db.encoding == UTF8 {
+		return string(b)
+	}
+	units := make([]uint16, len(b)/2)
+	for i := range units {
+		if db.encoding == UTF16LE {
+			units[i] = binary.LittleEndian.Uint16(b[i*2:])
+		} else {
+			units[i] = binary.BigEndian.Uint16(b[i*2:])
+		}
+	}
+	return string(utf16.Decode(units))
+}
+
+// page returns the bytes of a 1-based page number.
+func (db *Database) page(n int) ([]byte, error) {
+	if n < 1 || n > db.pageCount {
+		return nil, fmt.Errorf("page %d out of range", n)
+	}
+	p := make([]byte, db.pageSize)
+	if _, err := db.src.ReadAt(p, int64(n-1)*int64(db.pageSize)); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("page %d truncated", n)
+		}
+		return nil, fmt.Errorf("page %d: %w", n, err)
+	}
+	return p, nil
+}
+
+// Entry is one record of a b-tree: a table row with its rowid, or an index
+// entry (whose rowid, for a rowid table's index, is the record's last value).
+type Entry struct {
+	RowID  int64
+	Values []Value
+}
+
+// walker walks one b-tree, visiting each page at most once.
+type walker struct {
+	db      *Database
+	visited map[int]bool
+	visit   func(Entry) bool
+	stopped bool
+}
+
+// WalkTable calls visit for every row of the table b-tree rooted at root, in
+// rowid order, until visit returns false.
+func (db *Database) WalkTable(root int, visit func(Entry) bool) error {
+	w := &walker{db: db, visited: make(map[int]bool), visit: visit}
+	return w.walk(root, false, 0)
+}
+
+// WalkIndex calls visit for every entry of the index b-tree rooted at root,
+// until visit returns false. A WITHOUT ROWID table is stored this way too.
+func (db *Database) WalkIndex(root int, visit func(Entry) bool) error {
+	w := &walker{db: db, visited: make(map[int]bool), visit: visit}
+	return w.walk(root, true, 0)
+}
+
+func (w *walker) walk(n int, index bool, depth int) error {
+	if w.stopped {
+		return nil
+	}
+	if depth > maxDepth {
+		return errors.New("b-tree too deep")
+	}
+	if w.visited[n] {
+		return fmt.Errorf("page %d reached twice", n)
+	}
+	w.visited[n] = true
+
+	p, err := w.db.page(n)
+	if err != nil {
+		return err
+	}
+	hdr := 0
+	if n == 1 {
+		hdr = headerSize
+	}
+	if hdr+8 > len(p) {
+		return fmt.Errorf("page %d truncated", n)
+	}
+	kind := p[hdr]
+	interior := kind == pageTableInterior || kind == pageIndexInterior
+	switch {
+	case !index && (kind == pageTableLeaf || kind == pageTableInterior):
+	case index && (kind == pageIndexLeaf || kind == pageIndexInterior):
+	default:
+		return fmt.Errorf("page %d: unexpected page type 0x%02x", n, kind)
+	}
+	cells := int(binary.BigEndian.Uint16(p[hdr+3:]))
+	ptrs := hdr + 8
+	if interior {
+		ptrs = hdr + 12
+	}
+	if ptrs+2*cells > len(p) {
+		return fmt.Errorf("page %d: cell count %d overruns the page", n, cells)
+	}
+	base := (n - 1) * w.db.pageSize
+
+	for i := 0; i < cells && !w.stopped; i++ {
+		off := int(binary.BigEndian.Uint16(p[ptrs+2*i:]))
+		if off < ptrs || off >= w.db.usable {
+			return fmt.Errorf("page %d: cell %d out of range", n, i)
+		}
+		if interior {
+			if off+4 > len(p) {
+				return fmt.Errorf("page %d: cell %d truncated", n, i)
+			}
+			if err := w.walk(int(binary.BigEndian.Uint32(p[off:])), index, depth+1); err != nil {
+				return err
+			}
+			if !index {
+				continue // a table interior cell holds only a key
+			}
+			off += 4
+		}
+		entry, err := w.db.cell(p[:w.db.usable], base, off, index)
+		if err != nil {
+			return fmt.Errorf("page %d: cell %d: %w", n, i, err)
+		}
+		if !w.visit(entry) {
+			w.stopped = true
+		}
+	}
+	if interior && !w.stopped {
+		return w.walk(int(binary.BigEndian.Uint32(p[hdr+8:])), index, depth+1)
+	}
+	return nil
+}
+
+// cell decodes the record of the cell at off of a page whose first byte is at
+// file offset base.
+func (db *Database) cell(p []byte, base, off int, index bool) (Entry, error) {
+	size, n := varint(p[off:])
+	if n == 0 || size < 0 || size > db.size {
+		return Entry{}, errors.New("bad payload size")
+	}
+	off += n
+	var entry Entry
+	if !index {
+		rowid, n := varint(p[off:])
+		if n == 0 {
+			return Entry{}, errors.New("bad rowid")
+		}
+		entry.RowID = rowid
+		off += n
+	}
+	payload, spans, err := db.payload(p, base, off, int(size), index)
+	if err != nil {
+		return Entry{}, err
+	}
+	entry.Values, err = db.record(payload, spans)
+	if err != nil {
+		return Entry{}, err
+	}
+	if index && len(entry.Values) > 0 && entry.Values[len(entry.Values)-1].Kind == Integer {
+		entry.RowID = entry.Values[len(entry.Values)-1].Int
+	}
+	return entry, nil
+}
+
+// payload gathers a cell's payload from its page and its overflow chain,
+// with the file spans it was gathered from.
+func (db *Database) payload(p []byte, base, off, size int, index bool) ([]byte, []Span, error) {
+	u := db.usable
+	maxLocal := u - 35
+	if index {
+		maxLocal = (u-12)*64/255 - 23
+	}
+	local := size
+	if size > maxLocal {
+		minLocal := (u-12)*32/255 - 23
+		local = minLocal + (size-minLocal)%(u-4)
+		if local > maxLocal {
+			local = minLocal
+		}
+	}
+	if off+local > len(p) {
+		return nil, nil, errors.New("payload overruns the page")
+	}
+	payload := make([]byte, 0, size)
+	payload = append(payload, p[off:off+local]...)
+	spans := []Span{{Offset: base + off, Length: local}}
+	if local == size {
+		return payload, spans, nil
+	}
+
+	if off+local+4 > len(p) {
+		return nil, nil, errors.New("overflow pointer overruns the page")
+	}
+	next := int(binary.BigEndian.Uint32(p[off+local:]))
+	for hops := 0; len(payload) < size; hops++ {
+		if next == 0 || hops >= db.pageCount {
+			return nil, nil, errors.New("overflow chain broken")
+		}
+		op, err := db.page(next)
+		if err != nil {
+			return nil, nil, err
+		}
+		take := min(size-len(payload), u-4)
+		payload = append(payload, op[4:4+take]...)
+		spans = append(spans, Span{Offset: (next-1)*db.pageSize + 4, Length: take})
+		next = int(binary.BigEndian.Uint32(op))
+	}
+	return payload, spans, nil
+}
+
+// record decodes a record. spans locate the payload in the file, and each
+// value is given the spans of its own bytes.
+func (db *Database) record(payload []byte, spans []Span) ([]Value, error) {
+	hdrLen, n := varint(payload)
+	if n == 0 || hdrLen < int64(n) || hdrLen > int64(len(payload)) {
+		return nil, errors.New("bad record header")
+	}
+	var types []int64
+	for pos := n; pos < int(hdrLen); {
+		t, n := varint(payload[pos:int(hdrLen)])
+		if n == 0 || t < 0 || t == 10 || t == 11 {
+			return nil, errors.New("bad serial type")
+		}
+		types = append(types, t)
+		pos += n
+	}
+
+	values := make([]Value, 0, len(types))
+	pos := int(hdrLen)
+	for _, t := range types {
+		width := serialWidth(t)
+		if pos+width > len(payload) {
+			return nil, errors.New("record overruns its payload")
+		}
+		b := payload[pos : pos+width]
+		v := Value{Spans: subSpans(spans, pos, width)}
+		switch {
+		case t == 0:
+			v.Kind = Null
+		case t == 7:
+			v.Kind = Real
+			v.Float = math.Float64frombits(binary.BigEndian.Uint64(b))
+		case t == 8 || t == 9:
+			v.Kind = Integer
+			v.Int = t - 8
+		case t < 7:
+			v.Kind = Integer
+			v.Int = bigEndianInt(b)
+		case t%2 == 0:
+			v.Kind = Blob
+			v.Text = string(b)
+		default:
+			v.Kind = Text
+			v.Text = db.decodeText(b)
+		}
+		values = append(values, v)
+		pos += width
+	}
+	return values, nil
+}
+
+// serialWidth is the byte width of a value of serial type t.
+func serialWidth(t int64) int {
+	switch {
+	case t >= 12:
+		return int((t - 12) / 2)
+	case t == 7:
+		return 8
+	case t >= 1 && t <= 4:
+		return int(t)
+	case t == 5:
+		return 6
+	case t == 6:
+		return 8
+	}
+	return 0
+}
+
+// bigEndianInt decodes a big-endian two's complement integer of 1 to 8 bytes.
+func bigEndianInt(b []byte) int64 {
+	var v int64
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		v = -1
+	}
+	for _, c := range b {
+		v = v<<8 | int64(c)
+	}
+	return v
+}
+
+// subSpans returns the file spans of bytes [at, at+n) of a payload gathered
+// from spans.
+func subSpans(spans []Span, at, n int) []Span {
+	var out []Span
+	for _, s := range spans {
+		if n == 0 {
+			break
+		}
+		if at >= s.Length {
+			at -= s.Length
+			continue
+		}
+		take := min(n, s.Length-at)
+		out = append(out, Span{Offset: s.Offset + at, Length: take})
+		n -= take
+		at = 0
+	}
+	return out
+}
+
+// varint decodes an SQLite variable-length integer and returns it with the
+// number of bytes read, or 0 bytes when b is too short.
+func varint(b []byte) (int64, int) {
+	var v uint64
+	for i := 0; i < 9; i++ {
+		if i >= len(b) {
+			return 0, 0
+		}
+		if i == 8 {
+			return int64(v<<8 | uint64(b[i])), 9
+		}
+		v = v<<7 | uint64(b[i]&0x7f)
+		if b[i]&0x80 == 0 {
+			return int64(v), i + 1
+		}
+	}
+	return 0, 0
+}
@@ -0,0 +1,185 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package textextractsqlitelib
+
+import (
+	"encoding/binary"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/awslabs/ferret-scan/v2/internal/sqlitefixture"
+)
+
+// usersDB is a table spread over several leaf pages under an interior page,
+// with one value long enough to spill into an overflow chain, and an index.
+func usersDB(t *testing.T) []byte {
+	t.Helper()
+	var rows [][]any
+	for i := 1; i <= 30; i++ {
+		rows = append(rows, []any{nil, "Jane Doe", "536-22-1874", i * 10, 1.5})
+	}
+	rows[29][1] = strings.Repeat("note ", 400) + "SSN 123-45-6789"
+	return sqlitefixture.MustBuild(1024, sqlitefixture.UTF8, []sqlitefixture.Table{
+		{Name: "users", SQL: `CREATE TABLE users(id INTEGER PRIMARY KEY, "full name" TEXT, ssn TEXT, age INT, score REAL)`, Rows: rows},
+		{Name: "empty", SQL: "CREATE TABLE empty(a, b, c)"},
+	}, []sqlitefixture.Index{{Name: "users_age", Table: "users", SQL: "CREATE INDEX users_age ON users(age)", Column: 3}})
+}
+
+func TestExtractTables(t *testing.T) {
+	ex, err := Extract(usersDB(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ex.Tables) != 1 || len(ex.Notes) != 0 {
+		t.Fatalf("tables = %d, notes = %v", len(ex.Tables), ex.Notes)
+	}
+	tt := ex.Tables[0]
+	lines := strings.Split(tt.Text, "\n")
+	if lines[0] != "--- users ---" || lines[1] != "id\tfull name\tssn\tage\tscore" {
+		t.Errorf("heading and header = %q, %q", lines[0], lines[1])
+	}
+	// The rowid alias is stored as NULL and reads as the rowid.
+	if want := "7\tJane Doe\t536-22-1874\t70\t1.5"; lines[8] != want {
+		t.Errorf("row 7 = %q, want %q", lines[8], want)
+	}
+	if len(tt.Rows) != 30 {
+		t.Fatalf("rows = %d, want 30", len(tt.Rows))
+	}
+	r := tt.Rows[6]
+	if r.Label != "users[rowid=7]" || r.Line != 8 || !reflect.DeepEqual(r.Offsets, []int{0, 2, 11, 23, 26}) {
+		t.Errorf("row 7 located as %+v", r)
+	}
+	// The overflow chain is followed to the end of the value.
+	if !strings.HasSuffix(lines[31], "SSN 123-45-6789\t536-22-1874\t300\t1.5") {
+		t.Errorf("overflowing row = ...%q", lines[31][len(lines[31])-40:])
+	}
+}
+
+// A value's spans are the bytes it was read from, so writing to them changes
+// the value and nothing else.
+func TestValueSpansLocateStoredBytes(t *testing.T) {
+	data := usersDB(t)
+	db, err := Open(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var long Value
+	if err := db.Values(func(v Value) {
+		if strings.HasSuffix(v.Text, "123-45-6789") {
+			long = v
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(long.Spans) < 2 {
+		t.Fatalf("overflowing value has %d spans", len(long.Spans))
+	}
+	var stored []byte
+	for _, s := range long.Spans {
+		stored = append(stored, data[s.Offset:s.Offset+s.Length]...)
+	}
+	if string(stored) != long.Text {
+		t.Error("spans do not hold the value's bytes")
+	}
+
+	last := long.Spans[len(long.Spans)-1]
+	copy(data[last.Offset+last.Length-4:], "XXXX")
+	ex, err := Extract(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(ex.Text, "SSN 123-45-XXXX\t") {
+		t.Error("write through the spans did not change the value")
+	}
+}
+
+func TestExtractUTF16(t *testing.T) {
+	data := sqlitefixture.MustBuild(512, sqlitefixture.UTF16LE, []sqlitefixture.Table{
+		{Name: "people", SQL: "CREATE TABLE people(name, ssn, city)", Rows: [][]any{{"Zoë", "536-22-1874", "Köln"}}},
+	}, nil)
+	ex, err := Extract(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "--- people ---\nname\tssn\tcity\nZoë\t536-22-1874\tKöln\n"; ex.Text != want {
+		t.Errorf("got %q, want %q", ex.Text, want)
+	}
+}
+
+func TestParseCreateTable(t *testing.T) {
+	tests := []struct {
+		sql     string
+		columns []string
+		rowid   int
+		without bool
+		order   []int
+	}{
+		{"CREATE TABLE t(a INTEGER PRIMARY KEY, b TEXT)", []string{"a", "b"}, 0, false, []int{0, 1}},
+		{"CREATE TABLE t(a INTEGER PRIMARY KEY DESC, b)", []string{"a", "b"}, -1, false, []int{0, 1}},
+		{"CREATE TABLE t(a INT PRIMARY KEY, b)", []string{"a", "b"}, -1, false, []int{0, 1}},
+		{"CREATE TABLE t(x, id INTEGER, PRIMARY KEY(id))", []string{"x", "id"}, 1, false, []int{0, 1}},
+		{`CREATE TABLE "my ""t"" (1)" ([first name] VARCHAR(20) DEFAULT ('a,b'), "ssn" TEXT -- note, here
+			, /* c, d */ CONSTRAINT u UNIQUE (ssn), ` + "`e`" + ` CHECK (length(e) > 0))`,
+			[]string{"first name", "ssn", "e"}, -1, false, []int{0, 1, 2}},
+		{"CREATE TABLE kv(k TEXT, grp INT, v TEXT, PRIMARY KEY(grp, k)) WITHOUT ROWID", []string{"k", "grp", "v"}, -1, true, []int{1, 0, 2}},
+		{"CREATE TABLE s AS SELECT 1 AS a", nil, -1, false, nil},
+	}
+	for _, tc := range tests {
+		got := parseCreateTable(tc.sql)
+		if !reflect.DeepEqual(got.Columns, tc.columns) || got.RowIDColumn != tc.rowid || got.WithoutRowID != tc.without {
+			t.Errorf("%s:\n got columns %q rowid %d without %v", tc.sql, got.Columns, got.RowIDColumn, got.WithoutRowID)
+		}
+		if order := got.recordColumns(len(tc.columns)); len(tc.columns) > 0 && !reflect.DeepEqual(order, tc.order) {
+			t.Errorf("%s: record order %v, want %v", tc.sql, order, tc.order)
+		}
+	}
+}
+
+// A table whose b-tree is damaged is disclosed, and the others are still read.
+func TestDamagedTableIsNoted(t *testing.T) {
+	data := sqlitefixture.MustBuild(512, sqlitefixture.UTF8, []sqlitefixture.Table{
+		{Name: "bad", SQL: "CREATE TABLE bad(a, b, c)", Rows: [][]any{{"x", "y", "z"}}},
+		{Name: "good", SQL: "CREATE TABLE good(a, b, c)", Rows: [][]any{{"536-22-1874", "y", "z"}}},
+	}, nil)
+	data[512] = 0x42 // page 2, the root of "bad"
+	ex, err := Extract(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ex.Notes) != 1 || !strings.HasPrefix(ex.Notes[0], "table bad: ") {
+		t.Errorf("notes = %q", ex.Notes)
+	}
+	if !strings.Contains(ex.Text, "536-22-1874") {
+		t.Errorf("the readable table was not extracted: %q", ex.Text)
+	}
+}
+
+// An interior page that points at itself ends the walk with an error rather
+// than recursing.
+func TestCyclicBTreeTerminates(t *testing.T) {
+	var rows [][]any
+	for i := 0; i < 40; i++ {
+		rows = append(rows, []any{"a value long enough to need several pages"})
+	}
+	data := sqlitefixture.MustBuild(512, sqlitefixture.UTF8, []sqlitefixture.Table{{Name: "t", SQL: "CREATE TABLE t(a, b, c)", Rows: rows}}, nil)
+	root := 512 // page 2, the interior root
+	if data[root] != pageTableInterior {
+		t.Fatalf("fixture root is page type 0x%02x", data[root])
+	}
+	binary.BigEndian.PutUint32(data[root+8:], 2)
+	ex, err := Extract(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ex.Notes) != 1 || !strings.Contains(ex.Notes[0], "reached twice") {
+		t.Errorf("notes = %q", ex.Notes)
+	}
+}
+
+func TestOpenRejectsOtherFiles(t *testing.T) {
+	if _, err := Open([]byte("SQLite format 2\x00 and some more bytes")); err != ErrNotSQLite {
+		t.Errorf("err = %v", err)
+	}
+}
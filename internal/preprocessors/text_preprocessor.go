@@ -145,6 +145,31 @@ func pdfExtractionWarning(filePath string, err error) string {
 		filepath.Ext(filePath), detail)
 }
 
+// pdfPageCoverageWarning discloses pages of a PDF that yielded text elsewhere
+// but were themselves not scanned: pages the library could not read, and pages
+// past the extractor's text cap. Empty when every page was read.
+//
+// A page used to be dropped silently in both cases — a failed page was counted
+// and the count discarded, and pages 51 onwards were never opened — so a long
+// statement reported clean on the strength of its first fifty pages. Neither
+// message starts with the no-text prefix, so both classify as coverage cut
+// short: the file was scanned, just not all of it.
+func pdfPageCoverageWarning(filePath string, pdfContent *textextractpdftextlib.TextContent) string {
+	var notes []string
+	if pdfContent.FailedPages > 0 {
+		notes = append(notes, fmt.Sprintf(
+			"%d of %d pages in %s could not be read and were NOT scanned",
+			pdfContent.FailedPages, pdfContent.PageCount, filepath.Ext(filePath)))
+	}
+	if n := pdfContent.PagesNotExtracted; n > 0 {
+		notes = append(notes, fmt.Sprintf(
+			"pages %d-%d of %d in %s were NOT scanned: extracted text reached the %dMB per-document limit",
+			pdfContent.PageCount-n+1, pdfContent.PageCount, pdfContent.PageCount,
+			filepath.Ext(filePath), textextractpdftextlib.MaxTotalTextBytes/(1024*1024)))
+	}
+	return strings.Join(notes, "; ")
+}
+
 // processPDF extracts text from PDF documents
 func (tp *TextPreprocessor) processPDF(filePath string, content *ProcessedContent) (*ProcessedContent, error) {
 	pdfContent, err := textextractpdftextlib.ExtractText(filePath)
@@ -190,7 +215,23 @@ func (tp *TextPreprocessor) processPDF(filePath string, content *ProcessedConten
 		content.ExtractionWarning = fmt.Sprintf(
 			"no text extracted from %s: the file parsed but held no document text, "+
 				"so page content was NOT scanned", filepath.Ext(filePath))
+	} else {
+		content.ExtractionWarning = pdfPageCoverageWarning(filePath, pdfContent)
+	}
+
+	// Declare the body section ourselves so it can carry the page boundaries.
+	// The router would otherwise build the same section without them; every
+	// other field matches what it fills in for an undeclared preprocessor.
+	pages := make([]PageSpan, len(pdfContent.Pages))
+	for i, p := range pdfContent.Pages {
+		pages[i] = PageSpan{Page: p.Page, Line: p.Line}
 	}
+	content.Sections = []ContentSection{{
+		Name:  tp.name,
+		Kind:  SectionKindBody,
+		Text:  content.Text,
+		Pages: pages,
+	}}
 
 	content.Success = true
 
@@ -232,6 +273,22 @@ func (tp *TextPreprocessor) processOffice(filePath string, content *ProcessedCon
 	content.ExtractionWarning = officeContent.ExtractionWarning
 	content.Success = true
 
+	// A spreadsheet declares its body section itself so the cell locations
+	// travel with it, as processPDF does for page boundaries; a document with
+	// comments, tracked deletions or hidden sheets and slides declares one
+	// section per origin so each is reported as a source of its own.
+	if len(officeContent.Cells) > 0 || len(officeContent.Origins) > 0 {
+		cells := make([]CellLine, len(officeContent.Cells))
+		for i, cl := range officeContent.Cells {
+			pos := make([]CellPos, len(cl.Cells))
+			for j, c := range cl.Cells {
+				pos[j] = CellPos{Offset: c.Offset, Row: c.Row, Column: c.Column}
+			}
+			cells[i] = CellLine{Line: cl.Line, Sheet: cl.Sheet, Cells: pos}
+		}
+		content.Sections = tp.officeSections(filePath, content.Text, cells, officeContent.Origins)
+	}
+
 	// Enable position tracking for Office documents
 	content.EnablePositionTracking()
 	content.SetPositionConfidence(0.7) // Moderate confidence for Office text extraction
@@ -242,11 +299,90 @@ func (tp *TextPreprocessor) processOffice(filePath string, content *ProcessedCon
 	return content, nil
 }
 
+// officeSections splits an Office document's text into one body section per
+// origin span. Ordinary body text keeps the scanned file as its source; a
+// comment, the tracked deletions, a hidden sheet or a hidden slide is labelled
+// like a PDF's form field ("memo.docx -> comment:3") and sets AttributeBody, so
+// the label reaches document-path findings. Cells go to the section holding
+// their line, relative to it.
+func (tp *TextPreprocessor) officeSections(filePath, text string, cells []CellLine, origins []textextractofficetextlib.OriginSpan) []ContentSection {
+	type span struct {
+		line   int
+		source string
+	}
+	spans := []span{{line: 0}}
+	helper := NewRouterIntegrationHelper()
+	for _, o := range origins {
+		var source string
+		if o.Origin != textextractofficetextlib.OriginBody {
+			kind := o.Origin
+			if o.Name != "" {
+				kind += ":"
+			}
+			source = helper.CreateItemPath(filePath, kind, o.Name)
+		}
+		if last := &spans[len(spans)-1]; last.line == o.Line {
+			last.source = source
+		} else if o.Line > last.line {
+			spans = append(spans, span{line: o.Line, source: source})
+		}
+	}
+
+	// Byte offset of each span's first line, found in one pass over the text.
+	starts := make([]int, len(spans))
+	next, line := 1, 0
+	for i := 0; i < len(text) && next < len(spans); i++ {
+		if text[i] != '\n' {
+			continue
+		}
+		line++
+		for next < len(spans) && spans[next].line == line {
+			starts[next] = i + 1
+			next++
+		}
+	}
+	for ; next < len(spans); next++ {
+		starts[next] = len(text)
+	}
+
+	sections := make([]ContentSection, 0, len(spans))
+	c := 0
+	for i, sp := range spans {
+		end, endLine := len(text), -1
+		if i+1 < len(spans) {
+			end, endLine = starts[i+1], spans[i+1].line
+		}
+		section := ContentSection{
+			Name:          tp.name,
+			Kind:          SectionKindBody,
+			SourceFile:    sp.source,
+			Text:          text[starts[i]:end],
+			LineOffset:    sp.line,
+			AttributeBody: sp.source != "",
+		}
+		for ; c < len(cells) && (endLine < 0 || cells[c].Line < endLine); c++ {
+			cl := cells[c]
+			cl.Line -= sp.line
+			section.Cells = append(section.Cells, cl)
+		}
+		if section.Text != "" {
+			sections = append(sections, section)
+		}
+	}
+	return sections
+}
+
 // createPDFPositionMappings creates position mappings for PDF content
 func (tp *TextPreprocessor) createPDFPositionMappings(content *ProcessedContent, _ any) {
-	// For now, create basic line-based mappings
-	// In a real implementation, this would use the PDF structure information
+	// Line-based mappings, with the page taken from the extractor's recorded
+	// page boundaries rather than estimated by spreading lines evenly.
 	tp.createBasicLineMappings(content, "pdf_extraction")
+	if pageOf := content.PageLookup(); pageOf != nil {
+		for i := range content.PositionMappings {
+			m := &content.PositionMappings[i]
+			m.OriginalPosition.Page = pageOf(m.ExtractedPosition.Line)
+		}
+	}
 
 	// Add PDF-specific metadata
 	content.AddPositionMetadata("extraction_method", "pdf_text_extraction")
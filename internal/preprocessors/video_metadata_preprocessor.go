@@ -4,6 +4,8 @@
 package preprocessors
 
 import (
+	"strings"
+
 	"github.com/awslabs/ferret-scan/v2/internal/observability"
 	meta_extract_videolib "github.com/awslabs/ferret-scan/v2/internal/preprocessors/meta-extractors/meta-extract-videolib"
 )
@@ -54,6 +56,7 @@ func (vmp *VideoMetadataPreprocessor) processVideoMetadata(filePath string) (*Pr
 	if err != nil {
 		return vmp.HandleError(filePath, "video", err), err
 	}
+	defer meta_extract_videolib.CleanupAttachments(meta)
 
 	// Log file system information for observability (excluded from validator content)
 	vmp.LogFileSystemInfo(meta.Filename, meta.FileSize, meta.MimeType)
@@ -64,7 +67,44 @@ func (vmp *VideoMetadataPreprocessor) processVideoMetadata(filePath string) (*Pr
 	// Convert video metadata to text format for validation
 	text := meta.ToProcessedContent()
 
+	// A Matroska file's attachments route back through the router like an email's, so an
+	// attached image or text file is scanned by its own extractor and labelled
+	// "recording.mkv -> notes.txt". The video's own text is declared as a section first so
+	// the embedded sections that follow it keep their own rule sets.
+	var sections []ContentSection
+	var embeddedWarnings []string
+	if len(meta.Attachments) > 0 {
+		media := make([]EmbeddedMedia, len(meta.Attachments))
+		for i, a := range meta.Attachments {
+			media[i] = EmbeddedMedia{OriginalName: a.Name, TempFilePath: a.TempFilePath, MediaType: "attachment"}
+		}
+		embeddedText, embeddedSections, warnings := vmp.ProcessEmbeddedMedia(filePath, media)
+		embeddedWarnings = warnings
+		if embeddedText != "" {
+			sections = append(sections, ContentSection{
+				Name:       vmp.GetName(),
+				Kind:       SectionKindMetadata,
+				Type:       ProcessorTypeVideoMetadata,
+				SourceFile: filePath,
+				Text:       text,
+				LineOffset: 0,
+			})
+			shift := strings.Count(text, "\n")
+			for _, s := range embeddedSections {
+				// Attributed like an email attachment: no finding was ever recorded against an
+				// attachment of a video, so labelling it moves no suppression.
+				s.LineOffset += shift
+				s.AttributeBody = true
+				sections = append(sections, s)
+			}
+			text += embeddedText
+		}
+	}
+
 	content := vmp.BuildSuccessContent(filePath, text, "video_metadata", 0)
+	if content != nil && len(sections) > 0 {
+		content.Sections = sections
+	}
 
 	// Carry an extraction caveat forward, so a file whose box layout could not be followed to the
 	// end is not reported as clean.
@@ -77,6 +117,12 @@ func (vmp *VideoMetadataPreprocessor) processVideoMetadata(filePath string) (*Pr
 	if content != nil && meta.ExtractionWarning != "" {
 		content.ExtractionWarning = meta.ExtractionWarning
 	}
+	if content != nil && len(embeddedWarnings) > 0 {
+		if content.ExtractionWarning != "" {
+			content.ExtractionWarning += "; "
+		}
+		content.ExtractionWarning += strings.Join(embeddedWarnings, "; ")
+	}
 	return content, nil
 }
 
@@ -85,7 +131,7 @@ func (vmp *VideoMetadataPreprocessor) SetObserver(observer observability.Observe
 	vmp.BaseMetadataPreprocessor.SetObserver(observer)
 }
 
-// SetRouter sets the router instance (not used for video metadata but required by interface)
+// SetRouter sets the router instance for routing Matroska attachments
 func (vmp *VideoMetadataPreprocessor) SetRouter(router RouterInterface) {
 	vmp.BaseMetadataPreprocessor.SetRouter(router)
 }
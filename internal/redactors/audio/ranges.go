@@ -6,8 +6,10 @@ package audio
 import (
 	"bytes"
 	"encoding/binary"
+	"strings"
 
 	"github.com/awslabs/ferret-scan/v2/internal/redactors/isobmff"
+	"github.com/awslabs/ferret-scan/v2/internal/redactors/ogg"
 	"github.com/awslabs/ferret-scan/v2/internal/redactors/tagmeta"
 )
 
@@ -38,6 +40,8 @@ func metadataRanges(buf []byte, format audioFormat) []tagmeta.Region {
 		return flacMetadataRanges(buf)
 	case formatM4A:
 		return mp4MetadataRanges(buf)
+	case formatAIFF:
+		return aiffMetadataRanges(buf)
 	}
 	return nil
 }
@@ -205,3 +209,57 @@ func mp4MetadataRanges(buf []byte) []tagmeta.Region {
 	}
 	return out
 }
+
+// aiffMetadataRanges finds the text chunks of an AIFF or AIFF-C file: NAME, AUTH, "(c) ", ANNO,
+// COMT and an appended ID3 chunk, the set the AIFF extractor reads.
+//
+// RIFF's walk with the byte order reversed, and the same rule about what is never touched:
+// COMM is the format the sound data is decoded by, and SSND is the sound data.
+func aiffMetadataRanges(buf []byte) []tagmeta.Region {
+	const formHeader = 12
+	if len(buf) < formHeader || !bytes.Equal(buf[0:4], []byte("FORM")) ||
+		(!bytes.Equal(buf[8:12], []byte("AIFF")) && !bytes.Equal(buf[8:12], []byte("AIFC"))) {
+		return nil
+	}
+
+	var out []tagmeta.Region
+	pos := formHeader
+	for pos+8 <= len(buf) {
+		id := string(buf[pos : pos+4])
+		size := int64(binary.BigEndian.Uint32(buf[pos+4 : pos+8]))
+		dataStart := pos + 8
+		dataEnd := len(buf)
+		if size <= int64(len(buf)-dataStart) {
+			dataEnd = dataStart + int(size)
+		}
+
+		switch id {
+		case "NAME", "AUTH", "(c) ", "ANNO", "COMT", "ID3 ", "id3 ":
+			if dataEnd > dataStart {
+				out = append(out, tagmeta.Region{Start: dataStart, End: dataEnd, Label: "AIFF " + strings.TrimSpace(id)})
+			}
+		}
+
+		next := dataEnd
+		if size%2 == 1 && next < len(buf) && buf[next] == 0x00 {
+			// Pad byte, detected rather than assumed, as in riffMetadataRanges.
+			next++
+		}
+		if next <= pos {
+			return out
+		}
+		pos = next
+	}
+	return out
+}
+
+// oggCommentRanges returns the comment data of an Ogg comment packet, as a region of the packet
+// rather than of the file: past the magic, to the end, as flacMetadataRanges takes a whole
+// VORBIS_COMMENT block.
+func oggCommentRanges(s *ogg.Stream) []tagmeta.Region {
+	start := s.CommentMagicLen()
+	if len(s.Comment) <= start {
+		return nil
+	}
+	return []tagmeta.Region{{Start: start, End: len(s.Comment), Label: "Ogg " + string(s.Codec) + " comment header"}}
+}
@@ -210,3 +210,41 @@ func TestMP4DeepNestingTerminates(t *testing.T) {
 			"not terminating the walk")
 	}
 }
+
+func TestAIFFRangeCoversOnlyTheTextChunks(t *testing.T) {
+	chunk := func(id string, payload []byte) []byte {
+		out := append([]byte(id), binary.BigEndian.AppendUint32(nil, uint32(len(payload)))...)
+		out = append(out, payload...)
+		if len(payload)%2 == 1 {
+			out = append(out, 0x00)
+		}
+		return out
+	}
+	sound := bytes.Repeat([]byte{0xCC}, 40)
+
+	buf := []byte("FORM\x00\x00\x00\x00AIFF")
+	buf = append(buf, chunk("COMM", make([]byte, 18))...)
+	nameAt := len(buf) + 8
+	buf = append(buf, chunk("NAME", []byte("odd"))...) // padded
+	ssndAt := len(buf)
+	buf = append(buf, chunk("SSND", sound)...)
+	annoAt := len(buf) + 8
+	buf = append(buf, chunk("ANNO", []byte("note"))...)
+
+	got := aiffMetadataRanges(buf)
+	if len(got) != 2 {
+		t.Fatalf("aiffMetadataRanges returned %d ranges, want NAME and ANNO: %+v", len(got), got)
+	}
+	if got[0].Start != nameAt || got[0].End != nameAt+3 {
+		t.Errorf("NAME range = [%d,%d), want [%d,%d)", got[0].Start, got[0].End, nameAt, nameAt+3)
+	}
+	if got[1].Start != annoAt || got[1].End != annoAt+4 {
+		t.Errorf("ANNO range = [%d,%d), want [%d,%d); the walk lost alignment after the pad byte",
+			got[1].Start, got[1].End, annoAt, annoAt+4)
+	}
+	for _, rg := range got {
+		if rg.Start < ssndAt+8+len(sound) && rg.End > ssndAt {
+			t.Error("the SSND sound data falls inside a metadata range")
+		}
+	}
+}
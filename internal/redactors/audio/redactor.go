@@ -1,7 +1,7 @@
 // Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
 // SPDX-License-Identifier: Apache-2.0
 
-// Package audio redacts tag metadata in audio files (.mp3/.wav/.m4a/.flac).
+// Package audio redacts tag metadata in audio files (.mp3/.wav/.m4a/.flac/.ogg/.opus/.aiff).
 //
 // Before this package audio had no redactor at all. The read side is complete — there is a
 // dedicated preprocessor and four extractors, and audio is admitted as an embedded part too —
@@ -27,6 +27,13 @@
 // Synthetic is declined: it generates a plausible value whose length is unrelated to the
 // original. Claiming support and silently masking instead would misreport what the output
 // contains.
+//
+// # Ogg is the exception to "no size field changes"
+//
+// Nothing moves in an Ogg file either, but every page carries a checksum of its own bytes, and
+// a player drops a page whose checksum fails. So the comment packet is reassembled from its
+// pages, rewritten as one buffer — a value cut by a page boundary is found whole — copied back,
+// and the checksum of each page it occupies recomputed. See internal/redactors/ogg.
 package audio
 
 import (
@@ -40,6 +47,7 @@ import (
 	"github.com/awslabs/ferret-scan/v2/internal/detector"
 	"github.com/awslabs/ferret-scan/v2/internal/observability"
 	"github.com/awslabs/ferret-scan/v2/internal/redactors"
+	"github.com/awslabs/ferret-scan/v2/internal/redactors/ogg"
 	"github.com/awslabs/ferret-scan/v2/internal/redactors/tagmeta"
 )
 
@@ -52,6 +60,8 @@ const (
 	formatMP3
 	formatFLAC
 	formatM4A
+	formatOgg
+	formatAIFF
 )
 
 func (f audioFormat) String() string {
@@ -64,6 +74,10 @@ func (f audioFormat) String() string {
 		return "flac"
 	case formatM4A:
 		return "m4a"
+	case formatOgg:
+		return "ogg"
+	case formatAIFF:
+		return "aiff"
 	}
 	return "unknown"
 }
@@ -98,6 +112,12 @@ func (r *AudioRedactor) GetSupportedTypes() []string {
 		"wav", ".wav",
 		"m4a", ".m4a",
 		"flac", ".flac",
+		"ogg", ".ogg",
+		"oga", ".oga",
+		"opus", ".opus",
+		"aiff", ".aiff",
+		"aif", ".aif",
+		"aifc", ".aifc",
 	}
 }
 
@@ -138,7 +158,20 @@ func (r *AudioRedactor) RedactDocument(originalPath string, outputPath string, m
 		return nil, fmt.Errorf("not a recognised audio container: %s", filepath.Base(originalPath))
 	}
 
-	ranges := metadataRanges(raw, format)
+	var oggStream *ogg.Stream
+	var ranges []tagmeta.Region
+	if format == formatOgg {
+		// The regions are in the reassembled comment packet, not in the file: see the package
+		// comment. A walk that did not finish is a refusal, like an empty one.
+		oggStream, err = ogg.Read(bytes.NewReader(raw), int64(len(raw)))
+		if err != nil {
+			return nil, fmt.Errorf("could not read the Ogg comment header of %s, so its findings could not be removed: %w",
+				filepath.Base(originalPath), err)
+		}
+		ranges = oggCommentRanges(oggStream)
+	} else {
+		ranges = metadataRanges(raw, format)
+	}
 	if len(ranges) == 0 {
 		// No tag region located. Returning success here would write a byte-identical copy and
 		// report it as redacted, which is the exact failure #306 is about.
@@ -156,10 +189,24 @@ func (r *AudioRedactor) RedactDocument(originalPath string, outputPath string, m
 	// anything. Reported matches overlap — an AUTHOR_INFO field value contains the SSN
 	// reported separately — and a sequential replace loses whichever one it handles second.
 	// See planOverwrites.
-	plan, perMatch := tagmeta.Plan(raw, ranges, matches, strategy)
+	//
+	// tagBytes is what ranges index: the file itself, or for Ogg the comment packet.
+	tagBytes := raw
+	if oggStream != nil {
+		tagBytes = oggStream.Comment
+	}
+	plan, perMatch := tagmeta.Plan(tagBytes, ranges, matches, strategy)
 
 	modified := append([]byte(nil), raw...)
-	tagmeta.Apply(modified, plan)
+	if oggStream != nil {
+		packet := append([]byte(nil), oggStream.Comment...)
+		tagmeta.Apply(packet, plan)
+		if err := oggStream.WriteComment(modified, packet); err != nil {
+			return nil, fmt.Errorf("internal error: %w", err)
+		}
+	} else {
+		tagmeta.Apply(modified, plan)
+	}
 
 	var mappings []redactors.RedactionMapping
 	for i, m := range matches {
@@ -205,7 +252,18 @@ func (r *AudioRedactor) RedactDocument(originalPath string, outputPath string, m
 	// or in an encoding the search did not try, and every one of those looks like a success
 	// from the mapping count alone. Verifying the OUTPUT is the only assertion that cannot be
 	// satisfied by a partial job.
-	if residual := tagmeta.Residual(modified, ranges, matches); residual > 0 {
+	//
+	// For Ogg the packet is read back out of the written pages rather than checked in the
+	// buffer it was rewritten in, so a mistake copying it back is caught here too.
+	residualBytes := modified
+	if oggStream != nil {
+		written, err := ogg.Read(bytes.NewReader(modified), int64(len(modified)))
+		if err != nil {
+			return nil, fmt.Errorf("internal error: the redacted Ogg comment header no longer reads: %w", err)
+		}
+		residualBytes, ranges = written.Comment, oggCommentRanges(written)
+	}
+	if residual := tagmeta.Residual(residualBytes, ranges, matches); residual > 0 {
 		return nil, fmt.Errorf("%d reported value(s) remain in the %s metadata of %s after redaction; refusing to write a file that would look redacted",
 			residual, format, filepath.Base(originalPath))
 	}
@@ -243,6 +301,11 @@ func detectFormat(path string, buf []byte) audioFormat {
 		return formatFLAC
 	case len(buf) >= 12 && bytes.Equal(buf[4:8], []byte("ftyp")):
 		return formatM4A
+	case ogg.HasHeader(buf):
+		return formatOgg
+	case len(buf) >= 12 && bytes.Equal(buf[0:4], []byte("FORM")) &&
+		(bytes.Equal(buf[8:12], []byte("AIFF")) || bytes.Equal(buf[8:12], []byte("AIFC"))):
+		return formatAIFF
 	case len(buf) >= 3 && bytes.Equal(buf[0:3], []byte("ID3")):
 		return formatMP3
 	case len(buf) >= 2 && buf[0] == 0xFF && buf[1]&0xE0 == 0xE0:
@@ -259,6 +322,10 @@ func detectFormat(path string, buf []byte) audioFormat {
 		return formatFLAC
 	case ".m4a":
 		return formatM4A
+	case ".ogg", ".oga", ".opus":
+		return formatOgg
+	case ".aiff", ".aif", ".aifc":
+		return formatAIFF
 	}
 	return formatUnknown
 }
@@ -13,6 +13,7 @@ import (
 
 	"github.com/awslabs/ferret-scan/v2/internal/detector"
 	"github.com/awslabs/ferret-scan/v2/internal/redactors"
+	"github.com/awslabs/ferret-scan/v2/internal/redactors/ogg"
 	"github.com/awslabs/ferret-scan/v2/internal/redactors/tagmeta"
 )
 
@@ -159,6 +160,86 @@ func buildM4A(t *testing.T, dir string, value string) string {
 	return writeFixture(t, dir, "tagged.m4a", append(ftyp, moov...))
 }
 
+// buildAIFF writes a FORM/AIFF file with a NAME and an ANNO chunk, either side of the sound
+// data.
+func buildAIFF(t *testing.T, dir, name, annotation string) string {
+	t.Helper()
+
+	chunk := func(id string, payload []byte) []byte {
+		out := append([]byte(id), binary.BigEndian.AppendUint32(nil, uint32(len(payload)))...)
+		out = append(out, payload...)
+		if len(payload)%2 == 1 {
+			out = append(out, 0x00)
+		}
+		return out
+	}
+
+	comm := make([]byte, 18)
+	binary.BigEndian.PutUint16(comm[0:], 1)
+	binary.BigEndian.PutUint32(comm[2:], 2)
+	binary.BigEndian.PutUint16(comm[6:], 16)
+	copy(comm[8:], []byte{0x40, 0x0E, 0xAC, 0x44}) // 44100 as an 80-bit extended value
+
+	body := append([]byte("AIFF"), chunk("COMM", comm)...)
+	body = append(body, chunk("NAME", []byte(name))...)
+	body = append(body, chunk("SSND", append(make([]byte, 8), 0x01, 0x02, 0x03, 0x04))...)
+	body = append(body, chunk("ANNO", []byte(annotation))...)
+	form := append([]byte("FORM"), binary.BigEndian.AppendUint32(nil, uint32(len(body)))...)
+	return writeFixture(t, dir, "tagged.aiff", append(form, body...))
+}
+
+// oggPage builds one Ogg page holding body as a single packet piece, with a valid checksum.
+// open says the piece continues on the next page, so it must be a multiple of 255 bytes.
+func oggPage(headerType byte, seq uint32, body []byte, open bool) []byte {
+	var lacing []byte
+	n := len(body)
+	for ; n >= 255; n -= 255 {
+		lacing = append(lacing, 255)
+	}
+	if !open {
+		lacing = append(lacing, byte(n))
+	}
+	h := make([]byte, 27)
+	copy(h, "OggS")
+	h[5] = headerType
+	binary.LittleEndian.PutUint32(h[14:], 0x5EED)
+	binary.LittleEndian.PutUint32(h[18:], seq)
+	h[26] = byte(len(lacing))
+	page := append(append(h, lacing...), body...)
+	binary.LittleEndian.PutUint32(page[22:], ogg.Checksum(page))
+	return page
+}
+
+// buildOgg writes an Ogg Vorbis file whose comment header holds the given comments. With split
+// above zero, the header's first page carries only that many bytes (a multiple of 255), so the
+// rest of the header continues on a second page, as a long comment does.
+func buildOgg(t *testing.T, dir string, split int, comments ...string) string {
+	t.Helper()
+
+	ident := append([]byte("\x01vorbis"), 0, 0, 0, 0, 1)
+	ident = binary.LittleEndian.AppendUint32(ident, 8000)
+	ident = append(ident, make([]byte, 30-len(ident))...)
+
+	packet := binary.LittleEndian.AppendUint32([]byte("\x03vorbis"), 4)
+	packet = append(packet, "test"...)
+	packet = binary.LittleEndian.AppendUint32(packet, uint32(len(comments)))
+	for _, c := range comments {
+		packet = binary.LittleEndian.AppendUint32(packet, uint32(len(c)))
+		packet = append(packet, c...)
+	}
+	packet = append(packet, 1)
+
+	file := oggPage(0x02, 0, ident, false)
+	if split > 0 {
+		file = append(file, oggPage(0, 1, packet[:split], true)...)
+		file = append(file, oggPage(0x01, 2, packet[split:], false)...)
+	} else {
+		file = append(file, oggPage(0, 1, packet, false)...)
+	}
+	file = append(file, oggPage(0x04, 3, []byte{0x01, 0x02, 0x03, 0x04}, false)...)
+	return writeFixture(t, dir, "tagged.ogg", file)
+}
+
 func sortedKeys(m map[string]string) []string {
 	out := make([]string, 0, len(m))
 	for k := range m {
@@ -237,6 +318,16 @@ func TestReportedValuesRemovedFromEveryFormat(t *testing.T) {
 			src:     buildM4A(t, dir, "Recording ref "+testSSN),
 			matches: []detector.Match{match(testSSN, "SSN")},
 		},
+		{
+			name:    "aiff NAME/ANNO",
+			src:     buildAIFF(t, dir, "Contact "+testSSN, "Call "+testPhone),
+			matches: []detector.Match{match(testSSN, "SSN"), match(testPhone, "PHONE")},
+		},
+		{
+			name:    "ogg Vorbis comment header",
+			src:     buildOgg(t, dir, 0, "ARTIST=Contact "+testSSN, "COMMENT=Call "+testPhone),
+			matches: []detector.Match{match(testSSN, "SSN"), match(testPhone, "PHONE")},
+		},
 	}
 
 	for _, tc := range cases {
@@ -365,8 +456,8 @@ func TestSupportedTypesCoverEveryScannedAudioFormat(t *testing.T) {
 	for _, s := range NewAudioRedactor(nil, nil).GetSupportedTypes() {
 		got[s] = true
 	}
-	// The read side scans exactly these four (audio_metadata_preprocessor.go).
-	for _, ext := range []string{"mp3", "wav", "m4a", "flac"} {
+	// The read side scans exactly these (audiolib.NewAudioExtractor).
+	for _, ext := range []string{"mp3", "wav", "m4a", "flac", "ogg", "oga", "opus", "aiff", "aif", "aifc"} {
 		if !got[ext] || !got["."+ext] {
 			t.Errorf("%q is scanned but not claimed in both spellings; the manager looks it up "+
 				"both ways depending on the call path", ext)
@@ -535,3 +626,69 @@ func TestUTF16BETagTextIsRedacted(t *testing.T) {
 			"little-endian-only search leaves it in cleartext")
 	}
 }
+
+// A value cut in two by an Ogg page boundary must still be removed, and every page the comment
+// header occupies must still pass its checksum — a decoder drops a page that fails, and for the
+// comment header that is a file that no longer plays.
+//
+// The value never appears contiguously in the file, so a search of the file's bytes finds
+// nothing, and a residue check searching the same bytes would agree.
+func TestOggValueAcrossAPageBoundaryIsRemovedAndPagesStayValid(t *testing.T) {
+	dir := t.TempDir()
+	// Header: magic(7), vendor length(4) and "test"(4), count(4), then a length(4) before each
+	// comment. Pad the first comment so the page boundary falls inside the phone number.
+	prefix := 7 + 8 + 4 + 4
+	pad := 255 - prefix - len("TITLE=") - 4 - len("COMMENT=Call 415-5")
+	src := buildOgg(t, dir, 255, "TITLE="+strings.Repeat("n", pad), "COMMENT=Call "+testPhone)
+
+	before, _ := os.ReadFile(src) // #nosec G304 -- test temp dir
+	if bytes.Contains(before, []byte(testPhone)) {
+		t.Fatal("fixture: the value must be cut by the page boundary")
+	}
+	stream, err := ogg.Read(bytes.NewReader(before), int64(len(before)))
+	if err != nil || !bytes.Contains(stream.Comment, []byte(testPhone)) || len(stream.Pages) != 2 {
+		t.Fatalf("fixture: the comment header must span two pages and hold the value (%v)", err)
+	}
+
+	after := redact(t, src, []detector.Match{match(testPhone, "PHONE")})
+
+	if len(after) != len(before) {
+		t.Fatalf("file size changed %d -> %d", len(before), len(after))
+	}
+	written, err := ogg.Read(bytes.NewReader(after), int64(len(after)))
+	if err != nil {
+		t.Fatalf("the redacted file no longer reads: %v", err)
+	}
+	if bytes.Contains(written.Comment, []byte(testPhone)) {
+		t.Error("the value survived in the reassembled comment header")
+	}
+	for _, pg := range written.Pages {
+		page := after[pg.Offset : pg.Offset+pg.Length]
+		if binary.LittleEndian.Uint32(page[22:26]) != ogg.Checksum(page) {
+			t.Errorf("the page at %d fails its checksum; a decoder would drop it", pg.Offset)
+		}
+	}
+	if !bytes.Equal(after[len(after)-4:], []byte{0x01, 0x02, 0x03, 0x04}) {
+		t.Error("an audio page was modified")
+	}
+}
+
+// An Ogg file that ends inside its comment header is refused: the part past the end was never
+// searched, and a copy written from the part that was would look redacted.
+func TestTruncatedOggCommentHeaderIsRefused(t *testing.T) {
+	dir := t.TempDir()
+	src := buildOgg(t, dir, 255, "COMMENT=Call "+testPhone+strings.Repeat(".", 300))
+	b, _ := os.ReadFile(src) // #nosec G304 -- test temp dir
+	// Cut the file inside the second page of the header.
+	cut := writeFixture(t, dir, "cut.ogg", b[:len(b)-60])
+
+	out := filepath.Join(dir, "out.ogg")
+	_, err := NewAudioRedactor(nil, nil).RedactDocument(cut, out, []detector.Match{match(testPhone, "PHONE")},
+		redactors.RedactionFormatPreserving)
+	if err == nil {
+		t.Fatal("a truncated comment header was accepted")
+	}
+	if _, statErr := os.Stat(out); statErr == nil {
+		t.Error("an output file was written for a refused redaction")
+	}
+}
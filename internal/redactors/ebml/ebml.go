@@ -0,0 +1,659 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package ebml locates the descriptive metadata of a Matroska or WebM file (.mkv/.webm) without
+// reading the media payload.
+//
+// # Why a walk over an io.ReaderAt
+//
+// For the reason isobmff gives. A screen recording is routinely gigabytes, and the values worth
+// reporting — the title, the encoder, a comment tag, the name of an attached file — are a few
+// hundred bytes between the headers and the clusters. The walk reads element headers, the string
+// payloads of the elements it names, and nothing else; a Cluster is skipped by arithmetic, so the
+// video frames are never read. The extractor and the redactor share this walk, so both agree on
+// exactly which bytes are metadata.
+//
+// # Which elements
+//
+// Only the Segment children that are purely descriptive: Info, Tracks, Tags, Chapters and
+// Attachments. Cues and SeekHead are offset tables, and overwriting a byte there desynchronises
+// a player from the media while the file still parses — the same corruption isobmff avoids by
+// never touching stbl. Within those children, only the STRING leaves are returned as spans, so
+// the blast radius of a parsing mistake is a string a player displays.
+//
+// # Unknown sizes
+//
+// A live writer — a browser's MediaRecorder, OBS writing WebM — does not know how long a Segment
+// or Cluster will be when it writes the header, and records the size as "unknown". Such a Segment
+// runs to the end of the file. Such a Cluster ends where the next Segment-level element begins,
+// which can only be found by walking its blocks one header at a time; every one of them has a
+// known size, so that walk costs a header read per block and never a payload read.
+//
+// This package deliberately depends on nothing but the standard library: it is a container
+// parser, and nothing about redaction belongs in it.
+package ebml
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+// ErrElementBudget reports that the walk stopped early because a file declared more elements
+// than any real one contains.
+//
+// As with isobmff.ErrAtomBudget, termination does not depend on it; it bounds WORK. An
+// unknown-size Cluster is walked block by block, and a file of nothing but two-byte elements
+// describes millions of them. The caller must treat this as a refusal rather than as "no
+// metadata found": the walk did not finish, so what it returned may be incomplete.
+var ErrElementBudget = errors.New("ebml: element budget exhausted; refusing to treat a partial walk as complete")
+
+// ErrFieldBudget reports that the string elements of a file add up to more than maxFieldBytes.
+//
+// Each string is bounded by the file, but their sum is what this package holds in memory, and a
+// small file can declare the same region many times over through nested elements. Exceeding it
+// is a refusal, not a truncation: a partial read cannot support a claim about the whole file.
+var ErrFieldBudget = errors.New("ebml: metadata strings exceed the size limit; refusing to read part of them")
+
+// maxElements is the walk's work budget, matching isobmff's atom budget so the two video walks
+// give up at the same point. A two-hour recording with one SimpleBlock per frame in unknown-size
+// Clusters is on the order of 10^5 elements.
+const maxElements = 1 << 20
+
+// maxFieldBytes bounds the total string payload read. A real file's descriptive strings are a
+// few kilobytes; chapter titles for a long recording are tens.
+const maxFieldBytes = 16 << 20
+
+// maxDepth bounds recursion through the two elements that nest themselves: SimpleTag and
+// ChapterAtom. No real file nests either more than a few levels.
+const maxDepth = 16
+
+// MaxAttachments bounds how many attachments of one file are examined. Shared by the extractor,
+// which materializes them, and the redactor, which searches them, so the two sides stop at the
+// same attachment.
+const MaxAttachments = 64
+
+// MaxAttachmentBytes bounds one attachment either side will hold. An attachment larger than this
+// is not examined by the extractor, so nothing can be reported from it for the redactor to
+// remove.
+const MaxAttachmentBytes = 50 << 20
+
+// Element IDs, with their length-marker bits kept, as the Matroska specification writes them.
+const (
+	idEBML    = 0x1A45DFA3
+	idDocType = 0x4282
+	idSegment = 0x18538067
+
+	idSeekHead    = 0x114D9B74
+	idInfo        = 0x1549A966
+	idTracks      = 0x1654AE6B
+	idCues        = 0x1C53BB6B
+	idCluster     = 0x1F43B675
+	idTags        = 0x1254C367
+	idAttachments = 0x1941A469
+	idChapters    = 0x1043A770
+
+	idTimestampScale = 0x2AD7B1
+	idDuration       = 0x4489
+	idDateUTC        = 0x4461
+	idTitle          = 0x7BA9
+	idMuxingApp      = 0x4D80
+	idWritingApp     = 0x5741
+
+	idTrackEntry  = 0xAE
+	idTrackType   = 0x83
+	idName        = 0x536E
+	idCodecID     = 0x86
+	idVideo       = 0xE0
+	idPixelWidth  = 0xB0
+	idPixelHeight = 0xBA
+
+	idTag       = 0x7373
+	idSimpleTag = 0x67C8
+	idTagName   = 0x45A3
+	idTagString = 0x4487
+
+	idAttachedFile    = 0x61A7
+	idFileDescription = 0x467E
+	idFileName        = 0x466E
+	idFileMimeType    = 0x4660
+	idFileData        = 0x465C
+
+	idEditionEntry   = 0x45B9
+	idChapterAtom    = 0xB6
+	idChapterDisplay = 0x80
+	idChapString     = 0x85
+)
+
+// trackTypeVideo is the TrackType of a video track.
+const trackTypeVideo = 1
+
+// unknownSize is the decoded value of a size whose bits are all ones.
+const unknownSize = -1
+
+// epoch is the origin of a Matroska date: nanoseconds since the start of the millennium.
+var epoch = time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Span is a half-open [Start, End) byte range of the file.
+type Span struct {
+	Start int64
+	End   int64
+	Label string // which element group it came from, for the audit trail
+}
+
+// Len returns the span's length in bytes.
+func (s Span) Len() int64 { return s.End - s.Start }
+
+// Field is one descriptive string element: what the file calls it, its text, and where that text
+// is.
+type Field struct {
+	// Name is the element's name ("Title", "WritingApp", "TrackName", "ChapterTitle",
+	// "AttachmentName") or, for a tag, the TagName the file gives it ("ARTIST", "COMMENT").
+	Name  string
+	Value string // the element's text, trailing NUL padding removed
+	Span         // the element's whole payload, padding included
+}
+
+// Attachment is one AttachedFile: its declared name and type, and where its bytes are.
+type Attachment struct {
+	Name        string // producer-controlled; never use it to build a path
+	MimeType    string
+	Description string
+	Data        Span
+}
+
+// fontTypes are the attachment media types that hold a font.
+var fontTypes = []string{"font/", "application/x-truetype-font", "application/x-font", "application/vnd.ms-opentype", "application/font-"}
+
+// fontExts are the file extensions of a font attachment, for writers that leave the type generic.
+var fontExts = []string{".ttf", ".otf", ".ttc", ".woff", ".woff2"}
+
+// IsFont reports whether an attachment is a font.
+//
+// Fonts are the common case — a subtitled release carries a dozen — and they are glyph tables,
+// not prose. Both sides skip them, so the rule lives here where both can reach it rather than in
+// two copies that could disagree about which attachments were examined.
+func (a Attachment) IsFont() bool {
+	mime := strings.ToLower(a.MimeType)
+	for _, t := range fontTypes {
+		if strings.HasPrefix(mime, t) {
+			return true
+		}
+	}
+	name := strings.ToLower(a.Name)
+	for _, ext := range fontExts {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// Metadata is what the walk found.
+type Metadata struct {
+	DocType     string // "matroska" or "webm"
+	Fields      []Field
+	Attachments []Attachment
+
+	// Technical values of the first video track and of the segment, for the extractor's report.
+	Duration time.Duration
+	DateUTC  time.Time
+	Codec    string
+	Width    int
+	Height   int
+}
+
+// HasHeader reports whether head begins with an EBML header, which every Matroska and WebM file
+// does.
+func HasHeader(head []byte) bool {
+	return len(head) >= 4 && binary.BigEndian.Uint32(head) == idEBML
+}
+
+// Read walks a Matroska or WebM file and returns its descriptive metadata.
+//
+// A malformed or truncated file yields what was found before the damage and no error, as
+// isobmff.MetadataSpans does: a value that could be located is still worth reporting and
+// scrubbing, and the redactor's own residue check decides whether the result is safe.
+// ErrElementBudget and ErrFieldBudget are the cases that must not be read that way, so they are
+// returned.
+func Read(r io.ReaderAt, size int64) (*Metadata, error) {
+	w := &walker{r: r, budget: maxElements, fieldBudget: maxFieldBytes, md: &Metadata{}, scale: 1000000}
+	err := w.children(0, size, func(e element) error {
+		switch e.id {
+		case idEBML:
+			return w.children(e.payloadStart, e.payloadEnd, func(c element) error {
+				if c.id == idDocType {
+					v, err := w.str(c)
+					w.md.DocType = v
+					return err
+				}
+				return nil
+			})
+		case idSegment:
+			return w.segment(e)
+		}
+		return nil
+	})
+	if w.duration > 0 {
+		w.md.Duration = time.Duration(w.duration * float64(w.scale))
+	}
+	return w.md, err
+}
+
+// element is one element header: its ID and where its payload is.
+type element struct {
+	id           uint32
+	payloadStart int64
+	payloadEnd   int64
+}
+
+func (e element) span(label string) Span { return Span{e.payloadStart, e.payloadEnd, label} }
+
+// walker carries the state of one Read.
+type walker struct {
+	r           io.ReaderAt
+	budget      int
+	fieldBudget int64
+	md          *Metadata
+
+	scale    uint64
+	duration float64
+}
+
+// segment reads the descriptive children of one Segment and skips the rest.
+func (w *walker) segment(seg element) error {
+	return w.children(seg.payloadStart, seg.payloadEnd, func(e element) error {
+		switch e.id {
+		case idInfo:
+			return w.info(e)
+		case idTracks:
+			return w.children(e.payloadStart, e.payloadEnd, func(t element) error {
+				if t.id == idTrackEntry {
+					return w.track(t)
+				}
+				return nil
+			})
+		case idTags:
+			return w.children(e.payloadStart, e.payloadEnd, func(t element) error {
+				if t.id != idTag {
+					return nil
+				}
+				return w.children(t.payloadStart, t.payloadEnd, func(s element) error {
+					if s.id == idSimpleTag {
+						return w.simpleTag(s, 0)
+					}
+					return nil
+				})
+			})
+		case idAttachments:
+			return w.children(e.payloadStart, e.payloadEnd, func(a element) error {
+				if a.id == idAttachedFile {
+					return w.attachment(a)
+				}
+				return nil
+			})
+		case idChapters:
+			return w.children(e.payloadStart, e.payloadEnd, func(ed element) error {
+				if ed.id != idEditionEntry {
+					return nil
+				}
+				return w.children(ed.payloadStart, ed.payloadEnd, func(a element) error {
+					if a.id == idChapterAtom {
+						return w.chapter(a, 0)
+					}
+					return nil
+				})
+			})
+		}
+		return nil
+	})
+}
+
+func (w *walker) info(e element) error {
+	return w.children(e.payloadStart, e.payloadEnd, func(c element) error {
+		switch c.id {
+		case idTitle:
+			return w.field("Title", c, "MKV Info")
+		case idMuxingApp:
+			return w.field("MuxingApp", c, "MKV Info")
+		case idWritingApp:
+			return w.field("WritingApp", c, "MKV Info")
+		case idTimestampScale:
+			if v, ok := w.uint(c); ok && v > 0 {
+				w.scale = v
+			}
+		case idDuration:
+			w.duration = w.float(c)
+		case idDateUTC:
+			if v, ok := w.uint(c); ok && c.payloadEnd-c.payloadStart == 8 {
+				w.md.DateUTC = epoch.Add(time.Duration(int64(v)))
+			}
+		}
+		return nil
+	})
+}
+
+func (w *walker) track(t element) error {
+	var (
+		kind          uint64
+		codec         string
+		width, height uint64
+	)
+	err := w.children(t.payloadStart, t.payloadEnd, func(c element) error {
+		switch c.id {
+		case idTrackType:
+			kind, _ = w.uint(c)
+		case idName:
+			return w.field("TrackName", c, "MKV Tracks")
+		case idCodecID:
+			v, err := w.str(c)
+			codec = v
+			return err
+		case idVideo:
+			return w.children(c.payloadStart, c.payloadEnd, func(v element) error {
+				switch v.id {
+				case idPixelWidth:
+					width, _ = w.uint(v)
+				case idPixelHeight:
+					height, _ = w.uint(v)
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	if kind == trackTypeVideo && w.md.Codec == "" {
+		w.md.Codec = codec
+		w.md.Width = clampInt(width)
+		w.md.Height = clampInt(height)
+	}
+	return err
+}
+
+// simpleTag records a tag's string under its own TagName. A SimpleTag may nest SimpleTags — a
+// tag's sub-values — so it recurses, bounded by maxDepth.
+func (w *walker) simpleTag(s element, depth int) error {
+	if depth >= maxDepth {
+		return nil
+	}
+	var (
+		name  string
+		value *element
+	)
+	err := w.children(s.payloadStart, s.payloadEnd, func(c element) error {
+		switch c.id {
+		case idTagName:
+			v, err := w.str(c)
+			name = v
+			return err
+		case idTagString:
+			cc := c
+			value = &cc
+		case idSimpleTag:
+			return w.simpleTag(c, depth+1)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if value == nil {
+		return nil
+	}
+	if name == "" {
+		name = "Tag"
+	}
+	return w.field(name, *value, "MKV Tags")
+}
+
+func (w *walker) chapter(a element, depth int) error {
+	if depth >= maxDepth {
+		return nil
+	}
+	return w.children(a.payloadStart, a.payloadEnd, func(c element) error {
+		switch c.id {
+		case idChapterDisplay:
+			return w.children(c.payloadStart, c.payloadEnd, func(d element) error {
+				if d.id == idChapString {
+					return w.field("ChapterTitle", d, "MKV Chapters")
+				}
+				return nil
+			})
+		case idChapterAtom:
+			return w.chapter(c, depth+1)
+		}
+		return nil
+	})
+}
+
+func (w *walker) attachment(a element) error {
+	var att Attachment
+	err := w.children(a.payloadStart, a.payloadEnd, func(c element) error {
+		var err error
+		switch c.id {
+		case idFileName:
+			att.Name, err = w.fieldValue("AttachmentName", c, "MKV Attachments")
+		case idFileDescription:
+			att.Description, err = w.fieldValue("AttachmentDescription", c, "MKV Attachments")
+		case idFileMimeType:
+			att.MimeType, err = w.str(c)
+		case idFileData:
+			att.Data = c.span("MKV attachment")
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if att.Data.Len() > 0 {
+		w.md.Attachments = append(w.md.Attachments, att)
+	}
+	return nil
+}
+
+// field reads a string element and records it.
+func (w *walker) field(name string, e element, label string) error {
+	_, err := w.fieldValue(name, e, label)
+	return err
+}
+
+func (w *walker) fieldValue(name string, e element, label string) (string, error) {
+	v, err := w.str(e)
+	if err != nil {
+		return "", err
+	}
+	// An EMPTY payload is dropped: there is no value in it to report or redact, and the
+	// invariant worth having is that a returned span is non-empty.
+	if e.payloadEnd > e.payloadStart {
+		w.md.Fields = append(w.md.Fields, Field{Name: name, Value: v, Span: e.span(label)})
+	}
+	return v, nil
+}
+
+// str reads a string element, charging its length to the field budget.
+func (w *walker) str(e element) (string, error) {
+	n := e.payloadEnd - e.payloadStart
+	if n <= 0 {
+		return "", nil
+	}
+	if n > w.fieldBudget {
+		return "", ErrFieldBudget
+	}
+	w.fieldBudget -= n
+	buf := make([]byte, n)
+	if _, err := w.r.ReadAt(buf, e.payloadStart); err != nil && err != io.EOF {
+		return "", nil
+	}
+	// A string element may be padded with NULs to its declared size.
+	return strings.TrimRight(string(buf), "\x00"), nil
+}
+
+// uint reads an unsigned integer element of up to 8 bytes.
+func (w *walker) uint(e element) (uint64, bool) {
+	n := e.payloadEnd - e.payloadStart
+	if n <= 0 || n > 8 {
+		return 0, false
+	}
+	var buf [8]byte
+	if _, err := w.r.ReadAt(buf[8-n:], e.payloadStart); err != nil {
+		return 0, false
+	}
+	return binary.BigEndian.Uint64(buf[:]), true
+}
+
+// float reads a 4- or 8-byte float element; anything else, or a value that is not a finite
+// positive number, reads as zero.
+func (w *walker) float(e element) float64 {
+	n := e.payloadEnd - e.payloadStart
+	var v float64
+	switch n {
+	case 4:
+		var buf [4]byte
+		if _, err := w.r.ReadAt(buf[:], e.payloadStart); err != nil {
+			return 0
+		}
+		v = float64(math.Float32frombits(binary.BigEndian.Uint32(buf[:])))
+	case 8:
+		var buf [8]byte
+		if _, err := w.r.ReadAt(buf[:], e.payloadStart); err != nil {
+			return 0
+		}
+		v = math.Float64frombits(binary.BigEndian.Uint64(buf[:]))
+	}
+	// Bound the product with the timestamp scale so it cannot overflow a Duration.
+	if math.IsNaN(v) || v <= 0 || v > 1e15 {
+		return 0
+	}
+	return v
+}
+
+// children walks the elements in [start, end) and calls fn for each one.
+//
+// Every declared size is checked against the real end of the range before it is trusted, written
+// as `size > end-payloadStart` so a size near 2^56 cannot overflow the comparison. An overrun is
+// clamped to the range rather than rejected: a truncated download still holds its headers.
+func (w *walker) children(start, end int64, fn func(element) error) error {
+	off := start
+	for off < end {
+		w.budget--
+		if w.budget < 0 {
+			return ErrElementBudget
+		}
+		id, size, hdr, ok := w.header(off, end)
+		if !ok {
+			return nil
+		}
+		e := element{id: id, payloadStart: off + hdr}
+		switch {
+		case size == unknownSize && id == idCluster:
+			clusterEnd, err := w.clusterEnd(e.payloadStart, end)
+			if err != nil {
+				return err
+			}
+			e.payloadEnd = clusterEnd
+		case size == unknownSize || size > end-e.payloadStart:
+			e.payloadEnd = end
+		default:
+			e.payloadEnd = e.payloadStart + size
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+		off = e.payloadEnd
+	}
+	return nil
+}
+
+// clusterEnd finds where an unknown-size Cluster ends: at the first element that can only be a
+// Segment child, or a new EBML header or Segment. Its blocks are stepped over by their declared
+// sizes; none of them is read.
+func (w *walker) clusterEnd(start, end int64) (int64, error) {
+	off := start
+	for off < end {
+		w.budget--
+		if w.budget < 0 {
+			return 0, ErrElementBudget
+		}
+		id, size, hdr, ok := w.header(off, end)
+		if !ok || isSegmentLevel(id) || size == unknownSize || size > end-off-hdr {
+			return off, nil
+		}
+		off += hdr + size
+	}
+	return end, nil
+}
+
+// isSegmentLevel reports whether id ends an unknown-size Cluster.
+func isSegmentLevel(id uint32) bool {
+	switch id {
+	case idSeekHead, idInfo, idTracks, idCues, idCluster, idTags, idAttachments, idChapters, idEBML, idSegment:
+		return true
+	}
+	return false
+}
+
+// header decodes the element header at off: a 1-4 byte ID and a 1-8 byte size, both EBML
+// variable-length integers. ok is false when no well-formed header fits before end.
+func (w *walker) header(off, end int64) (id uint32, size int64, hdrLen int64, ok bool) {
+	var buf [12]byte
+	n := int64(len(buf))
+	if end-off < n {
+		n = end - off
+	}
+	if n < 2 {
+		return 0, 0, 0, false
+	}
+	read, _ := w.r.ReadAt(buf[:n], off)
+	b := buf[:read]
+
+	idLen := vintLen(b)
+	if idLen == 0 || idLen > 4 || idLen >= len(b) {
+		return 0, 0, 0, false
+	}
+	for _, c := range b[:idLen] {
+		id = id<<8 | uint32(c)
+	}
+
+	rest := b[idLen:]
+	sizeLen := vintLen(rest)
+	if sizeLen == 0 || sizeLen > len(rest) {
+		return 0, 0, 0, false
+	}
+	v := uint64(rest[0]) & (0xFF >> sizeLen)
+	allOnes := v == uint64(0xFF>>sizeLen)
+	for _, c := range rest[1:sizeLen] {
+		v = v<<8 | uint64(c)
+		allOnes = allOnes && c == 0xFF
+	}
+	hdrLen = int64(idLen + sizeLen)
+	if allOnes {
+		return id, unknownSize, hdrLen, true
+	}
+	if v > math.MaxInt64/2 {
+		return 0, 0, 0, false
+	}
+	return id, int64(v), hdrLen, true
+}
+
+// vintLen returns the length of the variable-length integer starting b, from the position of its
+// first set bit, or 0 when the first byte is zero (which no valid header starts with).
+func vintLen(b []byte) int {
+	if len(b) == 0 || b[0] == 0 {
+		return 0
+	}
+	n := 1
+	for mask := byte(0x80); b[0]&mask == 0; mask >>= 1 {
+		n++
+	}
+	return n
+}
+
+func clampInt(v uint64) int {
+	if v > math.MaxInt32 {
+		return 0
+	}
+	return int(v)
+}
@@ -0,0 +1,255 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package ebml
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"sync"
+	"testing"
+	"time"
+)
+
+// el builds one element with an 8-byte size field, which every reader must accept.
+func el(id uint32, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	var out []byte
+	switch {
+	case id > 0xFFFFFF:
+		out = binary.BigEndian.AppendUint32(out, id)
+	case id > 0xFFFF:
+		out = append(out, byte(id>>16), byte(id>>8), byte(id))
+	case id > 0xFF:
+		out = append(out, byte(id>>8), byte(id))
+	default:
+		out = append(out, byte(id))
+	}
+	out = append(out, 0x01)
+	size := make([]byte, 8)
+	binary.BigEndian.PutUint64(size, uint64(len(body)))
+	out = append(out, size[1:]...)
+	return append(out, body...)
+}
+
+// unknown builds a master element whose size is "unknown", as a live writer records it.
+func unknown(id uint32, payload ...[]byte) []byte {
+	out := binary.BigEndian.AppendUint32(nil, id)
+	out = append(out, 0x01, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF)
+	return append(out, bytes.Join(payload, nil)...)
+}
+
+func str(id uint32, s string) []byte { return el(id, []byte(s)) }
+
+func uintEl(id uint32, v uint64) []byte { return el(id, binary.BigEndian.AppendUint64(nil, v)) }
+
+func header(docType string) []byte { return el(idEBML, str(idDocType, docType)) }
+
+// block is media payload: its bytes must never be read.
+var block = bytes.Repeat([]byte("FRAME"), 64)
+
+func TestReadFindsEveryDescriptiveString(t *testing.T) {
+	file := append(header("matroska"), el(idSegment,
+		el(idSeekHead, el(0x4DBB, []byte{1, 2, 3})),
+		el(idInfo,
+			uintEl(idTimestampScale, 1000000),
+			el(idDuration, binary.BigEndian.AppendUint64(nil, math.Float64bits(90500))),
+			uintEl(idDateUTC, uint64(24*time.Hour)),
+			str(idTitle, "Quarterly review"),
+			str(idMuxingApp, "libebml"),
+			str(idWritingApp, "OBS Studio\x00\x00")),
+		el(idTracks, el(idTrackEntry,
+			uintEl(idTrackType, trackTypeVideo),
+			str(idName, "Jane's screen"),
+			str(idCodecID, "V_VP9"),
+			el(idVideo, uintEl(idPixelWidth, 1920), uintEl(idPixelHeight, 1080)))),
+		el(idCluster, el(0xE7, []byte{0}), el(0xA3, block)),
+		el(idTags, el(idTag, el(idSimpleTag,
+			str(idTagName, "COMMENT"),
+			str(idTagString, "SSN 123-45-6789"),
+			el(idSimpleTag, str(idTagName, "ARTIST"), str(idTagString, "Jane Doe"))))),
+		el(idChapters, el(idEditionEntry, el(idChapterAtom,
+			el(idChapterDisplay, str(idChapString, "Intro")),
+			el(idChapterAtom, el(idChapterDisplay, str(idChapString, "Nested")))))),
+		el(idAttachments, el(idAttachedFile,
+			str(idFileDescription, "minutes"),
+			str(idFileName, "notes.txt"),
+			str(idFileMimeType, "text/plain"),
+			el(idFileData, []byte("call me on 555-0100")))),
+	)...)
+
+	md, err := Read(bytes.NewReader(file), int64(len(file)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md.DocType != "matroska" {
+		t.Errorf("DocType = %q", md.DocType)
+	}
+	want := map[string]string{
+		"Title":                 "Quarterly review",
+		"MuxingApp":             "libebml",
+		"WritingApp":            "OBS Studio",
+		"TrackName":             "Jane's screen",
+		"COMMENT":               "SSN 123-45-6789",
+		"ARTIST":                "Jane Doe",
+		"ChapterTitle":          "Nested",
+		"AttachmentName":        "notes.txt",
+		"AttachmentDescription": "minutes",
+	}
+	got := map[string]string{}
+	for _, f := range md.Fields {
+		got[f.Name] = f.Value
+		// Every span must hold exactly the value, padding aside.
+		if !bytes.HasPrefix(file[f.Start:f.End], []byte(f.Value)) {
+			t.Errorf("%s span holds %q, not %q", f.Name, file[f.Start:f.End], f.Value)
+		}
+		if bytes.Contains(file[f.Start:f.End], []byte("FRAME")) {
+			t.Errorf("%s span reaches into the cluster", f.Name)
+		}
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("field %s = %q, want %q", k, got[k], v)
+		}
+	}
+	if len(md.Attachments) != 1 {
+		t.Fatalf("attachments = %d, want 1", len(md.Attachments))
+	}
+	a := md.Attachments[0]
+	if a.Name != "notes.txt" || a.MimeType != "text/plain" || a.IsFont() {
+		t.Errorf("attachment = %+v", a)
+	}
+	if string(file[a.Data.Start:a.Data.End]) != "call me on 555-0100" {
+		t.Errorf("attachment data span = %q", file[a.Data.Start:a.Data.End])
+	}
+	if md.Codec != "V_VP9" || md.Width != 1920 || md.Height != 1080 {
+		t.Errorf("video track = %s %dx%d", md.Codec, md.Width, md.Height)
+	}
+	if md.Duration != 90500*time.Millisecond {
+		t.Errorf("Duration = %v", md.Duration)
+	}
+	if !md.DateUTC.Equal(epoch.Add(24 * time.Hour)) {
+		t.Errorf("DateUTC = %v", md.DateUTC)
+	}
+}
+
+// recordingReader notes every range read, so a test can assert a payload was never touched.
+type recordingReader struct {
+	r     *bytes.Reader
+	mu    sync.Mutex
+	reads [][2]int64
+}
+
+func (rr *recordingReader) ReadAt(p []byte, off int64) (int, error) {
+	rr.mu.Lock()
+	rr.reads = append(rr.reads, [2]int64{off, off + int64(len(p))})
+	rr.mu.Unlock()
+	return rr.r.ReadAt(p, off)
+}
+
+// A browser recording: unknown-size Segment and Clusters, with the tags written after the media.
+func TestUnknownSizeClustersAreSteppedOverUnread(t *testing.T) {
+	clusters := append(
+		unknown(idCluster, el(0xE7, []byte{0}), el(0xA3, block), el(0xA3, block)),
+		unknown(idCluster, el(0xE7, []byte{1}), el(0xA3, block))...)
+	file := append(header("webm"), unknown(idSegment,
+		el(idInfo, str(idWritingApp, "Chrome")),
+		clusters,
+		el(idTags, el(idTag, el(idSimpleTag, str(idTagName, "TITLE"), str(idTagString, "after the media")))),
+	)...)
+
+	rr := &recordingReader{r: bytes.NewReader(file)}
+	md, err := Read(rr, int64(len(file)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var titles []string
+	for _, f := range md.Fields {
+		if f.Name == "TITLE" {
+			titles = append(titles, f.Value)
+		}
+	}
+	if len(titles) != 1 || titles[0] != "after the media" {
+		t.Fatalf("TITLE fields = %q; a tag after an unknown-size cluster was missed", titles)
+	}
+
+	for i := 0; ; {
+		j := bytes.Index(file[i:], block)
+		if j < 0 {
+			break
+		}
+		start, end := int64(i+j), int64(i+j+len(block))
+		for _, r := range rr.reads {
+			// A header read may overlap the first bytes of a payload; a payload READ would cover it.
+			if r[0] <= start && r[1] >= end {
+				t.Fatalf("block payload at %d was read", start)
+			}
+			if r[1]-r[0] > 12 && r[0] < end && r[1] > start {
+				t.Fatalf("read %v reaches into a block payload", r)
+			}
+		}
+		i += j + len(block)
+	}
+}
+
+func TestTruncatedFileYieldsWhatPrecedesTheDamage(t *testing.T) {
+	file := append(header("matroska"), el(idSegment,
+		el(idInfo, str(idTitle, "kept")),
+		el(idTags, el(idTag, el(idSimpleTag, str(idTagName, "COMMENT"), str(idTagString, "cut off here")))),
+	)...)
+	file = file[:len(file)-6]
+
+	md, err := Read(bytes.NewReader(file), int64(len(file)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range md.Fields {
+		if f.End > int64(len(file)) {
+			t.Fatalf("%s span %d-%d runs past the file", f.Name, f.Start, f.End)
+		}
+	}
+	if len(md.Fields) == 0 || md.Fields[0].Value != "kept" {
+		t.Fatalf("fields = %+v, want the title before the damage", md.Fields)
+	}
+}
+
+func TestElementBudgetIsAnError(t *testing.T) {
+	// Two-byte void elements: a file of nothing but headers.
+	voids := bytes.Repeat([]byte{0xEC, 0x80}, maxElements+1)
+	file := append(header("matroska"), unknown(idSegment, unknown(idCluster, voids))...)
+	if _, err := Read(bytes.NewReader(file), int64(len(file))); !errors.Is(err, ErrElementBudget) {
+		t.Fatalf("err = %v, want ErrElementBudget", err)
+	}
+}
+
+func TestIsFont(t *testing.T) {
+	for _, a := range []Attachment{
+		{Name: "Arial.ttf", MimeType: "application/octet-stream"},
+		{Name: "x", MimeType: "application/x-truetype-font"},
+		{Name: "x", MimeType: "font/otf"},
+		{Name: "SUB.OTF"},
+	} {
+		if !a.IsFont() {
+			t.Errorf("%+v is a font", a)
+		}
+	}
+	for _, a := range []Attachment{
+		{Name: "cover.jpg", MimeType: "image/jpeg"},
+		{Name: "notes.txt", MimeType: "text/plain"},
+	} {
+		if a.IsFont() {
+			t.Errorf("%+v is not a font", a)
+		}
+	}
+}
+
+func TestHasHeader(t *testing.T) {
+	if !HasHeader(header("webm")) {
+		t.Error("an EBML header was not recognised")
+	}
+	if HasHeader([]byte("RIFF\x00\x00\x00\x00AVI ")) || HasHeader(nil) {
+		t.Error("a non-EBML file was recognised")
+	}
+}
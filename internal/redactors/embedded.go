@@ -234,7 +234,7 @@ func (rm *RedactionManager) RedactEmbedded(req EmbeddedRedactionRequest) (*Embed
 		return nil, fmt.Errorf("%w: %s", ErrNoEmbeddedRedactor, filepath.Ext(req.PartName))
 	}
 
-	result, err := redactor.RedactDocument(inPath, outPath, req.Matches, req.Strategy)
+	result, err := redactor.RedactDocument(inPath, outPath, retargetItemMatches(req.Matches, req.PartName, inPath), req.Strategy)
 	if err != nil {
 		return nil, fmt.Errorf("redacting embedded part %s: %w", filepath.Base(req.PartName), err)
 	}
@@ -264,6 +264,31 @@ func (rm *RedactionManager) RedactEmbedded(req EmbeddedRedactionRequest) (*Embed
 	}, nil
 }
 
+// retargetItemMatches relabels the findings reported against an item WITHIN the
+// part, "outer.docx -> image1.png -> qr[0]", onto the temp file the part's
+// redactor is handed, "embedded.png -> qr[0]": that is the only name the
+// redactor knows its input by, and a redactor that acts on an item (the image
+// redactor blacks out a barcode) must not act on an item of the same name in a
+// sibling part. Every other match is passed through unchanged.
+func retargetItemMatches(matches []detector.Match, partName, childPath string) []detector.Match {
+	marker := " -> " + filepath.Base(filepath.FromSlash(partName)) + " -> "
+	var out []detector.Match
+	for i, m := range matches {
+		at := strings.LastIndex(m.Filename, marker)
+		if at < 0 {
+			continue
+		}
+		if out == nil {
+			out = append([]detector.Match(nil), matches...)
+		}
+		out[i].Filename = filepath.Base(childPath) + " -> " + m.Filename[at+len(marker):]
+	}
+	if out == nil {
+		return matches
+	}
+	return out
+}
+
 // withinDir reports whether path is dir itself or a descendant of it.
 //
 // Both sides are cleaned before comparison, so a "." or ".." element is resolved
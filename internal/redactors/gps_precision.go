@@ -0,0 +1,112 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package redactors
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// GPSPrecision is how many decimal places of a GPS position a metadata redactor keeps when a
+// position was reported. The zero value keeps none: the position is removed, as it always was.
+//
+// Decimal places of a degree rather than a distance, because that is what every encoding this
+// applies to can hold without changing length: an ISO 6709 string, an Exif rational and a 16.16
+// fixed-point word all express a truncated decimal exactly or to within their own resolution. A
+// distance is accepted on the command line and converted — see ParseGPSPrecision.
+type GPSPrecision int
+
+const (
+	// MinGPSPrecision is the coarsest precision kept: one decimal place, about 11 km.
+	MinGPSPrecision GPSPrecision = 1
+	// MaxGPSPrecision is the finest: five decimal places, about 1 m. Finer than that is the
+	// position itself, which keeping would not be a redaction.
+	MaxGPSPrecision GPSPrecision = 5
+)
+
+// metresPerDegree is the length of one degree of latitude, and of longitude at the equator.
+const metresPerDegree = 111320.0
+
+// Enabled reports whether positions are coarsened rather than removed.
+func (p GPSPrecision) Enabled() bool {
+	return p >= MinGPSPrecision && p <= MaxGPSPrecision
+}
+
+// Decimals returns the number of decimal places kept.
+func (p GPSPrecision) Decimals() int {
+	return int(p)
+}
+
+// Metres returns the size of one grid step in metres at the equator: the distance a coarsened
+// position may lie from the true one. It is smaller in longitude away from the equator.
+func (p GPSPrecision) Metres() int {
+	return int(math.Round(metresPerDegree / math.Pow10(int(p))))
+}
+
+// String renders the precision for the audit log and diagnostics.
+func (p GPSPrecision) String() string {
+	if !p.Enabled() {
+		return "removed"
+	}
+	return fmt.Sprintf("%d decimal places (about %s)", int(p), formatMetres(p.Metres()))
+}
+
+// AuditMetadata is what a redactor records on a mapping for a coarsened position, so the audit
+// log says what was kept and not only that something changed.
+func (p GPSPrecision) AuditMetadata() map[string]interface{} {
+	return map[string]interface{}{
+		"gps_precision_decimals": int(p),
+		"gps_precision_metres":   p.Metres(),
+	}
+}
+
+// ParseGPSPrecision reads a precision as a number of decimal places ("2") or as a distance
+// ("1km", "100m"). A distance is converted to the nearest number of decimal places, so "1km"
+// keeps two (about 1.1 km), and one outside the range the encodings can hold is an error rather
+// than clamped: clamping "50km" to one decimal place would keep a position five times finer
+// than was asked for.
+func ParseGPSPrecision(s string) (GPSPrecision, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return 0, nil
+	}
+
+	var p GPSPrecision
+	if n, err := strconv.Atoi(s); err == nil {
+		p = GPSPrecision(n)
+	} else {
+		unit := 1.0
+		num := s
+		switch {
+		case strings.HasSuffix(s, "km"):
+			unit, num = 1000, strings.TrimSuffix(s, "km")
+		case strings.HasSuffix(s, "m"):
+			num = strings.TrimSuffix(s, "m")
+		default:
+			return 0, fmt.Errorf("invalid GPS precision %q: want decimal places (e.g. 2) or a distance (e.g. 1km, 100m)", s)
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(num), 64)
+		if err != nil || value <= 0 || math.IsInf(value, 0) {
+			return 0, fmt.Errorf("invalid GPS precision %q: want decimal places (e.g. 2) or a distance (e.g. 1km, 100m)", s)
+		}
+		p = GPSPrecision(math.Round(math.Log10(metresPerDegree / (value * unit))))
+	}
+
+	if !p.Enabled() {
+		return 0, fmt.Errorf("GPS precision %q is out of range: %d to %d decimal places (about %s to %s)",
+			s, int(MinGPSPrecision), int(MaxGPSPrecision),
+			formatMetres(MinGPSPrecision.Metres()), formatMetres(MaxGPSPrecision.Metres()))
+	}
+	return p, nil
+}
+
+// formatMetres renders a distance in the unit a reader would use for it.
+func formatMetres(m int) string {
+	if m >= 1000 {
+		return fmt.Sprintf("%.1f km", float64(m)/1000)
+	}
+	return fmt.Sprintf("%d m", m)
+}
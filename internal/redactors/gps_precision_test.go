@@ -0,0 +1,36 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package redactors
+
+import "testing"
+
+func TestParseGPSPrecision(t *testing.T) {
+	cases := []struct {
+		in   string
+		want GPSPrecision
+		err  bool
+	}{
+		{"", 0, false},
+		{"2", 2, false},
+		{"1km", 2, false},
+		{"1 KM", 2, false},
+		{"100m", 3, false},
+		{"10km", 1, false},
+		{"1m", 5, false},
+		{"0", 0, true},
+		{"6", 0, true},
+		{"50km", 0, true}, // coarser than one decimal place can hold
+		{"2mi", 0, true},
+		{"-1km", 0, true},
+	}
+	for _, c := range cases {
+		got, err := ParseGPSPrecision(c.in)
+		if (err != nil) != c.err || got != c.want {
+			t.Errorf("ParseGPSPrecision(%q) = %d, %v; want %d, error %v", c.in, got, err, c.want, c.err)
+		}
+	}
+	if s := GPSPrecision(2).String(); s != "2 decimal places (about 1.1 km)" {
+		t.Errorf("String() = %q", s)
+	}
+}
@@ -0,0 +1,460 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package heif redacts metadata in HEIF and AVIF images (.heic/.heif/.avif).
+//
+// Both are ISO base media files, and the metadata is not in a box of its own: the top-level meta
+// box lists items, and iloc says where each item's bytes are — for an Exif block or an XMP
+// packet, usually in mdat between the image tiles. isobmff.MetadataItems reads those tables; this
+// package overwrites the located items in place.
+//
+// # Same length, in place
+//
+// For the reason the video redactor gives. Every item is addressed by absolute offset from iloc,
+// so making one item shorter moves every tile after it and invalidates the table that locates
+// them; re-encoding instead would cost the decoder an HEVC or AV1 encoder this tool does not
+// have. A replacement exactly as long as the value it replaces keeps every offset valid, so the
+// output is the input with some metadata bytes changed and nothing else. Synthetic, whose output
+// length is unrelated to the input's, is declined.
+//
+// # Coordinates are not text
+//
+// The extractor reports an Exif position as decimal degrees computed from RATIONAL values in the
+// GPS IFD, and an XMP position re-formatted from its property text. Neither report appears in the
+// file, so a text search — and the residue check behind it — finds nothing either way. Positions
+// are therefore located structurally by isobmff.ItemCoordinates, filled, and verified by running
+// the same finder over the result, as the video redactor does with isobmff.Coordinates.
+//
+// With --gps-precision a position is coarsened in place instead (isobmff.Coarsen): the Exif
+// rationals are rewritten as truncated degrees and the XMP values keep their shape with the
+// digits past the precision zeroed.
+package heif
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/awslabs/ferret-scan/v2/internal/detector"
+	"github.com/awslabs/ferret-scan/v2/internal/observability"
+	"github.com/awslabs/ferret-scan/v2/internal/redactors"
+	"github.com/awslabs/ferret-scan/v2/internal/redactors/isobmff"
+	"github.com/awslabs/ferret-scan/v2/internal/redactors/tagmeta"
+)
+
+// maxItemBytes caps the total item bytes this redactor will hold in memory at once. An iloc
+// length is attacker-controlled; MetadataItems bounds each extent by the file, and this bounds
+// the sum. Exceeding it is a refusal, not a truncation.
+const maxItemBytes = 10 << 20
+
+// headBytes is how much of the file is read to find the ftyp brands.
+const headBytes = 64
+
+// gpsType is the finding type the metadata validator emits for a position.
+const gpsType = "GPS"
+
+// HEIFRedactor redacts HEIF and AVIF metadata items by same-length in-place overwrite.
+type HEIFRedactor struct {
+	observer      observability.Observer
+	outputManager *redactors.OutputStructureManager
+	gpsPrecision  redactors.GPSPrecision
+}
+
+// NewHEIFRedactor creates a redactor for HEIF and AVIF images.
+func NewHEIFRedactor(outputManager *redactors.OutputStructureManager, observer observability.Observer) *HEIFRedactor {
+	if observer == nil {
+		observer = observability.NewStandardObserver(observability.ObservabilityMetrics, nil)
+	}
+	return &HEIFRedactor{observer: observer, outputManager: outputManager}
+}
+
+// SetGPSPrecision makes the redactor coarsen a reported position to the given precision
+// instead of filling it. Set it before redaction begins.
+func (r *HEIFRedactor) SetGPSPrecision(p redactors.GPSPrecision) {
+	r.gpsPrecision = p
+}
+
+// GetName returns the name of the redactor.
+func (r *HEIFRedactor) GetName() string { return "heif_metadata_redactor" }
+
+// GetComponentName returns the component name for observability.
+func (r *HEIFRedactor) GetComponentName() string { return "heif_metadata_redactor" }
+
+// GetSupportedTypes returns the image types this redactor handles, bare and dotted.
+func (r *HEIFRedactor) GetSupportedTypes() []string {
+	return []string{
+		"heic", ".heic",
+		"heif", ".heif",
+		"avif", ".avif",
+	}
+}
+
+// GetSupportedStrategies reports which strategies this redactor can honour. Synthetic is
+// excluded: it cannot preserve length.
+func (r *HEIFRedactor) GetSupportedStrategies() []redactors.RedactionStrategy {
+	return []redactors.RedactionStrategy{
+		redactors.RedactionSimple,
+		redactors.RedactionFormatPreserving,
+	}
+}
+
+// itemBlock is one metadata item: where its bytes live in the file, and those bytes once
+// modified.
+type itemBlock struct {
+	item     isobmff.Item
+	buf      []byte
+	scrubbed bool // a coordinate in this item was filled
+}
+
+// RedactDocument writes a redacted copy of a HEIF or AVIF image to outputPath.
+func (r *HEIFRedactor) RedactDocument(originalPath string, outputPath string, matches []detector.Match, strategy redactors.RedactionStrategy) (*redactors.RedactionResult, error) {
+	var finishTiming func(bool, map[string]interface{})
+	if r.observer != nil {
+		finishTiming = r.observer.StartTiming(r.GetComponentName(), "redact_document", originalPath)
+	} else {
+		finishTiming = func(bool, map[string]interface{}) {}
+	}
+	defer finishTiming(true, map[string]interface{}{
+		"output_path": outputPath,
+		"match_count": len(matches),
+		"strategy":    strategy.String(),
+	})
+
+	start := time.Now()
+	name := filepath.Base(originalPath)
+
+	src, err := os.Open(filepath.Clean(originalPath)) // #nosec G304 -- path vetted by the router
+	if err != nil {
+		return nil, fmt.Errorf("failed to open image file: %w", err)
+	}
+	defer func() { _ = src.Close() }()
+
+	info, err := src.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat image file: %w", err)
+	}
+	size := info.Size()
+
+	head := make([]byte, headBytes)
+	n, _ := io.ReadFull(src, head)
+	if !isobmff.IsHEIF(head[:n]) {
+		return nil, fmt.Errorf("not a recognised HEIF or AVIF image: %s", name)
+	}
+
+	items, err := isobmff.MetadataItems(src, size)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the item tables of %s: %w", name, err)
+	}
+	if len(items) == 0 {
+		// Writing an identical copy and calling it redacted is the failure this refuses.
+		return nil, fmt.Errorf("no Exif or XMP item found in %s, so its findings could not be removed", name)
+	}
+
+	total := int64(0)
+	for _, it := range items {
+		total += it.Len()
+	}
+	if total > maxItemBytes {
+		return nil, fmt.Errorf("%s declares %d bytes of metadata items, above the %d-byte limit; refusing rather than redacting part of it",
+			name, total, int64(maxItemBytes))
+	}
+
+	matches = redactors.ExpandClusterMatches(matches)
+	matches = redactors.RestoreBoundedMatchText(matches)
+
+	blocks, perMatch, err := r.planBlocks(src, items, matches, strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	gpsHandled, coarsened := r.scrubCoordinates(blocks, matches, perMatch)
+
+	if err := r.refuseIfAnythingUnlocated(name, matches, perMatch, gpsHandled); err != nil {
+		return nil, err
+	}
+	if residual := r.residual(blocks, matches); residual > 0 {
+		return nil, fmt.Errorf("%d reported value(s) remain in the metadata items of %s after redaction; refusing to write a file that would look redacted",
+			residual, name)
+	}
+	if err := verifyCoordinatesScrubbed(blocks, r.gpsPrecision); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	if err := r.write(originalPath, outputPath, size, blocks, name); err != nil {
+		return nil, err
+	}
+
+	mappings := r.mappings(matches, perMatch, gpsHandled, coarsened, strategy)
+
+	return &redactors.RedactionResult{
+		Success:          true,
+		RedactedFilePath: outputPath,
+		RedactionMap:     mappings,
+		ProcessingTime:   time.Since(start),
+		Confidence:       tagmeta.OverallConfidence(mappings),
+		Error:            nil,
+	}, nil
+}
+
+// planBlocks reads each item and computes its overwrites, without writing anything.
+func (r *HEIFRedactor) planBlocks(src io.ReaderAt, items []isobmff.Item, matches []detector.Match, strategy redactors.RedactionStrategy) ([]*itemBlock, []int, error) {
+	blocks := make([]*itemBlock, 0, len(items))
+	perMatch := make([]int, len(matches))
+
+	for _, it := range items {
+		buf, err := isobmff.ReadItem(src, it, maxItemBytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		region := []tagmeta.Region{{Start: 0, End: len(buf), Label: it.Kind}}
+		plan, found := tagmeta.Plan(buf, region, matches, strategy)
+		tagmeta.Apply(buf, plan)
+		for i, n := range found {
+			perMatch[i] += n
+		}
+		blocks = append(blocks, &itemBlock{item: it, buf: buf})
+	}
+	return blocks, perMatch, nil
+}
+
+// scrubCoordinates fills every position in every item when a position was reported and not
+// located as text, and returns whether it filled any.
+//
+// Driven by a reported finding, as in the video redactor: a position nobody flagged is not this
+// redactor's to delete.
+//
+// With a GPS precision set each position is coarsened instead, and the second result reports
+// that at least one was; one that cannot be coarsened at the same length is filled as before.
+func (r *HEIFRedactor) scrubCoordinates(blocks []*itemBlock, matches []detector.Match, perMatch []int) (bool, bool) {
+	reported := false
+	for i, m := range matches {
+		if strings.EqualFold(m.Type, gpsType) && m.Text != "" && perMatch[i] == 0 {
+			reported = true
+			break
+		}
+	}
+	if !reported {
+		return false, false
+	}
+
+	scrubbed, coarsened := 0, 0
+	for _, b := range blocks {
+		fill := isobmff.CoordinateFill(b.item.Kind)
+		for _, c := range isobmff.ItemCoordinates(b.item.Kind, b.buf) {
+			if c.Start < 0 || c.End > int64(len(b.buf)) || c.Start >= c.End {
+				continue
+			}
+			b.scrubbed = true
+			if r.gpsPrecision.Enabled() && isobmff.Coarsen(b.buf, c, r.gpsPrecision.Decimals()) {
+				coarsened++
+				continue
+			}
+			for i := c.Start; i < c.End; i++ {
+				b.buf[i] = fill
+			}
+			scrubbed++
+		}
+	}
+	if scrubbed > 0 {
+		r.logEvent("heif_coordinates_scrubbed", true, map[string]interface{}{
+			"values": scrubbed,
+			"items":  len(blocks),
+		})
+	}
+	if coarsened > 0 {
+		meta := r.gpsPrecision.AuditMetadata()
+		meta["values"] = coarsened
+		r.logEvent("heif_coordinates_precision_reduced", true, meta)
+	}
+	return scrubbed+coarsened > 0, coarsened > 0
+}
+
+// refuseIfAnythingUnlocated stops the redaction when a reported value was neither found in the
+// item bytes nor handled structurally. Only the TYPE is named, never the value.
+func (r *HEIFRedactor) refuseIfAnythingUnlocated(name string, matches []detector.Match, perMatch []int, gpsHandled bool) error {
+	var unlocated []string
+	for i, m := range matches {
+		if m.Text == "" || perMatch[i] > 0 {
+			continue
+		}
+		if strings.EqualFold(m.Type, gpsType) && gpsHandled {
+			continue
+		}
+		unlocated = append(unlocated, m.Type)
+	}
+	if len(unlocated) == 0 {
+		return nil
+	}
+	r.logEvent("heif_match_not_located", false, map[string]interface{}{
+		"types": strings.Join(unlocated, ","),
+		"count": len(unlocated),
+	})
+	return fmt.Errorf("%d reported value(s) of type %s could not be located in the metadata items of %s, so they could not be removed",
+		len(unlocated), strings.Join(unlocated, ","), name)
+}
+
+// residual re-checks every item for reported values after the overwrites, counted per match.
+func (r *HEIFRedactor) residual(blocks []*itemBlock, matches []detector.Match) int {
+	residual := 0
+	for _, m := range matches {
+		one := []detector.Match{m}
+		for _, b := range blocks {
+			region := []tagmeta.Region{{Start: 0, End: len(b.buf), Label: b.item.Kind}}
+			if tagmeta.Residual(b.buf, region, one) > 0 {
+				residual++
+				break
+			}
+		}
+	}
+	return residual
+}
+
+// verifyCoordinatesScrubbed re-runs the coordinate finder on every scrubbed item and asserts each
+// position it finds holds nothing but the fill. The text residue check cannot see this half of
+// the redaction: the reported position is absent from the file whether or not it was filled.
+// With a precision set, a position may instead be at that precision.
+func verifyCoordinatesScrubbed(blocks []*itemBlock, precision redactors.GPSPrecision) error {
+	for _, b := range blocks {
+		if !b.scrubbed {
+			continue
+		}
+		fill := isobmff.CoordinateFill(b.item.Kind)
+		for _, c := range isobmff.ItemCoordinates(b.item.Kind, b.buf) {
+			if c.Start < 0 || c.End > int64(len(b.buf)) || c.Start >= c.End {
+				continue
+			}
+			if precision.Enabled() && isobmff.AtPrecision(b.buf, c, precision.Decimals()) {
+				continue
+			}
+			for i := c.Start; i < c.End; i++ {
+				if b.buf[i] != fill {
+					return fmt.Errorf("a position in the %s item %d was not cleared; refusing to write a file whose position survived",
+						b.item.Kind, b.item.ID)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// write copies the file and overwrites each item's extents in place with the modified bytes.
+func (r *HEIFRedactor) write(originalPath, outputPath string, size int64, blocks []*itemBlock, name string) error {
+	if r.outputManager != nil {
+		if err := r.outputManager.EnsureDirectoryExists(outputPath); err != nil {
+			return fmt.Errorf("failed to ensure output directory: %w", err)
+		}
+	}
+
+	src, err := os.Open(filepath.Clean(originalPath)) // #nosec G304 -- path vetted by the router
+	if err != nil {
+		return fmt.Errorf("failed to reopen image file: %w", err)
+	}
+	defer func() { _ = src.Close() }()
+
+	// #nosec G304 G302 -- output path comes from the output manager; 0600 keeps the redacted
+	// copy as restricted as every other redactor's.
+	dst, err := os.OpenFile(filepath.Clean(outputPath), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create redacted file: %w", err)
+	}
+
+	fail := func(err error) error {
+		_ = dst.Close()
+		_ = os.Remove(outputPath)
+		return err
+	}
+
+	if _, err := io.Copy(dst, src); err != nil {
+		return fail(fmt.Errorf("failed to copy image file: %w", err))
+	}
+	for _, b := range blocks {
+		at := int64(0)
+		for _, e := range b.item.Extents {
+			if _, err := dst.WriteAt(b.buf[at:at+e.Len()], e.Start); err != nil {
+				return fail(fmt.Errorf("failed to write redacted metadata at offset %d: %w", e.Start, err))
+			}
+			at += e.Len()
+		}
+	}
+	if err := dst.Sync(); err != nil {
+		return fail(fmt.Errorf("failed to flush redacted file: %w", err))
+	}
+
+	if info, err := dst.Stat(); err != nil {
+		return fail(fmt.Errorf("failed to stat redacted file: %w", err))
+	} else if info.Size() != size {
+		return fail(fmt.Errorf("internal error: HEIF redaction changed file size from %d to %d bytes", size, info.Size()))
+	}
+
+	// Verify the output, not just the buffers that were meant to become it.
+	for _, b := range blocks {
+		got, err := isobmff.ReadItem(dst, b.item, maxItemBytes)
+		if err != nil {
+			return fail(fmt.Errorf("%s: %w", name, err))
+		}
+		if string(got) != string(b.buf) {
+			return fail(fmt.Errorf("%s: the redacted %s item %d is not what was written", name, b.item.Kind, b.item.ID))
+		}
+	}
+
+	if err := dst.Close(); err != nil {
+		_ = os.Remove(outputPath)
+		return fmt.Errorf("failed to close redacted file: %w", err)
+	}
+	return nil
+}
+
+// mappings records one entry per value this redactor actually wrote over.
+func (r *HEIFRedactor) mappings(matches []detector.Match, perMatch []int, gpsHandled, coarsened bool, strategy redactors.RedactionStrategy) []redactors.RedactionMapping {
+	var mappings []redactors.RedactionMapping
+	for i, m := range matches {
+		if m.Text == "" {
+			continue
+		}
+		method := "heif_item_same_length_overwrite"
+		occurrences := perMatch[i]
+		if occurrences == 0 {
+			if !gpsHandled || !strings.EqualFold(m.Type, gpsType) {
+				continue
+			}
+			method = "heif_coordinates_scrubbed"
+			occurrences = 1
+		}
+		meta := map[string]interface{}{
+			"occurrences":     occurrences,
+			"position_method": method,
+		}
+		if method == "heif_coordinates_scrubbed" && coarsened {
+			meta["position_method"] = "heif_coordinates_precision_reduced"
+			for k, v := range r.gpsPrecision.AuditMetadata() {
+				meta[k] = v
+			}
+		}
+		mappings = append(mappings, redactors.RedactionMapping{
+			RedactedText: tagmeta.SameLengthReplacement(m.Text, m.Type, strategy),
+			DataType:     m.Type,
+			Strategy:     strategy,
+			Confidence:   m.Confidence,
+			Metadata:     meta,
+		})
+	}
+	return mappings
+}
+
+func (r *HEIFRedactor) logEvent(op string, success bool, meta map[string]interface{}) {
+	if r.observer == nil {
+		return
+	}
+	r.observer.LogOperation(observability.StandardObservabilityData{
+		Component: r.GetComponentName(),
+		Operation: op,
+		Success:   success,
+		Metadata:  meta,
+	})
+}
+
+// compile-time check that this satisfies the interface the manager requires.
+var _ redactors.Redactor = (*HEIFRedactor)(nil)
@@ -0,0 +1,245 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package heif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/awslabs/ferret-scan/v2/internal/detector"
+	"github.com/awslabs/ferret-scan/v2/internal/redactors"
+	"github.com/awslabs/ferret-scan/v2/internal/redactors/isobmff"
+)
+
+const testSSN = "452-11-9384"
+
+var testXMP = []byte(`<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` +
+	`<rdf:Description xmlns:exif="http://ns.adobe.com/exif/1.0/" exif:GPSLatitude="37,46.494N">` +
+	`<exif:GPSLongitude>122,25.164W</exif:GPSLongitude></rdf:Description></rdf:RDF></x:xmpmeta>`)
+
+func atom(kind string, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	out := binary.BigEndian.AppendUint32(nil, uint32(8+len(body)))
+	out = append(out, kind...)
+	return append(out, body...)
+}
+
+func u16(v int) []byte { return binary.BigEndian.AppendUint16(nil, uint16(v)) }
+func u32(v int) []byte { return binary.BigEndian.AppendUint32(nil, uint32(v)) }
+
+// exifItem builds an Exif item holding an Artist and a GPS latitude, the way an iPhone lays it
+// out: a 4-byte offset, "Exif\0\0" and a TIFF block.
+func exifItem(artist string) []byte {
+	be := binary.BigEndian
+	entry := func(tag, typ, count, value int) []byte {
+		e := be.AppendUint16(nil, uint16(tag))
+		e = be.AppendUint16(e, uint16(typ))
+		e = be.AppendUint32(e, uint32(count))
+		return be.AppendUint32(e, uint32(value))
+	}
+	tiff := []byte("MM\x00*\x00\x00\x00\x08")
+	tiff = append(tiff, u16(2)...)
+	tiff = append(tiff, entry(0x013B, 2, len(artist)+1, 92)...)
+	tiff = append(tiff, entry(0x8825, 4, 1, 38)...)
+	tiff = append(tiff, u32(0)...)
+	tiff = append(tiff, u16(2)...) // GPS IFD at 38
+	tiff = append(tiff, entry(1, 2, 2, 'N'<<24)...)
+	tiff = append(tiff, entry(2, 5, 3, 68)...)
+	tiff = append(tiff, u32(0)...)
+	for _, v := range []int{37, 1, 46, 1, 2964, 100} { // at 68
+		tiff = append(tiff, u32(v)...)
+	}
+	tiff = append(append(tiff, artist...), 0) // at 92
+	return append([]byte("\x00\x00\x00\x06Exif\x00\x00"), tiff...)
+}
+
+// heicWith lays out an image item, an Exif item and an XMP item in mdat, addressed by iloc.
+func heicWith(t *testing.T, exif, xmp []byte) string {
+	t.Helper()
+	ftyp := atom("ftyp", []byte("heic"), u32(0), []byte("mif1heic"))
+	pixels := bytes.Repeat([]byte{0xAB}, 256)
+	infe := func(id int, itemType, contentType string) []byte {
+		body := bytes.Join([][]byte{{2, 0, 0, 0}, u16(id), u16(0), []byte(itemType), {0}}, nil)
+		if contentType != "" {
+			body = append(append(body, contentType...), 0)
+		}
+		return atom("infe", body)
+	}
+	build := func(at int) []byte {
+		loc := func(id, off, n int) []byte {
+			return bytes.Join([][]byte{u16(id), u16(0), u16(1), u32(off), u32(n)}, nil)
+		}
+		return atom("meta", []byte{0, 0, 0, 0},
+			atom("iinf", []byte{0, 0, 0, 0}, u16(3), infe(1, "hvc1", ""), infe(2, "Exif", ""), infe(3, "mime", "application/rdf+xml")),
+			atom("iloc", []byte{0, 0, 0, 0, 0x44, 0x00}, u16(3),
+				loc(1, at, len(pixels)), loc(2, at+len(pixels), len(exif)), loc(3, at+len(pixels)+len(exif), len(xmp))))
+	}
+	meta := build(0)
+	meta = build(len(ftyp) + len(meta) + 8)
+	file := bytes.Join([][]byte{ftyp, meta, atom("mdat", pixels, exif, xmp)}, nil)
+
+	p := filepath.Join(t.TempDir(), "IMG_0001.HEIC")
+	if err := os.WriteFile(p, file, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func redact(t *testing.T, src string, matches []detector.Match) ([]byte, *redactors.RedactionResult, error) {
+	t.Helper()
+	out := filepath.Join(t.TempDir(), "redacted.heic")
+	res, err := NewHEIFRedactor(nil, nil).RedactDocument(src, out, matches, redactors.RedactionFormatPreserving)
+	if err != nil {
+		if _, statErr := os.Stat(out); !os.IsNotExist(statErr) {
+			t.Errorf("a refused redaction left an output file behind")
+		}
+		return nil, nil, err
+	}
+	got, err := os.ReadFile(out) // #nosec G304 -- test-controlled temp path
+	if err != nil {
+		t.Fatal(err)
+	}
+	return got, res, nil
+}
+
+// TestRedactOverwritesItemsAndLeavesThePixelsAlone is the whole contract: the reported text is
+// gone, the position is zeroed, and every byte outside the two metadata items is the input's.
+func TestRedactOverwritesItemsAndLeavesThePixelsAlone(t *testing.T) {
+	exif := exifItem("Ann Lee SSN " + testSSN)
+	src := heicWith(t, exif, testXMP)
+	orig, _ := os.ReadFile(src) // #nosec G304 -- test-controlled temp path
+
+	got, res, err := redact(t, src, []detector.Match{
+		{Type: "SSN", Text: testSSN, Confidence: 90},
+		{Type: "GPS", Text: "GPSLatitudeDecimal: 37.774900", Confidence: 90},
+	})
+	if err != nil {
+		t.Fatalf("RedactDocument: %v", err)
+	}
+	if len(got) != len(orig) {
+		t.Fatalf("size changed from %d to %d", len(orig), len(got))
+	}
+	if bytes.Contains(got, []byte(testSSN)) {
+		t.Error("the SSN survived")
+	}
+	for _, pos := range []string{"37,46.494N", "122,25.164W"} {
+		if bytes.Contains(got, []byte(pos)) {
+			t.Errorf("the XMP position %q survived", pos)
+		}
+	}
+
+	items, err := isobmff.MetadataItems(bytes.NewReader(got), int64(len(got)))
+	if err != nil || len(items) != 2 {
+		t.Fatalf("the output's item tables no longer parse: %v %+v", err, items)
+	}
+	for _, it := range items {
+		buf, err := isobmff.ReadItem(bytes.NewReader(got), it, maxItemBytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range isobmff.ItemCoordinates(it.Kind, buf) {
+			if strings.Trim(string(buf[c.Start:c.End]), "\x00 ") != "" {
+				t.Errorf("%s position %q survived", it.Kind, buf[c.Start:c.End])
+			}
+		}
+	}
+
+	// Every differing byte is inside the Exif or XMP item.
+	exifAt := bytes.Index(orig, exif)
+	xmpAt := bytes.Index(orig, testXMP)
+	for i := range orig {
+		if orig[i] == got[i] {
+			continue
+		}
+		inExif := i >= exifAt && i < exifAt+len(exif)
+		inXMP := i >= xmpAt && i < xmpAt+len(testXMP)
+		if !inExif && !inXMP {
+			t.Fatalf("byte %d outside the metadata items changed", i)
+		}
+	}
+
+	methods := map[string]string{}
+	for _, m := range res.RedactionMap {
+		methods[m.DataType] = m.Metadata["position_method"].(string)
+	}
+	if methods["SSN"] != "heif_item_same_length_overwrite" || methods["GPS"] != "heif_coordinates_scrubbed" {
+		t.Errorf("position methods = %v", methods)
+	}
+}
+
+// TestPositionIsCoarsenedWhenAPrecisionIsSet keeps a city-level position: the Exif latitude
+// becomes 3777/100 degrees with zero minutes and seconds, and the XMP values keep their shape.
+func TestPositionIsCoarsenedWhenAPrecisionIsSet(t *testing.T) {
+	src := heicWith(t, exifItem("Ann Lee"), testXMP)
+	out := filepath.Join(t.TempDir(), "redacted.heic")
+	r := NewHEIFRedactor(nil, nil)
+	r.SetGPSPrecision(2)
+	res, err := r.RedactDocument(src, out, []detector.Match{{Type: "GPS", Text: "GPSLatitudeDecimal: 37.774900", Confidence: 90}},
+		redactors.RedactionFormatPreserving)
+	if err != nil {
+		t.Fatalf("RedactDocument: %v", err)
+	}
+	got, err := os.ReadFile(out) // #nosec G304 -- test-controlled temp path
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, pos := range []string{"37,46.200N", "122,24.600W"} {
+		if !bytes.Contains(got, []byte(pos)) {
+			t.Errorf("the XMP position was not coarsened to %q", pos)
+		}
+	}
+	rationals := bytes.Join([][]byte{u32(3777), u32(100), u32(0), u32(1), u32(0), u32(1)}, nil)
+	if !bytes.Contains(got, rationals) {
+		t.Error("the Exif latitude was not rewritten as 37.77 degrees")
+	}
+	if !bytes.Contains(got, []byte("Ann Lee")) {
+		t.Error("an unreported Exif value was changed")
+	}
+	meta := res.RedactionMap[0].Metadata
+	if meta["position_method"] != "heif_coordinates_precision_reduced" || meta["gps_precision_metres"] != 1113 {
+		t.Errorf("audit metadata = %v", meta)
+	}
+}
+
+// A position nobody reported is not removed.
+func TestUnreportedPositionIsLeftAlone(t *testing.T) {
+	src := heicWith(t, exifItem("Ann Lee SSN "+testSSN), testXMP)
+	got, _, err := redact(t, src, []detector.Match{{Type: "SSN", Text: testSSN, Confidence: 90}})
+	if err != nil {
+		t.Fatalf("RedactDocument: %v", err)
+	}
+	if !bytes.Contains(got, []byte("37,46.494N")) {
+		t.Error("an unreported XMP position was removed")
+	}
+}
+
+func TestUnlocatableValueIsRefused(t *testing.T) {
+	src := heicWith(t, exifItem("Ann Lee"), testXMP)
+	if _, _, err := redact(t, src, []detector.Match{{Type: "EMAIL", Text: "nobody@example.com", Confidence: 90}}); err == nil {
+		t.Fatal("a value absent from every item was reported as redacted")
+	}
+}
+
+func TestNotAHEIFImageIsRefused(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "fake.heic")
+	if err := os.WriteFile(p, []byte("SSN "+testSSN+" in a text file with the wrong name"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := redact(t, p, []detector.Match{{Type: "SSN", Text: testSSN}}); err == nil {
+		t.Fatal("a file that is not HEIF was accepted")
+	}
+}
+
+func TestSyntheticStrategyIsNotAdvertised(t *testing.T) {
+	for _, s := range NewHEIFRedactor(nil, nil).GetSupportedStrategies() {
+		if s == redactors.RedactionSynthetic {
+			t.Error("synthetic is advertised; it cannot preserve length")
+		}
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package image
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"path/filepath"
+	"strings"
+
+	"github.com/awslabs/ferret-scan/v2/internal/detector"
+	textextractbarcodelib "github.com/awslabs/ferret-scan/v2/internal/preprocessors/text-extractors/text-extract-barcodelib"
+	"github.com/awslabs/ferret-scan/v2/internal/redactors"
+)
+
+// barcodeRedaction is what a file's matches ask of its pixels: the symbol
+// labels findings were reported against ("qr[0]"), and the values found in
+// them.
+type barcodeRedaction struct {
+	labels map[string]bool
+	values []string
+}
+
+// barcodeRedactionFor collects the matches reported against a symbol of the
+// image at imagePath ("screenshot.png -> qr[0]"). A match in the image's
+// metadata is not one: stripping the metadata removes it, and the pixels are
+// left alone.
+//
+// The value of a match reported against a symbol of ANOTHER image, a sibling
+// part of the same document, is collected too, without its label: the same
+// value printed in a symbol here is found by value.
+func barcodeRedactionFor(matches []detector.Match, imagePath string) *barcodeRedaction {
+	name := filepath.Base(imagePath)
+	var br *barcodeRedaction
+	for _, m := range matches {
+		label, ok := textextractbarcodelib.LabelOf(m.Filename)
+		if !ok {
+			continue
+		}
+		if br == nil {
+			br = &barcodeRedaction{labels: make(map[string]bool)}
+		}
+		if owner := strings.TrimSuffix(m.Filename, " -> "+label); owner == name || strings.HasSuffix(owner, " -> "+name) {
+			br.labels[label] = true
+		}
+		if m.Text != "" {
+			br.values = append(br.values, m.Text)
+		}
+	}
+	if br != nil && len(br.labels) == 0 && len(br.values) == 0 {
+		return nil
+	}
+	return br
+}
+
+// targets returns the symbols to black out: every symbol a finding names, and
+// any other symbol holding one of the same values. Findings are deduplicated,
+// so a value printed in two symbols can be reported against only the first.
+//
+// A label no symbol answers to is an error. The symbols are found by the same
+// decoder, over the same pixels, that produced the finding, so a missing one
+// means the pixels were not the ones scanned, and blacking out the rest would
+// report a redaction that missed the value.
+func (br *barcodeRedaction) targets(symbols []textextractbarcodelib.Symbol) ([]textextractbarcodelib.Symbol, error) {
+	var out []textextractbarcodelib.Symbol
+	seen := make(map[string]bool)
+	for _, s := range symbols {
+		if br.labels[s.Label()] || br.holdsValue(s) {
+			out = append(out, s)
+			seen[s.Label()] = true
+		}
+	}
+	for label := range br.labels {
+		if !seen[label] {
+			return nil, fmt.Errorf("the barcode %s a finding was reported against was not found in the image", label)
+		}
+	}
+	return out, nil
+}
+
+// holdsValue reports whether a symbol's payload contains a redacted value.
+func (br *barcodeRedaction) holdsValue(s textextractbarcodelib.Symbol) bool {
+	text := s.ScanText()
+	for _, v := range br.values {
+		if strings.Contains(text, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// blackOutBarcodes fills the bounds of each targeted symbol with black, then
+// decodes the result again and fails if any symbol still holding a redacted
+// value can be read. The check is what makes the bounds safe to trust: a mask
+// that falls a module short can leave a symbol decodable, and a redaction that
+// did not happen must not be reported as one.
+//
+// img is modified in place when it is drawable; the image to encode is
+// returned either way. A paletted image (a GIF) is filled with the palette's
+// closest colour to black, so its palette and size are kept.
+func blackOutBarcodes(img image.Image, br *barcodeRedaction, format ImageFormat, strategy redactors.RedactionStrategy) (image.Image, []redactors.RedactionMapping, error) {
+	symbols := textextractbarcodelib.Decode(img)
+	targets, err := br.targets(symbols)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	canvas, ok := img.(draw.Image)
+	if !ok {
+		rgba := image.NewRGBA(img.Bounds())
+		draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+		canvas = rgba
+	}
+	var black color.Color = color.Black
+	if p, ok := canvas.(*image.Paletted); ok {
+		black = p.Palette.Convert(color.Black)
+	}
+	mappings := make([]redactors.RedactionMapping, 0, len(targets))
+	for _, s := range targets {
+		draw.Draw(canvas, s.Bounds, image.NewUniform(black), image.Point{}, draw.Src)
+		mappings = append(mappings, redactors.RedactionMapping{
+			RedactedText: "[BARCODE-REDACTED]",
+			Position: redactors.TextPosition{
+				Line:      0,
+				StartChar: 0,
+				EndChar:   len(s.Text),
+			},
+			DataType:   "BARCODE",
+			Strategy:   strategy,
+			Confidence: 1.0,
+
+			Metadata: map[string]interface{}{
+				"symbol":       s.Label(),
+				"barcode_type": string(s.Format),
+				"bounds": fmt.Sprintf("%d,%d,%d,%d",
+					s.Bounds.Min.X, s.Bounds.Min.Y, s.Bounds.Max.X, s.Bounds.Max.Y),
+				"image_format": format.String(),
+			},
+		})
+	}
+
+	for _, s := range textextractbarcodelib.Decode(canvas) {
+		if br.holdsValue(s) || overlapsAny(s.Bounds, targets) {
+			return nil, nil, fmt.Errorf("a barcode still decodes where %s was blacked out", s.Label())
+		}
+	}
+	return canvas, mappings, nil
+}
+
+// overlapsAny reports whether r overlaps the bounds of any of symbols.
+func overlapsAny(r image.Rectangle, symbols []textextractbarcodelib.Symbol) bool {
+	for _, s := range symbols {
+		if r.Overlaps(s.Bounds) {
+			return true
+		}
+	}
+	return false
+}
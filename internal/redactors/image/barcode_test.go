@@ -0,0 +1,167 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package image
+
+import (
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/awslabs/ferret-scan/v2/internal/detector"
+	textextractbarcodelib "github.com/awslabs/ferret-scan/v2/internal/preprocessors/text-extractors/text-extract-barcodelib"
+	"github.com/awslabs/ferret-scan/v2/internal/redactors"
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/qrcode"
+)
+
+const (
+	otpPayload  = "otpauth://totp/Example:alice@example.com?secret=JBSWY3DPEHPK3PXP&issuer=Example"
+	menuPayload = "https://example.com/menu"
+)
+
+// twoQRCodes is a white image with a QR code holding a TOTP secret above one
+// holding a harmless URL, so the secret's symbol is qr[0].
+func twoQRCodes(t *testing.T) *image.RGBA {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 400, 720))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	for i, text := range []string{otpPayload, menuPayload} {
+		m, err := qrcode.NewQRCodeWriter().Encode(text, gozxing.BarcodeFormat_QR_CODE, 300, 300, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		at := image.Pt(50, 30+i*360)
+		draw.Draw(img, m.Bounds().Add(at), m, image.Point{}, draw.Src)
+	}
+	return img
+}
+
+func secretMatch(path string) []detector.Match {
+	return []detector.Match{{
+		Text:     "JBSWY3DPEHPK3PXP",
+		Type:     "SECRETS",
+		Filename: filepath.Base(path) + " -> qr[0]",
+	}}
+}
+
+func decodedTexts(t *testing.T, path string) []string {
+	t.Helper()
+	symbols, err := textextractbarcodelib.DecodeFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var texts []string
+	for _, s := range symbols {
+		texts = append(texts, s.Text)
+	}
+	return texts
+}
+
+func TestBarcodeFindingIsBlackedOutAndTheOtherSymbolKept(t *testing.T) {
+	for _, ext := range []string{"png", "jpg", "gif"} {
+		t.Run(ext, func(t *testing.T) {
+			dir := t.TempDir()
+			in := filepath.Join(dir, "screenshot."+ext)
+			out := filepath.Join(dir, "out."+ext)
+			f, err := os.Create(in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			img := twoQRCodes(t)
+			switch ext {
+			case "png":
+				err = png.Encode(f, img)
+			case "jpg":
+				err = jpeg.Encode(f, img, &jpeg.Options{Quality: 90})
+			case "gif":
+				err = gif.Encode(f, img, &gif.Options{NumColors: 256, Drawer: draw.Src})
+			}
+			f.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := NewImageMetadataRedactor(nil, nil).RedactDocument(in, out, secretMatch(in), redactors.RedactionSimple)
+			if err != nil {
+				t.Fatalf("RedactDocument: %v", err)
+			}
+			if got := decodedTexts(t, out); len(got) != 1 || got[0] != menuPayload {
+				t.Errorf("symbols left in the output = %q, want only the menu URL", got)
+			}
+			var barcodes []redactors.RedactionMapping
+			for _, m := range result.RedactionMap {
+				if m.DataType == "BARCODE" {
+					barcodes = append(barcodes, m)
+				}
+			}
+			if len(barcodes) != 1 || barcodes[0].Metadata["symbol"] != "qr[0]" || barcodes[0].RedactedText != "[BARCODE-REDACTED]" {
+				t.Fatalf("barcode mappings = %+v", barcodes)
+			}
+			if strings.Contains(barcodes[0].RedactedText+barcodes[0].Metadata["bounds"].(string), "JBSWY") {
+				t.Error("the audit entry carries the payload")
+			}
+		})
+	}
+}
+
+// A finding in the image's metadata names the image, not a symbol, and leaves
+// the pixels alone.
+func TestMetadataFindingLeavesTheSymbolsAlone(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "screenshot.png")
+	out := filepath.Join(dir, "out.png")
+	f, err := os.Create(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(f, twoQRCodes(t)); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	matches := []detector.Match{{Text: "JBSWY3DPEHPK3PXP", Filename: "screenshot.png"}}
+	if _, err := NewImageMetadataRedactor(nil, nil).RedactDocument(in, out, matches, redactors.RedactionSimple); err != nil {
+		t.Fatal(err)
+	}
+	if got := decodedTexts(t, out); len(got) != 2 {
+		t.Errorf("symbols left in the output = %q, want both", got)
+	}
+}
+
+func TestBarcodeInAnAnimatedGIFIsRefused(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "screenshot.gif")
+	out := filepath.Join(dir, "out.gif")
+	src := twoQRCodes(t)
+	var anim gif.GIF
+	for i := 0; i < 2; i++ {
+		frame := image.NewPaletted(src.Bounds(), palette.Plan9)
+		draw.Draw(frame, frame.Bounds(), src, image.Point{}, draw.Src)
+		anim.Image = append(anim.Image, frame)
+		anim.Delay = append(anim.Delay, 10)
+	}
+	f, err := os.Create(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := gif.EncodeAll(f, &anim); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	_, err = NewImageMetadataRedactor(nil, nil).RedactDocument(in, out, secretMatch(in), redactors.RedactionSimple)
+	if err == nil || !strings.Contains(err.Error(), "animated") {
+		t.Fatalf("RedactDocument = %v, want a refusal naming the animation", err)
+	}
+	if _, statErr := os.Stat(out); !os.IsNotExist(statErr) {
+		t.Errorf("the refused output was left behind: %v", statErr)
+	}
+}
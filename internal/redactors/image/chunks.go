@@ -0,0 +1,374 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package image
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// droppedChunk records one piece of metadata removed from a container.
+type droppedChunk struct {
+	kind   string // metadata_type of the mapping: "xmp", "exif", "png_text", ...
+	field  string // the chunk or extension it came from
+	length int
+}
+
+// PNG chunks that say how to draw the pixels. Every other ancillary chunk is
+// metadata (tEXt, zTXt, iTXt, eXIf, tIME) or private, and is dropped; a
+// decoder must ignore an ancillary chunk it does not know, so dropping one
+// never changes the image. Critical chunks are always kept.
+var pngKeptAncillary = map[string]bool{
+	"tRNS": true, "cHRM": true, "gAMA": true, "iCCP": true, "sBIT": true,
+	"sRGB": true, "cICP": true, "mDCv": true, "cLLi": true, "bKGD": true,
+	"hIST": true, "pHYs": true, "sPLT": true,
+	"acTL": true, "fcTL": true, "fdAT": true, // APNG animation
+}
+
+var pngSignature = []byte{0x89, 'P', 'N', 'G', 0x0D, 0x0A, 0x1A, 0x0A}
+
+// stripPNG copies a PNG chunk by chunk, dropping every ancillary chunk not in
+// pngKeptAncillary. Kept chunks are copied verbatim, CRC included, so the image
+// data is never decoded. Anything after IEND is dropped too.
+func stripPNG(r io.Reader, w io.Writer) ([]droppedChunk, error) {
+	sig := make([]byte, len(pngSignature))
+	if _, err := io.ReadFull(r, sig); err != nil || !bytes.Equal(sig, pngSignature) {
+		return nil, errors.New("not a PNG file")
+	}
+	if _, err := w.Write(sig); err != nil {
+		return nil, err
+	}
+	var dropped []droppedChunk
+	var hdr [8]byte
+	for {
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			return nil, fmt.Errorf("truncated PNG: %w", err)
+		}
+		length := int64(binary.BigEndian.Uint32(hdr[:4]))
+		typ := string(hdr[4:8])
+		critical := hdr[4]&0x20 == 0
+		if !critical && !pngKeptAncillary[typ] {
+			if _, err := io.CopyN(io.Discard, r, length+4); err != nil {
+				return nil, fmt.Errorf("truncated PNG: %w", err)
+			}
+			dropped = append(dropped, droppedChunk{kind: pngChunkKind(typ), field: typ, length: int(length)})
+			continue
+		}
+		if _, err := w.Write(hdr[:]); err != nil {
+			return nil, err
+		}
+		if _, err := io.CopyN(w, r, length+4); err != nil {
+			return nil, fmt.Errorf("truncated PNG: %w", err)
+		}
+		if typ == "IEND" {
+			return dropped, nil
+		}
+	}
+}
+
+func pngChunkKind(typ string) string {
+	switch typ {
+	case "tEXt", "zTXt", "iTXt":
+		return "png_text"
+	case "eXIf":
+		return "exif"
+	case "tIME":
+		return "png_time"
+	default:
+		return "png_private"
+	}
+}
+
+// WebP chunks that hold the image. EXIF, "XMP " and unknown chunks are
+// dropped.
+var webpKeptChunks = map[string]bool{
+	"VP8 ": true, "VP8L": true, "VP8X": true, "ALPH": true,
+	"ANIM": true, "ANMF": true, "ICCP": true,
+}
+
+// VP8X feature flags announcing chunks that stripWebP removes.
+const (
+	vp8xXMPFlag  = 0x04
+	vp8xEXIFFlag = 0x08
+)
+
+// stripWebP copies a WebP chunk by chunk without its EXIF and XMP chunks,
+// rewriting the RIFF size and clearing the VP8X flags that announced them.
+// Anything after the RIFF payload is dropped.
+func stripWebP(r io.ReadSeeker, w io.Writer) ([]droppedChunk, error) {
+	var riff [12]byte
+	if _, err := io.ReadFull(r, riff[:]); err != nil || string(riff[:4]) != "RIFF" || string(riff[8:]) != "WEBP" {
+		return nil, errors.New("not a WebP file")
+	}
+	end := int64(binary.LittleEndian.Uint32(riff[4:8])) + 8
+
+	// First pass: find the chunks to keep, so the new RIFF size can be written
+	// ahead of them.
+	type chunk struct {
+		offset int64
+		size   int64 // header and padded payload
+	}
+	var kept []chunk
+	var dropped []droppedChunk
+	pos := int64(12)
+	var hdr [8]byte
+	for pos+8 <= end {
+		if _, err := r.Seek(pos, io.SeekStart); err != nil {
+			return nil, err
+		}
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			return nil, fmt.Errorf("truncated WebP: %w", err)
+		}
+		length := int64(binary.LittleEndian.Uint32(hdr[4:]))
+		size := 8 + length + length&1
+		if pos+size > end {
+			return nil, errors.New("truncated WebP: a chunk runs past the RIFF payload")
+		}
+		fourCC := string(hdr[:4])
+		if webpKeptChunks[fourCC] {
+			kept = append(kept, chunk{offset: pos, size: size})
+		} else {
+			kind := "webp_private"
+			switch fourCC {
+			case "EXIF":
+				kind = "exif"
+			case "XMP ":
+				kind = "xmp"
+			}
+			dropped = append(dropped, droppedChunk{kind: kind, field: fourCC, length: int(length)})
+		}
+		pos += size
+	}
+	if len(kept) == 0 {
+		return nil, errors.New("WebP file holds no image chunk")
+	}
+
+	total := int64(4)
+	for _, c := range kept {
+		total += c.size
+	}
+	if total > 0xFFFFFFFF {
+		return nil, errors.New("WebP file too large")
+	}
+	binary.LittleEndian.PutUint32(riff[4:8], uint32(total))
+	if _, err := w.Write(riff[:]); err != nil {
+		return nil, err
+	}
+	for _, c := range kept {
+		if _, err := r.Seek(c.offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(hdr[:]); err != nil {
+			return nil, err
+		}
+		payload := c.size - 8
+		if string(hdr[:4]) == "VP8X" && payload > 0 {
+			var flags [1]byte
+			if _, err := io.ReadFull(r, flags[:]); err != nil {
+				return nil, err
+			}
+			flags[0] &^= vp8xXMPFlag | vp8xEXIFFlag
+			if _, err := w.Write(flags[:]); err != nil {
+				return nil, err
+			}
+			payload--
+		}
+		if _, err := io.CopyN(w, r, payload); err != nil {
+			return nil, fmt.Errorf("truncated WebP: %w", err)
+		}
+	}
+	return dropped, nil
+}
+
+// GIF application extensions that drive playback or colour. Every other
+// application extension ("XMP DataXMP" among them) is dropped, as are comment
+// extensions.
+var gifKeptApplications = map[string]bool{
+	"NETSCAPE2.0": true, // loop count
+	"ANIMEXTS1.0": true, // loop count, older spelling
+	"ICCRGBG1012": true, // ICC colour profile
+}
+
+// stripGIF copies a GIF block by block without its comment extensions and
+// metadata application extensions. Image data is copied a sub-block at a time
+// and never decoded; anything after the trailer is dropped.
+func stripGIF(r io.Reader, w io.Writer) ([]droppedChunk, error) {
+	br := bufio.NewReader(r)
+	bw := bufio.NewWriter(w)
+	var head [13]byte // signature and logical screen descriptor
+	if _, err := io.ReadFull(br, head[:]); err != nil ||
+		(string(head[:6]) != "GIF87a" && string(head[:6]) != "GIF89a") {
+		return nil, errors.New("not a GIF file")
+	}
+	if _, err := bw.Write(head[:]); err != nil {
+		return nil, err
+	}
+	if head[10]&0x80 != 0 {
+		if _, err := io.CopyN(bw, br, 3<<(head[10]&0x07+1)); err != nil {
+			return nil, fmt.Errorf("truncated GIF: %w", err)
+		}
+	}
+	var dropped []droppedChunk
+	for {
+		block, err := br.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("truncated GIF: %w", err)
+		}
+		switch block {
+		case 0x3B: // trailer
+			if err := bw.WriteByte(block); err != nil {
+				return nil, err
+			}
+			return dropped, bw.Flush()
+		case 0x2C: // image descriptor, local colour table, LZW data
+			var desc [10]byte
+			desc[0] = block
+			if _, err := io.ReadFull(br, desc[1:]); err != nil {
+				return nil, fmt.Errorf("truncated GIF: %w", err)
+			}
+			if _, err := bw.Write(desc[:]); err != nil {
+				return nil, err
+			}
+			if desc[9]&0x80 != 0 {
+				if _, err := io.CopyN(bw, br, 3<<(desc[9]&0x07+1)); err != nil {
+					return nil, fmt.Errorf("truncated GIF: %w", err)
+				}
+			}
+			if _, err := io.CopyN(bw, br, 1); err != nil { // LZW minimum code size
+				return nil, fmt.Errorf("truncated GIF: %w", err)
+			}
+			if _, err := copyGIFSubBlocks(br, bw); err != nil {
+				return nil, err
+			}
+		case 0x21: // extension
+			label, err := br.ReadByte()
+			if err != nil {
+				return nil, fmt.Errorf("truncated GIF: %w", err)
+			}
+			var first []byte
+			if label == 0xFF {
+				if first, err = readGIFSubBlock(br); err != nil {
+					return nil, err
+				}
+			}
+			keep := label != 0xFE && (label != 0xFF || gifKeptApplications[string(first)])
+			if !keep {
+				n, err := copyGIFSubBlocks(br, io.Discard)
+				if err != nil {
+					return nil, err
+				}
+				d := droppedChunk{kind: "gif_comment", field: "comment", length: n}
+				if label == 0xFF {
+					d.kind, d.field, d.length = "gif_application", string(first), n+len(first)
+					if string(first) == "XMP DataXMP" {
+						d.kind = "xmp"
+					}
+				}
+				dropped = append(dropped, d)
+				continue
+			}
+			if _, err := bw.Write([]byte{block, label}); err != nil {
+				return nil, err
+			}
+			if label == 0xFF {
+				if _, err := bw.Write(append([]byte{byte(len(first))}, first...)); err != nil {
+					return nil, err
+				}
+			}
+			if _, err := copyGIFSubBlocks(br, bw); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("malformed GIF: unexpected block 0x%02X", block)
+		}
+	}
+}
+
+func readGIFSubBlock(br *bufio.Reader) ([]byte, error) {
+	n, err := br.ReadByte()
+	if err != nil {
+		return nil, fmt.Errorf("truncated GIF: %w", err)
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(br, buf); err != nil {
+		return nil, fmt.Errorf("truncated GIF: %w", err)
+	}
+	return buf, nil
+}
+
+// copyGIFSubBlocks copies sub-blocks, length bytes and terminator included,
+// and reports the number of data bytes.
+func copyGIFSubBlocks(br *bufio.Reader, w io.Writer) (int, error) {
+	total := 0
+	for {
+		n, err := br.ReadByte()
+		if err != nil {
+			return 0, fmt.Errorf("truncated GIF: %w", err)
+		}
+		if _, err := w.Write([]byte{n}); err != nil {
+			return 0, err
+		}
+		if n == 0 {
+			return total, nil
+		}
+		if _, err := io.CopyN(w, br, int64(n)); err != nil {
+			return 0, fmt.Errorf("truncated GIF: %w", err)
+		}
+		total += int(n)
+	}
+}
+
+// jpegMetadataSegments lists the XMP, Photoshop/IPTC and comment segments of a
+// JPEG ahead of its first scan. The JPEG path re-encodes, which drops them
+// all; this is only so the redaction map says what went.
+func jpegMetadataSegments(r io.ReadSeeker) []droppedChunk {
+	if _, err := r.Seek(2, io.SeekStart); err != nil {
+		return nil
+	}
+	br := bufio.NewReader(r)
+	var found []droppedChunk
+	for {
+		b, err := br.ReadByte()
+		if err != nil || b != 0xFF {
+			return found
+		}
+		code, err := br.ReadByte()
+		for err == nil && code == 0xFF {
+			code, err = br.ReadByte()
+		}
+		if err != nil || code == 0xD9 || code == 0xDA {
+			return found
+		}
+		if code == 0x01 || (code >= 0xD0 && code <= 0xD7) {
+			continue
+		}
+		var size [2]byte
+		if _, err := io.ReadFull(br, size[:]); err != nil {
+			return found
+		}
+		length := int(binary.BigEndian.Uint16(size[:])) - 2
+		if length < 0 {
+			return found
+		}
+		peek, _ := br.Peek(min(length, 29))
+		switch {
+		case code == 0xE1 && bytes.HasPrefix(peek, []byte("http://ns.adobe.com/xap/1.0/")):
+			found = append(found, droppedChunk{kind: "xmp", field: "APP1", length: length})
+		case code == 0xED:
+			found = append(found, droppedChunk{kind: "iptc", field: "APP13", length: length})
+		case code == 0xFE:
+			found = append(found, droppedChunk{kind: "comment", field: "COM", length: length})
+		}
+		if _, err := br.Discard(length); err != nil {
+			return found
+		}
+	}
+}
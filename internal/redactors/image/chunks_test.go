@@ -0,0 +1,208 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package image
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/awslabs/ferret-scan/v2/internal/redactors"
+)
+
+const xmpPacket = `<?xpacket begin="" id="W5M0MpCehiHzreSzNTczkc9d"?>` +
+	`<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` +
+	`<rdf:Description xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:creator><rdf:Seq><rdf:li>Ann Lee</rdf:li></rdf:Seq></dc:creator>` +
+	`</rdf:Description></rdf:RDF></x:xmpmeta><?xpacket end="w"?>`
+
+func pngChunk(typ string, data []byte) []byte {
+	out := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	out = append(out, typ...)
+	out = append(out, data...)
+	return binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(append([]byte(typ), data...)))
+}
+
+// redactBytes writes data to a file named name, redacts it and returns the
+// output and the redaction map.
+func redactBytes(t *testing.T, name string, data []byte) ([]byte, []redactors.RedactionMapping) {
+	t.Helper()
+	dir := t.TempDir()
+	in, out := filepath.Join(dir, name), filepath.Join(dir, "out-"+name)
+	if err := os.WriteFile(in, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	result, err := NewImageMetadataRedactor(nil, nil).RedactDocument(in, out, nil, redactors.RedactionSimple)
+	if err != nil {
+		t.Fatalf("RedactDocument: %v", err)
+	}
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return got, result.RedactionMap
+}
+
+func chunkKinds(mappings []redactors.RedactionMapping) []string {
+	var kinds []string
+	for _, m := range mappings {
+		kinds = append(kinds, m.Metadata["chunk"].(string))
+	}
+	return kinds
+}
+
+// A PNG loses its text and EXIF chunks and keeps its pixel data and
+// colour chunks byte for byte.
+func TestStripPNGDropsTextChunksOnly(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	img.Set(1, 1, color.NRGBA{R: 200, G: 10, B: 30, A: 255})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	orig := buf.Bytes()
+	// Insert metadata after IHDR (8 + 25 bytes) and a tEXt after the image data.
+	ihdrEnd := 8 + 25
+	iend := len(orig) - 12
+	var in []byte
+	in = append(in, orig[:ihdrEnd]...)
+	in = append(in, pngChunk("gAMA", []byte{0, 0, 0xB1, 0x8F})...)
+	in = append(in, pngChunk("iTXt", []byte("XML:com.adobe.xmp\x00\x00\x00\x00\x00"+xmpPacket))...)
+	in = append(in, pngChunk("eXIf", []byte("MM\x00*\x00\x00\x00\x08\x00\x00"))...)
+	in = append(in, orig[ihdrEnd:iend]...)
+	in = append(in, pngChunk("tEXt", []byte("Author\x00ann@example.com"))...)
+	in = append(in, orig[iend:]...)
+	in = append(in, "trailing"...)
+
+	got, mappings := redactBytes(t, "shot.png", in)
+	if bytes.Contains(got, []byte("ann@example.com")) || bytes.Contains(got, []byte("Ann Lee")) || bytes.Contains(got, []byte("trailing")) {
+		t.Fatal("metadata survived")
+	}
+	if want := []string{"iTXt", "eXIf", "tEXt"}; !equalStrings(chunkKinds(mappings), want) {
+		t.Errorf("dropped %v, want %v", chunkKinds(mappings), want)
+	}
+	if !bytes.Contains(got, pngChunk("gAMA", []byte{0, 0, 0xB1, 0x8F})) {
+		t.Error("the gAMA chunk was dropped")
+	}
+	// The image data is the original's, so the pixels are too.
+	if !bytes.Contains(got, orig[ihdrEnd:iend]) {
+		t.Error("the image data was rewritten")
+	}
+	dec, err := png.Decode(bytes.NewReader(got))
+	if err != nil {
+		t.Fatalf("output does not decode: %v", err)
+	}
+	if c := color.NRGBAModel.Convert(dec.At(1, 1)).(color.NRGBA); c != (color.NRGBA{R: 200, G: 10, B: 30, A: 255}) {
+		t.Errorf("pixel = %v", c)
+	}
+}
+
+// A GIF loses its comment and XMP extensions and keeps its loop extension.
+func TestStripGIFDropsCommentsAndXMP(t *testing.T) {
+	pal := color.Palette{color.Black, color.White}
+	frame := image.NewPaletted(image.Rect(0, 0, 4, 4), pal)
+	frame.SetColorIndex(2, 2, 1)
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, &gif.GIF{Image: []*image.Paletted{frame, frame}, Delay: []int{0, 0}}); err != nil {
+		t.Fatal(err)
+	}
+	orig := buf.Bytes()
+	// After the header, the logical screen descriptor and the global colour table.
+	at := 13
+	if orig[10]&0x80 != 0 {
+		at += 3 << (orig[10]&0x07 + 1)
+	}
+	comment := append([]byte{0x21, 0xFE, 16}, "by ann@corp.test"...)
+	comment = append(comment, 0)
+	xmp := append([]byte{0x21, 0xFF, 11}, "XMP DataXMP"...)
+	xmp = append(xmp, xmpPacket...)
+	for i := 0xFF; i >= 0; i-- { // the XMP "magic trailer"
+		xmp = append(xmp, byte(i))
+	}
+	xmp = append(xmp, 0)
+	in := append(append(append(append([]byte{}, orig[:at]...), comment...), xmp...), orig[at:]...)
+	if _, err := gif.DecodeAll(bytes.NewReader(in)); err != nil {
+		t.Fatalf("fixture does not decode: %v", err)
+	}
+
+	got, mappings := redactBytes(t, "anim.gif", in)
+	if bytes.Contains(got, []byte("ann@corp.test")) || bytes.Contains(got, []byte("Ann Lee")) {
+		t.Fatal("metadata survived")
+	}
+	if want := []string{"comment", "XMP DataXMP"}; !equalStrings(chunkKinds(mappings), want) {
+		t.Errorf("dropped %v, want %v", chunkKinds(mappings), want)
+	}
+	if !bytes.Equal(got, orig) {
+		t.Error("the output differs from the GIF the metadata was added to")
+	}
+}
+
+// A WebP loses its EXIF and XMP chunks; the RIFF size and the VP8X flags
+// are rewritten to match.
+func TestStripWebPFixesSizeAndFlags(t *testing.T) {
+	chunk := func(fourCC string, data []byte) []byte {
+		out := append([]byte(fourCC), binary.LittleEndian.AppendUint32(nil, uint32(len(data)))...)
+		out = append(out, data...)
+		if len(data)%2 == 1 {
+			out = append(out, 0)
+		}
+		return out
+	}
+	vp8x := []byte{vp8xXMPFlag | vp8xEXIFFlag | 0x10, 0, 0, 0, 3, 0, 0, 3, 0, 0}
+	vp8l := []byte{0x2F, 3, 0xC0, 0, 0, 0x07} // payload not decoded by the redactor
+	body := append(chunk("VP8X", vp8x), chunk("VP8L", vp8l)...)
+	body = append(body, chunk("EXIF", []byte("Exif\x00\x00MM\x00*"))...)
+	body = append(body, chunk("XMP ", []byte(xmpPacket))...)
+	in := append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(4+len(body)))...)
+	in = append(append(in, "WEBP"...), body...)
+
+	got, mappings := redactBytes(t, "pic.webp", in)
+	if want := []string{"EXIF", "XMP "}; !equalStrings(chunkKinds(mappings), want) {
+		t.Errorf("dropped %v, want %v", chunkKinds(mappings), want)
+	}
+	want := append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(4+len(chunk("VP8X", vp8x))+len(chunk("VP8L", vp8l))))...)
+	want = append(want, "WEBP"...)
+	want = append(want, chunk("VP8X", append([]byte{0x10}, vp8x[1:]...))...)
+	want = append(want, chunk("VP8L", vp8l)...)
+	if !bytes.Equal(got, want) {
+		t.Errorf("output\n%q\nwant\n%q", got, want)
+	}
+}
+
+// A container that ends mid-chunk is refused rather than half-copied.
+func TestStripRefusesTruncatedContainers(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	in, out := filepath.Join(dir, "cut.png"), filepath.Join(dir, "out.png")
+	if err := os.WriteFile(in, buf.Bytes()[:40], 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewImageMetadataRedactor(nil, nil).RedactDocument(in, out, nil, redactors.RedactionSimple); err == nil {
+		t.Error("a truncated PNG was reported as redacted")
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Errorf("an output file survived the failure (stat err = %v)", err)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,92 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package image
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/awslabs/ferret-scan/v2/internal/redactors"
+)
+
+// GPSPosition is the position an image's EXIF GPS IFD decodes to, in signed decimal degrees.
+type GPSPosition struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// writeJPEGWithPosition writes a re-encoded JPEG with one EXIF segment added after SOI, holding
+// nothing but the position truncated to the precision.
+//
+// A new segment rather than the original one with its GPS values rewritten: the re-encode
+// exists to drop every metadata segment, and keeping the original EXIF would keep the serial
+// numbers, timestamps and free text that the strip removes. What is kept is the one value the
+// caller asked to keep, in a form that holds no more than that.
+func writeJPEGWithPosition(w io.Writer, encoded []byte, pos GPSPosition, p redactors.GPSPrecision) error {
+	if len(encoded) < 2 || encoded[0] != 0xFF || encoded[1] != 0xD8 {
+		return fmt.Errorf("the encoder produced no JPEG start-of-image marker")
+	}
+	segment := gpsOnlyEXIF(pos, p.Decimals())
+	for _, part := range [][]byte{encoded[:2], segment, encoded[2:]} {
+		if _, err := w.Write(part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// gpsOnlyEXIF builds an APP1 EXIF segment whose TIFF block holds IFD0 with a single GPSInfo
+// pointer, and a GPS IFD with the version, the two hemisphere references and the two angles.
+// Each angle is degrees as a rational over 10^decimals with zero minutes and seconds, which is
+// the truncated value exactly.
+func gpsOnlyEXIF(pos GPSPosition, decimals int) []byte {
+	be := binary.BigEndian
+	const (
+		typeByte, typeASCII, typeLong, typeRational = 1, 2, 4, 5
+		gpsIFD                                      = 26 // after the header and a one-entry IFD0
+		latAt                                       = gpsIFD + 2 + 5*12 + 4
+		lonAt                                       = latAt + 24
+	)
+	entry := func(tag, typ uint16, count, value uint32) []byte {
+		e := be.AppendUint16(nil, tag)
+		e = be.AppendUint16(e, typ)
+		e = be.AppendUint32(e, count)
+		return be.AppendUint32(e, value)
+	}
+	ref := func(v float64, pos, neg byte) uint32 {
+		if v < 0 {
+			return uint32(neg) << 24
+		}
+		return uint32(pos) << 24
+	}
+	angle := func(v float64) []byte {
+		scale := math.Pow10(decimals)
+		// #nosec G115 -- at most 180 * 10^5, well inside a uint32
+		out := be.AppendUint32(nil, uint32(math.Abs(v)*scale+1e-7))
+		out = be.AppendUint32(out, uint32(scale))
+		return append(out, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 1)
+	}
+
+	tiff := []byte("MM\x00*\x00\x00\x00\x08")
+	tiff = be.AppendUint16(tiff, 1)
+	tiff = append(tiff, entry(0x8825, typeLong, 1, gpsIFD)...)
+	tiff = be.AppendUint32(tiff, 0)
+	tiff = be.AppendUint16(tiff, 5)
+	tiff = append(tiff, entry(0x0000, typeByte, 4, 0x02030000)...)
+	tiff = append(tiff, entry(0x0001, typeASCII, 2, ref(pos.Latitude, 'N', 'S'))...)
+	tiff = append(tiff, entry(0x0002, typeRational, 3, latAt)...)
+	tiff = append(tiff, entry(0x0003, typeASCII, 2, ref(pos.Longitude, 'E', 'W'))...)
+	tiff = append(tiff, entry(0x0004, typeRational, 3, lonAt)...)
+	tiff = be.AppendUint32(tiff, 0)
+	tiff = append(tiff, angle(pos.Latitude)...)
+	tiff = append(tiff, angle(pos.Longitude)...)
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xFF, 0xE1}
+	// #nosec G115 -- a fixed payload of under 200 bytes
+	segment = be.AppendUint16(segment, uint16(len(payload)+2))
+	return append(segment, payload...)
+}
@@ -0,0 +1,77 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package image
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/awslabs/ferret-scan/v2/internal/redactors"
+	"github.com/rwcarlsen/goexif/exif"
+)
+
+// TestJPEGKeepsOnlyACoarsenedPosition is the field-photo case: with a precision set the output
+// carries the position at city level and nothing else from the original EXIF.
+func TestJPEGKeepsOnlyACoarsenedPosition(t *testing.T) {
+	dir := t.TempDir()
+	plain := filepath.Join(dir, "plain.jpg")
+	writeGrayJPEG(t, plain, 16, 16)
+	encoded, err := os.ReadFile(plain) // #nosec G304 -- test-controlled temp path
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := filepath.Join(dir, "site.jpg")
+	f, err := os.Create(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writeJPEGWithPosition(f, encoded, GPSPosition{Latitude: 37.774929, Longitude: -122.419416}, 6); err != nil {
+		t.Fatal(err)
+	}
+	_ = f.Close()
+
+	out := filepath.Join(dir, "out.jpg")
+	r := NewImageMetadataRedactor(nil, nil)
+	r.SetGPSPrecision(2)
+	result, err := r.RedactDocument(in, out, nil, redactors.RedactionSimple)
+	if err != nil {
+		t.Fatalf("RedactDocument: %v", err)
+	}
+
+	got, err := os.ReadFile(out) // #nosec G304 -- test-controlled temp path
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, err := exif.Decode(bytes.NewReader(got))
+	if err != nil {
+		t.Fatalf("the output has no readable EXIF: %v", err)
+	}
+	lat, lon, err := x.LatLong()
+	if err != nil || math.Abs(lat-37.77) > 1e-9 || math.Abs(lon+122.41) > 1e-9 {
+		t.Errorf("position = %f, %f (%v); want 37.77, -122.41", lat, lon, err)
+	}
+
+	var recorded bool
+	for _, m := range result.RedactionMap {
+		if m.Metadata["position_method"] == "image_gps_precision_reduced" {
+			recorded = m.Metadata["gps_precision_decimals"] == 2
+		}
+	}
+	if !recorded {
+		t.Error("the audit log does not record the precision that was kept")
+	}
+
+	// Without a precision the position is removed, as before.
+	r = NewImageMetadataRedactor(nil, nil)
+	if _, err := r.RedactDocument(in, out, nil, redactors.RedactionSimple); err != nil {
+		t.Fatal(err)
+	}
+	got, _ = os.ReadFile(out) // #nosec G304 -- test-controlled temp path
+	if _, err := exif.Decode(bytes.NewReader(got)); err == nil {
+		t.Error("EXIF survived a redaction with no precision set")
+	}
+}
@@ -4,9 +4,11 @@
 package image
 
 import (
+	"bufio"
 	"bytes"
 	"fmt"
 	"image"
+	"image/gif"
 	"image/jpeg"
 	"image/png"
 	"io"
@@ -48,6 +50,9 @@ type ImageMetadataRedactor struct {
 	// preserveImageQuality controls whether to preserve original image quality
 	preserveImageQuality bool
 
+	// gpsPrecision, when enabled, keeps a JPEG's position coarsened instead of removing it
+	gpsPrecision redactors.GPSPrecision
+
 	// supportedFormats lists the image formats this redactor can handle
 	supportedFormats map[string]bool
 }
@@ -55,7 +60,7 @@ type ImageMetadataRedactor struct {
 // ImageFormat represents the type of image format
 // maxRedactablePixels bounds the declared width x height of an image this redactor will decode.
 //
-// Stripping metadata from a JPEG or PNG here decodes the pixels and re-encodes them, so peak memory
+// Stripping metadata from a JPEG here decodes the pixels and re-encodes them, so peak memory
 // follows the DECLARED dimensions — a number taken from the file. Measured cost of one decode is
 // about 1 byte per pixel for greyscale and nearer 2.5 for colour, so this budget is roughly 64MB to
 // 160MB per image; the worker pool caps concurrency at 8, which bounds the whole run at about 1.3GB
@@ -204,8 +209,9 @@ func (imr *ImageMetadataRedactor) RedactDocument(originalPath string, outputPath
 		metadata = &ImageMetadata{}
 	}
 
-	// Perform metadata redaction
-	redactionMap, err := imr.redactImageMetadata(originalPath, outputPath, format, metadata, strategy)
+	// Perform metadata redaction, and black out the barcodes findings were
+	// reported against
+	redactionMap, err := imr.redactImageMetadata(originalPath, outputPath, format, metadata, barcodeRedactionFor(matches, originalPath), strategy)
 	if err != nil {
 		return nil, fmt.Errorf("failed to redact image metadata: %w", err)
 	}
@@ -230,6 +236,7 @@ type ImageMetadata struct {
 	Format     ImageFormat            `json:"format"`
 	HasEXIF    bool                   `json:"has_exif"`
 	EXIFData   map[string]string      `json:"exif_data,omitempty"`
+	GPS        *GPSPosition           `json:"gps,omitempty"`
 	Dimensions ImageDimensions        `json:"dimensions"`
 	FileSize   int64                  `json:"file_size"`
 	ColorModel string                 `json:"color_model"`
@@ -348,7 +355,7 @@ func (imr *ImageMetadataRedactor) extractImageMetadata(filePath string, format I
 	// Extract EXIF data for supported formats
 	if format == FormatJPEG || format == FormatTIFF {
 		file.Seek(0, 0) // Reset file position
-		exifData, err := imr.extractEXIFData(file)
+		exifData, x, err := imr.extractEXIFData(file)
 		if err != nil {
 			imr.logEvent("exif_extraction_failed", false, map[string]interface{}{
 				"error": err.Error(),
@@ -356,6 +363,9 @@ func (imr *ImageMetadataRedactor) extractImageMetadata(filePath string, format I
 		} else {
 			metadata.HasEXIF = true
 			metadata.EXIFData = exifData
+			if lat, lon, err := x.LatLong(); err == nil {
+				metadata.GPS = &GPSPosition{Latitude: lat, Longitude: lon}
+			}
 		}
 	}
 
@@ -370,21 +380,21 @@ func (imr *ImageMetadataRedactor) extractImageMetadata(filePath string, format I
 }
 
 // extractEXIFData extracts EXIF data from an image file
-func (imr *ImageMetadataRedactor) extractEXIFData(reader io.Reader) (map[string]string, error) {
+func (imr *ImageMetadataRedactor) extractEXIFData(reader io.Reader) (map[string]string, *exif.Exif, error) {
 	exifData := make(map[string]string)
 
 	x, err := exif.Decode(reader)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode EXIF data: %w", err)
+		return nil, nil, fmt.Errorf("failed to decode EXIF data: %w", err)
 	}
 
 	// Walk through all EXIF fields
 	err = x.Walk(&exifWalker{data: exifData})
 	if err != nil {
-		return nil, fmt.Errorf("failed to walk EXIF data: %w", err)
+		return nil, nil, fmt.Errorf("failed to walk EXIF data: %w", err)
 	}
 
-	return exifData, nil
+	return exifData, x, nil
 }
 
 // exifWalker implements exif.Walker interface to collect EXIF data
@@ -405,7 +415,11 @@ func (w *exifWalker) Walk(name exif.FieldName, tag *tiff.Tag) error {
 }
 
 // redactImageMetadata removes metadata from an image file
-func (imr *ImageMetadataRedactor) redactImageMetadata(originalPath, outputPath string, format ImageFormat, metadata *ImageMetadata, strategy redactors.RedactionStrategy) ([]redactors.RedactionMapping, error) {
+//
+// barcodes, when not nil, names the symbols in the pixels that hold a finding;
+// they are blacked out, which means decoding and re-encoding the image whatever
+// its format.
+func (imr *ImageMetadataRedactor) redactImageMetadata(originalPath, outputPath string, format ImageFormat, metadata *ImageMetadata, barcodes *barcodeRedaction, strategy redactors.RedactionStrategy) ([]redactors.RedactionMapping, error) {
 	// Ensure output directory exists
 	if imr.outputManager != nil {
 		if err := imr.outputManager.EnsureDirectoryExists(outputPath); err != nil {
@@ -427,7 +441,12 @@ func (imr *ImageMetadataRedactor) redactImageMetadata(originalPath, outputPath s
 	// Refusing is what the surrounding code already does for a format it cannot strip safely, and it
 	// inherits that path's guarantee: the error removes the output file, so a refusal can never be
 	// mistaken for a redacted document.
-	if px := int64(metadata.Dimensions.Width) * int64(metadata.Dimensions.Height); px > maxRedactablePixels {
+	//
+	// Only JPEG is decoded to strip metadata. PNG, GIF and WebP are stripped chunk by chunk and
+	// never decoded, so the budget applies to them only when a barcode in the pixels is to be
+	// blacked out.
+	decodes := format == FormatJPEG || barcodes != nil
+	if px := int64(metadata.Dimensions.Width) * int64(metadata.Dimensions.Height); decodes && px > maxRedactablePixels {
 		imr.logEvent("image_pixel_budget_exceeded", false, map[string]interface{}{
 			"width":  metadata.Dimensions.Width,
 			"height": metadata.Dimensions.Height,
@@ -456,12 +475,12 @@ func (imr *ImageMetadataRedactor) redactImageMetadata(originalPath, outputPath s
 	// Process based on image format
 	switch format {
 	case FormatJPEG:
-		err = imr.redactJPEGMetadata(originalFile, outputFile, metadata, &redactionMap, strategy)
-	case FormatPNG:
-		err = imr.redactPNGMetadata(originalFile, outputFile, metadata, &redactionMap, strategy)
+		err = imr.redactJPEGMetadata(originalFile, outputFile, metadata, barcodes, &redactionMap, strategy)
+	case FormatPNG, FormatGIF, FormatWEBP:
+		err = imr.stripContainerMetadata(originalFile, outputFile, format, barcodes, &redactionMap, strategy)
 	default:
-		// Metadata stripping is only implemented for JPEG and PNG. Other formats
-		// (GIF, TIFF, BMP, WEBP) have no real redaction path and must NOT silently
+		// Metadata stripping is not implemented for TIFF and BMP. They have no
+		// real redaction path and must NOT silently
 		// copy the original through — doing so would leave EXIF/GPS/serial metadata
 		// intact in a file the caller believes was redacted. Fail safe instead.
 		err = fmt.Errorf("metadata redaction not implemented for %s images", format.String())
@@ -491,13 +510,21 @@ func (imr *ImageMetadataRedactor) redactImageMetadata(originalPath, outputPath s
 }
 
 // redactJPEGMetadata removes EXIF and other metadata from JPEG files
-func (imr *ImageMetadataRedactor) redactJPEGMetadata(originalFile *os.File, outputFile *os.File, metadata *ImageMetadata, redactionMap *[]redactors.RedactionMapping, strategy redactors.RedactionStrategy) error {
+func (imr *ImageMetadataRedactor) redactJPEGMetadata(originalFile *os.File, outputFile *os.File, metadata *ImageMetadata, barcodes *barcodeRedaction, redactionMap *[]redactors.RedactionMapping, strategy redactors.RedactionStrategy) error {
 	// Decode the JPEG image
 	img, err := jpeg.Decode(originalFile)
 	if err != nil {
 		return fmt.Errorf("failed to decode JPEG: %w", err)
 	}
 
+	// The pixels are re-encoded anyway, so a barcode is blacked out on the way.
+	var barcodeMappings []redactors.RedactionMapping
+	if barcodes != nil {
+		if img, barcodeMappings, err = blackOutBarcodes(img, barcodes, FormatJPEG, strategy); err != nil {
+			return err
+		}
+	}
+
 	// Create JPEG encoding options
 	options := &jpeg.Options{
 		Quality: 95, // High quality to preserve image
@@ -508,10 +535,20 @@ func (imr *ImageMetadataRedactor) redactJPEGMetadata(originalFile *os.File, outp
 	}
 
 	// Encode the image without metadata
-	err = jpeg.Encode(outputFile, img, options)
+	var encoded bytes.Buffer
+	err = jpeg.Encode(&encoded, img, options)
 	if err != nil {
 		return fmt.Errorf("failed to encode JPEG: %w", err)
 	}
+	keepGPS := imr.gpsPrecision.Enabled() && metadata.GPS != nil
+	if keepGPS {
+		err = writeJPEGWithPosition(outputFile, encoded.Bytes(), *metadata.GPS, imr.gpsPrecision)
+	} else {
+		_, err = outputFile.Write(encoded.Bytes())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write JPEG: %w", err)
+	}
 
 	// Create redaction mapping for metadata removal. Field names sorted: these
 	// mappings become audit-log entries whose IDs are assigned from slice
@@ -541,6 +578,25 @@ func (imr *ImageMetadataRedactor) redactJPEGMetadata(originalFile *os.File, outp
 			*redactionMap = append(*redactionMap, mapping)
 		}
 	}
+	// The re-encode drops XMP, Photoshop/IPTC and comment segments as well.
+	*redactionMap = append(*redactionMap, chunkMappings(jpegMetadataSegments(originalFile), FormatJPEG, strategy)...)
+
+	if keepGPS {
+		meta := imr.gpsPrecision.AuditMetadata()
+		meta["exif_field"] = "GPSLatitude,GPSLongitude"
+		meta["metadata_type"] = "exif"
+		meta["image_format"] = "jpeg"
+		meta["position_method"] = "image_gps_precision_reduced"
+		*redactionMap = append(*redactionMap, redactors.RedactionMapping{
+			RedactedText: "[GPS-PRECISION-REDUCED]",
+			DataType:     "IMAGE_METADATA",
+			Strategy:     strategy,
+			Confidence:   1.0,
+			Metadata:     meta,
+		})
+		imr.logEvent("image_gps_precision_reduced", true, imr.gpsPrecision.AuditMetadata())
+	}
+	*redactionMap = append(*redactionMap, barcodeMappings...)
 
 	return nil
 }
@@ -555,41 +611,112 @@ func sortedEXIFFieldNames(exifData map[string]string) []string {
 	return names
 }
 
-// redactPNGMetadata removes metadata from PNG files
-func (imr *ImageMetadataRedactor) redactPNGMetadata(originalFile *os.File, outputFile *os.File, metadata *ImageMetadata, redactionMap *[]redactors.RedactionMapping, strategy redactors.RedactionStrategy) error {
-	// Decode the PNG image
-	img, err := png.Decode(originalFile)
-	if err != nil {
-		return fmt.Errorf("failed to decode PNG: %w", err)
+// stripContainerMetadata removes the metadata chunks of a PNG, GIF or WebP without
+// re-encoding the pixels: text chunks, EXIF, XMP, comments and private chunks are
+// dropped and everything else is copied byte for byte. One mapping is recorded per
+// dropped chunk.
+//
+// When barcodes is not nil the stripped image is decoded, its symbols blacked out
+// and the pixels re-encoded; see reencodeWithoutBarcodes.
+func (imr *ImageMetadataRedactor) stripContainerMetadata(originalFile *os.File, outputFile *os.File, format ImageFormat, barcodes *barcodeRedaction, redactionMap *[]redactors.RedactionMapping, strategy redactors.RedactionStrategy) error {
+	var stripped io.Writer = outputFile
+	var buf bytes.Buffer
+	if barcodes != nil {
+		stripped = &buf
+	}
+	var dropped []droppedChunk
+	var err error
+	switch format {
+	case FormatPNG:
+		dropped, err = stripPNG(bufio.NewReader(originalFile), stripped)
+	case FormatGIF:
+		dropped, err = stripGIF(originalFile, stripped)
+	case FormatWEBP:
+		dropped, err = stripWebP(originalFile, stripped)
 	}
-
-	// Encode the image without metadata
-	err = png.Encode(outputFile, img)
 	if err != nil {
-		return fmt.Errorf("failed to encode PNG: %w", err)
+		return err
 	}
+	*redactionMap = append(*redactionMap, chunkMappings(dropped, format, strategy)...)
+	if barcodes != nil {
+		mappings, err := reencodeWithoutBarcodes(buf.Bytes(), outputFile, format, barcodes, strategy)
+		if err != nil {
+			return err
+		}
+		*redactionMap = append(*redactionMap, mappings...)
+	}
+	return nil
+}
 
-	// PNG files don't typically have EXIF data, but they can have text chunks
-	// Create a generic metadata removal mapping
-	mapping := redactors.RedactionMapping{
-		RedactedText: "[METADATA-REMOVED]",
-		Position: redactors.TextPosition{
-			Line:      0,
-			StartChar: 0,
-			EndChar:   12,
-		},
-		DataType:   "IMAGE_METADATA",
-		Strategy:   strategy,
-		Confidence: 1.0,
-
-		Metadata: map[string]interface{}{
-			"metadata_type": "png_chunks",
-			"image_format":  "png",
-		},
+// reencodeWithoutBarcodes decodes a stripped PNG or GIF, blacks out its
+// symbols and encodes it to w.
+//
+// The re-encode keeps the pixels, the palette of a paletted image and a GIF's
+// logical screen; the chunks stripping kept (colour profile, gamma) are not
+// carried over. An animated GIF is refused rather than having one frame blacked
+// out, since a symbol can be in any frame, and so is a WebP: there is no WebP
+// encoder to write the result with. A refusal fails the file, which removes
+// the output, so it is never mistaken for a redacted image.
+func reencodeWithoutBarcodes(stripped []byte, w io.Writer, format ImageFormat, barcodes *barcodeRedaction, strategy redactors.RedactionStrategy) ([]redactors.RedactionMapping, error) {
+	switch format {
+	case FormatPNG:
+		img, err := png.Decode(bytes.NewReader(stripped))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode PNG: %w", err)
+		}
+		out, mappings, err := blackOutBarcodes(img, barcodes, format, strategy)
+		if err != nil {
+			return nil, err
+		}
+		if err := png.Encode(w, out); err != nil {
+			return nil, fmt.Errorf("failed to encode PNG: %w", err)
+		}
+		return mappings, nil
+	case FormatGIF:
+		g, err := gif.DecodeAll(bytes.NewReader(stripped))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode GIF: %w", err)
+		}
+		if len(g.Image) != 1 {
+			return nil, fmt.Errorf("cannot black out a barcode in an animated GIF (%d frames)", len(g.Image))
+		}
+		// A paletted frame is drawable, so it is blacked out in place.
+		_, mappings, err := blackOutBarcodes(g.Image[0], barcodes, format, strategy)
+		if err != nil {
+			return nil, err
+		}
+		if err := gif.EncodeAll(w, g); err != nil {
+			return nil, fmt.Errorf("failed to encode GIF: %w", err)
+		}
+		return mappings, nil
+	default:
+		return nil, fmt.Errorf("cannot black out a barcode in a %s image: it cannot be re-encoded", format.String())
 	}
-	*redactionMap = append(*redactionMap, mapping)
+}
 
-	return nil
+// chunkMappings records removed chunks, in file order.
+func chunkMappings(dropped []droppedChunk, format ImageFormat, strategy redactors.RedactionStrategy) []redactors.RedactionMapping {
+	mappings := make([]redactors.RedactionMapping, 0, len(dropped))
+	for _, d := range dropped {
+		mappings = append(mappings, redactors.RedactionMapping{
+			RedactedText: "[METADATA-REMOVED]",
+			Position: redactors.TextPosition{
+				Line:      0,
+				StartChar: 0,
+				EndChar:   d.length,
+			},
+			DataType:   "IMAGE_METADATA",
+			Strategy:   strategy,
+			Confidence: 1.0,
+
+			Metadata: map[string]interface{}{
+				"metadata_type": d.kind,
+				"chunk":         d.field,
+				"image_format":  format.String(),
+			},
+		})
+	}
+	return mappings
 }
 
 // logEvent logs an event if observer is available
@@ -638,6 +765,12 @@ func (imr *ImageMetadataRedactor) SetPreserveImageQuality(preserve bool) {
 	imr.preserveImageQuality = preserve
 }
 
+// SetGPSPrecision makes the redactor keep a JPEG's GPS position, coarsened to the given
+// precision, in the otherwise metadata-free output. Set it before redaction begins.
+func (imr *ImageMetadataRedactor) SetGPSPrecision(p redactors.GPSPrecision) {
+	imr.gpsPrecision = p
+}
+
 // GetImageMetadata extracts and returns metadata from an image file without redaction
 func (imr *ImageMetadataRedactor) GetImageMetadata(filePath string) (*ImageMetadata, error) {
 	format, err := imr.detectImageFormat(filePath)
@@ -0,0 +1,529 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package isobmff
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Item kinds reported by MetadataItems.
+const (
+	ItemExif = "Exif"
+	ItemXMP  = "XMP"
+)
+
+// xmpContentType is the MIME type a HEIF writer gives an XMP item (ISO/IEC 23008-12 Annex A).
+const xmpContentType = "application/rdf+xml"
+
+// maxMetaBytes caps the top-level meta box MetadataItems will read into memory.
+//
+// meta holds the item tables, not the items: an iPhone photo's is about 4 KB even with 48 grid
+// tiles, because every tile is an iloc entry of a few bytes. The pixel data and, usually, the
+// Exif item itself live in mdat. A declared meta of megabytes is therefore either an idat
+// carrying the items inline or a hostile size, and both are bounded here.
+const maxMetaBytes = 16 << 20
+
+// ErrMetaTooLarge reports a meta box above maxMetaBytes. Like ErrAtomBudget it is a refusal,
+// not "no metadata found".
+var ErrMetaTooLarge = errors.New("isobmff: HEIF meta box exceeds the size limit")
+
+// heifBrands are the ftyp brands of HEIF still images and image sequences, HEVC- and
+// AV1-coded alike. A .mp4 never carries one of these, so a video is not mistaken for a photo.
+var heifBrands = map[string]bool{
+	"mif1": true, "mif2": true, "msf1": true,
+	"heic": true, "heix": true, "heim": true, "heis": true,
+	"hevc": true, "hevx": true, "hevm": true, "hevs": true,
+	"avif": true, "avis": true,
+}
+
+// Item is one Exif or XMP item of a HEIF image: its ID, its kind and the file ranges that hold
+// its bytes, in order. Most items have one extent; the format allows several, and the item's
+// bytes are their concatenation.
+type Item struct {
+	ID      uint32
+	Kind    string
+	Extents []Span
+}
+
+// Len returns the item's length in bytes.
+func (it Item) Len() int64 {
+	n := int64(0)
+	for _, e := range it.Extents {
+		n += e.Len()
+	}
+	return n
+}
+
+// IsHEIF reports whether the head of a file is the ftyp box of a HEIF or AVIF image.
+//
+// By brand, major or compatible, because the extension says nothing about the container: .heic
+// files from some Android phones carry "mif1" as major brand and "heic" only in the
+// compatible list, and an AVIF is "avif" with "mif1" alongside. head should hold the whole
+// ftyp box; 64 bytes covers every writer measured.
+func IsHEIF(head []byte) bool {
+	if len(head) < 16 || string(head[4:8]) != "ftyp" {
+		return false
+	}
+	end := int(binary.BigEndian.Uint32(head[0:4]))
+	if end > len(head) {
+		end = len(head)
+	}
+	if heifBrands[string(head[8:12])] {
+		return true
+	}
+	// Compatible brands follow the 4-byte minor version.
+	for at := 16; at+4 <= end; at += 4 {
+		if heifBrands[string(head[at:at+4])] {
+			return true
+		}
+	}
+	return false
+}
+
+// MetadataItems locates the Exif and XMP items of a HEIF or AVIF image.
+//
+// # Why items and not atoms
+//
+// A HEIF photo's metadata is not in a box of its own. The top-level meta box lists ITEMS — the
+// image tiles, the thumbnail, the Exif block, the XMP packet — in iinf, and iloc says where
+// each item's bytes are: usually somewhere in mdat, between the tiles. So the box walk that
+// finds udta in a video finds nothing here; the item tables have to be read to know which
+// bytes of mdat are metadata and which are pixels.
+//
+// Only meta is read, bounded by maxMetaBytes. The items themselves are returned as file
+// ranges and left for the caller to read.
+//
+// An Exif item is recognised by its item type, an XMP item by a "mime" item type whose content
+// type is application/rdf+xml. An item stored by reference to another item (iloc construction
+// method 2) or in an external file (a non-zero data reference) is not returned: its bytes
+// cannot be named as one range of this file, and a range that is only a guess is a range that
+// gets overwritten wrongly. Extents that fall outside the file are treated the same way.
+//
+// A file with no meta, or a meta that does not parse, yields no items and no error, as
+// MetadataSpans does for a malformed atom tree.
+func MetadataItems(r io.ReaderAt, size int64) ([]Item, error) {
+	var meta Span
+	budget := maxAtoms
+	err := walk(r, 0, size, 0, &budget, func(name []byte, payloadStart, payloadEnd int64) bool {
+		if meta.End == 0 && bytes.Equal(name, metaAtom) {
+			meta = Span{payloadStart, payloadEnd, "HEIF meta"}
+		}
+		return false // items are found from the top-level meta only
+	})
+	if err != nil {
+		return nil, err
+	}
+	if meta.Len() < 4 {
+		return nil, nil
+	}
+	if meta.Len() > maxMetaBytes {
+		return nil, fmt.Errorf("%w: %d bytes", ErrMetaTooLarge, meta.Len())
+	}
+
+	buf := make([]byte, meta.Len())
+	if _, err := r.ReadAt(buf, meta.Start); err != nil {
+		return nil, nil
+	}
+
+	// meta is a FullBox; in a HEIF file it always carries the prefix.
+	var iinf, iloc []byte
+	idat := Span{}
+	_ = walk(bytes.NewReader(buf), 4, int64(len(buf)), 0, &budget, func(name []byte, payloadStart, payloadEnd int64) bool {
+		switch string(name) {
+		case "iinf":
+			iinf = buf[payloadStart:payloadEnd]
+		case "iloc":
+			iloc = buf[payloadStart:payloadEnd]
+		case "idat":
+			idat = Span{meta.Start + payloadStart, meta.Start + payloadEnd, "HEIF idat"}
+		}
+		return false
+	})
+	if iinf == nil || iloc == nil {
+		return nil, nil
+	}
+
+	kinds := parseItemInfo(iinf)
+	if len(kinds) == 0 {
+		return nil, nil
+	}
+	items := parseItemLocations(iloc, kinds, idat, size)
+	return items, nil
+}
+
+// parseItemInfo reads an iinf payload and returns the kind of every Exif and XMP item by ID.
+func parseItemInfo(p []byte) map[uint32]string {
+	if len(p) < 4 {
+		return nil
+	}
+	at := 4 // version and flags
+	if p[0] == 0 {
+		at += 2
+	} else {
+		at += 4
+	}
+	if at > len(p) {
+		return nil
+	}
+
+	kinds := make(map[uint32]string)
+	budget := maxAtoms
+	_ = walk(bytes.NewReader(p), int64(at), int64(len(p)), 0, &budget, func(name []byte, payloadStart, payloadEnd int64) bool {
+		if string(name) != "infe" {
+			return false
+		}
+		if id, kind, ok := parseItemInfoEntry(p[payloadStart:payloadEnd]); ok {
+			kinds[id] = kind
+		}
+		return false
+	})
+	return kinds
+}
+
+// parseItemInfoEntry reads one infe payload and reports the item's ID and kind when it is an
+// Exif or XMP item.
+func parseItemInfoEntry(p []byte) (uint32, string, bool) {
+	if len(p) < 4 {
+		return 0, "", false
+	}
+	version := p[0]
+	at := 4
+	var id uint32
+	itemType := "mime" // versions 0 and 1 describe every item by content type
+	switch {
+	case version >= 2:
+		if version == 2 {
+			if at+2 > len(p) {
+				return 0, "", false
+			}
+			id = uint32(binary.BigEndian.Uint16(p[at:]))
+			at += 2
+		} else {
+			if at+4 > len(p) {
+				return 0, "", false
+			}
+			id = binary.BigEndian.Uint32(p[at:])
+			at += 4
+		}
+		if at+6 > len(p) {
+			return 0, "", false
+		}
+		at += 2 // item_protection_index
+		itemType = string(p[at : at+4])
+		at += 4
+	default:
+		if at+4 > len(p) {
+			return 0, "", false
+		}
+		id = uint32(binary.BigEndian.Uint16(p[at:]))
+		at += 4 // item_ID, item_protection_index
+	}
+
+	if itemType == "Exif" {
+		return id, ItemExif, true
+	}
+	if itemType != "mime" {
+		return 0, "", false
+	}
+	_, at = cString(p, at) // item_name
+	contentType, _ := cString(p, at)
+	if strings.EqualFold(strings.TrimSpace(contentType), xmpContentType) {
+		return id, ItemXMP, true
+	}
+	return 0, "", false
+}
+
+// cString reads a NUL-terminated string at p[at:] and returns it with the offset after the NUL.
+func cString(p []byte, at int) (string, int) {
+	if at >= len(p) {
+		return "", len(p)
+	}
+	n := bytes.IndexByte(p[at:], 0)
+	if n < 0 {
+		return string(p[at:]), len(p)
+	}
+	return string(p[at : at+n]), at + n + 1
+}
+
+// parseItemLocations reads an iloc payload and returns the extents of the items named in kinds.
+//
+// Every field width is declared in the box header (0, 4 or 8 bytes), so this is arithmetic
+// over those widths and nothing else. A width the spec does not allow, or an entry that runs
+// past the payload, stops the parse: what was located before it is still returned.
+func parseItemLocations(p []byte, kinds map[uint32]string, idat Span, size int64) []Item {
+	if len(p) < 6 {
+		return nil
+	}
+	version := p[0]
+	offsetSize := int(p[4] >> 4)
+	lengthSize := int(p[4] & 0x0F)
+	baseOffsetSize := int(p[5] >> 4)
+	indexSize := 0
+	if version == 1 || version == 2 {
+		indexSize = int(p[5] & 0x0F)
+	}
+	for _, w := range []int{offsetSize, lengthSize, baseOffsetSize, indexSize} {
+		if w != 0 && w != 4 && w != 8 {
+			return nil
+		}
+	}
+
+	rd := &fieldReader{p: p, at: 6}
+	var count uint64
+	if version < 2 {
+		count = rd.uint(2)
+	} else {
+		count = rd.uint(4)
+	}
+
+	var items []Item
+	for i := uint64(0); i < count && !rd.short; i++ {
+		var id uint32
+		if version < 2 {
+			id = uint32(rd.uint(2))
+		} else {
+			id = uint32(rd.uint(4))
+		}
+		method := uint64(0)
+		if version == 1 || version == 2 {
+			method = rd.uint(2) & 0x0F
+		}
+		dataRef := rd.uint(2)
+		base := rd.uint(baseOffsetSize)
+		extentCount := rd.uint(2)
+
+		item := Item{ID: id, Kind: kinds[id]}
+		usable := item.Kind != "" && dataRef == 0 && (method == 0 || method == 1)
+		for e := uint64(0); e < extentCount && !rd.short; e++ {
+			if indexSize > 0 {
+				rd.uint(indexSize)
+			}
+			off := rd.uint(offsetSize)
+			length := rd.uint(lengthSize)
+			if !usable {
+				continue
+			}
+			span, ok := extentSpan(item.Kind, method, base, off, length, idat, size)
+			if !ok {
+				usable = false
+				continue
+			}
+			item.Extents = append(item.Extents, span)
+		}
+		if rd.short {
+			break
+		}
+		if usable && len(item.Extents) > 0 {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// extentSpan resolves one iloc extent to a file range. Construction method 0 addresses the
+// file; method 1 addresses the payload of meta's idat box. A length of 0 means "to the end of
+// the addressed data".
+func extentSpan(kind string, method, base, off, length uint64, idat Span, size int64) (Span, bool) {
+	origin, limit := int64(0), size
+	if method == 1 {
+		if idat.Len() <= 0 {
+			return Span{}, false
+		}
+		origin, limit = idat.Start, idat.End
+	}
+	rel := base + off
+	if rel < base || rel > uint64(limit-origin) {
+		return Span{}, false
+	}
+	start := origin + int64(rel)
+	end := limit
+	if length != 0 {
+		if length > uint64(limit-start) {
+			return Span{}, false
+		}
+		end = start + int64(length)
+	}
+	if end <= start {
+		return Span{}, false
+	}
+	return Span{start, end, "HEIF " + kind + " item"}, true
+}
+
+// fieldReader reads big-endian fields of a declared width, recording a short read instead of
+// panicking.
+type fieldReader struct {
+	p     []byte
+	at    int
+	short bool
+}
+
+func (f *fieldReader) uint(width int) uint64 {
+	if width == 0 {
+		return 0
+	}
+	if f.at+width > len(f.p) {
+		f.short = true
+		f.at = len(f.p)
+		return 0
+	}
+	var v uint64
+	for _, b := range f.p[f.at : f.at+width] {
+		v = v<<8 | uint64(b)
+	}
+	f.at += width
+	return v
+}
+
+// ReadItem returns the bytes of an item: its extents, concatenated. limit bounds the total, and
+// an item above it is an error rather than a truncated read.
+func ReadItem(r io.ReaderAt, it Item, limit int64) ([]byte, error) {
+	if n := it.Len(); n > limit {
+		return nil, fmt.Errorf("isobmff: %s item %d is %d bytes, above the %d-byte limit", it.Kind, it.ID, n, limit)
+	}
+	buf := make([]byte, 0, it.Len())
+	for _, e := range it.Extents {
+		part := make([]byte, e.Len())
+		if _, err := r.ReadAt(part, e.Start); err != nil {
+			return nil, fmt.Errorf("isobmff: failed to read %s item %d at offset %d: %w", it.Kind, it.ID, e.Start, err)
+		}
+		buf = append(buf, part...)
+	}
+	return buf, nil
+}
+
+// ExifTIFF returns the TIFF structure inside an Exif item's bytes and its offset in them.
+//
+// The item begins with a 4-byte offset to the TIFF header (ISO/IEC 23008-12 A.2.1), which
+// writers set to 6 to skip an "Exif\0\0" prefix. Some write 0 and keep the prefix anyway, so a
+// header that is not where the offset says is looked for in the first bytes before giving up.
+func ExifTIFF(item []byte) ([]byte, int, bool) {
+	if len(item) < 4 {
+		return nil, 0, false
+	}
+	isTIFF := func(at int) bool {
+		if at < 0 || at+8 > len(item) {
+			return false
+		}
+		h := string(item[at : at+4])
+		return h == "II*\x00" || h == "MM\x00*"
+	}
+	at := 4 + int(binary.BigEndian.Uint32(item[0:4]))
+	if at >= 4 && isTIFF(at) {
+		return item[at:], at, true
+	}
+	for at := 4; at < 4+16 && at < len(item); at++ {
+		if isTIFF(at) {
+			return item[at:], at, true
+		}
+	}
+	return nil, 0, false
+}
+
+// gpsPositionTags are the GPS IFD entries that hold a position: latitude, longitude,
+// altitude, and the destination latitude and longitude (Exif 2.3, 4.6.6).
+var gpsPositionTags = map[uint16]bool{0x0002: true, 0x0004: true, 0x0006: true, 0x0014: true, 0x0016: true}
+
+// gpsRefTags are the hemisphere references of those positions. Each is a 2-byte ASCII value
+// stored inside its IFD entry, so the span is the entry's 4-byte value field.
+var gpsRefTags = map[uint16]bool{0x0001: true, 0x0003: true, 0x0013: true, 0x0015: true}
+
+// xmpPosition matches an XMP position property in either RDF form — attribute shorthand or
+// element content — and captures its value.
+var xmpPosition = regexp.MustCompile(`[A-Za-z0-9_]+:GPS(?:Dest)?(?:Latitude|Longitude|Altitude)(?:\s*=\s*"([^"]*)"|\s*=\s*'([^']*)'|>([^<]*)<)`)
+
+// ItemCoordinates returns the spans of an Exif or XMP item that hold a GPS position,
+// item-relative. They are the HEIF counterpart of Coordinates, and exist for the same reason:
+// the position is reported re-formatted, so the text that was reported is not in the file.
+//
+//   - In an Exif item the position is a set of RATIONAL values in the GPS IFD, reported as
+//     decimal degrees. The spans are those values' bytes; the IFD entries that point at them
+//     are left alone, so the TIFF structure still parses. Filled with zero they decode as 0/0,
+//     which is no number at all, rather than as another position. The N/S and E/W references
+//     are included: a hemisphere is still a statement about where the picture was taken.
+//   - In an XMP item the position is text such as "37,46.494N", and the reported value is the
+//     same property re-formatted. The spans are the property values; filled with spaces the
+//     packet is still well-formed XML and the properties read as empty.
+func ItemCoordinates(kind string, item []byte) []Span {
+	switch kind {
+	case ItemExif:
+		return exifCoordinates(item)
+	case ItemXMP:
+		var out []Span
+		for _, m := range xmpPosition.FindAllSubmatchIndex(item, -1) {
+			for g := 2; g+1 < len(m); g += 2 {
+				if m[g] >= 0 && m[g+1] > m[g] {
+					out = append(out, Span{int64(m[g]), int64(m[g+1]), "XMP GPS"})
+				}
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// CoordinateFill is the byte ItemCoordinates' spans are filled with for an item kind.
+func CoordinateFill(kind string) byte {
+	if kind == ItemXMP {
+		return ' '
+	}
+	return 0
+}
+
+// exifCoordinates walks IFD0 to the GPS IFD and returns the value bytes of its position
+// entries.
+func exifCoordinates(item []byte) []Span {
+	tiff, base, ok := ExifTIFF(item)
+	if !ok {
+		return nil
+	}
+	var order binary.ByteOrder = binary.BigEndian
+	if tiff[0] == 'I' {
+		order = binary.LittleEndian
+	}
+	// visit receives each entry's fields and the offset of its value field.
+	entries := func(ifd uint32, visit func(tag, typ uint16, count, value uint32, field int)) {
+		if uint64(ifd)+2 > uint64(len(tiff)) {
+			return
+		}
+		n := int(order.Uint16(tiff[ifd:]))
+		at := int(ifd) + 2
+		for i := 0; i < n && at+12 <= len(tiff); i, at = i+1, at+12 {
+			visit(order.Uint16(tiff[at:]), order.Uint16(tiff[at+2:]), order.Uint32(tiff[at+4:]), order.Uint32(tiff[at+8:]), at+8)
+		}
+	}
+
+	gps := uint32(0)
+	entries(order.Uint32(tiff[4:8]), func(tag, typ uint16, count, value uint32, field int) {
+		if tag == 0x8825 {
+			gps = value
+		}
+	})
+	if gps == 0 {
+		return nil
+	}
+
+	var out []Span
+	entries(gps, func(tag, typ uint16, count, value uint32, field int) {
+		const ascii, rational, srational = 2, 5, 10
+		if gpsRefTags[tag] && typ == ascii && count <= 4 {
+			out = append(out, Span{int64(base + field), int64(base + field + 4), "Exif GPS reference"})
+			return
+		}
+		if !gpsPositionTags[tag] || (typ != rational && typ != srational) || count == 0 {
+			return
+		}
+		end := uint64(value) + 8*uint64(count)
+		if end > uint64(len(tiff)) {
+			return
+		}
+		out = append(out, Span{int64(base) + int64(value), int64(base) + int64(end), "Exif GPS"})
+	})
+	return out
+}
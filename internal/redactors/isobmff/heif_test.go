@@ -0,0 +1,174 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package isobmff
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// The HEIF layout below is the one an iPhone writes: ftyp, then a top-level meta whose iinf
+// lists the image and an Exif item and whose iloc places both in mdat by absolute offset.
+// Tiles are collapsed to one image item; the tables do not change shape with their number.
+
+func u16(v int) []byte { return binary.BigEndian.AppendUint16(nil, uint16(v)) }
+func u32(v int) []byte { return binary.BigEndian.AppendUint32(nil, uint32(v)) }
+
+func infe(id int, itemType, contentType string) []byte {
+	body := append([]byte{2, 0, 0, 0}, u16(id)...)
+	body = append(body, 0, 0)
+	body = append(body, itemType...)
+	body = append(body, 0) // item_name
+	if contentType != "" {
+		body = append(append(body, contentType...), 0)
+	}
+	return atom("infe", body)
+}
+
+// heifWith lays out an image item and the given Exif and XMP payloads in mdat. When inIdat is
+// set the XMP item is stored in meta's idat instead (iloc construction method 1).
+func heifWith(exifItem, xmp []byte, inIdat bool) []byte {
+	ftyp := atom("ftyp", []byte("heic"), u32(0), []byte("mif1heic"))
+	pixels := bytes.Repeat([]byte{0xAB}, 64)
+	build := func(mdatPayloadAt int) []byte {
+		iinf := atom("iinf", []byte{0, 0, 0, 0}, u16(3),
+			infe(1, "hvc1", ""), infe(2, "Exif", ""), infe(3, "mime", "application/rdf+xml"))
+		loc := func(id, method, off, n int) []byte {
+			return bytes.Join([][]byte{u16(id), u16(method), u16(0), u16(1), u32(off), u32(n)}, nil)
+		}
+		xmpLoc := loc(3, 0, mdatPayloadAt+len(pixels)+len(exifItem), len(xmp))
+		if inIdat {
+			xmpLoc = loc(3, 1, 0, len(xmp))
+		}
+		iloc := atom("iloc", []byte{1, 0, 0, 0, 0x44, 0x00}, u16(3),
+			loc(1, 0, mdatPayloadAt, len(pixels)),
+			loc(2, 0, mdatPayloadAt+len(pixels), len(exifItem)),
+			xmpLoc)
+		children := [][]byte{{0, 0, 0, 0}, atom("hdlr", make([]byte, 8), []byte("pict"), make([]byte, 13)), iinf, iloc}
+		if inIdat {
+			children = append(children, atom("idat", xmp))
+		}
+		return atom("meta", children...)
+	}
+	meta := build(0)
+	meta = build(len(ftyp) + len(meta) + 8)
+	mdat := []byte{}
+	mdat = append(mdat, pixels...)
+	mdat = append(mdat, exifItem...)
+	if !inIdat {
+		mdat = append(mdat, xmp...)
+	}
+	return bytes.Join([][]byte{ftyp, meta, atom("mdat", mdat)}, nil)
+}
+
+// TestMetadataItemsLocatesExifAndXMPButNotPixels pins the extents exactly: a range that is too
+// wide reaches the image item, and overwriting that corrupts the picture while the file parses.
+func TestMetadataItemsLocatesExifAndXMPButNotPixels(t *testing.T) {
+	exifItem := []byte("\x00\x00\x00\x06Exif\x00\x00MM\x00*\x00\x00\x00\x08")
+	xmp := []byte(`<x:xmpmeta><dc:creator>Ann Lee</dc:creator></x:xmpmeta>`)
+
+	for _, inIdat := range []bool{false, true} {
+		file := heifWith(exifItem, xmp, inIdat)
+		items, err := MetadataItems(bytes.NewReader(file), int64(len(file)))
+		if err != nil {
+			t.Fatalf("idat=%v: %v", inIdat, err)
+		}
+		if len(items) != 2 {
+			t.Fatalf("idat=%v: items = %+v, want the Exif and XMP items only", inIdat, items)
+		}
+		want := map[string][]byte{ItemExif: exifItem, ItemXMP: xmp}
+		for _, it := range items {
+			got, err := ReadItem(bytes.NewReader(file), it, 1<<20)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want[it.Kind]) {
+				t.Errorf("idat=%v: %s item = %q, want %q", inIdat, it.Kind, got, want[it.Kind])
+			}
+		}
+	}
+}
+
+// An iloc extent that points past the end of the file names no bytes of this file, and is
+// dropped rather than clamped.
+func TestMetadataItemsDropsExtentsOutsideTheFile(t *testing.T) {
+	file := heifWith([]byte("\x00\x00\x00\x00MM\x00*"), []byte("<x/>"), false)
+	cut := file[:len(file)-2]
+	items, err := MetadataItems(bytes.NewReader(cut), int64(len(cut)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, it := range items {
+		if it.Kind == ItemXMP {
+			t.Errorf("an XMP item running past the end of the file was returned: %+v", it)
+		}
+	}
+}
+
+func TestIsHEIFReadsCompatibleBrands(t *testing.T) {
+	for _, tc := range []struct {
+		ftyp []byte
+		want bool
+	}{
+		{atom("ftyp", []byte("heic"), u32(0), []byte("mif1heic")), true},
+		{atom("ftyp", []byte("mif1"), u32(0), []byte("mif1heic")), true},
+		{atom("ftyp", []byte("avif"), u32(0), []byte("avifmif1miaf")), true},
+		{atom("ftyp", []byte("isom"), u32(0), []byte("isomiso2mp41")), false},
+		{atom("ftyp", []byte("qt  "), u32(0), []byte("qt  ")), false},
+	} {
+		if got := IsHEIF(append(tc.ftyp, make([]byte, 32)...)); got != tc.want {
+			t.Errorf("IsHEIF(%q) = %v, want %v", tc.ftyp, got, tc.want)
+		}
+	}
+}
+
+// TestItemCoordinatesFindTheGPSRationals builds a little-endian TIFF — what iPhones write —
+// with a GPS IFD, and checks that exactly the position values are returned, not the IFD
+// entries that point at them.
+func TestItemCoordinatesFindTheGPSRationals(t *testing.T) {
+	le := binary.LittleEndian
+	tiff := []byte("II*\x00")
+	tiff = le.AppendUint32(tiff, 8)
+	entry := func(tag, typ, count, value int) []byte {
+		e := le.AppendUint16(nil, uint16(tag))
+		e = le.AppendUint16(e, uint16(typ))
+		e = le.AppendUint32(e, uint32(count))
+		return le.AppendUint32(e, uint32(value))
+	}
+	// IFD0 at 8: one entry, GPSInfo -> 26.
+	tiff = append(tiff, le.AppendUint16(nil, 1)...)
+	tiff = append(tiff, entry(0x8825, 4, 1, 26)...)
+	tiff = le.AppendUint32(tiff, 0)
+	// GPS IFD at 26: LatitudeRef inline, Latitude -> 62.
+	tiff = append(tiff, le.AppendUint16(nil, 2)...)
+	tiff = append(tiff, entry(1, 2, 2, 'N')...)
+	tiff = append(tiff, entry(2, 5, 3, 62)...)
+	tiff = le.AppendUint32(tiff, 0)
+	for len(tiff) < 62 {
+		tiff = append(tiff, 0)
+	}
+	for _, v := range []int{37, 1, 46, 1, 2964, 100} {
+		tiff = le.AppendUint32(tiff, uint32(v))
+	}
+	item := append([]byte("\x00\x00\x00\x06Exif\x00\x00"), tiff...)
+
+	// The reference's value field is the last 4 bytes of its entry, at 26+2+8.
+	spans := ItemCoordinates(ItemExif, item)
+	if len(spans) != 2 || spans[0].Start != 10+36 || spans[0].End != 10+40 ||
+		spans[1].Start != 10+62 || spans[1].End != 10+62+24 {
+		t.Fatalf("spans = %+v, want the reference's value field at [46,50) and the 24 latitude bytes at [72,96)", spans)
+	}
+}
+
+func TestItemCoordinatesFindXMPPositionsInBothForms(t *testing.T) {
+	xmp := []byte(`<rdf:Description exif:GPSLatitude="37,46.494N"><exif:GPSLongitude>122,25.164W</exif:GPSLongitude></rdf:Description>`)
+	var got []string
+	for _, sp := range ItemCoordinates(ItemXMP, xmp) {
+		got = append(got, string(xmp[sp.Start:sp.End]))
+	}
+	if len(got) != 2 || got[0] != "37,46.494N" || got[1] != "122,25.164W" {
+		t.Errorf("values = %q", got)
+	}
+}
@@ -2,7 +2,8 @@
 // SPDX-License-Identifier: Apache-2.0
 
 // Package isobmff locates the metadata atoms of an ISO base media file — .mp4, .m4v, .m4a and
-// QuickTime .mov — without reading the media payload.
+// QuickTime .mov — without reading the media payload, and the Exif and XMP items of a HEIF or
+// AVIF image (see MetadataItems).
 //
 // # Why a walk over an io.ReaderAt
 //
@@ -0,0 +1,551 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package isobmff
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+)
+
+// Coarsen rewrites the position in a span returned by Coordinates or ItemCoordinates to the
+// given number of decimal places, in place and at exactly the same length, and reports whether
+// it could. A caller that gets false fills the span as it would without a precision: a position
+// that cannot be coarsened is removed, never kept as it was.
+//
+// Truncated toward zero rather than rounded, so the integer degree never changes and a position
+// moves at most one grid step, toward the equator and the prime meridian. Each encoding keeps its
+// own form:
+//
+//   - An ISO 6709 string keeps its DD, DDMM or DDMMSS shape and every character's width; the
+//     digits past the precision become zero. The height is cut to whole metres.
+//   - A 16.16 fixed-point position (a loci atom, or a ©xyz payload holding no string) is set to
+//     the nearest fixed-point value to the truncated degree. A loci place name and notes are
+//     text finer than any grid and are blanked with spaces, which keeps the NUL terminators the
+//     coordinate offsets are found by.
+//   - Exif GPS rationals become degrees/10^n, 0/1 minutes and 0/1 seconds, which is exact; an
+//     altitude becomes whole metres. The hemisphere references are kept.
+//   - An XMP "DDD,MM.mmk" or "DDD,MM,SSk" value keeps its shape and widths. One whose coarsened
+//     digits would not fit — a one-digit seconds field that would need two — cannot be
+//     rewritten at the same length and reports false.
+func Coarsen(buf []byte, span Span, decimals int) bool {
+	b, ok := spanBytes(buf, span)
+	if !ok {
+		return false
+	}
+	switch span.Label {
+	case "MP4 loci":
+		return coarsenLoci(b, decimals)
+	case "MP4 ©xyz", "MP4 ©xyz data":
+		if iso6709.Match(b) {
+			return coarsenISO6709(b, decimals)
+		}
+		return len(b) >= 12 && coarsenFixedPoint(b[:12], 2, decimals)
+	case "Exif GPS":
+		return coarsenExifRationals(buf, b, decimals)
+	case "Exif GPS reference":
+		return true // a hemisphere is kept at any precision
+	case "XMP GPS":
+		return coarsenXMP(b, decimals)
+	}
+	// Any other label is a string the layout found by its ISO 6709 shape.
+	return iso6709.Match(b) && coarsenISO6709(b, decimals)
+}
+
+// AtPrecision reports whether the position in a span carries no more than the given number of
+// decimal places. It is the check a redactor runs on the modified bytes in place of "the span
+// holds only the fill": re-reading the position rather than comparing against what was written,
+// so a coarsening that wrote the wrong digits fails it too.
+//
+// A value is at the precision when it lies within its own encoding's resolution of a grid
+// point: a fixed-point word cannot hold 37.77 exactly, and a DDMM string with whole minutes
+// cannot hold it at all.
+func AtPrecision(buf []byte, span Span, decimals int) bool {
+	b, ok := spanBytes(buf, span)
+	if !ok {
+		return true // nothing there to hold a position
+	}
+	switch span.Label {
+	case "MP4 loci":
+		return lociAtPrecision(b, decimals)
+	case "MP4 ©xyz", "MP4 ©xyz data":
+		if iso6709.Match(b) {
+			return iso6709AtPrecision(b, decimals)
+		}
+		return len(b) < 12 || fixedPointAtPrecision(b[:12], 2, decimals)
+	case "Exif GPS":
+		return exifRationalsAtPrecision(buf, b, decimals)
+	case "Exif GPS reference":
+		return true
+	case "XMP GPS":
+		return xmpAtPrecision(b, decimals)
+	}
+	return iso6709AtPrecision(b, decimals)
+}
+
+// spanBytes returns the bytes of a span, if it lies inside buf.
+func spanBytes(buf []byte, span Span) ([]byte, bool) {
+	if span.Start < 0 || span.End > int64(len(buf)) || span.Start >= span.End {
+		return nil, false
+	}
+	return buf[span.Start:span.End], true
+}
+
+// truncatedCells returns |v| truncated to decimals places, in units of 10^-decimals degrees. The
+// small bias keeps a value written exactly on a grid point — 37.77, parsed as 37.769999… — on
+// it.
+func truncatedCells(v float64, decimals int) int64 {
+	return int64(math.Abs(v)*math.Pow10(decimals) + 1e-7)
+}
+
+// nearGrid reports whether v is within unit degrees of a multiple of 10^-decimals.
+func nearGrid(v, unit float64, decimals int) bool {
+	step := math.Pow10(-decimals)
+	if unit >= step {
+		return true // the encoding itself is no finer than the grid
+	}
+	k := math.Round(math.Abs(v) / step)
+	return math.Abs(math.Abs(v)-k*step) <= unit*(1+1e-6)+1e-12
+}
+
+// angle is one sexagesimal angle as written in a string: slices of the buffer holding its
+// degree, minute and second digits and the fraction of the smallest of them, so it can be read
+// and rewritten in place. min and sec are nil when the form has no such field.
+type angle struct {
+	deg, min, sec, frac []byte
+}
+
+// value returns the angle's magnitude in degrees and the resolution its form can express.
+func (a angle) value() (float64, float64) {
+	unit := 1.0
+	v := float64(digitsValue(a.deg))
+	if a.min != nil {
+		unit = 1.0 / 60
+		v += float64(digitsValue(a.min)) * unit
+	}
+	if a.sec != nil {
+		unit = 1.0 / 3600
+		v += float64(digitsValue(a.sec)) * unit
+	}
+	if len(a.frac) > 0 {
+		// Digits past the fifteenth are below a float64's resolution, and would overflow the
+		// integer they are read into.
+		frac := a.frac[:min(len(a.frac), 15)]
+		v += float64(digitsValue(frac)) * unit * math.Pow10(-len(frac))
+		unit *= math.Pow10(-len(a.frac))
+	}
+	return v, unit
+}
+
+// coarsen rewrites the angle truncated to decimals places. The degree digits never change;
+// the minutes, seconds and fraction are recomputed from the remainder in integer arithmetic, so
+// no float error reaches a written digit. Reports false when a recomputed field does not fit
+// its width.
+func (a angle) coarsen(decimals int) bool {
+	v, _ := a.value()
+	scale := int64(math.Pow10(decimals))
+	rem := truncatedCells(v, decimals) - digitsValue(a.deg)*scale
+	if rem < 0 {
+		rem = 0
+	}
+
+	// Only the first nine fraction digits are computed; any past those are zero at any
+	// precision this is called with, and nine keeps the products below inside an int64.
+	f := min(len(a.frac), 9)
+	fracScale := int64(math.Pow10(f))
+	perDegree := int64(1)
+	switch {
+	case a.sec != nil:
+		perDegree = 3600
+	case a.min != nil:
+		perDegree = 60
+	}
+	q := rem * perDegree * fracScale / scale
+	whole, frac := q/fracScale, q%fracScale
+
+	switch {
+	case a.sec != nil:
+		if !putDigits(a.min, whole/60) || !putDigits(a.sec, whole%60) {
+			return false
+		}
+	case a.min != nil:
+		if !putDigits(a.min, whole) {
+			return false
+		}
+	}
+	if !putDigits(a.frac[:f], frac) {
+		return false
+	}
+	for i := f; i < len(a.frac); i++ {
+		a.frac[i] = '0'
+	}
+	return true
+}
+
+// digitsValue reads ASCII digits as a number; the callers have already matched them as digits.
+func digitsValue(b []byte) int64 {
+	var v int64
+	for _, c := range b {
+		v = v*10 + int64(c-'0')
+	}
+	return v
+}
+
+// putDigits writes v zero-padded into exactly len(dst) digits, and reports false if it does not
+// fit.
+func putDigits(dst []byte, v int64) bool {
+	for i := len(dst) - 1; i >= 0; i-- {
+		dst[i] = byte('0' + v%10)
+		v /= 10
+	}
+	return v == 0
+}
+
+// isoComponents splits one ISO 6709 string into its latitude, longitude and optional height
+// fields, each starting at its sign. A CRS suffix and the terminating '/' are not part of any.
+func isoComponents(s []byte) [][]byte {
+	if i := bytes.Index(s, []byte("CRS")); i > 0 {
+		s = s[:i]
+	}
+	s = bytes.TrimSuffix(s, []byte("/"))
+	var fields [][]byte
+	start := 0
+	for i := 1; i < len(s); i++ {
+		if s[i] == '+' || s[i] == '-' {
+			fields = append(fields, s[start:i])
+			start = i
+		}
+	}
+	return append(fields, s[start:])
+}
+
+// isoAngle parses one signed ISO 6709 latitude (degreeDigits 2) or longitude (3).
+func isoAngle(field []byte, degreeDigits int) (angle, bool) {
+	body := field[1:]
+	intPart, frac := body, []byte(nil)
+	if dot := bytes.IndexByte(body, '.'); dot >= 0 {
+		intPart, frac = body[:dot], body[dot+1:]
+	}
+	a := angle{frac: frac}
+	switch len(intPart) - degreeDigits {
+	case 0:
+		a.deg = intPart
+	case 2:
+		a.deg, a.min = intPart[:degreeDigits], intPart[degreeDigits:]
+	case 4:
+		a.deg, a.min, a.sec = intPart[:degreeDigits], intPart[degreeDigits:degreeDigits+2], intPart[degreeDigits+2:]
+	default:
+		return angle{}, false
+	}
+	return a, true
+}
+
+// coarsenISO6709 coarsens every ISO 6709 string in b.
+func coarsenISO6709(b []byte, decimals int) bool {
+	found := false
+	for _, loc := range iso6709.FindAllIndex(b, -1) {
+		fields := isoComponents(b[loc[0]:loc[1]])
+		if len(fields) < 2 {
+			return false
+		}
+		for i, degreeDigits := range []int{2, 3} {
+			a, ok := isoAngle(fields[i], degreeDigits)
+			if !ok || !a.coarsen(decimals) {
+				return false
+			}
+		}
+		if len(fields) >= 3 {
+			// Height in whole metres: a floor number is as precise as an address.
+			if dot := bytes.IndexByte(fields[2], '.'); dot >= 0 {
+				for i := dot + 1; i < len(fields[2]); i++ {
+					fields[2][i] = '0'
+				}
+			}
+		}
+		found = true
+	}
+	return found
+}
+
+// iso6709AtPrecision checks every ISO 6709 string in b.
+func iso6709AtPrecision(b []byte, decimals int) bool {
+	for _, loc := range iso6709.FindAllIndex(b, -1) {
+		fields := isoComponents(b[loc[0]:loc[1]])
+		if len(fields) < 2 {
+			return false
+		}
+		for i, degreeDigits := range []int{2, 3} {
+			a, ok := isoAngle(fields[i], degreeDigits)
+			if !ok {
+				return false
+			}
+			if v, unit := a.value(); !nearGrid(v, unit, decimals) {
+				return false
+			}
+		}
+		if len(fields) >= 3 {
+			if dot := bytes.IndexByte(fields[2], '.'); dot >= 0 && len(bytes.Trim(fields[2][dot+1:], "0")) > 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// fixedPointScale is the denominator of a 16.16 fixed-point coordinate.
+const fixedPointScale = 65536
+
+// coarsenFixedPoint coarsens consecutive 16.16 words: the first angles as degrees, the one
+// after them, if present, as a height in whole metres.
+func coarsenFixedPoint(b []byte, angles, decimals int) bool {
+	for i := 0; i+4 <= len(b); i += 4 {
+		// #nosec G115 -- bit-pattern preservation for sign-extension, as the extractor reads it
+		w := int32(binary.BigEndian.Uint32(b[i:]))
+		if i/4 < angles {
+			v := float64(w) / fixedPointScale
+			c := float64(truncatedCells(v, decimals)) / math.Pow10(decimals)
+			w = int32(math.Copysign(math.Round(c*fixedPointScale), v))
+		} else {
+			w = w / fixedPointScale * fixedPointScale
+		}
+		// #nosec G115 -- see above
+		binary.BigEndian.PutUint32(b[i:], uint32(w))
+	}
+	return true
+}
+
+// fixedPointAtPrecision checks words written by coarsenFixedPoint.
+func fixedPointAtPrecision(b []byte, angles, decimals int) bool {
+	for i := 0; i+4 <= len(b); i += 4 {
+		// #nosec G115 -- see coarsenFixedPoint
+		w := int32(binary.BigEndian.Uint32(b[i:]))
+		if i/4 < angles {
+			if !nearGrid(float64(w)/fixedPointScale, 1.0/fixedPointScale, decimals) {
+				return false
+			}
+		} else if w%fixedPointScale != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// lociFields locates the parts of a loci payload: version and flags(4), language(2), a
+// NUL-terminated place name, role(1), longitude, latitude and altitude as 16.16 words, then a
+// NUL-terminated astronomical body and NUL-terminated notes. The same layout the extractor's
+// parseLociBox reads.
+func lociFields(b []byte) (name, coords, notes []byte, ok bool) {
+	const head = 4 + 2
+	if len(b) < head {
+		return nil, nil, nil, false
+	}
+	nameEnd := bytes.IndexByte(b[head:], 0)
+	if nameEnd < 0 {
+		return nil, nil, nil, false
+	}
+	at := head + nameEnd + 1 + 1
+	if at+12 > len(b) {
+		return nil, nil, nil, false
+	}
+	name, coords = b[head:head+nameEnd], b[at:at+12]
+	at += 12
+	if body := bytes.IndexByte(b[at:], 0); body >= 0 {
+		at += body + 1
+		if end := bytes.IndexByte(b[at:], 0); end >= 0 {
+			notes = b[at : at+end]
+		}
+	}
+	return name, coords, notes, true
+}
+
+// coarsenLoci coarsens a loci position and blanks its place name and notes.
+func coarsenLoci(b []byte, decimals int) bool {
+	name, coords, notes, ok := lociFields(b)
+	if !ok {
+		return false
+	}
+	for _, text := range [][]byte{name, notes} {
+		for i := range text {
+			text[i] = ' '
+		}
+	}
+	return coarsenFixedPoint(coords, 2, decimals)
+}
+
+// lociAtPrecision checks a payload written by coarsenLoci.
+func lociAtPrecision(b []byte, decimals int) bool {
+	name, coords, notes, ok := lociFields(b)
+	if !ok {
+		return false
+	}
+	return len(bytes.TrimSpace(name)) == 0 && len(bytes.TrimSpace(notes)) == 0 &&
+		fixedPointAtPrecision(coords, 2, decimals)
+}
+
+// exifOrder returns the byte order of an Exif item's TIFF structure.
+func exifOrder(item []byte) (binary.ByteOrder, bool) {
+	tiff, _, ok := ExifTIFF(item)
+	if !ok {
+		return nil, false
+	}
+	if tiff[0] == 'I' {
+		return binary.LittleEndian, true
+	}
+	return binary.BigEndian, true
+}
+
+// coarsenExifRationals rewrites a GPS position value: three rationals for an angle, one for an
+// altitude.
+func coarsenExifRationals(item, b []byte, decimals int) bool {
+	order, ok := exifOrder(item)
+	if !ok {
+		return false
+	}
+	put := func(i int, num, den uint32) {
+		order.PutUint32(b[8*i:], num)
+		order.PutUint32(b[8*i+4:], den)
+	}
+	switch len(b) {
+	case 24:
+		v, ok := exifAngle(order, b)
+		if !ok {
+			return false
+		}
+		// #nosec G115 -- at most 180 * 10^5, well inside a uint32
+		put(0, uint32(truncatedCells(v, decimals)), uint32(math.Pow10(decimals)))
+		put(1, 0, 1)
+		put(2, 0, 1)
+		return true
+	case 8:
+		num, den := order.Uint32(b), order.Uint32(b[4:])
+		if den == 0 {
+			put(0, 0, 1)
+		} else {
+			put(0, num/den, 1)
+		}
+		return true
+	}
+	return false
+}
+
+// exifRationalsAtPrecision checks a value written by coarsenExifRationals.
+func exifRationalsAtPrecision(item, b []byte, decimals int) bool {
+	order, ok := exifOrder(item)
+	if !ok {
+		return false
+	}
+	switch len(b) {
+	case 24:
+		v, ok := exifAngle(order, b)
+		return ok && nearGrid(v, 1e-9, decimals)
+	case 8:
+		num, den := order.Uint32(b), order.Uint32(b[4:])
+		return den != 0 && num%den == 0
+	}
+	return false
+}
+
+// exifAngle reads degrees, minutes and seconds rationals as decimal degrees.
+func exifAngle(order binary.ByteOrder, b []byte) (float64, bool) {
+	v := 0.0
+	for i, div := range []float64{1, 60, 3600} {
+		num, den := order.Uint32(b[8*i:]), order.Uint32(b[8*i+4:])
+		if den == 0 {
+			if num != 0 {
+				return 0, false
+			}
+			continue
+		}
+		v += float64(num) / float64(den) / div
+	}
+	return v, true
+}
+
+// xmpAngle parses an XMP GPSCoordinate, "DDD,MM.mmk" or "DDD,MM,SSk" with k one of NSEW.
+func xmpAngle(v []byte) (angle, bool) {
+	if len(v) < 4 || bytes.IndexByte([]byte("NSEWnsew"), v[len(v)-1]) < 0 {
+		return angle{}, false
+	}
+	parts := bytes.Split(v[:len(v)-1], []byte(","))
+	if len(parts) != 2 && len(parts) != 3 {
+		return angle{}, false
+	}
+	a := angle{deg: parts[0], min: parts[1]}
+	if len(parts) == 3 {
+		a.sec = parts[2]
+	}
+	last := &a.min
+	if a.sec != nil {
+		last = &a.sec
+	}
+	if dot := bytes.IndexByte(*last, '.'); dot >= 0 {
+		a.frac = (*last)[dot+1:]
+		*last = (*last)[:dot]
+	}
+	for _, field := range [][]byte{a.deg, a.min, a.sec, a.frac} {
+		for _, c := range field {
+			if c < '0' || c > '9' {
+				return angle{}, false
+			}
+		}
+	}
+	if len(a.deg) == 0 || len(a.min) == 0 || (a.sec != nil && len(a.sec) == 0) {
+		return angle{}, false
+	}
+	return a, true
+}
+
+// xmpRational parses an XMP rational such as a GPSAltitude, "num/den".
+func xmpRational(v []byte) (num, den []byte, ok bool) {
+	slash := bytes.IndexByte(v, '/')
+	if slash <= 0 || slash == len(v)-1 {
+		return nil, nil, false
+	}
+	num, den = v[:slash], v[slash+1:]
+	for _, c := range append(append([]byte(nil), num...), den...) {
+		if c < '0' || c > '9' {
+			return nil, nil, false
+		}
+	}
+	return num, den, true
+}
+
+// coarsenXMP rewrites an XMP GPS property value: a coordinate, or an altitude rational as
+// whole metres, zero-padded to its width.
+func coarsenXMP(b []byte, decimals int) bool {
+	v := bytes.TrimSpace(b)
+	if a, ok := xmpAngle(v); ok {
+		return a.coarsen(decimals)
+	}
+	if num, den, ok := xmpRational(v); ok {
+		d := digitsValue(den)
+		metres := int64(0)
+		if d != 0 {
+			metres = digitsValue(num) / d
+		}
+		if !putDigits(v[:len(v)-2], metres) {
+			return false
+		}
+		copy(v[len(v)-2:], "/1")
+		return true
+	}
+	return false
+}
+
+// xmpAtPrecision checks a value written by coarsenXMP. An empty value holds no position.
+func xmpAtPrecision(b []byte, decimals int) bool {
+	v := bytes.TrimSpace(b)
+	if len(v) == 0 {
+		return true
+	}
+	if a, ok := xmpAngle(v); ok {
+		deg, unit := a.value()
+		return nearGrid(deg, unit, decimals)
+	}
+	if num, den, ok := xmpRational(v); ok {
+		d := digitsValue(den)
+		return d != 0 && digitsValue(num)%d == 0
+	}
+	return false
+}
@@ -0,0 +1,163 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package isobmff
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// TestCoarsenISO6709KeepsEachForm runs the three Annex H forms through a 2-decimal coarsening.
+// 37.7749,-122.4194 is 37°46.494′ and 37°46′29.64″; truncated to 37.77 it is 37°46.2′, or
+// 37°46′12″.
+func TestCoarsenISO6709KeepsEachForm(t *testing.T) {
+	cases := []struct{ in, want string }{
+		{"+37.7749-122.4194+012.345/", "+37.7700-122.4100+012.000/"},
+		{"+3746.494-12225.164/", "+3746.200-12224.600/"},
+		{"+374629.64-1222509.84/", "+374612.00-1222436.00/"},
+		{"-33.8688+151.2093CRSWGS_84/", "-33.8600+151.2000CRSWGS_84/"},
+		{"+37.7-122.4/", "+37.7-122.4/"}, // already coarser than asked
+	}
+	for _, c := range cases {
+		buf := append([]byte("\x00\x12\x15\xc7"), c.in...)
+		span := Span{0, int64(len(buf)), "MP4 ©xyz"}
+		if AtPrecision(buf, span, 2) != (c.in == c.want) {
+			t.Errorf("%s: AtPrecision before = %v", c.in, !(c.in == c.want))
+		}
+		if !Coarsen(buf, span, 2) {
+			t.Fatalf("%s: not coarsened", c.in)
+		}
+		if got := string(buf[4:]); got != c.want {
+			t.Errorf("Coarsen(%s) = %s, want %s", c.in, got, c.want)
+		}
+		if !bytes.Equal(buf[:4], []byte("\x00\x12\x15\xc7")) {
+			t.Errorf("%s: the length and language prefix changed", c.in)
+		}
+		if !AtPrecision(buf, span, 2) {
+			t.Errorf("%s: AtPrecision after = false", c.in)
+		}
+	}
+}
+
+func fixedPoint(v float64) []byte {
+	return binary.BigEndian.AppendUint32(nil, uint32(int32(math.Round(v*65536))))
+}
+
+func readFixed(b []byte) float64 {
+	return float64(int32(binary.BigEndian.Uint32(b))) / 65536
+}
+
+func TestCoarsenLociKeepsTheLayoutAndBlanksThePlaceName(t *testing.T) {
+	payload := append([]byte{0, 0, 0, 0, 0x15, 0xc7}, "1 Market St\x00"...)
+	payload = append(payload, 0)
+	coords := len(payload)
+	payload = append(payload, fixedPoint(-122.4194)...)
+	payload = append(payload, fixedPoint(37.7749)...)
+	payload = append(payload, fixedPoint(12.5)...)
+	payload = append(payload, "earth\x00front door\x00"...)
+	buf := atom("loci", payload)
+	span := Coordinates(buf)[0]
+
+	if AtPrecision(buf, span, 2) {
+		t.Fatal("the original position already passes the check")
+	}
+	if !Coarsen(buf, span, 2) {
+		t.Fatal("not coarsened")
+	}
+	at := 8 + coords
+	lon, lat, alt := readFixed(buf[at:]), readFixed(buf[at+4:]), readFixed(buf[at+8:])
+	if math.Abs(lon+122.41) > 1e-4 || math.Abs(lat-37.77) > 1e-4 || alt != 12 {
+		t.Errorf("position = %f, %f, %f; want -122.41, 37.77, 12", lat, lon, alt)
+	}
+	if bytes.Contains(buf, []byte("Market")) || bytes.Contains(buf, []byte("front door")) {
+		t.Error("the place name or notes survived")
+	}
+	if !bytes.Contains(buf, []byte("earth\x00")) || buf[8+6+11] != 0 {
+		t.Error("a NUL terminator or the astronomical body moved")
+	}
+	if !AtPrecision(buf, span, 2) {
+		t.Error("AtPrecision after = false")
+	}
+}
+
+func TestCoarsenFixedPointXyz(t *testing.T) {
+	payload := append(append(fixedPoint(51.50735), fixedPoint(-0.12776)...), fixedPoint(35.9)...)
+	buf := atom("\xa9xyz", payload)
+	span := Coordinates(buf)[0]
+	if !Coarsen(buf, span, 3) {
+		t.Fatal("not coarsened")
+	}
+	if lat, lon := readFixed(buf[8:]), readFixed(buf[12:]); math.Abs(lat-51.507) > 1e-4 || math.Abs(lon+0.127) > 1e-4 {
+		t.Errorf("position = %f, %f; want 51.507, -0.127", lat, lon)
+	}
+	if !AtPrecision(buf, span, 3) {
+		t.Error("AtPrecision after = false")
+	}
+}
+
+func TestCoarsenExifRationalsAndXMP(t *testing.T) {
+	be := binary.BigEndian
+	tiff := []byte("MM\x00*")
+	tiff = be.AppendUint32(tiff, 8)
+	entry := func(tag, typ, count, value int) []byte {
+		e := be.AppendUint16(nil, uint16(tag))
+		e = be.AppendUint16(e, uint16(typ))
+		e = be.AppendUint32(e, uint32(count))
+		return be.AppendUint32(e, uint32(value))
+	}
+	tiff = append(tiff, be.AppendUint16(nil, 1)...)
+	tiff = append(tiff, entry(0x8825, 4, 1, 26)...)
+	tiff = be.AppendUint32(tiff, 0)
+	// GPS IFD at 26: LatitudeRef "N" inline, Latitude -> 68, Altitude -> 92.
+	tiff = append(tiff, be.AppendUint16(nil, 3)...)
+	tiff = append(tiff, entry(1, 2, 2, 'N'<<24)...)
+	tiff = append(tiff, entry(2, 5, 3, 68)...)
+	tiff = append(tiff, entry(6, 5, 1, 92)...)
+	tiff = be.AppendUint32(tiff, 0)
+	for len(tiff) < 68 {
+		tiff = append(tiff, 0)
+	}
+	for _, v := range []int{37, 1, 46, 1, 2964, 100, 12345, 1000} {
+		tiff = be.AppendUint32(tiff, uint32(v))
+	}
+	item := append([]byte("\x00\x00\x00\x06Exif\x00\x00"), tiff...)
+
+	spans := ItemCoordinates(ItemExif, item)
+	for _, sp := range spans {
+		if !Coarsen(item, sp, 2) || !AtPrecision(item, sp, 2) {
+			t.Fatalf("%s: not coarsened", sp.Label)
+		}
+	}
+	var got []uint32
+	for at := 10 + 68; at < 10+68+32; at += 4 {
+		got = append(got, be.Uint32(item[at:]))
+	}
+	want := []uint32{3777, 100, 0, 1, 0, 1, 12, 1}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("rationals = %v, want %v", got, want)
+		}
+	}
+	if item[10+36] != 'N' {
+		t.Error("the hemisphere reference was not kept")
+	}
+
+	xmp := []byte(`<x exif:GPSLatitude="37,46.494N" exif:GPSAltitude="12345/1000"><exif:GPSLongitude>122,25,9W</exif:GPSLongitude></x>`)
+	spans = ItemCoordinates(ItemXMP, xmp)
+	if len(spans) != 3 {
+		t.Fatalf("spans = %+v", spans)
+	}
+	if !Coarsen(xmp, spans[0], 2) || !Coarsen(xmp, spans[1], 2) {
+		t.Fatal("not coarsened")
+	}
+	if !bytes.Contains(xmp, []byte(`"37,46.200N"`)) || !bytes.Contains(xmp, []byte(`"00000012/1"`)) {
+		t.Errorf("xmp = %s", xmp)
+	}
+	// 122°25′9″ truncated to 122.41° is 122°24′36″, and 36 does not fit a one-digit field.
+	if Coarsen(xmp, spans[2], 2) {
+		t.Error("a value whose coarsened digits do not fit was reported as coarsened")
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/awslabs/ferret-scan/v2/internal/detector"
+	"github.com/awslabs/ferret-scan/v2/internal/filetype"
 	"github.com/awslabs/ferret-scan/v2/internal/observability"
 	"github.com/awslabs/ferret-scan/v2/internal/preprocessors"
 )
@@ -306,6 +307,17 @@ func (rm *RedactionManager) GetRedactorForFile(filePath string) (Redactor, error
 	ext := strings.ToLower(filepath.Ext(filePath))
 
 	redactor, exists := rm.redactors[ext]
+
+	// A file in a format its name does not name, or in a format under a name no
+	// redactor claims, is redacted as the format it is. It was scanned that way
+	// (the router reads it by content), and every redactor chooses how to parse
+	// and rewrite a file by its extension, so the one its name selects would
+	// misread it: a JPEG named .png parsed as a PNG, a Word document named .zip
+	// not redacted at all.
+	if format, _ := filetype.Detect(filePath); format != nil && (!exists || format.Contradicts(filePath)) {
+		return rm.redactorForFormat(filePath, format)
+	}
+
 	if !exists {
 		// A file whose BYTES are text is redactable as text, whatever it is named.
 		//
@@ -357,10 +369,80 @@ func (rm *RedactionManager) GetRedactorForFile(filePath string) (Redactor, error
 			return plain, nil
 		}
 	}
+	// The same for a notebook, whose signature is being JSON: a text file named
+	// .ipynb was scanned as text, so it is redacted as text.
+	if plain, ok := rm.redactors[".txt"]; ok && ext == ".ipynb" && !preprocessors.IsNotebookFile(filePath) {
+		return plain, nil
+	}
 
 	return redactor, nil
 }
 
+// redactorForFormat returns the redactor for a file of format whose name does
+// not select it. Text stays with the text redactor, as it would under any other
+// name: an RTF letter saved as .txt is scanned and rewritten as text. Otherwise
+// the redactor that claims one of the format's extensions redacts the file
+// through an alias named with that extension; a format no redactor claims is
+// refused, and the refusal is disclosed as an unredacted file, like any other.
+func (rm *RedactionManager) redactorForFormat(filePath string, format *filetype.Format) (Redactor, error) {
+	if plain, ok := rm.redactors[".txt"]; ok && looksLikeTextFile(filePath) {
+		return plain, nil
+	}
+	for _, ext := range format.Extensions {
+		if redactor, ok := rm.redactors[ext]; ok {
+			return &aliasRedactor{Redactor: redactor, rm: rm, ext: ext}, nil
+		}
+	}
+	return nil, fmt.Errorf("file content is a %s, which no redactor handles; not redacted", format.Name)
+}
+
+// aliasRedactor hands its redactor the file through an alias named with ext.
+type aliasRedactor struct {
+	Redactor
+	rm  *RedactionManager
+	ext string
+}
+
+// RedactDocument redacts the file at originalPath as a file named with ext. The
+// matches that name the file are retargeted to the alias, as a container's
+// matches are to an embedded part's temp file, and an embedded part keeps its
+// depth under the alias.
+func (a *aliasRedactor) RedactDocument(originalPath, outputPath string, matches []detector.Match, strategy RedactionStrategy) (*RedactionResult, error) {
+	alias, release, err := filetype.Alias(originalPath, a.ext)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s as %s: %w", filepath.Base(originalPath), a.ext, err)
+	}
+	defer release()
+	if depth := a.rm.embeddedDepth.depthOf(originalPath); depth > 0 {
+		a.rm.embeddedDepth.set(alias, depth)
+		defer a.rm.embeddedDepth.clear(alias)
+	}
+	return a.Redactor.RedactDocument(alias, outputPath, retargetAliasMatches(matches, originalPath, alias), strategy)
+}
+
+// retargetAliasMatches renames, in the matches, the file at filePath to its
+// alias, whether a match names it by path or by base name
+// ("photo.jpg -> qr[0]" becomes "photo.jpg.png -> qr[0]").
+func retargetAliasMatches(matches []detector.Match, filePath, alias string) []detector.Match {
+	var out []detector.Match
+	for i, m := range matches {
+		for _, name := range [][2]string{{filePath, alias}, {filepath.Base(filePath), filepath.Base(alias)}} {
+			if m.Filename != name[0] && !strings.HasPrefix(m.Filename, name[0]+" -> ") {
+				continue
+			}
+			if out == nil {
+				out = append([]detector.Match(nil), matches...)
+			}
+			out[i].Filename = name[1] + m.Filename[len(name[0]):]
+			break
+		}
+	}
+	if out == nil {
+		return matches
+	}
+	return out
+}
+
 // looksLikeTextFile reports whether a file's leading bytes are text, using the SAME sniff that
 // decided to scan it.
 //
@@ -401,12 +483,13 @@ var containerRedactorExtensions = map[string]struct{}{
 	".docx": {}, ".xlsx": {}, ".pptx": {},
 	".docm": {}, ".xlsm": {}, ".pptm": {},
 	".doc": {}, ".xls": {}, ".ppt": {},
+	".sqlite": {}, ".sqlite3": {}, ".db": {}, ".db3": {},
 }
 
-// hasContainerSignature reports whether a file begins with the ZIP (OOXML) or OLE
-// compound-file magic. A read failure returns true so an unreadable file keeps its
-// extension-selected redactor and fails through the existing path, rather than
-// being silently rewritten as text.
+// hasContainerSignature reports whether a file begins with the ZIP (OOXML), OLE
+// compound-file or SQLite magic. A read failure returns true so an unreadable
+// file keeps its extension-selected redactor and fails through the existing
+// path, rather than being silently rewritten as text.
 func hasContainerSignature(filePath string) bool {
 	f, err := os.Open(filepath.Clean(filePath)) // #nosec G304 -- path already vetted by the caller
 	if err != nil {
@@ -414,7 +497,7 @@ func hasContainerSignature(filePath string) bool {
 	}
 	defer f.Close()
 
-	var head [8]byte
+	var head [16]byte
 	n, err := io.ReadFull(f, head[:])
 	if err != nil && n < 4 {
 		return true
@@ -428,6 +511,10 @@ func hasContainerSignature(filePath string) bool {
 	if n >= 8 && head[0] == 0xD0 && head[1] == 0xCF && head[2] == 0x11 && head[3] == 0xE0 {
 		return true
 	}
+	// SQLite database.
+	if n == len(head) && string(head[:]) == "SQLite format 3\x00" {
+		return true
+	}
 	return false
 }
 
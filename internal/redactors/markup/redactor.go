@@ -0,0 +1,249 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package markup redacts HTML, XML and RTF documents.
+//
+// Findings in these documents are made against their decoded text: entities
+// resolved, tags and RTF control words removed. Redacting the raw bytes as
+// plain text could not find a value written as "&#53;36-22-1874" or split as
+// "123-<b>45</b>-6789", and a replacement containing "<" or "{" could break
+// the markup around it. This redactor locates each finding in the same decoded
+// text, maps it back to the source bytes it came from, and rewrites only that
+// character data, escaped for where it lands. Tags, attribute names and RTF
+// groups are copied through untouched.
+//
+// The output is then read back and searched for every reported value, and a
+// document that still holds one is refused rather than written, on the same
+// policy as the Office redactor.
+package markup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/awslabs/ferret-scan/v2/internal/detector"
+	"github.com/awslabs/ferret-scan/v2/internal/observability"
+	"github.com/awslabs/ferret-scan/v2/internal/preprocessors"
+	textextractmarkuplib "github.com/awslabs/ferret-scan/v2/internal/preprocessors/text-extractors/text-extract-markuplib"
+	"github.com/awslabs/ferret-scan/v2/internal/redactors"
+	"github.com/awslabs/ferret-scan/v2/internal/redactors/replacement"
+)
+
+// MarkupRedactor redacts the character data of HTML, XML and RTF documents.
+type MarkupRedactor struct {
+	observer      observability.Observer
+	outputManager *redactors.OutputStructureManager
+}
+
+// NewMarkupRedactor creates a redactor for HTML, XML and RTF documents.
+func NewMarkupRedactor(outputManager *redactors.OutputStructureManager, observer observability.Observer) *MarkupRedactor {
+	if observer == nil {
+		observer = observability.NewStandardObserver(observability.ObservabilityMetrics, nil)
+	}
+	return &MarkupRedactor{observer: observer, outputManager: outputManager}
+}
+
+// GetName returns the name of the redactor.
+func (r *MarkupRedactor) GetName() string { return "markup_redactor" }
+
+// GetComponentName returns the component name for observability.
+func (r *MarkupRedactor) GetComponentName() string { return "markup_redactor" }
+
+// GetSupportedTypes returns the markup types this redactor handles.
+func (r *MarkupRedactor) GetSupportedTypes() []string {
+	return []string{".html", ".htm", ".xhtml", ".xml", ".rtf"}
+}
+
+// GetSupportedStrategies returns the redaction strategies this redactor supports.
+func (r *MarkupRedactor) GetSupportedStrategies() []redactors.RedactionStrategy {
+	return []redactors.RedactionStrategy{
+		redactors.RedactionSimple,
+		redactors.RedactionFormatPreserving,
+		redactors.RedactionSynthetic,
+	}
+}
+
+// RedactDocument writes a redacted copy of a markup document to outputPath.
+func (r *MarkupRedactor) RedactDocument(originalPath string, outputPath string, matches []detector.Match, strategy redactors.RedactionStrategy) (*redactors.RedactionResult, error) {
+	finishTiming := r.observer.StartTiming(r.GetComponentName(), "redact_document", originalPath)
+	defer finishTiming(true, map[string]interface{}{
+		"output_path": outputPath,
+		"match_count": len(matches),
+		"strategy":    strategy.String(),
+	})
+
+	start := time.Now()
+
+	format, ok := textextractmarkuplib.FormatFor(originalPath)
+	if !ok {
+		return nil, fmt.Errorf("not a markup document: %s", filepath.Base(originalPath))
+	}
+	raw, err := os.ReadFile(originalPath) // #nosec G304 -- path vetted by the router
+	if err != nil {
+		return nil, fmt.Errorf("failed to read original file: %w", err)
+	}
+	source, encoding, ex := preprocessors.ReadMarkup(raw, format)
+
+	// Same normalization as every other path, for the same reasons: a cluster's and a
+	// bounded match's Text occur nowhere in the document, and a match nested in a
+	// wider one must not be applied first. See the plaintext redactor.
+	matches = redactors.ExpandClusterMatches(matches)
+	matches = redactors.RestoreBoundedMatchText(matches)
+	matches = redactors.ResolveOverlaps(matches)
+
+	edits, mappings := r.locate(ex.Text, matches, strategy)
+	redacted := ex.Rewrite([]byte(source), edits)
+
+	// Refuse to write a document that still holds a reported value, read back the
+	// way the scan read it. A value the locator could not place, or one whose
+	// rewrite was dropped because its escape overlapped another's, ends up here
+	// rather than in a file under the redaction output directory.
+	//
+	// TYPES, never the values: this message reaches stderr and every output format.
+	check := textextractmarkuplib.Extract(redacted, format)
+	if residue := redactors.ResidualValues([]string{check.Text}, matches); len(residue) > 0 {
+		return nil, fmt.Errorf(
+			"refusing to write %s: %d reported value(s) still present in the document's text (types: %s)",
+			filepath.Base(outputPath), len(residue), strings.Join(redactors.ResidueTypes(residue), ", "))
+	}
+
+	if r.outputManager != nil {
+		if err := r.outputManager.EnsureDirectoryExists(outputPath); err != nil {
+			return nil, fmt.Errorf("failed to ensure output directory: %w", err)
+		}
+	}
+	// #nosec G703 -- outputPath is operator-controlled (--redaction-output-dir +
+	// mirrored input filename), as for the plaintext redactor.
+	if err := os.WriteFile(outputPath, preprocessors.EncodeFromUTF8(string(redacted), encoding), 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write redacted file: %w", err)
+	}
+	if r.outputManager != nil {
+		if info, err := os.Stat(originalPath); err == nil {
+			os.Chmod(outputPath, info.Mode())
+			os.Chtimes(outputPath, info.ModTime(), info.ModTime())
+		}
+	}
+
+	return &redactors.RedactionResult{
+		Success:          true,
+		RedactedFilePath: outputPath,
+		RedactionMap:     mappings,
+		ProcessingTime:   time.Since(start),
+		Confidence:       overallConfidence(mappings),
+		Error:            nil,
+	}, nil
+}
+
+// locate finds each match in the extracted text and returns the edits that
+// replace it.
+//
+// A match is looked for on its own line first, at the first occurrence no
+// earlier match has claimed, so two findings of the same value on one line
+// land on both occurrences. A match not on its line (a line-number drift, or a
+// scan made without preprocessors) falls back to the first unclaimed
+// occurrence anywhere.
+func (r *MarkupRedactor) locate(text string, matches []detector.Match, strategy redactors.RedactionStrategy) ([]textextractmarkuplib.Edit, []redactors.RedactionMapping) {
+	sorted := make([]detector.Match, len(matches))
+	copy(sorted, matches)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].LineNumber < sorted[j].LineNumber })
+
+	lineStarts := []int{0}
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+
+	var edits []textextractmarkuplib.Edit
+	var mappings []redactors.RedactionMapping
+	// Claimed start offsets. Overlap between different values was already resolved
+	// by ResolveOverlaps; what is left is the same value found more than once.
+	claimed := make(map[int]bool, len(matches))
+	find := func(lo, hi int, value string) int {
+		for from := lo; from < hi; {
+			idx := strings.Index(text[from:hi], value)
+			if idx < 0 {
+				return -1
+			}
+			pos := from + idx
+			if !claimed[pos] {
+				return pos
+			}
+			from = pos + 1
+		}
+		return -1
+	}
+
+	for _, m := range sorted {
+		if m.Text == "" {
+			continue
+		}
+		pos := -1
+		if m.LineNumber >= 1 && m.LineNumber <= len(lineStarts) {
+			lo := lineStarts[m.LineNumber-1]
+			hi := len(text)
+			if m.LineNumber < len(lineStarts) {
+				hi = lineStarts[m.LineNumber]
+			}
+			pos = find(lo, hi, m.Text)
+		}
+		if pos < 0 {
+			pos = find(0, len(text), m.Text)
+		}
+		if pos < 0 {
+			r.logEvent("match_not_located", false, map[string]interface{}{
+				"match_type":   m.Type,
+				"match_line":   m.LineNumber,
+				"match_length": len(m.Text),
+			})
+			continue
+		}
+
+		claimed[pos] = true
+		repl := replacement.Generate(m.Text, m.Type, strategy)
+		edits = append(edits, textextractmarkuplib.Edit{Start: pos, End: pos + len(m.Text), Replacement: repl})
+		mappings = append(mappings, redactors.RedactionMapping{
+			RedactedText: repl,
+			Position: redactors.TextPosition{
+				Line:      m.LineNumber,
+				StartChar: pos,
+				EndChar:   pos + len(m.Text),
+			},
+			DataType:   m.Type,
+			Strategy:   strategy,
+			Confidence: m.Confidence / 100.0,
+			Metadata: map[string]interface{}{
+				"position_method": "markup_source_map",
+			},
+		})
+	}
+	return edits, mappings
+}
+
+func (r *MarkupRedactor) logEvent(op string, success bool, meta map[string]interface{}) {
+	if r.observer == nil {
+		return
+	}
+	r.observer.LogOperation(observability.StandardObservabilityData{
+		Component: r.GetComponentName(),
+		Operation: op,
+		Success:   success,
+		Metadata:  meta,
+	})
+}
+
+// overallConfidence averages the per-redaction confidence.
+func overallConfidence(mappings []redactors.RedactionMapping) float64 {
+	if len(mappings) == 0 {
+		return 1.0
+	}
+	total := 0.0
+	for _, m := range mappings {
+		total += m.Confidence
+	}
+	return total / float64(len(mappings))
+}
@@ -0,0 +1,109 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package markup
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/awslabs/ferret-scan/v2/internal/detector"
+	textextractmarkuplib "github.com/awslabs/ferret-scan/v2/internal/preprocessors/text-extractors/text-extract-markuplib"
+	"github.com/awslabs/ferret-scan/v2/internal/redactors"
+)
+
+// found reports a match for every occurrence of value in the document's
+// extracted text, with the line number a scan would give it.
+func found(t *testing.T, path, typ, value string) []detector.Match {
+	t.Helper()
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	format, _ := textextractmarkuplib.FormatFor(path)
+	var out []detector.Match
+	for i, line := range strings.Split(textextractmarkuplib.Extract(raw, format).Text, "\n") {
+		for n := strings.Count(line, value); n > 0; n-- {
+			out = append(out, detector.Match{Text: value, Type: typ, LineNumber: i + 1, Confidence: 90,
+				Context: detector.ContextInfo{FullLine: line}})
+		}
+	}
+	if len(out) == 0 {
+		t.Fatalf("%q not found in the extracted text of %s", value, filepath.Base(path))
+	}
+	return out
+}
+
+func writeDoc(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRedactHTMLRewritesOnlyCharacterData(t *testing.T) {
+	path := writeDoc(t, "claim.html",
+		`<html><body><p class="ssn">SSN 536-<b>22</b>-&#49;874</p>`+
+			`<p>again: 536-22-1874</p><img alt="536-22-1874" src="a.png"></body></html>`)
+	out := filepath.Join(t.TempDir(), "claim.html")
+
+	r := NewMarkupRedactor(nil, nil)
+	res, err := r.RedactDocument(path, out, found(t, path, "SSN", "536-22-1874"), redactors.RedactionFormatPreserving)
+	if err != nil {
+		t.Fatalf("RedactDocument: %v", err)
+	}
+	if len(res.RedactionMap) != 3 {
+		t.Errorf("got %d redactions, want 3", len(res.RedactionMap))
+	}
+
+	got, _ := os.ReadFile(out)
+	want := `<html><body><p class="ssn">SSN ***-<b>**</b>-1874</p>` +
+		`<p>again: ***-**-1874</p><img alt="***-**-1874" src="a.png"></body></html>`
+	if string(got) != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestRedactRTF(t *testing.T) {
+	path := writeDoc(t, "memo.rtf",
+		`{\rtf1\ansi{\fonttbl{\f0 Arial;}}\f0 Card 4111 1111 \b 1111 1111\b0\par Caf\'e9}`)
+	out := filepath.Join(t.TempDir(), "memo.rtf")
+
+	r := NewMarkupRedactor(nil, nil)
+	if _, err := r.RedactDocument(path, out, found(t, path, "CREDIT_CARD", "4111 1111 1111 1111"), redactors.RedactionSimple); err != nil {
+		t.Fatalf("RedactDocument: %v", err)
+	}
+	got, _ := os.ReadFile(out)
+	if !strings.HasPrefix(string(got), `{\rtf1\ansi{\fonttbl{\f0 Arial;}}\f0 Card `) ||
+		!strings.HasSuffix(string(got), `\b0\par Caf\'e9}`) {
+		t.Errorf("markup around the value changed: %s", got)
+	}
+	text := textextractmarkuplib.Extract(got, textextractmarkuplib.FormatRTF).Text
+	if strings.Contains(text, "4111") || !strings.Contains(text, "REDACTED") {
+		t.Errorf("redacted text = %q", text)
+	}
+}
+
+// A value that is still in the output is a refusal, and the refusal names the
+// type and never the value.
+func TestRedactRefusesResidue(t *testing.T) {
+	path := writeDoc(t, "two.xml", `<r><a>536-22-1874</a><b>536-22-1874</b></r>`)
+	out := filepath.Join(t.TempDir(), "two.xml")
+
+	// Only the first occurrence is reported.
+	matches := found(t, path, "SSN", "536-22-1874")[:1]
+	_, err := NewMarkupRedactor(nil, nil).RedactDocument(path, out, matches, redactors.RedactionSimple)
+	if err == nil {
+		t.Fatal("RedactDocument wrote a document that still holds a reported value")
+	}
+	if !strings.Contains(err.Error(), "SSN") || strings.Contains(err.Error(), "536-22-1874") {
+		t.Errorf("error = %v", err)
+	}
+	if _, statErr := os.Stat(out); statErr == nil {
+		t.Error("output file written despite the refusal")
+	}
+}
@@ -0,0 +1,276 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package notebook redacts Jupyter notebooks (.ipynb).
+//
+// Findings in a notebook are made against the text of its cells and outputs,
+// not against the JSON it is stored as. Redacting the JSON as plain text would
+// miss a value the JSON escapes ("\u0035" for "5") or a traceback breaks up
+// with colour codes, and a replacement holding a quote would break the file.
+// This redactor locates each finding in the same extracted text, maps it back
+// to the JSON string it came from, and rewrites only that string, re-escaped.
+// Every other byte of the notebook, cell metadata and image outputs included,
+// is copied through, so the copy opens in Jupyter as the same notebook.
+//
+// With outputs clearing on (--clear-notebook-outputs), any cell holding a HIGH
+// confidence finding, in its source or its outputs, also has its outputs
+// emptied, as "Clear Output" would. A secret printed once tends to be printed
+// in a form no validator recognizes too: a repr, a truncated DataFrame, an
+// HTTP response.
+//
+// The output is then read back, and a notebook that still holds a reported
+// value is refused rather than written, on the same policy as the Office and
+// markup redactors.
+package notebook
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/awslabs/ferret-scan/v2/internal/detector"
+	"github.com/awslabs/ferret-scan/v2/internal/observability"
+	textextractnotebooklib "github.com/awslabs/ferret-scan/v2/internal/preprocessors/text-extractors/text-extract-notebooklib"
+	"github.com/awslabs/ferret-scan/v2/internal/redactors"
+	"github.com/awslabs/ferret-scan/v2/internal/redactors/replacement"
+)
+
+// highConfidence is the lowest confidence reported as HIGH.
+const highConfidence = 90
+
+// NotebookRedactor redacts the cells and outputs of Jupyter notebooks.
+type NotebookRedactor struct {
+	observer      observability.Observer
+	outputManager *redactors.OutputStructureManager
+	clearOutputs  bool
+}
+
+// NewNotebookRedactor creates a redactor for Jupyter notebooks.
+func NewNotebookRedactor(outputManager *redactors.OutputStructureManager, observer observability.Observer) *NotebookRedactor {
+	if observer == nil {
+		observer = observability.NewStandardObserver(observability.ObservabilityMetrics, nil)
+	}
+	return &NotebookRedactor{observer: observer, outputManager: outputManager}
+}
+
+// SetClearOutputs makes the redactor empty the outputs of every cell holding
+// a HIGH confidence finding. Set it before redaction begins.
+func (r *NotebookRedactor) SetClearOutputs(clear bool) {
+	r.clearOutputs = clear
+}
+
+// GetName returns the name of the redactor.
+func (r *NotebookRedactor) GetName() string { return "notebook_redactor" }
+
+// GetComponentName returns the component name for observability.
+func (r *NotebookRedactor) GetComponentName() string { return "notebook_redactor" }
+
+// GetSupportedTypes returns the notebook types this redactor handles.
+func (r *NotebookRedactor) GetSupportedTypes() []string {
+	return []string{".ipynb"}
+}
+
+// GetSupportedStrategies returns the redaction strategies this redactor supports.
+func (r *NotebookRedactor) GetSupportedStrategies() []redactors.RedactionStrategy {
+	return []redactors.RedactionStrategy{
+		redactors.RedactionSimple,
+		redactors.RedactionFormatPreserving,
+		redactors.RedactionSynthetic,
+	}
+}
+
+// RedactDocument writes a redacted copy of a notebook to outputPath.
+func (r *NotebookRedactor) RedactDocument(originalPath string, outputPath string, matches []detector.Match, strategy redactors.RedactionStrategy) (*redactors.RedactionResult, error) {
+	finishTiming := r.observer.StartTiming(r.GetComponentName(), "redact_document", originalPath)
+	defer finishTiming(true, map[string]interface{}{
+		"output_path":   outputPath,
+		"match_count":   len(matches),
+		"strategy":      strategy.String(),
+		"clear_outputs": r.clearOutputs,
+	})
+
+	start := time.Now()
+
+	raw, err := os.ReadFile(originalPath) // #nosec G304 -- path vetted by the router
+	if err != nil {
+		return nil, fmt.Errorf("failed to read original file: %w", err)
+	}
+	ex, err := textextractnotebooklib.Extract(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read notebook %s: %w", filepath.Base(originalPath), err)
+	}
+
+	// Same normalization as every other path, for the same reasons: a cluster's and a
+	// bounded match's Text occur nowhere in the document, and a match nested in a
+	// wider one must not be applied first. See the plaintext redactor.
+	matches = redactors.ExpandClusterMatches(matches)
+	matches = redactors.RestoreBoundedMatchText(matches)
+	matches = redactors.ResolveOverlaps(matches)
+
+	edits, mappings, clear := r.locate(ex, matches, strategy)
+	redacted := ex.Rewrite(raw, edits, clear)
+
+	// Refuse to write a notebook that still holds a reported value, read back the
+	// way the scan read it. A value the locator could not place ends up here rather
+	// than in a file under the redaction output directory.
+	//
+	// TYPES, never the values: this message reaches stderr and every output format.
+	check, err := textextractnotebooklib.Extract(redacted)
+	if err != nil {
+		return nil, fmt.Errorf("refusing to write %s: the redacted notebook does not read back: %w", filepath.Base(outputPath), err)
+	}
+	if residue := redactors.ResidualValues([]string{check.Text}, matches); len(residue) > 0 {
+		return nil, fmt.Errorf(
+			"refusing to write %s: %d reported value(s) still present in the notebook's text (types: %s)",
+			filepath.Base(outputPath), len(residue), strings.Join(redactors.ResidueTypes(residue), ", "))
+	}
+
+	if r.outputManager != nil {
+		if err := r.outputManager.EnsureDirectoryExists(outputPath); err != nil {
+			return nil, fmt.Errorf("failed to ensure output directory: %w", err)
+		}
+	}
+	// #nosec G703 -- outputPath is operator-controlled (--redaction-output-dir +
+	// mirrored input filename), as for the plaintext redactor.
+	if err := os.WriteFile(outputPath, redacted, 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write redacted file: %w", err)
+	}
+	if r.outputManager != nil {
+		if info, err := os.Stat(originalPath); err == nil {
+			os.Chmod(outputPath, info.Mode())
+			os.Chtimes(outputPath, info.ModTime(), info.ModTime())
+		}
+	}
+
+	return &redactors.RedactionResult{
+		Success:          true,
+		RedactedFilePath: outputPath,
+		RedactionMap:     mappings,
+		ProcessingTime:   time.Since(start),
+		Confidence:       overallConfidence(mappings),
+		Error:            nil,
+	}, nil
+}
+
+// locate finds each match in the extracted text and returns the edits that
+// replace it, and, when outputs clearing is on, the cells whose outputs to
+// empty.
+//
+// A match is looked for on its own line first, at the first occurrence no
+// earlier match has claimed, so two findings of the same value on one line
+// land on both occurrences. A match not on its line (a line-number drift, or a
+// scan made without preprocessors) falls back to the first unclaimed
+// occurrence anywhere.
+func (r *NotebookRedactor) locate(ex *textextractnotebooklib.Extraction, matches []detector.Match, strategy redactors.RedactionStrategy) ([]textextractnotebooklib.Edit, []redactors.RedactionMapping, map[int]bool) {
+	text := ex.Text
+	sorted := make([]detector.Match, len(matches))
+	copy(sorted, matches)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].LineNumber < sorted[j].LineNumber })
+
+	lineStarts := []int{0}
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+
+	var edits []textextractnotebooklib.Edit
+	var mappings []redactors.RedactionMapping
+	clear := map[int]bool{}
+	// Claimed start offsets. Overlap between different values was already resolved
+	// by ResolveOverlaps; what is left is the same value found more than once.
+	claimed := make(map[int]bool, len(matches))
+	find := func(lo, hi int, value string) int {
+		for from := lo; from < hi; {
+			idx := strings.Index(text[from:hi], value)
+			if idx < 0 {
+				return -1
+			}
+			pos := from + idx
+			if !claimed[pos] {
+				return pos
+			}
+			from = pos + 1
+		}
+		return -1
+	}
+
+	for _, m := range sorted {
+		if m.Text == "" {
+			continue
+		}
+		pos := -1
+		if m.LineNumber >= 1 && m.LineNumber <= len(lineStarts) {
+			lo := lineStarts[m.LineNumber-1]
+			hi := len(text)
+			if m.LineNumber < len(lineStarts) {
+				hi = lineStarts[m.LineNumber]
+			}
+			pos = find(lo, hi, m.Text)
+		}
+		if pos < 0 {
+			pos = find(0, len(text), m.Text)
+		}
+		if pos < 0 {
+			r.logEvent("match_not_located", false, map[string]interface{}{
+				"match_type":   m.Type,
+				"match_line":   m.LineNumber,
+				"match_length": len(m.Text),
+			})
+			continue
+		}
+
+		claimed[pos] = true
+		seg, _ := ex.CellAt(pos)
+		if r.clearOutputs && m.Confidence >= highConfidence {
+			clear[seg.Cell] = true
+		}
+		repl := replacement.Generate(m.Text, m.Type, strategy)
+		edits = append(edits, textextractnotebooklib.Edit{Start: pos, End: pos + len(m.Text), Replacement: repl})
+		mappings = append(mappings, redactors.RedactionMapping{
+			RedactedText: repl,
+			Position: redactors.TextPosition{
+				Line:      m.LineNumber,
+				StartChar: pos,
+				EndChar:   pos + len(m.Text),
+			},
+			DataType:   m.Type,
+			Strategy:   strategy,
+			Confidence: m.Confidence / 100.0,
+			Metadata: map[string]interface{}{
+				"position_method": "notebook_cell_map",
+				"cell":            seg.Cell,
+				"cell_type":       seg.CellType,
+				"in_output":       seg.Output,
+			},
+		})
+	}
+	return edits, mappings, clear
+}
+
+func (r *NotebookRedactor) logEvent(op string, success bool, meta map[string]interface{}) {
+	if r.observer == nil {
+		return
+	}
+	r.observer.LogOperation(observability.StandardObservabilityData{
+		Component: r.GetComponentName(),
+		Operation: op,
+		Success:   success,
+		Metadata:  meta,
+	})
+}
+
+// overallConfidence averages the per-redaction confidence.
+func overallConfidence(mappings []redactors.RedactionMapping) float64 {
+	if len(mappings) == 0 {
+		return 1.0
+	}
+	total := 0.0
+	for _, m := range mappings {
+		total += m.Confidence
+	}
+	return total / float64(len(mappings))
+}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/awslabs/ferret-scan/v2/internal/detector"
 	"github.com/awslabs/ferret-scan/v2/internal/embedded"
+	textextractbarcodelib "github.com/awslabs/ferret-scan/v2/internal/preprocessors/text-extractors/text-extract-barcodelib"
 	"github.com/awslabs/ferret-scan/v2/internal/redactors"
 )
 
@@ -72,6 +73,7 @@ func (or *OfficeRedactor) redactEmbeddedParts(
 	if len(values) == 0 {
 		return nil, nil
 	}
+	symbolValues := barcodeValueSet(matches)
 
 	var merged []redactors.RedactionMapping
 	var unredacted []unredactedPart
@@ -98,7 +100,12 @@ func (or *OfficeRedactor) redactEmbeddedParts(
 		// container is refused rather than written. Scanning it is still worth it --
 		// the finding gets reported either way -- and failing loudly is the honest end
 		// state when the value cannot be removed.
-		if embedded.ResidueInspectable(child.name) && !partHoldsValue(child.content, values, 0) {
+		//
+		// A barcode is the exception to "inspectable": its payload is in the pixels,
+		// not the bytes, so an image part is also decoded when a finding was reported
+		// against a symbol.
+		if embedded.ResidueInspectable(child.name) && !partHoldsValue(child.content, values, 0) &&
+			len(symbolValuesIn(child.name, child.content, symbolValues)) == 0 {
 			continue
 		}
 
@@ -150,7 +157,9 @@ func (or *OfficeRedactor) redactEmbeddedParts(
 		// has been wrong before in this codebase: a count of 1 has been reported on an
 		// output byte-identical to its input. The only claim worth making is that the
 		// value is no longer there, so check the bytes that will actually be written.
-		if residue := valuesPresentIn(res.Content, values, 0, false); len(residue) > 0 {
+		residue := valuesPresentIn(res.Content, values, 0, false)
+		residue = append(residue, symbolValuesIn(child.name, res.Content, symbolValues)...)
+		if len(residue) > 0 {
 			unredacted = append(unredacted, unredactedPart{
 				name: child.name,
 				reason: fmt.Sprintf("%d reported value(s) still present after redaction",
@@ -208,7 +217,7 @@ func embeddedValueSet(matches []detector.Match) [][]byte {
 }
 
 // minResidueValueLen is the shortest value worth searching for in arbitrary bytes.
-const minResidueValueLen = 4
+const minResidueValueLen = redactors.MinResidueValueLen
 
 // describeEmbeddedFailure turns a dispatch error into a short operator-facing
 // reason.
@@ -325,6 +334,44 @@ func scanForValues(content []byte, values [][]byte, depth int, stopAtFirst bool,
 	return found
 }
 
+// barcodeValueSet collects the values of the matches reported against a barcode
+// symbol ("outer.docx -> image1.png -> qr[0]"). Empty when there are none, which
+// keeps the gate from decoding the pixels of every image in a document whose
+// findings are all in its text.
+func barcodeValueSet(matches []detector.Match) []string {
+	var out []string
+	for _, m := range matches {
+		if _, ok := textextractbarcodelib.LabelOf(m.Filename); ok && len(m.Text) >= minResidueValueLen {
+			out = append(out, m.Text)
+		}
+	}
+	return out
+}
+
+// symbolValuesIn returns the values held in the barcode symbols of an image part.
+//
+// An image too large to decode returns nothing: the scan refused it too, so no
+// finding came from its symbols.
+func symbolValuesIn(name string, content []byte, values []string) []string {
+	if len(values) == 0 || embedded.KindOfPath(name) != "image" {
+		return nil
+	}
+	symbols, err := textextractbarcodelib.DecodeBytes(content)
+	if err != nil {
+		return nil
+	}
+	var found []string
+	for _, s := range symbols {
+		text := s.ScanText()
+		for _, v := range values {
+			if strings.Contains(text, v) {
+				found = append(found, v)
+			}
+		}
+	}
+	return found
+}
+
 // embeddedFailureSummary renders the unredacted parts as one operator-facing line.
 func embeddedFailureSummary(parts []unredactedPart) string {
 	names := make([]string, 0, len(parts))
@@ -47,13 +47,19 @@ func TestIsTextContainingFileIgnoresPartNameCase(t *testing.T) {
 		{"xlsx Sheet caps", "xl/Worksheets/Sheet1.xml", DocumentTypeXLSX, true},
 		{"xlsx shared strings", "xl/sharedStrings.xml", DocumentTypeXLSX, true},
 		{"xlsx shared strings lower", "xl/sharedstrings.xml", DocumentTypeXLSX, true},
+		{"xlsx legacy comments", "xl/comments1.xml", DocumentTypeXLSX, true},
+		{"xlsx threaded comments", "xl/threadedComments/threadedComment1.xml", DocumentTypeXLSX, true},
 		{"xlsx workbook is not text", "xl/workbook.xml", DocumentTypeXLSX, false},
+		{"xlsx persons are not text", "xl/persons/person.xml", DocumentTypeXLSX, false},
 
 		// PowerPoint.
 		{"pptx slide", "ppt/slides/slide1.xml", DocumentTypePPTX, true},
 		{"pptx Slide caps", "ppt/Slides/Slide1.xml", DocumentTypePPTX, true},
 		{"pptx layout", "ppt/slideLayouts/slideLayout1.xml", DocumentTypePPTX, true},
 		{"pptx master", "ppt/slideMasters/slideMaster1.xml", DocumentTypePPTX, true},
+		{"pptx comments", "ppt/comments/comment1.xml", DocumentTypePPTX, true},
+		{"pptx modern comments", "ppt/comments/modernComment_100_1.xml", DocumentTypePPTX, true},
+		{"pptx comment authors are not text", "ppt/commentAuthors.xml", DocumentTypePPTX, false},
 		{"pptx presentation is not a slide", "ppt/presentation.xml", DocumentTypePPTX, false},
 	}
 
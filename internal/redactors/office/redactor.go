@@ -13,7 +13,6 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
-	"sync/atomic"
 	"time"
 
 	"github.com/awslabs/ferret-scan/v2/internal/detector"
@@ -54,6 +53,10 @@ type OfficeRedactor struct {
 	// is nil and an embedded part holds a reported value, that is DISCLOSED rather
 	// than passed over — see redactEmbeddedParts.
 	embeddedRedactor redactors.EmbeddedRedactor
+
+	// sanitizeMetadata removes the package's personal metadata on every
+	// redaction, matched or not. See SetSanitizeMetadata.
+	sanitizeMetadata bool
 }
 
 // OfficeDocumentType represents the type of Office document
@@ -206,6 +209,14 @@ func (or *OfficeRedactor) RedactDocument(originalPath string, outputPath string,
 		return nil, fmt.Errorf("failed to redact office content: %w", err)
 	}
 
+	if or.sanitizeMetadata {
+		sanitized, err := sanitizeMetadataParts(modifiedContents)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sanitize office metadata: %w", err)
+		}
+		redactionMap = append(redactionMap, sanitized...)
+	}
+
 	// Redact files embedded INSIDE this document, each by the redactor that owns
 	// its format, and store the results back at their own entry names.
 	embeddedMap, unredacted := or.redactEmbeddedParts(originalPath, modifiedContents, children, matches, strategy)
@@ -555,14 +566,18 @@ func (or *OfficeRedactor) isTextContainingFile(fileName string, docType OfficeDo
 		return false
 
 	case DocumentTypeXLSX:
-		// Excel: worksheets and the shared string table.
-		return strings.HasPrefix(name, "xl/worksheets/") || name == "xl/sharedstrings.xml"
+		// Excel: worksheets, the shared string table, and cell comments, legacy
+		// and threaded. The extractor scans comments, so a value reported from
+		// one must be rewritable here.
+		return strings.HasPrefix(name, "xl/worksheets/") || name == "xl/sharedstrings.xml" ||
+			strings.HasPrefix(name, "xl/comments") || strings.HasPrefix(name, "xl/threadedcomments/")
 
 	case DocumentTypePPTX:
-		// PowerPoint: slides, layouts, masters.
+		// PowerPoint: slides, layouts, masters, and slide comments.
 		return strings.HasPrefix(name, "ppt/slides/") ||
 			strings.HasPrefix(name, "ppt/slidelayouts/") ||
-			strings.HasPrefix(name, "ppt/slidemasters/")
+			strings.HasPrefix(name, "ppt/slidemasters/") ||
+			strings.HasPrefix(name, "ppt/comments/")
 
 	default:
 		return false
@@ -739,12 +754,13 @@ func (or *OfficeRedactor) isTextElement(path []string, docType OfficeDocumentTyp
 		return lastElement == "t" || lastElement == "delText"
 
 	case DocumentTypeXLSX:
-		// Excel text elements: t (text in shared strings), v (cell value), f (formula)
-		return lastElement == "t" || lastElement == "v" || lastElement == "f"
+		// Excel text elements: t (text in shared strings and legacy comments), v
+		// (cell value), f (formula), text (a threaded comment)
+		return lastElement == "t" || lastElement == "v" || lastElement == "f" || lastElement == "text"
 
 	case DocumentTypePPTX:
-		// PowerPoint text elements: a:t (text)
-		return lastElement == "t"
+		// PowerPoint text elements: a:t (text), p:text (a legacy comment)
+		return lastElement == "t" || lastElement == "text"
 
 	default:
 		return false
@@ -834,6 +850,13 @@ func (or *OfficeRedactor) redactOfficeContent(zipContents *OfficeZipContents, ex
 		return nil, nil, err
 	}
 
+	// After the rewrite, so a value in deleted text is still recorded as
+	// redacted where it could be located; see acceptTrackedDeletions for why the
+	// revision goes as well.
+	if docType == DocumentTypeDOCX {
+		or.acceptTrackedDeletions(modifiedContents)
+	}
+
 	return redactionMap, modifiedContents, nil
 }
 
@@ -926,19 +949,9 @@ func (or *OfficeRedactor) applyPendingRedactions(zipContents *OfficeZipContents,
 	return nil
 }
 
-// residueIdentifyPasses counts how many times the residue check has entered its
-// per-value identification loop.
-//
-// It exists for one guard: the identification loop is O(values x text) and must stay
-// off the successful path. Measured when it was NOT: +0.00s, +0.01s, +0.02s then
-// +0.09s as the reported-value count doubled through 500/1000/2000/4000, i.e. about
-// 4.5x per doubling.
-//
-// A counter rather than a timing assertion, because the quadratic here is pure
-// strings.Contains work that allocates nothing — so an allocation-based guard is blind
-// to it (verified: restoring the quadratic passed an allocation ratio test) — and
-// wall-clock thresholds are not portable to the Windows runner.
-var residueIdentifyPasses atomic.Uint64
+// residueIdentifyPasses is the shared residue check's identification counter, named
+// here for the tests that guard it against this package's parts.
+var residueIdentifyPasses = &redactors.ResidueIdentifyPasses
 
 // parentPartResidue returns the reported values still present in the document's own
 // XML parts after redaction, comparing on DECODED text.
@@ -962,41 +975,13 @@ var residueIdentifyPasses atomic.Uint64
 //     malformed part the redactor never claimed to rewrite would fail closed on the
 //     wrong thing.
 //
-// # Cost
-//
-// Two stages, and the split is deliberate. A naive "one strings.Contains per value per
-// part" is O(values x text) and measured quadratic: on a .docx with 4000 distinct
-// reported values the identification scan cost +0.00s, +0.01s, +0.02s then +0.09s as
-// the value count doubled — about 4.5x per doubling. Small in absolute terms, but this
-// repo has repeatedly been bitten by exactly that shape once inputs grew.
-//
-// So the common case pays only a single trie pass. strings.Replacer builds one trie
-// over all the values and scans each part once, independent of how many values there
-// are; if nothing matched, there is no residue and we are done. The per-value
-// identification loop runs ONLY when a hit has already been found, i.e. only on the
-// path that is about to refuse to write the document, where an extra pass is free
-// relative to failing the run.
+// The two-stage search itself, and why it is shaped that way, is
+// redactors.ResidualValues.
 func parentPartResidue(contents *OfficeZipContents, matches []detector.Match) []detector.Match {
 	if contents == nil || len(matches) == 0 {
 		return nil
 	}
 
-	wanted := make([]string, 0, len(matches))
-	byText := make(map[string]detector.Match, len(matches))
-	for _, m := range matches {
-		if len(m.Text) < minResidueValueLen {
-			continue
-		}
-		if _, dup := byText[m.Text]; dup {
-			continue
-		}
-		byText[m.Text] = m
-		wanted = append(wanted, m.Text)
-	}
-	if len(wanted) == 0 {
-		return nil
-	}
-
 	// Sorted part order so the reported residue list is stable run to run.
 	names := make([]string, 0, len(contents.Files))
 	for name := range contents.Files {
@@ -1006,77 +991,19 @@ func parentPartResidue(contents *OfficeZipContents, matches []detector.Match) []
 	}
 	sort.Strings(names)
 
-	// Stage 1: one trie pass per part over ALL values at once. Replacing every value
-	// with nothing and comparing is a presence oracle whose cost does not grow with the
-	// number of values.
-	probeArgs := make([]string, 0, len(wanted)*2)
-	for _, v := range wanted {
-		probeArgs = append(probeArgs, v, "")
-	}
-	probe := strings.NewReplacer(probeArgs...)
-
 	texts := make([]string, 0, len(names))
-	anyHit := false
 	for _, name := range names {
-		text, ok := decodedPartText(contents.Files[name])
-		if !ok {
-			continue
-		}
-		texts = append(texts, text)
-		if !anyHit && probe.Replace(text) != text {
-			anyHit = true
-		}
-	}
-	if !anyHit {
-		return nil
-	}
-
-	// Stage 2: name the offenders. Only reached when the document is already going to
-	// be refused, so the per-value scan is not on any successful path.
-	residueIdentifyPasses.Add(1)
-	found := make(map[string]struct{}, len(wanted))
-	for _, text := range texts {
-		for _, v := range wanted {
-			if _, already := found[v]; already {
-				continue
-			}
-			if strings.Contains(text, v) {
-				found[v] = struct{}{}
-			}
-		}
-	}
-
-	out := make([]detector.Match, 0, len(found))
-	for _, v := range wanted {
-		if _, ok := found[v]; ok {
-			out = append(out, byText[v])
+		if text, ok := decodedPartText(contents.Files[name]); ok {
+			texts = append(texts, text)
 		}
 	}
-	return out
+	return redactors.ResidualValues(texts, matches)
 }
 
-// residueTypes lists the distinct finding types of a residue set, sorted, for a message
-// that must not carry the values themselves.
-//
-// A match with no Type still has to be counted as something an operator can see, so it
-// is named "unknown" rather than dropped — a residue that reports fewer types than
-// values would understate what is still in the file.
+// residueTypes lists the distinct finding types of a residue set; see
+// redactors.ResidueTypes.
 func residueTypes(residue []detector.Match) []string {
-	seen := make(map[string]struct{}, len(residue))
-	out := make([]string, 0, len(residue))
-	for _, m := range residue {
-		t := m.Type
-		if t == "" {
-			t = "unknown"
-		}
-		if _, dup := seen[t]; dup {
-			continue
-		}
-		seen[t] = struct{}{}
-		out = append(out, t)
-	}
-	sort.Strings(out)
-	return out
+	return redactors.ResidueTypes(residue)
 }
 
 // decodedPartText concatenates a part's entity-decoded character data and attribute
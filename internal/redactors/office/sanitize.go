@@ -0,0 +1,185 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package office
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/awslabs/ferret-scan/v2/internal/redactors"
+)
+
+// SetSanitizeMetadata makes RedactDocument also remove the package's personal
+// metadata, whether or not a match names it: the author and editor, the
+// company, manager, template and hyperlink base, the attached template's
+// path, every custom property, the revision IDs (rsids) Word stamps on
+// paragraphs and runs, and the printer name in stored printer settings. Set
+// by internal/core for --sanitize-metadata.
+func (or *OfficeRedactor) SetSanitizeMetadata(enabled bool) {
+	or.sanitizeMetadata = enabled
+}
+
+// Elements of docProps/core.xml and docProps/app.xml whose text is emptied, by
+// local name. The element is kept, empty, so the part stays what every reader
+// of it expects.
+var (
+	corePersonalElements = []string{"creator", "lastModifiedBy"}
+	appPersonalElements  = []string{"Company", "Manager", "Template", "HyperlinkBase"}
+)
+
+var (
+	// rsidAttr is one revision-ID attribute within a start tag: w:rsidR,
+	// w:rsidRPr, w:rsidRDefault, w:rsidP, w:rsidDel, w:rsidSect, w:rsidTr.
+	rsidAttr = regexp.MustCompile(`\s+[A-Za-z_][\w.-]*:rsid\w*\s*=\s*("[^"]*"|'[^']*')`)
+
+	// rsidsElement is the table of every revision ID in word/settings.xml.
+	rsidsElement = regexp.MustCompile(`(?s)<([A-Za-z_][\w.-]*:)?rsids\b[^>]*?(/>|>.*?</([A-Za-z_][\w.-]*:)?rsids\s*>)`)
+
+	// attachedTemplate is the reference in word/settings.xml to the template a
+	// document was made from, and templateRelationship its target: the path,
+	// often on a file share, the template was loaded from.
+	attachedTemplate     = regexp.MustCompile(`<([A-Za-z_][\w.-]*:)?attachedTemplate\b[^>]*/>`)
+	templateRelationship = regexp.MustCompile(`<Relationship\b[^>]*/attachedTemplate"[^>]*/>`)
+
+	// customProperty is one property of docProps/custom.xml.
+	customProperty = regexp.MustCompile(`(?s)<([A-Za-z_][\w.-]*:)?property\b[^>]*?(/>|>.*?</([A-Za-z_][\w.-]*:)?property\s*>)`)
+)
+
+// devModeNameBytes is the size of dmDeviceName, the first field of the
+// DEVMODEW structure a printerSettings part holds: 32 UTF-16 characters.
+const devModeNameBytes = 64
+
+// sanitizeMetadataParts removes the personal metadata of the package in place.
+// It edits only properties, attributes and the printer record, never text a
+// reader sees, so it needs no match and cannot damage the body.
+//
+// The returned mappings record what no metadata extractor reads back, the
+// revision IDs, attached template and printer names, one per part changed, so
+// a caller's report can name them. Properties are left to the caller, which
+// sees them gone by extracting the output again.
+func sanitizeMetadataParts(contents *OfficeZipContents) ([]redactors.RedactionMapping, error) {
+	var mappings []redactors.RedactionMapping
+	for _, name := range contents.orderedNames() {
+		data := contents.Files[name]
+		lower := strings.ToLower(name)
+		var (
+			out   []byte
+			field string
+			err   error
+		)
+		switch {
+		case lower == "docprops/core.xml":
+			out = emptyElements(data, corePersonalElements)
+		case lower == "docprops/app.xml":
+			out = emptyElements(data, appPersonalElements)
+		case lower == "docprops/custom.xml":
+			out = customProperty.ReplaceAll(data, nil)
+		case strings.HasPrefix(lower, "word/") && strings.HasSuffix(lower, ".xml"):
+			if out, err = stripRevisionIDs(data); err != nil {
+				return nil, fmt.Errorf("failed to remove revision IDs from %s: %w", name, err)
+			}
+			if lower == "word/settings.xml" {
+				out = rsidsElement.ReplaceAll(out, nil)
+				out = attachedTemplate.ReplaceAll(out, nil)
+			}
+			field = "RevisionIDs"
+		case lower == "word/_rels/settings.xml.rels":
+			out = templateRelationship.ReplaceAll(data, nil)
+			field = "AttachedTemplate"
+		case path.Base(path.Dir(lower)) == "printersettings" && strings.HasSuffix(lower, ".bin"):
+			out = clearPrinterName(data)
+			field = "PrinterName"
+		default:
+			continue
+		}
+		if bytes.Equal(out, data) {
+			continue
+		}
+		contents.Files[name] = out
+		if field != "" {
+			mappings = append(mappings, redactors.RedactionMapping{
+				DataType:   "METADATA",
+				Strategy:   redactors.RedactionSimple,
+				Confidence: 1.0,
+				Metadata: map[string]interface{}{
+					"office_file":    name,
+					"metadata_field": field,
+				},
+			})
+		}
+	}
+	return mappings, nil
+}
+
+// emptyElements removes the text of every element of data whose local name is
+// in names.
+func emptyElements(data []byte, names []string) []byte {
+	for _, n := range names {
+		re := regexp.MustCompile(`(?s)(<(?:[A-Za-z_][\w.-]*:)?` + n + `\b[^>]*[^/]>|<(?:[A-Za-z_][\w.-]*:)?` + n + `>).*?(</(?:[A-Za-z_][\w.-]*:)?` + n + `\s*>)`)
+		data = re.ReplaceAll(data, []byte("$1$2"))
+	}
+	return data
+}
+
+// stripRevisionIDs removes the rsid attributes from every start tag of an XML
+// part. Tags are found by tokenizing, so text that merely reads like an
+// attribute is never touched.
+func stripRevisionIDs(data []byte) ([]byte, error) {
+	if !bytes.Contains(data, []byte(":rsid")) {
+		return data, nil
+	}
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.Strict = false
+	var out bytes.Buffer
+	var copied int64
+	for {
+		start := d.InputOffset()
+		tok, err := d.RawToken()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		se, ok := tok.(xml.StartElement)
+		if !ok || !hasRevisionID(se) {
+			continue
+		}
+		end := d.InputOffset()
+		out.Write(data[copied:start])
+		out.Write(rsidAttr.ReplaceAll(data[start:end], nil))
+		copied = end
+	}
+	out.Write(data[copied:])
+	return out.Bytes(), nil
+}
+
+func hasRevisionID(se xml.StartElement) bool {
+	for _, a := range se.Attr {
+		if a.Name.Space != "" && strings.HasPrefix(a.Name.Local, "rsid") {
+			return true
+		}
+	}
+	return false
+}
+
+// clearPrinterName zeroes dmDeviceName, which names the printer, or the share
+// path of a network printer. The rest of the record is the page setup and is
+// kept; the length is unchanged.
+func clearPrinterName(data []byte) []byte {
+	if len(data) < devModeNameBytes || bytes.Count(data[:devModeNameBytes], []byte{0}) == devModeNameBytes {
+		return data
+	}
+	out := append([]byte(nil), data...)
+	for i := 0; i < devModeNameBytes; i++ {
+		out[i] = 0
+	}
+	return out
+}
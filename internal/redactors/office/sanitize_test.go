@@ -0,0 +1,185 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package office
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/awslabs/ferret-scan/v2/internal/redactors"
+)
+
+// Sanitizing removes the personal metadata of a package with no match at all,
+// and leaves the text a reader sees as it was, including text that reads like
+// a revision-ID attribute.
+func TestSanitizeMetadataRemovesPersonalMetadata(t *testing.T) {
+	dir := t.TempDir()
+	printer := make([]byte, 220)
+	copy(printer, []byte("\\\x00\\\x00p\x00r\x00n\x00-\x004\x00"))
+	printer[100] = 7 // page setup, kept
+
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Default Extension="bin" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.printerSettings"/>` +
+			`<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>` +
+			`</Types>`},
+		{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>` +
+			`</Relationships>`},
+		{"word/document.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>` +
+			`<w:p w:rsidR="00A1B2C3" w:rsidRDefault="00D4E5F6"><w:r w:rsidRPr="00123456"><w:t>Set w:rsidR="00FFFFFF" in the template.</w:t></w:r></w:p>` +
+			`<w:sectPr w:rsidR="00A1B2C3"/></w:body></w:document>`},
+		{"word/settings.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<w:settings xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">` +
+			`<w:zoom w:percent="100"/><w:attachedTemplate r:id="rId1"/><w:rsids><w:rsidRoot w:val="00A1B2C3"/><w:rsid w:val="00D4E5F6"/></w:rsids>` +
+			`</w:settings>`},
+		{"word/_rels/settings.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/attachedTemplate"` +
+			` Target="file:///\\\\corp-fs01\\templates\\Normal.dotm" TargetMode="External"/>` +
+			`</Relationships>`},
+		{"word/printerSettings/printerSettings1.bin", string(printer)},
+		{"docProps/core.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties"` +
+			` xmlns:dc="http://purl.org/dc/elements/1.1/">` +
+			`<dc:title>Quarterly plan</dc:title><dc:creator>Jane Quincy</dc:creator><cp:lastModifiedBy>Omar Lind</cp:lastModifiedBy>` +
+			`</cp:coreProperties>`},
+		{"docProps/app.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<Properties xmlns="http://schemas.openxmlformats.org/officeDocument/2006/extended-properties">` +
+			`<Template>\\corp-fs01\templates\plan.dotx</Template><Company>Project Nightjar</Company><Pages>1</Pages>` +
+			`</Properties>`},
+		{"docProps/custom.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<Properties xmlns="http://schemas.openxmlformats.org/officeDocument/2006/custom-properties"` +
+			` xmlns:vt="http://schemas.openxmlformats.org/officeDocument/2006/docPropsVTypes">` +
+			`<property fmtid="{D5CDD505-2E9C-101B-9397-08002B2CF9AE}" pid="2" name="ClientName"><vt:lpwstr>Acme Holdings</vt:lpwstr></property>` +
+			`</Properties>`},
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, p := range parts {
+		w, err := zw.Create(p.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(p.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	in := filepath.Join(dir, "plan.docx")
+	if err := os.WriteFile(in, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewOfficeRedactor(nil, nil)
+	r.SetSanitizeMetadata(true)
+	out := filepath.Join(dir, "out.docx")
+	result, err := r.RedactDocument(in, out, nil, redactors.RedactionSimple)
+	if err != nil {
+		t.Fatalf("RedactDocument: %v", err)
+	}
+
+	got := readParts(t, out)
+	for _, gone := range []string{"Jane Quincy", "Omar Lind", "corp-fs01", "Project Nightjar", "Acme Holdings", "ClientName", "00A1B2C3", "00D4E5F6", "00123456", "<w:rsids>", "attachedTemplate"} {
+		for name, content := range got {
+			if strings.Contains(content, gone) {
+				t.Errorf("%s still holds %q:\n%s", name, gone, content)
+			}
+		}
+	}
+	for name, kept := range map[string]string{
+		"word/document.xml": `<w:t>Set w:rsidR="00FFFFFF" in the template.</w:t>`,
+		"word/settings.xml": `<w:zoom w:percent="100"/>`,
+		"docProps/core.xml": `<dc:title>Quarterly plan</dc:title><dc:creator></dc:creator>`,
+		"docProps/app.xml":  `<Company></Company><Pages>1</Pages>`,
+	} {
+		if !strings.Contains(got[name], kept) {
+			t.Errorf("%s lost %q:\n%s", name, kept, got[name])
+		}
+	}
+	bin := got["word/printerSettings/printerSettings1.bin"]
+	if len(bin) != len(printer) || bin[:devModeNameBytes] != string(make([]byte, devModeNameBytes)) || bin[100] != 7 {
+		t.Errorf("printer settings = %q, want the name zeroed and the rest kept", bin)
+	}
+
+	fields := map[string]string{}
+	for _, m := range result.RedactionMap {
+		fields[m.Metadata["office_file"].(string)] = m.Metadata["metadata_field"].(string)
+	}
+	if fields["word/document.xml"] != "RevisionIDs" || fields["word/settings.xml"] != "RevisionIDs" ||
+		fields["word/_rels/settings.xml.rels"] != "AttachedTemplate" ||
+		fields["word/printerSettings/printerSettings1.bin"] != "PrinterName" || len(fields) != 4 {
+		t.Errorf("mappings = %v", fields)
+	}
+}
+
+// Without the setting, a redaction with no match leaves the metadata alone.
+func TestSanitizeMetadataIsOffByDefault(t *testing.T) {
+	dir := t.TempDir()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, p := range []struct{ name, content string }{
+		{"[Content_Types].xml", `<?xml version="1.0"?><Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"/>`},
+		{"word/document.xml", `<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body><w:p w:rsidR="00A1B2C3"/></w:body></w:document>`},
+		{"docProps/core.xml", `<cp:coreProperties xmlns:cp="c" xmlns:dc="d"><dc:creator>Jane Quincy</dc:creator></cp:coreProperties>`},
+	} {
+		w, err := zw.Create(p.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(p.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	in := filepath.Join(dir, "a.docx")
+	if err := os.WriteFile(in, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "out.docx")
+	if _, err := NewOfficeRedactor(nil, nil).RedactDocument(in, out, nil, redactors.RedactionSimple); err != nil {
+		t.Fatal(err)
+	}
+	got := readParts(t, out)
+	if !strings.Contains(got["docProps/core.xml"], "Jane Quincy") || !strings.Contains(got["word/document.xml"], "00A1B2C3") {
+		t.Errorf("metadata changed without sanitizing: %v", got)
+	}
+}
+
+func readParts(t *testing.T, path string) map[string]string {
+	t.Helper()
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	parts := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts[f.Name] = string(data)
+	}
+	return parts
+}
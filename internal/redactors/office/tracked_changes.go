@@ -0,0 +1,58 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package office
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// trackedDeletionRe matches one <w:del> revision with the runs it deleted. The
+// attribute group may not end in "/": a self-closing <w:del .../> in a
+// paragraph's properties marks a deleted paragraph mark, holds no text, and
+// taken for an opening tag would swallow the document up to the next deletion.
+var trackedDeletionRe = regexp.MustCompile(`(?s)<w:del\b(?:[^>]*[^/>])?>.*?</w:del>`)
+
+// acceptTrackedDeletions accepts every tracked deletion in the Word parts of a
+// redacted copy, removing the deleted runs as Word does when a reviewer accepts
+// the change. It returns how many revisions were removed.
+//
+// Rewriting a reported value inside w:delText is not enough on its own. Word
+// splits one deletion into a revision per run of formatting, so an SSN deleted
+// across a bold and a plain run sits in two w:delText elements that no single
+// replacement sees, and the revision keeps the value for anyone who rejects the
+// change. Deleted text is, by definition, text the author removed from the
+// document, so accepting the deletion changes nothing a reader of the final
+// document sees, and takes every value in it out of the copy whether or not a
+// validator named it.
+//
+// Parts are taken in name order so the logged count and the output do not depend
+// on map iteration.
+func (or *OfficeRedactor) acceptTrackedDeletions(contents *OfficeZipContents) int {
+	names := make([]string, 0, len(contents.Files))
+	for name := range contents.Files {
+		if or.isTextContainingFile(name, DocumentTypeDOCX) && strings.HasPrefix(strings.ToLower(name), "word/") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	removed := 0
+	for _, name := range names {
+		content := contents.Files[name]
+		n := len(trackedDeletionRe.FindAllIndex(content, -1))
+		if n == 0 {
+			continue
+		}
+		contents.addFile(name, trackedDeletionRe.ReplaceAll(content, nil))
+		removed += n
+	}
+	if removed > 0 {
+		or.logEvent("tracked_deletions_accepted", true, map[string]interface{}{
+			"revisions": removed,
+		})
+	}
+	return removed
+}
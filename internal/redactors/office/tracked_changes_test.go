@@ -0,0 +1,111 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package office
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/awslabs/ferret-scan/v2/internal/detector"
+	"github.com/awslabs/ferret-scan/v2/internal/redactors"
+)
+
+const wordNS = `xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"`
+
+// writeParts writes a package holding parts in the order given, as name/content
+// pairs, and returns its path.
+func writeParts(t *testing.T, dir, name string, pairs ...string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: pairs[i], Method: zip.Deflate})
+		if err != nil {
+			t.Fatalf("creating %s: %v", pairs[i], err)
+		}
+		if _, err := w.Write([]byte(pairs[i+1])); err != nil {
+			t.Fatalf("writing %s: %v", pairs[i], err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("closing zip: %v", err)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatalf("writing %s: %v", path, err)
+	}
+	return path
+}
+
+// A tracked deletion must not survive in the redacted copy.
+//
+// Word split this deletion into two revisions, one per run of formatting, so the
+// SSN the scanner reports from the joined deleted text sits in no single
+// w:delText and no replacement can locate it. Rejecting the change in the
+// "redacted" copy would bring the whole value back; accepting it removes it.
+func TestTrackedDeletionIsAcceptedInRedactedCopy(t *testing.T) {
+	dir := t.TempDir()
+	const ssn = "449-87-4100"
+	src := writeParts(t, dir, "memo.docx",
+		"word/document.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>`+
+			`<w:document `+wordNS+`><w:body>`+
+			`<w:p><w:pPr><w:rPr><w:del w:id="9" w:author="A" w:date="2026-01-01T00:00:00Z"/></w:rPr></w:pPr>`+
+			`<w:r><w:t xml:space="preserve">Kept text. </w:t></w:r>`+
+			`<w:del w:id="1" w:author="A"><w:r><w:rPr><w:b/></w:rPr><w:delText>SSN 449-87</w:delText></w:r></w:del>`+
+			`<w:del w:id="2" w:author="A"><w:r><w:delText>-4100</w:delText></w:r></w:del>`+
+			`<w:r><w:t xml:space="preserve"> Still kept.</w:t></w:r></w:p>`+
+			`</w:body></w:document>`)
+
+	matches := []detector.Match{{Text: ssn, Type: "SSN", Confidence: 100, LineNumber: 4,
+		Context: detector.ContextInfo{FullLine: "SSN " + ssn}}}
+	out := filepath.Join(dir, "out.docx")
+	if _, err := NewOfficeRedactor(nil, nil).RedactDocument(src, out, matches, redactors.RedactionSimple); err != nil {
+		t.Fatalf("RedactDocument: %v", err)
+	}
+
+	doc := string(inflateAll(t, out)["word/document.xml"])
+	for _, gone := range []string{"449-87", "4100", "<w:delText"} {
+		if strings.Contains(doc, gone) {
+			t.Errorf("redacted document still holds %q:\n%s", gone, doc)
+		}
+	}
+	for _, kept := range []string{"Kept text. ", " Still kept.", `<w:del w:id="9"`} {
+		if !strings.Contains(doc, kept) {
+			t.Errorf("redacted document lost %q, which was not deleted text:\n%s", kept, doc)
+		}
+	}
+}
+
+// A value reported from a spreadsheet comment is rewritten in the comment part,
+// legacy or threaded, rather than logged as unlocatable and left in place.
+func TestSpreadsheetCommentValueIsRedacted(t *testing.T) {
+	dir := t.TempDir()
+	const ssn = "536-22-1874"
+	src := writeParts(t, dir, "book.xlsx",
+		"xl/worksheets/sheet1.xml", `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`+
+			`<sheetData><row r="1"><c r="A1" t="inlineStr"><is><t>Name</t></is></c></row></sheetData></worksheet>`,
+		"xl/comments1.xml", `<comments xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`+
+			`<authors><author>A</author></authors><commentList>`+
+			`<comment ref="A1" authorId="0"><text><r><t>SSN `+ssn+`</t></r></text></comment>`+
+			`</commentList></comments>`,
+		"xl/threadedComments/threadedComment1.xml", `<ThreadedComments xmlns="http://schemas.microsoft.com/office/spreadsheetml/2018/threadedcomments">`+
+			`<threadedComment ref="A1" id="{1}"><text>SSN `+ssn+`</text></threadedComment></ThreadedComments>`)
+
+	matches := []detector.Match{{Text: ssn, Type: "SSN", Confidence: 100, LineNumber: 3,
+		Context: detector.ContextInfo{FullLine: "SSN " + ssn}}}
+	out := filepath.Join(dir, "out.xlsx")
+	if _, err := NewOfficeRedactor(nil, nil).RedactDocument(src, out, matches, redactors.RedactionSimple); err != nil {
+		t.Fatalf("RedactDocument: %v", err)
+	}
+
+	for name, content := range inflateAll(t, out) {
+		if strings.Contains(string(content), ssn) {
+			t.Errorf("%s still holds %s:\n%s", name, ssn, content)
+		}
+	}
+}
@@ -0,0 +1,384 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package ogg locates the comment packet of an Ogg Vorbis or Opus stream (.ogg, .oga, .opus)
+// and recomputes the page checksums a rewrite of it invalidates.
+//
+// # Why the packet, not the pages
+//
+// An Ogg file is a sequence of pages, and a packet is cut into segments that may continue
+// from one page to the next. The comment packet — the vendor string and the TITLE=, ARTIST=
+// and COMMENT= fields a voice-note app writes — is usually the whole of the second page, but a
+// long comment or a base64 cover image spreads it over several, and a page boundary can fall
+// in the middle of a value. A search over the file's bytes would then find neither half, and
+// a redactor relying on it would report the file clean. So the packet is reassembled, read and
+// rewritten as one buffer, and Pieces says where each part of it lies in the file.
+//
+// # Why the checksums
+//
+// Every page carries a CRC-32 of its own bytes, and a decoder drops a page whose checksum does
+// not match — for the comment header, that is a file that no longer plays. A same-length
+// overwrite moves nothing, so only the checksums of the pages the packet occupies change, and
+// PatchChecksums recomputes exactly those.
+//
+// Only the first Vorbis or Opus stream is read. A multiplexed file (an .ogv with a Theora
+// video stream beside its audio) is outside what the audio extractor reports, and so outside
+// what the redactor is asked to remove.
+//
+// This package deliberately depends on nothing but the standard library: it is a container
+// parser, and nothing about redaction belongs in it.
+package ogg
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// ErrNoStream reports a file whose first pages begin no Vorbis or Opus stream.
+var ErrNoStream = errors.New("ogg: no Vorbis or Opus stream found")
+
+// ErrTruncated reports that the file ended before the comment packet did. The Stream returned
+// with it holds the part that was present; a caller that writes must treat it as a refusal.
+var ErrTruncated = errors.New("ogg: the file ends inside the comment header")
+
+// ErrPacketBudget reports a comment packet larger than maxPacketBytes.
+var ErrPacketBudget = errors.New("ogg: comment header exceeds the size limit; refusing to read part of it")
+
+// ErrPageBudget reports that the walk stopped early because the headers spread over more pages
+// than any real file uses. The caller must treat it as a refusal: the walk did not finish.
+var ErrPageBudget = errors.New("ogg: page budget exhausted; refusing to treat a partial walk as complete")
+
+const (
+	// pageHeader is the fixed part of a page header, before its lacing table.
+	pageHeader = 27
+
+	// maxPacketBytes bounds the comment packet. A cover image stored as a base64 comment makes
+	// it a few hundred kilobytes; nothing real approaches this.
+	maxPacketBytes = 16 << 20
+
+	// maxPages bounds the pages walked before the comment packet ends. A real file finishes
+	// its headers in two or three, or in a few hundred with a large cover image.
+	maxPages = 1 << 16
+
+	// tailBytes is how much of the end of the file is searched for the last page, whose
+	// granule position gives the duration. A page is at most 65307 bytes.
+	tailBytes = 65307 * 2
+)
+
+// Codec names the stream's codec.
+type Codec string
+
+const (
+	Vorbis Codec = "Vorbis"
+	Opus   Codec = "Opus"
+)
+
+// Span is a half-open [Start, End) byte range of the file.
+type Span struct {
+	Start int64
+	End   int64
+}
+
+// Len returns the span's length in bytes.
+func (s Span) Len() int64 { return s.End - s.Start }
+
+// Page is one page the comment packet occupies, header included.
+type Page struct {
+	Offset int64 // of the "OggS" capture pattern
+	Length int64 // header, lacing table and body
+}
+
+// Stream is what the walk found.
+type Stream struct {
+	Codec  Codec
+	Serial uint32
+
+	// Identification is the first packet: channels and sample rate.
+	Identification []byte
+
+	// Comment is the whole comment packet, its magic ("\x03vorbis" or "OpusTags") included.
+	Comment []byte
+
+	// Pieces are where the bytes of Comment lie in the file, in order. Their lengths add up
+	// to len(Comment).
+	Pieces []Span
+
+	// Pages are the pages Pieces fall in, whose checksums a rewrite must recompute.
+	Pages []Page
+
+	// LastGranule is the granule position of the stream's last page, or -1 if none was found.
+	LastGranule int64
+}
+
+// CommentMagicLen returns how many bytes of Comment are the packet's magic rather than comment
+// data.
+func (s *Stream) CommentMagicLen() int {
+	if s.Codec == Opus {
+		return len(opusTags)
+	}
+	return len(vorbisComment)
+}
+
+var (
+	vorbisIdent   = []byte("\x01vorbis")
+	vorbisComment = []byte("\x03vorbis")
+	opusHead      = []byte("OpusHead")
+	opusTags      = []byte("OpusTags")
+)
+
+// HasHeader reports whether head begins with an Ogg page.
+func HasHeader(head []byte) bool {
+	return len(head) >= 4 && string(head[:4]) == "OggS"
+}
+
+// page is one parsed page header.
+type page struct {
+	offset     int64
+	headerType byte
+	granule    int64
+	serial     uint32
+	lacing     []byte
+	bodyStart  int64
+	bodyLen    int64
+}
+
+func (p *page) end() int64 { return p.bodyStart + p.bodyLen }
+
+// readPage parses the page at off. It never reads the body.
+func readPage(r io.ReaderAt, size, off int64) (*page, error) {
+	if off+pageHeader > size {
+		return nil, io.ErrUnexpectedEOF
+	}
+	var h [pageHeader]byte
+	if _, err := r.ReadAt(h[:], off); err != nil {
+		return nil, err
+	}
+	if string(h[:4]) != "OggS" || h[4] != 0 {
+		return nil, errors.New("ogg: no page at the expected offset")
+	}
+	nsegs := int64(h[26])
+	if off+pageHeader+nsegs > size {
+		return nil, io.ErrUnexpectedEOF
+	}
+	lacing := make([]byte, nsegs)
+	if _, err := r.ReadAt(lacing, off+pageHeader); err != nil {
+		return nil, err
+	}
+	var bodyLen int64
+	for _, l := range lacing {
+		bodyLen += int64(l)
+	}
+	return &page{
+		offset:     off,
+		headerType: h[5],
+		granule:    int64(binary.LittleEndian.Uint64(h[6:14])),
+		serial:     binary.LittleEndian.Uint32(h[14:18]),
+		lacing:     lacing,
+		bodyStart:  off + pageHeader + nsegs,
+		bodyLen:    bodyLen,
+	}, nil
+}
+
+// Read walks the pages of r up to the end of the first Vorbis or Opus stream's comment packet.
+func Read(r io.ReaderAt, size int64) (*Stream, error) {
+	s := &Stream{LastGranule: -1}
+
+	// packet is which of the stream's packets the next segment belongs to: 0 is the
+	// identification header, 1 the comments. found says the stream has been chosen.
+	packet, found := 0, false
+	var off int64
+	for pages := 0; ; pages++ {
+		if pages >= maxPages {
+			return s, ErrPageBudget
+		}
+		p, err := readPage(r, size, off)
+		if err != nil {
+			if !found {
+				return nil, ErrNoStream
+			}
+			return s, ErrTruncated
+		}
+		if p.end() > size {
+			if !found {
+				return nil, ErrNoStream
+			}
+			return s, ErrTruncated
+		}
+		off = p.end()
+
+		if !found {
+			if p.headerType&0x02 == 0 {
+				// The beginning-of-stream pages come first; past them, no stream begins.
+				return nil, ErrNoStream
+			}
+			head := make([]byte, min(p.bodyLen, int64(len(opusHead))))
+			if _, err := r.ReadAt(head, p.bodyStart); err != nil {
+				return nil, ErrNoStream
+			}
+			switch {
+			case hasPrefix(head, vorbisIdent):
+				s.Codec = Vorbis
+			case hasPrefix(head, opusHead):
+				s.Codec = Opus
+			default:
+				continue
+			}
+			s.Serial, found = p.serial, true
+		}
+		if p.serial != s.Serial {
+			continue
+		}
+
+		// Walk the lacing table, appending each segment to the packet it belongs to. A lacing
+		// value below 255 ends its packet.
+		pos := p.bodyStart
+		contributed := false
+		for _, l := range p.lacing {
+			seg := Span{Start: pos, End: pos + int64(l)}
+			pos = seg.End
+			switch packet {
+			case 0:
+				if int64(len(s.Identification))+seg.Len() > maxPacketBytes {
+					return s, ErrPacketBudget
+				}
+				s.Identification, err = appendSpan(r, s.Identification, seg)
+			case 1:
+				if int64(len(s.Comment))+seg.Len() > maxPacketBytes {
+					return s, ErrPacketBudget
+				}
+				s.Comment, err = appendSpan(r, s.Comment, seg)
+				s.addPiece(seg)
+				contributed = true
+			}
+			if err != nil {
+				return s, err
+			}
+			if l < 255 {
+				packet++
+			}
+			if packet == 2 {
+				break
+			}
+		}
+		if contributed {
+			s.Pages = append(s.Pages, Page{Offset: p.offset, Length: p.end() - p.offset})
+		}
+		if packet == 2 {
+			break
+		}
+	}
+
+	if len(s.Comment) < s.CommentMagicLen() ||
+		!hasPrefix(s.Comment, vorbisComment) && !hasPrefix(s.Comment, opusTags) {
+		return s, errors.New("ogg: the second header packet is not a comment header")
+	}
+	s.LastGranule = lastGranule(r, size, s.Serial)
+	return s, nil
+}
+
+// addPiece records seg, merging it with the previous piece when the two are adjacent — as every
+// segment of one page is.
+func (s *Stream) addPiece(seg Span) {
+	if seg.Len() == 0 {
+		return
+	}
+	if n := len(s.Pieces); n > 0 && s.Pieces[n-1].End == seg.Start {
+		s.Pieces[n-1].End = seg.End
+		return
+	}
+	s.Pieces = append(s.Pieces, seg)
+}
+
+func appendSpan(r io.ReaderAt, dst []byte, seg Span) ([]byte, error) {
+	if seg.Len() == 0 {
+		return dst, nil
+	}
+	n := len(dst)
+	dst = append(dst, make([]byte, seg.Len())...)
+	_, err := r.ReadAt(dst[n:], seg.Start)
+	return dst, err
+}
+
+func hasPrefix(b, prefix []byte) bool {
+	return len(b) >= len(prefix) && string(b[:len(prefix)]) == string(prefix)
+}
+
+// lastGranule finds the stream's last page in the tail of the file. A page found by searching
+// for its capture pattern is accepted only if its checksum holds, because "OggS" can occur in
+// packet data by chance.
+func lastGranule(r io.ReaderAt, size int64, serial uint32) int64 {
+	start := max(size-tailBytes, 0)
+	tail := make([]byte, size-start)
+	if _, err := r.ReadAt(tail, start); err != nil && err != io.EOF {
+		return -1
+	}
+	for i := len(tail) - pageHeader; i >= 0; i-- {
+		if string(tail[i:i+4]) != "OggS" {
+			continue
+		}
+		p, err := readPage(r, size, start+int64(i))
+		if err != nil || p.serial != serial || p.end() > size {
+			continue
+		}
+		buf := tail[i : p.end()-start]
+		if binary.LittleEndian.Uint32(buf[22:26]) != Checksum(buf) {
+			continue
+		}
+		return p.granule
+	}
+	return -1
+}
+
+// crcTable is the Ogg CRC-32: polynomial 0x04c11db7, most significant bit first, no reflection
+// and no final inversion — not the reflected CRC-32 hash/crc32 computes.
+var crcTable = func() (t [256]uint32) {
+	for i := range t {
+		c := uint32(i) << 24
+		for range 8 {
+			if c&0x80000000 != 0 {
+				c = c<<1 ^ 0x04c11db7
+			} else {
+				c <<= 1
+			}
+		}
+		t[i] = c
+	}
+	return t
+}()
+
+// Checksum returns the CRC of one whole page, computed as the format specifies: with the page's
+// own checksum field taken as zero.
+func Checksum(page []byte) uint32 {
+	var crc uint32
+	for i, b := range page {
+		if i >= 22 && i < 26 {
+			b = 0
+		}
+		crc = crc<<8 ^ crcTable[byte(crc>>24)^b]
+	}
+	return crc
+}
+
+// WriteComment copies packet, which must be as long as s.Comment, back over s.Pieces in buf
+// and recomputes the checksum of every page it occupies. buf is the whole file.
+func (s *Stream) WriteComment(buf []byte, packet []byte) error {
+	if len(packet) != len(s.Comment) {
+		return errors.New("ogg: a rewritten comment header must keep its length")
+	}
+	var n int64
+	for _, p := range s.Pieces {
+		if p.Start < 0 || p.End > int64(len(buf)) {
+			return errors.New("ogg: comment header piece outside the file")
+		}
+		n += int64(copy(buf[p.Start:p.End], packet[n:n+p.Len()]))
+	}
+	for _, pg := range s.Pages {
+		if pg.Offset < 0 || pg.Offset+pg.Length > int64(len(buf)) {
+			return errors.New("ogg: comment header page outside the file")
+		}
+		page := buf[pg.Offset : pg.Offset+pg.Length]
+		binary.LittleEndian.PutUint32(page[22:26], Checksum(page))
+	}
+	return nil
+}
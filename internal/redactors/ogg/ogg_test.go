@@ -0,0 +1,183 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package ogg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// oggPage builds one page holding body as a single packet piece, with a valid checksum. open
+// says the piece continues on the next page, so it must be a multiple of 255 bytes.
+func oggPage(headerType byte, serial, seq uint32, granule int64, body []byte, open bool) []byte {
+	var lacing []byte
+	n := len(body)
+	for n >= 255 {
+		lacing = append(lacing, 255)
+		n -= 255
+	}
+	if !open {
+		lacing = append(lacing, byte(n))
+	} else if n != 0 {
+		panic("an open page must end on a 255 segment")
+	}
+	h := make([]byte, pageHeader)
+	copy(h, "OggS")
+	h[5] = headerType
+	binary.LittleEndian.PutUint64(h[6:], uint64(granule))
+	binary.LittleEndian.PutUint32(h[14:], serial)
+	binary.LittleEndian.PutUint32(h[18:], seq)
+	h[26] = byte(len(lacing))
+	page := append(append(h, lacing...), body...)
+	binary.LittleEndian.PutUint32(page[22:], Checksum(page))
+	return page
+}
+
+func vorbisCommentPacket(fields ...string) []byte {
+	out := append([]byte(nil), vorbisComment...)
+	out = binary.LittleEndian.AppendUint32(out, 6)
+	out = append(out, "Lavf61"...)
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(fields)))
+	for _, f := range fields {
+		out = binary.LittleEndian.AppendUint32(out, uint32(len(f)))
+		out = append(out, f...)
+	}
+	return append(out, 1)
+}
+
+func vorbisIdentPacket() []byte {
+	out := append([]byte(nil), vorbisIdent...)
+	out = append(out, 0, 0, 0, 0, 1)
+	out = binary.LittleEndian.AppendUint32(out, 48000)
+	return append(out, make([]byte, 30-len(out))...)
+}
+
+func validChecksums(t *testing.T, file []byte) {
+	t.Helper()
+	for off := 0; off < len(file); {
+		p, err := readPage(bytes.NewReader(file), int64(len(file)), int64(off))
+		if err != nil {
+			t.Fatalf("page at %d: %v", off, err)
+		}
+		page := file[off:p.end()]
+		if binary.LittleEndian.Uint32(page[22:26]) != Checksum(page) {
+			t.Errorf("page at %d fails its checksum", off)
+		}
+		off = int(p.end())
+	}
+}
+
+func TestCommentPacketSpanningPagesIsReassembled(t *testing.T) {
+	// Pad the packet so it ends exactly on a 255 boundary inside the first page, with the
+	// value itself cut across the page boundary.
+	packet := vorbisCommentPacket("TITLE=voice note", "COMMENT=call 555-0100 now")
+	cut := bytes.Index(packet, []byte("555-0100")) + 4
+	pad := (255 - cut%255) % 255
+	packet = vorbisCommentPacket("TITLE=voice note"+string(bytes.Repeat([]byte{'.'}, pad)), "COMMENT=call 555-0100 now")
+	cut = bytes.Index(packet, []byte("555-0100")) + 4
+	if cut%255 != 0 {
+		t.Fatalf("fixture: cut at %d", cut)
+	}
+
+	file := oggPage(0x02, 7, 0, 0, vorbisIdentPacket(), false)
+	file = append(file, oggPage(0, 7, 1, 0, packet[:cut], true)...)
+	file = append(file, oggPage(0x01, 7, 2, 0, packet[cut:], false)...)
+	file = append(file, oggPage(0x04, 7, 3, 96000, bytes.Repeat([]byte{0xAA}, 40), false)...)
+
+	s, err := Read(bytes.NewReader(file), int64(len(file)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Codec != Vorbis || !bytes.Equal(s.Comment, packet) {
+		t.Fatalf("codec %q, comment %q", s.Codec, s.Comment)
+	}
+	if len(s.Pieces) != 2 || len(s.Pages) != 2 {
+		t.Fatalf("pieces %+v, pages %+v", s.Pieces, s.Pages)
+	}
+	if s.LastGranule != 96000 {
+		t.Errorf("LastGranule = %d", s.LastGranule)
+	}
+	if bytes.Contains(file, []byte("555-0100")) {
+		t.Fatal("fixture: the value must not be contiguous in the file")
+	}
+
+	rewritten := bytes.ReplaceAll(s.Comment, []byte("555-0100"), []byte("XXX-XXXX"))
+	out := append([]byte(nil), file...)
+	if err := s.WriteComment(out, rewritten); err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != len(file) {
+		t.Fatal("the file changed size")
+	}
+	validChecksums(t, out)
+	again, err := Read(bytes.NewReader(out), int64(len(out)))
+	if err != nil || !bytes.Equal(again.Comment, rewritten) {
+		t.Fatalf("the rewritten packet reads back as %q (%v)", again.Comment, err)
+	}
+	if !bytes.Equal(out[len(out)-40:], file[len(file)-40:]) {
+		t.Error("an audio page was modified")
+	}
+}
+
+func TestOpusStreamIsFoundBesideAnotherStream(t *testing.T) {
+	tags := append([]byte(nil), opusTags...)
+	tags = binary.LittleEndian.AppendUint32(tags, 0)
+	tags = binary.LittleEndian.AppendUint32(tags, 1)
+	tags = binary.LittleEndian.AppendUint32(tags, 11)
+	tags = append(tags, "ARTIST=Jane"...)
+
+	head := append(append([]byte(nil), opusHead...), 1, 2, 0x38, 0x01, 0x80, 0xBB, 0, 0, 0, 0, 0)
+	file := oggPage(0x02, 1, 0, 0, []byte("\x80theora-header-data"), false)
+	file = append(file, oggPage(0x02, 2, 0, 0, head, false)...)
+	file = append(file, oggPage(0, 2, 1, 0, tags, false)...)
+
+	s, err := Read(bytes.NewReader(file), int64(len(file)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Codec != Opus || s.Serial != 2 || !bytes.HasSuffix(s.Comment, []byte("ARTIST=Jane")) {
+		t.Fatalf("stream = %+v", s)
+	}
+	if s.CommentMagicLen() != 8 {
+		t.Errorf("CommentMagicLen = %d", s.CommentMagicLen())
+	}
+}
+
+func TestTruncatedCommentIsReported(t *testing.T) {
+	packet := vorbisCommentPacket("COMMENT=" + string(bytes.Repeat([]byte{'x'}, 600)))
+	file := oggPage(0x02, 7, 0, 0, vorbisIdentPacket(), false)
+	file = append(file, oggPage(0, 7, 1, 0, packet[:510], true)...)
+
+	s, err := Read(bytes.NewReader(file), int64(len(file)))
+	if err != ErrTruncated {
+		t.Fatalf("err = %v, want ErrTruncated", err)
+	}
+	if s == nil || len(s.Comment) != 510 {
+		t.Error("the part of the comment that was present was not returned")
+	}
+}
+
+func TestNoAudioStream(t *testing.T) {
+	file := oggPage(0x02, 1, 0, 0, []byte("\x7fFLAC\x01\x00"), false)
+	if _, err := Read(bytes.NewReader(file), int64(len(file))); err != ErrNoStream {
+		t.Errorf("err = %v, want ErrNoStream", err)
+	}
+	if HasHeader([]byte("RIFF")) || !HasHeader(file) {
+		t.Error("HasHeader")
+	}
+}
+
+func TestChecksumMatchesTheReferenceValue(t *testing.T) {
+	// CRC-32/POSIX without its final inversion, which is what libogg computes: the POSIX
+	// check value 0x765E7680 with every bit flipped.
+	data := []byte("123456789")
+	var crc uint32
+	for _, b := range data {
+		crc = crc<<8 ^ crcTable[byte(crc>>24)^b]
+	}
+	if crc != 0x89A1897F {
+		t.Errorf("crc = %#x", crc)
+	}
+}
@@ -79,7 +79,7 @@ func (ptr *PlainTextRedactor) GetName() string {
 
 // GetSupportedTypes returns the file types this redactor can handle
 func (ptr *PlainTextRedactor) GetSupportedTypes() []string {
-	return []string{"text", ".txt", ".log", ".csv", ".json", ".xml", ".yaml", ".yml", ".md", ".conf", ".ini"}
+	return []string{"text", ".txt", ".log", ".csv", ".json", ".yaml", ".yml", ".md", ".conf", ".ini"}
 }
 
 // GetSupportedStrategies returns the redaction strategies this redactor supports
@@ -0,0 +1,135 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package redactors
+
+import (
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/awslabs/ferret-scan/v2/internal/detector"
+)
+
+// MinResidueValueLen is the shortest reported value a residue check searches for.
+// A shorter value produces meaningless hits and is not something redaction targets.
+const MinResidueValueLen = 4
+
+// ResidueIdentifyPasses counts how many times ResidualValues has entered its
+// per-value identification loop.
+//
+// It exists for one guard: the identification loop is O(values x text) and must stay
+// off the successful path. Measured when it was NOT: +0.00s, +0.01s, +0.02s then
+// +0.09s as the reported-value count doubled through 500/1000/2000/4000, i.e. about
+// 4.5x per doubling.
+//
+// A counter rather than a timing assertion, because the quadratic here is pure
+// strings.Contains work that allocates nothing — so an allocation-based guard is blind
+// to it (verified: restoring the quadratic passed an allocation ratio test) — and
+// wall-clock thresholds are not portable to the Windows runner.
+var ResidueIdentifyPasses atomic.Uint64
+
+// ResidualValues returns the reported values still present in texts, once each and in
+// the order they were reported. Values shorter than MinResidueValueLen are ignored.
+//
+// texts must be the DECODED text of the output — what a reader or a rescan sees, not
+// its stored bytes. A value held as "Fairbanks &amp; Kettleworth" is still
+// "Fairbanks & Kettleworth", and a byte search would certify exactly that leak.
+//
+// # Cost
+//
+// Two stages, and the split is deliberate. A naive "one strings.Contains per value per
+// text" is O(values x text) and measured quadratic. So the common case pays only a
+// single trie pass: strings.Replacer builds one trie over all the values and scans
+// each text once, independent of how many values there are; if nothing matched, there
+// is no residue and we are done. The per-value identification loop runs ONLY when a
+// hit has already been found, i.e. only on the path that is about to refuse to write
+// the document, where an extra pass is free relative to failing the run.
+func ResidualValues(texts []string, matches []detector.Match) []detector.Match {
+	if len(texts) == 0 || len(matches) == 0 {
+		return nil
+	}
+
+	wanted := make([]string, 0, len(matches))
+	byText := make(map[string]detector.Match, len(matches))
+	for _, m := range matches {
+		if len(m.Text) < MinResidueValueLen {
+			continue
+		}
+		if _, dup := byText[m.Text]; dup {
+			continue
+		}
+		byText[m.Text] = m
+		wanted = append(wanted, m.Text)
+	}
+	if len(wanted) == 0 {
+		return nil
+	}
+
+	// Stage 1: one trie pass per text over ALL values at once. Replacing every value
+	// with nothing and comparing is a presence oracle whose cost does not grow with the
+	// number of values.
+	probeArgs := make([]string, 0, len(wanted)*2)
+	for _, v := range wanted {
+		probeArgs = append(probeArgs, v, "")
+	}
+	probe := strings.NewReplacer(probeArgs...)
+
+	anyHit := false
+	for _, text := range texts {
+		if probe.Replace(text) != text {
+			anyHit = true
+			break
+		}
+	}
+	if !anyHit {
+		return nil
+	}
+
+	// Stage 2: name the offenders. Only reached when the document is already going to
+	// be refused, so the per-value scan is not on any successful path.
+	ResidueIdentifyPasses.Add(1)
+	found := make(map[string]struct{}, len(wanted))
+	for _, text := range texts {
+		for _, v := range wanted {
+			if _, already := found[v]; already {
+				continue
+			}
+			if strings.Contains(text, v) {
+				found[v] = struct{}{}
+			}
+		}
+	}
+
+	out := make([]detector.Match, 0, len(found))
+	for _, v := range wanted {
+		if _, ok := found[v]; ok {
+			out = append(out, byText[v])
+		}
+	}
+	return out
+}
+
+// ResidueTypes lists the distinct finding types of a residue set, sorted, for a message
+// that must not carry the values themselves.
+//
+// A match with no Type still has to be counted as something an operator can see, so it
+// is named "unknown" rather than dropped — a residue that reports fewer types than
+// values would understate what is still in the file.
+func ResidueTypes(residue []detector.Match) []string {
+	seen := make(map[string]struct{}, len(residue))
+	out := make([]string, 0, len(residue))
+	for _, m := range residue {
+		t := m.Type
+		if t == "" {
+			t = "unknown"
+		}
+		if _, dup := seen[t]; dup {
+			continue
+		}
+		seen[t] = struct{}{}
+		out = append(out, t)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,276 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package riff locates the descriptive metadata of a RIFF AVI file (.avi) without reading the
+// media payload.
+//
+// # Why a walk over an io.ReaderAt
+//
+// For the reason isobmff and ebml give: the frames are nearly all of the file, and the values
+// worth reporting — the INFO list's title, comment, artist and software, a stream's name, a
+// camera's recording date — are a few hundred bytes in front of them. Chunk headers are read at
+// 8 bytes apiece, the movi list is skipped by arithmetic, and only the string chunks named below
+// are read.
+//
+// # Which chunks
+//
+// The INFO list, the strn stream names and the IDIT date a camera writes in hdrl. avih, strh and
+// strf are read for the extractor's technical report and never returned as spans: they are the
+// stream headers a player decodes by, and idx1 and the OpenDML indexes are offsets into movi. A
+// parsing mistake here can therefore damage only a string a player displays.
+//
+// An OpenDML file larger than 1 GB continues in further top-level RIFF "AVIX" chunks, which hold
+// only movi data. They are stepped over like any other chunk.
+//
+// This package deliberately depends on nothing but the standard library: it is a container
+// parser, and nothing about redaction belongs in it.
+package riff
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+// ErrChunkBudget reports that the walk stopped early because a file declared more chunks than
+// any real one contains. The caller must treat it as a refusal: the walk did not finish.
+var ErrChunkBudget = errors.New("riff: chunk budget exhausted; refusing to treat a partial walk as complete")
+
+// ErrFieldBudget reports that the string chunks of a file add up to more than maxFieldBytes.
+var ErrFieldBudget = errors.New("riff: metadata strings exceed the size limit; refusing to read part of them")
+
+// maxChunks is the walk's work budget, matching the isobmff and ebml walks. movi is never
+// descended into, so a real file has a few dozen chunks outside it.
+const maxChunks = 1 << 20
+
+// maxFieldBytes bounds the total string payload read.
+const maxFieldBytes = 16 << 20
+
+// maxDepth bounds LIST nesting. A real AVI nests three deep (RIFF > hdrl > strl).
+const maxDepth = 8
+
+// chunkHeader is a chunk's 4-byte ID and 4-byte little-endian size.
+const chunkHeader = 8
+
+// Span is a half-open [Start, End) byte range of the file.
+type Span struct {
+	Start int64
+	End   int64
+	Label string // which list it came from, for the audit trail
+}
+
+// Len returns the span's length in bytes.
+func (s Span) Len() int64 { return s.End - s.Start }
+
+// Field is one descriptive string chunk.
+type Field struct {
+	ID    string // the chunk's four-character code: "INAM", "ICMT", "strn", "IDIT"
+	Value string // the chunk's text, trailing NULs removed
+	Span         // the chunk's whole payload
+}
+
+// Metadata is what the walk found.
+type Metadata struct {
+	Fields []Field
+
+	// From avih and the first video strh, for the extractor's report.
+	Duration time.Duration
+	Width    int
+	Height   int
+	Codec    string
+
+	// MissingPad records that an odd-length chunk was not followed by the pad byte RIFF
+	// requires, so the walk had to realign. The file is malformed and may be truncated.
+	MissingPad bool
+}
+
+// IsAVI reports whether head begins a RIFF AVI file.
+func IsAVI(head []byte) bool {
+	return len(head) >= 12 && string(head[0:4]) == "RIFF" && string(head[8:12]) == "AVI "
+}
+
+// ReadAVI walks a RIFF AVI file and returns its descriptive metadata.
+//
+// A malformed or truncated file yields what was found before the damage and no error, as the
+// other video walks do. ErrChunkBudget and ErrFieldBudget are returned.
+func ReadAVI(r io.ReaderAt, size int64) (*Metadata, error) {
+	w := &walker{r: r, budget: maxChunks, fieldBudget: maxFieldBytes, md: &Metadata{}}
+	err := w.chunks(0, size, func(c chunk) error {
+		if c.id != "RIFF" || c.form != "AVI " {
+			return nil
+		}
+		return w.list(c, 0)
+	})
+	return w.md, err
+}
+
+// chunk is one chunk header; form is the list type of a RIFF or LIST chunk.
+type chunk struct {
+	id           string
+	form         string
+	payloadStart int64 // after the list type, for a RIFF or LIST chunk
+	payloadEnd   int64
+}
+
+// walker carries the state of one ReadAVI.
+type walker struct {
+	r           io.ReaderAt
+	budget      int
+	fieldBudget int64
+	md          *Metadata
+}
+
+// list reads the children of a RIFF or LIST chunk that can hold metadata.
+func (w *walker) list(l chunk, depth int) error {
+	if depth >= maxDepth {
+		return nil
+	}
+	return w.chunks(l.payloadStart, l.payloadEnd, func(c chunk) error {
+		switch {
+		case c.id == "LIST" && (c.form == "hdrl" || c.form == "strl"):
+			return w.list(c, depth+1)
+		case c.id == "LIST" && c.form == "INFO":
+			return w.chunks(c.payloadStart, c.payloadEnd, func(f chunk) error {
+				return w.field(f, "AVI INFO")
+			})
+		case c.id == "strn":
+			return w.field(c, "AVI stream name")
+		case c.id == "IDIT":
+			return w.field(c, "AVI hdrl")
+		case c.id == "avih":
+			w.mainHeader(c)
+		case c.id == "strh":
+			w.streamHeader(c)
+		}
+		// Everything else — movi above all, idx1, JUNK, strf — is stepped over unread.
+		return nil
+	})
+}
+
+// field reads a string chunk and records it.
+func (w *walker) field(c chunk, label string) error {
+	n := c.payloadEnd - c.payloadStart
+	if n <= 0 {
+		return nil
+	}
+	if n > w.fieldBudget {
+		return ErrFieldBudget
+	}
+	w.fieldBudget -= n
+	buf := make([]byte, n)
+	if _, err := w.r.ReadAt(buf, c.payloadStart); err != nil && err != io.EOF {
+		return nil
+	}
+	w.md.Fields = append(w.md.Fields, Field{
+		ID:    c.id,
+		Value: strings.TrimRight(string(buf), "\x00"),
+		Span:  Span{c.payloadStart, c.payloadEnd, label},
+	})
+	return nil
+}
+
+// mainHeader reads avih: microseconds per frame at 0, total frames at 16, width at 32 and height
+// at 36.
+func (w *walker) mainHeader(c chunk) {
+	var buf [40]byte
+	if c.payloadEnd-c.payloadStart < int64(len(buf)) {
+		return
+	}
+	if _, err := w.r.ReadAt(buf[:], c.payloadStart); err != nil {
+		return
+	}
+	usPerFrame := binary.LittleEndian.Uint32(buf[0:])
+	frames := binary.LittleEndian.Uint32(buf[16:])
+	if us := uint64(usPerFrame) * uint64(frames); us < math.MaxInt64/uint64(time.Microsecond) {
+		w.md.Duration = time.Duration(us) * time.Microsecond
+	}
+	w.md.Width = clampInt(binary.LittleEndian.Uint32(buf[32:]))
+	w.md.Height = clampInt(binary.LittleEndian.Uint32(buf[36:]))
+}
+
+// streamHeader reads strh: the stream type at 0 and its handler, the codec, at 4.
+func (w *walker) streamHeader(c chunk) {
+	var buf [8]byte
+	if w.md.Codec != "" || c.payloadEnd-c.payloadStart < int64(len(buf)) {
+		return
+	}
+	if _, err := w.r.ReadAt(buf[:], c.payloadStart); err != nil {
+		return
+	}
+	if string(buf[0:4]) == "vids" && isPrintable(buf[4:8]) {
+		w.md.Codec = strings.TrimSpace(string(buf[4:8]))
+	}
+}
+
+// chunks walks the chunks in [start, end) and calls fn for each one.
+//
+// Declared sizes are clamped to the range, written as `size > end-payloadStart` so the comparison
+// cannot overflow. The pad byte after an odd-length chunk is DETECTED rather than assumed, as the
+// WAV extractor does: a writer that omits it would otherwise put every later header one byte off.
+func (w *walker) chunks(start, end int64, fn func(chunk) error) error {
+	off := start
+	for end-off >= chunkHeader {
+		w.budget--
+		if w.budget < 0 {
+			return ErrChunkBudget
+		}
+		var hdr [chunkHeader + 4]byte
+		n := int64(len(hdr))
+		if end-off < n {
+			n = end - off
+		}
+		if _, err := w.r.ReadAt(hdr[:n], off); err != nil && err != io.EOF {
+			return nil
+		}
+		if !isPrintable(hdr[0:4]) {
+			// No longer on a chunk boundary; what follows cannot be trusted.
+			return nil
+		}
+		c := chunk{id: string(hdr[0:4]), payloadStart: off + chunkHeader}
+		size := int64(binary.LittleEndian.Uint32(hdr[4:8]))
+		if size > end-c.payloadStart {
+			size = end - c.payloadStart
+		}
+		c.payloadEnd = c.payloadStart + size
+		next := c.payloadEnd
+		if (c.id == "RIFF" || c.id == "LIST") && size >= 4 && n == int64(len(hdr)) {
+			c.form = string(hdr[8:12])
+			c.payloadStart += 4
+		}
+		if err := fn(c); err != nil {
+			return err
+		}
+		if size%2 == 1 && next < end {
+			var pad [1]byte
+			if _, err := w.r.ReadAt(pad[:], next); err == nil {
+				if pad[0] == 0 {
+					next++
+				} else {
+					w.md.MissingPad = true
+				}
+			}
+		}
+		off = next
+	}
+	return nil
+}
+
+// isPrintable reports whether b is printable ASCII, which every chunk ID and FourCC is.
+func isPrintable(b []byte) bool {
+	for _, c := range b {
+		if c < 0x20 || c > 0x7E {
+			return false
+		}
+	}
+	return true
+}
+
+func clampInt(v uint32) int {
+	if v > math.MaxInt32 {
+		return 0
+	}
+	return int(v)
+}
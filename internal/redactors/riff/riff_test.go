@@ -0,0 +1,139 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package riff
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+// ck builds one chunk, padded to an even length as RIFF requires.
+func ck(id string, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	out := append([]byte(id), binary.LittleEndian.AppendUint32(nil, uint32(len(body)))...)
+	out = append(out, body...)
+	if len(body)%2 == 1 {
+		out = append(out, 0)
+	}
+	return out
+}
+
+func list(form string, children ...[]byte) []byte {
+	return ck("LIST", append([][]byte{[]byte(form)}, children...)...)
+}
+
+func avih(usPerFrame, frames, width, height uint32) []byte {
+	b := make([]byte, 56)
+	binary.LittleEndian.PutUint32(b[0:], usPerFrame)
+	binary.LittleEndian.PutUint32(b[16:], frames)
+	binary.LittleEndian.PutUint32(b[32:], width)
+	binary.LittleEndian.PutUint32(b[36:], height)
+	return ck("avih", b)
+}
+
+func strh(kind, handler string) []byte {
+	b := make([]byte, 56)
+	copy(b, kind)
+	copy(b[4:], handler)
+	return ck("strh", b)
+}
+
+var frame = bytes.Repeat([]byte("FRAME"), 40)
+
+func avi(children ...[]byte) []byte {
+	return ck("RIFF", append([][]byte{[]byte("AVI ")}, children...)...)
+}
+
+func TestReadAVIFindsInfoStreamNamesAndDate(t *testing.T) {
+	file := avi(
+		list("hdrl",
+			avih(40000, 250, 640, 480),
+			list("strl", strh("vids", "H264"), ck("strf", make([]byte, 40)), ck("strn", []byte("Front door cam\x00"))),
+			ck("IDIT", []byte("THU OCT 26 16:46:04 2006\n\x00"))),
+		list("INFO",
+			ck("INAM", []byte("Visit\x00")),
+			ck("ICMT", []byte("SSN 123-45-6789")), // odd length: padded
+			ck("ISFT", []byte("Lavf58.76.100\x00"))),
+		ck("JUNK", make([]byte, 12)),
+		list("movi", ck("00dc", frame), ck("00dc", frame)),
+		ck("idx1", make([]byte, 32)),
+	)
+
+	md, err := ReadAVI(bytes.NewReader(file), int64(len(file)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"INAM": "Visit",
+		"ICMT": "SSN 123-45-6789",
+		"ISFT": "Lavf58.76.100",
+		"strn": "Front door cam",
+		"IDIT": "THU OCT 26 16:46:04 2006\n",
+	}
+	if len(md.Fields) != len(want) {
+		t.Fatalf("fields = %+v", md.Fields)
+	}
+	for _, f := range md.Fields {
+		if want[f.ID] != f.Value {
+			t.Errorf("%s = %q, want %q", f.ID, f.Value, want[f.ID])
+		}
+		if !bytes.HasPrefix(file[f.Start:f.End], []byte(f.Value)) {
+			t.Errorf("%s span holds %q", f.ID, file[f.Start:f.End])
+		}
+		if bytes.Contains(file[f.Start:f.End], []byte("FRAME")) {
+			t.Errorf("%s span reaches into movi", f.ID)
+		}
+	}
+	if md.Duration != 10*time.Second || md.Width != 640 || md.Height != 480 || md.Codec != "H264" {
+		t.Errorf("technical = %v %dx%d %q", md.Duration, md.Width, md.Height, md.Codec)
+	}
+	if md.MissingPad {
+		t.Error("a compliant file was reported as missing a pad byte")
+	}
+}
+
+func TestMissingPadByteIsRealignedAndReported(t *testing.T) {
+	icmt := []byte("ICMT\x03\x00\x00\x00abc") // odd length, no pad
+	info := append([]byte("INFO"), icmt...)
+	info = append(info, ck("INAM", []byte("after"))...)
+	file := avi(ck("LIST", info))
+
+	md, err := ReadAVI(bytes.NewReader(file), int64(len(file)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !md.MissingPad {
+		t.Error("the missing pad byte was not reported")
+	}
+	if len(md.Fields) != 2 || md.Fields[1].ID != "INAM" || md.Fields[1].Value != "after" {
+		t.Fatalf("fields = %+v; the walk lost alignment", md.Fields)
+	}
+}
+
+func TestOverrunningSizeIsClamped(t *testing.T) {
+	file := avi(list("INFO", ck("INAM", []byte("title"))))
+	// Declare the INFO list larger than the file.
+	binary.LittleEndian.PutUint32(file[16:], 1<<31)
+
+	md, err := ReadAVI(bytes.NewReader(file), int64(len(file)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range md.Fields {
+		if f.End > int64(len(file)) {
+			t.Fatalf("span %d-%d runs past the file", f.Start, f.End)
+		}
+	}
+}
+
+func TestIsAVI(t *testing.T) {
+	if !IsAVI(avi()) {
+		t.Error("an AVI header was not recognised")
+	}
+	if IsAVI([]byte("RIFF\x00\x00\x00\x00WAVE")) {
+		t.Error("a WAV file was recognised as AVI")
+	}
+}
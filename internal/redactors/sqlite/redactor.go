@@ -0,0 +1,555 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package sqlite redacts SQLite databases.
+//
+// # In place, at the same length
+//
+// A database is a b-tree of pages whose cells carry record headers that give
+// every value's type and byte length. Rebuilding it means rebalancing pages
+// and rewriting every header a changed length touches, which is a database
+// engine's job, and getting it wrong yields a file sqlite3 calls malformed. So
+// this redactor takes the approach the legacy Office redactor takes for OLE
+// containers: each value is overwritten with a replacement of THE SAME BYTE
+// LENGTH, read and written through the exact bytes the reader took it from
+// (overflow pages included). Record headers, page layout and the schema are
+// untouched, and the copy opens as the same database with the affected cells
+// changed.
+//
+// A text value keeps its length in the database's text encoding, UTF-8 or
+// UTF-16. A number whose decimal form holds a reported value is set to zero,
+// at its stored width. The one value that cannot change is a rowid: it is the
+// b-tree key. A finding in an INTEGER PRIMARY KEY column is therefore left,
+// and the residue check below refuses the file.
+//
+// # Every copy
+//
+// A value is not only in its row. An index holds its own copy of every indexed
+// value, and a deleted or updated row leaves its old bytes in free space until
+// the page is reused. Free space is searched as raw bytes, and any copy of a
+// reported value left there is masked. An index entry cannot be overwritten
+// the same way: the index is sorted by the values it copies, a changed entry
+// would be out of order, and sqlite3 would report the copy as corrupt. Nor can
+// the primary key of a WITHOUT ROWID table, stored the same way. A database
+// whose reported values are in an index or such a key is refused, naming the
+// index, so it can be dropped before redaction and recreated after.
+//
+// The output is then read back, every stored value and the raw bytes, and a
+// copy that still holds a reported value is refused rather than written, on
+// the same policy as the Office and markup redactors. A write-ahead log beside
+// the database is not part of the copy.
+//
+// The database is redacted in a copy beside the output, read and written a
+// page at a time, and the copy is renamed into place once it is clean.
+package sqlite
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/awslabs/ferret-scan/v2/internal/detector"
+	"github.com/awslabs/ferret-scan/v2/internal/observability"
+	textextractsqlitelib "github.com/awslabs/ferret-scan/v2/internal/preprocessors/text-extractors/text-extract-sqlitelib"
+	"github.com/awslabs/ferret-scan/v2/internal/redactors"
+	"github.com/awslabs/ferret-scan/v2/internal/redactors/replacement"
+)
+
+// maskByte is what a value becomes when no length-preserving replacement fits,
+// as in the legacy Office redactor.
+const maskByte = '*'
+
+// SQLiteRedactor redacts SQLite databases by same-length in-place overwrite.
+type SQLiteRedactor struct {
+	observer      observability.Observer
+	outputManager *redactors.OutputStructureManager
+}
+
+// NewSQLiteRedactor creates a redactor for SQLite databases.
+func NewSQLiteRedactor(outputManager *redactors.OutputStructureManager, observer observability.Observer) *SQLiteRedactor {
+	if observer == nil {
+		observer = observability.NewStandardObserver(observability.ObservabilityMetrics, nil)
+	}
+	return &SQLiteRedactor{observer: observer, outputManager: outputManager}
+}
+
+// GetName returns the name of the redactor.
+func (r *SQLiteRedactor) GetName() string { return "sqlite_redactor" }
+
+// GetComponentName returns the component name for observability.
+func (r *SQLiteRedactor) GetComponentName() string { return "sqlite_redactor" }
+
+// GetSupportedTypes returns the database types this redactor handles.
+func (r *SQLiteRedactor) GetSupportedTypes() []string {
+	return []string{".sqlite", ".sqlite3", ".db", ".db3"}
+}
+
+// GetSupportedStrategies reports which strategies this redactor can honour.
+// Synthetic is declined for the reason the legacy Office redactor declines it:
+// a generated value's length is unrelated to the original's.
+func (r *SQLiteRedactor) GetSupportedStrategies() []redactors.RedactionStrategy {
+	return []redactors.RedactionStrategy{
+		redactors.RedactionSimple,
+		redactors.RedactionFormatPreserving,
+	}
+}
+
+// RedactDocument writes a redacted copy of an SQLite database to outputPath.
+// The original is copied beside outputPath and redacted there a page at a
+// time, so the database is never held in memory whole; the copy replaces
+// outputPath only once it has been read back clean.
+func (r *SQLiteRedactor) RedactDocument(originalPath string, outputPath string, matches []detector.Match, strategy redactors.RedactionStrategy) (*redactors.RedactionResult, error) {
+	finishTiming := r.observer.StartTiming(r.GetComponentName(), "redact_document", originalPath)
+	defer finishTiming(true, map[string]interface{}{
+		"output_path": outputPath,
+		"match_count": len(matches),
+		"strategy":    strategy.String(),
+	})
+
+	start := time.Now()
+
+	src, err := os.Open(filepath.Clean(originalPath)) // #nosec G304 -- path vetted by the router
+	if err != nil {
+		return nil, fmt.Errorf("failed to read original file: %w", err)
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read original file: %w", err)
+	}
+	size := info.Size()
+	if _, err := textextractsqlitelib.OpenReader(src, size); err != nil {
+		if errors.Is(err, textextractsqlitelib.ErrNotSQLite) {
+			return nil, fmt.Errorf("not an SQLite database: %s", filepath.Base(originalPath))
+		}
+		return nil, fmt.Errorf("failed to read database: %w", err)
+	}
+
+	if r.outputManager != nil {
+		if err := r.outputManager.EnsureDirectoryExists(outputPath); err != nil {
+			return nil, fmt.Errorf("failed to ensure output directory: %w", err)
+		}
+	}
+	// #nosec G703 -- outputPath is operator-controlled (--redaction-output-dir +
+	// mirrored input filename), as for the plaintext redactor.
+	out, err := os.CreateTemp(filepath.Dir(outputPath), "."+filepath.Base(outputPath)+".*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to write redacted file: %w", err)
+	}
+	written := false
+	defer func() {
+		if !written {
+			out.Close()
+			os.Remove(out.Name())
+		}
+	}()
+	if _, err := io.Copy(out, src); err != nil {
+		return nil, fmt.Errorf("failed to write redacted file: %w", err)
+	}
+	db, err := textextractsqlitelib.OpenReader(out, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read database: %w", err)
+	}
+
+	// Same normalization as every other path: a cluster's and a bounded match's
+	// Text occur nowhere in the database, and a match nested in a wider one must
+	// not be applied first.
+	matches = redactors.ExpandClusterMatches(matches)
+	matches = redactors.RestoreBoundedMatchText(matches)
+	matches = redactors.ResolveOverlaps(matches)
+	// Wider values first, so a value inside another is not masked on its own and
+	// leave the rest of the wider one unmatched.
+	sort.SliceStable(matches, func(i, j int) bool { return len(matches[i].Text) > len(matches[j].Text) })
+
+	// Each stored value is overwritten as the walk reaches it, every match in
+	// turn. A value that orders its b-tree is not: see keyedTrees.
+	stored := make([]int, len(matches))
+	keyed := map[string]bool{}
+	var writeErr error
+	err = db.Values(func(v textextractsqlitelib.Value) {
+		for i, m := range matches {
+			if m.Text == "" || writeErr != nil || !holds(v, m.Text) {
+				continue
+			}
+			if v.Key {
+				keyed[v.Tree] = true
+				continue
+			}
+			n, err := overwriteValue(db, out, &v, m, strategy)
+			stored[i] += n
+			writeErr = err
+		}
+	})
+	if err == nil {
+		err = writeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to redact database: %w", err)
+	}
+	if len(keyed) > 0 {
+		return nil, keyedTreesError(db, outputPath, keyed)
+	}
+
+	var mappings []redactors.RedactionMapping
+	for i, m := range matches {
+		if m.Text == "" {
+			continue
+		}
+		// Free space, and anything else the b-trees do not reach. Only a value long
+		// enough to mean something is searched for: a two-digit value would match
+		// page headers.
+		loose := 0
+		if len(m.Text) >= redactors.MinResidueValueLen {
+			if loose, err = maskRaw(db, out, size, m.Text); err != nil {
+				return nil, fmt.Errorf("failed to redact database: %w", err)
+			}
+		}
+		if stored[i]+loose == 0 {
+			r.logEvent("match_not_located", false, map[string]interface{}{
+				"match_type":   m.Type,
+				"match_length": len(m.Text),
+			})
+			continue
+		}
+		mappings = append(mappings, redactors.RedactionMapping{
+			RedactedText: sameLengthReplacement(db, m.Text, m.Type, strategy),
+			DataType:     m.Type,
+			Strategy:     strategy,
+			Confidence:   m.Confidence / 100.0,
+			Metadata: map[string]interface{}{
+				"occurrences":            stored[i],
+				"free_space_occurrences": loose,
+				"position_method":        "sqlite_record_overwrite",
+			},
+		})
+	}
+
+	if info, err := out.Stat(); err != nil || info.Size() != size {
+		// Structural invariant, as in the legacy Office redactor.
+		return nil, fmt.Errorf("internal error: SQLite redaction changed file size from %d bytes", size)
+	}
+
+	// TYPES, never the values: this message reaches stderr and every output format.
+	residue, err := residualValues(out, size, matches)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read redacted database back: %w", err)
+	}
+	if len(residue) > 0 {
+		return nil, fmt.Errorf(
+			"refusing to write %s: %d reported value(s) still present in the database (types: %s)",
+			filepath.Base(outputPath), len(residue), strings.Join(redactors.ResidueTypes(residue), ", "))
+	}
+
+	if err := out.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write redacted file: %w", err)
+	}
+	if err := os.Rename(out.Name(), outputPath); err != nil {
+		return nil, fmt.Errorf("failed to write redacted file: %w", err)
+	}
+	written = true
+	if r.outputManager != nil {
+		os.Chmod(outputPath, info.Mode())
+		os.Chtimes(outputPath, info.ModTime(), info.ModTime())
+	}
+
+	return &redactors.RedactionResult{
+		Success:          true,
+		RedactedFilePath: outputPath,
+		RedactionMap:     mappings,
+		ProcessingTime:   time.Since(start),
+		Confidence:       overallConfidence(mappings),
+		Error:            nil,
+	}, nil
+}
+
+// holds reports whether a stored value contains a reported value: in its text,
+// or in the decimal form of a number.
+func holds(v textextractsqlitelib.Value, text string) bool {
+	switch v.Kind {
+	case textextractsqlitelib.Text, textextractsqlitelib.Blob:
+		return strings.Contains(v.Text, text)
+	case textextractsqlitelib.Integer, textextractsqlitelib.Real:
+		return len(v.Spans) > 0 && strings.Contains(v.String(), text)
+	}
+	return false
+}
+
+// keyedTreesError refuses a database in which a reported value orders a
+// b-tree. An index keeps its entries sorted by the values it copies, and a
+// WITHOUT ROWID table its rows by their primary key; overwritten in place,
+// they would be out of order, and sqlite3 reports such a file as corrupt
+// (PRAGMA integrity_check) and may miss rows looked up through it. Re-sorting
+// means rebuilding the b-tree, which is the database engine's job, so the
+// refusal names what to drop. Names come from the schema, never from a value.
+func keyedTreesError(db *textextractsqlitelib.Database, outputPath string, keyed map[string]bool) error {
+	isIndex := map[string]bool{}
+	if schema, err := db.Schema(); err == nil {
+		for _, ix := range schema.Indexes {
+			isIndex[ix.Name] = true
+		}
+	}
+	var names []string
+	for name := range keyed {
+		if isIndex[name] {
+			names = append(names, fmt.Sprintf("index %q", name))
+		} else {
+			names = append(names, fmt.Sprintf("primary key of table %q", name))
+		}
+	}
+	sort.Strings(names)
+	return fmt.Errorf(
+		"refusing to write %s: reported values are in the %s, whose order an in-place overwrite would break; "+
+			"drop the index (or move the values out of the key), redact, then recreate it",
+		filepath.Base(outputPath), strings.Join(names, ", "))
+}
+
+// overwriteValue replaces every occurrence of a match in one stored value,
+// writing through the value's spans, and returns how many it replaced. v is
+// updated in step, so a later match is not found in bytes already masked.
+func overwriteValue(db *textextractsqlitelib.Database, w io.WriterAt, v *textextractsqlitelib.Value, m detector.Match, strategy redactors.RedactionStrategy) (int, error) {
+	switch v.Kind {
+	case textextractsqlitelib.Text, textextractsqlitelib.Blob:
+		n := strings.Count(v.Text, m.Text)
+		if n == 0 {
+			return 0, nil
+		}
+		var repl string
+		var encoded []byte
+		if v.Kind == textextractsqlitelib.Text {
+			repl = sameLengthReplacement(db, m.Text, m.Type, strategy)
+			v.Text = strings.ReplaceAll(v.Text, m.Text, repl)
+			encoded = db.EncodeText(v.Text)
+		} else {
+			// A blob is bytes, not text in the database's encoding.
+			repl = sameLengthBytes(m.Text, m.Type, strategy)
+			v.Text = strings.ReplaceAll(v.Text, m.Text, repl)
+			encoded = []byte(v.Text)
+		}
+		if ok, err := writeSpans(w, v.Spans, encoded); !ok || err != nil {
+			return 0, err
+		}
+		return n, nil
+	case textextractsqlitelib.Integer, textextractsqlitelib.Real:
+		if !holds(*v, m.Text) {
+			return 0, nil
+		}
+		// Zero is all-zero bytes at every integer width and as an IEEE 754 double.
+		for _, s := range v.Spans {
+			if _, err := w.WriteAt(make([]byte, s.Length), int64(s.Offset)); err != nil {
+				return 0, err
+			}
+		}
+		v.Int, v.Float = 0, 0
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// writeSpans writes b over a value's spans, which must hold exactly len(b)
+// bytes.
+func writeSpans(w io.WriterAt, spans []textextractsqlitelib.Span, b []byte) (bool, error) {
+	total := 0
+	for _, s := range spans {
+		total += s.Length
+	}
+	if total != len(b) {
+		return false, nil
+	}
+	for _, s := range spans {
+		if _, err := w.WriteAt(b[:s.Length], int64(s.Offset)); err != nil {
+			return false, err
+		}
+		b = b[s.Length:]
+	}
+	return true, nil
+}
+
+// rawChunk is how much of the file a raw search reads at once.
+const rawChunk = 1 << 20
+
+// eachChunk calls fn with the file in chunks of rawChunk bytes, each preceded
+// by the last overlap bytes of the one before, so a run of up to overlap+1
+// bytes is whole in some chunk. A chunk fn reports dirty is written back
+// before the next is read, so a run masked at the end of one chunk is seen
+// masked at the start of the next.
+func eachChunk(f *os.File, size int64, overlap int, fn func(b []byte) (dirty bool)) error {
+	buf := make([]byte, rawChunk+overlap)
+	for pos := int64(0); pos < size; pos += rawChunk {
+		from := max(pos-int64(overlap), 0)
+		b := buf[:min(pos+rawChunk, size)-from]
+		if _, err := f.ReadAt(b, from); err != nil {
+			return err
+		}
+		if fn(b) {
+			if _, err := f.WriteAt(b, from); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// maskRaw masks every occurrence of value left anywhere in the file, in UTF-8
+// and in the database's text encoding, and returns how many it masked.
+func maskRaw(db *textextractsqlitelib.Database, f *os.File, size int64, value string) (int, error) {
+	patterns := [][]byte{[]byte(value)}
+	if db.Encoding() != textextractsqlitelib.UTF8 {
+		patterns = append(patterns, db.EncodeText(value))
+	}
+	count := 0
+	for i, pat := range patterns {
+		mask := bytes.Repeat([]byte{maskByte}, len(pat))
+		if i > 0 {
+			mask = db.EncodeText(strings.Repeat(string(maskByte), len(pat)/2))
+		}
+		err := eachChunk(f, size, len(pat)-1, func(b []byte) bool {
+			dirty := false
+			for off := 0; ; {
+				i := bytes.Index(b[off:], pat)
+				if i < 0 {
+					break
+				}
+				copy(b[off+i:], mask)
+				off += i + len(pat)
+				count++
+				dirty = true
+			}
+			return dirty
+		})
+		if err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
+
+// residualValues returns the reported values the redacted database still
+// holds: in any value of any table or index, in the extracted text (which is
+// where a rowid shows), or in its raw bytes, read a chunk at a time.
+func residualValues(f *os.File, size int64, matches []detector.Match) ([]detector.Match, error) {
+	db, err := textextractsqlitelib.OpenReader(f, size)
+	if err != nil {
+		return nil, err
+	}
+	ex, err := textextractsqlitelib.ExtractReader(f, size)
+	if err != nil {
+		return nil, err
+	}
+	var stored strings.Builder
+	if err := db.Values(func(v textextractsqlitelib.Value) {
+		stored.WriteString(v.String())
+		stored.WriteByte('\n')
+	}); err != nil {
+		return nil, err
+	}
+	found := map[string]bool{}
+	for _, m := range redactors.ResidualValues([]string{ex.Text, stored.String()}, matches) {
+		found[m.Text] = true
+	}
+
+	// Raw bytes, overlapping by the widest value in any encoding and one byte
+	// more, for UTF-16 read at the other alignment.
+	overlap := 0
+	for _, m := range matches {
+		overlap = max(overlap, len(db.EncodeText(m.Text))+1, len(m.Text))
+	}
+	enc := db.Encoding()
+	err = eachChunk(f, size, overlap, func(b []byte) bool {
+		texts := []string{string(b)}
+		if enc != textextractsqlitelib.UTF8 && len(b) > 1 {
+			// Text left in free space in UTF-16, read at either byte alignment.
+			texts = append(texts, decodeUTF16(b, enc), decodeUTF16(b[1:], enc))
+		}
+		for _, m := range redactors.ResidualValues(texts, matches) {
+			found[m.Text] = true
+		}
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var residue []detector.Match
+	for _, m := range matches {
+		if found[m.Text] {
+			residue = append(residue, m)
+			delete(found, m.Text)
+		}
+	}
+	return residue, nil
+}
+
+// decodeUTF16 reads arbitrary bytes as UTF-16 text.
+func decodeUTF16(b []byte, enc textextractsqlitelib.TextEncoding) string {
+	units := make([]uint16, len(b)/2)
+	for i := range units {
+		if enc == textextractsqlitelib.UTF16LE {
+			units[i] = binary.LittleEndian.Uint16(b[i*2:])
+		} else {
+			units[i] = binary.BigEndian.Uint16(b[i*2:])
+		}
+	}
+	return string(utf16.Decode(units))
+}
+
+// sameLengthReplacement produces a replacement that encodes to exactly as many
+// bytes as original in the database's text encoding: the format-preserving
+// form when it fits and differs from the original, a mask otherwise. See the
+// legacy Office redactor's function of the same name for why both conditions
+// are checked.
+func sameLengthReplacement(db *textextractsqlitelib.Database, original, dataType string, strategy redactors.RedactionStrategy) string {
+	width := len(db.EncodeText(original))
+	if strategy == redactors.RedactionFormatPreserving || strategy == redactors.RedactionSimple {
+		fp := replacement.FormatPreserving(original, dataType)
+		if fp != original && len(db.EncodeText(fp)) == width {
+			return fp
+		}
+	}
+	if db.Encoding() != textextractsqlitelib.UTF8 {
+		width /= 2
+	}
+	return strings.Repeat(string(maskByte), width)
+}
+
+// sameLengthBytes is sameLengthReplacement for bytes stored as they are.
+func sameLengthBytes(original, dataType string, strategy redactors.RedactionStrategy) string {
+	if strategy == redactors.RedactionFormatPreserving || strategy == redactors.RedactionSimple {
+		fp := replacement.FormatPreserving(original, dataType)
+		if fp != original && len(fp) == len(original) {
+			return fp
+		}
+	}
+	return strings.Repeat(string(maskByte), len(original))
+}
+
+func (r *SQLiteRedactor) logEvent(op string, success bool, meta map[string]interface{}) {
+	if r.observer == nil {
+		return
+	}
+	r.observer.LogOperation(observability.StandardObservabilityData{
+		Component: r.GetComponentName(),
+		Operation: op,
+		Success:   success,
+		Metadata:  meta,
+	})
+}
+
+// overallConfidence averages the per-redaction confidence.
+func overallConfidence(mappings []redactors.RedactionMapping) float64 {
+	if len(mappings) == 0 {
+		return 1.0
+	}
+	total := 0.0
+	for _, m := range mappings {
+		total += m.Confidence
+	}
+	return total / float64(len(mappings))
+}
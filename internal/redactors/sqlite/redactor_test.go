@@ -0,0 +1,227 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package sqlite
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/awslabs/ferret-scan/v2/internal/detector"
+	textextractsqlitelib "github.com/awslabs/ferret-scan/v2/internal/preprocessors/text-extractors/text-extract-sqlitelib"
+	"github.com/awslabs/ferret-scan/v2/internal/redactors"
+	"github.com/awslabs/ferret-scan/v2/internal/sqlitefixture"
+)
+
+func writeDB(t *testing.T, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "app.db")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func match(typ, value string) detector.Match {
+	return detector.Match{Text: value, Type: typ, Confidence: 90}
+}
+
+func redact(t *testing.T, data []byte, matches ...detector.Match) (*redactors.RedactionResult, []byte, error) {
+	t.Helper()
+	path := writeDB(t, data)
+	out := filepath.Join(t.TempDir(), "app.db")
+	res, err := NewSQLiteRedactor(nil, nil).RedactDocument(path, out, matches, redactors.RedactionFormatPreserving)
+	if err != nil {
+		return nil, nil, err
+	}
+	got, rerr := os.ReadFile(out)
+	if rerr != nil {
+		t.Fatal(rerr)
+	}
+	return res, got, nil
+}
+
+func usersWithIndex(t *testing.T, index string, column int) []byte {
+	return sqlitefixture.MustBuild(1024, sqlitefixture.UTF8, []sqlitefixture.Table{
+		{Name: "users", SQL: "CREATE TABLE users(id INTEGER PRIMARY KEY, name TEXT, ssn TEXT)", Rows: [][]any{
+			{nil, "Jane Roe", "536-22-1874"},
+			{nil, "John Doe", "078-05-1120"},
+		}},
+	}, []sqlitefixture.Index{{Name: index, Table: "users", SQL: "CREATE INDEX " + index + " ON users(" +
+		[]string{"id", "name", "ssn"}[column] + ")", Column: column}})
+}
+
+// A value in a column no index covers is overwritten in its row, and the copy
+// reads back as the same tables with the same columns.
+func TestRedactRow(t *testing.T) {
+	data := usersWithIndex(t, "users_name", 1)
+	res, got, err := redact(t, data, match("SSN", "536-22-1874"))
+	if err != nil {
+		t.Fatalf("RedactDocument: %v", err)
+	}
+	if len(got) != len(data) {
+		t.Errorf("size changed from %d to %d", len(data), len(got))
+	}
+	if len(res.RedactionMap) != 1 || res.RedactionMap[0].Metadata["occurrences"] != 1 {
+		t.Errorf("redactions = %+v", res.RedactionMap)
+	}
+	if bytes.Contains(got, []byte("536-22-1874")) {
+		t.Error("the value is still in the file")
+	}
+
+	ex, err := textextractsqlitelib.Extract(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "--- users ---\nid\tname\tssn\n1\tJane Roe\t***-**-1874\n2\tJohn Doe\t078-05-1120\n"
+	if ex.Text != want {
+		t.Errorf("got  %q\nwant %q", ex.Text, want)
+	}
+	assertIntegrity(t, got)
+}
+
+// An index entry overwritten in place would be out of the index's order, a
+// file sqlite3 reports as corrupt, so a value an index holds is refused, and
+// the refusal names the index and not the value. Nothing is left at the
+// output path.
+func TestRefusesValueInIndex(t *testing.T) {
+	path := writeDB(t, usersWithIndex(t, "users_ssn", 2))
+	dir := t.TempDir()
+	out := filepath.Join(dir, "app.db")
+	_, err := NewSQLiteRedactor(nil, nil).RedactDocument(path, out, []detector.Match{match("SSN", "536-22-1874")},
+		redactors.RedactionFormatPreserving)
+	if err == nil {
+		t.Fatal("expected a refusal")
+	}
+	if msg := err.Error(); !strings.Contains(msg, "refusing to write") || !strings.Contains(msg, `index "users_ssn"`) ||
+		strings.Contains(msg, "536-22-1874") {
+		t.Errorf("err = %v", err)
+	}
+	if left, _ := os.ReadDir(dir); len(left) != 0 {
+		t.Errorf("left behind: %v", left)
+	}
+}
+
+// The redacted copy is a database sqlite3 accepts. Every index is walked in
+// order here; the sqlite3 shell, where there is one, runs its own check.
+func assertIntegrity(t *testing.T, data []byte) {
+	t.Helper()
+	db, err := textextractsqlitelib.Open(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema, err := db.Schema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ix := range schema.Indexes {
+		var prev *textextractsqlitelib.Value
+		err := db.WalkIndex(ix.RootPage, func(e textextractsqlitelib.Entry) bool {
+			v := e.Values[0]
+			if prev != nil && v.Kind == prev.Kind && v.Text < prev.Text {
+				t.Errorf("index %s out of order: %q after %q", ix.Name, v.Text, prev.Text)
+			}
+			prev = &v
+			return true
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	shell, err := exec.LookPath("sqlite3")
+	if err != nil {
+		return
+	}
+	path := writeDB(t, data)
+	out, err := exec.Command(shell, path, "PRAGMA integrity_check;").CombinedOutput()
+	if err != nil || strings.TrimSpace(string(out)) != "ok" {
+		t.Errorf("integrity_check: %s %v", out, err)
+	}
+}
+
+func TestRedactUTF16(t *testing.T) {
+	data := sqlitefixture.MustBuild(512, sqlitefixture.UTF16LE, []sqlitefixture.Table{
+		{Name: "people", SQL: "CREATE TABLE people(name, email)", Rows: [][]any{{"Zoë", "zoe@corp.example"}}},
+	}, nil)
+	_, got, err := redact(t, data, match("EMAIL", "zoe@corp.example"))
+	if err != nil {
+		t.Fatalf("RedactDocument: %v", err)
+	}
+	ex, err := textextractsqlitelib.Extract(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(ex.Text, "zoe@corp.example") || !strings.Contains(ex.Text, "Zoë\t") {
+		t.Errorf("got %q", ex.Text)
+	}
+}
+
+// A value split across overflow pages is overwritten through every page.
+func TestRedactOverflowingValue(t *testing.T) {
+	note := strings.Repeat("note ", 300) + "card 4111 1111 1111 1111 on file"
+	data := sqlitefixture.MustBuild(512, sqlitefixture.UTF8, []sqlitefixture.Table{
+		{Name: "notes", SQL: "CREATE TABLE notes(body)", Rows: [][]any{{note}}},
+	}, nil)
+	_, got, err := redact(t, data, match("CREDIT_CARD", "4111 1111 1111 1111"))
+	if err != nil {
+		t.Fatalf("RedactDocument: %v", err)
+	}
+	ex, err := textextractsqlitelib.Extract(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(ex.Text, "4111 1111 1111 1111") || !strings.Contains(ex.Text, " on file\n") {
+		t.Errorf("got ...%q", ex.Text[len(ex.Text)-60:])
+	}
+}
+
+// A number whose decimal form holds a value is zeroed at its stored width.
+func TestRedactNumber(t *testing.T) {
+	data := sqlitefixture.MustBuild(512, sqlitefixture.UTF8, []sqlitefixture.Table{
+		{Name: "accounts", SQL: "CREATE TABLE accounts(owner, card INTEGER)", Rows: [][]any{{"Jane", 4111111111111111}}},
+	}, nil)
+	_, got, err := redact(t, data, match("CREDIT_CARD", "4111111111111111"))
+	if err != nil {
+		t.Fatalf("RedactDocument: %v", err)
+	}
+	ex, err := textextractsqlitelib.Extract(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(ex.Text, "Jane\t0\n") {
+		t.Errorf("got %q", ex.Text)
+	}
+}
+
+// A rowid is the b-tree key and cannot be rewritten, so a copy that would keep
+// it is refused, and the refusal names the type, not the value.
+func TestRefusesWhenRowIDHoldsValue(t *testing.T) {
+	var rows [][]any
+	for i := 0; i < 1000; i++ {
+		rows = append(rows, []any{nil, "x"})
+	}
+	data := sqlitefixture.MustBuild(1024, sqlitefixture.UTF8, []sqlitefixture.Table{
+		{Name: "accounts", SQL: "CREATE TABLE accounts(acct INTEGER PRIMARY KEY, owner)", Rows: rows},
+	}, nil)
+	_, _, err := redact(t, data, match("BANK_ACCOUNT", "1000"))
+	if err == nil {
+		t.Fatal("expected a refusal")
+	}
+	if msg := err.Error(); !strings.Contains(msg, "refusing to write") || !strings.Contains(msg, "BANK_ACCOUNT") ||
+		strings.Contains(msg, "1000") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestRejectsOtherFiles(t *testing.T) {
+	path := writeDB(t, []byte("not a database"))
+	_, err := NewSQLiteRedactor(nil, nil).RedactDocument(path, filepath.Join(t.TempDir(), "x.db"), nil, redactors.RedactionSimple)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+}
@@ -4,9 +4,14 @@
 package redactors
 
 import (
+	"archive/zip"
+	"bytes"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/awslabs/ferret-scan/v2/internal/detector"
 )
 
 // The scanner admits files by SNIFFING their bytes; redactor selection matched an
@@ -214,3 +219,136 @@ func TestLooksLikeTextFile(t *testing.T) {
 		}
 	}
 }
+
+// ".db" names many formats. A text file so named is scanned as text, so it is
+// redacted as text; a file with the SQLite header keeps the SQLite redactor.
+func TestTextFileNamedDBIsDivertedToText(t *testing.T) {
+	rm := newTestManager(t)
+	plain := &stubRedactor{exts: []string{".txt"}}
+	db := &stubRedactor{exts: []string{".db"}}
+	if err := rm.RegisterRedactor(plain); err != nil {
+		t.Fatal(err)
+	}
+	if err := rm.RegisterRedactor(db); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	text := filepath.Join(dir, "notes.db")
+	sqlite := filepath.Join(dir, "app.db")
+	if err := os.WriteFile(text, []byte(textualContent), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(sqlite, append([]byte("SQLite format 3\x00"), make([]byte, 84)...), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := rm.GetRedactorForFile(text); err != nil || got != Redactor(plain) {
+		t.Errorf("text file named .db resolved to %v, %v; want the text redactor", got, err)
+	}
+	if got, err := rm.GetRedactorForFile(sqlite); err != nil || got != Redactor(db) {
+		t.Errorf("SQLite database resolved to %v, %v; want the SQLite redactor", got, err)
+	}
+}
+
+// pathSpyRedactor records the path it is handed, whether the file there holds
+// what the original did, and the matches.
+type pathSpyRedactor struct {
+	stubRedactor
+	gotPath    string
+	gotContent []byte
+	gotMatches []detector.Match
+}
+
+func (p *pathSpyRedactor) RedactDocument(originalPath, outputPath string,
+	matches []detector.Match, strategy RedactionStrategy) (*RedactionResult, error) {
+	p.gotPath, p.gotMatches = originalPath, matches
+	p.gotContent, _ = os.ReadFile(originalPath)
+	return p.stubRedactor.RedactDocument(originalPath, outputPath, matches, strategy)
+}
+
+// A file whose content contradicts its name is redacted as what it is: by the
+// redactor for its format, through a path named for the format, never by the
+// redactor its name selects. Text stays with the text redactor.
+func TestDisguisedFileIsRedactedAsItsFormat(t *testing.T) {
+	rm := newTestManager(t)
+	plain := &stubRedactor{exts: []string{".txt"}}
+	pdf := &pathSpyRedactor{stubRedactor: stubRedactor{exts: []string{".pdf"}}}
+	img := &pathSpyRedactor{stubRedactor: stubRedactor{exts: []string{".jpg", ".png"}}}
+	office := &pathSpyRedactor{stubRedactor: stubRedactor{exts: []string{".docx"}}}
+	for _, r := range []Redactor{plain, pdf, img, office} {
+		if err := rm.RegisterRedactor(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var docx bytes.Buffer
+	zw := zip.NewWriter(&docx)
+	for _, name := range []string{"[Content_Types].xml", "word/document.xml"} {
+		if _, err := zw.Create(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name, file string
+		content    []byte
+		want       *pathSpyRedactor
+		wantExt    string
+	}{
+		{"PDF saved as .txt", "notes.txt", []byte("%PDF-1.7\n1 0 obj\n<< >>\nendobj\n"), pdf, ".pdf"},
+		{"PNG named .jpg", "photo.jpg", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), img, ".png"},
+		{"Word document named .zip", "report.zip", docx.Bytes(), office, ".docx"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p := filepath.Join(t.TempDir(), tc.file)
+			if err := os.WriteFile(p, tc.content, 0o600); err != nil {
+				t.Fatal(err)
+			}
+			got, err := rm.GetRedactorForFile(p)
+			if err != nil {
+				t.Fatalf("GetRedactorForFile: %v", err)
+			}
+			matches := []detector.Match{{Text: "452-11-9384", Type: "SSN", Filename: tc.file + " -> qr[0]"}}
+			if _, err := got.RedactDocument(p, p+".out", matches, RedactionSimple); err != nil {
+				t.Fatalf("RedactDocument: %v", err)
+			}
+			if filepath.Ext(tc.want.gotPath) != tc.wantExt || !bytes.Equal(tc.want.gotContent, tc.content) {
+				t.Errorf("redactor was handed %q holding %q; want the file under a %s name", tc.want.gotPath, tc.want.gotContent, tc.wantExt)
+			}
+			if want := filepath.Base(tc.want.gotPath) + " -> qr[0]"; tc.want.gotMatches[0].Filename != want {
+				t.Errorf("match names %q, want %q", tc.want.gotMatches[0].Filename, want)
+			}
+			if _, err := os.Lstat(tc.want.gotPath); !os.IsNotExist(err) {
+				t.Errorf("alias %s left behind: %v", tc.want.gotPath, err)
+			}
+		})
+	}
+
+	rtf := filepath.Join(t.TempDir(), "letter.txt")
+	if err := os.WriteFile(rtf, []byte(`{\rtf1\ansi SSN 452-11-9384}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := rm.GetRedactorForFile(rtf); err != nil || got != Redactor(plain) {
+		t.Errorf("RTF saved as .txt resolved to %v, %v; want the text redactor", got, err)
+	}
+}
+
+// A file in a format no redactor handles is refused, not handed to the redactor
+// its name selects.
+func TestDisguisedFileWithoutARedactorIsRefused(t *testing.T) {
+	rm := newTestManager(t)
+	if err := rm.RegisterRedactor(&stubRedactor{exts: []string{".txt"}}); err != nil {
+		t.Fatal(err)
+	}
+	p := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(p, []byte("%PDF-1.7\n1 0 obj\n<< >>\nendobj\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := rm.GetRedactorForFile(p); err == nil || !strings.Contains(err.Error(), "PDF document") {
+		t.Errorf("PDF named .txt resolved to %v, %v; want a refusal naming the format", got, err)
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package video
+
+import (
+	"io"
+
+	"github.com/awslabs/ferret-scan/v2/internal/embedded"
+	"github.com/awslabs/ferret-scan/v2/internal/redactors/ebml"
+	"github.com/awslabs/ferret-scan/v2/internal/redactors/isobmff"
+	"github.com/awslabs/ferret-scan/v2/internal/redactors/riff"
+)
+
+// headBytes is how much of the file is read to recognise its container.
+const headBytes = 12
+
+// layout is one video container this redactor can walk: where its metadata is, and where a
+// position is stored inside a piece of it.
+//
+// Everything after the walk — the same-length plan, the coordinate scrub, the residue checks
+// and the copy-and-patch write — is shared, because it depends only on the spans. That is the
+// property worth keeping: three containers, one place that decides whether a file is safe to
+// hand over.
+type layout struct {
+	// structure names what was walked, for messages.
+	structure string
+
+	// locate returns the tag payload spans, and separately the attachment spans: attachments
+	// are searched one at a time and held only when they change, so they are not charged to
+	// maxTagBytes.
+	locate func(r io.ReaderAt, size int64) (tags, attachments []isobmff.Span, err error)
+
+	// coordinates returns the parts of one tag payload that hold a position, buffer-relative.
+	coordinates func(buf []byte) []isobmff.Span
+}
+
+// layoutFor picks the layout by magic. The extension is the caller's claim; the bytes are the
+// file, and a container this package cannot walk is a container whose values it cannot remove.
+func layoutFor(head []byte) (layout, bool) {
+	switch {
+	case isobmff.HasHeader(head):
+		return layout{
+			structure: "atom tree",
+			locate: func(r io.ReaderAt, size int64) ([]isobmff.Span, []isobmff.Span, error) {
+				spans, err := isobmff.MetadataSpans(r, size)
+				return spans, nil, err
+			},
+			coordinates: isobmff.Coordinates,
+		}, true
+	case ebml.HasHeader(head):
+		return layout{structure: "EBML element tree", locate: locateMatroska, coordinates: textCoordinates}, true
+	case riff.IsAVI(head):
+		return layout{structure: "RIFF chunk list", locate: locateAVI, coordinates: textCoordinates}, true
+	}
+	return layout{}, false
+}
+
+// locateMatroska returns every string element the extractor reads, and the attachments it
+// examines.
+//
+// The attachments are selected by the same rules, in the same order, as the extractor's
+// materializeAttachments: fonts skipped, the first ebml.MaxAttachments of the rest, none over
+// ebml.MaxAttachmentBytes, and none past embedded.BudgetBytes. An attachment the read side never
+// examined cannot have produced a finding, and one it did examine must be searched here, so the
+// two selections have to be the same selection.
+func locateMatroska(r io.ReaderAt, size int64) ([]isobmff.Span, []isobmff.Span, error) {
+	md, err := ebml.Read(r, size)
+	if err != nil {
+		return nil, nil, err
+	}
+	tags := make([]isobmff.Span, 0, len(md.Fields))
+	for _, f := range md.Fields {
+		tags = append(tags, isobmff.Span{Start: f.Start, End: f.End, Label: f.Label})
+	}
+
+	var attachments []isobmff.Span
+	var total int64
+	examined := 0
+	for _, a := range md.Attachments {
+		if a.IsFont() {
+			continue
+		}
+		if examined >= ebml.MaxAttachments {
+			break
+		}
+		examined++
+		if a.Data.Len() > ebml.MaxAttachmentBytes {
+			continue
+		}
+		if total+a.Data.Len() > embedded.BudgetBytes {
+			break
+		}
+		total += a.Data.Len()
+		attachments = append(attachments, isobmff.Span{Start: a.Data.Start, End: a.Data.End, Label: a.Data.Label})
+	}
+	return tags, attachments, nil
+}
+
+// locateAVI returns every string chunk the extractor reads.
+func locateAVI(r io.ReaderAt, size int64) ([]isobmff.Span, []isobmff.Span, error) {
+	md, err := riff.ReadAVI(r, size)
+	if err != nil {
+		return nil, nil, err
+	}
+	tags := make([]isobmff.Span, 0, len(md.Fields))
+	for _, f := range md.Fields {
+		tags = append(tags, isobmff.Span{Start: f.Start, End: f.End, Label: f.Label})
+	}
+	return tags, nil, nil
+}
+
+// textCoordinates reports a whole tag string as a position when it holds an ISO 6709 string —
+// the shape the extractor's GPS search parses out of a Matroska LOCATION tag or any other string
+// property.
+//
+// The whole string, for the reason isobmff.Coordinates gives for a ©xyz payload: the extractor
+// re-formats the position, so nothing short of emptying the value is sure to leave no position
+// behind. A string of NULs is an empty string to both a Matroska and a RIFF reader.
+func textCoordinates(buf []byte) []isobmff.Span {
+	if isobmff.FindISO6709(buf) == nil {
+		return nil
+	}
+	return []isobmff.Span{{Start: 0, End: int64(len(buf)), Label: "ISO 6709 string"}}
+}
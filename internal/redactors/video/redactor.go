@@ -1,7 +1,8 @@
 // Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
 // SPDX-License-Identifier: Apache-2.0
 
-// Package video redacts metadata in ISO base media video files (.mp4/.m4v/.mov).
+// Package video redacts metadata in video files: ISO base media (.mp4/.m4v/.mov), Matroska and
+// WebM (.mkv/.webm), and AVI (.avi).
 //
 // Before this package video had no redactor at all. The read side is complete — a dedicated
 // preprocessor and extractor walk moov>udta and report the title, author, comment, copyright,
@@ -34,6 +35,18 @@
 // check that is supposed to catch a miss — the check agrees with the bug. So coordinates are
 // scrubbed structurally, by zeroing the payload of the atom that holds them, and verified by
 // their own assertion rather than by the text search. See isobmff.CoordinateSpans.
+//
+// With --gps-precision the same payloads are coarsened rather than zeroed — the digits past the
+// precision become zero, in whatever encoding the file used — and the assertion becomes "every
+// position left is at that precision". See SetGPSPrecision and isobmff.Coarsen.
+//
+// # Three walks, one redaction
+//
+// Matroska and AVI are walked by their own packages, ebml and riff, and only the walk differs —
+// see layout. A Matroska attachment is searched like a tag payload: it is stored as raw bytes,
+// so a value the read side found in an attached text file or image is overwritten where it
+// lies. An attachment whose value cannot be found that way — a compressed document — leaves
+// the value unlocated, and the file is refused.
 package video
 
 import (
@@ -78,6 +91,7 @@ const gpsType = "GPS"
 type VideoRedactor struct {
 	observer      observability.Observer
 	outputManager *redactors.OutputStructureManager
+	gpsPrecision  redactors.GPSPrecision
 }
 
 // NewVideoRedactor creates a redactor for video files.
@@ -88,6 +102,12 @@ func NewVideoRedactor(outputManager *redactors.OutputStructureManager, observer
 	return &VideoRedactor{observer: observer, outputManager: outputManager}
 }
 
+// SetGPSPrecision makes the redactor coarsen a reported position to the given precision
+// instead of zeroing it. Set it before redaction begins.
+func (r *VideoRedactor) SetGPSPrecision(p redactors.GPSPrecision) {
+	r.gpsPrecision = p
+}
+
 // GetName returns the name of the redactor.
 func (r *VideoRedactor) GetName() string { return "video_metadata_redactor" }
 
@@ -98,15 +118,14 @@ func (r *VideoRedactor) GetComponentName() string { return "video_metadata_redac
 //
 // Both bare and dotted spellings, matching every other redactor: the manager is called with
 // each form in different code paths.
-//
-// .mkv and .webm are absent deliberately. They are EBML, not atom-based, and nothing scans them
-// today — the file-extension validator admits exactly these three — so claiming them here would
-// register a redactor for a file type that never reaches it.
 func (r *VideoRedactor) GetSupportedTypes() []string {
 	return []string{
 		"mp4", ".mp4",
 		"m4v", ".m4v",
 		"mov", ".mov",
+		"mkv", ".mkv",
+		"webm", ".webm",
+		"avi", ".avi",
 	}
 }
 
@@ -121,12 +140,13 @@ func (r *VideoRedactor) GetSupportedStrategies() []redactors.RedactionStrategy {
 	}
 }
 
-// tagBlock is one udta payload: where it lives in the file, and its bytes once modified.
+// tagBlock is one metadata payload: where it lives in the file, and its bytes once modified.
 type tagBlock struct {
 	span     isobmff.Span
 	buf      []byte
-	coords   []isobmff.Span // coordinate payloads, buffer-relative
-	scrubbed bool           // a coordinate payload in this block was scrubbed
+	find     func([]byte) []isobmff.Span // the layout's coordinate finder; nil for an attachment
+	coords   []isobmff.Span              // coordinate payloads, buffer-relative
+	scrubbed bool                        // a coordinate payload in this block was scrubbed or coarsened
 }
 
 // RedactDocument writes a redacted copy of a video file to outputPath.
@@ -158,20 +178,19 @@ func (r *VideoRedactor) RedactDocument(originalPath string, outputPath string, m
 	}
 	size := info.Size()
 
-	var head [8]byte
-	if _, err := io.ReadFull(src, head[:]); err != nil || !isobmff.HasHeader(head[:]) {
-		// Magic, not the extension. The extension is the caller's claim; the bytes are the
-		// file, and a container this package cannot walk is a container whose values it cannot
-		// remove.
+	head := make([]byte, headBytes)
+	n, _ := io.ReadFull(src, head)
+	l, ok := layoutFor(head[:n])
+	if !ok {
 		return nil, fmt.Errorf("not a recognised video container: %s", name)
 	}
 
-	spans, err := isobmff.MetadataSpans(src, size)
+	spans, attachments, err := l.locate(src, size)
 	if err != nil {
 		// A partial walk cannot support a claim about the whole file.
-		return nil, fmt.Errorf("could not walk the atom tree of %s: %w", name, err)
+		return nil, fmt.Errorf("could not walk the %s of %s: %w", l.structure, name, err)
 	}
-	if len(spans) == 0 {
+	if len(spans) == 0 && len(attachments) == 0 {
 		// No tag region located. Returning success here would write a byte-identical copy and
 		// report it as redacted, which is the exact failure #358 is about.
 		return nil, fmt.Errorf("no video metadata region found in %s, so its findings could not be removed", name)
@@ -192,14 +211,14 @@ func (r *VideoRedactor) RedactDocument(originalPath string, outputPath string, m
 	matches = redactors.ExpandClusterMatches(matches)
 	matches = redactors.RestoreBoundedMatchText(matches)
 
-	blocks, perMatch, err := r.planBlocks(src, spans, matches, strategy)
+	blocks, perMatch, err := r.planBlocks(src, l, spans, attachments, matches, strategy)
 	if err != nil {
 		return nil, err
 	}
 
 	// Coordinates first, because their absence from the text search is expected rather than a
 	// miss, and because whether the remaining unlocated matches are tolerable depends on it.
-	gpsHandled := r.scrubCoordinates(blocks, matches, perMatch)
+	gpsHandled, coarsened := r.scrubCoordinates(blocks, matches, perMatch)
 
 	if err := r.refuseIfAnythingUnlocated(name, matches, perMatch, gpsHandled); err != nil {
 		return nil, err
@@ -212,7 +231,7 @@ func (r *VideoRedactor) RedactDocument(originalPath string, outputPath string, m
 		return nil, fmt.Errorf("%d reported value(s) remain in the metadata of %s after redaction; refusing to write a file that would look redacted",
 			residual, name)
 	}
-	if err := verifyCoordinatesScrubbed(blocks); err != nil {
+	if err := verifyCoordinatesScrubbed(blocks, r.gpsPrecision); err != nil {
 		return nil, fmt.Errorf("%s: %w", name, err)
 	}
 
@@ -220,7 +239,7 @@ func (r *VideoRedactor) RedactDocument(originalPath string, outputPath string, m
 		return nil, err
 	}
 
-	mappings := r.mappings(matches, perMatch, gpsHandled, strategy)
+	mappings := r.mappings(matches, perMatch, gpsHandled, coarsened, strategy)
 
 	return &redactors.RedactionResult{
 		Success:          true,
@@ -236,28 +255,56 @@ func (r *VideoRedactor) RedactDocument(originalPath string, outputPath string, m
 //
 // Only these payloads are read. The media stream — which is all but a few kilobytes of a real
 // video — is never loaded, which is the whole point of walking the file rather than reading it.
-func (r *VideoRedactor) planBlocks(src io.ReaderAt, spans []isobmff.Span, matches []detector.Match, strategy redactors.RedactionStrategy) ([]*tagBlock, []int, error) {
+//
+// An attachment is read, searched and then dropped unless it holds a reported value, so peak
+// memory is one attachment plus the ones that change rather than every attachment in the file.
+func (r *VideoRedactor) planBlocks(src io.ReaderAt, l layout, spans, attachments []isobmff.Span, matches []detector.Match, strategy redactors.RedactionStrategy) ([]*tagBlock, []int, error) {
 	blocks := make([]*tagBlock, 0, len(spans))
 	perMatch := make([]int, len(matches))
 
-	for _, sp := range spans {
-		length := sp.Len()
-		if length <= 0 {
-			continue
-		}
-		buf := make([]byte, length)
+	plan := func(sp isobmff.Span) ([]byte, []int, error) {
+		buf := make([]byte, sp.Len())
 		if _, err := src.ReadAt(buf, sp.Start); err != nil {
 			return nil, nil, fmt.Errorf("failed to read metadata at offset %d: %w", sp.Start, err)
 		}
-
 		region := []tagmeta.Region{{Start: 0, End: len(buf), Label: sp.Label}}
-		plan, found := tagmeta.Plan(buf, region, matches, strategy)
-		tagmeta.Apply(buf, plan)
+		p, found := tagmeta.Plan(buf, region, matches, strategy)
+		tagmeta.Apply(buf, p)
 		for i, n := range found {
 			perMatch[i] += n
 		}
+		return buf, found, nil
+	}
+
+	for _, sp := range spans {
+		if sp.Len() <= 0 {
+			continue
+		}
+		buf, _, err := plan(sp)
+		if err != nil {
+			return nil, nil, err
+		}
+		blocks = append(blocks, &tagBlock{span: sp, buf: buf, find: l.coordinates, coords: l.coordinates(buf)})
+	}
 
-		blocks = append(blocks, &tagBlock{span: sp, buf: buf, coords: isobmff.Coordinates(buf)})
+	for _, sp := range attachments {
+		if sp.Len() <= 0 {
+			continue
+		}
+		buf, found, err := plan(sp)
+		if err != nil {
+			return nil, nil, err
+		}
+		changed := false
+		for _, n := range found {
+			changed = changed || n > 0
+		}
+		// Kept when it changed, and also when a value survives in it: the residue check below
+		// has to see every attachment that still holds one.
+		region := []tagmeta.Region{{Start: 0, End: len(buf), Label: sp.Label}}
+		if changed || tagmeta.Residual(buf, region, matches) > 0 {
+			blocks = append(blocks, &tagBlock{span: sp, buf: buf})
+		}
 	}
 	return blocks, perMatch, nil
 }
@@ -273,7 +320,11 @@ func (r *VideoRedactor) planBlocks(src io.ReaderAt, spans []isobmff.Span, matche
 // on a real ffmpeg-written .mov, where the extractor reads the same payload as binary and
 // reported "18.335022, 11059.211639" — a '*' fill there leaves the first coordinate untouched and
 // the redacted file still reports GPS. Zero is the one fill no reader turns back into a location.
-func (r *VideoRedactor) scrubCoordinates(blocks []*tagBlock, matches []detector.Match, perMatch []int) bool {
+//
+// With a GPS precision set, each payload is coarsened in place instead (isobmff.Coarsen), and the
+// second result reports that at least one was. A payload that cannot be coarsened at the same
+// length is zeroed as before: the fallback is always the stronger redaction, never the original.
+func (r *VideoRedactor) scrubCoordinates(blocks []*tagBlock, matches []detector.Match, perMatch []int) (bool, bool) {
 	reported := false
 	for i, m := range matches {
 		if strings.EqualFold(m.Type, gpsType) && m.Text != "" && perMatch[i] == 0 {
@@ -282,19 +333,23 @@ func (r *VideoRedactor) scrubCoordinates(blocks []*tagBlock, matches []detector.
 		}
 	}
 	if !reported {
-		return false
+		return false, false
 	}
 
-	scrubbed := 0
+	scrubbed, coarsened := 0, 0
 	for _, b := range blocks {
 		for _, c := range b.coords {
 			if c.Start < 0 || c.End > int64(len(b.buf)) || c.Start >= c.End {
 				continue
 			}
+			b.scrubbed = true
+			if r.gpsPrecision.Enabled() && isobmff.Coarsen(b.buf, c, r.gpsPrecision.Decimals()) {
+				coarsened++
+				continue
+			}
 			for i := c.Start; i < c.End; i++ {
 				b.buf[i] = 0
 			}
-			b.scrubbed = true
 			scrubbed++
 		}
 	}
@@ -304,7 +359,12 @@ func (r *VideoRedactor) scrubCoordinates(blocks []*tagBlock, matches []detector.
 			"blocks":   len(blocks),
 		})
 	}
-	return scrubbed > 0
+	if coarsened > 0 {
+		meta := r.gpsPrecision.AuditMetadata()
+		meta["payloads"] = coarsened
+		r.logEvent("video_coordinate_precision_reduced", true, meta)
+	}
+	return scrubbed+coarsened > 0, coarsened > 0
 }
 
 // refuseIfAnythingUnlocated stops the redaction when a reported value was not found in the tag
@@ -369,16 +429,20 @@ func (r *VideoRedactor) residual(blocks []*tagBlock, matches []detector.Match) i
 // Checked by re-running the coordinate finder on the modified bytes rather than by comparing
 // against what was written. That way the assertion is "no position remains", which is the
 // property that matters, instead of "the fill byte was applied", which a wrong fill byte would
-// also satisfy.
-func verifyCoordinatesScrubbed(blocks []*tagBlock) error {
+// also satisfy. With a precision set, a payload passes when it is zero or when every position in
+// it is at that precision.
+func verifyCoordinatesScrubbed(blocks []*tagBlock, precision redactors.GPSPrecision) error {
 	for _, b := range blocks {
-		if !b.scrubbed {
+		if !b.scrubbed || b.find == nil {
 			continue
 		}
-		for _, c := range isobmff.Coordinates(b.buf) {
+		for _, c := range b.find(b.buf) {
 			if c.Start < 0 || c.End > int64(len(b.buf)) || c.Start >= c.End {
 				continue
 			}
+			if precision.Enabled() && isobmff.AtPrecision(b.buf, c, precision.Decimals()) {
+				continue
+			}
 			for i := c.Start; i < c.End; i++ {
 				if b.buf[i] != 0 {
 					return fmt.Errorf("a coordinate payload at file offset %d was not cleared; refusing to write a file whose position survived",
@@ -477,7 +541,10 @@ func verifyWritten(dst io.ReaderAt, blocks []*tagBlock) error {
 
 // mappings records one entry per value this redactor actually wrote over — never one it merely
 // tried.
-func (r *VideoRedactor) mappings(matches []detector.Match, perMatch []int, gpsHandled bool, strategy redactors.RedactionStrategy) []redactors.RedactionMapping {
+//
+// A coarsened position is recorded with the precision that was kept, so the audit log says the
+// file still holds a position and how fine it is.
+func (r *VideoRedactor) mappings(matches []detector.Match, perMatch []int, gpsHandled, coarsened bool, strategy redactors.RedactionStrategy) []redactors.RedactionMapping {
 	var mappings []redactors.RedactionMapping
 	for i, m := range matches {
 		if m.Text == "" {
@@ -494,15 +561,22 @@ func (r *VideoRedactor) mappings(matches []detector.Match, perMatch []int, gpsHa
 			method = "video_coordinate_atom_scrubbed"
 			occurrences = 1
 		}
+		meta := map[string]interface{}{
+			"occurrences":     occurrences,
+			"position_method": method,
+		}
+		if method == "video_coordinate_atom_scrubbed" && coarsened {
+			meta["position_method"] = "video_coordinate_precision_reduced"
+			for k, v := range r.gpsPrecision.AuditMetadata() {
+				meta[k] = v
+			}
+		}
 		mappings = append(mappings, redactors.RedactionMapping{
 			RedactedText: tagmeta.SameLengthReplacement(m.Text, m.Type, strategy),
 			DataType:     m.Type,
 			Strategy:     strategy,
 			Confidence:   m.Confidence,
-			Metadata: map[string]interface{}{
-				"occurrences":     occurrences,
-				"position_method": method,
-			},
+			Metadata:     meta,
 		})
 	}
 	return mappings
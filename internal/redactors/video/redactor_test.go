@@ -259,6 +259,50 @@ func TestSecondPositionCopyInLociIsAlsoScrubbed(t *testing.T) {
 	}
 }
 
+// TestPositionIsCoarsenedWhenAPrecisionIsSet covers both copies of a position with a precision
+// set: the .mov text form keeps its length and language prefix and its string shape, the loci
+// copy keeps its layout with its place name blanked, and the audit entry records the precision.
+func TestPositionIsCoarsenedWhenAPrecisionIsSet(t *testing.T) {
+	fixed := func(v float64) []byte {
+		b := make([]byte, 4)
+		binary.BigEndian.PutUint32(b, uint32(int32(v*65536)))
+		return b
+	}
+	xyz := append([]byte{0x00, 0x12, 0x55, 0xc4}, []byte("+36.3506-082.6985/")...)
+	loci := atom("loci", []byte{0, 0, 0, 0}, []byte{0x15, 0xc7}, []byte("12 Elm Rd\x00"), []byte{0},
+		fixed(-82.6985), fixed(36.3506), fixed(447.4), []byte("earth\x00"))
+	src := mp4With(t, t.TempDir(), "field.mov", atom("\xa9xyz", xyz), loci)
+
+	out := filepath.Join(t.TempDir(), "redacted.mov")
+	r := NewVideoRedactor(nil, nil)
+	r.SetGPSPrecision(2)
+	res, err := r.RedactDocument(src, out, []detector.Match{match("GPS", "36.350600, -82.698500")}, redactors.RedactionFormatPreserving)
+	if err != nil {
+		t.Fatalf("RedactDocument: %v", err)
+	}
+
+	after := mustRead(t, out)
+	if !bytes.Contains(after, []byte("\x00\x12\x55\xc4+36.3500-082.6900/")) {
+		t.Errorf("©xyz was not coarsened in place: %q", after[bytes.Index(after, []byte("\xa9xyz")):][:30])
+	}
+	at := bytes.Index(after, []byte("loci")) + 4 + 6
+	if string(after[at:at+10]) != "         \x00" {
+		t.Errorf("loci place name = %q, want it blanked with its terminator kept", after[at:at+10])
+	}
+	lat := float64(int32(binary.BigEndian.Uint32(after[at+11+4:]))) / 65536
+	if lat < 36.3499 || lat > 36.3501 {
+		t.Errorf("loci latitude = %f, want 36.35", lat)
+	}
+
+	if len(res.RedactionMap) != 1 {
+		t.Fatalf("mappings = %+v", res.RedactionMap)
+	}
+	meta := res.RedactionMap[0].Metadata
+	if meta["position_method"] != "video_coordinate_precision_reduced" || meta["gps_precision_decimals"] != 2 {
+		t.Errorf("audit metadata = %v; it must record the precision that was kept", meta)
+	}
+}
+
 // TestUnlocatableValueIsRefused is the fail-closed rule. A value the redactor cannot find is a
 // value it cannot remove, and writing the file anyway is what produces a "redacted" copy that
 // still holds a reported secret while the audit trail shows no failures.
@@ -365,8 +409,8 @@ func TestSupportedTypesCoverTheScannedExtensions(t *testing.T) {
 	for _, tp := range NewVideoRedactor(nil, nil).GetSupportedTypes() {
 		claimed[tp] = true
 	}
-	// The scanner's video set is exactly these three (preprocessors.FileExtensionValidator).
-	for _, ext := range []string{".mp4", ".m4v", ".mov"} {
+	// The scanner's video set (preprocessors.FileExtensionValidator).
+	for _, ext := range []string{".mp4", ".m4v", ".mov", ".mkv", ".webm", ".avi"} {
 		if !claimed[ext] || !claimed[strings.TrimPrefix(ext, ".")] {
 			t.Errorf("%s is scanned but not claimed in both spellings; the manager is called with "+
 				"each form in different code paths", ext)
@@ -0,0 +1,194 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package video
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/awslabs/ferret-scan/v2/internal/detector"
+	"github.com/awslabs/ferret-scan/v2/internal/redactors/ebml"
+	"github.com/awslabs/ferret-scan/v2/internal/redactors/riff"
+)
+
+// The layouts below follow what the writers produce: a Tags element after the clusters, as
+// ffmpeg writes it; unknown-size clusters, as a browser's MediaRecorder writes them; and an
+// INFO list between hdrl and movi, as ffmpeg writes an .avi.
+
+// mkvEl builds one EBML element with an 8-byte size field.
+func mkvEl(id uint32, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	var out []byte
+	for shift := 24; shift >= 0; shift -= 8 {
+		if b := byte(id >> shift); b != 0 || len(out) > 0 {
+			out = append(out, b)
+		}
+	}
+	out = append(out, 0x01)
+	size := binary.BigEndian.AppendUint64(nil, uint64(len(body)))
+	return append(append(out, size[1:]...), body...)
+}
+
+func mkvStr(id uint32, s string) []byte { return mkvEl(id, []byte(s)) }
+
+func mkvTag(name, value string) []byte {
+	return mkvEl(0x67C8, mkvStr(0x45A3, name), mkvStr(0x4487, value))
+}
+
+var frames = bytes.Repeat([]byte{0xCD}, 512)
+
+func mkvWith(t *testing.T, dir string, tags [][]byte, attachments ...[]byte) string {
+	t.Helper()
+	file := mkvEl(0x1A45DFA3, mkvStr(0x4282, "matroska"))
+	segment := [][]byte{
+		mkvEl(0x1549A966, mkvStr(0x7BA9, testName+" walkthrough"), mkvStr(0x5741, "OBS Studio")),
+		// An unknown-size cluster: the tags after it must still be found.
+		append([]byte{0x1F, 0x43, 0xB6, 0x75, 0x01, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF},
+			append(mkvEl(0xE7, []byte{0}), mkvEl(0xA3, frames)...)...),
+		mkvEl(0x1254C367, mkvEl(0x7373, tags...)),
+	}
+	if len(attachments) > 0 {
+		segment = append(segment, mkvEl(0x1941A469, attachments...))
+	}
+	file = append(file, mkvEl(0x18538067, segment...)...)
+	p := filepath.Join(dir, "recording.mkv")
+	if err := os.WriteFile(p, file, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func mkvAttachment(name, mime, data string) []byte {
+	return mkvEl(0x61A7, mkvStr(0x466E, name), mkvStr(0x4660, mime), mkvEl(0x465C, []byte(data)))
+}
+
+func TestMatroskaTagsAndAttachmentsAreOverwrittenInPlace(t *testing.T) {
+	dir := t.TempDir()
+	src := mkvWith(t, dir,
+		[][]byte{mkvTag("COMMENT", "Employee SSN "+testSSN)},
+		mkvAttachment("notes.txt", "text/plain", "reach "+testName+" about the badge"),
+		mkvAttachment("Font.ttf", "application/x-truetype-font", "glyphs"))
+
+	out, res, err := redact(t, src, []detector.Match{match("SSN", testSSN), match("PERSON_NAME", testName)})
+	if err != nil {
+		t.Fatalf("RedactDocument: %v", err)
+	}
+	before, after := mustRead(t, src), mustRead(t, out)
+	if len(before) != len(after) {
+		t.Fatalf("size changed from %d to %d", len(before), len(after))
+	}
+	for _, v := range []string{testSSN, testName} {
+		if bytes.Contains(after, []byte(v)) {
+			t.Errorf("a reported value is still in the redacted file")
+		}
+	}
+	if !bytes.Contains(after, frames) {
+		t.Error("the cluster payload was modified")
+	}
+	if !bytes.Contains(after, []byte("glyphs")) {
+		t.Error("a font attachment was modified")
+	}
+
+	// The structure must still walk to the same elements: same-length overwrite moved nothing.
+	md, err := ebml.Read(bytes.NewReader(after), int64(len(after)))
+	if err != nil || len(md.Attachments) != 2 {
+		t.Fatalf("the redacted file no longer walks: %v, %d attachments", err, len(md.Attachments))
+	}
+	// One mapping per reported value, however many places it was written over: the name is in
+	// the title and in the attachment.
+	if len(res.RedactionMap) != 2 {
+		t.Errorf("RedactionMap has %d entries, want 2", len(res.RedactionMap))
+	}
+}
+
+func TestMatroskaLocationTagIsEmptied(t *testing.T) {
+	dir := t.TempDir()
+	src := mkvWith(t, dir, [][]byte{mkvTag("LOCATION", "+37.7749-122.4194/")})
+
+	out, res, err := redact(t, src, []detector.Match{match("GPS", "GPS_Coordinates: 37.774900, -122.419400")})
+	if err != nil {
+		t.Fatalf("RedactDocument: %v", err)
+	}
+	after := mustRead(t, out)
+	if bytes.Contains(after, []byte("+37.7749")) {
+		t.Fatal("the position survived")
+	}
+	md, err := ebml.Read(bytes.NewReader(after), int64(len(after)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range md.Fields {
+		if f.Name == "LOCATION" && f.Value != "" {
+			t.Errorf("LOCATION reads %q after redaction, want empty", f.Value)
+		}
+	}
+	if len(res.RedactionMap) != 1 || res.RedactionMap[0].Metadata["position_method"] != "video_coordinate_atom_scrubbed" {
+		t.Errorf("RedactionMap = %+v", res.RedactionMap)
+	}
+}
+
+func TestMatroskaValueInACompressedAttachmentIsRefused(t *testing.T) {
+	dir := t.TempDir()
+	// The value was reported from inside a deflated document, so its text is not in the bytes.
+	src := mkvWith(t, dir, [][]byte{mkvTag("TITLE", "clip")},
+		mkvAttachment("report.docx", "application/vnd.openxmlformats-officedocument.wordprocessingml.document", "PK\x03\x04 deflated"))
+
+	out, _, err := redact(t, src, []detector.Match{match("SSN", testSSN)})
+	if err == nil {
+		t.Fatal("a value that could not be located was reported as redacted")
+	}
+	if _, statErr := os.Stat(out); statErr == nil {
+		t.Error("an output file was written for a refused redaction")
+	}
+}
+
+func riffChunk(id string, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	out := append([]byte(id), binary.LittleEndian.AppendUint32(nil, uint32(len(body)))...)
+	out = append(out, body...)
+	if len(body)%2 == 1 {
+		out = append(out, 0)
+	}
+	return out
+}
+
+func TestAVIInfoAndStreamNamesAreOverwrittenInPlace(t *testing.T) {
+	file := riffChunk("RIFF", []byte("AVI "),
+		riffChunk("LIST", []byte("hdrl"), riffChunk("avih", make([]byte, 56)),
+			riffChunk("LIST", []byte("strl"), riffChunk("strh", make([]byte, 56)), riffChunk("strn", []byte(testName+"'s camera\x00")))),
+		riffChunk("LIST", []byte("INFO"), riffChunk("ICMT", []byte("SSN "+testSSN+"\x00")), riffChunk("ISFT", []byte("Lavf\x00"))),
+		riffChunk("LIST", []byte("movi"), riffChunk("00dc", frames)),
+		riffChunk("idx1", make([]byte, 16)))
+	src := filepath.Join(t.TempDir(), "camera.avi")
+	if err := os.WriteFile(src, file, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	out, res, err := redact(t, src, []detector.Match{match("SSN", testSSN), match("PERSON_NAME", testName)})
+	if err != nil {
+		t.Fatalf("RedactDocument: %v", err)
+	}
+	after := mustRead(t, out)
+	if len(after) != len(file) {
+		t.Fatalf("size changed from %d to %d", len(file), len(after))
+	}
+	for _, v := range []string{testSSN, testName} {
+		if bytes.Contains(after, []byte(v)) {
+			t.Errorf("a reported value is still in the redacted file")
+		}
+	}
+	if !bytes.Contains(after, frames) {
+		t.Error("the movi payload was modified")
+	}
+	md, err := riff.ReadAVI(bytes.NewReader(after), int64(len(after)))
+	if err != nil || len(md.Fields) != 3 {
+		t.Fatalf("the redacted file no longer walks: %v, %+v", err, md)
+	}
+	if len(res.RedactionMap) != 2 {
+		t.Errorf("RedactionMap has %d entries, want 2", len(res.RedactionMap))
+	}
+}
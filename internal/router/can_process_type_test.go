@@ -132,3 +132,28 @@ func TestCanProcessTypeTextNeedsNoPreprocessors(t *testing.T) {
 		}
 	}
 }
+
+// A Parquet or Avro file bounds its own memory, so the size gate passes it at any
+// size; with preprocessors disabled nothing would read it, and the gate refuses it.
+func TestColumnarFileIsExemptFromTheSizeGate(t *testing.T) {
+	big := filepath.Join(t.TempDir(), "events.parquet")
+	f, err := os.Create(big) // #nosec G304 -- test temp dir
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString("PAR1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Truncate(MaxFileSize + 1); err != nil {
+		t.Fatal(err)
+	}
+	_ = f.Close()
+
+	fr := newRouterForTest(t)
+	if ok, reason := CanProcessFile(fr, big, true); !ok {
+		t.Errorf("oversize Parquet refused: %s", reason)
+	}
+	if ok, _ := CanProcessFile(fr, big, false); ok {
+		t.Error("oversize Parquet accepted with preprocessors disabled")
+	}
+}
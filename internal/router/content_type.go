@@ -0,0 +1,140 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package router
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/awslabs/ferret-scan/v2/internal/filetype"
+	"github.com/awslabs/ferret-scan/v2/internal/preprocessors"
+)
+
+// contentPath returns the path the preprocessors read filePath through.
+//
+// That is filePath itself unless its content is in a format its name does not
+// route to: a Word document named .dat, a PDF saved as .txt, an RTF letter named
+// .doc. Every preprocessor, text extractor and the decryption step choose how to
+// read a file by its extension, so such a file was read as the wrong format or
+// refused. It is read instead through an alias named with its format's own
+// extension (filetype.Alias). release removes the alias; relabel puts filePath
+// back wherever the alias path reached the result.
+//
+// A file whose format is not identified, or whose name already routes to the
+// right reader (a .jpeg, a .tiff), is read by its name as before; so is every
+// file when the alias cannot be made.
+func (fr *FileRouter) contentPath(filePath string) (readPath string, release func()) {
+	format, err := filetype.Detect(filePath)
+	if err != nil || format == nil {
+		return filePath, func() {}
+	}
+	ext := strings.ToLower(filepath.Ext(filePath))
+	if !format.Contradicts(filePath) && readerFamily(ext) == readerFamily(format.Ext()) {
+		return filePath, func() {}
+	}
+
+	readPath, removeAlias, err := filetype.Alias(filePath, format.Ext())
+	if err != nil {
+		fr.logContentRouting(filePath, format, err)
+		return filePath, func() {}
+	}
+	fr.logContentRouting(filePath, format, nil)
+
+	// An embedded item read through an alias is as deep as the item: the alias
+	// is the parent path its own embedded items report.
+	if depth := fr.depthOf(filePath); depth > 0 {
+		fr.setDepth(readPath, depth)
+	}
+	return readPath, func() {
+		fr.clearDepth(readPath)
+		removeAlias()
+	}
+}
+
+func (fr *FileRouter) logContentRouting(filePath string, format *filetype.Format, err error) {
+	if fr.observer == nil || fr.observer.Debug() == nil {
+		return
+	}
+	if err != nil {
+		fr.observer.Debug().LogDetail("content_type_routing",
+			fmt.Sprintf("File: %s is a %s; reading it by its name, as no alias could be made: %v",
+				filepath.Base(filePath), format.Name, err))
+		return
+	}
+	fr.observer.Debug().LogDetail("content_type_routing",
+		fmt.Sprintf("File: %s is a %s; reading it as %s", filepath.Base(filePath), format.Name, format.Ext()))
+}
+
+// readerFamily names the preprocessors an extension routes to, or "" for one
+// no preprocessor claims by name.
+func readerFamily(ext string) string {
+	p := extProbe(ext)
+	switch {
+	case extValidator.IsPDFFile(p):
+		return "pdf"
+	case extValidator.IsOfficeFile(p):
+		return "office"
+	case extValidator.IsImageFile(p):
+		return "image"
+	case extValidator.IsAudioFile(p):
+		return "audio"
+	case extValidator.IsVideoFile(p):
+		return "video"
+	case extValidator.IsEmailFile(p):
+		return "email"
+	case extValidator.IsMarkupFile(p):
+		return "markup"
+	case extValidator.IsSQLiteFile(p):
+		return "sqlite"
+	case extValidator.IsColumnarFile(p):
+		return "columnar"
+	}
+	return ""
+}
+
+// relabel replaces the alias a file was read through with the file's own path
+// in what the preprocessors returned about the file: section sources, the paths
+// of embedded items ("alias -> image1.png"), warnings and metadata values. The
+// extracted text is left alone: it is the document's, and a name an extractor
+// wrote into it is neither a finding's location nor worth moving every offset
+// after it for.
+func relabel(content *preprocessors.ProcessedContent, readPath, filePath string) {
+	r := strings.NewReplacer(readPath, filePath, filepath.Base(readPath), filepath.Base(filePath))
+	replace := func(s string) string {
+		if !strings.Contains(s, filepath.Base(readPath)) {
+			return s
+		}
+		return r.Replace(s)
+	}
+	content.ExtractionWarning = replace(content.ExtractionWarning)
+	for i := range content.Sections {
+		content.Sections[i].SourceFile = replace(content.Sections[i].SourceFile)
+	}
+	for k, v := range content.Metadata {
+		content.Metadata[k] = relabelValue(v, replace)
+	}
+}
+
+// relabelValue applies replace to the strings in a metadata value, and in the
+// maps and lists it holds.
+func relabelValue(v interface{}, replace func(string) string) interface{} {
+	switch v := v.(type) {
+	case string:
+		return replace(v)
+	case []string:
+		for i := range v {
+			v[i] = replace(v[i])
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = relabelValue(v[i], replace)
+		}
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = relabelValue(e, replace)
+		}
+	}
+	return v
+}
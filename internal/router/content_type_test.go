@@ -0,0 +1,150 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package router
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/awslabs/ferret-scan/v2/internal/filetype"
+	"github.com/awslabs/ferret-scan/v2/internal/preprocessors"
+)
+
+// Every format content detection can name must be one a preprocessor reads by
+// the extension the router aliases it to, or the gate promises a file routing
+// then refuses (see TestGateAndRoutingAgree).
+func TestEveryDetectedFormatRoutesToAPreprocessor(t *testing.T) {
+	for _, f := range filetype.Formats {
+		if readerFamily(f.Ext()) == "" {
+			t.Errorf("%s is read as %s, which no preprocessor claims", f.Name, f.Ext())
+		}
+	}
+}
+
+// A file named for another format is read as what it is, and reported under
+// its own name: nothing of the alias it was read through reaches the result.
+func TestDisguisedFilesAreReadByContent(t *testing.T) {
+	dir := t.TempDir()
+	cases := []struct {
+		name, reason, want string
+		data               []byte
+	}{
+		{"minutes.dat", "Word document", "Contact: casey.morgan@example.com", buildDOCX(t, "Contact: casey.morgan@example.com", nil)},
+		{"notes.txt", "PDF document", "applicant_ssn: 536-22-1874", formPDF()},
+		{"export", "Word document", "Account owner: Riley Chen", buildDOCX(t, "Account owner: Riley Chen", nil)},
+	}
+	fr := newProductionRouter()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(dir, tc.name)
+			if err := os.WriteFile(path, tc.data, 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if ok, reason := fr.CanProcessFile(path, true); !ok || reason != tc.reason {
+				t.Errorf("CanProcessFile = %v, %q; want true, %q", ok, reason, tc.reason)
+			}
+			if !CanProcessType(path, true) {
+				t.Error("CanProcessType = false, want true")
+			}
+
+			pc, err := fr.ProcessFile(path, nil)
+			if err != nil {
+				t.Fatalf("ProcessFile: %v", err)
+			}
+			if !strings.Contains(pc.Text, tc.want) {
+				t.Errorf("text %q does not hold %q", pc.Text, tc.want)
+			}
+			if pc.OriginalPath != path || pc.Filename != tc.name {
+				t.Errorf("OriginalPath, Filename = %q, %q; want %q, %q", pc.OriginalPath, pc.Filename, path, tc.name)
+			}
+			for _, s := range pc.Sections {
+				// The file itself, or an item of it by the file's name.
+				if s.SourceFile != path && !strings.HasPrefix(s.SourceFile, tc.name+" -> ") {
+					t.Errorf("section %s: SourceFile = %q, want %q or an item of it", s.Name, s.SourceFile, path)
+				}
+			}
+			if strings.Contains(pc.ExtractionWarning+fmt.Sprint(pc.Metadata), "ferret-content-") {
+				t.Errorf("the alias path leaked into the result: %q %v", pc.ExtractionWarning, pc.Metadata)
+			}
+		})
+	}
+
+	leftovers, _ := filepath.Glob(filepath.Join(os.TempDir(), "ferret-content-*", "minutes.dat.docx"))
+	if len(leftovers) != 0 {
+		t.Errorf("aliases left behind: %v", leftovers)
+	}
+}
+
+// Without preprocessors a disguised binary is what it was before: not text,
+// so not processable.
+func TestDisguisedFileNeedsPreprocessors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "minutes.dat")
+	if err := os.WriteFile(path, buildDOCX(t, "body", nil), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if ok, reason := NewFileRouter(false).CanProcessFile(path, false); ok {
+		t.Errorf("CanProcessFile without preprocessors = true, %q", reason)
+	}
+}
+
+// A name that already routes to the right reader is read by that name, with
+// no alias.
+func TestMatchingNameIsReadInPlace(t *testing.T) {
+	fr := newProductionRouter()
+	for _, name := range []string{"photo.jpeg", "plan.docx", "notes.txt"} {
+		path := filepath.Join(t.TempDir(), name)
+		data := []byte("plain text\n")
+		switch filepath.Ext(name) {
+		case ".jpeg":
+			data = []byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x10, 'J', 'F', 'I', 'F', 0x00}
+		case ".docx":
+			data = buildDOCX(t, "body", nil)
+		}
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		readPath, release := fr.contentPath(path)
+		release()
+		if readPath != path {
+			t.Errorf("%s is read through %s", name, readPath)
+		}
+	}
+}
+
+// relabel puts the file's own name back where the alias reached what is said
+// about the file, and leaves the document's text as extracted: a name in the
+// text is the author's, and the offsets after it must not move.
+func TestRelabelLeavesTextAlone(t *testing.T) {
+	readPath, filePath := "/tmp/ferret-content-1/minutes.dat.docx", "/data/minutes.dat"
+	text := "see minutes.dat.docx for the draft"
+	pc := &preprocessors.ProcessedContent{
+		Text:              text,
+		ExtractionWarning: "no body in " + readPath,
+		Sections: []preprocessors.ContentSection{
+			{Name: "document_body", SourceFile: readPath, Text: text},
+			{Name: "embedded_media", SourceFile: "minutes.dat.docx -> image1.png"},
+		},
+		Metadata: map[string]interface{}{
+			"office_metadata_path":  readPath,
+			"office_metadata_items": []interface{}{"minutes.dat.docx -> image1.png"},
+		},
+	}
+	relabel(pc, readPath, filePath)
+
+	if pc.Text != text || pc.Sections[0].Text != text {
+		t.Errorf("text relabelled: %q, %q", pc.Text, pc.Sections[0].Text)
+	}
+	if pc.ExtractionWarning != "no body in "+filePath {
+		t.Errorf("ExtractionWarning = %q", pc.ExtractionWarning)
+	}
+	if pc.Sections[0].SourceFile != filePath || pc.Sections[1].SourceFile != "minutes.dat -> image1.png" {
+		t.Errorf("SourceFile = %q, %q", pc.Sections[0].SourceFile, pc.Sections[1].SourceFile)
+	}
+	if got := fmt.Sprint(pc.Metadata); strings.Contains(got, "ferret-content-") || strings.Contains(got, "dat.docx") {
+		t.Errorf("the alias is still in the metadata: %v", got)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"encoding/json"
 	"io"
 	"time"
+
+	"github.com/awslabs/ferret-scan/v2/internal/execguard"
 )
 
 // ProcessingContext provides standardized context to preprocessors
@@ -25,6 +27,12 @@ type ProcessingContext struct {
 	StartTime time.Time `json:"start_time"`
 	Debug     bool      `json:"debug"`
 
+	// LiveBytes is the run's shared --max-live-bytes budget, or nil when there
+	// is none. The router holds it against the file for the extractors that
+	// bound their own working set (see execguard.HoldLiveBytes), and passes it
+	// on to embedded files.
+	LiveBytes *execguard.BytesLimiter `json:"-"`
+
 	// Internal
 	metrics *RouterMetrics `json:"-"`
 	logger  *DebugLogger   `json:"-"`
@@ -0,0 +1,95 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package router
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// An mbox's messages are labelled by position, and an attachment is routed back
+// through the router and labelled under its message. The base64 attachment is
+// only readable once decoded, so finding its content proves the route.
+func TestEmailSectionsAreAttributedPerMessage(t *testing.T) {
+	mbox := "From jane@example.com Mon Jan  1 00:00:00 2024\n" +
+		"From: Jane <jane@example.com>\n" +
+		"Subject: hello\n" +
+		"\n" +
+		"Nothing to see.\n" +
+		"\n" +
+		"From bob@example.com Mon Jan  1 00:00:01 2024\n" +
+		"From: Bob <bob@example.com>\n" +
+		"Subject: invoice\n" +
+		"MIME-Version: 1.0\n" +
+		"Content-Type: multipart/mixed; boundary=b\n" +
+		"\n" +
+		"--b\n" +
+		"Content-Type: text/plain\n" +
+		"\n" +
+		"Card 4111 1111 1111 1111 attached.\n" +
+		"--b\n" +
+		"Content-Type: text/plain\n" +
+		"Content-Disposition: attachment; filename=invoice.txt\n" +
+		"Content-Transfer-Encoding: base64\n" +
+		"\n" +
+		"U1NOIDUzNi0yMi0xODc0Cg==\n" +
+		"--b--\n"
+	path := filepath.Join(t.TempDir(), "inbox.mbox")
+	if err := os.WriteFile(path, []byte(mbox), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	fr := NewFileRouter(false)
+	RegisterDefaultPreprocessors(fr)
+	fr.InitializePreprocessors(CreateRouterConfig(false))
+
+	pc, err := fr.ProcessFile(path, nil)
+	if err != nil {
+		t.Fatalf("ProcessFile: %v", err)
+	}
+	if strings.Contains(pc.Text, "U1NOIDUzNi0yMi0xODc0") {
+		t.Error("the attachment's transfer encoding was scanned as text")
+	}
+
+	lines := strings.Split(pc.Text, "\n")
+	want := map[string]string{
+		"inbox.mbox -> msg 1":                "Nothing to see.",
+		"inbox.mbox -> msg 2":                "Card 4111 1111 1111 1111",
+		"inbox.mbox -> msg 2 -> invoice.txt": "SSN 536-22-1874",
+	}
+	for _, s := range pc.Sections {
+		wantText, ok := want[s.SourceFile]
+		if !ok || !strings.Contains(s.Text, wantText) {
+			continue
+		}
+		if !s.AttributeBody {
+			t.Errorf("%s: section does not attribute document-path findings", s.SourceFile)
+		}
+		if got := strings.Join(lines[s.LineOffset:s.LineOffset+strings.Count(s.Text, "\n")], "\n"); !strings.Contains(got, wantText) {
+			t.Errorf("%s: LineOffset %d points at %q", s.SourceFile, s.LineOffset, got)
+		}
+		delete(want, s.SourceFile)
+	}
+	for source, text := range want {
+		t.Errorf("no section for %s holding %q", source, text)
+	}
+}
+
+// An Outlook message is a compound file, not text, and used to be refused at
+// the gate.
+func TestEmailMSGPassesTheGate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "message.msg")
+	if err := os.WriteFile(path, []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1, 0, 0}, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	fr := NewFileRouter(false)
+	if ok, reason := fr.CanProcessFile(path, true); !ok {
+		t.Errorf("CanProcessFile = false (%s), want true", reason)
+	}
+	if ok, _ := fr.CanProcessFile(path, false); ok {
+		t.Error("CanProcessFile admitted a binary .msg with preprocessors disabled")
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package router
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+
+	"github.com/awslabs/ferret-scan/v2/internal/encryption"
+	"github.com/awslabs/ferret-scan/v2/internal/preprocessors"
+)
+
+func defaultRouter() *FileRouter {
+	fr := NewFileRouter(false)
+	RegisterDefaultPreprocessors(fr)
+	fr.InitializePreprocessors(CreateRouterConfig(false))
+	return fr
+}
+
+// encryptedFormPDF is formPDF encrypted under a user password.
+func encryptedFormPDF(t *testing.T, userPW string) string {
+	t.Helper()
+	conf := model.NewDefaultConfiguration()
+	conf.UserPW = userPW
+	conf.OwnerPW = userPW + "-owner"
+	conf.Cmd = model.ENCRYPT
+	// Encrypted without api.Encrypt's validation, which rejects formPDF's field
+	// for lacking /DA; decryption has to cope with documents like it.
+	ctx, err := api.ReadContext(bytes.NewReader(formPDF()), conf)
+	if err != nil {
+		t.Fatalf("reading fixture: %v", err)
+	}
+	var out bytes.Buffer
+	if err := api.WriteContext(ctx, &out); err != nil {
+		t.Fatalf("encrypting fixture: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "form.pdf")
+	if err := os.WriteFile(path, out.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// Every extractor reads the decrypted document: the form field value comes from
+// the structure extractor, which parses the file independently of the text
+// extractor. The attachment is declined and disclosed, because routing it would
+// mean writing decrypted bytes to a temporary file.
+func TestEncryptedPDFIsScannedFromMemory(t *testing.T) {
+	path := encryptedFormPDF(t, "form-pw")
+	passwords, err := encryption.NewPasswords([]encryption.Rule{{Path: "*.pdf", Password: "form-pw"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fr := defaultRouter()
+	fr.SetPasswords(passwords)
+
+	pc, err := fr.ProcessFile(path, nil)
+	if err != nil {
+		t.Fatalf("ProcessFile: %v", err)
+	}
+	if !strings.Contains(pc.Text, "536-22-1874") || !strings.Contains(pc.Text, "Reviewed by Jane") {
+		t.Errorf("decrypted form field and annotation missing from the text:\n%s", pc.Text)
+	}
+	if strings.Contains(pc.Text, "412-68-3321") {
+		t.Error("the attachment of a decrypted document was extracted and routed")
+	}
+	if !strings.Contains(pc.ExtractionWarning, `embedded file "data.csv" was not examined`) {
+		t.Errorf("ExtractionWarning = %q, want the declined attachment disclosed", pc.ExtractionWarning)
+	}
+	if _, held := encryption.Plaintext(path); held {
+		t.Error("the plaintext is still held after the file finished")
+	}
+}
+
+func TestLockedDocumentFailsWithErrEncrypted(t *testing.T) {
+	path := encryptedFormPDF(t, "form-pw")
+
+	_, err := defaultRouter().ProcessFile(path, nil)
+	if !errors.Is(err, preprocessors.ErrEncrypted) {
+		t.Fatalf("err = %v, want ErrEncrypted so the file is reported as not examined", err)
+	}
+}
+
+// The Office extractors open the zip from the held plaintext: the file on disk
+// is the encrypted wrapper and is not a zip at all. The wrapper is stood in for
+// here by bytes Unlock does not recognise, so the test exercises the extractors'
+// side of the contract without an encryptor.
+func TestHeldOfficePlaintextIsReadAndItsPartsDeclined(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, body := range map[string]string{
+		"word/document.xml": `<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">` +
+			`<w:body><w:p><w:r><w:t>SSN 536-22-1874</w:t></w:r></w:p></w:body></w:document>`,
+		"word/embeddings/notes.txt": "Jane 412-68-3321",
+	} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(body))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "locked.docx")
+	if err := os.WriteFile(path, []byte("stand-in for the encrypted wrapper"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	release := encryption.Hold(path, buf.Bytes())
+	defer release()
+
+	pc, err := defaultRouter().ProcessFile(path, nil)
+	if err != nil {
+		t.Fatalf("ProcessFile: %v", err)
+	}
+	if !strings.Contains(pc.Text, "536-22-1874") {
+		t.Errorf("body text of the held document missing:\n%s", pc.Text)
+	}
+	if strings.Contains(pc.Text, "412-68-3321") {
+		t.Error("an embedded part of a decrypted document was extracted and routed")
+	}
+	if !strings.Contains(pc.ExtractionWarning, `embedded part "notes.txt" was not examined`) {
+		t.Errorf("ExtractionWarning = %q, want the declined part disclosed", pc.ExtractionWarning)
+	}
+}
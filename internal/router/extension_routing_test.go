@@ -29,7 +29,7 @@ func TestExtensionRouting_MatchesPreprocessorCapability(t *testing.T) {
 		".docx", ".doc", ".xlsx", ".xls", ".pptx", ".ppt", ".odt", ".ods", ".odp",
 		".pdf",
 		".jpg", ".jpeg", ".png", ".gif", ".tiff", ".tif", ".bmp", ".webp",
-		".heic", ".heif", ".raw", ".cr2", ".nef", ".arw",
+		".heic", ".heif", ".avif", ".raw", ".cr2", ".nef", ".arw",
 		".mp4", ".mov", ".avi", ".mkv", ".wmv", ".flv", ".webm", ".m4v", ".3gp", ".ogv",
 		".mp3", ".flac", ".wav", ".ogg", ".m4a", ".aac", ".wma", ".opus",
 	}
@@ -75,8 +75,15 @@ func TestExtensionRouting_SupportedTypesUnchanged(t *testing.T) {
 		".jpg": "image_metadata", ".jpeg": "image_metadata", ".png": "image_metadata",
 		".gif": "image_metadata", ".tiff": "image_metadata", ".tif": "image_metadata",
 		".bmp": "image_metadata", ".webp": "image_metadata",
+		// HEIF and AVIF, read through their meta item tables.
+		".heic": "image_metadata", ".heif": "image_metadata", ".avif": "image_metadata",
 		".mp4": "video_metadata", ".mov": "video_metadata", ".m4v": "video_metadata",
+		// Matroska, WebM and AVI, read through their own tag walkers.
+		".mkv": "video_metadata", ".webm": "video_metadata", ".avi": "video_metadata",
 		".mp3": "audio_metadata", ".flac": "audio_metadata", ".wav": "audio_metadata", ".m4a": "audio_metadata",
+		// Ogg Vorbis and Opus through their comment header, AIFF through its text chunks.
+		".ogg": "audio_metadata", ".oga": "audio_metadata", ".opus": "audio_metadata",
+		".aiff": "audio_metadata", ".aif": "audio_metadata", ".aifc": "audio_metadata",
 	}
 	for ext, mt := range want {
 		if !isBinaryDocument(ext) {
@@ -97,9 +104,9 @@ func TestExtensionRouting_UnsupportedTypesSkipped(t *testing.T) {
 		// them. They are now read by the legacy OLE extractor, so they belong in
 		// the supported set above — this test's contract is "handled by no
 		// preprocessor", not "legacy".
-		".heic", ".heif", ".raw", ".cr2", ".nef", ".arw",
-		".avi", ".mkv", ".wmv", ".flv", ".webm", ".3gp", ".ogv",
-		".ogg", ".aac", ".wma", ".opus",
+		".raw", ".cr2", ".nef", ".arw",
+		".wmv", ".flv", ".3gp", ".ogv",
+		".aac", ".wma",
 	}
 	for _, ext := range skipped {
 		if isBinaryDocument(ext) {
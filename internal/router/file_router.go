@@ -16,6 +16,9 @@ import (
 	"time"
 
 	"github.com/awslabs/ferret-scan/v2/internal/embedded"
+	"github.com/awslabs/ferret-scan/v2/internal/encryption"
+	"github.com/awslabs/ferret-scan/v2/internal/execguard"
+	"github.com/awslabs/ferret-scan/v2/internal/filetype"
 	"github.com/awslabs/ferret-scan/v2/internal/observability"
 	"github.com/awslabs/ferret-scan/v2/internal/preprocessors"
 )
@@ -42,6 +45,10 @@ type FileRouter struct {
 	// currently being processed rather than growing across a directory scan.
 	embeddedDepthMu sync.Mutex
 	embeddedDepth   map[string]int
+
+	// passwords supplies candidate passwords for encrypted documents; nil means
+	// none were configured, and only the empty PDF user password is tried.
+	passwords *encryption.Passwords
 }
 
 // MaxEmbeddedDepth bounds how deep the router follows containers inside containers.
@@ -68,6 +75,16 @@ const MaxEmbeddedDepth = embedded.MaxDepth
 // MaxFileSize is the default maximum file size the router will process (100 MB).
 const MaxFileSize = int64(100 * 1024 * 1024)
 
+// SizeExempt reports whether a file is read within a memory bound of its own,
+// and so is not held to MaxFileSize. Parquet and Avro files are read a page or
+// a block at a time and their text stops at the columnar extractor's limit;
+// the rows past it are disclosed as not scanned, which reports a data-lake
+// export larger than MaxFileSize as incomplete coverage instead of refusing it
+// unread. Discovery and the router both ask, so the two gates cannot drift.
+func SizeExempt(filePath string, enablePreprocessors bool) bool {
+	return enablePreprocessors && preprocessors.IsColumnarFile(filePath)
+}
+
 // NewFileRouter creates a new file router
 func NewFileRouter(debug bool) *FileRouter {
 	level := observability.ObservabilityMetrics
@@ -93,6 +110,13 @@ func (fr *FileRouter) InitializePreprocessors(config map[string]interface{}) {
 	fr.preprocessors = fr.registry.CreateAll(config)
 }
 
+// SetPasswords configures the passwords tried on encrypted documents (see
+// --password-file). Set it before processing begins; it is read, unlocked, by
+// every worker.
+func (fr *FileRouter) SetPasswords(p *encryption.Passwords) {
+	fr.passwords = p
+}
+
 // ReasonUnreadable prefixes the reason returned when a file exists but cannot be
 // opened or stat'ed — a permission error, a broken symlink, a vanished file.
 //
@@ -145,7 +169,7 @@ func (fr *FileRouter) CanProcessFile(filePath string, enablePreprocessors bool)
 		return false, fmt.Sprintf("%s: not a regular file (%s)", ReasonUnreadable, describeFileMode(info.Mode()))
 	}
 
-	if info.Size() > MaxFileSize {
+	if info.Size() > MaxFileSize && !SizeExempt(filePath, enablePreprocessors) {
 		return false, fmt.Sprintf("File too large (max: %dMB)", MaxFileSize/(1024*1024))
 	}
 
@@ -157,6 +181,35 @@ func (fr *FileRouter) CanProcessFile(filePath string, enablePreprocessors bool)
 		return false, "Binary document (requires preprocessors)"
 	}
 
+	// An Outlook .msg is a compound file and fails the text sniff below, though
+	// the email preprocessor reads it. .eml and .mbox are text and pass the sniff
+	// either way, so they stay scannable with preprocessors disabled.
+	if isEmailFile(ext) && enablePreprocessors {
+		return true, "Email message"
+	}
+
+	// An SQLite database is binary and fails the text sniff as well. ".db" names
+	// many formats, so the header decides rather than the name.
+	if enablePreprocessors && preprocessors.IsSQLiteDatabase(filePath) {
+		return true, "SQLite database"
+	}
+
+	// Parquet and Avro are binary as well, and the magic decides as it does for
+	// SQLite, so a text file named .parquet is still read as text.
+	if enablePreprocessors && preprocessors.IsColumnarFile(filePath) {
+		return true, "Columnar data file"
+	}
+
+	// Any other format a preprocessor reads is recognised by its content, so a
+	// Word document named .dat or with no extension at all is read as one (see
+	// contentPath). Before the text sniff, which an RTF document passes: it is
+	// read by the markup preprocessor, not as raw control words.
+	if enablePreprocessors {
+		if format, _ := filetype.Detect(filePath); format != nil {
+			return true, format.Name
+		}
+	}
+
 	// Check if it's a text file. Distinguish "read it, it is not text" from "could
 	// not read it": the old condition (err == nil && isText) collapsed both into
 	// the unsupported-type reason below, so a permission-denied .txt was reported
@@ -203,6 +256,20 @@ func CanProcessType(filePath string, enablePreprocessors bool) bool {
 	if isBinaryDocument(ext) {
 		return enablePreprocessors
 	}
+	if isEmailFile(ext) && enablePreprocessors {
+		return true
+	}
+	if enablePreprocessors && preprocessors.IsSQLiteDatabase(filePath) {
+		return true
+	}
+	if enablePreprocessors && preprocessors.IsColumnarFile(filePath) {
+		return true
+	}
+	if enablePreprocessors {
+		if format, _ := filetype.Detect(filePath); format != nil {
+			return true
+		}
+	}
 
 	// Anything else is processable only if it sniffs as text. An unreadable file is
 	// reported as not-processable here: the caller is deciding whether to mention a
@@ -269,7 +336,9 @@ func (fr *FileRouter) ProcessEmbedded(childPath, parentPath string) (*preprocess
 	// Removed when this child finishes, so the map tracks only in-flight files.
 	defer fr.clearDepth(childPath)
 
-	return fr.ProcessFile(childPath, nil)
+	// The child is read within its parent's job, so it charges the parent's
+	// live-bytes budget.
+	return fr.ProcessFile(childPath, &ProcessingContext{FilePath: childPath, LiveBytes: execguard.LiveBytesFor(parentPath)})
 }
 
 // depthOf reports how deep a path sits inside containers. Absent = top level.
@@ -340,10 +409,14 @@ func (fr *FileRouter) processFileInternal(filePath string, config *ProcessingCon
 		}, nil
 	}
 
+	// A file whose content is not what its name says is read as what it is.
+	readPath, release := fr.contentPath(filePath)
+	defer release()
+
 	// Find capable preprocessors
 	var capable []preprocessors.Preprocessor
 	for _, p := range fr.preprocessors {
-		if p.CanProcess(filePath) {
+		if p.CanProcess(readPath) {
 			capable = append(capable, p)
 		}
 	}
@@ -352,6 +425,26 @@ func (fr *FileRouter) processFileInternal(filePath string, config *ProcessingCon
 		return nil, fmt.Errorf("no preprocessor can handle file: %s", filePath)
 	}
 
+	// An encrypted document is decrypted ONCE, here, rather than by each
+	// preprocessor: the text, metadata and structure extractors all read the same
+	// file concurrently, and each running the key derivation (100,000 SHA-512
+	// rounds per candidate password for Office) would multiply the cost by the
+	// number of extractors. The plaintext is held in memory for the duration of
+	// this call and the extractors read it from there; it is never written out.
+	//
+	// A document that stays locked fails the whole file with an error wrapping
+	// encryption.ErrEncrypted. Letting the extractors run on it instead would
+	// report ciphertext as scanned, or at best a vague extraction failure, and an
+	// encrypted document is the one most likely to hold what the scan is for.
+	plaintext, err := encryption.Unlock(readPath, fr.passwords.For(filePath))
+	if err != nil {
+		return nil, err
+	}
+	if plaintext != nil {
+		defer encryption.Hold(readPath, plaintext)()
+	}
+	defer execguard.HoldLiveBytes(readPath, config.LiveBytes)()
+
 	// Sort by name so the assembly order below is a property of the file type,
 	// not of how the registry happened to be iterated. For Office and PDF files
 	// this puts "Text Extractor" ahead of "office_metadata"/"pdf_metadata", so
@@ -386,7 +479,7 @@ func (fr *FileRouter) processFileInternal(filePath string, config *ProcessingCon
 						err = fmt.Errorf("preprocessor panic in %s: %v", processor.GetName(), r)
 					}
 				}()
-				result, err = processor.Process(filePath)
+				result, err = processor.Process(readPath)
 			}()
 
 			processingTime := time.Since(processStart)
@@ -573,6 +666,9 @@ func (fr *FileRouter) processFileInternal(filePath string, config *ProcessingCon
 			// re-parsing the text for separators.
 			Sections: sections,
 		}
+		if readPath != filePath {
+			relabel(result, readPath, filePath)
+		}
 
 		return result, nil
 	}
@@ -673,6 +769,13 @@ func isBinaryDocument(ext string) bool {
 		extValidator.IsAudioFile(p)
 }
 
+// isEmailFile reports whether ext is a message or mailbox the email
+// preprocessor reads. Not a binary document: .eml and .mbox are text, and the
+// metadata routing below has no email type.
+func isEmailFile(ext string) bool {
+	return extValidator.IsEmailFile(extProbe(ext))
+}
+
 // isMetadataCapableFile determines if a file extension indicates metadata capability
 // This reuses the existing isBinaryDocument logic as these files can contain metadata
 func isMetadataCapableFile(ext string) bool {
@@ -44,6 +44,83 @@ func RegisterDefaultPreprocessors(router *FileRouter) {
 		return processor
 	})
 
+	// PDF structure preprocessor factory (form fields, annotations, bookmarks
+	// and embedded files of a PDF)
+	router.RegisterPreprocessor("pdf_structure", func(config map[string]interface{}) preprocessors.Preprocessor {
+		processor := preprocessors.NewPDFStructurePreprocessor()
+		processor.SetRouter(router)
+		// Set observer for debug logging
+		if router.observer != nil {
+			processor.SetObserver(router.observer)
+		}
+		return processor
+	})
+
+	// Email preprocessor factory (headers, bodies and attachments of .eml,
+	// .mbox and .msg messages)
+	router.RegisterPreprocessor("email", func(config map[string]interface{}) preprocessors.Preprocessor {
+		processor := preprocessors.NewEmailPreprocessor()
+		processor.SetRouter(router)
+		// Set observer for debug logging
+		if router.observer != nil {
+			processor.SetObserver(router.observer)
+		}
+		return processor
+	})
+
+	// Markup preprocessor factory (decoded text of HTML, XML and RTF documents)
+	router.RegisterPreprocessor("markup", func(config map[string]interface{}) preprocessors.Preprocessor {
+		processor := preprocessors.NewMarkupPreprocessor()
+		// Set observer for debug logging
+		if router.observer != nil {
+			processor.SetObserver(router.observer)
+		}
+		return processor
+	})
+
+	// SQLite preprocessor factory (tables and rows of SQLite databases)
+	router.RegisterPreprocessor("sqlite", func(config map[string]interface{}) preprocessors.Preprocessor {
+		processor := preprocessors.NewSQLitePreprocessor()
+		// Set observer for debug logging
+		if router.observer != nil {
+			processor.SetObserver(router.observer)
+		}
+		return processor
+	})
+
+	// Notebook preprocessor factory (cells and text outputs of Jupyter notebooks)
+	router.RegisterPreprocessor("notebook", func(config map[string]interface{}) preprocessors.Preprocessor {
+		processor := preprocessors.NewNotebookPreprocessor()
+		// Set observer for debug logging
+		if router.observer != nil {
+			processor.SetObserver(router.observer)
+		}
+		return processor
+	})
+
+	// Barcode preprocessor factory (QR, Data Matrix, Aztec and PDF417 symbols in images)
+	router.RegisterPreprocessor("barcode", func(config map[string]interface{}) preprocessors.Preprocessor {
+		processor := preprocessors.NewBarcodePreprocessor()
+		// Set observer for debug logging
+		if router.observer != nil {
+			processor.SetObserver(router.observer)
+		}
+		return processor
+	})
+
+	// Columnar preprocessor factory (rows of Parquet and Avro files)
+	router.RegisterPreprocessor("columnar", func(config map[string]interface{}) preprocessors.Preprocessor {
+		processor := preprocessors.NewColumnarPreprocessor()
+		if val, ok := config["sample_rows"].(int64); ok {
+			processor.SetSampleRows(val)
+		}
+		// Set observer for debug logging
+		if router.observer != nil {
+			processor.SetObserver(router.observer)
+		}
+		return processor
+	})
+
 	// Office metadata preprocessor factory (for Office document metadata)
 	router.RegisterPreprocessor("office_metadata", func(config map[string]interface{}) preprocessors.Preprocessor {
 		processor := preprocessors.NewOfficeMetadataPreprocessor()
@@ -0,0 +1,35 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package router
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// An HTML file is scanned as its decoded text only. The plain-text preprocessor
+// used to claim it too, and its raw copy held the value only as markup.
+func TestMarkupIsScannedDecoded(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "page.html")
+	if err := os.WriteFile(path, []byte(`<p>SSN 536-<b>22</b>-&#49;874</p>`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	fr := NewFileRouter(false)
+	RegisterDefaultPreprocessors(fr)
+	fr.InitializePreprocessors(CreateRouterConfig(false))
+
+	pc, err := fr.ProcessFile(path, nil)
+	if err != nil {
+		t.Fatalf("ProcessFile: %v", err)
+	}
+	if !strings.Contains(pc.Text, "SSN 536-22-1874") {
+		t.Errorf("decoded value missing from %q", pc.Text)
+	}
+	if strings.Contains(pc.Text, "<b>") {
+		t.Errorf("raw markup scanned alongside the decoded text: %q", pc.Text)
+	}
+}
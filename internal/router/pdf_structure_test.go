@@ -0,0 +1,95 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package router
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/awslabs/ferret-scan/v2/internal/preprocessors"
+)
+
+// formPDF is a PDF whose only content is a filled-in form field, a sticky note
+// on page 1 and an attached CSV: nothing the text extractor reads.
+func formPDF() []byte {
+	csv := "name,ssn\nJane,412-68-3321\n"
+	objs := []string{
+		"<< /Type /Catalog /Pages 2 0 R /AcroForm << /Fields [4 0 R] >> " +
+			"/Names << /EmbeddedFiles << /Names [(data.csv) 6 0 R] >> >> >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Annots [5 0 R] >>",
+		"<< /T (applicant_ssn) /FT /Tx /V (536-22-1874) >>",
+		"<< /Type /Annot /Subtype /Text /Rect [0 0 10 10] /Contents (Reviewed by Jane) >>",
+		"<< /Type /Filespec /F (data.csv) /EF << /F 7 0 R >> >>",
+		fmt.Sprintf("<< /Type /EmbeddedFile /Length %d >>\nstream\n%s\nendstream", len(csv), csv),
+	}
+	var sb strings.Builder
+	sb.WriteString("%PDF-1.7\n")
+	offsets := make([]int, len(objs))
+	for i, o := range objs {
+		offsets[i] = sb.Len()
+		fmt.Fprintf(&sb, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	xref := sb.Len()
+	fmt.Fprintf(&sb, "xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&sb, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&sb, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objs)+1, xref)
+	return []byte(sb.String())
+}
+
+// Each form field, annotation and attachment arrives as its own body section,
+// labelled with the item it came from and marked to carry that label onto
+// document-path findings. The attachment is routed back through the router, so
+// its own preprocessor's text is what is scanned.
+func TestPDFStructureSectionsAreAttributedPerItem(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "form.pdf")
+	if err := os.WriteFile(path, formPDF(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	fr := NewFileRouter(false)
+	RegisterDefaultPreprocessors(fr)
+	fr.InitializePreprocessors(CreateRouterConfig(false))
+
+	pc, err := fr.ProcessFile(path, nil)
+	if err != nil {
+		t.Fatalf("ProcessFile: %v", err)
+	}
+
+	lines := strings.Split(pc.Text, "\n")
+	want := map[string]string{
+		"form.pdf -> field:applicant_ssn": "applicant_ssn: 536-22-1874",
+		"form.pdf -> annotation:page1":    "Reviewed by Jane",
+		"form.pdf -> data.csv":            "Jane,412-68-3321",
+	}
+	for _, s := range pc.Sections {
+		if !s.AttributeBody {
+			continue
+		}
+		wantText, ok := want[s.SourceFile]
+		if !ok {
+			continue
+		}
+		if !strings.Contains(s.Text, wantText) {
+			t.Errorf("%s: section text %q does not hold %q", s.SourceFile, s.Text, wantText)
+		}
+		// The section must point at its own bytes in the combined text.
+		if got := strings.Join(lines[s.LineOffset:s.LineOffset+strings.Count(s.Text, "\n")], "\n"); !strings.Contains(got, wantText) {
+			t.Errorf("%s: LineOffset %d points at %q", s.SourceFile, s.LineOffset, got)
+		}
+		if s.SourceFile == "form.pdf -> annotation:page1" &&
+			(len(s.Pages) != 1 || s.Pages[0] != (preprocessors.PageSpan{Page: 1})) {
+			t.Errorf("annotation section pages = %v, want page 1", s.Pages)
+		}
+		delete(want, s.SourceFile)
+	}
+	for source := range want {
+		t.Errorf("no attributed section for %s", source)
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package scanresult
+
+import "sort"
+
+// Comparison is the difference between two results, base (the earlier scan)
+// and head (the later one).
+//
+// Findings are paired first by suppression identity, the same hash a
+// suppression rule records, so a finding a rule written against the base scan
+// would still suppress is unchanged. That identity includes the finding's line
+// and line number, so a finding moved by lines inserted above it is then
+// paired by its line-free identity: the same type and value, with the same
+// context, in a file of the same name. A new value on a nearby line differs in
+// value or context and is not paired with an old one.
+type Comparison struct {
+	// New are head findings with no counterpart in base.
+	New []Finding `json:"new"`
+	// Fixed are base findings with no counterpart in head.
+	Fixed []Finding `json:"fixed"`
+	// Unchanged are head findings whose counterpart is in the same confidence band.
+	Unchanged []Finding `json:"unchanged"`
+	// BandChanged are paired findings whose confidence band moved.
+	BandChanged []BandChange `json:"band_changed"`
+}
+
+// BandChange is one finding present in both results whose confidence band
+// differs between them. Finding is the head side.
+type BandChange struct {
+	Finding
+	FromLevel      string  `json:"from_confidence_level"`
+	FromConfidence float64 `json:"from_confidence"`
+}
+
+// Raised reports whether the finding moved to a more severe band.
+func (c BandChange) Raised() bool {
+	return levelRank(c.ConfidenceLevel) > levelRank(c.FromLevel)
+}
+
+func levelRank(level string) int {
+	switch level {
+	case "HIGH":
+		return 3
+	case "MEDIUM":
+		return 2
+	case "LOW":
+		return 1
+	}
+	return 0
+}
+
+// Compare pairs the findings of base and head by identity, then pairs what is
+// left by line-free identity.
+//
+// Identities are treated as a multiset: a result can legitimately carry two
+// findings with one identity (two validators reporting the same value), and
+// three occurrences in base against two in head is one fixed finding, not
+// none. Each list is sorted by file, line and type so the report is stable.
+func Compare(base, head *Result) *Comparison {
+	pending := make(map[string][]Finding, len(base.Findings))
+	for _, f := range base.Findings {
+		pending[f.Hash] = append(pending[f.Hash], f)
+	}
+
+	c := &Comparison{New: []Finding{}, Fixed: []Finding{}, Unchanged: []Finding{}, BandChanged: []BandChange{}}
+	var unpaired []Finding
+	for _, f := range head.Findings {
+		olds := pending[f.Hash]
+		if len(olds) == 0 {
+			unpaired = append(unpaired, f)
+			continue
+		}
+		pending[f.Hash] = olds[1:]
+		c.pair(olds[0], f)
+	}
+
+	// What the first pass left in base, by line-free identity. A result that
+	// predates the line-free identity leaves it empty, and those findings stay
+	// fixed and new as before.
+	moved := map[string][]Finding{}
+	var fixed []Finding
+	for _, f := range base.Findings {
+		if olds := pending[f.Hash]; len(olds) > 0 {
+			old := olds[0]
+			pending[f.Hash] = olds[1:]
+			if old.LineFree == "" {
+				fixed = append(fixed, old)
+				continue
+			}
+			moved[old.LineFree] = append(moved[old.LineFree], old)
+		}
+	}
+	for _, f := range unpaired {
+		olds := moved[f.LineFree]
+		if f.LineFree == "" || len(olds) == 0 {
+			c.New = append(c.New, f)
+			continue
+		}
+		moved[f.LineFree] = olds[1:]
+		c.pair(olds[0], f)
+	}
+	for _, f := range base.Findings {
+		if olds := moved[f.LineFree]; f.LineFree != "" && len(olds) > 0 {
+			fixed = append(fixed, olds[0])
+			moved[f.LineFree] = olds[1:]
+		}
+	}
+	c.Fixed = append(c.Fixed, fixed...)
+
+	sortFindings(c.New)
+	sortFindings(c.Fixed)
+	sortFindings(c.Unchanged)
+	sort.SliceStable(c.BandChanged, func(i, j int) bool {
+		return lessFinding(c.BandChanged[i].Finding, c.BandChanged[j].Finding)
+	})
+	return c
+}
+
+// pair records head finding f as the counterpart of base finding old.
+func (c *Comparison) pair(old, f Finding) {
+	if old.ConfidenceLevel != f.ConfidenceLevel {
+		c.BandChanged = append(c.BandChanged, BandChange{Finding: f, FromLevel: old.ConfidenceLevel, FromConfidence: old.Confidence})
+		return
+	}
+	c.Unchanged = append(c.Unchanged, f)
+}
+
+func sortFindings(findings []Finding) {
+	sort.SliceStable(findings, func(i, j int) bool { return lessFinding(findings[i], findings[j]) })
+}
+
+func lessFinding(a, b Finding) bool {
+	if a.Filename != b.Filename {
+		return a.Filename < b.Filename
+	}
+	if a.LineNumber != b.LineNumber {
+		return a.LineNumber < b.LineNumber
+	}
+	if a.Type != b.Type {
+		return a.Type < b.Type
+	}
+	return a.Hash < b.Hash
+}
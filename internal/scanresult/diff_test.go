@@ -0,0 +1,149 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package scanresult
+
+import (
+	"testing"
+
+	"github.com/awslabs/ferret-scan/v2/internal/detector"
+	"github.com/awslabs/ferret-scan/v2/internal/formatters"
+	jsonfmt "github.com/awslabs/ferret-scan/v2/internal/formatters/json"
+	"github.com/awslabs/ferret-scan/v2/internal/formatters/sarif"
+)
+
+func finding(hash, level string, line int) Finding {
+	return Finding{Hash: hash, Type: "SSN", Filename: "a.txt", LineNumber: line, ConfidenceLevel: level}
+}
+
+func hashes(findings []Finding) []string {
+	out := make([]string, 0, len(findings))
+	for _, f := range findings {
+		out = append(out, f.Hash)
+	}
+	return out
+}
+
+func TestCompare(t *testing.T) {
+	base := &Result{Findings: []Finding{
+		finding("kept", "HIGH", 1),
+		finding("gone", "HIGH", 2),
+		finding("rose", "LOW", 3),
+		finding("fell", "HIGH", 4),
+	}}
+	head := &Result{Findings: []Finding{
+		finding("fresh", "MEDIUM", 9),
+		finding("fell", "MEDIUM", 4),
+		finding("kept", "HIGH", 1),
+		finding("rose", "HIGH", 3),
+	}}
+
+	c := Compare(base, head)
+	if got := hashes(c.New); len(got) != 1 || got[0] != "fresh" {
+		t.Errorf("new = %v", got)
+	}
+	if got := hashes(c.Fixed); len(got) != 1 || got[0] != "gone" {
+		t.Errorf("fixed = %v", got)
+	}
+	if got := hashes(c.Unchanged); len(got) != 1 || got[0] != "kept" {
+		t.Errorf("unchanged = %v", got)
+	}
+	if len(c.BandChanged) != 2 {
+		t.Fatalf("band changed = %+v", c.BandChanged)
+	}
+	// Sorted by line: "rose" (3) before "fell" (4).
+	if rose := c.BandChanged[0]; rose.Hash != "rose" || rose.FromLevel != "LOW" || !rose.Raised() {
+		t.Errorf("rose = %+v", rose)
+	}
+	if fell := c.BandChanged[1]; fell.Hash != "fell" || fell.Raised() {
+		t.Errorf("fell = %+v", fell)
+	}
+}
+
+// TestCompare_DuplicateIdentities treats identities as a multiset: three
+// occurrences against two is one fixed finding, not zero.
+func TestCompare_DuplicateIdentities(t *testing.T) {
+	base := &Result{Findings: []Finding{finding("dup", "HIGH", 1), finding("dup", "HIGH", 1), finding("dup", "HIGH", 1)}}
+	head := &Result{Findings: []Finding{finding("dup", "HIGH", 1), finding("dup", "HIGH", 1)}}
+
+	c := Compare(base, head)
+	if len(c.Fixed) != 1 || len(c.Unchanged) != 2 || len(c.New) != 0 {
+		t.Errorf("fixed=%d unchanged=%d new=%d, want 1, 2, 0", len(c.Fixed), len(c.Unchanged), len(c.New))
+	}
+
+	c = Compare(head, base)
+	if len(c.New) != 1 || len(c.Fixed) != 0 {
+		t.Errorf("reversed: new=%d fixed=%d, want 1 and 0", len(c.New), len(c.Fixed))
+	}
+}
+
+func TestCompare_EmptyListsAreNotNil(t *testing.T) {
+	c := Compare(&Result{}, &Result{})
+	if c.New == nil || c.Fixed == nil || c.Unchanged == nil || c.BandChanged == nil {
+		t.Error("empty comparison lists must be non-nil so JSON renders [] rather than null")
+	}
+}
+
+// TestCompare_MovedFindingIsUnchanged moves a finding down by inserting lines
+// above it. Its suppression identity changes with its line number, and the
+// line-free identity still pairs it; a different value written where it used
+// to be is new, not a continuation of it.
+func TestCompare_MovedFindingIsUnchanged(t *testing.T) {
+	const inserted = 7
+	ssn := func(text string, line int) detector.Match {
+		return detector.Match{
+			Text: text, Type: "SSN", Validator: "SSN", Confidence: 95,
+			Filename: "records/people.txt", LineNumber: line,
+			Context: detector.ContextInfo{FullLine: "ssn: " + text, BeforeText: "ssn: "},
+		}
+	}
+	baseMatches := []detector.Match{ssn("123-45-6789", 4)}
+	headMatches := []detector.Match{ssn("123-45-6789", 4+inserted), ssn("536-22-1874", 4)}
+
+	for name, f := range map[string]formatters.Formatter{
+		FormatJSON:  jsonfmt.NewFormatter(),
+		FormatSARIF: sarif.NewFormatter(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			load := func(matches []detector.Match) *Result {
+				out, err := f.Format(matches, nil, formatters.FormatterOptions{ConfidenceLevel: map[string]bool{"high": true, "medium": true, "low": true}})
+				if err != nil {
+					t.Fatal(err)
+				}
+				result, err := Parse([]byte(out))
+				if err != nil {
+					t.Fatal(err)
+				}
+				return result
+			}
+			base, head := load(baseMatches), load(headMatches)
+			for _, h := range head.Findings {
+				if h.Hash == base.Findings[0].Hash {
+					t.Fatal("fixture: the moved finding must change suppression identity")
+				}
+			}
+
+			c := Compare(base, head)
+			if len(c.Unchanged) != 1 || c.Unchanged[0].LineNumber != 4+inserted {
+				t.Errorf("unchanged = %+v, want the finding at its new line %d", c.Unchanged, 4+inserted)
+			}
+			if len(c.Fixed) != 0 {
+				t.Errorf("fixed = %+v, want none: the finding moved, it was not fixed", c.Fixed)
+			}
+			if len(c.New) != 1 || c.New[0].LineNumber != 4 {
+				t.Errorf("new = %+v, want the other value at line 4", c.New)
+			}
+		})
+	}
+}
+
+// A result written before the line-free identity carries none, and a moved
+// finding in it is fixed and new as it always was.
+func TestCompare_WithoutLineFreeIdentity(t *testing.T) {
+	base := &Result{Findings: []Finding{finding("old-line", "HIGH", 1)}}
+	head := &Result{Findings: []Finding{finding("new-line", "HIGH", 8)}}
+	c := Compare(base, head)
+	if len(c.Fixed) != 1 || len(c.New) != 1 || len(c.Unchanged) != 0 {
+		t.Errorf("fixed=%d new=%d unchanged=%d, want 1, 1, 0", len(c.Fixed), len(c.New), len(c.Unchanged))
+	}
+}
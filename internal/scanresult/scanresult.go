@@ -0,0 +1,255 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package scanresult reads a saved ferret-scan result — the JSON or SARIF
+// report of an earlier run — back into findings keyed by suppression identity.
+//
+// It exists so tooling can work from a report instead of a re-scan: importing
+// a result's findings as suppression rules, or comparing two results. Only the
+// fields those uses need are read; a result is not round-tripped.
+package scanresult
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/awslabs/ferret-scan/v2/internal/detector"
+	"github.com/awslabs/ferret-scan/v2/internal/formatters/sarif"
+	"github.com/awslabs/ferret-scan/v2/internal/formatters/shared"
+	"github.com/awslabs/ferret-scan/v2/internal/suppressions"
+)
+
+// Formats a result file can be in.
+const (
+	FormatJSON  = "json"
+	FormatSARIF = "sarif"
+)
+
+// maxResultBytes bounds how much of a result file is read. Results are written
+// by ferret-scan itself, but the path is operator-supplied and a diff reads two
+// of them; 512 MiB comfortably covers a six-figure finding count.
+const maxResultBytes = 512 << 20
+
+// Finding is one active (unsuppressed) finding from a result.
+type Finding struct {
+	Hash            string  `json:"suppression_hash"`
+	Type            string  `json:"type"`
+	Validator       string  `json:"validator,omitempty"`
+	Filename        string  `json:"filename"`
+	LineNumber      int     `json:"line_number"`
+	Confidence      float64 `json:"confidence"`
+	ConfidenceLevel string  `json:"confidence_level"`
+
+	// LineFree is the finding's identity without its line
+	// (suppressions.LineFreeHash); empty when the result predates it.
+	LineFree string `json:"-"`
+}
+
+// Result is a parsed result file.
+type Result struct {
+	Format   string
+	Findings []Finding
+
+	// Suppressed counts findings the producing scan had already suppressed.
+	// They are not returned: both consumers act on active findings only.
+	Suppressed int
+
+	// Unidentified counts active findings with no recoverable identity — a
+	// result written before identities were emitted, without the matched text
+	// and context needed to recompute one. They are dropped from Findings and
+	// reported so a caller can say how much of the input it could not use.
+	Unidentified int
+
+	// Truncated is set when the producing scan's --limit dropped findings, so
+	// the result is not a complete list. A finding missing from it may simply
+	// have been cut, which a comparison has to say rather than call it fixed.
+	Truncated bool
+}
+
+// Load reads and parses a result file.
+func Load(path string) (*Result, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open result %q: %w", path, err)
+	}
+	defer f.Close()
+
+	var buf bytes.Buffer
+	n, err := buf.ReadFrom(io.LimitReader(f, maxResultBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read result %q: %w", path, err)
+	}
+	if n > maxResultBytes {
+		return nil, fmt.Errorf("result %q exceeds %d bytes", path, maxResultBytes)
+	}
+	result, err := Parse(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("result %q: %w", path, err)
+	}
+	return result, nil
+}
+
+// Parse parses a JSON or SARIF result, telling them apart by their top-level
+// keys: a SARIF log has "runs", a ferret-scan JSON result has "results".
+func Parse(data []byte) (*Result, error) {
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("not a JSON or SARIF result: %w", err)
+	}
+	if _, ok := probe["runs"]; ok {
+		return parseSARIF(data)
+	}
+	if _, ok := probe["results"]; ok {
+		return parseJSON(data)
+	}
+	return nil, fmt.Errorf("not a ferret-scan result: expected a top-level \"results\" (json) or \"runs\" (sarif) key")
+}
+
+func parseJSON(data []byte) (*Result, error) {
+	var resp struct {
+		Results    []shared.JSONMatch `json:"results"`
+		Suppressed []json.RawMessage  `json:"suppressed"`
+		Truncated  bool               `json:"truncated"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("malformed json result: %w", err)
+	}
+
+	result := &Result{Format: FormatJSON, Findings: []Finding{}, Suppressed: len(resp.Suppressed), Truncated: resp.Truncated}
+	for _, m := range resp.Results {
+		hash, lineFree := m.SuppressionHash, m.LineFreeHash
+		if hash == "" {
+			hash = recomputeHash(m)
+		}
+		if lineFree == "" {
+			lineFree = recomputeLineFreeHash(m)
+		}
+		if hash == "" {
+			result.Unidentified++
+			continue
+		}
+		level := m.ConfidenceLevel
+		if level == "" {
+			level = shared.GetConfidenceLevel(m.Confidence)
+		}
+		result.Findings = append(result.Findings, Finding{
+			Hash:            hash,
+			Type:            m.Type,
+			Validator:       m.Validator,
+			Filename:        m.Filename,
+			LineNumber:      m.LineNumber,
+			Confidence:      m.Confidence,
+			ConfidenceLevel: level,
+			LineFree:        lineFree,
+		})
+	}
+	return result, nil
+}
+
+// recomputeHash rebuilds the identity of a finding from a result written without
+// one. It is possible only when the result was produced with --show-match (and
+// --verbose for the context fields): the identity covers the matched value and
+// its line, and without them any hash would identify a different finding.
+func recomputeHash(m shared.JSONMatch) string {
+	if m.Text == "" || m.Text == "[HIDDEN]" {
+		return ""
+	}
+	return suppressions.FindingHash(detector.Match{
+		Text:       m.Text,
+		Type:       m.Type,
+		Filename:   m.Filename,
+		LineNumber: m.LineNumber,
+		Context: detector.ContextInfo{
+			FullLine:   m.FullLine,
+			BeforeText: m.BeforeText,
+			AfterText:  m.AfterText,
+		},
+	})
+}
+
+// recomputeLineFreeHash is recomputeHash for the line-free identity.
+func recomputeLineFreeHash(m shared.JSONMatch) string {
+	if m.Text == "" || m.Text == "[HIDDEN]" {
+		return ""
+	}
+	return suppressions.LineFreeHash(detector.Match{
+		Text:     m.Text,
+		Type:     m.Type,
+		Filename: m.Filename,
+		Context:  detector.ContextInfo{BeforeText: m.BeforeText, AfterText: m.AfterText},
+	})
+}
+
+func parseSARIF(data []byte) (*Result, error) {
+	var log struct {
+		Runs []struct {
+			Results []struct {
+				RuleID              string            `json:"ruleId"`
+				PartialFingerprints map[string]string `json:"partialFingerprints"`
+				Suppressions        []json.RawMessage `json:"suppressions"`
+				Properties          struct {
+					Confidence      float64 `json:"confidence"`
+					ConfidenceLevel string  `json:"confidenceLevel"`
+					Validator       string  `json:"validator"`
+				} `json:"properties"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+						Region struct {
+							StartLine int `json:"startLine"`
+						} `json:"region"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+			Properties struct {
+				Truncated bool `json:"ferretScanTruncated"`
+			} `json:"properties"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(data, &log); err != nil {
+		return nil, fmt.Errorf("malformed sarif result: %w", err)
+	}
+
+	result := &Result{Format: FormatSARIF, Findings: []Finding{}}
+	for _, run := range log.Runs {
+		result.Truncated = result.Truncated || run.Properties.Truncated
+		for _, r := range run.Results {
+			if len(r.Suppressions) > 0 {
+				result.Suppressed++
+				continue
+			}
+			// SARIF carries no before/after context, so the fingerprint is the
+			// only identity a SARIF result can supply.
+			hash := r.PartialFingerprints[sarif.FingerprintSuppressionHash]
+			if hash == "" {
+				result.Unidentified++
+				continue
+			}
+			f := Finding{
+				Hash:            hash,
+				Type:            r.RuleID,
+				Validator:       r.Properties.Validator,
+				Confidence:      r.Properties.Confidence,
+				ConfidenceLevel: r.Properties.ConfidenceLevel,
+				LineFree:        r.PartialFingerprints[sarif.FingerprintLineFreeHash],
+			}
+			if f.ConfidenceLevel == "" {
+				f.ConfidenceLevel = shared.GetConfidenceLevel(f.Confidence)
+			}
+			if len(r.Locations) > 0 {
+				loc := r.Locations[0].PhysicalLocation
+				f.Filename = strings.TrimPrefix(loc.ArtifactLocation.URI, "file://")
+				f.LineNumber = loc.Region.StartLine
+			}
+			result.Findings = append(result.Findings, f)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,153 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package scanresult
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/awslabs/ferret-scan/v2/internal/detector"
+	"github.com/awslabs/ferret-scan/v2/internal/formatters"
+	jsonfmt "github.com/awslabs/ferret-scan/v2/internal/formatters/json"
+	"github.com/awslabs/ferret-scan/v2/internal/formatters/sarif"
+	"github.com/awslabs/ferret-scan/v2/internal/suppressions"
+)
+
+func fixtureMatches() ([]detector.Match, []detector.SuppressedMatch) {
+	active := []detector.Match{
+		{
+			Text: "123-45-6789", Type: "SSN", Validator: "SSN", Confidence: 95,
+			Filename: "records/people.txt", LineNumber: 4,
+			Context: detector.ContextInfo{FullLine: "ssn: 123-45-6789", BeforeText: "ssn: "},
+		},
+		{
+			Text: "alice@example.com", Type: "EMAIL", Validator: "EMAIL", Confidence: 70,
+			Filename: "/srv/repo/contacts.md", LineNumber: 9,
+		},
+	}
+	suppressed := []detector.SuppressedMatch{{
+		Match:        detector.Match{Text: "bob@example.com", Type: "EMAIL", Filename: "x.md", LineNumber: 1},
+		SuppressedBy: "SUP-00000001",
+	}}
+	return active, suppressed
+}
+
+// TestParse_RoundTrip writes a result through the real JSON and SARIF formatters
+// — with the matched value hidden, as in any shared report — and checks each
+// active finding reads back under the identity a suppression rule would record.
+func TestParse_RoundTrip(t *testing.T) {
+	active, suppressed := fixtureMatches()
+	want := map[string]detector.Match{}
+	for _, m := range active {
+		want[suppressions.FindingHash(m)] = m
+	}
+
+	for name, f := range map[string]formatters.Formatter{
+		FormatJSON:  jsonfmt.NewFormatter(),
+		FormatSARIF: sarif.NewFormatter(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			out, err := f.Format(append([]detector.Match(nil), active...), suppressed, formatters.FormatterOptions{ConfidenceLevel: map[string]bool{"high": true, "medium": true, "low": true}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := Parse([]byte(out))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Format != name {
+				t.Errorf("format = %q, want %q", result.Format, name)
+			}
+			if result.Suppressed != 1 || result.Unidentified != 0 {
+				t.Errorf("suppressed=%d unidentified=%d, want 1 and 0", result.Suppressed, result.Unidentified)
+			}
+			if len(result.Findings) != len(active) {
+				t.Fatalf("got %d findings, want %d", len(result.Findings), len(active))
+			}
+			for _, got := range result.Findings {
+				m, ok := want[got.Hash]
+				if !ok {
+					t.Errorf("finding %+v has an identity no input match produces", got)
+					continue
+				}
+				if got.Type != m.Type || got.LineNumber != m.LineNumber || got.Confidence != m.Confidence {
+					t.Errorf("finding %+v does not describe %s at line %d", got, m.Type, m.LineNumber)
+				}
+			}
+		})
+	}
+}
+
+// TestParse_LegacyJSON covers results written before identities were emitted:
+// the identity is recomputed when the value was shown, and counted as missing
+// when it was hidden.
+func TestParse_LegacyJSON(t *testing.T) {
+	m := detector.Match{Text: "123-45-6789", Type: "SSN", Filename: "a.txt", LineNumber: 2}
+	data := `{"results": [
+		{"text": "123-45-6789", "type": "SSN", "filename": "a.txt", "line_number": 2, "confidence": 80},
+		{"text": "[HIDDEN]", "type": "SSN", "filename": "b.txt", "line_number": 3, "confidence": 80}
+	]}`
+	result, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Findings) != 1 || result.Findings[0].Hash != suppressions.FindingHash(m) {
+		t.Errorf("findings = %+v, want the shown finding with a recomputed identity", result.Findings)
+	}
+	if result.Unidentified != 1 {
+		t.Errorf("unidentified = %d, want 1", result.Unidentified)
+	}
+	if result.Findings[0].ConfidenceLevel != "MEDIUM" {
+		t.Errorf("confidence level = %q, want it derived from the score", result.Findings[0].ConfidenceLevel)
+	}
+}
+
+// TestParse_Truncated checks a --limit result is recognised as incomplete in
+// both formats, so a comparison does not report the dropped findings as fixed.
+func TestParse_Truncated(t *testing.T) {
+	active, _ := fixtureMatches()
+	opts := formatters.FormatterOptions{ConfidenceLevel: map[string]bool{"high": true, "medium": true, "low": true}, Limit: 1}
+	for name, f := range map[string]formatters.Formatter{
+		FormatJSON:  jsonfmt.NewFormatter(),
+		FormatSARIF: sarif.NewFormatter(),
+	} {
+		out, err := f.Format(append([]detector.Match(nil), active...), nil, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := Parse([]byte(out))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.Truncated || len(result.Findings) != 1 {
+			t.Errorf("%s: truncated=%v findings=%d, want a truncated result with one finding", name, result.Truncated, len(result.Findings))
+		}
+	}
+}
+
+func TestParse_RejectsOtherDocuments(t *testing.T) {
+	for _, data := range []string{"", "not json", `{"version": "1.0"}`, `[1, 2]`} {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("Parse(%q) accepted a document that is not a result", data)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "result.json")
+	if err := os.WriteFile(path, []byte(`{"results": []}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	result, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Format != FormatJSON || len(result.Findings) != 0 {
+		t.Errorf("result = %+v", result)
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("missing file accepted")
+	}
+}
@@ -93,6 +93,12 @@ func init() {
 // TestUnscoredChecksAreAccountedFor keeps it honest: every name in core.CheckNames()
 // must either be scored or appear here with a reason.
 var UnscoredChecks = map[string]string{
+	"DOCUMENT_FINGERPRINT": "finds only copies of documents the user registers; there is " +
+		"no text to score without a store. internal/fingerprint and the validator test their own documents.",
+	"EDM_MATCH": "finds only the values of a table the user indexes; there is no " +
+		"value to score without one. internal/edm and the validator test their own tables.",
+	"FILE_TYPE_MISMATCH": "reads the scanned file's bytes, not its text, and the corpus " +
+		"scores text. internal/filetype and the validator test their own files.",
 	"OTP": "scope is provisioning secrets (otpauth:// URIs, base32 seeds, recovery " +
 		"codes), verified working; transient 6-digit codes are deliberately out of scope.",
 	"SOCIAL_MEDIA": "config-gated by design; verified working with the shipped " +
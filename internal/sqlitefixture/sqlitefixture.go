@@ -0,0 +1,374 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package sqlitefixture writes SQLite database files for tests.
+//
+// The repo carries no binary fixtures, and a test that needs the sqlite3 shell
+// is a test that does not run in CI. This writes the file format directly:
+// page 1 holds the schema, each table is one leaf page or, when its rows do not
+// fit, leaf pages under one interior page, and a record too large for its page
+// spills into an overflow chain. Each index is a single leaf page. There is no
+// freelist and no pointer map. The files it writes pass
+// "PRAGMA integrity_check" in the sqlite3 shell.
+//
+// Two packages need this: the SQLite text extractor and the SQLite redactor.
+// It is a leaf package (no ferret-scan imports), as the extractor's own tests
+// import it, so that both use one implementation of the page and record
+// encoding.
+package sqlitefixture
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+// Magic is the 16-byte string a SQLite database file begins with.
+const Magic = "SQLite format 3\x00"
+
+// Encoding is a database's text encoding, as the header stores it.
+type Encoding uint32
+
+const (
+	// UTF8 and the two UTF-16 byte orders are the header's values 1, 2 and 3.
+	UTF8    Encoding = 1
+	UTF16LE Encoding = 2
+	UTF16BE Encoding = 3
+)
+
+// Table is a table and its rows. Rowids are 1, 2, 3...; nil is NULL. Values
+// are nil, int, int64, float64, string or []byte.
+type Table struct {
+	Name string
+	SQL  string
+	Rows [][]any
+}
+
+// Index is an index on one column of a table.
+type Index struct {
+	Name   string
+	Table  string
+	SQL    string
+	Column int
+}
+
+type builder struct {
+	err      error
+	pageSize int
+	encoding Encoding
+	pages    [][]byte
+}
+
+// Build writes a database of pageSize-byte pages holding tables and indexes.
+// It returns an error rather than a corrupt file when a page overflows, which
+// happens for an index whose keys do not fit one leaf page.
+func Build(pageSize int, encoding Encoding, tables []Table, indexes []Index) ([]byte, error) {
+	f := &builder{pageSize: pageSize, encoding: encoding}
+	f.pages = [][]byte{nil} // page 1, written last
+
+	var schema [][]any
+	for _, tbl := range tables {
+		var cells []leafCell
+		for i, r := range tbl.Rows {
+			cells = append(cells, leafCell{rowid: int64(i + 1), payload: f.record(r)})
+		}
+		root := f.writeTable(cells)
+		schema = append(schema, []any{"table", tbl.Name, tbl.Name, int64(root), tbl.SQL})
+	}
+	for _, ix := range indexes {
+		var tbl Table
+		for _, t := range tables {
+			if t.Name == ix.Table {
+				tbl = t
+			}
+		}
+		type key struct {
+			v     any
+			rowid int64
+		}
+		var keys []key
+		for i, r := range tbl.Rows {
+			keys = append(keys, key{r[ix.Column], int64(i + 1)})
+		}
+		sort.SliceStable(keys, func(i, j int) bool { return lessValue(keys[i].v, keys[j].v) })
+		var cells [][]byte
+		for _, k := range keys {
+			payload := f.record([]any{k.v, k.rowid})
+			cells = append(cells, append(appendVarint(nil, int64(len(payload))), payload...))
+		}
+		root := f.addPage(f.leaf(0x0A, false, cells, 0))
+		schema = append(schema, []any{"index", ix.Name, ix.Table, int64(root), ix.SQL})
+	}
+
+	var schemaCells [][]byte
+	for i, r := range schema {
+		schemaCells = append(schemaCells, f.tableLeafCell(int64(i+1), f.record(r)))
+	}
+	f.pages[0] = f.leaf(0x0D, true, schemaCells, 0)
+
+	out := make([]byte, 0, len(f.pages)*pageSize)
+	for _, p := range f.pages {
+		out = append(out, p...)
+	}
+	f.header(out, len(f.pages))
+	if f.err != nil {
+		return nil, f.err
+	}
+	return out, nil
+}
+
+// MustBuild is Build for fixtures known to fit; it panics on error.
+func MustBuild(pageSize int, encoding Encoding, tables []Table, indexes []Index) []byte {
+	data, err := Build(pageSize, encoding, tables, indexes)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+func (f *builder) header(out []byte, pageCount int) {
+	copy(out, Magic)
+	ps := f.pageSize
+	if ps == 65536 {
+		ps = 1
+	}
+	binary.BigEndian.PutUint16(out[16:], uint16(ps))
+	out[18], out[19] = 1, 1
+	out[21], out[22], out[23] = 64, 32, 32
+	binary.BigEndian.PutUint32(out[24:], 1)
+	binary.BigEndian.PutUint32(out[28:], uint32(pageCount))
+	binary.BigEndian.PutUint32(out[40:], 1)
+	binary.BigEndian.PutUint32(out[44:], 4)
+	binary.BigEndian.PutUint32(out[56:], uint32(f.encoding))
+	binary.BigEndian.PutUint32(out[92:], 1)
+	binary.BigEndian.PutUint32(out[96:], 3040001)
+}
+
+func (f *builder) addPage(p []byte) int {
+	f.pages = append(f.pages, p)
+	return len(f.pages)
+}
+
+type leafCell struct {
+	rowid   int64
+	payload []byte
+}
+
+// writeTable writes a table b-tree and returns its root page.
+func (f *builder) writeTable(cells []leafCell) int {
+	// Pack cells into leaves greedily.
+	var leaves [][][]byte
+	var lastRowid []int64
+	var cur [][]byte
+	used := 8
+	for _, c := range cells {
+		cell := f.tableLeafCell(c.rowid, c.payload)
+		if len(cur) > 0 && used+len(cell)+2 > f.pageSize {
+			leaves = append(leaves, cur)
+			cur, used = nil, 8
+		}
+		cur = append(cur, cell)
+		used += len(cell) + 2
+		if len(lastRowid) < len(leaves)+1 {
+			lastRowid = append(lastRowid, 0)
+		}
+		lastRowid[len(leaves)] = c.rowid
+	}
+	leaves = append(leaves, cur)
+	if len(leaves) == 1 {
+		return f.addPage(f.leaf(0x0D, false, leaves[0], 0))
+	}
+	root := f.addPage(nil)
+	var interior [][]byte
+	var right int
+	for i, l := range leaves {
+		n := f.addPage(f.leaf(0x0D, false, l, 0))
+		if i == len(leaves)-1 {
+			right = n
+			break
+		}
+		cell := binary.BigEndian.AppendUint32(nil, uint32(n))
+		interior = append(interior, appendVarint(cell, lastRowid[i]))
+	}
+	f.pages[root-1] = f.leaf(0x05, false, interior, right)
+	return root
+}
+
+// tableLeafCell encodes a table leaf cell, spilling a large payload into an
+// overflow chain.
+func (f *builder) tableLeafCell(rowid int64, payload []byte) []byte {
+	cell := appendVarint(nil, int64(len(payload)))
+	cell = appendVarint(cell, rowid)
+	u := f.pageSize
+	maxLocal := u - 35
+	if len(payload) <= maxLocal {
+		return append(cell, payload...)
+	}
+	minLocal := (u-12)*32/255 - 23
+	local := minLocal + (len(payload)-minLocal)%(u-4)
+	if local > maxLocal {
+		local = minLocal
+	}
+	cell = append(cell, payload[:local]...)
+	rest := payload[local:]
+	first := len(f.pages) + 1
+	for len(rest) > 0 {
+		take := min(len(rest), u-4)
+		p := make([]byte, u)
+		if take < len(rest) {
+			binary.BigEndian.PutUint32(p, uint32(len(f.pages)+2))
+		}
+		copy(p[4:], rest[:take])
+		f.addPage(p)
+		rest = rest[take:]
+	}
+	return binary.BigEndian.AppendUint32(cell, uint32(first))
+}
+
+// leaf lays out a b-tree page: the header, the cell pointer array, and the
+// cells packed at the end of the page.
+func (f *builder) leaf(kind byte, first bool, cells [][]byte, right int) []byte {
+	p := make([]byte, f.pageSize)
+	hdr := 0
+	if first {
+		hdr = 100
+	}
+	hdrLen := 8
+	if kind == 0x05 || kind == 0x02 {
+		hdrLen = 12
+		binary.BigEndian.PutUint32(p[hdr+8:], uint32(right))
+	}
+	p[hdr] = kind
+	binary.BigEndian.PutUint16(p[hdr+3:], uint16(len(cells)))
+	end := f.pageSize
+	for i, c := range cells {
+		end -= len(c)
+		if end < hdr+hdrLen+2*len(cells) {
+			if f.err == nil {
+				f.err = fmt.Errorf("sqlitefixture: page overflows: %d cells", len(cells))
+			}
+			return p
+		}
+		copy(p[end:], c)
+		binary.BigEndian.PutUint16(p[hdr+hdrLen+2*i:], uint16(end))
+	}
+	binary.BigEndian.PutUint16(p[hdr+5:], uint16(end))
+	return p
+}
+
+// record encodes a record.
+func (f *builder) record(values []any) []byte {
+	var types, body []byte
+	for _, v := range values {
+		switch v := v.(type) {
+		case nil:
+			types = appendVarint(types, 0)
+		case int:
+			types, body = appendInt(types, body, int64(v))
+		case int64:
+			types, body = appendInt(types, body, v)
+		case float64:
+			types = appendVarint(types, 7)
+			body = binary.BigEndian.AppendUint64(body, math.Float64bits(v))
+		case string:
+			enc := f.encodeText(v)
+			types = appendVarint(types, int64(len(enc))*2+13)
+			body = append(body, enc...)
+		case []byte:
+			types = appendVarint(types, int64(len(v))*2+12)
+			body = append(body, v...)
+		default:
+			if f.err == nil {
+				f.err = fmt.Errorf("sqlitefixture: value of type %T", v)
+			}
+		}
+	}
+	// The header length counts itself; one byte is enough for any fixture.
+	hdr := appendVarint(nil, int64(len(types)+1))
+	return append(append(hdr, types...), body...)
+}
+
+// encodeText encodes a string in the database's text encoding.
+func (f *builder) encodeText(s string) []byte {
+	if f.encoding == UTF8 {
+		return []byte(s)
+	}
+	var b []byte
+	for _, u := range utf16.Encode([]rune(s)) {
+		if f.encoding == UTF16LE {
+			b = binary.LittleEndian.AppendUint16(b, u)
+		} else {
+			b = binary.BigEndian.AppendUint16(b, u)
+		}
+	}
+	return b
+}
+
+func appendInt(types, body []byte, v int64) ([]byte, []byte) {
+	switch {
+	case v == 0:
+		return appendVarint(types, 8), body
+	case v == 1:
+		return appendVarint(types, 9), body
+	case v >= math.MinInt8 && v <= math.MaxInt8:
+		return appendVarint(types, 1), append(body, byte(v))
+	case v >= math.MinInt16 && v <= math.MaxInt16:
+		return appendVarint(types, 2), binary.BigEndian.AppendUint16(body, uint16(v))
+	case v >= math.MinInt32 && v <= math.MaxInt32:
+		return appendVarint(types, 4), binary.BigEndian.AppendUint32(body, uint32(v))
+	}
+	return appendVarint(types, 6), binary.BigEndian.AppendUint64(body, uint64(v))
+}
+
+func appendVarint(b []byte, v int64) []byte {
+	u := uint64(v)
+	if u <= 0x7f {
+		return append(b, byte(u))
+	}
+	if u > 1<<56-1 {
+		var buf [9]byte
+		buf[8] = byte(u)
+		u >>= 8
+		for i := 7; i >= 0; i-- {
+			buf[i] = byte(u&0x7f) | 0x80
+			u >>= 7
+		}
+		return append(b, buf[:]...)
+	}
+	var buf []byte
+	for u > 0 {
+		buf = append([]byte{byte(u & 0x7f)}, buf...)
+		u >>= 7
+	}
+	for i := 0; i < len(buf)-1; i++ {
+		buf[i] |= 0x80
+	}
+	return append(b, buf...)
+}
+
+// lessValue orders index keys the way SQLite does for the fixtures' values:
+// NULL, then numbers, then text by bytes.
+func lessValue(a, b any) bool {
+	rank := func(v any) int {
+		switch v.(type) {
+		case nil:
+			return 0
+		case int, int64, float64:
+			return 1
+		}
+		return 2
+	}
+	if ra, rb := rank(a), rank(b); ra != rb {
+		return ra < rb
+	}
+	switch a := a.(type) {
+	case int:
+		return a < b.(int)
+	case string:
+		return strings.Compare(a, b.(string)) < 0
+	}
+	return false
+}
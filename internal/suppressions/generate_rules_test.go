@@ -4,6 +4,7 @@
 package suppressions
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -189,3 +190,35 @@ func TestGenerateSuppressionRules_GeneratedRuleStillSuppresses(t *testing.T) {
 		t.Error("suppressed but no rule returned")
 	}
 }
+
+// Every path that creates a rule numbers it after the highest ID in the file,
+// gaps included, because they all take the ID from nextRuleID.
+func TestEveryCreationPathNumbersAfterTheHighestID(t *testing.T) {
+	sm := NewSuppressionManager(filepath.Join(t.TempDir(), "s.yaml"))
+	if err := sm.AddSuppression(newLineMatch("SSN", "123-45-6789", 1), "seed", "test", nil); err != nil {
+		t.Fatal(err)
+	}
+	sm.config.Rules[0].ID = "SUP-00000040"
+
+	finding := func(line float64) map[string]interface{} {
+		return map[string]interface{}{"type": "EMAIL", "text": "a@example.com", "filename": "f.txt", "line_number": line}
+	}
+	create := []func() error{
+		func() error { return sm.AddSuppression(newLineMatch("SSN", "123-45-6789", 2), "r", "test", nil) },
+		func() error { return sm.CreateSuppressionFromFinding("", "r", finding(3)) },
+		func() error { return sm.CreateSuppressionFromFindingWithState("h4", "r", finding(4), true) },
+		func() error {
+			return sm.GenerateSuppressionRules([]detector.Match{newLineMatch("SSN", "123-45-6789", 5)}, "r", true)
+		},
+	}
+	for i, c := range create {
+		if err := c(); err != nil {
+			t.Fatalf("path %d: %v", i, err)
+		}
+		rules := sm.config.Rules
+		want := fmt.Sprintf("SUP-%08d", 41+i)
+		if got := rules[len(rules)-1].ID; got != want {
+			t.Errorf("path %d: ID = %s, want %s", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,314 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package suppressions
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadConfigFile reads and parses a suppression file strictly. Unlike the
+// manager's loader, which treats an unreadable or malformed file as empty so a
+// scan can proceed, this returns the error: a merge that silently read nothing
+// from its source would report success having merged nothing.
+func LoadConfigFile(path string) (*SuppressionConfig, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read suppression file %q: %w", path, err)
+	}
+	var config SuppressionConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("suppression file %q is malformed: %w", path, err)
+	}
+	return &config, nil
+}
+
+// ruleIdentity is what makes two rules "the same rule" across files: the
+// finding hash for a hash rule, the matcher for a pattern rule. IDs are not
+// identity — two teams numbering rules independently both start at SUP-00000001.
+func ruleIdentity(rule SuppressionRule) string {
+	if !rule.IsPattern() {
+		return "hash:" + rule.Hash
+	}
+	if rule.Match == nil {
+		return "pattern:"
+	}
+	m := rule.Match
+	return fmt.Sprintf("pattern:%q|%q|%q|%q|%q|%q",
+		m.Path, m.Validator, m.Type, m.MaxConfidence, m.ValueRegex, m.ValueTarget)
+}
+
+// sameDecision reports whether two rules with the same identity record the
+// same decision. Bookkeeping timestamps (created, last seen, reviewed) differ
+// between copies of one decision and are deliberately not compared.
+func sameDecision(a, b SuppressionRule) bool {
+	if a.Enabled != b.Enabled || a.Reason != b.Reason {
+		return false
+	}
+	if (a.ExpiresAt == nil) != (b.ExpiresAt == nil) {
+		return false
+	}
+	return a.ExpiresAt == nil || a.ExpiresAt.Equal(*b.ExpiresAt)
+}
+
+// MergeConflict is a rule present in both files with different decisions. The
+// existing rule is kept; the incoming one is reported and not written.
+type MergeConflict struct {
+	ExistingID string
+	IncomingID string
+	Existing   SuppressionRule
+	Incoming   SuppressionRule
+}
+
+// MergeReport summarises a merge.
+type MergeReport struct {
+	Added      []string          // IDs of rules appended, after renumbering
+	Duplicates []string          // incoming IDs already present with the same decision
+	Renumbered map[string]string // incoming ID -> assigned ID
+	Conflicts  []MergeConflict
+}
+
+// Merge adds the rules of another suppression file to this one.
+//
+// A rule whose identity is new is appended; if its ID is already taken it gets
+// the next free ID and the rename is reported. A rule identical in decision to
+// one already present is a duplicate and is skipped, but its last_seen_at is
+// carried over when newer so the merged file does not look staler than either
+// input. A rule with the same identity and a different decision is a conflict:
+// the existing rule wins and the conflict is reported for a human to resolve,
+// because picking either side automatically would silently overrule a team.
+func (sm *SuppressionManager) Merge(other *SuppressionConfig) (*MergeReport, error) {
+	if sm.config == nil {
+		sm.config = &SuppressionConfig{
+			Version: "1.0",
+			Rules:   []SuppressionRule{},
+		}
+	}
+	report := &MergeReport{Added: []string{}, Duplicates: []string{}, Conflicts: []MergeConflict{}}
+	if other == nil {
+		return report, nil
+	}
+
+	byIdentity := make(map[string]int, len(sm.config.Rules))
+	ids := make(map[string]bool, len(sm.config.Rules))
+	for i, rule := range sm.config.Rules {
+		byIdentity[ruleIdentity(rule)] = i
+		ids[rule.ID] = true
+	}
+
+	nextID := sm.ruleIDSequence()
+	changed := false
+	for _, incoming := range other.Rules {
+		key := ruleIdentity(incoming)
+		if idx, ok := byIdentity[key]; ok {
+			existing := &sm.config.Rules[idx]
+			if !sameDecision(*existing, incoming) {
+				report.Conflicts = append(report.Conflicts, MergeConflict{
+					ExistingID: existing.ID,
+					IncomingID: incoming.ID,
+					Existing:   *existing,
+					Incoming:   incoming,
+				})
+				continue
+			}
+			report.Duplicates = append(report.Duplicates, incoming.ID)
+			if incoming.LastSeenAt != nil && (existing.LastSeenAt == nil || incoming.LastSeenAt.After(*existing.LastSeenAt)) {
+				seen := *incoming.LastSeenAt
+				existing.LastSeenAt = &seen
+				changed = true
+			}
+			continue
+		}
+
+		rule := incoming
+		if rule.ID == "" || ids[rule.ID] {
+			// An incoming rule kept under its own ID may already hold the
+			// next sequential one, so keep drawing until a free ID comes up.
+			rule.ID = nextID()
+			for ids[rule.ID] {
+				rule.ID = nextID()
+			}
+			if incoming.ID != "" {
+				if report.Renumbered == nil {
+					report.Renumbered = make(map[string]string)
+				}
+				report.Renumbered[incoming.ID] = rule.ID
+			}
+		}
+		sm.config.Rules = append(sm.config.Rules, rule)
+		byIdentity[key] = len(sm.config.Rules) - 1
+		ids[rule.ID] = true
+		report.Added = append(report.Added, rule.ID)
+		changed = true
+	}
+
+	if changed {
+		if err := sm.saveConfig(); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// ruleIDSequence hands out consecutive IDs after the current highest. Bulk
+// operations use it instead of nextRuleID, which rescans every rule per call
+// and would make a 50,000-finding import quadratic.
+func (sm *SuppressionManager) ruleIDSequence() func() string {
+	var next int
+	_, _ = fmt.Sscanf(sm.nextRuleID(), "SUP-%08d", &next)
+	return func() string {
+		id := fmt.Sprintf("SUP-%08d", next)
+		next++
+		return id
+	}
+}
+
+// ImportedFinding is a finding read back from a scan result, reduced to what a
+// rule needs: its identity hash and the descriptive metadata rules carry.
+type ImportedFinding struct {
+	Hash       string
+	Type       string
+	Filename   string
+	LineNumber int
+	Confidence float64
+}
+
+// ImportFindings adds one hash rule per imported finding that is not already
+// covered. It mirrors GenerateSuppressionRules — same ID sequence, same
+// metadata keys, same default one-week expiry — but starts from hashes a
+// formatter already computed rather than from live matches. It returns the
+// IDs added and the number of findings an existing rule already covered.
+func (sm *SuppressionManager) ImportFindings(findings []ImportedFinding, reason, createdBy string, enabled bool) ([]string, int, error) {
+	if sm.config == nil {
+		sm.config = &SuppressionConfig{
+			Version: "1.0",
+			Rules:   []SuppressionRule{},
+		}
+	}
+
+	existing := make(map[string]int, len(sm.config.Rules))
+	for i, rule := range sm.config.Rules {
+		if !rule.IsPattern() {
+			existing[rule.Hash] = i
+		}
+	}
+
+	nextID := sm.ruleIDSequence()
+	now := time.Now()
+	defaultExpiry := now.AddDate(0, 0, 7)
+	added := []string{}
+	covered := 0
+	for _, f := range findings {
+		if f.Hash == "" {
+			continue
+		}
+		if idx, ok := existing[f.Hash]; ok {
+			sm.config.Rules[idx].LastSeenAt = &now
+			covered++
+			continue
+		}
+		id := nextID()
+		expiry := defaultExpiry
+		sm.config.Rules = append(sm.config.Rules, SuppressionRule{
+			ID:         id,
+			Hash:       f.Hash,
+			Reason:     reason,
+			Enabled:    enabled,
+			CreatedBy:  createdBy,
+			CreatedAt:  now,
+			LastSeenAt: &now,
+			ExpiresAt:  &expiry,
+			Metadata: map[string]string{
+				"finding_type": f.Type,
+				"filename":     filepath.Base(f.Filename),
+				"line_number":  fmt.Sprintf("%d", f.LineNumber),
+				"confidence":   fmt.Sprintf("%.2f", f.Confidence),
+			},
+		})
+		existing[f.Hash] = len(sm.config.Rules) - 1
+		added = append(added, id)
+	}
+
+	if len(added) > 0 || covered > 0 {
+		if err := sm.saveConfig(); err != nil {
+			return added, covered, err
+		}
+	}
+	return added, covered, nil
+}
+
+// StaleRules returns the rules not seen for at least maxAge as of now, oldest
+// first. A rule that has never been seen is judged by its creation time: a rule
+// created a year ago that no scan has ever matched is the strongest deletion
+// candidate there is, not an exemption.
+func (sm *SuppressionManager) StaleRules(maxAge time.Duration, now time.Time) []SuppressionRule {
+	if sm.config == nil {
+		return []SuppressionRule{}
+	}
+	cutoff := now.Add(-maxAge)
+	stale := []SuppressionRule{}
+	for _, rule := range sm.config.Rules {
+		if lastActivity(rule).Before(cutoff) {
+			stale = append(stale, rule)
+		}
+	}
+	sort.SliceStable(stale, func(i, j int) bool {
+		return lastActivity(stale[i]).Before(lastActivity(stale[j]))
+	})
+	return stale
+}
+
+// lastActivity is when a rule was last known to matter: last seen, else created.
+func lastActivity(rule SuppressionRule) time.Time {
+	if rule.LastSeenAt != nil {
+		return *rule.LastSeenAt
+	}
+	return rule.CreatedAt
+}
+
+// ExpiringWithin returns the rules that are not yet expired but will expire
+// within window of now, soonest first. Disabled rules are included: they are
+// still decisions that lapse, and the listing is for the humans who own them.
+func (sm *SuppressionManager) ExpiringWithin(window time.Duration, now time.Time) []SuppressionRule {
+	if sm.config == nil {
+		return []SuppressionRule{}
+	}
+	limit := now.Add(window)
+	expiring := []SuppressionRule{}
+	for _, rule := range sm.config.Rules {
+		if rule.ExpiresAt != nil && rule.ExpiresAt.After(now) && !rule.ExpiresAt.After(limit) {
+			expiring = append(expiring, rule)
+		}
+	}
+	sort.SliceStable(expiring, func(i, j int) bool {
+		return expiring[i].ExpiresAt.Before(*expiring[j].ExpiresAt)
+	})
+	return expiring
+}
+
+// ReviewSuppression stamps reviewed_by and reviewed_at on a rule.
+func (sm *SuppressionManager) ReviewSuppression(id, reviewer string, at time.Time) error {
+	if sm.config == nil {
+		return fmt.Errorf("no suppression config loaded")
+	}
+	if reviewer == "" {
+		return fmt.Errorf("reviewer is required")
+	}
+
+	for i := range sm.config.Rules {
+		if sm.config.Rules[i].ID == id {
+			reviewedAt := at
+			sm.config.Rules[i].ReviewedBy = reviewer
+			sm.config.Rules[i].ReviewedAt = &reviewedAt
+			return sm.saveConfig()
+		}
+	}
+
+	return fmt.Errorf("suppression rule with ID %s not found", id)
+}
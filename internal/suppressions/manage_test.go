@@ -0,0 +1,213 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package suppressions
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeRuleFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "suppressions.yaml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestMerge covers the four outcomes of merging one rule file into another: new
+// rules appended, duplicates skipped, colliding IDs renumbered and conflicting
+// decisions reported with the existing rule kept.
+func TestMerge(t *testing.T) {
+	base := writeRuleFile(t, `version: "1.0"
+rules:
+  - id: SUP-00000001
+    hash: aaaa
+    reason: fixture
+    enabled: true
+    created_at: 2026-01-01T00:00:00Z
+  - id: SUP-00000002
+    hash: bbbb
+    reason: fixture
+    enabled: true
+    created_at: 2026-01-01T00:00:00Z
+`)
+	incoming := writeRuleFile(t, `version: "1.0"
+rules:
+  - id: SUP-00000001
+    hash: aaaa
+    reason: fixture
+    enabled: true
+    created_at: 2026-02-01T00:00:00Z
+    last_seen_at: 2026-03-01T00:00:00Z
+  - id: SUP-00000002
+    hash: bbbb
+    reason: real data, do not suppress
+    enabled: false
+    created_at: 2026-02-01T00:00:00Z
+  - id: SUP-00000001
+    hash: cccc
+    reason: other team
+    enabled: true
+    created_at: 2026-02-01T00:00:00Z
+  - id: SUP-00000003
+    kind: pattern
+    reason: docs
+    enabled: true
+    created_at: 2026-02-01T00:00:00Z
+    match:
+      path: docs/**
+`)
+
+	other, err := LoadConfigFile(incoming)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sm := NewSuppressionManager(base)
+	report, err := sm.Merge(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Duplicates) != 1 || report.Duplicates[0] != "SUP-00000001" {
+		t.Errorf("duplicates = %v", report.Duplicates)
+	}
+	if len(report.Conflicts) != 1 || report.Conflicts[0].ExistingID != "SUP-00000002" {
+		t.Fatalf("conflicts = %+v", report.Conflicts)
+	}
+	if len(report.Added) != 2 {
+		t.Fatalf("added = %v, want the renumbered hash rule and the pattern rule", report.Added)
+	}
+	if got := report.Renumbered["SUP-00000001"]; got == "" || got == "SUP-00000001" {
+		t.Errorf("colliding ID not renumbered: %v", report.Renumbered)
+	}
+
+	reloaded := NewSuppressionManager(base).ListSuppressions()
+	if len(reloaded) != 4 {
+		t.Fatalf("merged file has %d rules, want 4", len(reloaded))
+	}
+	seen := map[string]bool{}
+	for _, rule := range reloaded {
+		if seen[rule.ID] {
+			t.Errorf("duplicate ID %s after merge", rule.ID)
+		}
+		seen[rule.ID] = true
+		switch rule.Hash {
+		case "aaaa":
+			if rule.LastSeenAt == nil {
+				t.Error("newer last_seen_at not carried over from duplicate")
+			}
+		case "bbbb":
+			if !rule.Enabled || rule.Reason != "fixture" {
+				t.Errorf("conflict overwrote existing rule: %+v", rule)
+			}
+		}
+	}
+}
+
+func TestLoadConfigFile_Strict(t *testing.T) {
+	if _, err := LoadConfigFile(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("missing file accepted")
+	}
+	if _, err := LoadConfigFile(writeRuleFile(t, "rules: [")); err == nil {
+		t.Error("malformed file accepted")
+	}
+}
+
+func TestImportFindings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "suppressions.yaml")
+	sm := NewSuppressionManager(path)
+	match := newTestMatch("SSN", "123-45-6789", "data.txt")
+	if err := sm.AddSuppression(match, "known", "tester", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	added, existing, err := sm.ImportFindings([]ImportedFinding{
+		{Hash: FindingHash(match), Type: "SSN", Filename: "data.txt", LineNumber: 1, Confidence: 90},
+		{Hash: "dddd", Type: "EMAIL", Filename: "/srv/x/mail.txt", LineNumber: 7, Confidence: 72.5},
+		{Hash: "dddd", Type: "EMAIL", Filename: "/srv/x/mail.txt", LineNumber: 7, Confidence: 72.5},
+		{Type: "EMAIL"},
+	}, "imported", "ci", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(added) != 1 || existing != 2 {
+		t.Fatalf("added=%v existing=%d, want one new rule and two covered findings", added, existing)
+	}
+
+	rules := NewSuppressionManager(path).ListSuppressions()
+	rule := rules[len(rules)-1]
+	if rule.Hash != "dddd" || rule.Enabled || rule.Metadata["filename"] != "mail.txt" || rule.ExpiresAt == nil {
+		t.Errorf("imported rule = %+v", rule)
+	}
+}
+
+func TestStaleRules(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	recent := now.AddDate(0, 0, -10)
+	old := now.AddDate(0, 0, -200)
+	sm := &SuppressionManager{config: &SuppressionConfig{Rules: []SuppressionRule{
+		{ID: "seen-recently", CreatedAt: old, LastSeenAt: &recent},
+		{ID: "seen-long-ago", CreatedAt: old, LastSeenAt: &old},
+		{ID: "never-seen-old", CreatedAt: now.AddDate(-1, 0, 0)},
+		{ID: "never-seen-new", CreatedAt: recent},
+	}}}
+
+	stale := sm.StaleRules(90*24*time.Hour, now)
+	if len(stale) != 2 || stale[0].ID != "never-seen-old" || stale[1].ID != "seen-long-ago" {
+		t.Errorf("stale = %v, want never-seen-old then seen-long-ago", ruleIDs(stale))
+	}
+}
+
+func TestExpiringWithin(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	at := func(days int) *time.Time { t := now.AddDate(0, 0, days); return &t }
+	sm := &SuppressionManager{config: &SuppressionConfig{Rules: []SuppressionRule{
+		{ID: "expired", ExpiresAt: at(-1)},
+		{ID: "in-ten", ExpiresAt: at(10)},
+		{ID: "in-three", ExpiresAt: at(3)},
+		{ID: "in-thirty", ExpiresAt: at(30)},
+		{ID: "never"},
+	}}}
+
+	got := sm.ExpiringWithin(14*24*time.Hour, now)
+	if len(got) != 2 || got[0].ID != "in-three" || got[1].ID != "in-ten" {
+		t.Errorf("expiring = %v, want in-three then in-ten", ruleIDs(got))
+	}
+}
+
+func TestReviewSuppression(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "suppressions.yaml")
+	sm := NewSuppressionManager(path)
+	id, err := sm.AddPatternSuppression(PatternMatch{Path: "docs/**"}, "docs", "tester", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sm.ReviewSuppression(id, "", time.Now()); err == nil {
+		t.Error("empty reviewer accepted")
+	}
+	if err := sm.ReviewSuppression("SUP-99999999", "alex", time.Now()); err == nil {
+		t.Error("unknown ID accepted")
+	}
+	at := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	if err := sm.ReviewSuppression(id, "alex", at); err != nil {
+		t.Fatal(err)
+	}
+
+	rule := NewSuppressionManager(path).ListSuppressions()[0]
+	if rule.ReviewedBy != "alex" || rule.ReviewedAt == nil || !rule.ReviewedAt.Equal(at) {
+		t.Errorf("review not recorded: by=%q at=%v", rule.ReviewedBy, rule.ReviewedAt)
+	}
+}
+
+func ruleIDs(rules []SuppressionRule) []string {
+	ids := make([]string, len(rules))
+	for i, r := range rules {
+		ids[i] = r.ID
+	}
+	return ids
+}
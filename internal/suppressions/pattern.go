@@ -0,0 +1,165 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package suppressions
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/awslabs/ferret-scan/v2/internal/detector"
+	"github.com/awslabs/ferret-scan/v2/internal/pathglob"
+)
+
+// RuleKindPattern marks a rule that matches findings by attribute instead of by
+// finding hash. A rule with an empty Kind is a hash rule, which is what every rule
+// file written before pattern rules existed contains.
+const RuleKindPattern = "pattern"
+
+// Value targets for PatternMatch.ValueRegex.
+const (
+	// ValueTargetHash evaluates the regex against the lowercase hex SHA-256 of the
+	// matched value, so the rule file never has to hold the plaintext.
+	ValueTargetHash = "hash"
+
+	// ValueTargetPlaintext evaluates the regex against the matched value itself.
+	ValueTargetPlaintext = "plaintext"
+)
+
+// PatternMatch is the matcher block of a pattern rule. Every populated field must
+// match for the rule to apply; an empty field matches anything. A pattern with no
+// populated field at all is rejected, because it would suppress every finding.
+type PatternMatch struct {
+	// Path is a slash-separated glob evaluated against the finding's filename.
+	// `*` and `?` stay inside one path segment and `**` spans any number of
+	// segments. A pattern is unanchored: "docs/**" matches "docs/a.md",
+	// "./docs/a.md" and "/srv/repo/docs/a.md" alike, because the same rule file is
+	// applied to scans started from different working directories.
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+
+	// Validator matches Match.Validator, case-insensitively.
+	Validator string `yaml:"validator,omitempty" json:"validator,omitempty"`
+
+	// Type matches Match.Type (the sub-type, e.g. EMAIL or API_KEY_OR_SECRET),
+	// case-insensitively.
+	Type string `yaml:"type,omitempty" json:"type,omitempty"`
+
+	// MaxConfidence is the confidence ceiling: a finding scoring above it is not
+	// suppressed. Either a number on the 0–100 scale, inclusive, or a band name —
+	// LOW covers findings below 60 and MEDIUM findings below 90, mirroring the
+	// bands every formatter reports.
+	MaxConfidence string `yaml:"max_confidence,omitempty" json:"max_confidence,omitempty"`
+
+	// ValueRegex, when set, must match the finding's value. ValueTarget selects
+	// what it is evaluated against and is required whenever ValueRegex is set:
+	// guessing would either put plaintext in the rule file or silently never match.
+	ValueRegex  string `yaml:"value_regex,omitempty" json:"value_regex,omitempty"`
+	ValueTarget string `yaml:"value_target,omitempty" json:"value_target,omitempty"`
+}
+
+// IsPattern reports whether this rule matches by attribute rather than by hash.
+func (r SuppressionRule) IsPattern() bool {
+	return r.Kind == RuleKindPattern
+}
+
+// compiledPattern is a pattern rule ready for matching. idx is the rule's position
+// in config.Rules, for the same reallocation reason rulesByHash stores positions.
+type compiledPattern struct {
+	idx        int
+	match      PatternMatch
+	path       pathglob.Glob
+	ceiling    float64
+	hasCeiling bool
+	exclusive  bool // band ceilings exclude their upper bound
+	valueRe    *regexp.Regexp
+}
+
+// compilePattern validates a pattern matcher and prepares it for matching.
+func compilePattern(pm *PatternMatch) (compiledPattern, error) {
+	if pm == nil {
+		return compiledPattern{}, fmt.Errorf("pattern rule has no match block")
+	}
+	cp := compiledPattern{match: *pm}
+	if pm.Path == "" && pm.Validator == "" && pm.Type == "" && pm.MaxConfidence == "" && pm.ValueRegex == "" {
+		return cp, fmt.Errorf("pattern rule matches every finding; set at least one matcher")
+	}
+
+	if pm.Path != "" {
+		glob, err := pathglob.Compile(pm.Path)
+		if err != nil {
+			return cp, err
+		}
+		cp.path = glob
+	}
+
+	if pm.MaxConfidence != "" {
+		switch strings.ToUpper(strings.TrimSpace(pm.MaxConfidence)) {
+		case "LOW":
+			cp.ceiling, cp.exclusive = 60, true
+		case "MEDIUM":
+			cp.ceiling, cp.exclusive = 90, true
+		case "HIGH":
+			cp.ceiling = 100
+		default:
+			v, err := strconv.ParseFloat(strings.TrimSpace(pm.MaxConfidence), 64)
+			if err != nil || v < 0 || v > 100 {
+				return cp, fmt.Errorf("invalid max_confidence %q: want 0-100 or LOW, MEDIUM, HIGH", pm.MaxConfidence)
+			}
+			cp.ceiling = v
+		}
+		cp.hasCeiling = true
+	}
+
+	if pm.ValueRegex != "" {
+		switch pm.ValueTarget {
+		case ValueTargetHash, ValueTargetPlaintext:
+		case "":
+			return cp, fmt.Errorf("value_regex requires value_target (%s or %s)", ValueTargetHash, ValueTargetPlaintext)
+		default:
+			return cp, fmt.Errorf("invalid value_target %q: want %s or %s", pm.ValueTarget, ValueTargetHash, ValueTargetPlaintext)
+		}
+		re, err := regexp.Compile(pm.ValueRegex)
+		if err != nil {
+			return cp, fmt.Errorf("invalid value_regex: %w", err)
+		}
+		cp.valueRe = re
+	}
+	return cp, nil
+}
+
+// matches reports whether a finding satisfies every populated matcher.
+func (cp *compiledPattern) matches(match detector.Match) bool {
+	if cp.match.Validator != "" && !strings.EqualFold(cp.match.Validator, match.Validator) {
+		return false
+	}
+	if cp.match.Type != "" && !strings.EqualFold(cp.match.Type, match.Type) {
+		return false
+	}
+	if cp.hasCeiling {
+		if match.Confidence != match.Confidence { // NaN never sits under a ceiling
+			return false
+		}
+		if cp.exclusive && match.Confidence >= cp.ceiling {
+			return false
+		}
+		if !cp.exclusive && match.Confidence > cp.ceiling {
+			return false
+		}
+	}
+	if cp.match.Path != "" && !cp.path.Match(match.Filename) {
+		return false
+	}
+	if cp.valueRe != nil {
+		value := match.Text
+		if cp.match.ValueTarget == ValueTargetHash {
+			value = fmt.Sprintf("%x", sha256.Sum256([]byte(match.Text)))
+		}
+		if !cp.valueRe.MatchString(value) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,230 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package suppressions
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/awslabs/ferret-scan/v2/internal/detector"
+)
+
+func TestPatternMatch_Matchers(t *testing.T) {
+	finding := detector.Match{
+		Type:       "EMAIL",
+		Validator:  "EMAIL",
+		Text:       "alice@example.com",
+		Filename:   "/srv/repo/docs/guide/setup.md",
+		Confidence: 55,
+	}
+	valueHash := fmt.Sprintf("%x", sha256.Sum256([]byte(finding.Text)))
+
+	tests := []struct {
+		name string
+		pm   PatternMatch
+		want bool
+	}{
+		{"path double star", PatternMatch{Path: "docs/**"}, true},
+		{"path double star with leaf", PatternMatch{Path: "docs/**/*.md"}, true},
+		{"path leading dot slash", PatternMatch{Path: "./docs/**"}, true},
+		{"path other tree", PatternMatch{Path: "testdata/**"}, false},
+		{"path star stays in segment", PatternMatch{Path: "docs/*.md"}, false},
+		{"type case-insensitive", PatternMatch{Type: "email"}, true},
+		{"type mismatch", PatternMatch{Type: "SSN"}, false},
+		{"validator", PatternMatch{Validator: "email"}, true},
+		{"band ceiling LOW", PatternMatch{MaxConfidence: "LOW"}, true},
+		{"numeric ceiling below", PatternMatch{MaxConfidence: "50"}, false},
+		{"numeric ceiling inclusive", PatternMatch{MaxConfidence: "55"}, true},
+		{"plaintext regex", PatternMatch{ValueRegex: `@example\.com$`, ValueTarget: ValueTargetPlaintext}, true},
+		{"hash regex", PatternMatch{ValueRegex: "^" + valueHash + "$", ValueTarget: ValueTargetHash}, true},
+		{"hash regex does not see plaintext", PatternMatch{ValueRegex: "example", ValueTarget: ValueTargetHash}, false},
+		{"all matchers", PatternMatch{Path: "docs/**", Type: "EMAIL", MaxConfidence: "MEDIUM"}, true},
+		{"one matcher fails", PatternMatch{Path: "docs/**", Type: "EMAIL", MaxConfidence: "10"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cp, err := compilePattern(&tt.pm)
+			if err != nil {
+				t.Fatalf("compilePattern: %v", err)
+			}
+			if got := cp.matches(finding); got != tt.want {
+				t.Errorf("matches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPatternMatch_RejectsInvalid(t *testing.T) {
+	for name, pm := range map[string]PatternMatch{
+		"empty":                {},
+		"bad glob":             {Path: "docs/[a"},
+		"bad ceiling":          {MaxConfidence: "VERY"},
+		"ceiling out of range": {MaxConfidence: "150"},
+		"regex without target": {ValueRegex: "x"},
+		"unknown target":       {ValueRegex: "x", ValueTarget: "both"},
+		"bad regex":            {ValueRegex: "(", ValueTarget: ValueTargetPlaintext},
+	} {
+		if _, err := compilePattern(&pm); err == nil {
+			t.Errorf("%s: compilePattern accepted %+v", name, pm)
+		}
+	}
+}
+
+// TestIsSuppressed_PatternRuleFromYAML loads a hand-written file mixing both rule
+// kinds and checks the pattern rule applies only where its matchers do.
+func TestIsSuppressed_PatternRuleFromYAML(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "suppressions.yaml")
+	content := `version: "1.0"
+rules:
+  - id: SUP-00000001
+    kind: pattern
+    reason: documentation examples
+    enabled: true
+    created_at: 2026-01-01T00:00:00Z
+    match:
+      path: docs/**
+      type: EMAIL
+  - id: SUP-00000002
+    kind: pattern
+    reason: low-confidence fixtures
+    enabled: true
+    created_at: 2026-01-01T00:00:00Z
+    match:
+      path: testdata/**
+      max_confidence: LOW
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	sm := NewSuppressionManager(path)
+
+	cases := []struct {
+		match  detector.Match
+		wantID string
+	}{
+		{detector.Match{Type: "EMAIL", Filename: "docs/a.md", Confidence: 95}, "SUP-00000001"},
+		{detector.Match{Type: "SSN", Filename: "docs/a.md", Confidence: 95}, ""},
+		{detector.Match{Type: "SSN", Filename: "pkg/testdata/x.txt", Confidence: 40}, "SUP-00000002"},
+		{detector.Match{Type: "SSN", Filename: "pkg/testdata/x.txt", Confidence: 60}, ""},
+	}
+	for _, c := range cases {
+		suppressed, rule := sm.IsSuppressed(c.match)
+		if c.wantID == "" {
+			if suppressed {
+				t.Errorf("%s in %s suppressed by %s, want unsuppressed", c.match.Type, c.match.Filename, rule.ID)
+			}
+			continue
+		}
+		if !suppressed || rule.ID != c.wantID {
+			t.Errorf("%s in %s: suppressed=%v rule=%v, want %s", c.match.Type, c.match.Filename, suppressed, rule, c.wantID)
+		}
+	}
+}
+
+// TestIsSuppressed_HashRuleBeforePattern pins evaluation order: a hash rule that
+// identifies the finding wins over a pattern rule that also covers it, so the
+// rule reported against a finding is the most specific one.
+func TestIsSuppressed_HashRuleBeforePattern(t *testing.T) {
+	sm := NewSuppressionManager(filepath.Join(t.TempDir(), "suppressions.yaml"))
+	match := newTestMatch("EMAIL", "bob@example.com", "docs/readme.md")
+
+	patternID, err := sm.AddPatternSuppression(PatternMatch{Path: "docs/**"}, "docs", "tester", nil)
+	if err != nil {
+		t.Fatalf("AddPatternSuppression: %v", err)
+	}
+	if err := sm.AddSuppression(match, "specific", "tester", nil); err != nil {
+		t.Fatalf("AddSuppression: %v", err)
+	}
+
+	_, rule := sm.IsSuppressed(match)
+	if rule == nil || rule.ID == patternID {
+		t.Fatalf("rule = %v, want the hash rule", rule)
+	}
+}
+
+func TestIsSuppressed_PatternRuleHonoursEnabledAndExpiry(t *testing.T) {
+	sm := NewSuppressionManager(filepath.Join(t.TempDir(), "suppressions.yaml"))
+	match := newTestMatch("EMAIL", "carol@example.com", "docs/readme.md")
+
+	past := time.Now().Add(-time.Hour)
+	id, err := sm.AddPatternSuppression(PatternMatch{Path: "docs/**"}, "docs", "tester", &past)
+	if err != nil {
+		t.Fatalf("AddPatternSuppression: %v", err)
+	}
+	if suppressed, _ := sm.IsSuppressed(match); suppressed {
+		t.Error("expired pattern rule suppressed a finding")
+	}
+	if expired := sm.GetExpiredRule(match); expired == nil || expired.ID != id {
+		t.Errorf("GetExpiredRule = %v, want %s", expired, id)
+	}
+
+	if err := sm.EditSuppression(id, "docs", "tester", false, nil); err != nil {
+		t.Fatal(err)
+	}
+	if suppressed, _ := sm.IsSuppressed(match); suppressed {
+		t.Error("disabled pattern rule suppressed a finding")
+	}
+}
+
+// TestGenerateSuppressionRules_TouchesPatternRule checks that a finding covered by
+// a pattern rule refreshes that rule's last_seen_at instead of growing a hash rule
+// of its own — the bookkeeping the stale-rule audit depends on.
+func TestGenerateSuppressionRules_TouchesPatternRule(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "suppressions.yaml")
+	sm := NewSuppressionManager(path)
+	id, err := sm.AddPatternSuppression(PatternMatch{Path: "docs/**", Type: "EMAIL"}, "docs", "tester", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	matches := []detector.Match{
+		newTestMatch("EMAIL", "dave@example.com", "docs/a.md"),
+		newTestMatch("EMAIL", "erin@example.com", "src/a.go"),
+	}
+	if err := sm.GenerateSuppressionRules(matches, "generated", false); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded := NewSuppressionManager(path)
+	rules := reloaded.ListSuppressions()
+	if len(rules) != 2 {
+		t.Fatalf("got %d rules, want the pattern rule plus one generated rule", len(rules))
+	}
+	for _, rule := range rules {
+		if rule.ID == id {
+			if rule.LastSeenAt == nil {
+				t.Error("pattern rule last_seen_at not updated")
+			}
+			if rule.Match == nil || rule.Match.Path != "docs/**" {
+				t.Errorf("pattern matcher did not round-trip: %+v", rule.Match)
+			}
+		}
+	}
+}
+
+func TestEditPatternSuppression(t *testing.T) {
+	sm := NewSuppressionManager(filepath.Join(t.TempDir(), "suppressions.yaml"))
+	id, err := sm.AddPatternSuppression(PatternMatch{Path: "docs/**"}, "docs", "tester", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sm.EditPatternSuppression(id, PatternMatch{}); err == nil {
+		t.Error("EditPatternSuppression accepted an empty matcher")
+	}
+	if err := sm.EditPatternSuppression(id, PatternMatch{Path: "examples/**"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if suppressed, _ := sm.IsSuppressed(newTestMatch("SSN", "x", "docs/a.md")); suppressed {
+		t.Error("old matcher still applied after edit")
+	}
+	if suppressed, _ := sm.IsSuppressed(newTestMatch("SSN", "x", "examples/a.md")); !suppressed {
+		t.Error("new matcher not applied after edit")
+	}
+}
@@ -45,10 +45,14 @@ func annotateHashesWithAllowlistPragma(data []byte) []byte {
 	return hashLineWithoutComment.ReplaceAll(data, []byte("$1 # pragma: allowlist secret"))
 }
 
-// SuppressionRule represents a single suppression rule
+// SuppressionRule represents a single suppression rule. A hash rule (empty Kind)
+// suppresses the one finding whose identity hash it records; a pattern rule
+// (Kind "pattern") suppresses every finding its Match block describes.
 type SuppressionRule struct {
 	ID         string            `yaml:"id"`
-	Hash       string            `yaml:"hash"`
+	Kind       string            `yaml:"kind,omitempty"`
+	Hash       string            `yaml:"hash,omitempty"`
+	Match      *PatternMatch     `yaml:"match,omitempty"`
 	Reason     string            `yaml:"reason"`
 	Enabled    bool              `yaml:"enabled"`
 	CreatedBy  string            `yaml:"created_by,omitempty"`
@@ -83,7 +87,11 @@ type SuppressionManager struct {
 	// value is a slice; the original linear scan returned the first match,
 	// which we preserve here. Rebuilt on every load/save under indexMu.
 	rulesByHash map[string][]int
-	indexMu     sync.RWMutex
+	// patterns holds the compiled pattern rules, in file order. They are
+	// evaluated only after the hash index misses, so a file of hash rules pays
+	// nothing for them. Rebuilt alongside rulesByHash.
+	patterns []compiledPattern
+	indexMu  sync.RWMutex
 }
 
 // NewSuppressionManager creates a new suppression manager
@@ -165,17 +173,66 @@ func (sm *SuppressionManager) rebuildHashIndex() {
 }
 
 // rebuildHashIndexLocked rebuilds the index assuming the caller already holds
-// indexMu for writing.
+// indexMu for writing. Pattern rules are compiled here too; one that fails to
+// compile is reported on stderr and never matches, the same fail-closed stance
+// loadConfig takes for a malformed file.
 func (sm *SuppressionManager) rebuildHashIndexLocked() {
 	if sm.config == nil {
 		sm.rulesByHash = nil
+		sm.patterns = nil
 		return
 	}
 	idx := make(map[string][]int, len(sm.config.Rules))
+	var patterns []compiledPattern
 	for i, rule := range sm.config.Rules {
+		if rule.IsPattern() {
+			cp, err := compilePattern(rule.Match)
+			if err != nil {
+				fmt.Fprintf(os.Stderr,
+					"warning: suppression rule %s is invalid (%v) — it will NOT be applied\n",
+					rule.ID, err)
+				continue
+			}
+			cp.idx = i
+			patterns = append(patterns, cp)
+			continue
+		}
 		idx[rule.Hash] = append(idx[rule.Hash], i)
 	}
 	sm.rulesByHash = idx
+	sm.patterns = patterns
+}
+
+// activeRuleLocked returns the first enabled, unexpired rule that suppresses the
+// finding: hash rules through the index first, then pattern rules in file order.
+// The caller must hold indexMu for reading or writing.
+func (sm *SuppressionManager) activeRuleLocked(match detector.Match, candidates []string) *SuppressionRule {
+	now := time.Now()
+	active := func(rule *SuppressionRule) bool {
+		return rule.Enabled && ruleActive(rule, now)
+	}
+	for _, findingHash := range candidates {
+		for _, ruleIdx := range sm.rulesByHash[findingHash] {
+			if rule := &sm.config.Rules[ruleIdx]; active(rule) {
+				return rule
+			}
+		}
+	}
+	for i := range sm.patterns {
+		rule := &sm.config.Rules[sm.patterns[i].idx]
+		if active(rule) && sm.patterns[i].matches(match) {
+			return rule
+		}
+	}
+	return nil
+}
+
+// ruleActive reports whether rule has not expired at now. A rule is in force up
+// to and including the instant it expires, wherever expiry is decided: matching,
+// cleanup and rule generation must agree, or a rule matches findings that
+// cleanup has already dropped.
+func ruleActive(rule *SuppressionRule, now time.Time) bool {
+	return rule.ExpiresAt == nil || !now.After(*rule.ExpiresAt)
 }
 
 // generateFindingHash creates a unique hash for a finding
@@ -217,6 +274,31 @@ func (sm *SuppressionManager) generateFindingHash(match detector.Match) string {
 	return sm.findingHashVersion(match, hashVersionCurrent)
 }
 
+// FindingHash returns the current-version identity of a finding — the value a
+// newly written hash rule records. Formatters emit it so a scan result can be
+// turned back into rules or compared with another result without re-scanning.
+func FindingHash(match detector.Match) string {
+	return (&SuppressionManager{}).generateFindingHash(match)
+}
+
+// LineFreeHash identifies a finding wherever it sits in its file: the type, the
+// file's basename, and the hashes of its surrounding context and matched value
+// that the finding hash also folds in, without the line and its number. It is
+// not a suppression identity — a rule keyed on it would keep suppressing a
+// value after the line around it changed — but it is what pairs a finding
+// across two scans once lines inserted above it have moved it.
+func LineFreeHash(match detector.Match) string {
+	sm := &SuppressionManager{}
+	composite := strings.Join([]string{
+		match.Type,
+		filepath.Base(match.Filename),
+		sm.hashSensitiveData(match.Context.BeforeText + match.Context.AfterText),
+		sm.hashSensitiveData(match.Text),
+	}, "|")
+	hash := sha256.Sum256([]byte(composite))
+	return fmt.Sprintf("%x", hash)
+}
+
 // hashVersion identifies a finding-hash formula. Old rule files carry hashes computed
 // by an older formula, and they have to keep working: an operator's suppression file is
 // a record of decisions they already made, not a cache we may invalidate.
@@ -377,7 +459,9 @@ func (sm *SuppressionManager) hashSensitiveData(data string) string {
 	return fmt.Sprintf("%x", hash)[:16] // Use first 16 chars for brevity
 }
 
-// IsSuppressed checks if a finding should be suppressed.
+// IsSuppressed checks if a finding should be suppressed. Hash rules are
+// consulted first through the O(1) index; pattern rules are evaluated only when
+// no hash rule applies.
 // Safe for concurrent use across goroutines.
 func (sm *SuppressionManager) IsSuppressed(match detector.Match) (bool, *SuppressionRule) {
 	if !sm.enabled || sm.config == nil {
@@ -392,21 +476,9 @@ func (sm *SuppressionManager) IsSuppressed(match detector.Match) (bool, *Suppres
 	// Fast read-locked path: index is already built.
 	sm.indexMu.RLock()
 	if sm.rulesByHash != nil {
-		for _, findingHash := range candidates {
-			for _, ruleIdx := range sm.rulesByHash[findingHash] {
-				rule := &sm.config.Rules[ruleIdx]
-				if !rule.Enabled {
-					continue
-				}
-				if rule.ExpiresAt != nil && time.Now().After(*rule.ExpiresAt) {
-					continue
-				}
-				sm.indexMu.RUnlock()
-				return true, rule
-			}
-		}
+		rule := sm.activeRuleLocked(match, candidates)
 		sm.indexMu.RUnlock()
-		return false, nil
+		return rule != nil, rule
 	}
 	sm.indexMu.RUnlock()
 
@@ -417,21 +489,9 @@ func (sm *SuppressionManager) IsSuppressed(match detector.Match) (bool, *Suppres
 	if sm.rulesByHash == nil {
 		sm.rebuildHashIndexLocked()
 	}
-	for _, findingHash := range candidates {
-		for _, ruleIdx := range sm.rulesByHash[findingHash] {
-			rule := &sm.config.Rules[ruleIdx]
-			if !rule.Enabled {
-				continue
-			}
-			if rule.ExpiresAt != nil && time.Now().After(*rule.ExpiresAt) {
-				continue
-			}
-			sm.indexMu.Unlock()
-			return true, rule
-		}
-	}
+	rule := sm.activeRuleLocked(match, candidates)
 	sm.indexMu.Unlock()
-	return false, nil
+	return rule != nil, rule
 }
 
 // AddSuppression adds a new suppression rule
@@ -454,17 +514,7 @@ func (sm *SuppressionManager) AddSuppression(match detector.Match, reason, creat
 		}
 	}
 
-	// Generate unique ID with sequential number
-	maxID := 0
-	for _, existingRule := range sm.config.Rules {
-		if existingRule.ID != "" {
-			var num int
-			if _, err := fmt.Sscanf(existingRule.ID, "SUP-%08d", &num); err == nil && num > maxID {
-				maxID = num
-			}
-		}
-	}
-	id := fmt.Sprintf("SUP-%08d", maxID+1)
+	id := sm.nextRuleID()
 
 	// Set default expiration to 1 week if not provided
 	if expiresAt == nil {
@@ -559,7 +609,7 @@ func (sm *SuppressionManager) CleanupExpired() int {
 
 	var activeRules []SuppressionRule
 	for _, rule := range sm.config.Rules {
-		if rule.ExpiresAt == nil || now.Before(*rule.ExpiresAt) {
+		if ruleActive(&rule, now) {
 			activeRules = append(activeRules, rule)
 		}
 	}
@@ -607,9 +657,9 @@ func (sm *SuppressionManager) GetExpiredRule(match detector.Match) *SuppressionR
 	}
 
 	for _, rule := range sm.config.Rules {
-		if sm.hashMatchesFinding(rule.Hash, match) && rule.Enabled {
+		if sm.ruleMatchesFinding(rule, match) && rule.Enabled {
 			// Check if rule has expired
-			if rule.ExpiresAt != nil && time.Now().After(*rule.ExpiresAt) {
+			if !ruleActive(&rule, time.Now()) {
 				return &rule
 			}
 		}
@@ -618,6 +668,16 @@ func (sm *SuppressionManager) GetExpiredRule(match detector.Match) *SuppressionR
 	return nil
 }
 
+// ruleMatchesFinding reports whether a rule of either kind describes the
+// finding, ignoring its enabled and expiry state.
+func (sm *SuppressionManager) ruleMatchesFinding(rule SuppressionRule, match detector.Match) bool {
+	if !rule.IsPattern() {
+		return sm.hashMatchesFinding(rule.Hash, match)
+	}
+	cp, err := compilePattern(rule.Match)
+	return err == nil && cp.matches(match)
+}
+
 // SetEnabled enables or disables the suppression manager
 func (sm *SuppressionManager) SetEnabled(enabled bool) {
 	sm.enabled = enabled
@@ -649,7 +709,15 @@ func (sm *SuppressionManager) GenerateSuppressionRules(matches []detector.Match,
 	// through it would be silently lost. IsSuppressed already indexes by
 	// position for the same reason.
 	existingHashes := make(map[string]int, len(sm.config.Rules))
+	var patterns []compiledPattern
 	for i := range sm.config.Rules {
+		if sm.config.Rules[i].IsPattern() {
+			if cp, err := compilePattern(sm.config.Rules[i].Match); err == nil {
+				cp.idx = i
+				patterns = append(patterns, cp)
+			}
+			continue
+		}
 		existingHashes[sm.config.Rules[i].Hash] = i
 	}
 
@@ -657,16 +725,7 @@ func (sm *SuppressionManager) GenerateSuppressionRules(matches []detector.Match,
 	updatedCount := 0
 	now := time.Now()
 
-	// Find max ID once
-	maxID := 0
-	for _, existingRule := range sm.config.Rules {
-		if existingRule.ID != "" {
-			var num int
-			if _, err := fmt.Sscanf(existingRule.ID, "SUP-%08d", &num); err == nil && num > maxID {
-				maxID = num
-			}
-		}
-	}
+	nextID := sm.ruleIDSequence()
 
 	for _, match := range matches {
 		findingHash := sm.generateFindingHash(match)
@@ -684,6 +743,18 @@ func (sm *SuppressionManager) GenerateSuppressionRules(matches []detector.Match,
 				break
 			}
 		}
+		// A finding already covered by an active pattern rule needs no rule of
+		// its own; the pattern rule's last_seen_at is what records that it is
+		// still earning its place in the file.
+		if matchedIdx < 0 {
+			for i := range patterns {
+				rule := &sm.config.Rules[patterns[i].idx]
+				if rule.Enabled && ruleActive(rule, now) && patterns[i].matches(match) {
+					matchedIdx = patterns[i].idx
+					break
+				}
+			}
+		}
 		if idx := matchedIdx; idx >= 0 {
 			// Update last_seen_at for existing rule. Written through the live
 			// slice so it survives any reallocation caused by the appends below.
@@ -692,9 +763,6 @@ func (sm *SuppressionManager) GenerateSuppressionRules(matches []detector.Match,
 			continue
 		}
 
-		// Generate unique ID with sequential number
-		id := fmt.Sprintf("SUP-%08d", maxID+addedCount+1)
-
 		// Set default expiration to 1 week
 		defaultExpiry := now.AddDate(0, 0, 7)
 
@@ -709,7 +777,7 @@ func (sm *SuppressionManager) GenerateSuppressionRules(matches []detector.Match,
 		}
 
 		rule := SuppressionRule{
-			ID:         id,
+			ID:         nextID(),
 			Hash:       findingHash,
 			Reason:     ruleReason,
 			Enabled:    enabled,
@@ -834,17 +902,7 @@ func (sm *SuppressionManager) CreateSuppressionFromFindingWithExpiration(hash, r
 		}
 	}
 
-	// Generate unique ID with sequential number
-	maxID := 0
-	for _, existingRule := range sm.config.Rules {
-		if existingRule.ID != "" {
-			var num int
-			if _, err := fmt.Sscanf(existingRule.ID, "SUP-%08d", &num); err == nil && num > maxID {
-				maxID = num
-			}
-		}
-	}
-	id := fmt.Sprintf("SUP-%08d", maxID+1)
+	id := sm.nextRuleID()
 
 	// Extract metadata from finding data with proper hashes
 	metadata := map[string]string{
@@ -924,17 +982,7 @@ func (sm *SuppressionManager) CreateSuppressionFromFindingWithState(hash, reason
 		}
 	}
 
-	// Generate unique ID with sequential number
-	maxID := 0
-	for _, existingRule := range sm.config.Rules {
-		if existingRule.ID != "" {
-			var num int
-			if _, err := fmt.Sscanf(existingRule.ID, "SUP-%08d", &num); err == nil && num > maxID {
-				maxID = num
-			}
-		}
-	}
-	id := fmt.Sprintf("SUP-%08d", maxID+1)
+	id := sm.nextRuleID()
 
 	// Extract metadata from finding data
 	metadata := map[string]string{
@@ -963,3 +1011,68 @@ func (sm *SuppressionManager) CreateSuppressionFromFindingWithState(hash, reason
 	sm.config.Rules = append(sm.config.Rules, rule)
 	return sm.saveConfig()
 }
+
+// nextRuleID returns the next sequential SUP-NNNNNNNN identifier. Every path
+// that creates a rule takes its ID from here, so they cannot number rules
+// differently.
+func (sm *SuppressionManager) nextRuleID() string {
+	maxID := 0
+	for _, existingRule := range sm.config.Rules {
+		var num int
+		if _, err := fmt.Sscanf(existingRule.ID, "SUP-%08d", &num); err == nil && num > maxID {
+			maxID = num
+		}
+	}
+	return fmt.Sprintf("SUP-%08d", maxID+1)
+}
+
+// AddPatternSuppression adds a pattern rule and returns its ID. The matcher is
+// validated before anything is written, so a rule that could never match — or
+// one that would match everything — is refused instead of saved.
+func (sm *SuppressionManager) AddPatternSuppression(pm PatternMatch, reason, createdBy string, expiresAt *time.Time) (string, error) {
+	if _, err := compilePattern(&pm); err != nil {
+		return "", err
+	}
+	if sm.config == nil {
+		sm.config = &SuppressionConfig{
+			Version: "1.0",
+			Rules:   []SuppressionRule{},
+		}
+	}
+
+	id := sm.nextRuleID()
+	sm.config.Rules = append(sm.config.Rules, SuppressionRule{
+		ID:        id,
+		Kind:      RuleKindPattern,
+		Match:     &pm,
+		Reason:    reason,
+		Enabled:   true,
+		CreatedBy: createdBy,
+		CreatedAt: time.Now(),
+		ExpiresAt: expiresAt,
+	})
+	return id, sm.saveConfig()
+}
+
+// EditPatternSuppression replaces the matcher of an existing pattern rule.
+// Reason, status and expiry are edited through EditSuppression like any rule.
+func (sm *SuppressionManager) EditPatternSuppression(id string, pm PatternMatch) error {
+	if sm.config == nil {
+		return fmt.Errorf("no suppression config loaded")
+	}
+	if _, err := compilePattern(&pm); err != nil {
+		return err
+	}
+
+	for i := range sm.config.Rules {
+		if sm.config.Rules[i].ID == id {
+			if !sm.config.Rules[i].IsPattern() {
+				return fmt.Errorf("suppression rule %s is not a pattern rule", id)
+			}
+			sm.config.Rules[i].Match = &pm
+			return sm.saveConfig()
+		}
+	}
+
+	return fmt.Errorf("suppression rule with ID %s not found", id)
+}
@@ -120,6 +120,27 @@ func TestCleanupExpired(t *testing.T) {
 	}
 }
 
+// A rule is in force through the instant it expires, and matching, cleanup and
+// rule generation all decide that through ruleActive.
+func TestRuleActiveAtExpiry(t *testing.T) {
+	now := time.Now()
+	later, earlier := now.Add(time.Second), now.Add(-time.Nanosecond)
+	for _, tc := range []struct {
+		name    string
+		expires *time.Time
+		want    bool
+	}{
+		{"never", nil, true},
+		{"later", &later, true},
+		{"at this instant", &now, true},
+		{"earlier", &earlier, false},
+	} {
+		if got := ruleActive(&SuppressionRule{ExpiresAt: tc.expires}, now); got != tc.want {
+			t.Errorf("%s: ruleActive = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
 func TestSetEnabled(t *testing.T) {
 	sm := NewSuppressionManager("")
 	sm.SetEnabled(false)
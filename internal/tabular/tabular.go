@@ -33,9 +33,16 @@
 //     so the tests below are deliberately strict and any ambiguity (unbalanced
 //     quotes, ragged rows, no header-ish cells) means "not a table" and the
 //     caller's existing behavior is unchanged.
+//   - One header per sheet. A spreadsheet is extracted as one text, each sheet
+//     under a "--- name ---" heading line, and every sheet has its own header
+//     row. Content carrying heading lines is analyzed sheet by sheet, and a
+//     caller asks At(line) for the table that governs a line. Before this the
+//     heading itself was taken for the header row of the whole workbook and
+//     nothing in a spreadsheet was ever recognised as tabular.
 package tabular
 
 import (
+	"regexp"
 	"sort"
 	"strings"
 )
@@ -83,6 +90,42 @@ type Table struct {
 	headerLine int
 	// ok reports whether the content was recognised as tabular.
 	ok bool
+
+	// start is the 0-based line at which the lines this table governs begin.
+	start int
+	// headed reports that line start is the sheet's heading line, which belongs
+	// to no table.
+	headed bool
+	// sheets holds a table per sheet, in line order, when the content carries
+	// sheet heading lines; nil otherwise. See At.
+	sheets []*Table
+}
+
+// sheetHeadingRe matches the line a spreadsheet extractor writes before each
+// sheet's rows ("--- sheet1 ---", "--- Payroll ---").
+var sheetHeadingRe = regexp.MustCompile(`^--- .+ ---\r?$`)
+
+// notTable is what At returns for lines no table governs. Table methods never
+// modify their receiver, so one value serves every caller.
+var notTable = &Table{}
+
+// At returns the table governing line (0-based): the sheet's own table when the
+// content was analyzed sheet by sheet, the whole content's table otherwise. The
+// result is never nil, and is a non-table for lines outside any recognised
+// table, sheet heading lines included. Its HeaderLine is a line of the whole
+// content, so callers compare it with the same line index they passed in.
+func (t *Table) At(line int) *Table {
+	if t == nil {
+		return notTable
+	}
+	if len(t.sheets) == 0 {
+		return t
+	}
+	i := sort.Search(len(t.sheets), func(i int) bool { return t.sheets[i].start > line }) - 1
+	if i < 0 || (t.sheets[i].headed && line == t.sheets[i].start) {
+		return notTable
+	}
+	return t.sheets[i]
 }
 
 // IsTable reports whether the content was recognised as delimited tabular data.
@@ -130,6 +173,10 @@ func (b *LineBounds) Fields() int {
 // Analyze inspects content once and returns the recognised table, or a non-table
 // Table when the content is not delimited data.
 //
+// Content with sheet heading lines is analyzed sheet by sheet: the result is then
+// a table when any sheet is one, its own accessors describe the first such sheet,
+// and At resolves a line to its sheet's table.
+//
 // Failure modes are deliberate returns, not errors: every rejection leaves the
 // caller with IsTable() == false and therefore unchanged behavior.
 func Analyze(content string) *Table {
@@ -138,6 +185,46 @@ func Analyze(content string) *Table {
 	}
 	lines := strings.Split(content, "\n")
 
+	var headings []int
+	for i, line := range lines {
+		if sheetHeadingRe.MatchString(line) {
+			headings = append(headings, i)
+		}
+	}
+	if len(headings) == 0 {
+		return analyzeLines(lines, 0)
+	}
+
+	// A sheet runs from its heading to the next; text before the first heading
+	// is a segment of its own.
+	bounds := headings
+	if headings[0] != 0 {
+		bounds = append([]int{0}, headings...)
+	}
+	root := &Table{}
+	for i, start := range bounds {
+		end := len(lines)
+		if i+1 < len(bounds) {
+			end = bounds[i+1]
+		}
+		headed := sheetHeadingRe.MatchString(lines[start])
+		body := start
+		if headed {
+			body++
+		}
+		sheet := analyzeLines(lines[body:end], body)
+		sheet.start, sheet.headed = start, headed
+		root.sheets = append(root.sheets, sheet)
+		if sheet.ok && !root.ok {
+			root.delimiter, root.headers, root.headerLine, root.ok = sheet.delimiter, sheet.headers, sheet.headerLine, true
+		}
+	}
+	return root
+}
+
+// analyzeLines recognises a table in lines, which begin at line offset of the
+// content; the recorded header line counts from the start of the content.
+func analyzeLines(lines []string, offset int) *Table {
 	// Find the header candidate: the first line that is not blank and not a
 	// comment. A preamble of "#"-commented lines is common in exported data.
 	headerIdx := -1
@@ -210,7 +297,7 @@ func Analyze(content string) *Table {
 	for i, c := range cells {
 		headers[i] = strings.ToLower(strings.Trim(strings.TrimSpace(c), `"`))
 	}
-	return &Table{delimiter: delim, headers: headers, headerLine: headerIdx, ok: true}
+	return &Table{delimiter: delim, headers: headers, headerLine: offset + headerIdx, ok: true}
 }
 
 // Bounds computes the field-start offsets for one line. Call once per line and
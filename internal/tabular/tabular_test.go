@@ -338,3 +338,49 @@ func TestAnalyzeIsDeterministic(t *testing.T) {
 		t.Errorf("tie-break chose %q, want ',' (the earlier candidate)", first.Delimiter())
 	}
 }
+
+// A flattened workbook holds one table per sheet under a "--- name ---"
+// separator. Each sheet's rows are read against its own header row, not the
+// first sheet's, and the separator is never taken for a header.
+func TestAtUsesEachSheetsOwnHeader(t *testing.T) {
+	content := "--- Staff ---\n" +
+		"name\tssn\t\n" +
+		"Jane\t536-22-1874\t\n" +
+		"Bob\t449-87-4100\t\n" +
+		"\n" +
+		"--- Travel ---\n" +
+		"id\tcountry\tpassport\t\n" +
+		"1\tUS\t987654321\t\n" +
+		"2\tCA\t512345678\t\n"
+	lines := strings.Split(content, "\n")
+	table := Analyze(content)
+
+	staff := table.At(2)
+	if !staff.IsTable() || staff.HeaderLine() != 1 {
+		t.Fatalf("line 2: IsTable=%v HeaderLine=%d, want the Staff header on line 1", staff.IsTable(), staff.HeaderLine())
+	}
+	if h := staff.HeaderAt(staff.Bounds(lines[2]), strings.Index(lines[2], "536")); h != "ssn" {
+		t.Errorf("Staff value read under %q, want ssn", h)
+	}
+
+	travel := table.At(7)
+	if !travel.IsTable() || travel.HeaderLine() != 6 {
+		t.Fatalf("line 7: IsTable=%v HeaderLine=%d, want the Travel header on line 6", travel.IsTable(), travel.HeaderLine())
+	}
+	if h := travel.HeaderAt(travel.Bounds(lines[7]), strings.Index(lines[7], "987")); h != "passport" {
+		t.Errorf("Travel value read under %q, want passport", h)
+	}
+
+	if table.At(0).IsTable() || table.At(5).IsTable() {
+		t.Error("a sheet separator line was treated as part of a table")
+	}
+}
+
+// Content without sheet separators is one table, and At returns it for every
+// line.
+func TestAtWithoutSheetsIsTheWholeTable(t *testing.T) {
+	table := Analyze("name,passport\nJane,987654321\nBob,512345678\n")
+	if got := table.At(1); got != table {
+		t.Errorf("At returned a different table for unsheeted content")
+	}
+}
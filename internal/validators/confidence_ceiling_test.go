@@ -7,7 +7,10 @@ import (
 	"testing"
 
 	"github.com/awslabs/ferret-scan/v2/internal/detector"
+	"github.com/awslabs/ferret-scan/v2/internal/validators/docfingerprint"
+	"github.com/awslabs/ferret-scan/v2/internal/validators/edmmatch"
 	"github.com/awslabs/ferret-scan/v2/internal/validators/secrets"
+	"github.com/awslabs/ferret-scan/v2/internal/validators/typemismatch"
 )
 
 // A validator can declare a hard ceiling on a finding's confidence, and the bridge
@@ -93,4 +96,53 @@ func TestCeilingKeyMatchesTheSecretsValidator(t *testing.T) {
 			"findings it protects go back to being promoted by document context.",
 			ConfidenceCeilingKey, secrets.ConfidenceCeilingKey)
 	}
+	if ConfidenceCeilingKey != typemismatch.ConfidenceCeilingKey {
+		t.Errorf("ceiling key drift: bridge has %q, file type validator has %q",
+			ConfidenceCeilingKey, typemismatch.ConfidenceCeilingKey)
+	}
+}
+
+// A floor is the mirror of a ceiling: a finding certain because of the value itself
+// keeps its band through the test-data and other document-context penalties.
+func TestClampToFloorHonoursADeclaredFloor(t *testing.T) {
+	m := detector.Match{
+		Confidence: 70,
+		Metadata:   map[string]any{ConfidenceFloorKey: 90.0},
+	}
+	clampToFloor(&m)
+	if m.Confidence != 90 {
+		t.Errorf("Confidence = %v, want 90: a declared floor must survive the "+
+			"document-context penalties applied after the validator returns", m.Confidence)
+	}
+
+	m = detector.Match{Confidence: 100, Metadata: map[string]any{ConfidenceFloorKey: 90.0}}
+	clampToFloor(&m)
+	if m.Confidence != 100 {
+		t.Errorf("Confidence = %v, want 100 unchanged: a floor is a lower bound", m.Confidence)
+	}
+
+	for _, meta := range []map[string]any{nil, {ConfidenceFloorKey: 90}, {ConfidenceFloorKey: -1.0}} {
+		m := detector.Match{Confidence: 40, Metadata: meta}
+		clampToFloor(&m)
+		if m.Confidence != 40 {
+			t.Errorf("metadata %v: Confidence = %v, want 40 unchanged", meta, m.Confidence)
+		}
+	}
+}
+
+// TestFloorKeyMatchesItsValidators guards the floor's duplicated literal, as
+// TestCeilingKeyMatchesTheSecretsValidator guards the ceiling's.
+func TestFloorKeyMatchesItsValidators(t *testing.T) {
+	if ConfidenceFloorKey != edmmatch.ConfidenceFloorKey {
+		t.Errorf("floor key drift: bridge has %q, EDM validator has %q",
+			ConfidenceFloorKey, edmmatch.ConfidenceFloorKey)
+	}
+	if ConfidenceFloorKey != docfingerprint.ConfidenceFloorKey {
+		t.Errorf("floor key drift: bridge has %q, fingerprint validator has %q",
+			ConfidenceFloorKey, docfingerprint.ConfidenceFloorKey)
+	}
+	if ConfidenceFloorKey != typemismatch.ConfidenceFloorKey {
+		t.Errorf("floor key drift: bridge has %q, file type validator has %q",
+			ConfidenceFloorKey, typemismatch.ConfidenceFloorKey)
+	}
 }
@@ -0,0 +1,44 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package docfingerprint
+
+import "github.com/awslabs/ferret-scan/v2/internal/help"
+
+// GetCheckInfo returns standardized information about the DOCUMENT_FINGERPRINT check.
+func (v *Validator) GetCheckInfo() help.CheckInfo {
+	return help.CheckInfo{
+		Name:             CheckType,
+		ShortDescription: "Finds copies and excerpts of registered confidential documents",
+		DetailedDescription: `The DOCUMENT_FINGERPRINT check reports content copied from documents you have registered as confidential: a board deck pasted into a ticket, a contract excerpt in an email, a design document saved as a PDF in a public bucket. It names the registered document and how much of the content it shares.
+
+Documents are registered with "ferret-scan fingerprint register plan.docx memo.pdf --store docs.fp". Their text is extracted by the same preprocessors a scan uses, so a document registered as a Word file is found in a PDF, a text file or a spreadsheet cell. The store holds hashes of six-word runs chosen by winnowing, and the registered names; it holds no text.
+
+Case, spacing, line breaks and punctuation are ignored, so a reflowed or reformatted copy is found as well as an exact one. A shared passage is found wherever it sits in the content; a couple of shared sentences are the least that is reported.
+
+Similarity is the share of the smaller text's fingerprints found in the other: a whole copy is 100%, and so is an excerpt that makes up all of an email. Content is reported from 30% similarity (validators.fingerprint.threshold) and at least four shared fingerprints, at MEDIUM confidence rising to HIGH from 75%. Each shared stretch of content is a finding, so every copied stretch is redacted.
+
+This check is separate from INTELLECTUAL_PROPERTY, which looks for patterns (patent numbers, copyright notices, internal URLs) rather than for the text of particular documents.`,
+
+		Patterns: []string{
+			"Text sharing runs of words with a registered document, after normalising case, spacing and punctuation",
+			"An excerpt of a registered document inside a longer text",
+		},
+
+		SupportedFormats: []string{
+			"Documents registered in any format the preprocessors extract text from",
+		},
+
+		ConfidenceFactors: []help.ConfidenceFactor{
+			{Name: "Similarity", Description: "60 plus 0.4 times the similarity in percent: MEDIUM at the threshold, HIGH from 75%", Weight: 100},
+		},
+
+		ConfigurationInfo: "Set validators.fingerprint.store to the path of a store built with 'ferret-scan fingerprint register', or pass --fingerprints on the command line. validators.fingerprint.threshold sets the similarity in percent reported from (default 30).",
+
+		Examples: []string{
+			"ferret-scan fingerprint register board-deck.pdf strategy.docx --store confidential.fp",
+			"ferret-scan --file ./shared --recursive --fingerprints confidential.fp",
+			"ferret-scan --file mail.eml --fingerprints confidential.fp --checks DOCUMENT_FINGERPRINT",
+		},
+	}
+}
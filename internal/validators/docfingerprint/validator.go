@@ -0,0 +1,228 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package docfingerprint reports scanned content copied from a registered
+// confidential document (a fingerprint store, see internal/fingerprint).
+package docfingerprint
+
+import (
+	stdctx "context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/awslabs/ferret-scan/v2/internal/config"
+	"github.com/awslabs/ferret-scan/v2/internal/detector"
+	"github.com/awslabs/ferret-scan/v2/internal/fingerprint"
+	"github.com/awslabs/ferret-scan/v2/internal/observability"
+)
+
+// CheckType is the finding type, and the name --checks selects the check by.
+const CheckType = "DOCUMENT_FINGERPRINT"
+
+// ConfigSection is the validators section of the configuration this check
+// reads; StoreKey names the store file in it and ThresholdKey the similarity,
+// in percent, from which a copy is reported.
+const (
+	ConfigSection = "fingerprint"
+	StoreKey      = "store"
+	ThresholdKey  = "threshold"
+)
+
+// DefaultThreshold is the similarity, in percent, reported from when the
+// configuration sets none: a third of the smaller text is well past the
+// stock phrases two unrelated documents of one company share.
+const DefaultThreshold = 30
+
+// ConfidenceFloorKey is the Match.Metadata key carrying a hard lower bound on a
+// finding's confidence, read by the dual-path bridge after its context
+// adjustments. The literal is duplicated rather than imported to keep the
+// bridge from depending on a validator package; a bridge test fails if the
+// two drift apart.
+const ConfidenceFloorKey = "confidence_floor"
+
+// Validator finds content copied from the documents of a fingerprint store.
+// With no store configured it finds nothing.
+type Validator struct {
+	store     *fingerprint.Store
+	threshold float64
+	// loadErr is why the configured store could not be used. It is returned
+	// by every scan, so a missing store fails the check loudly rather than
+	// reporting a clean file.
+	loadErr error
+
+	observer observability.Observer
+}
+
+// NewValidator creates a validator with no store.
+func NewValidator() *Validator {
+	return &Validator{threshold: DefaultThreshold}
+}
+
+// SetObserver sets the observability component.
+func (v *Validator) SetObserver(observer observability.Observer) {
+	v.observer = observer
+}
+
+// Configure loads the store named by validators.fingerprint.store and the
+// threshold set by validators.fingerprint.threshold. A setting the config does
+// not make is left as it was, so a profile without a fingerprint section keeps
+// the store and threshold the global configuration set.
+func (v *Validator) Configure(cfg *config.Config) {
+	if cfg == nil || cfg.Validators == nil {
+		return
+	}
+	section := cfg.Validators[ConfigSection]
+	if path, _ := section[StoreKey].(string); path != "" {
+		v.store, v.loadErr = fingerprint.Open(path)
+	}
+	var threshold float64
+	switch t := section[ThresholdKey].(type) {
+	case nil:
+		return
+	case int:
+		threshold = float64(t)
+	case float64:
+		threshold = t
+	default:
+		v.loadErr = fmt.Errorf("validators.%s.%s must be a number, got %v", ConfigSection, ThresholdKey, t)
+		return
+	}
+	if threshold <= 0 || threshold > 100 {
+		v.loadErr = fmt.Errorf("validators.%s.%s must be above 0 and at most 100, got %v", ConfigSection, ThresholdKey, threshold)
+		return
+	}
+	v.threshold = threshold
+}
+
+// ValidateContent validates preprocessed content against the store.
+func (v *Validator) ValidateContent(content string, originalPath string) ([]detector.Match, error) {
+	// Backward-compatible shim: run with a background context (never cancels).
+	return v.ValidateContentCtx(stdctx.Background(), content, originalPath)
+}
+
+// ValidateContentCtx implements execguard.ContextAwareValidator. Similarity is
+// a property of the whole content, so it is measured in one pass rather than
+// line by line; ctx is polled before the pass and between the findings it
+// yields.
+//
+// Each line of each stretch of content shared with a registered document is
+// one finding, carrying the document and the similarity of the whole content
+// to it. Redaction removes every copied stretch and not only the first, and
+// removes it line by line, as every redactor locates a value within its line
+// or paragraph.
+func (v *Validator) ValidateContentCtx(ctx stdctx.Context, content string, originalPath string) ([]detector.Match, error) {
+	if v.loadErr != nil {
+		return nil, v.loadErr
+	}
+	if v.store == nil {
+		return nil, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var finishTiming func(bool, map[string]interface{})
+	if v.observer != nil {
+		finishTiming = v.observer.StartTiming("fingerprint_validator", "validate_content", originalPath)
+	}
+
+	var matches []detector.Match
+	for _, m := range v.store.Compare(content, v.threshold/100) {
+		if err := ctx.Err(); err != nil {
+			if finishTiming != nil {
+				finishTiming(false, map[string]interface{}{"cancelled": true, "match_count": len(matches)})
+			}
+			return matches, err
+		}
+		percent := math.Round(m.Similarity * 100)
+		confidence := confidenceFor(percent)
+		for _, p := range m.Passages {
+			for _, l := range passageLines(content, p) {
+				matches = append(matches, detector.Match{
+					Text:       content[l.start:l.end],
+					LineNumber: l.number,
+					Type:       CheckType,
+					Confidence: confidence,
+					Filename:   originalPath,
+					Validator:  "fingerprint",
+					Context:    detector.LineContext(content[l.lineStart:l.lineEnd], l.start-l.lineStart, l.end-l.lineStart),
+					Metadata: map[string]any{
+						"source":               "preprocessed_content",
+						"fingerprint_document": m.Document,
+						"similarity_percent":   int(percent),
+						"matched_fingerprints": m.Matched,
+						ConfidenceFloorKey:     confidence,
+					},
+				})
+			}
+		}
+	}
+
+	if finishTiming != nil {
+		finishTiming(true, map[string]interface{}{"match_count": len(matches)})
+	}
+	return matches, nil
+}
+
+// span is the part of one line a passage covers.
+type span struct {
+	number             int // 1-based line number
+	start, end         int // the covered text, trimmed of surrounding space
+	lineStart, lineEnd int // the whole line
+}
+
+// passageLines splits passage p of content into the parts of each line it
+// covers, leaving out lines it covers only blank space of.
+func passageLines(content string, p fingerprint.Passage) []span {
+	var out []span
+	number := strings.Count(content[:p.Start], "\n") + 1
+	lineStart := strings.LastIndexByte(content[:p.Start], '\n') + 1
+	for start := p.Start; start < p.End; number++ {
+		lineEnd := strings.IndexByte(content[lineStart:], '\n')
+		if lineEnd < 0 {
+			lineEnd = len(content)
+		} else {
+			lineEnd += lineStart
+		}
+		end := lineEnd
+		if end > p.End {
+			end = p.End
+		}
+		s, e := start, end
+		for s < e && isSpace(content[s]) {
+			s++
+		}
+		for e > s && isSpace(content[e-1]) {
+			e--
+		}
+		if s < e {
+			out = append(out, span{number: number, start: s, end: e, lineStart: lineStart, lineEnd: lineEnd})
+		}
+		start, lineStart = lineEnd+1, lineEnd+1
+	}
+	return out
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\r' || b == '\n'
+}
+
+// confidenceFor maps a similarity in percent to a confidence: MEDIUM from the
+// lowest threshold, HIGH from three quarters of the content shared. The
+// overlap is measured, not guessed, so it is also the finding's floor.
+func confidenceFor(percent float64) float64 {
+	return 60 + 0.4*percent
+}
+
+// CalculateConfidence is part of the detector.Validator interface. Confidence
+// comes from the similarity of a whole text, which one match string does not
+// have.
+func (v *Validator) CalculateConfidence(match string) (float64, map[string]bool) {
+	return 0, map[string]bool{}
+}
+
+// AnalyzeContext is part of the detector.Validator interface. Context does not
+// move a measured overlap.
+func (v *Validator) AnalyzeContext(match string, context detector.ContextInfo) float64 {
+	return 0
+}
@@ -0,0 +1,156 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package docfingerprint
+
+import (
+	"context"
+	"math/rand"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/awslabs/ferret-scan/v2/internal/config"
+	"github.com/awslabs/ferret-scan/v2/internal/fingerprint"
+)
+
+var vocabulary = strings.Fields(`the board approved a revised forecast for
+northern region revenue after merger talks with our largest supplier stalled
+in march while legal reviewed pricing terms and margin targets across every
+product line including retail wholesale and licensing channels next quarter`)
+
+// prose returns n words drawn from vocabulary: text no run of six words
+// repeats in, so only what was copied is shared.
+func prose(seed int64, n int) string {
+	r := rand.New(rand.NewSource(seed))
+	ws := make([]string, n)
+	for i := range ws {
+		ws[i] = vocabulary[r.Intn(len(vocabulary))]
+	}
+	return strings.Join(ws, " ")
+}
+
+func configured(t *testing.T, section map[string]interface{}, docs map[string]string) *Validator {
+	t.Helper()
+	s := fingerprint.NewStore()
+	for name, text := range docs {
+		if _, err := s.Add(name, text); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(t.TempDir(), "docs.fp")
+	if err := s.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if section == nil {
+		section = map[string]interface{}{}
+	}
+	section[StoreKey] = path
+	v := NewValidator()
+	v.Configure(&config.Config{Validators: map[string]map[string]interface{}{ConfigSection: section}})
+	return v
+}
+
+func TestValidateContent(t *testing.T) {
+	plan := prose(1, 300)
+	v := configured(t, nil, map[string]string{"board/plan.docx": plan})
+
+	// Two excerpts of the plan among unrelated lines: one on a line of its
+	// own, one reflowed into a line of five words each.
+	pw := strings.Fields(plan)
+	var reflowed []string
+	for i := 150; i < 180; i += 5 {
+		reflowed = append(reflowed, "  "+strings.Join(pw[i:i+5], " "))
+	}
+	content := "Subject: notes\n" + prose(2, 20) + "\n" +
+		strings.Join(pw[20:70], " ") + "\n" + prose(3, 20) + "\n" +
+		strings.Join(reflowed, "\n") + "\n" + prose(4, 20)
+	matches, err := v.ValidateContent(content, "mail.eml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) < 3 {
+		t.Fatalf("got %d matches (%+v), want the excerpts", len(matches), matches)
+	}
+	lines := strings.Split(content, "\n")
+	first := matches[0]
+	if first.Type != CheckType || first.LineNumber != 3 || !strings.Contains(lines[2], first.Text) {
+		t.Errorf("first match = %+v, want part of the one-line excerpt on line 3", first)
+	}
+	for _, m := range matches[1:] {
+		// One finding per line of the reflowed excerpt, trimmed of its indent.
+		if m.LineNumber < 5 || m.LineNumber > 10 || !strings.Contains(lines[m.LineNumber-1], m.Text) || strings.HasPrefix(m.Text, " ") {
+			t.Errorf("match %+v is not within a line of the reflowed excerpt", m)
+		}
+	}
+	for _, m := range matches {
+		if m.Metadata["fingerprint_document"] != "board/plan.docx" || m.Context.FullLine != lines[m.LineNumber-1] {
+			t.Errorf("match %+v: metadata %v", m, m.Metadata)
+		}
+		percent, _ := m.Metadata["similarity_percent"].(int)
+		if percent < 30 || m.Confidence != 60+0.4*float64(percent) || m.Metadata[ConfidenceFloorKey] != m.Confidence {
+			t.Errorf("similarity %v%% with confidence %v and floor %v", percent, m.Confidence, m.Metadata[ConfidenceFloorKey])
+		}
+	}
+}
+
+func TestWholeCopyIsHighConfidence(t *testing.T) {
+	plan := prose(1, 300)
+	v := configured(t, nil, map[string]string{"plan.docx": plan})
+	matches, err := v.ValidateContent(strings.ToUpper(plan), "copy.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || matches[0].Metadata["similarity_percent"] != 100 || matches[0].Confidence != 100 {
+		t.Errorf("matches = %+v, want the one line at 100%% and confidence 100", matches)
+	}
+}
+
+func TestThreshold(t *testing.T) {
+	plan := prose(1, 300)
+	content := strings.Join(strings.Fields(plan)[:60], " ") + " " + prose(5, 240)
+	low := configured(t, map[string]interface{}{ThresholdKey: 10}, map[string]string{"plan.docx": plan})
+	if matches, err := low.ValidateContent(content, "a.txt"); err != nil || len(matches) == 0 {
+		t.Fatalf("threshold 10: matches = %v, err = %v; want the excerpt", matches, err)
+	}
+	high := configured(t, map[string]interface{}{ThresholdKey: 90.0}, map[string]string{"plan.docx": plan})
+	if matches, err := high.ValidateContent(content, "a.txt"); err != nil || matches != nil {
+		t.Errorf("threshold 90: matches = %v, err = %v; want nothing", matches, err)
+	}
+	for _, bad := range []interface{}{0, 101, "high"} {
+		v := configured(t, map[string]interface{}{ThresholdKey: bad}, map[string]string{"plan.docx": plan})
+		if _, err := v.ValidateContent(content, "a.txt"); err == nil {
+			t.Errorf("threshold %v: ValidateContent returned no error", bad)
+		}
+	}
+}
+
+func TestNoStoreFindsNothing(t *testing.T) {
+	v := NewValidator()
+	v.Configure(&config.Config{})
+	if matches, err := v.ValidateContent(prose(1, 300), "a.txt"); err != nil || matches != nil {
+		t.Errorf("ValidateContent = %v, %v; want nothing without a store", matches, err)
+	}
+}
+
+// A configured store that cannot be read must fail the scan, not report the
+// file clean.
+func TestUnreadableStoreIsAnError(t *testing.T) {
+	v := NewValidator()
+	v.Configure(&config.Config{Validators: map[string]map[string]interface{}{
+		ConfigSection: {StoreKey: filepath.Join(t.TempDir(), "missing.fp")},
+	}})
+	if _, err := v.ValidateContent("anything", "a.txt"); err == nil {
+		t.Error("ValidateContent with a missing store returned no error")
+	}
+}
+
+func TestValidateContentCtxCancelled(t *testing.T) {
+	plan := prose(1, 300)
+	v := configured(t, nil, map[string]string{"plan.docx": plan})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := v.ValidateContentCtx(ctx, plan, "a.txt"); err != context.Canceled {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
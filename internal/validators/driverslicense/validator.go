@@ -253,16 +253,18 @@ func (v *Validator) ValidateContentCtx(ctx stdctx.Context, content string, origi
 		// The memo keys on the header cell, of which there are only as many as there
 		// are columns, and each is a short string: this cannot reintroduce the
 		// per-match whole-line scan that made this validator quadratic before.
+		// rows is the table governing this line: in a spreadsheet, its sheet's.
+		rows := table.At(lineNum)
 		var lineBounds *tabular.LineBounds
-		if table.IsTable() && lineNum != table.HeaderLine() {
-			lineBounds = table.Bounds(line)
+		if rows.IsTable() && lineNum != rows.HeaderLine() {
+			lineBounds = rows.Bounds(line)
 		}
 		headerImpact := make(map[string]float64, 4)
 		impactForColumn := func(off int) float64 {
 			if lineBounds == nil {
 				return 0
 			}
-			h := table.HeaderAt(lineBounds, off)
+			h := rows.HeaderAt(lineBounds, off)
 			if h == "" {
 				return 0
 			}
@@ -772,6 +774,7 @@ func isWordByte(b byte) bool {
 // Used only for admission. The precise per-column decision is impactForColumn, which
 // scores the header of the match's own column.
 func (v *Validator) tableHeaderHasPositiveKeyword(table *tabular.Table, lineNum int) bool {
+	table = table.At(lineNum)
 	if !table.IsTable() || lineNum == table.HeaderLine() {
 		return false
 	}
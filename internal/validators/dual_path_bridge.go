@@ -288,7 +288,7 @@ func (evb *EnhancedValidatorBridge) ProcessContentCtx(ctx stdctx.Context, conten
 	}
 
 	// Process content through dual paths
-	result, err := evb.processDualPath(ctx, routedContent, contextInsights)
+	result, err := evb.processDualPath(ctx, routedContent, contextInsights, content.PageLookup(), content.SourceLookup())
 	if err != nil {
 		// A context cancellation/timeout OR a per-validator budget outcome (v2 Move
 		// C: time/match budget) is terminal: do NOT fall back to a legacy re-run —
@@ -358,7 +358,13 @@ func (evb *EnhancedValidatorBridge) ProcessContentCtx(ctx stdctx.Context, conten
 
 // processDualPath processes content through both validation paths. ctx is
 // threaded to the document-path dispatch chokepoint.
-func (evb *EnhancedValidatorBridge) processDualPath(ctx stdctx.Context, routedContent *router.RoutedContent, contextInsights context.ContextInsights) (*DualPathValidationResult, error) {
+//
+// pageOf maps a line of the full extraction to its page, and is nil for unpaged
+// content; sourceOf maps it to the source a finding there is reported against,
+// and is nil when no section claims one. Both apply to document-path matches
+// only: those are counted against FullText, while a metadata-path match counts
+// lines within its own item and already carries its item's source.
+func (evb *EnhancedValidatorBridge) processDualPath(ctx stdctx.Context, routedContent *router.RoutedContent, contextInsights context.ContextInsights, pageOf func(line int) int, sourceOf func(line int) string) (*DualPathValidationResult, error) {
 	startTime := time.Now()
 
 	result := &DualPathValidationResult{
@@ -399,6 +405,10 @@ func (evb *EnhancedValidatorBridge) processDualPath(ctx stdctx.Context, routedCo
 	documentText := routedContent.FullText
 	if documentText == "" {
 		documentText = routedContent.DocumentBody
+		// Lines are then counted in the routed body, not the extraction the
+		// page and section boundaries describe.
+		pageOf = nil
+		sourceOf = nil
 	}
 
 	// Process document body content in parallel
@@ -427,10 +437,14 @@ func (evb *EnhancedValidatorBridge) processDualPath(ctx stdctx.Context, routedCo
 				// the scan is flagged incomplete). Other errors discard the slice,
 				// preserving historical behavior.
 				if firstBudgetError([]error{err}) != nil {
+					assignPages(matches, pageOf)
+					assignSources(matches, sourceOf)
 					result.DocumentMatches = matches
 				}
 				return
 			}
+			assignPages(matches, pageOf)
+			assignSources(matches, sourceOf)
 			result.DocumentMatches = matches
 		}()
 	}
@@ -529,6 +543,38 @@ func (evb *EnhancedValidatorBridge) processDualPath(ctx stdctx.Context, routedCo
 	return result, nil
 }
 
+// assignPages stamps each match with the page its line was extracted from. A
+// match that already carries a page keeps it, a finding about the whole file
+// has none, and a nil pageOf (unpaged content) leaves every match untouched.
+func assignPages(matches []detector.Match, pageOf func(line int) int) {
+	if pageOf == nil {
+		return
+	}
+	for i := range matches {
+		if matches[i].Page == 0 && matches[i].LineNumber > 0 && !matches[i].IsFileLevel() {
+			matches[i].Page = pageOf(matches[i].LineNumber)
+		}
+	}
+}
+
+// assignSources reports each match against the section its line was extracted
+// from, for sections that declare their own source (see
+// ContentSection.AttributeBody). A finding about the whole file stays against
+// the file, and a nil sourceOf leaves every match untouched.
+func assignSources(matches []detector.Match, sourceOf func(line int) string) {
+	if sourceOf == nil {
+		return
+	}
+	for i := range matches {
+		if matches[i].LineNumber <= 0 || matches[i].IsFileLevel() {
+			continue
+		}
+		if source := sourceOf(matches[i].LineNumber); source != "" {
+			matches[i].Filename = source
+		}
+	}
+}
+
 // ConfidenceCeilingKey is the Match.Metadata key a validator sets to declare a hard
 // upper bound on a finding's confidence. It is read here, in the bridge, because this
 // is where confidence is RAISED after a validator has finished: a document-context
@@ -570,6 +616,31 @@ func clampToCeiling(match *detector.Match) {
 	}
 }
 
+// ConfidenceFloorKey is the Match.Metadata key a validator sets to declare a hard
+// lower bound on a finding's confidence: the mirror of ConfidenceCeilingKey, for a
+// finding whose certainty comes from the value itself. An exact data match is one: a
+// value found in the index of known records is that record's value whether or not the
+// document around it looks like test data, and a -30 test-data penalty would
+// otherwise move it out of HIGH. A document fingerprint's similarity is another: the
+// overlap with a registered document is measured, not inferred from the page around
+// it. Same value rules as the ceiling.
+const ConfidenceFloorKey = "confidence_floor"
+
+// clampToFloor applies a match's declared confidence floor, if it has one. Call it
+// after every adjustment that can lower Confidence.
+func clampToFloor(match *detector.Match) {
+	if match.Metadata == nil {
+		return
+	}
+	floor, ok := match.Metadata[ConfidenceFloorKey].(float64)
+	if !ok || floor <= 0 {
+		return
+	}
+	if match.Confidence < floor {
+		match.Confidence = floor
+	}
+}
+
 // routeContentWithRetry attempts content routing with retry logic
 func (evb *EnhancedValidatorBridge) routeContentWithRetry(content *preprocessors.ProcessedContent) (*router.RoutedContent, error) {
 	var lastErr error
@@ -674,6 +745,8 @@ func (evb *EnhancedValidatorBridge) processContentLegacy(ctx stdctx.Context, con
 			})
 		}
 	} else {
+		assignPages(documentMatches, content.PageLookup())
+		assignSources(documentMatches, content.SourceLookup())
 		allMatches = append(allMatches, documentMatches...)
 	}
 
@@ -902,6 +975,7 @@ func (dvb *DocumentValidatorBridge) ProcessDocumentContentCtx(ctx stdctx.Context
 					originalConfidence := matches[i].Confidence
 					matches[i].Confidence += adjustment
 					clampToCeiling(&matches[i])
+					clampToFloor(&matches[i])
 
 					// Ensure confidence stays within bounds
 					if matches[i].Confidence > 100 {
@@ -1171,6 +1245,7 @@ func (mvb *MetadataValidatorBridge) ProcessMetadataContentCtx(ctx stdctx.Context
 				originalConfidence := matches[i].Confidence
 				matches[i].Confidence += totalAdjustment
 				clampToCeiling(&matches[i])
+				clampToFloor(&matches[i])
 
 				// Ensure confidence stays within bounds
 				if matches[i].Confidence > 100 {
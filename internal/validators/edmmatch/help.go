@@ -0,0 +1,45 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package edmmatch
+
+import "github.com/awslabs/ferret-scan/v2/internal/help"
+
+// GetCheckInfo returns standardized information about the EDM_MATCH check.
+func (v *Validator) GetCheckInfo() help.CheckInfo {
+	return help.CheckInfo{
+		Name:             CheckType,
+		ShortDescription: "Finds the values of a table of known sensitive records (exact data match)",
+		DetailedDescription: `The EDM_MATCH check reports values that appear in a table of your own sensitive records: a customer export, an employee roster, a patient list. Where the pattern checks find every number shaped like an SSN, this check finds the SSNs of your customers and names the column each came from.
+
+The table is indexed once with "ferret-scan edm build table.csv --out table.edm". The index holds salted hashes of the normalised values, grouped by column and row; it never holds a value. Values are normalised before hashing, so "123-45-6789" matches "123 45 6789" and "DOE, Jane" matches "doe jane".
+
+A value distinctive enough to identify a record on its own (an SSN, an account number, an email address, a full name of three or more words) is reported wherever it appears. A weaker value (a first and last name, the last four digits of a long number) is reported only together with another field of the same record on the same line: "Jane Doe ... ending 6789" is a record match when Jane Doe's SSN ends in 6789, and nothing when it does not.
+
+Every finding is HIGH confidence and is not lowered by document context. The check is off until an index is given, with --edm or validators.edm.index in the configuration.
+
+The salted hashes of short or low-entropy values can be recovered by anyone holding the index and willing to hash every candidate: protect the index file as you would the table it was built from.`,
+
+		Patterns: []string{
+			"Any value of the indexed table, normalised for case, spacing and punctuation",
+			"Two fields of one record on one line (a partial-record match)",
+			"The last four digits of a long numeric field, with another field of the same record",
+		},
+
+		SupportedFormats: []string{
+			"Index built from a CSV table whose first row names its columns",
+		},
+
+		ConfidenceFactors: []help.ConfidenceFactor{
+			{Name: "Indexed Value", Description: "The value hashes to an entry of the index", Weight: 100},
+		},
+
+		ConfigurationInfo: "Set validators.edm.index to the path of an index built with 'ferret-scan edm build', or pass --edm on the command line.",
+
+		Examples: []string{
+			"ferret-scan edm build customers.csv --out customers.edm",
+			"ferret-scan --file export.log --edm customers.edm",
+			"ferret-scan --file . --recursive --edm customers.edm --checks EDM_MATCH",
+		},
+	}
+}
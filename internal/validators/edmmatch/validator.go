@@ -0,0 +1,152 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package edmmatch reports the values of a table of known sensitive records
+// (an exact data match index, see internal/edm) found in scanned content.
+package edmmatch
+
+import (
+	stdctx "context"
+	"strings"
+
+	"github.com/awslabs/ferret-scan/v2/internal/config"
+	"github.com/awslabs/ferret-scan/v2/internal/detector"
+	"github.com/awslabs/ferret-scan/v2/internal/edm"
+	"github.com/awslabs/ferret-scan/v2/internal/execguard"
+	"github.com/awslabs/ferret-scan/v2/internal/observability"
+)
+
+// CheckType is the finding type, and the name --checks selects the check by.
+const CheckType = "EDM_MATCH"
+
+// ConfigSection is the validators section of the configuration this check
+// reads; IndexKey names the index file in it.
+const (
+	ConfigSection = "edm"
+	IndexKey      = "index"
+)
+
+// ConfidenceFloorKey is the Match.Metadata key carrying a hard lower bound on a
+// finding's confidence, read by the dual-path bridge after its context
+// adjustments. The literal is duplicated rather than imported to keep the
+// bridge from depending on a validator package; a bridge test fails if the
+// two drift apart.
+const ConfidenceFloorKey = "confidence_floor"
+
+// matchConfidence is the confidence of every finding: a value found in the
+// index is a known record's value, whatever the line around it says. The
+// floor keeps it HIGH through a document-context penalty (a file named
+// test_fixtures.csv that holds real customers is still a leak).
+const (
+	matchConfidence = 100.0
+	confidenceFloor = 90.0
+)
+
+// Validator finds the values of an EDM index. With no index configured it
+// finds nothing.
+type Validator struct {
+	index *edm.Index
+	// loadErr is why the configured index could not be read. It is returned
+	// by every scan, so a missing index fails the check loudly rather than
+	// reporting a clean file.
+	loadErr error
+
+	observer observability.Observer
+}
+
+// NewValidator creates a validator with no index.
+func NewValidator() *Validator {
+	return &Validator{}
+}
+
+// SetObserver sets the observability component.
+func (v *Validator) SetObserver(observer observability.Observer) {
+	v.observer = observer
+}
+
+// Configure loads the index named by validators.edm.index. A config that names
+// no index clears the one loaded before; a profile that keeps the global index
+// is handed it explicitly (see core.BuildValidatorSet).
+func (v *Validator) Configure(cfg *config.Config) {
+	v.index, v.loadErr = nil, nil
+	if cfg == nil || cfg.Validators == nil {
+		return
+	}
+	path, _ := cfg.Validators[ConfigSection][IndexKey].(string)
+	if path == "" {
+		return
+	}
+	v.index, v.loadErr = edm.Open(path)
+}
+
+// ValidateContent validates preprocessed content against the index.
+func (v *Validator) ValidateContent(content string, originalPath string) ([]detector.Match, error) {
+	// Backward-compatible shim: run with a background context (never cancels).
+	return v.ValidateContentCtx(stdctx.Background(), content, originalPath)
+}
+
+// ValidateContentCtx implements execguard.ContextAwareValidator, polling ctx
+// once per line. A line is the window a record match is looked for in (see
+// edm.Index.Search).
+func (v *Validator) ValidateContentCtx(ctx stdctx.Context, content string, originalPath string) ([]detector.Match, error) {
+	if v.loadErr != nil {
+		return nil, v.loadErr
+	}
+	if v.index == nil {
+		return nil, nil
+	}
+	var finishTiming func(bool, map[string]interface{})
+	if v.observer != nil {
+		finishTiming = v.observer.StartTiming("edm_validator", "validate_content", originalPath)
+	}
+
+	var matches []detector.Match
+	for lineNum, line := range strings.Split(content, "\n") {
+		if execguard.LineLoopCancelled(ctx, lineNum) {
+			if finishTiming != nil {
+				finishTiming(false, map[string]interface{}{"cancelled": true, "match_count": len(matches)})
+			}
+			return matches, ctx.Err()
+		}
+		for _, h := range v.index.Search(line) {
+			meta := map[string]any{
+				"source":           "preprocessed_content",
+				"edm_columns":      h.Columns,
+				"match_kind":       "value",
+				ConfidenceFloorKey: confidenceFloor,
+			}
+			if h.Record {
+				meta["match_kind"] = "record"
+				meta["edm_record_fields"] = h.Fields
+				meta["edm_row"] = h.Row
+			}
+			matches = append(matches, detector.Match{
+				Text:       line[h.Start:h.End],
+				LineNumber: lineNum + 1,
+				Type:       CheckType,
+				Confidence: matchConfidence,
+				Filename:   originalPath,
+				Validator:  "edm",
+				Context:    detector.LineContext(line, h.Start, h.End),
+				Metadata:   meta,
+			})
+		}
+	}
+
+	if finishTiming != nil {
+		finishTiming(true, map[string]interface{}{"match_count": len(matches)})
+	}
+	return matches, nil
+}
+
+// CalculateConfidence is part of the detector.Validator interface. A value the
+// index holds is certain; the index is what decides it.
+func (v *Validator) CalculateConfidence(match string) (float64, map[string]bool) {
+	return matchConfidence, map[string]bool{"indexed": true}
+}
+
+// AnalyzeContext is part of the detector.Validator interface. Context does not
+// move an exact match.
+func (v *Validator) AnalyzeContext(match string, context detector.ContextInfo) float64 {
+	return 0
+}
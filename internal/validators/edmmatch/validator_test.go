@@ -0,0 +1,106 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package edmmatch
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/awslabs/ferret-scan/v2/internal/config"
+	"github.com/awslabs/ferret-scan/v2/internal/edm"
+)
+
+func configured(t *testing.T, table string) *Validator {
+	t.Helper()
+	ix, _, err := edm.Build(strings.NewReader(table))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "table.edm")
+	if err := ix.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+	v := NewValidator()
+	v.Configure(&config.Config{Validators: map[string]map[string]interface{}{
+		ConfigSection: {IndexKey: path},
+	}})
+	return v
+}
+
+func TestValidateContent(t *testing.T) {
+	v := configured(t, "name,ssn\nJane Doe,123-45-6789\n")
+	matches, err := v.ValidateContent("intro\nrefund for Jane Doe, SSN ending 6789\nSSN 123 45 6789\n", "notes.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 3 {
+		t.Fatalf("got %d matches (%+v), want the record's two fields and the SSN", len(matches), matches)
+	}
+	name := matches[0]
+	if name.Text != "Jane Doe" || name.LineNumber != 2 || name.Type != CheckType || name.Confidence != 100 {
+		t.Errorf("first match = %+v", name)
+	}
+	if name.Metadata["match_kind"] != "record" || name.Metadata["edm_row"] != 1 ||
+		!reflect.DeepEqual(name.Metadata["edm_record_fields"], []string{"name", "ssn (last 4)"}) {
+		t.Errorf("record metadata = %v", name.Metadata)
+	}
+	if name.Metadata[ConfidenceFloorKey] != 90.0 {
+		t.Errorf("metadata %v carries no confidence floor", name.Metadata)
+	}
+	ssn := matches[2]
+	if ssn.Text != "123 45 6789" || ssn.LineNumber != 3 || ssn.Metadata["match_kind"] != "value" ||
+		!reflect.DeepEqual(ssn.Metadata["edm_columns"], []string{"ssn"}) {
+		t.Errorf("SSN match = %+v", ssn)
+	}
+}
+
+func TestNoIndexFindsNothing(t *testing.T) {
+	v := NewValidator()
+	v.Configure(&config.Config{})
+	if matches, err := v.ValidateContent("Jane Doe 123-45-6789", "a.txt"); err != nil || matches != nil {
+		t.Errorf("ValidateContent = %v, %v; want nothing without an index", matches, err)
+	}
+}
+
+// Reconfiguring without an index drops the one configured before, and with it
+// an error from an index that could not be read.
+func TestReconfigureWithoutAnIndexClearsIt(t *testing.T) {
+	v := configured(t, "name,ssn\nJane Doe,123-45-6789\n")
+	v.Configure(&config.Config{})
+	if matches, err := v.ValidateContent("Jane Doe 123-45-6789", "a.txt"); err != nil || matches != nil {
+		t.Errorf("ValidateContent = %v, %v; want nothing once the index is unset", matches, err)
+	}
+
+	v.Configure(&config.Config{Validators: map[string]map[string]interface{}{
+		ConfigSection: {IndexKey: filepath.Join(t.TempDir(), "missing.edm")},
+	}})
+	v.Configure(&config.Config{Validators: map[string]map[string]interface{}{}})
+	if _, err := v.ValidateContent("anything", "a.txt"); err != nil {
+		t.Errorf("ValidateContent kept the error of an index no longer configured: %v", err)
+	}
+}
+
+// A configured index that cannot be read must fail the scan, not report the
+// file clean.
+func TestUnreadableIndexIsAnError(t *testing.T) {
+	v := NewValidator()
+	v.Configure(&config.Config{Validators: map[string]map[string]interface{}{
+		ConfigSection: {IndexKey: filepath.Join(t.TempDir(), "missing.edm")},
+	}})
+	if _, err := v.ValidateContent("anything", "a.txt"); err == nil {
+		t.Error("ValidateContent with a missing index returned no error")
+	}
+}
+
+func TestValidateContentCtxCancelled(t *testing.T) {
+	v := configured(t, "ssn\n123-45-6789\n")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := v.ValidateContentCtx(ctx, strings.Repeat("SSN 123-45-6789\n", 100000), "a.txt"); err != context.Canceled {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
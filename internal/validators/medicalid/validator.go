@@ -218,6 +218,9 @@ func (v *Validator) scanLine(ctx stdctx.Context, line string, lineNum int, origi
 
 	// Column bounds for this row, resolved once. Only for a DATA row: the header
 	// row itself is not a value-bearing line.
+	// From here on, table is the one governing this line: in a spreadsheet,
+	// its sheet's.
+	table = table.At(lineNum)
 	var lineBounds *tabular.LineBounds
 	if table.IsTable() && lineNum != table.HeaderLine() {
 		lineBounds = table.Bounds(line)
@@ -218,6 +218,17 @@ func (v *Validator) initializeDefaultValidationRules() {
 			"camera_make", "camera_model", "camera_serial", "device_id",
 			"artist", "creator", "copyright", "software", "usercomment",
 			"exif_artist", "exif_creator", "exif_copyright",
+			// XMP, IPTC, PNG text and comment fields (see meta-extract-exiflib)
+			"xmp_rights", "xmp_usageterms", "xmp_owner", "xmp_history_softwareagent",
+			"xmp_city", "xmp_state", "xmp_location", "xmp_sublocation",
+			"xmp_description", "xmp_title", "xmp_headline", "xmp_captionwriter",
+			"xmp_authorsposition", "xmp_credit", "xmp_instructions",
+			"iptc_byline", "iptc_caption", "iptc_contact", "iptc_city",
+			"iptc_sublocation", "iptc_provincestate", "iptc_credit",
+			"iptc_headline", "iptc_objectname", "iptc_specialinstructions",
+			"png_author", "png_comment", "png_description", "png_title",
+			"png_source", "png_disclaimer", "png_warning",
+			"jfif_comment", "gif_comment",
 		},
 		ConfidenceBoosts: map[string]float64{
 			"gps":     0.6, // High confidence for GPS data
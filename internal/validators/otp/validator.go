@@ -344,10 +344,11 @@ func (v *Validator) ValidateContentCtx(ctx stdctx.Context, content string, origi
 		}
 
 		lc := v.buildOTPLineContext(line)
-		if table.IsTable() && lineNum != table.HeaderLine() {
-			lc.table = table
-			lc.bounds = table.Bounds(line)
-			for _, h := range table.Headers() {
+		// The table governing this line: in a spreadsheet, its sheet's.
+		if rows := table.At(lineNum); rows.IsTable() && lineNum != rows.HeaderLine() {
+			lc.table = rows
+			lc.bounds = rows.Bounds(line)
+			for _, h := range rows.Headers() {
 				if v.hasPositiveContext(strings.ToLower(h)) {
 					lc.headerPos = true
 					break
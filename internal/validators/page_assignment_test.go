@@ -0,0 +1,76 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package validators
+
+import (
+	"testing"
+
+	"github.com/awslabs/ferret-scan/v2/internal/detector"
+	"github.com/awslabs/ferret-scan/v2/internal/preprocessors"
+)
+
+// lineReportingValidator reports one finding on each of the given lines.
+type lineReportingValidator struct{ lines []int }
+
+func (v lineReportingValidator) CalculateConfidence(string) (float64, map[string]bool) {
+	return 0, nil
+}
+func (v lineReportingValidator) AnalyzeContext(string, detector.ContextInfo) float64 { return 0 }
+func (v lineReportingValidator) ValidateContent(_ string, path string) ([]detector.Match, error) {
+	var out []detector.Match
+	for _, l := range v.lines {
+		out = append(out, detector.Match{Text: "x", Type: "TEST", Confidence: 90, LineNumber: l, Filename: path})
+	}
+	return out, nil
+}
+
+// Document-path findings are stamped with the page the preprocessor declared for
+// their line, through the real bridge stack; lines outside the paged section get
+// no page rather than the nearest one.
+func TestBridge_AssignsPagesFromDeclaredSections(t *testing.T) {
+	// Lines 1-2 are page 1, lines 3-4 page 2 (line 3 is the page-break marker,
+	// which belongs to the page before), line 5 the unpaged form block, and
+	// line 7 lies in the metadata section after the body.
+	body := "p1 a\np1 b\n--- PAGE BREAK ---\np2 a\nform\n"
+	text := body + "\nAuthor: someone"
+	pc := &preprocessors.ProcessedContent{
+		Text:          text,
+		OriginalPath:  "doc.pdf",
+		Filename:      "doc.pdf",
+		ProcessorType: "Text Extractor+pdf_metadata",
+		Success:       true,
+		Sections: []preprocessors.ContentSection{
+			{
+				Name: "Text Extractor", Kind: preprocessors.SectionKindBody, SourceFile: "doc.pdf",
+				Text:  body,
+				Pages: []preprocessors.PageSpan{{Page: 1, Line: 0}, {Page: 2, Line: 3}, {Page: 0, Line: 4}},
+			},
+			{
+				Name: "pdf_metadata", Kind: preprocessors.SectionKindMetadata, Type: "document_metadata",
+				SourceFile: "doc.pdf", Text: "Author: someone", LineOffset: 6,
+			},
+		},
+	}
+
+	d := buildWrapper(t, map[string]detector.Validator{"TEST": lineReportingValidator{lines: []int{1, 3, 4, 5, 7}}})
+	matches, err := d.ValidateProcessedContent(pc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[int]int{1: 1, 3: 1, 4: 2, 5: 0, 7: 0}
+	seen := 0
+	for _, m := range matches {
+		if m.Type != "TEST" {
+			continue
+		}
+		seen++
+		if m.Page != want[m.LineNumber] {
+			t.Errorf("line %d: page %d, want %d", m.LineNumber, m.Page, want[m.LineNumber])
+		}
+	}
+	if seen != len(want) {
+		t.Fatalf("%d findings, want %d", seen, len(want))
+	}
+}
@@ -655,9 +655,11 @@ func (v *Validator) ValidateContentCtx(ctx stdctx.Context, content string, origi
 		// it, so mapping a match to its column stays a binary search rather than a
 		// per-match rescan. Nil when the document is not tabular, or on the header
 		// row itself (a header cell is a label, not a value to report).
+		// rows is the table governing this line: in a spreadsheet, its sheet's.
+		rows := table.At(lineNum)
 		var lineBounds *tabular.LineBounds
-		if table.IsTable() && lineNum != table.HeaderLine() {
-			lineBounds = table.Bounds(line)
+		if rows.IsTable() && lineNum != rows.HeaderLine() {
+			lineBounds = rows.Bounds(line)
 		}
 
 		// Collected per line, with byte spans retained, so overlapping claims can
@@ -690,7 +692,7 @@ func (v *Validator) ValidateContentCtx(ctx stdctx.Context, content string, origi
 				// search over the per-line bounds computed above, so this is
 				// O(log fields) rather than a rescan. Empty for non-tabular
 				// documents, which leaves scoring exactly as it was.
-				lc.columnHeader = table.HeaderAt(lineBounds, matchIndex)
+				lc.columnHeader = rows.HeaderAt(lineBounds, matchIndex)
 
 				// A line-2 MRZ candidate is only a passport if its check digits
 				// verify. The pattern is a 44-character shape gate that any base32
@@ -0,0 +1,59 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package validators
+
+import (
+	"testing"
+
+	"github.com/awslabs/ferret-scan/v2/internal/detector"
+	"github.com/awslabs/ferret-scan/v2/internal/preprocessors"
+)
+
+// Document-path findings take the source of a section that sets AttributeBody,
+// and only of such a section: an embedded part labelled before the flag existed
+// keeps reporting against the container, so rules written against it still match.
+func TestBridge_AttributesFindingsToDeclaringSection(t *testing.T) {
+	body := "page text\n"
+	field := "applicant_ssn: 1\n"
+	media := "Artist: someone\n"
+	pc := &preprocessors.ProcessedContent{
+		Text:          body + "\n\n--- pdf_structure ---\n" + field + media,
+		OriginalPath:  "form.pdf",
+		Filename:      "form.pdf",
+		ProcessorType: "Text Extractor+pdf_structure",
+		Success:       true,
+		Sections: []preprocessors.ContentSection{
+			{Name: "Text Extractor", Kind: preprocessors.SectionKindBody, SourceFile: "form.pdf", Text: body},
+			{
+				Name: "pdf_structure", Kind: preprocessors.SectionKindBody, SourceFile: "form.pdf -> field:applicant_ssn",
+				Text: field, LineOffset: 4, AttributeBody: true,
+			},
+			{
+				Name: "audio_metadata", Kind: preprocessors.SectionKindMetadata, Type: "audio_metadata",
+				SourceFile: "form.pdf -> clip.wav", Text: media, LineOffset: 5,
+			},
+		},
+	}
+
+	d := buildWrapper(t, map[string]detector.Validator{"TEST": lineReportingValidator{lines: []int{1, 5, 6}}})
+	matches, err := d.ValidateProcessedContent(pc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[int]string{1: "form.pdf", 5: "form.pdf -> field:applicant_ssn", 6: "form.pdf"}
+	seen := 0
+	for _, m := range matches {
+		if m.Type != "TEST" {
+			continue
+		}
+		seen++
+		if m.Filename != want[m.LineNumber] {
+			t.Errorf("line %d: reported against %q, want %q", m.LineNumber, m.Filename, want[m.LineNumber])
+		}
+	}
+	if seen != len(want) {
+		t.Fatalf("%d findings, want %d", seen, len(want))
+	}
+}
@@ -404,9 +404,11 @@ func (v *Validator) ValidateContentCtx(ctx stdctx.Context, content string, origi
 		// per-match rescan — this validator has been audited for exactly that
 		// O(matches x lineLength) shape. Nil when the document is not tabular, and
 		// on the header row itself, where a cell is a label rather than a value.
+		// rows is the table governing this line: in a spreadsheet, its sheet's.
+		rows := table.At(lineNum)
 		var lineBounds *tabular.LineBounds
-		if len(foundMatches) > 0 && table.IsTable() && lineNum != table.HeaderLine() {
-			lineBounds = table.Bounds(line)
+		if len(foundMatches) > 0 && rows.IsTable() && lineNum != rows.HeaderLine() {
+			lineBounds = rows.Bounds(line)
 		}
 
 		for _, ms := range foundMatches {
@@ -421,7 +423,7 @@ func (v *Validator) ValidateContentCtx(ctx stdctx.Context, content string, origi
 			if lc != nil {
 				lc.columnHeader = ""
 				if lineBounds != nil && ms.start >= 0 {
-					lc.columnHeader = table.HeaderAt(lineBounds, ms.start)
+					lc.columnHeader = rows.HeaderAt(lineBounds, ms.start)
 				}
 			}
 			// Clean the SSN for validation
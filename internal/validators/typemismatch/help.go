@@ -0,0 +1,43 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package typemismatch
+
+import "github.com/awslabs/ferret-scan/v2/internal/help"
+
+// GetCheckInfo returns standardized information about the FILE_TYPE_MISMATCH check.
+func (v *Validator) GetCheckInfo() help.CheckInfo {
+	return help.CheckInfo{
+		Name:             CheckType,
+		ShortDescription: "Reports files whose content is in a format their extension does not name",
+		DetailedDescription: `The FILE_TYPE_MISMATCH check reports a file whose content contradicts its extension: a Word document saved as minutes.dat, a PDF renamed notes.txt, a spreadsheet exported as report.bin. Renaming is a common way for a document to slip past a filter that goes by extension, and the finding is informational: it names the format the content is in, not a value in it.
+
+The format is identified from the file's leading bytes (its magic number and, for ZIP and OLE containers, the parts inside), for every format the preprocessors read: PDF, Office and OpenDocument files, legacy Office and Outlook messages, RTF, images, audio, video, SQLite, Parquet and Avro. The scan reads a disguised file as what it is whether or not this check runs, so its text is searched by every other check.
+
+An extension that any name of the format uses is not a mismatch: a .docm or a .zip holding a Word document is not reported, nor an RTF letter named .doc. A file with no extension is read by content and not reported, as its name claims no format. Archive members and stdin are not reported, as they have no file to read.
+
+The finding is LOW confidence, fixed: context does not move it. Redaction leaves a disguised file alone and lists it as unredacted, as the redactors parse by extension.`,
+
+		Patterns: []string{
+			"A file whose leading bytes identify a format its extension is not a name of",
+		},
+
+		SupportedFormats: []string{
+			"PDF, Word, Excel, PowerPoint, OpenDocument, legacy Office, Outlook .msg and RTF",
+			"JPEG, PNG, GIF, WebP, TIFF, BMP, HEIF and AVIF images",
+			"MP3, WAV, FLAC, Ogg, AIFF, M4A, MP4, QuickTime, WebM, Matroska and AVI",
+			"SQLite databases, Parquet and Avro files",
+		},
+
+		ConfidenceFactors: []help.ConfidenceFactor{
+			{Name: "File signature", Description: "The content's format is identified from its bytes, so the finding is a fixed 50 (LOW)", Weight: 100},
+		},
+
+		ConfigurationInfo: "No configuration. Select the check with --checks FILE_TYPE_MISMATCH, or leave it out of --checks to turn it off; disguised files are read by content either way.",
+
+		Examples: []string{
+			"ferret-scan --file ./exports --recursive --checks FILE_TYPE_MISMATCH",
+			"ferret-scan --file minutes.dat",
+		},
+	}
+}
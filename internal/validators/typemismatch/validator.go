@@ -0,0 +1,113 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package typemismatch reports a file whose content is in a format its
+// extension does not name: a Word document saved as .dat, a PDF renamed .txt.
+package typemismatch
+
+import (
+	stdctx "context"
+	"path/filepath"
+	"strings"
+
+	"github.com/awslabs/ferret-scan/v2/internal/detector"
+	"github.com/awslabs/ferret-scan/v2/internal/filetype"
+	"github.com/awslabs/ferret-scan/v2/internal/observability"
+)
+
+// CheckType is the finding type, and the name --checks selects the check by.
+const CheckType = "FILE_TYPE_MISMATCH"
+
+// Confidence is the finding's fixed confidence, LOW: a renamed file is worth
+// knowing about, and is not by itself sensitive data.
+const Confidence = 50.0
+
+// ConfidenceCeilingKey and ConfidenceFloorKey are the Match.Metadata keys
+// carrying hard bounds on a finding's confidence, read by the dual-path bridge
+// after its context adjustments. A mismatch is read from the file's bytes, so
+// no keyword around the text should move it. The literals are duplicated
+// rather than imported to keep the bridge from depending on a validator
+// package; a bridge test fails if they drift apart.
+const (
+	ConfidenceCeilingKey = "confidence_ceiling"
+	ConfidenceFloorKey   = "confidence_floor"
+)
+
+// Validator compares the format a file's content is in with its extension.
+type Validator struct {
+	observer observability.Observer
+}
+
+// NewValidator creates a new file type mismatch validator.
+func NewValidator() *Validator {
+	return &Validator{}
+}
+
+// SetObserver sets the observability component.
+func (v *Validator) SetObserver(observer observability.Observer) {
+	v.observer = observer
+}
+
+// ValidateContent reports the file at originalPath if its content contradicts
+// its extension.
+func (v *Validator) ValidateContent(content string, originalPath string) ([]detector.Match, error) {
+	// Backward-compatible shim: run with a background context (never cancels).
+	return v.ValidateContentCtx(stdctx.Background(), content, originalPath)
+}
+
+// ValidateContentCtx implements execguard.ContextAwareValidator. The finding is
+// about the file and not its text, so content is not read: the file's leading
+// bytes are, by filetype.Detect. A path that cannot be read (an archive member,
+// stdin) yields nothing, as does a file with no extension, which names no
+// format to contradict.
+//
+// The one finding is file-level (detector.FileLevelKey): its Text is the format
+// the content is in, which appears nowhere in the file, so nothing is redacted
+// for it.
+func (v *Validator) ValidateContentCtx(ctx stdctx.Context, content string, originalPath string) ([]detector.Match, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var finishTiming func(bool, map[string]interface{})
+	if v.observer != nil {
+		finishTiming = v.observer.StartTiming("file_type_validator", "validate_content", originalPath)
+	}
+
+	var matches []detector.Match
+	if format, err := filetype.Detect(originalPath); err == nil && format != nil && format.Contradicts(originalPath) {
+		matches = append(matches, detector.Match{
+			Text:       format.Name,
+			LineNumber: 1,
+			Type:       CheckType,
+			Confidence: Confidence,
+			Filename:   originalPath,
+			Validator:  "file_type",
+			Metadata: map[string]any{
+				"source":              "file_signature",
+				"detected_format":     format.Name,
+				"file_extension":      strings.ToLower(filepath.Ext(originalPath)),
+				"expected_extensions": strings.Join(format.Extensions, ", "),
+				ConfidenceCeilingKey:  Confidence,
+				ConfidenceFloorKey:    Confidence,
+				detector.FileLevelKey: true,
+			},
+		})
+	}
+
+	if finishTiming != nil {
+		finishTiming(true, map[string]interface{}{"match_count": len(matches)})
+	}
+	return matches, nil
+}
+
+// CalculateConfidence is part of the detector.Validator interface. A mismatch
+// is read from a file, which one match string does not have.
+func (v *Validator) CalculateConfidence(match string) (float64, map[string]bool) {
+	return 0, map[string]bool{}
+}
+
+// AnalyzeContext is part of the detector.Validator interface. Context does not
+// move a mismatch read from the file's bytes.
+func (v *Validator) AnalyzeContext(match string, context detector.ContextInfo) float64 {
+	return 0
+}
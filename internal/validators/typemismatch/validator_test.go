@@ -0,0 +1,80 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package typemismatch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateContent(t *testing.T) {
+	pdf := []byte("%PDF-1.7\n1 0 obj\n")
+	jpeg := []byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x10, 'J', 'F', 'I', 'F', 0x00}
+	cases := []struct {
+		name   string
+		data   []byte
+		format string // "" for no finding
+	}{
+		{"notes.txt", pdf, "PDF document"},
+		{"scan.PNG", jpeg, "JPEG image"},
+		{"report.pdf", pdf, ""},
+		{"photo.jpeg", jpeg, ""},
+		{"export", pdf, ""},
+		{"notes.txt", []byte("plain text\n"), ""},
+	}
+	v := NewValidator()
+	for _, tc := range cases {
+		t.Run(tc.name+"/"+tc.format, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tc.name)
+			if err := os.WriteFile(path, tc.data, 0o600); err != nil {
+				t.Fatal(err)
+			}
+			matches, err := v.ValidateContent("extracted text", path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.format == "" {
+				if len(matches) != 0 {
+					t.Errorf("want no finding, got %+v", matches)
+				}
+				return
+			}
+			if len(matches) != 1 {
+				t.Fatalf("want one finding, got %+v", matches)
+			}
+			m := matches[0]
+			if m.Type != CheckType || m.Text != tc.format || m.Filename != path || m.Confidence != Confidence {
+				t.Errorf("finding = %s %q %s %v", m.Type, m.Text, m.Filename, m.Confidence)
+			}
+			if !m.IsFileLevel() {
+				t.Error("a mismatch must be file-level: its text is not in the file")
+			}
+			if want := strings.ToLower(filepath.Ext(tc.name)); m.Metadata["file_extension"] != want {
+				t.Errorf("file_extension = %v, want %q", m.Metadata["file_extension"], want)
+			}
+		})
+	}
+}
+
+// Content without a file behind it (stdin, an archive member) has nothing to
+// compare, and is not an error.
+func TestValidateContentWithoutAFile(t *testing.T) {
+	for _, path := range []string{"<stdin>", "bundle.zip -> notes.txt"} {
+		matches, err := NewValidator().ValidateContent("%PDF-1.7", path)
+		if err != nil || len(matches) != 0 {
+			t.Errorf("%s: got %v, %v; want nothing", path, matches, err)
+		}
+	}
+}
+
+func TestValidateContentCtxHonoursCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewValidator().ValidateContentCtx(ctx, "", "notes.txt"); err == nil {
+		t.Error("want the context's error")
+	}
+}
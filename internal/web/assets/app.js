@@ -715,8 +715,11 @@ function getSelectedChecks() {
     if (document.getElementById('cloudResources').checked) checks.push('CLOUD_RESOURCES');
     if (document.getElementById('creditCard').checked) checks.push('CREDIT_CARD');
     if (document.getElementById('dateOfBirth').checked) checks.push('DATE_OF_BIRTH');
+    if (document.getElementById('documentFingerprint').checked) checks.push('DOCUMENT_FINGERPRINT');
     if (document.getElementById('driversLicense').checked) checks.push('DRIVERS_LICENSE');
+    if (document.getElementById('edmMatch').checked) checks.push('EDM_MATCH');
     if (document.getElementById('email').checked) checks.push('EMAIL');
+    if (document.getElementById('fileTypeMismatch').checked) checks.push('FILE_TYPE_MISMATCH');
     if (document.getElementById('intellectualProperty').checked) checks.push('INTELLECTUAL_PROPERTY');
     if (document.getElementById('ipAddress').checked) checks.push('IP_ADDRESS');
     if (document.getElementById('medicalId').checked) checks.push('MEDICAL_ID');
@@ -735,7 +738,7 @@ function getSelectedChecks() {
 
 function toggleAllChecks() {
     const allChecked = document.getElementById('allChecks').checked;
-    const checkboxes = ['bankAccount', 'secrets', 'cloudResources', 'creditCard', 'dateOfBirth', 'driversLicense', 'email', 'intellectualProperty', 'ipAddress', 'medicalId', 'metadata', 'otp', 'passport', 'personName', 'phone', 'physicalAddress', 'socialMedia', 'ssn', 'vin'];
+    const checkboxes = ['bankAccount', 'secrets', 'cloudResources', 'creditCard', 'dateOfBirth', 'documentFingerprint', 'driversLicense', 'edmMatch', 'email', 'fileTypeMismatch', 'intellectualProperty', 'ipAddress', 'medicalId', 'metadata', 'otp', 'passport', 'personName', 'phone', 'physicalAddress', 'socialMedia', 'ssn', 'vin'];
 
     checkboxes.forEach(id => {
         const element = document.getElementById(id);
@@ -1291,10 +1294,16 @@ function displaySuppressions(rules) {
             // value here is stored XSS (HIGH-3). escapeAttr is safe in
             // both HTML text and quoted-attribute/onclick-string contexts
             // (it escapes < > & " '), which all three of these hit below.
-            const filename = escapeAttr(metadata.filename || 'Unknown');
-            const fileType = escapeAttr(metadata.finding_type || metadata.file_type || 'Unknown');
-            const lineNumber = metadata.line_number || 'Unknown';
-            const confidence = metadata.confidence ? Math.round(parseFloat(metadata.confidence)) : 'Unknown';
+            // A pattern rule describes many findings, so its row shows the
+            // matcher rather than one finding's coordinates. The matcher comes
+            // from the same rule file, so it is escaped the same way.
+            const pattern = (rule.Kind || rule.kind) === 'pattern' ? (rule.Match || rule.match || {}) : null;
+            const filename = escapeAttr(pattern ? (pattern.path || 'any path') : (metadata.filename || 'Unknown'));
+            const fileType = escapeAttr(pattern ? (pattern.type || pattern.validator || 'any type') : (metadata.finding_type || metadata.file_type || 'Unknown'));
+            const lineNumber = pattern ? 'pattern' : (metadata.line_number || 'Unknown');
+            const confidence = pattern
+                ? (pattern.max_confidence ? '≤ ' + escapeAttr(pattern.max_confidence) : 'any')
+                : (metadata.confidence ? Math.round(parseFloat(metadata.confidence)) + '%' : 'Unknown%');
 
             row.innerHTML = `
                 <td><input type="checkbox" class="suppression-checkbox" value="${ruleId}" data-change="updateBulkActions"></td>
@@ -1303,7 +1312,7 @@ function displaySuppressions(rules) {
                 <td>${filename}</td>
                 <td>${fileType}</td>
                 <td><div class="finding-text" style="max-width: 200px; overflow: hidden; text-overflow: ellipsis; white-space: nowrap;" title="${escapeAttr(metadata.finding_text || 'N/A')}">${escapeHtml((metadata.finding_text || 'N/A').substring(0, 50))}${(metadata.finding_text || 'N/A').length > 50 ? '...' : ''}</div></td>
-                <td>${confidence}%</td>
+                <td>${confidence}</td>
                 <td>${lineNumber}</td>
                 <td>
                     <label class="toggle-switch" style="margin-right: 8px;">
@@ -1516,7 +1525,9 @@ function showSuppressionDetails(id) {
     content.innerHTML = `
         <div style="display: grid; grid-template-columns: 150px 1fr; gap: 8px; margin-bottom: 16px;">
             <strong>ID:</strong> <span style="font-family: monospace;">${escapeHtml(rule.ID || rule.id)}</span>
-            <strong>Hash:</strong> <span style="font-family: monospace; word-break: break-all;">${escapeHtml(rule.Hash || rule.hash)}</span>
+            ${(rule.Kind || rule.kind) === 'pattern'
+                ? `<strong>Pattern:</strong> <span style="font-family: monospace; word-break: break-all;">${Object.entries(rule.Match || rule.match || {}).map(([key, value]) => `${escapeHtml(String(key))}=${escapeHtml(String(value))}`).join(', ')}</span>`
+                : `<strong>Hash:</strong> <span style="font-family: monospace; word-break: break-all;">${escapeHtml(rule.Hash || rule.hash)}</span>`}
             <strong>Status:</strong> <span style="color: ${(rule.Enabled !== undefined ? rule.Enabled : rule.enabled) ? '#037f0c' : '#d13212'}; font-weight: 600;">${(rule.Enabled !== undefined ? rule.Enabled : rule.enabled) ? 'ENABLED' : 'DISABLED'}</span>
             <strong>Reason:</strong> <span>${escapeHtml(rule.Reason || rule.reason)}</span>
             <strong>Created By:</strong> <span>${escapeHtml(rule.CreatedBy || rule.created_by || 'Unknown')}</span>
@@ -1547,6 +1558,10 @@ function showEditSuppressionModal(id) {
         showAlert('Suppression rule not found', 'error');
         return;
     }
+    if ((rule.Kind || rule.kind) === 'pattern') {
+        showPatternRuleModal(id);
+        return;
+    }
 
     document.getElementById('editSuppressionId').value = rule.ID || rule.id;
     document.getElementById('editSuppressionReason').value = rule.Reason || rule.reason;
@@ -1642,6 +1657,106 @@ document.getElementById('editSuppressionForm').addEventListener('submit', async
     }
 });
 
+// Pattern rules: one modal serves both create (no id) and edit (id set).
+function showPatternRuleModal(id) {
+    const form = document.getElementById('patternRuleForm');
+    form.reset();
+    document.getElementById('patternRuleId').value = '';
+    document.getElementById('patternRuleTitle').textContent = 'Add Pattern Rule';
+
+    if (id) {
+        const rule = allSuppressionRules.find(r => (r.ID || r.id) === id);
+        if (!rule) {
+            showAlert('Suppression rule not found', 'error');
+            return;
+        }
+        const pattern = rule.Match || rule.match || {};
+        document.getElementById('patternRuleTitle').textContent = 'Edit Pattern Rule';
+        document.getElementById('patternRuleId').value = rule.ID || rule.id;
+        document.getElementById('patternRulePath').value = pattern.path || '';
+        document.getElementById('patternRuleValidator').value = pattern.validator || '';
+        document.getElementById('patternRuleType').value = pattern.type || '';
+        document.getElementById('patternRuleMaxConfidence').value = pattern.max_confidence || '';
+        document.getElementById('patternRuleValueRegex').value = pattern.value_regex || '';
+        document.getElementById('patternRuleValueTarget').value = pattern.value_target || 'hash';
+        document.getElementById('patternRuleReason').value = rule.Reason || rule.reason || '';
+        document.getElementById('patternRuleEnabled').value = (rule.Enabled !== undefined ? rule.Enabled : rule.enabled).toString();
+
+        const expiresAt = rule.ExpiresAt || rule.expires_at;
+        if (expiresAt) {
+            const date = new Date(expiresAt);
+            if (!isNaN(date.getTime())) {
+                const pad = (n) => String(n).padStart(2, '0');
+                document.getElementById('patternRuleExpiresAt').value =
+                    `${date.getFullYear()}-${pad(date.getMonth() + 1)}-${pad(date.getDate())}T${pad(date.getHours())}:${pad(date.getMinutes())}`;
+            }
+        }
+    }
+
+    document.getElementById('patternRuleModal').classList.remove('hidden');
+}
+
+function closePatternRuleModal() {
+    document.getElementById('patternRuleModal').classList.add('hidden');
+    document.getElementById('patternRuleForm').reset();
+}
+
+document.getElementById('patternRuleForm').addEventListener('submit', async function (e) {
+    e.preventDefault();
+
+    const id = document.getElementById('patternRuleId').value;
+    const valueRegex = document.getElementById('patternRuleValueRegex').value.trim();
+    const match = {
+        path: document.getElementById('patternRulePath').value.trim(),
+        validator: document.getElementById('patternRuleValidator').value.trim(),
+        type: document.getElementById('patternRuleType').value.trim(),
+        max_confidence: document.getElementById('patternRuleMaxConfidence').value.trim(),
+        value_regex: valueRegex,
+        value_target: valueRegex ? document.getElementById('patternRuleValueTarget').value : ''
+    };
+
+    let expiresAt = null;
+    const expiresAtValue = document.getElementById('patternRuleExpiresAt').value;
+    if (expiresAtValue) {
+        const date = new Date(expiresAtValue);
+        if (isNaN(date.getTime())) {
+            showAlert('Invalid expiration date format', 'error');
+            return;
+        }
+        expiresAt = date.toISOString();
+    }
+
+    const body = {
+        match: match,
+        reason: document.getElementById('patternRuleReason').value,
+        enabled: document.getElementById('patternRuleEnabled').value === 'true',
+        expires_at: expiresAt
+    };
+    if (id) {
+        const rule = allSuppressionRules.find(r => (r.ID || r.id) === id);
+        body.id = id;
+        body.created_by = rule ? (rule.CreatedBy || rule.created_by || '') : '';
+    }
+
+    try {
+        const response = await fetch(id ? '/suppressions/edit' : '/suppressions/create-pattern', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify(body)
+        });
+        const data = await response.json();
+        if (data.success) {
+            showAlert(id ? 'Pattern rule updated successfully' : 'Pattern rule created successfully', 'success');
+            closePatternRuleModal();
+            loadSuppressions();
+        } else {
+            showAlert('Failed to save pattern rule: ' + (data.error || 'Unknown error'));
+        }
+    } catch (error) {
+        showAlert('Error saving pattern rule: ' + error.message);
+    }
+});
+
 async function downloadSuppressionFile() {
     try {
         const response = await fetch('/suppressions/download');
@@ -2711,10 +2826,12 @@ const clickActions = {
     showEditSuppressionModal: (el) => showEditSuppressionModal(el.dataset.arg),
     removeSuppression: (el) => removeSuppression(el.dataset.arg),
     showAddSuppressionModal: (el) => showAddSuppressionModal(el.dataset.hash, el.dataset.reason),
+    showPatternRuleModal: () => showPatternRuleModal(''),
 
     // Modal close / cancel / confirm buttons
     closeAddSuppressionModal: () => closeAddSuppressionModal(),
     closeEditSuppressionModal: () => closeEditSuppressionModal(),
+    closePatternRuleModal: () => closePatternRuleModal(),
     closeSuppressionDetailsModal: () => closeSuppressionDetailsModal(),
     closeRemoveSuppressionModal: () => closeRemoveSuppressionModal(),
     confirmRemoveSuppression: () => confirmRemoveSuppression(),
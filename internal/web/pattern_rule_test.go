@@ -0,0 +1,76 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/awslabs/ferret-scan/v2/internal/suppressions"
+)
+
+// TestPatternRuleCreateAndEdit drives the two endpoints the suppressions page
+// uses for pattern rules and checks the rule file reflects each step, including
+// that an invalid matcher is refused without touching the stored rule.
+func TestPatternRuleCreateAndEdit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "suppressions.yaml")
+	ws := NewWebServerWithOptions("0", "127.0.0.1", "", path, nil)
+
+	post := func(handler http.HandlerFunc, body map[string]any) map[string]any {
+		t.Helper()
+		payload, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(payload)))
+		var resp map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode response %q: %v", rec.Body.String(), err)
+		}
+		return resp
+	}
+
+	resp := post(ws.handleSuppressionsCreatePattern, map[string]any{
+		"reason": "docs examples",
+		"match":  map[string]any{"path": "docs/**", "type": "EMAIL"},
+	})
+	id, _ := resp["id"].(string)
+	if resp["success"] != true || id == "" {
+		t.Fatalf("create-pattern response = %v", resp)
+	}
+
+	resp = post(ws.handleSuppressionsEdit, map[string]any{
+		"id": id, "reason": "docs examples", "enabled": true,
+		"match": map[string]any{"value_regex": "x"},
+	})
+	if resp["success"] == true {
+		t.Fatal("edit accepted a value_regex without value_target")
+	}
+
+	resp = post(ws.handleSuppressionsEdit, map[string]any{
+		"id": id, "reason": "any low finding", "enabled": true,
+		"expires_at": "2099-01-01T00:00:00Z",
+		"match":      map[string]any{"path": "testdata/**", "max_confidence": "LOW"},
+	})
+	if resp["success"] != true {
+		t.Fatalf("edit response = %v", resp)
+	}
+
+	rules := suppressions.NewSuppressionManager(path).ListSuppressions()
+	if len(rules) != 1 {
+		t.Fatalf("got %d rules, want 1", len(rules))
+	}
+	rule := rules[0]
+	if !rule.IsPattern() || rule.Match == nil || rule.Match.Path != "testdata/**" || rule.Match.MaxConfidence != "LOW" {
+		t.Errorf("matcher not updated: %+v", rule.Match)
+	}
+	if rule.Reason != "any low finding" || rule.ExpiresAt == nil {
+		t.Errorf("reason/expiry not updated: reason=%q expires=%v", rule.Reason, rule.ExpiresAt)
+	}
+}
@@ -34,7 +34,9 @@ import (
 	// Import formatters to register them
 	_ "github.com/awslabs/ferret-scan/v2/internal/formatters/csv"
 	_ "github.com/awslabs/ferret-scan/v2/internal/formatters/gitlab-sast"
+	_ "github.com/awslabs/ferret-scan/v2/internal/formatters/html"
 	_ "github.com/awslabs/ferret-scan/v2/internal/formatters/json"
+	_ "github.com/awslabs/ferret-scan/v2/internal/formatters/jsonl"
 	_ "github.com/awslabs/ferret-scan/v2/internal/formatters/junit"
 	_ "github.com/awslabs/ferret-scan/v2/internal/formatters/sarif"
 	_ "github.com/awslabs/ferret-scan/v2/internal/formatters/text"
@@ -278,6 +280,7 @@ func (ws *WebServer) setupRoutes() {
 	// Suppression management endpoints (delegate to CLI suppression system)
 	ws.mux.HandleFunc("/suppressions", ws.handleSuppressions)
 	ws.mux.HandleFunc("/suppressions/create", ws.handleSuppressionsCreate)
+	ws.mux.HandleFunc("/suppressions/create-pattern", ws.handleSuppressionsCreatePattern)
 	ws.mux.HandleFunc("/suppressions/edit", ws.handleSuppressionsEdit)
 	ws.mux.HandleFunc("/suppressions/remove", ws.handleSuppressionsRemove)
 	ws.mux.HandleFunc("/suppressions/enable", ws.handleSuppressionsEnable)
@@ -1153,6 +1156,22 @@ type suppressionRequest struct {
 	// omitted key leaves the pointer nil too, which the handler treats the
 	// same way. An ISO-8601 string becomes a concrete time.
 	ExpiresAt *string `json:"expires_at,omitempty"`
+	// Match is the matcher block for create-pattern, and for edit when the
+	// rule being edited is a pattern rule. Nil on every other endpoint.
+	Match *suppressions.PatternMatch `json:"match,omitempty"`
+}
+
+// parseExpiresAt converts an optional RFC3339 expires_at into the *time.Time the
+// suppression manager takes; nil or empty means no expiry.
+func parseExpiresAt(value *string) (*time.Time, error) {
+	if value == nil || *value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, *value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expires_at: %v (expected RFC3339)", err)
+	}
+	return &t, nil
 }
 
 // suppressionEndpoint wraps a handler with shared boilerplate: method
@@ -1230,10 +1249,43 @@ func (ws *WebServer) handleSuppressionsCreate(w http.ResponseWriter, r *http.Req
 	})(w, r)
 }
 
+// handleSuppressionsCreatePattern creates a pattern rule (POST /suppressions/create-pattern).
+func (ws *WebServer) handleSuppressionsCreatePattern(w http.ResponseWriter, r *http.Request) {
+	ws.suppressionEndpoint("POST", true, func(req suppressionRequest, mgr *suppressions.SuppressionManager) (any, error) {
+		if req.Match == nil {
+			return nil, fmt.Errorf("Failed to create pattern rule: no match block supplied")
+		}
+		expiresAt, err := parseExpiresAt(req.ExpiresAt)
+		if err != nil {
+			return nil, err
+		}
+		createdBy := req.CreatedBy
+		if createdBy == "" {
+			createdBy = "web-ui"
+		}
+		id, err := mgr.AddPatternSuppression(*req.Match, req.Reason, createdBy, expiresAt)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to create pattern rule: %v", err)
+		}
+		return map[string]any{"success": true, "message": "Pattern rule created successfully", "id": id}, nil
+	})(w, r)
+}
+
 // handleSuppressionsEdit edits an existing suppression rule (POST /suppressions/edit).
+// A request carrying a match block also replaces a pattern rule's matcher; the
+// matcher is validated first, so an invalid pattern leaves the rule untouched.
 func (ws *WebServer) handleSuppressionsEdit(w http.ResponseWriter, r *http.Request) {
 	ws.suppressionEndpoint("POST", true, func(req suppressionRequest, mgr *suppressions.SuppressionManager) (any, error) {
-		if err := mgr.EditSuppression(req.ID, req.Reason, req.CreatedBy, req.Enabled, nil); err != nil {
+		expiresAt, err := parseExpiresAt(req.ExpiresAt)
+		if err != nil {
+			return nil, err
+		}
+		if req.Match != nil {
+			if err := mgr.EditPatternSuppression(req.ID, *req.Match); err != nil {
+				return nil, fmt.Errorf("Failed to edit suppression rule: %v", err)
+			}
+		}
+		if err := mgr.EditSuppression(req.ID, req.Reason, req.CreatedBy, req.Enabled, expiresAt); err != nil {
 			return nil, fmt.Errorf("Failed to edit suppression rule: %v", err)
 		}
 		return successMessage("Suppression rule updated successfully"), nil
@@ -1338,13 +1390,9 @@ func (ws *WebServer) handleSuppressionsBulkUpdateExpiration(w http.ResponseWrite
 			return nil, fmt.Errorf("no suppression IDs supplied")
 		}
 
-		var newExpiry *time.Time
-		if req.ExpiresAt != nil && *req.ExpiresAt != "" {
-			t, err := time.Parse(time.RFC3339, *req.ExpiresAt)
-			if err != nil {
-				return nil, fmt.Errorf("invalid expires_at: %v (expected RFC3339)", err)
-			}
-			newExpiry = &t
+		newExpiry, err := parseExpiresAt(req.ExpiresAt)
+		if err != nil {
+			return nil, err
 		}
 
 		// Build a quick lookup so we preserve each rule's current fields.
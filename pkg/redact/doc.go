@@ -23,12 +23,16 @@
 //
 // One capability difference is worth knowing up front: this package builds its
 // validator set with no project config (validator-specific tuning is a v2
-// concern), so the two validators whose detection depends on config or on
+// concern), so the validators whose detection depends on config or on
 // filesystem access are unavailable here and are excluded from
-// ValidCheckNames — METADATA (needs a file to read metadata from) and
+// ValidCheckNames — METADATA (needs a file to read metadata from),
 // SOCIAL_MEDIA (ships no built-in patterns; platform patterns come only from
-// validators.social_media.platform_patterns in a config file). pkg/scan loads
-// project config and supports both.
+// validators.social_media.platform_patterns in a config file), EDM_MATCH
+// (finds the values of the index named by validators.edm.index) and
+// DOCUMENT_FINGERPRINT (finds copies of the documents of the store named by
+// validators.fingerprint.store) and FILE_TYPE_MISMATCH (compares a file's bytes
+// with its extension). pkg/scan loads project config and supports the first
+// four; its file scan supports FILE_TYPE_MISMATCH.
 //
 // # Design goals
 //
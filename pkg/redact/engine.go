@@ -147,24 +147,36 @@ func NewEngine(opts EngineOptions) (*Engine, error) {
 //	               Configure(cfg), and NewEngine deliberately passes a nil
 //	               config (see above: validator-specific tuning is a v2
 //	               concern), so the validator is unconditionally inert here.
+//	EDM_MATCH    — finds only the values of an index named in config
+//	               (validators.edm.index), for the same nil-config reason.
+//	DOCUMENT_FINGERPRINT — finds only copies of the documents of a store named
+//	               in config (validators.fingerprint.store), likewise.
+//	FILE_TYPE_MISMATCH — compares a scanned FILE's bytes with its extension,
+//	               and a string in memory has neither.
 //
-// Both are "cannot work on this path", so both fail closed. Before this map
+// All are "cannot work on this path", so both fail closed. Before this map
 // existed, METADATA errored while SOCIAL_MEDIA constructed a live engine and
 // silently returned zero findings plus the input verbatim — a redaction
 // library reporting success on cleartext, indistinguishable from clean input.
-// Callers that need SOCIAL_MEDIA should use pkg/scan, which loads project
-// config and therefore configures the validator.
+// Callers that need SOCIAL_MEDIA, EDM_MATCH or DOCUMENT_FINGERPRINT should use
+// pkg/scan, which loads project config and therefore configures the validator;
+// FILE_TYPE_MISMATCH needs pkg/scan's file scan.
 var checksUnsupportedInMemory = map[string]bool{
-	"METADATA":     true,
-	"SOCIAL_MEDIA": true,
+	"DOCUMENT_FINGERPRINT": true,
+	"EDM_MATCH":            true,
+	"FILE_TYPE_MISMATCH":   true,
+	"METADATA":             true,
+	"SOCIAL_MEDIA":         true,
 }
 
 // ValidCheckNames returns the sorted set of canonical validator IDs accepted
 // in EngineOptions.Checks (e.g. "CREDIT_CARD", "EMAIL", "SSN"). It does NOT
 // include the "all" sentinel or the empty default, both of which select every
 // validator, nor the names in checksUnsupportedInMemory ("METADATA", which
-// needs filesystem access, and "SOCIAL_MEDIA", which has no built-in patterns)
-// — selecting only those would error with "no validators enabled".
+// needs filesystem access, "SOCIAL_MEDIA", "EDM_MATCH" and
+// "DOCUMENT_FINGERPRINT", which find nothing without config, and
+// "FILE_TYPE_MISMATCH", which needs a file) — selecting only those would error
+// with "no validators enabled".
 //
 // Every name returned here can actually produce a finding on this path; that
 // is enforced by TestValidCheckNames_AllDetectAndRedact, which drives a
@@ -327,12 +327,16 @@ type EngineOptions struct {
 	// "IP_ADDRESS", "PERSON_NAME"). Call ValidCheckNames for the
 	// authoritative list.
 	//
-	// Two validators are NOT available on this in-memory path and are
-	// dropped from the set: "METADATA" (requires filesystem access) and
+	// Five validators are NOT available on this in-memory path and are
+	// dropped from the set: "METADATA" (requires filesystem access),
 	// "SOCIAL_MEDIA" (has no built-in patterns; its only pattern source is
 	// project config, which this API does not accept — use pkg/scan if you
-	// need it). Naming only those returns a "no validators enabled" error
-	// rather than an engine that silently detects nothing.
+	// need it), "EDM_MATCH" (needs an index named in project config, for
+	// the same reason) and "DOCUMENT_FINGERPRINT" (needs a fingerprint store
+	// named in project config, likewise) and "FILE_TYPE_MISMATCH" (reads
+	// the scanned file's bytes, and a string has none). Naming only those
+	// returns a "no validators enabled" error rather than an engine that
+	// silently detects nothing.
 	Checks []string
 
 	// Strategy is the default redaction strategy for Redact calls that
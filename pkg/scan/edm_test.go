@@ -0,0 +1,48 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package scan_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/awslabs/ferret-scan/v2/internal/edm"
+	"github.com/awslabs/ferret-scan/v2/pkg/scan"
+)
+
+func TestScanText_EDMIndex(t *testing.T) {
+	ix, _, err := edm.Build(strings.NewReader("name,account\nJane Doe,0012345678\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "customers.edm")
+	if err := ix.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// EMAIL alone is selected: naming an index runs its check all the same.
+	res, err := scan.ScanText(context.Background(), "refund to Jane Doe, account ending 5678", scan.TextOptions{
+		Checks:                 []string{"EMAIL"},
+		DisableConfigDiscovery: true,
+		EDMIndex:               path,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range res.Findings {
+		if f.Type == "EDM_MATCH" {
+			got = append(got, f.Text)
+		}
+	}
+	if strings.Join(got, "|") != "Jane Doe|5678" {
+		t.Errorf("EDM_MATCH findings = %q, want the name and the account's last 4", got)
+	}
+
+	if _, err := scan.ScanText(context.Background(), "x", scan.TextOptions{EDMIndex: filepath.Join(t.TempDir(), "none.edm")}); err == nil {
+		t.Error("ScanText with a missing index returned no error")
+	}
+}
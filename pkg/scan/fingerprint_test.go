@@ -0,0 +1,53 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package scan_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/awslabs/ferret-scan/v2/internal/fingerprint"
+	"github.com/awslabs/ferret-scan/v2/pkg/scan"
+)
+
+const boardMinutes = "The board approved the northern expansion on the condition that " +
+	"supplier margins recover before the third quarter. Legal will review the " +
+	"revised licensing terms with our two largest distributors while finance " +
+	"models a price increase of four percent across wholesale channels."
+
+func TestScanText_FingerprintStore(t *testing.T) {
+	s := fingerprint.NewStore()
+	if _, err := s.Add("minutes.docx", boardMinutes); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "docs.fp")
+	if err := s.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// EMAIL alone is selected: naming a store runs its check all the same.
+	res, err := scan.ScanText(context.Background(), "FYI —\n"+strings.ToUpper(boardMinutes), scan.TextOptions{
+		Checks:                 []string{"EMAIL"},
+		DisableConfigDiscovery: true,
+		FingerprintStore:       path,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []scan.Finding
+	for _, f := range res.Findings {
+		if f.Type == "DOCUMENT_FINGERPRINT" {
+			got = append(got, f)
+		}
+	}
+	if len(got) != 1 || got[0].LineNumber != 2 {
+		t.Fatalf("DOCUMENT_FINGERPRINT findings = %+v, want the pasted minutes on line 2", got)
+	}
+
+	if _, err := scan.ScanText(context.Background(), "x", scan.TextOptions{FingerprintStore: filepath.Join(t.TempDir(), "none.fp")}); err == nil {
+		t.Error("ScanText with a missing store returned no error")
+	}
+}
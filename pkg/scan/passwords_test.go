@@ -0,0 +1,111 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package scan_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+
+	"github.com/awslabs/ferret-scan/v2/pkg/scan"
+)
+
+// lockedPDF writes a one-page PDF holding text, encrypted with AES-256 under
+// userPW, and returns its path.
+func lockedPDF(t *testing.T, text, userPW string) string {
+	t.Helper()
+	content := "BT /F1 12 Tf 72 700 Td (" + text + ") Tj ET\n"
+	objs := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(content), content),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	}
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objs))
+	for i, o := range objs {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objs)+1, xref)
+
+	conf := model.NewDefaultConfiguration()
+	conf.UserPW = userPW
+	conf.OwnerPW = userPW + "-owner"
+	var out bytes.Buffer
+	if err := api.Encrypt(bytes.NewReader(b.Bytes()), &out, conf); err != nil {
+		t.Fatalf("encrypting fixture: %v", err)
+	}
+	dir := filepath.Join(t.TempDir(), "finance")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "statement.pdf")
+	if err := os.WriteFile(path, out.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// The password reaches the document through FileOptions, and the finding comes
+// from the decrypted text. Without a password the same document is reported as
+// incomplete, with the encrypted cause, instead of as a clean scan.
+func TestScanFileDecryptsWithPasswordRule(t *testing.T) {
+	path := lockedPDF(t, "Employee SSN 449-87-4100 on file.", "statement-pw")
+	checks := []string{"SSN"}
+
+	got, err := scan.ScanFile(context.Background(), path, scan.FileOptions{
+		Checks:    checks,
+		Passwords: []scan.PasswordRule{{Path: "finance/*.pdf", Password: "statement-pw"}},
+	})
+	if err != nil {
+		t.Fatalf("ScanFile: %v", err)
+	}
+	if len(got.Findings) == 0 {
+		t.Fatalf("no findings from the decrypted document (incomplete=%v: %s)",
+			got.Incomplete, got.IncompleteReason)
+	}
+	if got.Incomplete {
+		t.Errorf("decrypted scan marked incomplete: %s", got.IncompleteReason)
+	}
+
+	locked, err := scan.ScanFile(context.Background(), path, scan.FileOptions{Checks: checks})
+	if err != nil {
+		t.Fatalf("ScanFile without a password: %v", err)
+	}
+	if len(locked.Findings) != 0 {
+		t.Errorf("got %d findings from a document no password opened", len(locked.Findings))
+	}
+	if !locked.Incomplete || !strings.Contains(locked.IncompleteReason, "encrypted") {
+		t.Errorf("Incomplete = %v (%q), want the document reported as encrypted",
+			locked.Incomplete, locked.IncompleteReason)
+	}
+	if strings.Contains(locked.IncompleteReason, "statement-pw") {
+		t.Errorf("IncompleteReason %q repeats a password", locked.IncompleteReason)
+	}
+}
+
+func TestScanFileRejectsUnusablePasswordRule(t *testing.T) {
+	path := lockedPDF(t, "x", "pw")
+	_, err := scan.ScanFile(context.Background(), path, scan.FileOptions{
+		Passwords: []scan.PasswordRule{{Path: "*.pdf"}},
+	})
+	if err == nil {
+		t.Fatal("want an error for a rule with no password")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"io"
 
 	"github.com/awslabs/ferret-scan/v2/internal/core"
+	"github.com/awslabs/ferret-scan/v2/internal/redactors"
 	"github.com/awslabs/ferret-scan/v2/internal/router"
 )
 
@@ -35,6 +36,15 @@ type RedactFileOptions struct {
 	// DisableConfigDiscovery uses built-in defaults only. Takes precedence over
 	// ConfigPath.
 	DisableConfigDiscovery bool
+
+	// ClearNotebookOutputs empties the outputs of every Jupyter notebook cell
+	// holding a HIGH confidence finding, besides redacting the finding.
+	ClearNotebookOutputs bool
+
+	// GPSPrecision keeps a reported GPS position in image and video metadata,
+	// coarsened instead of removed: a number of decimal places ("2") or a
+	// distance ("1km", "100m"). Empty removes the position.
+	GPSPrecision string
 }
 
 // RedactFileResult reports the outcome of a file-level redaction.
@@ -73,14 +83,20 @@ func RedactFile(path string, opts RedactFileOptions) (*RedactFileResult, error)
 	if err != nil {
 		return nil, err
 	}
+	gpsPrecision, err := redactors.ParseGPSPrecision(opts.GPSPrecision)
+	if err != nil {
+		return nil, err
+	}
 
 	result, err := core.RedactFile(core.RedactConfig{
-		FilePath:  path,
-		OutputDir: opts.OutputDir,
-		Strategy:  strategy,
-		Checks:    checks,
-		Config:    resolveConfig(opts.ConfigPath, opts.DisableConfigDiscovery),
-		LogWriter: logWriter,
+		FilePath:             path,
+		OutputDir:            opts.OutputDir,
+		Strategy:             strategy,
+		Checks:               checks,
+		Config:               resolveConfig(opts.ConfigPath, opts.DisableConfigDiscovery),
+		LogWriter:            logWriter,
+		ClearNotebookOutputs: opts.ClearNotebookOutputs,
+		GPSPrecision:         gpsPrecision,
 	})
 	if err != nil {
 		return nil, err
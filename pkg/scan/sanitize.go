@@ -0,0 +1,77 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package scan
+
+import (
+	"io"
+
+	"github.com/awslabs/ferret-scan/v2/internal/core"
+)
+
+// SanitizeMetadataOptions configures a metadata sanitization.
+type SanitizeMetadataOptions struct {
+	// OutputDir is the base directory where the sanitized copy is written,
+	// mirroring the source path beneath it as RedactFile does.
+	OutputDir string
+
+	// LogWriter receives payload-free progress output. Defaults to io.Discard.
+	LogWriter io.Writer
+}
+
+// RemovedMetadataField is one metadata field a sanitization removed.
+type RemovedMetadataField struct {
+	// Source is the extractor that read the field ("office_metadata",
+	// "image_metadata", "audio_metadata", ...), or "office_package" for
+	// revision IDs, the attached template and printer names.
+	Source string `json:"source"`
+	// Field is the field's name as a scan's metadata reports it ("Author",
+	// "Custom_ClientName", "GPS_Coordinates").
+	Field string `json:"field"`
+	// Part is the package part an "office_package" field was removed from.
+	Part string `json:"part,omitempty"`
+}
+
+// SanitizeMetadataResult reports the outcome of a metadata sanitization.
+type SanitizeMetadataResult struct {
+	// SanitizedFilePath is the path to the sanitized copy (same type as input).
+	SanitizedFilePath string
+	// Removed lists the fields whose values are gone from the copy. It holds
+	// names only, never values.
+	Removed []RemovedMetadataField
+}
+
+// SanitizeMetadata writes a copy of a file with its personal metadata
+// removed, whether or not a scan would report it: authors, editors, company,
+// template, custom properties, revision IDs and printer names of Office
+// documents, and the EXIF, XMP, GPS and tag fields of images, audio and video.
+// Delegates to internal/core.SanitizeFile.
+//
+// The copy is read back with the scan's metadata extractors, and an error is
+// returned, with no copy left, if a personal field survived. Formats whose
+// redactor cannot remove metadata (PDF, text, archives) are an error. The
+// original is never modified.
+func SanitizeMetadata(path string, opts SanitizeMetadataOptions) (*SanitizeMetadataResult, error) {
+	logWriter := opts.LogWriter
+	if logWriter == nil {
+		logWriter = io.Discard
+	}
+
+	result, err := core.SanitizeFile(core.SanitizeConfig{
+		FilePath:  path,
+		OutputDir: opts.OutputDir,
+		LogWriter: logWriter,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	removed := make([]RemovedMetadataField, 0, len(result.Removed))
+	for _, f := range result.Removed {
+		removed = append(removed, RemovedMetadataField{Source: f.Source, Field: f.Field, Part: f.Part})
+	}
+	return &SanitizeMetadataResult{
+		SanitizedFilePath: result.SanitizedFilePath,
+		Removed:           removed,
+	}, nil
+}
@@ -0,0 +1,90 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package scan_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/awslabs/ferret-scan/v2/pkg/scan"
+)
+
+func TestSanitizeMetadata(t *testing.T) {
+	dir := t.TempDir()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, p := range []struct{ name, content string }{
+		{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>` +
+			`</Types>`},
+		{"word/document.xml", `<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">` +
+			`<w:body><w:p w:rsidR="00A1B2C3"><w:r><w:t>Minutes</w:t></w:r></w:p></w:body></w:document>`},
+		{"docProps/core.xml", `<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties"` +
+			` xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:creator>Jane Quincy</dc:creator></cp:coreProperties>`},
+	} {
+		w, err := zw.Create(p.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(p.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	in := filepath.Join(dir, "minutes.docx")
+	if err := os.WriteFile(in, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := scan.SanitizeMetadata(in, scan.SanitizeMetadataOptions{OutputDir: filepath.Join(dir, "out")})
+	if err != nil {
+		t.Fatalf("SanitizeMetadata: %v", err)
+	}
+	want := map[string]bool{"Author": false, "RevisionIDs": false}
+	for _, f := range res.Removed {
+		if _, ok := want[f.Field]; ok {
+			want[f.Field] = true
+		}
+	}
+	if !want["Author"] || !want["RevisionIDs"] {
+		t.Errorf("Removed = %+v, want Author and RevisionIDs", res.Removed)
+	}
+
+	zr, err := zip.OpenReader(res.SanitizedFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		if strings.Contains(string(data), "Jane Quincy") || strings.Contains(string(data), "00A1B2C3") {
+			t.Errorf("%s still holds personal metadata: %s", f.Name, data)
+		}
+	}
+}
+
+func TestSanitizeMetadataUnsupported(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(in, []byte("Author: Jane Quincy\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := scan.SanitizeMetadata(in, scan.SanitizeMetadataOptions{OutputDir: filepath.Join(dir, "out")}); err == nil {
+		t.Error("SanitizeMetadata of a text file returned no error")
+	}
+}
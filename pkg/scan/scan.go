@@ -27,7 +27,10 @@ import (
 	"github.com/awslabs/ferret-scan/v2/internal/config"
 	"github.com/awslabs/ferret-scan/v2/internal/core"
 	"github.com/awslabs/ferret-scan/v2/internal/detector"
+	"github.com/awslabs/ferret-scan/v2/internal/edm"
+	"github.com/awslabs/ferret-scan/v2/internal/encryption"
 	"github.com/awslabs/ferret-scan/v2/internal/explain"
+	"github.com/awslabs/ferret-scan/v2/internal/fingerprint"
 )
 
 // TextOptions configures an in-memory text scan.
@@ -78,6 +81,13 @@ type TextOptions struct {
 	// This is what a hermetic test or a CI-shaped caller wants: identical behaviour
 	// regardless of the working directory or the machine.
 	DisableConfigDiscovery bool
+
+	// EDMIndex is the path of an exact data match index; see FileOptions.EDMIndex.
+	EDMIndex string
+
+	// FingerprintStore is the path of a document fingerprint store; see
+	// FileOptions.FingerprintStore.
+	FingerprintStore string
 }
 
 // FileOptions configures a file-path scan.
@@ -112,6 +122,38 @@ type FileOptions struct {
 	// DisableConfigDiscovery ignores all ambient config and uses built-in defaults only.
 	// Takes precedence over ConfigPath.
 	DisableConfigDiscovery bool
+
+	// Passwords are tried on an encrypted PDF or Office document whose path
+	// matches the rule's glob; the in-process mirror of --password-file. The
+	// document is decrypted in memory only. One that no password opens is
+	// reported through Result.Incomplete, never scanned as ciphertext.
+	Passwords []PasswordRule
+
+	// SampleRows, when positive, reads only the first that many rows of each
+	// Parquet or Avro file, for a fast classification of a file too large to
+	// read through; the in-process mirror of --sample-rows. The rows left unread
+	// are reported through Result.Incomplete.
+	SampleRows int64
+
+	// EDMIndex is the path of an exact data match index built with
+	// `ferret-scan edm build`; the in-process mirror of --edm. The indexed
+	// table's values are reported as EDM_MATCH findings, and the check is run
+	// even when Checks leaves it out.
+	EDMIndex string
+
+	// FingerprintStore is the path of a store of confidential documents
+	// registered with `ferret-scan fingerprint register`; the in-process mirror
+	// of --fingerprints. Content copied from them is reported as
+	// DOCUMENT_FINGERPRINT findings, and the check is run even when Checks
+	// leaves it out.
+	FingerprintStore string
+}
+
+// PasswordRule supplies one password for the documents whose path matches Path,
+// a glob in the suppression-rule dialect ("finance/**/*.xlsx", "*.pdf", "**").
+type PasswordRule struct {
+	Path     string
+	Password string
 }
 
 // Finding is one piece of sensitive data detected.
@@ -235,12 +277,20 @@ func ScanText(_ context.Context, text string, opts TextOptions) (*Result, error)
 	if err != nil {
 		return nil, err
 	}
+	cfg, checks, err := withEDMIndex(resolveConfig(opts.ConfigPath, opts.DisableConfigDiscovery), checks, opts.EDMIndex)
+	if err != nil {
+		return nil, err
+	}
+	cfg, checks, err = withFingerprintStore(cfg, checks, opts.FingerprintStore)
+	if err != nil {
+		return nil, err
+	}
 
 	coreResult, err := core.ScanContent(text, core.ContentScanConfig{
 		VirtualPath: label,
 		Checks:      checks,
 		Explain:     opts.Explain,
-		Config:      resolveConfig(opts.ConfigPath, opts.DisableConfigDiscovery),
+		Config:      cfg,
 		LogWriter:   logWriter,
 	})
 	if err != nil {
@@ -274,14 +324,36 @@ func ScanFile(_ context.Context, path string, opts FileOptions) (*Result, error)
 		return nil, err
 	}
 
+	var passwords *encryption.Passwords
+	if len(opts.Passwords) > 0 {
+		rules := make([]encryption.Rule, len(opts.Passwords))
+		for i, r := range opts.Passwords {
+			rules[i] = encryption.Rule{Path: r.Path, Password: r.Password}
+		}
+		if passwords, err = encryption.NewPasswords(rules); err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+	}
+
+	cfg, checks, err := withEDMIndex(resolveConfig(opts.ConfigPath, opts.DisableConfigDiscovery), checks, opts.EDMIndex)
+	if err != nil {
+		return nil, err
+	}
+	cfg, checks, err = withFingerprintStore(cfg, checks, opts.FingerprintStore)
+	if err != nil {
+		return nil, err
+	}
+
 	coreResult, err := core.ScanFile(core.ScanConfig{
 		FilePath:            path,
 		Checks:              checks,
 		EnablePreprocessors: true,
 		Explain:             opts.Explain,
-		Config:              resolveConfig(opts.ConfigPath, opts.DisableConfigDiscovery),
+		Config:              cfg,
 		LogWriter:           logWriter,
 		MaxLiveBytes:        opts.MaxLiveBytes,
+		Passwords:           passwords,
+		SampleRows:          opts.SampleRows,
 	})
 	if err != nil {
 		return nil, err
@@ -401,6 +473,33 @@ func normalizeChecks(checks []string) ([]string, error) {
 	return out, nil
 }
 
+// withEDMIndex adds an EDM index to a scan's config and checks. An index that
+// does not open is an error here, before the scan, rather than a failed check
+// on every file.
+func withEDMIndex(cfg *config.Config, checks []string, path string) (*config.Config, []string, error) {
+	if path == "" {
+		return cfg, checks, nil
+	}
+	if _, err := edm.Open(path); err != nil {
+		return nil, nil, fmt.Errorf("scan: EDM index: %w", err)
+	}
+	cfg, checks = core.WithEDMIndex(cfg, checks, path)
+	return cfg, checks, nil
+}
+
+// withFingerprintStore adds a fingerprint store to a scan's config and checks,
+// as withEDMIndex adds an index.
+func withFingerprintStore(cfg *config.Config, checks []string, path string) (*config.Config, []string, error) {
+	if path == "" {
+		return cfg, checks, nil
+	}
+	if _, err := fingerprint.Open(path); err != nil {
+		return nil, nil, fmt.Errorf("scan: %w", err)
+	}
+	cfg, checks = core.WithFingerprintStore(cfg, checks, path)
+	return cfg, checks, nil
+}
+
 // resolveConfig turns the two config knobs into a *config.Config.
 //
 // One place, so ScanText, ScanFile and RedactFile cannot drift about what
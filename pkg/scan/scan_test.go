@@ -240,7 +240,7 @@ func TestScanFile_MissingFile(t *testing.T) {
 
 func TestRedactFile_UnsupportedType(t *testing.T) {
 	dir := t.TempDir()
-	f := filepath.Join(dir, "movie.mkv")
+	f := filepath.Join(dir, "movie.wmv")
 	os.WriteFile(f, []byte("\x00\x00video"), 0o644)
 
 	_, err := RedactFile(f, RedactFileOptions{OutputDir: t.TempDir()})